@@ -8,6 +8,7 @@ import (
 	"time"
 
 	"github.com/rogpeppe/gogen-avro/v7/compiler"
+	"github.com/rogpeppe/gogen-avro/v7/generator"
 	"github.com/rogpeppe/gogen-avro/v7/schema"
 	"github.com/rogpeppe/gogen-avro/v7/vm"
 
@@ -19,6 +20,22 @@ var (
 	byteType = reflect.TypeOf(byte(0))
 )
 
+func init() {
+	// The compiler names the method that decodes a record
+	// with generator.ToPublicName, which by default drops the
+	// namespace, so records with the same name in different
+	// namespaces (see RegisterName) would share a method.
+	generator.SetNamer(qualifiedNamer{})
+}
+
+// qualifiedNamer implements generator.Namer by leaving
+// names, including their namespaces, unchanged.
+type qualifiedNamer struct{}
+
+func (qualifiedNamer) ToPublicName(name string) string {
+	return name
+}
+
 type decodeProgram struct {
 	vm.Program
 
@@ -29,19 +46,36 @@ type decodeProgram struct {
 	// directly into the target value (for example when
 	// the target is a struct type).
 	enter []enterFunc
+	// store holds an entry for each Enter instruction whose
+	// value is neither a reference into the target value nor
+	// assignable to it, that stores the value in the target
+	// after it has been decoded.
+	store []storeFunc
 	// makeDefault holds an entry for each SetDefault instruction
 	// in the program, indexed by pc, that gets the default
 	// value for a field.
 	makeDefault []func() reflect.Value
+	// convert holds an entry for each Set instruction in the
+	// program, indexed by pc, that targets a value with a
+	// registered logical type.
+	convert []Converter
+	// mapKey holds an entry for each AppendMap instruction in the
+	// program, indexed by pc, that targets a map with a key type
+	// other than string. It converts the Avro key to the Go key.
+	mapKey []func(string) (reflect.Value, error)
 
 	readerType *Type
 }
 
 type analyzer struct {
+	names       *Names
 	prog        *vm.Program
 	pcInfo      []pcInfo
 	enter       []enterFunc
+	store       []storeFunc
 	makeDefault []func() reflect.Value
+	convert     []Converter
+	mapKey      []func(string) (reflect.Value, error)
 }
 
 // enterFunc is used to "enter" a field or union value.
@@ -50,6 +84,11 @@ type analyzer struct {
 // reference to a part of the outer one.
 type enterFunc = func(reflect.Value) (reflect.Value, bool)
 
+// storeFunc is used to store an inner value that's been
+// decoded into its outer value. It's passed the outer value
+// and the inner value.
+type storeFunc = func(reflect.Value, reflect.Value) error
+
 type pcInfo struct {
 	// path holds the descent path into the type for an instruction
 	// in the program. It has an entry for each Enter
@@ -99,7 +138,7 @@ type pathElem struct {
 // Avro values encoded with the given writer schema.
 func compileDecoder(names *Names, t reflect.Type, writerType *Type) (*decodeProgram, error) {
 	// First determine the schema for the type.
-	readerType, err := avroTypeOf(names, t)
+	readerType, err := avroTypeOfWithWriter(names, t, writerType)
 	if err != nil {
 		return nil, fmt.Errorf("cannot determine schema for %s: %v", t, err)
 	}
@@ -108,10 +147,21 @@ func compileDecoder(names *Names, t reflect.Type, writerType *Type) (*decodeProg
 	}
 	prog, err := compiler.Compile(writerType.avroType, readerType.avroType)
 	if err != nil {
+		// The compiler only reports the first problem it finds, so
+		// check the types ourselves to find all of them.
+		if rerr := resolutionError(writerType, readerType); rerr != nil {
+			return nil, fmt.Errorf("cannot create decoder: %w", rerr)
+		}
 		return nil, fmt.Errorf("cannot create decoder: %v", err)
 	}
-	prog1, err := analyzeProgramTypes(prog, t, readerType.avroType)
+	prog1, err := analyzeProgramTypes(names, prog, t, readerType.avroType)
 	if err != nil {
+		// The compiler doesn't detect all mismatches (for example
+		// a string written where an int is read), so some are
+		// only found when analyzing the program.
+		if rerr := resolutionError(writerType, readerType); rerr != nil {
+			return nil, fmt.Errorf("cannot create decoder: %w", rerr)
+		}
 		return nil, fmt.Errorf("analysis failed: %v", err)
 	}
 	prog1.readerType = readerType
@@ -122,12 +172,16 @@ func compileDecoder(names *Names, t reflect.Type, writerType *Type) (*decodeProg
 // respect to the given type (the program must have been generated for that
 // type) and returns a program with a populated "enter" field allowing
 // the VM to correctly create union and field values for Enter instructions.
-func analyzeProgramTypes(prog *vm.Program, t reflect.Type, readerType schema.AvroType) (*decodeProgram, error) {
+func analyzeProgramTypes(names *Names, prog *vm.Program, t reflect.Type, readerType schema.AvroType) (*decodeProgram, error) {
 	a := &analyzer{
+		names:       names,
 		prog:        prog,
 		pcInfo:      make([]pcInfo, len(prog.Instructions)),
 		enter:       make([]enterFunc, len(prog.Instructions)),
+		store:       make([]storeFunc, len(prog.Instructions)),
 		makeDefault: make([]func() reflect.Value, len(prog.Instructions)),
+		convert:     make([]Converter, len(prog.Instructions)),
+		mapKey:      make([]func(string) (reflect.Value, error), len(prog.Instructions)),
 	}
 	if debugging {
 		debugf("analyze %d instructions; type %s\n%s {", len(prog.Instructions), t, prog)
@@ -147,7 +201,10 @@ func analyzeProgramTypes(prog *vm.Program, t reflect.Type, readerType schema.Avr
 	prog1 := &decodeProgram{
 		Program:     *prog,
 		enter:       a.enter,
+		store:       a.store,
 		makeDefault: a.makeDefault,
+		convert:     a.convert,
+		mapKey:      a.mapKey,
 	}
 	// Sanity check that all Enter and SetDefault
 	// instructions have associated info.
@@ -229,6 +286,26 @@ func (a *analyzer) eval(stack []int, calls []int, path []pathElem) (retErr error
 				}
 				break
 			}
+			if conv := logicalConverter(elem.avroType, elem.ftype); conv != nil {
+				a.convert[pc] = conv
+				break
+			}
+			if conv := unsignedConverter(elem.avroType, elem.ftype); conv != nil {
+				a.convert[pc] = conv
+				break
+			}
+			if conv := newTextConverter(elem.avroType, elem.ftype); conv != nil {
+				a.convert[pc] = conv
+				break
+			}
+			if conv := newBinaryConverter(elem.avroType, elem.ftype); conv != nil {
+				a.convert[pc] = conv
+				break
+			}
+			if conv := newEnumConverter(elem.avroType, elem.ftype); conv != nil {
+				a.convert[pc] = conv
+				break
+			}
 			// TODO: sanity-check that if it's Set(Bytes), the previous
 			// instruction was Read(Bytes) (i.e. frame.Bytes hasn't been invalidated).
 			if !canAssignVMType(inst.Operand, elem.ftype) {
@@ -236,16 +313,26 @@ func (a *analyzer) eval(stack []int, calls []int, path []pathElem) (retErr error
 			}
 		case vm.Enter:
 			index := inst.Operand
+			if u, ok := elem.avroType.(*schema.UnionField); ok && pc+1 < len(a.prog.Instructions) {
+				if next := a.prog.Instructions[pc+1]; next.Op == vm.Read {
+					index = exactUnionMember(u, index, next.Operand)
+					a.prog.Instructions[pc].Operand = index
+				}
+			}
 			if debugging {
 				debugf("enter %d -> %v, %d entries", index, elem.info.Type, len(elem.info.Entries))
 			}
-			enterf, newElem, err := enter(elem, index)
+			enterf, storef, newElem, err := enter(elem, index)
 			if err != nil {
 				return fmt.Errorf("cannot enter: %v", err)
 			}
 			path = append(path, newElem)
 			a.enter[pc] = enterf
+			a.store[pc] = storef
 		case vm.AppendArray:
+			if elem.ftype.Kind() == reflect.Array {
+				return fmt.Errorf("cannot decode array into %s (arrays are only supported in struct fields, pointers and unions)", elem.ftype)
+			}
 			if elem.ftype.Kind() != reflect.Slice {
 				return fmt.Errorf("cannot append to %T", elem.ftype)
 			}
@@ -261,9 +348,11 @@ func (a *analyzer) eval(stack []int, calls []int, path []pathElem) (retErr error
 			if elem.ftype.Kind() != reflect.Map {
 				return fmt.Errorf("cannot append to %T", elem.ftype)
 			}
-			if elem.ftype.Key().Kind() != reflect.String {
-				return fmt.Errorf("invalid key type for map %s", elem.ftype)
+			mapKey, err := mapKeyDecoder(elem.ftype.Key())
+			if err != nil {
+				return err
 			}
+			a.mapKey[pc] = mapKey
 			newElem, err := enterContainer(elem)
 			if err != nil {
 				return fmt.Errorf("cannot enter map: %v", err)
@@ -284,6 +373,13 @@ func (a *analyzer) eval(stack []int, calls []int, path []pathElem) (retErr error
 				return fmt.Errorf("no default info found at index %d at %v", index, pathStr(path))
 			}
 			a.makeDefault[pc] = info.MakeDefault
+			md, err := a.tagDefault(elem, index, info)
+			if err != nil {
+				return err
+			}
+			if md != nil {
+				a.makeDefault[pc] = md
+			}
 		case vm.Call:
 			found := false
 			for _, pc := range calls {
@@ -346,6 +442,32 @@ func (a *analyzer) eval(stack []int, calls []int, path []pathElem) (retErr error
 	return nil
 }
 
+// tagDefault returns a function that makes the default value for the
+// field with the given index in the record at elem, if the Go struct
+// field has an avrodefault tag, or nil otherwise.
+func (a *analyzer) tagDefault(elem pathElem, index int, info typeinfo.Info) (func() reflect.Value, error) {
+	if elem.ftype.Kind() != reflect.Struct || len(info.FieldIndex) == 0 {
+		return nil, nil
+	}
+	if _, ok := elem.ftype.FieldByIndex(info.FieldIndex).Tag.Lookup("avrodefault"); !ok {
+		return nil, nil
+	}
+	ref, ok := elem.avroType.(*schema.Reference)
+	if !ok {
+		return nil, nil
+	}
+	def, ok := ref.Def.(*schema.RecordDefinition)
+	if !ok || index >= len(def.Fields()) {
+		return nil, nil
+	}
+	f := def.Fields()[index]
+	if !f.HasDefault() || f.Default() == nil {
+		// A null default is the zero value, as usual.
+		return nil, nil
+	}
+	return tagDefault(a.names, f, info.Type)
+}
+
 // enter returns an enter function and the new path element
 // resulting from an Enter into the given path element at
 // the given index.
@@ -355,27 +477,34 @@ func (a *analyzer) eval(stack []int, calls []int, path []pathElem) (retErr error
 // and returns the new value to decode into and also reports
 // whether the new value is a reference into the original
 // value (if not, it will need to be copied into the original value).
-func enter(elem pathElem, index int) (enterFunc, pathElem, error) {
+//
+// The store function is nil unless the new value needs to be
+// stored other than by assigning it to the original value.
+func enter(elem pathElem, index int) (enterFunc, storeFunc, pathElem, error) {
 	var entryType schema.AvroType
 	var info typeinfo.Info
 	switch at := elem.avroType.(type) {
 	case *schema.UnionField:
 		itemTypes := at.ItemTypes()
 		if len(elem.info.Entries) != len(itemTypes) {
-			return nil, pathElem{}, fmt.Errorf("union type mismatch")
+			return nil, nil, pathElem{}, fmt.Errorf("union type mismatch")
 		}
-		if index >= len(elem.info.Entries) {
-			return nil, pathElem{}, fmt.Errorf("union index out of bounds")
+		entries, err := unionEntries(at, elem.info.Entries)
+		if err != nil {
+			return nil, nil, pathElem{}, err
+		}
+		if index >= len(entries) {
+			return nil, nil, pathElem{}, fmt.Errorf("union index out of bounds")
 		}
 
 		entryType = itemTypes[index]
-		info = elem.info.Entries[index]
+		info = entries[index]
 	case *schema.Reference:
 		switch def := at.Def.(type) {
 		case *schema.RecordDefinition:
 			fields := def.Fields()
 			if index >= len(fields) {
-				return nil, pathElem{}, fmt.Errorf("field index out of bounds (%d/%d)", index, len(fields))
+				return nil, nil, pathElem{}, fmt.Errorf("field index out of bounds (%d/%d)", index, len(fields))
 			}
 			field := fields[index]
 			// The reader type might not exactly match the
@@ -386,28 +515,43 @@ func enter(elem pathElem, index int) (enterFunc, pathElem, error) {
 			// for a field that matches the Avro field.
 			info1, ok := entryByName(elem.info.Entries, field.Name())
 			if !ok {
-				return nil, pathElem{}, fmt.Errorf("could not find entry for field %q in %v", field.Name(), elem.ftype)
+				return nil, nil, pathElem{}, fmt.Errorf("could not find entry for field %q in %v", field.Name(), elem.ftype)
 			}
 			info = info1
 			entryType = field.Type()
 		default:
-			return nil, pathElem{}, fmt.Errorf("unexpected Enter on Avro definition %T", def)
+			return nil, nil, pathElem{}, fmt.Errorf("unexpected Enter on Avro definition %T", def)
 		}
 	default:
-		return nil, pathElem{}, fmt.Errorf("unexpected Enter on Avro type %T", at)
+		return nil, nil, pathElem{}, fmt.Errorf("unexpected Enter on Avro type %T", at)
 	}
 	if info.Type == nil {
 		// Special case for the nil type. Return
 		// a zero value that will never be used.
 		return func(v reflect.Value) (reflect.Value, bool) {
 			return reflect.Value{}, true
-		}, pathElem{}, nil
+		}, nil, pathElem{}, nil
+	}
+	if info.Type.Kind() == reflect.Interface && !info.IsUnion {
+		// It's an interface placeholder with a type taken from
+		// the writer type (see TypeOfWithWriter).
+		if at, ok := entryType.(*schema.UnionField); ok {
+			// Treat it like a generated union type.
+			entries, err := placeholderUnionEntries(at)
+			if err != nil {
+				return nil, nil, pathElem{}, err
+			}
+			info.IsUnion = true
+			info.Entries = entries
+		} else {
+			return enterPlaceholder(elem, info, entryType)
+		}
 	}
 	if len(info.Entries) == 0 {
 		// The type itself might contribute information.
 		info1, err := typeinfo.ForType(info.Type)
 		if err != nil {
-			return nil, pathElem{}, fmt.Errorf("cannot get info for %s: %v", info.Type, err)
+			return nil, nil, pathElem{}, fmt.Errorf("cannot get info for %s: %v", info.Type, err)
 		}
 		info1.FieldIndex = info.FieldIndex
 		info = info1
@@ -420,10 +564,20 @@ func enter(elem pathElem, index int) (enterFunc, pathElem, error) {
 	var enter func(v reflect.Value) (reflect.Value, bool)
 	switch elem.ftype.Kind() {
 	case reflect.Struct:
+		if _, ok := typeinfo.NullableValueType(elem.ftype); ok && elem.info.IsUnion {
+			// It's a database/sql nullable type. The value is
+			// in the first field and the second field reports
+			// whether it's valid.
+			enter = func(v reflect.Value) (reflect.Value, bool) {
+				v.Field(1).SetBool(true)
+				return v.Field(0), true
+			}
+			break
+		}
 		fieldIndex := info.FieldIndex
 		enter = func(v reflect.Value) (reflect.Value, bool) {
-			debugf("entering field %d in type %v", fieldIndex, v.Type())
-			return v.Field(fieldIndex), true
+			debugf("entering field %v in type %v", fieldIndex, v.Type())
+			return v.FieldByIndex(fieldIndex), true
 		}
 	case reflect.Interface:
 		enter = func(v reflect.Value) (reflect.Value, bool) {
@@ -431,7 +585,7 @@ func enter(elem pathElem, index int) (enterFunc, pathElem, error) {
 		}
 	case reflect.Ptr:
 		if len(elem.info.Entries) != 2 {
-			return nil, pathElem{}, fmt.Errorf("pointer type without a two-member union")
+			return nil, nil, pathElem{}, fmt.Errorf("pointer type without a two-member union")
 		}
 		enter = func(v reflect.Value) (reflect.Value, bool) {
 			inner := reflect.New(info.Type)
@@ -439,9 +593,99 @@ func enter(elem pathElem, index int) (enterFunc, pathElem, error) {
 			return inner.Elem(), true
 		}
 	default:
-		return nil, pathElem{}, fmt.Errorf("unexpected type %v for Enter", elem.ftype)
+		return nil, nil, pathElem{}, fmt.Errorf("unexpected type %v for Enter", elem.ftype)
+	}
+	if t := newElem.ftype; t.Kind() == reflect.Array && t.Elem() != byteType {
+		return enterArray(elem, info, newElem)
 	}
-	return enter, newElem, nil
+	return enter, nil, newElem, nil
+}
+
+// enterArray is like enter for a Go array type with non-byte
+// elements described by newElem, which holds an Avro array. Go arrays
+// can't be appended to, so the elements are decoded into a new slice
+// that's copied into the array when it's complete, checking that
+// the lengths match.
+func enterArray(elem pathElem, info typeinfo.Info, newElem pathElem) (enterFunc, storeFunc, pathElem, error) {
+	t := newElem.ftype
+	var store storeFunc
+	switch elem.ftype.Kind() {
+	case reflect.Struct:
+		if _, ok := typeinfo.NullableValueType(elem.ftype); ok && elem.info.IsUnion {
+			return nil, nil, pathElem{}, fmt.Errorf("cannot decode array into nullable type %s", elem.ftype)
+		}
+		fieldIndex := info.FieldIndex
+		store = func(v, s reflect.Value) error {
+			return copyToArray(v.FieldByIndex(fieldIndex), s)
+		}
+	case reflect.Ptr:
+		store = func(v, s reflect.Value) error {
+			p := reflect.New(t)
+			if err := copyToArray(p.Elem(), s); err != nil {
+				return err
+			}
+			v.Set(p)
+			return nil
+		}
+	case reflect.Interface:
+		store = func(v, s reflect.Value) error {
+			a := reflect.New(t).Elem()
+			if err := copyToArray(a, s); err != nil {
+				return err
+			}
+			v.Set(a)
+			return nil
+		}
+	}
+	st := reflect.SliceOf(t.Elem())
+	enter := func(v reflect.Value) (reflect.Value, bool) {
+		s := reflect.New(st).Elem()
+		s.Set(reflect.MakeSlice(st, 0, t.Len()))
+		return s, false
+	}
+	newElem.ftype = st
+	return enter, store, newElem, nil
+}
+
+// copyToArray copies the elements of the slice s to the array a,
+// returning an error if their lengths differ.
+func copyToArray(a, s reflect.Value) error {
+	if s.Len() != a.Len() {
+		return fmt.Errorf("cannot decode array of length %d into %s", s.Len(), a.Type())
+	}
+	reflect.Copy(a, s)
+	return nil
+}
+
+// enterPlaceholder is like enter for a struct field described by info
+// that's an interface placeholder holding a value of the non-union Avro
+// type at. The value is decoded into a new value of the
+// corresponding Go type, which is then stored in the field.
+func enterPlaceholder(elem pathElem, info typeinfo.Info, at schema.AvroType) (enterFunc, storeFunc, pathElem, error) {
+	if elem.ftype.Kind() != reflect.Struct {
+		return nil, nil, pathElem{}, fmt.Errorf("cannot decode %s into %s inside %s", schemaFragment(at), info.Type, elem.ftype)
+	}
+	t, err := placeholderGoType(at)
+	if err != nil {
+		return nil, nil, pathElem{}, err
+	}
+	info1, err := typeinfo.ForType(t)
+	if err != nil {
+		return nil, nil, pathElem{}, fmt.Errorf("cannot get info for %s: %v", t, err)
+	}
+	fieldIndex := info.FieldIndex
+	enter := func(v reflect.Value) (reflect.Value, bool) {
+		return reflect.New(t).Elem(), false
+	}
+	store := func(v, inner reflect.Value) error {
+		v.FieldByIndex(fieldIndex).Set(inner)
+		return nil
+	}
+	return enter, store, pathElem{
+		ftype:    t,
+		info:     info1,
+		avroType: at,
+	}, nil
 }
 
 // enterContainer returns the path element resulting
@@ -464,6 +708,16 @@ func enterContainer(elem pathElem) (pathElem, error) {
 		}
 		elem1.info = info
 	}
+	if at, ok := elem1.avroType.(*schema.UnionField); ok && elem1.ftype.Kind() == reflect.Interface && !elem1.info.IsUnion {
+		// It's an interface placeholder holding a union; treat
+		// it like a generated union type.
+		entries, err := placeholderUnionEntries(at)
+		if err != nil {
+			return pathElem{}, err
+		}
+		elem1.info.IsUnion = true
+		elem1.info.Entries = entries
+	}
 	return elem1, nil
 }
 
@@ -476,6 +730,84 @@ func entryByName(entries []typeinfo.Info, fieldName string) (typeinfo.Info, bool
 	return typeinfo.Info{}, false
 }
 
+// unionEntries returns the entries for the members of the union at,
+// in the same order as the members. When all the entries have union
+// member names, they're matched to the members by name; otherwise
+// they're assumed to be in the same order already.
+func unionEntries(at *schema.UnionField, entries []typeinfo.Info) ([]typeinfo.Info, error) {
+	if len(entries) == 0 {
+		return entries, nil
+	}
+	for _, entry := range entries {
+		if entry.UnionName == "" {
+			return entries, nil
+		}
+	}
+	itemTypes := at.ItemTypes()
+	ordered := make([]typeinfo.Info, len(itemTypes))
+	for i, t := range itemTypes {
+		name := unionMemberName(t)
+		found := false
+		for _, entry := range entries {
+			if entry.UnionName == name {
+				ordered[i] = entry
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("no Go type found for union member %s", name)
+		}
+	}
+	return ordered, nil
+}
+
+// unionMemberName returns the name that identifies the member
+// at of a union: the full name of a named type, or the name of
+// its kind otherwise.
+func unionMemberName(at schema.AvroType) string {
+	if ref, ok := at.(*schema.Reference); ok {
+		return ref.TypeName.String()
+	}
+	return kindOf(at).String()
+}
+
+// exactUnionMember returns the index of the member of the union at
+// that a string or bytes value read with the given VM operand should
+// be stored in, given that the compiler chose the member at index.
+//
+// The compiler chooses the first member that the written value can
+// be promoted to. Strings and bytes can each be promoted to the other,
+// so when a union holds both, one of them would never be chosen, and
+// its values would be decoded into the Go type of the other.
+func exactUnionMember(at *schema.UnionField, index int, operand int) int {
+	var exact func(schema.AvroType) bool
+	switch operand {
+	case vm.Bytes:
+		exact = func(t schema.AvroType) bool {
+			_, ok := t.(*schema.BytesField)
+			return ok
+		}
+	case vm.String:
+		exact = func(t schema.AvroType) bool {
+			_, ok := t.(*schema.StringField)
+			return ok
+		}
+	default:
+		return index
+	}
+	itemTypes := at.ItemTypes()
+	if index >= len(itemTypes) || exact(itemTypes[index]) {
+		return index
+	}
+	for i, t := range itemTypes {
+		if exact(t) {
+			return i
+		}
+	}
+	return index
+}
+
 func canAssignVMType(operand int, dstType reflect.Type) bool {
 	// Note: the logic in this switch reflects the Set logic in the decoder.eval method.
 	dstKind := dstType.Kind()
@@ -494,7 +826,7 @@ func canAssignVMType(operand int, dstType reflect.Type) bool {
 		}
 		return dstKind == reflect.Slice && dstType.Elem() == byteType
 	case vm.String:
-		return dstKind == reflect.String
+		return dstKind == reflect.String || dstType == rawMessageType
 	default:
 		return false
 	}
@@ -504,7 +836,11 @@ func equalPathRef(p1, p2 []pathElem) bool {
 	if len(p1) == 0 || len(p2) == 0 {
 		return len(p1) == len(p2)
 	}
-	return p1[len(p1)-1].ftype == p2[len(p2)-1].ftype
+	t1, t2 := p1[len(p1)-1].ftype, p2[len(p2)-1].ftype
+	// Go types registered with the same Avro name share the
+	// code that decodes it, which is fine when their fields
+	// are identical.
+	return t1 == t2 || (t1.Kind() == reflect.Struct && t1.ConvertibleTo(t2))
 }
 
 func pathStr(ps []pathElem) string {
@@ -0,0 +1,116 @@
+package avro
+
+import (
+	"fmt"
+	"strings"
+)
+
+// DefaultArenaChunkSize holds the default size of the chunks
+// allocated by an Arena.
+const DefaultArenaChunkSize = 64 * 1024
+
+// Arena allocates the strings and byte slices produced when
+// unmarshaling from a small number of large chunks of memory rather
+// than allocating each one separately. This can significantly reduce
+// garbage collector overhead when decoding large numbers of values.
+//
+// Values allocated from an Arena remain valid after Reset; Reset
+// just drops the arena's references to its chunks so that the
+// memory can be reclaimed by the garbage collector when none of the
+// values allocated from it are in use any more. This means that
+// retaining a single small value will keep its whole chunk alive.
+//
+// The zero value of an Arena is ready to use. An Arena must not be
+// copied after first use and must not be used concurrently.
+type Arena struct {
+	// ChunkSize holds the size of the chunks allocated by the arena.
+	// Values larger than a quarter of this size are allocated
+	// separately. If it's zero, DefaultArenaChunkSize is used.
+	ChunkSize int
+
+	// Limit holds the maximum number of bytes that can be
+	// allocated from the arena before Reset is called. If an
+	// unmarshal operation would exceed it, it fails with a
+	// *DecodeError. If it's zero, there is no limit.
+	Limit int
+
+	used  int
+	str   strings.Builder
+	bytes []byte
+}
+
+// Used returns the number of bytes allocated from the arena
+// since it was created or last reset.
+func (a *Arena) Used() int {
+	return a.used
+}
+
+// Reset releases the arena's chunks and resets its usage to zero.
+func (a *Arena) Reset() {
+	a.used = 0
+	a.str = strings.Builder{}
+	a.bytes = nil
+}
+
+func (a *Arena) chunkSize() int {
+	if a.ChunkSize <= 0 {
+		return DefaultArenaChunkSize
+	}
+	return a.ChunkSize
+}
+
+// reserve accounts for the allocation of n bytes.
+func (a *Arena) reserve(n int) error {
+	if a.Limit > 0 && a.used+n > a.Limit {
+		return fmt.Errorf("arena memory limit of %d bytes exceeded", a.Limit)
+	}
+	a.used += n
+	return nil
+}
+
+// newString returns a string holding a copy of b.
+func (a *Arena) newString(b []byte) (string, error) {
+	if len(b) == 0 {
+		return "", nil
+	}
+	if err := a.reserve(len(b)); err != nil {
+		return "", err
+	}
+	chunkSize := a.chunkSize()
+	if len(b) > chunkSize/4 {
+		return string(b), nil
+	}
+	if a.str.Cap()-a.str.Len() < len(b) {
+		// Start a new chunk. Strings already returned
+		// continue to refer to the old one.
+		a.str = strings.Builder{}
+		a.str.Grow(chunkSize)
+	}
+	start := a.str.Len()
+	a.str.Write(b)
+	return a.str.String()[start:], nil
+}
+
+// newBytes returns a byte slice holding a copy of b.
+// The returned slice has no spare capacity, so appending
+// to it will not affect other values.
+func (a *Arena) newBytes(b []byte) ([]byte, error) {
+	if len(b) == 0 {
+		return []byte{}, nil
+	}
+	if err := a.reserve(len(b)); err != nil {
+		return nil, err
+	}
+	chunkSize := a.chunkSize()
+	if len(b) > chunkSize/4 {
+		data := make([]byte, len(b))
+		copy(data, b)
+		return data, nil
+	}
+	if cap(a.bytes)-len(a.bytes) < len(b) {
+		a.bytes = make([]byte, 0, chunkSize)
+	}
+	start := len(a.bytes)
+	a.bytes = append(a.bytes, b...)
+	return a.bytes[start:len(a.bytes):len(a.bytes)], nil
+}
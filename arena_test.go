@@ -0,0 +1,99 @@
+package avro_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro"
+)
+
+type arenaRecord struct {
+	A string
+	B []byte
+	C map[string]string
+	D []string
+}
+
+func TestUnmarshalArena(t *testing.T) {
+	c := qt.New(t)
+	x := arenaRecord{
+		A: "hello",
+		B: []byte("bytes"),
+		C: map[string]string{"k": "v"},
+		D: []string{"one", "two", "three"},
+	}
+	data, wType, err := avro.Marshal(x)
+	c.Assert(err, qt.Equals, nil)
+
+	var a avro.Arena
+	var x1 arenaRecord
+	_, err = avro.UnmarshalArena(data, &x1, wType, &a)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(x1, qt.DeepEquals, x)
+	c.Assert(a.Used(), qt.Equals, len("hello")+len("bytes")+len("kv")+len("onetwothree"))
+
+	// Appending to the returned bytes must not affect
+	// other values allocated from the arena.
+	x1.B = append(x1.B, "xxxxxxxxxx"...)
+	var x2 arenaRecord
+	_, err = avro.UnmarshalArena(data, &x2, wType, &a)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(x2, qt.DeepEquals, x)
+
+	// Values remain valid after the arena has been reset.
+	a.Reset()
+	c.Assert(a.Used(), qt.Equals, 0)
+	var x3 arenaRecord
+	_, err = avro.UnmarshalArena(data, &x3, wType, &a)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(x2, qt.DeepEquals, x)
+	c.Assert(x3, qt.DeepEquals, x)
+}
+
+func TestUnmarshalArenaLimit(t *testing.T) {
+	c := qt.New(t)
+	data, wType, err := avro.Marshal(arenaRecord{
+		A: "hello",
+		D: []string{"one", "two", "three"},
+	})
+	c.Assert(err, qt.Equals, nil)
+	a := &avro.Arena{
+		Limit: 12,
+	}
+	var x arenaRecord
+	_, err = avro.UnmarshalArena(data, &x, wType, a)
+	c.Assert(err, qt.ErrorMatches, `arena memory limit of 12 bytes exceeded`)
+	var derr *avro.DecodeError
+	c.Assert(errors.As(err, &derr), qt.Equals, true)
+
+	a.Reset()
+	a.Limit = 0
+	_, err = avro.UnmarshalArena(data, &x, wType, a)
+	c.Assert(err, qt.Equals, nil)
+}
+
+func TestSingleDecoderUnmarshalArena(t *testing.T) {
+	c := qt.New(t)
+	at, err := avro.TypeOf(arenaRecord{})
+	c.Assert(err, qt.Equals, nil)
+	r := memRegistry{
+		1: at,
+	}
+	ctx := context.Background()
+	x := arenaRecord{
+		A: "hello",
+		B: []byte{},
+		D: []string{"a"},
+	}
+	data, err := avro.NewSingleEncoder(r, nil).Marshal(ctx, x)
+	c.Assert(err, qt.Equals, nil)
+	var a avro.Arena
+	var x1 arenaRecord
+	_, err = avro.NewSingleDecoder(r, nil).UnmarshalArena(ctx, data, &x1, &a)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(x1, qt.DeepEquals, x)
+	c.Assert(a.Used(), qt.Equals, len("helloa"))
+}
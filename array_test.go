@@ -0,0 +1,65 @@
+package avro_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro"
+)
+
+func TestFixedLengthArray(t *testing.T) {
+	c := qt.New(t)
+	type R struct {
+		V [3]int32
+		P *[2]float64
+	}
+	at, err := avro.TypeOf(R{})
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(at.String(), qt.JSONEquals, json.RawMessage(`{
+		"type": "record",
+		"name": "R",
+		"fields": [{
+			"name": "V",
+			"default": [0, 0, 0],
+			"type": {"type": "array", "items": "int"}
+		}, {
+			"name": "P",
+			"default": null,
+			"type": ["null", {"type": "array", "items": "double"}]
+		}]
+	}`))
+	for _, x := range []R{{
+		V: [3]int32{1, 2, 3},
+		P: &[2]float64{0.5, 1.5},
+	}, {
+		V: [3]int32{4, 5, 6},
+	}} {
+		data, wType, err := avro.Marshal(x)
+		c.Assert(err, qt.Equals, nil)
+		var x1 R
+		_, err = avro.Unmarshal(data, &x1, wType)
+		c.Assert(err, qt.Equals, nil)
+		c.Assert(x1, qt.DeepEquals, x)
+	}
+}
+
+func TestFixedLengthArrayLengthMismatch(t *testing.T) {
+	c := qt.New(t)
+	type R struct {
+		V []int32
+	}
+	data, wType, err := avro.Marshal(R{
+		V: []int32{1, 2},
+	})
+	c.Assert(err, qt.Equals, nil)
+	{
+		type R struct {
+			V [3]int32
+		}
+		var x R
+		_, err = avro.Unmarshal(data, &x, wType)
+		c.Assert(err, qt.ErrorMatches, `cannot decode array of length 2 into \[3\]int32`)
+	}
+}
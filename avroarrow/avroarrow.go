@@ -0,0 +1,265 @@
+// Package avroarrow converts between Avro values and Apache Arrow
+// record batches, so that Avro data can be handed to columnar
+// tools without first decoding it into Go values.
+//
+// A Builder appends Avro-encoded values of a record type to the
+// columns of a record batch, and an Encoder encodes the rows of a
+// record batch as Avro values.
+//
+// A Reader reads record batches from an object container file (see
+// NewOCFReader) or from a stream of values in the framed binary
+// format used by avro.Transcoder (see NewFramedReader), and the
+// Encoder's WriteOCF and WriteFramed methods write record batches
+// in the same formats.
+//
+// The fields of an Avro record become the columns of the batch.
+// Avro types map to Arrow types as follows:
+//
+//   - null: null
+//   - boolean: bool
+//   - int: int32, date32 with a date logical type, or time32[ms]
+//     with a time-millis logical type
+//   - long: int64, timestamp[ms] or timestamp[us] with a timestamp logical
+//     type (in UTC, or with no time zone for a local timestamp), or
+//     time64[us] with a time-micros logical type
+//   - float: float32
+//   - double: float64
+//   - bytes: binary, or decimal with a decimal logical type
+//   - string: utf8
+//   - enum: utf8, holding the symbol
+//   - fixed: fixed_size_binary, or decimal with a decimal logical type
+//   - array: list
+//   - map: list of struct with a "key" utf8 field and a "value" field
+//   - record: struct
+//   - a union of null and one other type: a nullable value of that type
+//
+// Other unions and recursive types aren't supported.
+//
+// The Avro schema is stored in the metadata of the Arrow schema
+// under SchemaMetadataKey, so a batch made by a Builder can be
+// converted back to exactly the same Avro type.
+package avroarrow
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/apache/arrow/go/arrow"
+	"github.com/rogpeppe/gogen-avro/v7/schema"
+
+	"github.com/heetch/avro"
+	"github.com/heetch/avro/internal/typeinfo"
+)
+
+// SchemaMetadataKey holds the key of the Arrow schema metadata
+// entry that holds the Avro schema of a record batch.
+const SchemaMetadataKey = "avro.schema"
+
+// Schema returns the Arrow schema for values of the Avro record type t.
+func Schema(t *avro.Type) (*arrow.Schema, error) {
+	def, err := recordDefinition(t)
+	if err != nil {
+		return nil, err
+	}
+	m := &schemaMapper{
+		inProgress: make(map[schema.QualifiedName]bool),
+	}
+	fields, err := m.fields(def)
+	if err != nil {
+		return nil, err
+	}
+	md := arrow.NewMetadata([]string{SchemaMetadataKey}, []string{t.String()})
+	return arrow.NewSchema(fields, &md), nil
+}
+
+// recordDefinition returns the definition of the Avro record type t.
+func recordDefinition(t *avro.Type) (*schema.RecordDefinition, error) {
+	at, err := typeinfo.ParseSchema(t.String(), nil)
+	if err != nil {
+		return nil, err
+	}
+	if ref, ok := at.(*schema.Reference); ok {
+		if def, ok := ref.Def.(*schema.RecordDefinition); ok {
+			return def, nil
+		}
+	}
+	return nil, fmt.Errorf("cannot convert non-record type %s", t)
+}
+
+type schemaMapper struct {
+	// inProgress holds the names of the records that are
+	// currently being mapped, so we can detect recursive types.
+	inProgress map[schema.QualifiedName]bool
+}
+
+func (m *schemaMapper) fields(def *schema.RecordDefinition) ([]arrow.Field, error) {
+	name := def.AvroName()
+	if m.inProgress[name] {
+		return nil, fmt.Errorf("recursive type %s is not supported", name)
+	}
+	m.inProgress[name] = true
+	defer delete(m.inProgress, name)
+	fields := make([]arrow.Field, len(def.Fields()))
+	for i, f := range def.Fields() {
+		at := f.Type()
+		nullable := false
+		if elem, _, ok := nullableUnion(at); ok {
+			at, nullable = elem, true
+		}
+		dt, err := m.dataType(at)
+		if err != nil {
+			return nil, fmt.Errorf("cannot convert field %s.%s: %v", name.Name, f.Name(), err)
+		}
+		fields[i] = arrow.Field{
+			Name:     f.Name(),
+			Type:     dt,
+			Nullable: nullable || dt.ID() == arrow.NULL,
+		}
+	}
+	return fields, nil
+}
+
+func (m *schemaMapper) dataType(at schema.AvroType) (arrow.DataType, error) {
+	switch at := at.(type) {
+	case *schema.NullField:
+		return arrow.Null, nil
+	case *schema.BoolField:
+		return arrow.FixedWidthTypes.Boolean, nil
+	case *schema.IntField:
+		switch logicalType(at) {
+		case "date":
+			return arrow.FixedWidthTypes.Date32, nil
+		case "time-millis":
+			return arrow.FixedWidthTypes.Time32ms, nil
+		}
+		return arrow.PrimitiveTypes.Int32, nil
+	case *schema.LongField:
+		switch logicalType(at) {
+		case "timestamp-millis":
+			return arrow.FixedWidthTypes.Timestamp_ms, nil
+		case "timestamp-micros":
+			return arrow.FixedWidthTypes.Timestamp_us, nil
+		case "local-timestamp-millis":
+			return &arrow.TimestampType{Unit: arrow.Millisecond}, nil
+		case "local-timestamp-micros":
+			return &arrow.TimestampType{Unit: arrow.Microsecond}, nil
+		case "time-micros":
+			return arrow.FixedWidthTypes.Time64us, nil
+		}
+		return arrow.PrimitiveTypes.Int64, nil
+	case *schema.FloatField:
+		return arrow.PrimitiveTypes.Float32, nil
+	case *schema.DoubleField:
+		return arrow.PrimitiveTypes.Float64, nil
+	case *schema.BytesField:
+		if logicalType(at) == "decimal" {
+			return decimalType(at)
+		}
+		return arrow.BinaryTypes.Binary, nil
+	case *schema.StringField:
+		return arrow.BinaryTypes.String, nil
+	case *schema.ArrayField:
+		elem, err := m.elemType(at.ItemType())
+		if err != nil {
+			return nil, err
+		}
+		return arrow.ListOf(elem), nil
+	case *schema.MapField:
+		elem, err := m.elemType(at.ItemType())
+		if err != nil {
+			return nil, err
+		}
+		_, _, nullable := nullableUnion(at.ItemType())
+		return arrow.ListOf(arrow.StructOf(
+			arrow.Field{Name: "key", Type: arrow.BinaryTypes.String},
+			arrow.Field{Name: "value", Type: elem, Nullable: nullable},
+		)), nil
+	case *schema.UnionField:
+		return nil, fmt.Errorf("unsupported union type %s", schemaFragment(at))
+	case *schema.Reference:
+		switch def := at.Def.(type) {
+		case *schema.EnumDefinition:
+			return arrow.BinaryTypes.String, nil
+		case *schema.FixedDefinition:
+			if logicalType(at) == "decimal" {
+				return decimalType(at)
+			}
+			return &arrow.FixedSizeBinaryType{ByteWidth: def.SizeBytes()}, nil
+		case *schema.RecordDefinition:
+			fields, err := m.fields(def)
+			if err != nil {
+				return nil, err
+			}
+			return arrow.StructOf(fields...), nil
+		default:
+			return nil, fmt.Errorf("unknown definition type %T", def)
+		}
+	default:
+		return nil, fmt.Errorf("unknown Avro type %T", at)
+	}
+}
+
+// elemType returns the Arrow type of the items of an array or
+// the values of a map. Arrow list items are always nullable,
+// so a union of null and another type can be used here too.
+func (m *schemaMapper) elemType(at schema.AvroType) (arrow.DataType, error) {
+	if elem, _, ok := nullableUnion(at); ok {
+		at = elem
+	}
+	return m.dataType(at)
+}
+
+// decimalType returns the Arrow decimal type for the Avro decimal type at.
+func decimalType(at schema.AvroType) (arrow.DataType, error) {
+	precision, _ := at.Attribute("precision").(float64)
+	scale, _ := at.Attribute("scale").(float64)
+	if precision < 1 || precision > 38 {
+		return nil, fmt.Errorf("unsupported decimal precision %v", precision)
+	}
+	return &arrow.Decimal128Type{
+		Precision: int32(precision),
+		Scale:     int32(scale),
+	}, nil
+}
+
+// nullableUnion reports whether at is a union of null and one other
+// type. If so, it returns the other type and the index of the null
+// member.
+func nullableUnion(at schema.AvroType) (elem schema.AvroType, nullIndex int, ok bool) {
+	u, ok := at.(*schema.UnionField)
+	if !ok {
+		return nil, 0, false
+	}
+	items := u.ItemTypes()
+	if len(items) != 2 {
+		return nil, 0, false
+	}
+	for i, item := range items {
+		if _, ok := item.(*schema.NullField); ok {
+			elem := items[1-i]
+			if _, ok := elem.(*schema.NullField); ok {
+				return nil, 0, false
+			}
+			return elem, i, true
+		}
+	}
+	return nil, 0, false
+}
+
+func logicalType(at schema.AvroType) string {
+	s, _ := at.Attribute("logicalType").(string)
+	return s
+}
+
+// schemaFragment returns the JSON form of at for use in error messages.
+func schemaFragment(at schema.AvroType) string {
+	def, err := at.Definition(make(map[schema.QualifiedName]interface{}))
+	if err != nil {
+		return fmt.Sprintf("<%T>", at)
+	}
+	data, err := json.Marshal(def)
+	if err != nil {
+		return fmt.Sprintf("<%T>", at)
+	}
+	return string(data)
+}
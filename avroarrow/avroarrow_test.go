@@ -0,0 +1,507 @@
+package avroarrow_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"encoding/json"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/apache/arrow/go/arrow"
+	"github.com/apache/arrow/go/arrow/array"
+	"github.com/apache/arrow/go/arrow/decimal128"
+	"github.com/apache/arrow/go/arrow/memory"
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro"
+	"github.com/heetch/avro/avroarrow"
+	"github.com/heetch/avro/avroocf"
+	"github.com/heetch/avro/internal/testtypes"
+)
+
+type Point struct {
+	X, Y int
+}
+
+type R struct {
+	B   bool
+	I   int32
+	L   int64
+	F   float32
+	D   float64
+	S   string
+	By  []byte
+	Fx  [3]byte
+	T   time.Time
+	E   testtypes.Enum
+	N   avro.Null
+	P   *string
+	A   []int
+	M   map[string]int
+	Pt  Point
+	OPt *Point
+}
+
+func TestRoundTrip(t *testing.T) {
+	c := qt.New(t)
+	mem := memory.NewCheckedAllocator(memory.NewGoAllocator())
+	defer mem.AssertSize(t, 0)
+
+	s := "hello"
+	vals := []R{{
+		B:   true,
+		I:   -12,
+		L:   1234567890123,
+		F:   1.5,
+		D:   -2.25,
+		S:   "hello",
+		By:  []byte{1, 2, 3},
+		Fx:  [3]byte{'a', 'b', 'c'},
+		T:   time.Date(2020, 1, 15, 18, 47, 8, 888888000, time.UTC),
+		E:   testtypes.EnumThree,
+		P:   &s,
+		A:   []int{1, 2, 3},
+		M:   map[string]int{"a": 1, "b": 2},
+		Pt:  Point{1, 2},
+		OPt: &Point{3, 4},
+	}, {
+		By: []byte{},
+		T:  time.Unix(0, 0).UTC(),
+	}}
+	at, err := avro.TypeOf(R{})
+	c.Assert(err, qt.Equals, nil)
+	b, err := avroarrow.NewBuilder(mem, at)
+	c.Assert(err, qt.Equals, nil)
+	defer b.Release()
+	for _, v := range vals {
+		data, _, err := avro.Marshal(v)
+		c.Assert(err, qt.Equals, nil)
+		err = b.Append(data)
+		c.Assert(err, qt.Equals, nil)
+	}
+	c.Assert(b.Len(), qt.Equals, 2)
+	rec := b.NewRecord()
+	defer rec.Release()
+	c.Assert(b.Len(), qt.Equals, 0)
+	c.Assert(rec.NumRows(), qt.Equals, int64(2))
+	c.Assert(rec.NumCols(), qt.Equals, int64(16))
+
+	// Check a few of the columns.
+	c.Assert(rec.Column(5).(*array.String).Value(0), qt.Equals, "hello")
+	c.Assert(rec.Column(8).(*array.Timestamp).Value(0), qt.Equals, arrow.Timestamp(vals[0].T.UnixNano()/1000))
+	c.Assert(rec.Column(9).(*array.String).Value(0), qt.Equals, "Three")
+	c.Assert(rec.Column(9).(*array.String).Value(1), qt.Equals, "One")
+	c.Assert(rec.Column(11).IsNull(0), qt.Equals, false)
+	c.Assert(rec.Column(11).IsNull(1), qt.Equals, true)
+	c.Assert(rec.Column(12).(*array.List).ListValues().(*array.Int64).Int64Values(), qt.DeepEquals, []int64{1, 2, 3})
+	c.Assert(rec.Column(15).IsNull(1), qt.Equals, true)
+
+	// Converting back gives the original values.
+	enc, err := avroarrow.NewEncoder(rec.Schema())
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(enc.Type().String(), qt.Equals, at.String())
+	for i, v := range vals {
+		data, err := enc.AppendRow(nil, rec, i)
+		c.Assert(err, qt.Equals, nil)
+		var x R
+		_, err = avro.Unmarshal(data, &x, enc.Type())
+		c.Assert(err, qt.Equals, nil)
+		c.Assert(x, qt.DeepEquals, v)
+	}
+}
+
+func TestSchema(t *testing.T) {
+	c := qt.New(t)
+	at, err := avro.ParseType(`{
+		"type": "record",
+		"name": "R",
+		"fields": [
+			{"name": "date", "type": {"type": "int", "logicalType": "date"}},
+			{"name": "timeMillis", "type": {"type": "int", "logicalType": "time-millis"}},
+			{"name": "timeMicros", "type": {"type": "long", "logicalType": "time-micros"}},
+			{"name": "ts", "type": {"type": "long", "logicalType": "timestamp-millis"}},
+			{"name": "localTS", "type": {"type": "long", "logicalType": "local-timestamp-micros"}},
+			{"name": "dec", "type": {"type": "bytes", "logicalType": "decimal", "precision": 10, "scale": 2}},
+			{"name": "fixedDec", "type": {"type": "fixed", "name": "D", "size": 8, "logicalType": "decimal", "precision": 18}},
+			{"name": "opt", "type": ["string", "null"]},
+			{"name": "m", "type": {"type": "map", "values": ["null", "long"]}}
+		]
+	}`)
+	c.Assert(err, qt.Equals, nil)
+	s, err := avroarrow.Schema(at)
+	c.Assert(err, qt.Equals, nil)
+	want := arrow.NewSchema([]arrow.Field{
+		{Name: "date", Type: arrow.FixedWidthTypes.Date32},
+		{Name: "timeMillis", Type: arrow.FixedWidthTypes.Time32ms},
+		{Name: "timeMicros", Type: arrow.FixedWidthTypes.Time64us},
+		{Name: "ts", Type: arrow.FixedWidthTypes.Timestamp_ms},
+		{Name: "localTS", Type: &arrow.TimestampType{Unit: arrow.Microsecond}},
+		{Name: "dec", Type: &arrow.Decimal128Type{Precision: 10, Scale: 2}},
+		{Name: "fixedDec", Type: &arrow.Decimal128Type{Precision: 18}},
+		{Name: "opt", Type: arrow.BinaryTypes.String, Nullable: true},
+		{Name: "m", Type: arrow.ListOf(arrow.StructOf(
+			arrow.Field{Name: "key", Type: arrow.BinaryTypes.String},
+			arrow.Field{Name: "value", Type: arrow.PrimitiveTypes.Int64, Nullable: true},
+		))},
+	}, nil)
+	c.Assert(s.Equal(want), qt.Equals, true, qt.Commentf("got %v", s))
+	md := s.Metadata()
+	c.Assert(md.Keys(), qt.DeepEquals, []string{avroarrow.SchemaMetadataKey})
+	c.Assert(md.Values(), qt.DeepEquals, []string{at.String()})
+}
+
+var schemaErrorTests = []struct {
+	testName    string
+	schema      string
+	expectError string
+}{{
+	testName:    "non-record",
+	schema:      `"int"`,
+	expectError: `cannot convert non-record type "int"`,
+}, {
+	testName:    "general-union",
+	schema:      `{"type": "record", "name": "R", "fields": [{"name": "u", "type": ["int", "string"]}]}`,
+	expectError: `cannot convert field R.u: unsupported union type \["int","string"\]`,
+}, {
+	testName:    "recursive",
+	schema:      `{"type": "record", "name": "R", "fields": [{"name": "r", "type": ["null", "R"]}]}`,
+	expectError: `cannot convert field R.r: recursive type R is not supported`,
+}, {
+	testName:    "large-decimal",
+	schema:      `{"type": "record", "name": "R", "fields": [{"name": "d", "type": {"type": "bytes", "logicalType": "decimal", "precision": 40}}]}`,
+	expectError: `cannot convert field R.d: unsupported decimal precision 40`,
+}}
+
+func TestSchemaError(t *testing.T) {
+	c := qt.New(t)
+	for _, test := range schemaErrorTests {
+		c.Run(test.testName, func(c *qt.C) {
+			at, err := avro.ParseType(test.schema)
+			c.Assert(err, qt.Equals, nil)
+			_, err = avroarrow.Schema(at)
+			c.Assert(err, qt.ErrorMatches, test.expectError)
+		})
+	}
+}
+
+func TestDecimal(t *testing.T) {
+	c := qt.New(t)
+	mem := memory.NewCheckedAllocator(memory.NewGoAllocator())
+	defer mem.AssertSize(t, 0)
+
+	at, err := avro.ParseType(`{
+		"type": "record",
+		"name": "R",
+		"fields": [
+			{"name": "b", "type": {"type": "bytes", "logicalType": "decimal", "precision": 10, "scale": 2}},
+			{"name": "f", "type": {"type": "fixed", "name": "F", "size": 4, "logicalType": "decimal", "precision": 9}}
+		]
+	}`)
+	c.Assert(err, qt.Equals, nil)
+	b, err := avroarrow.NewBuilder(mem, at)
+	c.Assert(err, qt.Equals, nil)
+	defer b.Release()
+	// -12.34 as bytes and 300 as fixed.
+	data := []byte{4, 0xfb, 0x2e, 0, 0, 0x01, 0x2c}
+	err = b.Append(data)
+	c.Assert(err, qt.Equals, nil)
+	rec := b.NewRecord()
+	defer rec.Release()
+	c.Assert(rec.Column(0).(*array.Decimal128).Value(0), qt.Equals, decimal128.FromI64(-1234))
+	c.Assert(rec.Column(1).(*array.Decimal128).Value(0), qt.Equals, decimal128.FromI64(300))
+
+	enc, err := avroarrow.NewEncoder(rec.Schema())
+	c.Assert(err, qt.Equals, nil)
+	got, err := enc.AppendRow(nil, rec, 0)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(got, qt.DeepEquals, data)
+}
+
+func TestAppendInvalidData(t *testing.T) {
+	c := qt.New(t)
+	mem := memory.NewCheckedAllocator(memory.NewGoAllocator())
+	defer mem.AssertSize(t, 0)
+
+	at, err := avro.TypeOf(Point{})
+	c.Assert(err, qt.Equals, nil)
+	b, err := avroarrow.NewBuilder(mem, at)
+	c.Assert(err, qt.Equals, nil)
+	defer b.Release()
+
+	err = b.Append([]byte{2})
+	c.Assert(err, qt.ErrorMatches, `cannot decode value: Y: unexpected EOF`)
+	err = b.Append([]byte{2, 4, 6})
+	c.Assert(err, qt.ErrorMatches, `cannot decode value: unexpected data after value`)
+	c.Assert(b.Len(), qt.Equals, 0)
+
+	// The builder is still usable after an error.
+	err = b.Append([]byte{2, 4})
+	c.Assert(err, qt.Equals, nil)
+	rec := b.NewRecord()
+	defer rec.Release()
+	c.Assert(rec.NumRows(), qt.Equals, int64(1))
+	c.Assert(rec.Column(0).Len(), qt.Equals, 1)
+	c.Assert(rec.Column(1).Len(), qt.Equals, 1)
+}
+
+func TestEncodeWithoutAvroSchema(t *testing.T) {
+	c := qt.New(t)
+	mem := memory.NewCheckedAllocator(memory.NewGoAllocator())
+	defer mem.AssertSize(t, 0)
+
+	s := arrow.NewSchema([]arrow.Field{
+		{Name: "a", Type: arrow.PrimitiveTypes.Int8},
+		{Name: "b", Type: arrow.BinaryTypes.String, Nullable: true},
+		{Name: "c", Type: arrow.ListOf(arrow.PrimitiveTypes.Uint32)},
+		{Name: "d", Type: arrow.StructOf(
+			arrow.Field{Name: "e", Type: &arrow.FixedSizeBinaryType{ByteWidth: 2}},
+		)},
+	}, nil)
+	enc, err := avroarrow.NewEncoder(s)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(enc.Type().String(), qt.JSONEquals, json.RawMessage(`{
+		"type": "record",
+		"name": "Record",
+		"fields": [{
+			"name": "a",
+			"type": "int"
+		}, {
+			"name": "b",
+			"type": ["null", "string"],
+			"default": null
+		}, {
+			"name": "c",
+			"type": {"type": "array", "items": "long"}
+		}, {
+			"name": "d",
+			"type": {
+				"type": "record",
+				"name": "Record_d",
+				"fields": [{
+					"name": "e",
+					"type": {"type": "fixed", "name": "Record_d_e", "size": 2}
+				}]
+			}
+		}]
+	}`))
+
+	b := array.NewRecordBuilder(mem, s)
+	defer b.Release()
+	b.Field(0).(*array.Int8Builder).AppendValues([]int8{1, -2}, nil)
+	b.Field(1).(*array.StringBuilder).AppendValues([]string{"x", ""}, []bool{true, false})
+	lb := b.Field(2).(*array.ListBuilder)
+	lb.Append(true)
+	lb.ValueBuilder().(*array.Uint32Builder).AppendValues([]uint32{1, 4294967295}, nil)
+	lb.Append(true)
+	sb := b.Field(3).(*array.StructBuilder)
+	sb.AppendValues([]bool{true, true})
+	sb.FieldBuilder(0).(*array.FixedSizeBinaryBuilder).AppendValues([][]byte{[]byte("ab"), []byte("cd")}, nil)
+	rec := b.NewRecord()
+	defer rec.Release()
+
+	type D struct {
+		E [2]byte `json:"e"`
+	}
+	type Record struct {
+		A int     `json:"a"`
+		B *string `json:"b"`
+		C []int64 `json:"c"`
+		D D       `json:"d"`
+	}
+	x := "x"
+	want := []Record{{
+		A: 1,
+		B: &x,
+		C: []int64{1, 4294967295},
+		D: D{[2]byte{'a', 'b'}},
+	}, {
+		A: -2,
+		D: D{[2]byte{'c', 'd'}},
+	}}
+	for i := range want {
+		data, err := enc.AppendRow(nil, rec, i)
+		c.Assert(err, qt.Equals, nil)
+		var got Record
+		_, err = avro.Unmarshal(data, &got, enc.Type())
+		c.Assert(err, qt.Equals, nil)
+		c.Assert(got, qt.DeepEquals, want[i])
+	}
+}
+
+func TestAvroTypeWithMismatchedMetadata(t *testing.T) {
+	c := qt.New(t)
+	md := arrow.NewMetadata([]string{avroarrow.SchemaMetadataKey}, []string{`{"type": "record", "name": "R", "fields": [{"name": "a", "type": "long"}]}`})
+	s := arrow.NewSchema([]arrow.Field{
+		{Name: "a", Type: arrow.BinaryTypes.String},
+	}, &md)
+	_, err := avroarrow.AvroType(s)
+	c.Assert(err, qt.ErrorMatches, `Avro schema in Arrow metadata does not match Arrow schema`)
+}
+
+func TestEncodeNullInNonNullableColumn(t *testing.T) {
+	c := qt.New(t)
+	mem := memory.NewCheckedAllocator(memory.NewGoAllocator())
+	defer mem.AssertSize(t, 0)
+
+	s := arrow.NewSchema([]arrow.Field{
+		{Name: "a", Type: arrow.PrimitiveTypes.Int64},
+	}, nil)
+	enc, err := avroarrow.NewEncoder(s)
+	c.Assert(err, qt.Equals, nil)
+	b := array.NewRecordBuilder(mem, s)
+	defer b.Release()
+	b.Field(0).AppendNull()
+	rec := b.NewRecord()
+	defer rec.Release()
+	_, err = enc.AppendRow(nil, rec, 0)
+	c.Assert(err, qt.ErrorMatches, `cannot encode row 0: a: null value cannot be encoded as "long"`)
+}
+
+func TestOCFRoundTrip(t *testing.T) {
+	c := qt.New(t)
+	mem := memory.NewCheckedAllocator(memory.NewGoAllocator())
+	defer mem.AssertSize(t, 0)
+
+	points := []Point{{1, 2}, {3, 4}, {5, 6}}
+	at, err := avro.TypeOf(Point{})
+	c.Assert(err, qt.Equals, nil)
+	var in bytes.Buffer
+	w, err := avroocf.NewWriter(&in, at, &avroocf.WriterOptions{
+		BlockSize: 1,
+	})
+	c.Assert(err, qt.Equals, nil)
+	for _, p := range points {
+		c.Assert(w.Write(p), qt.Equals, nil)
+	}
+	c.Assert(w.Close(), qt.Equals, nil)
+
+	// Each block becomes a record batch.
+	or, err := avroocf.NewReader(&in)
+	c.Assert(err, qt.Equals, nil)
+	r, err := avroarrow.NewOCFReader(mem, or)
+	c.Assert(err, qt.Equals, nil)
+	defer r.Release()
+	enc, err := avroarrow.NewEncoder(r.Schema())
+	c.Assert(err, qt.Equals, nil)
+	var out bytes.Buffer
+	w, err = avroocf.NewWriter(&out, enc.Type(), nil)
+	c.Assert(err, qt.Equals, nil)
+	n := 0
+	for r.Next() {
+		c.Assert(r.Record().NumRows(), qt.Equals, int64(1))
+		c.Assert(enc.WriteOCF(w, r.Record()), qt.Equals, nil)
+		n++
+	}
+	c.Assert(r.Err(), qt.Equals, nil)
+	c.Assert(n, qt.Equals, len(points))
+	c.Assert(w.Close(), qt.Equals, nil)
+
+	// All the values are written to a single block.
+	or, err = avroocf.NewReader(&out)
+	c.Assert(err, qt.Equals, nil)
+	b, err := or.NextBlock()
+	c.Assert(err, qt.Equals, nil)
+	var got []Point
+	c.Assert(b.Decode(&got), qt.Equals, nil)
+	c.Assert(got, qt.DeepEquals, points)
+	_, err = or.NextBlock()
+	c.Assert(err, qt.Equals, io.EOF)
+}
+
+func TestWriteOCFWithMismatchedSchema(t *testing.T) {
+	c := qt.New(t)
+	mem := memory.NewCheckedAllocator(memory.NewGoAllocator())
+	defer mem.AssertSize(t, 0)
+
+	at, err := avro.TypeOf(Point{})
+	c.Assert(err, qt.Equals, nil)
+	b, err := avroarrow.NewBuilder(mem, at)
+	c.Assert(err, qt.Equals, nil)
+	defer b.Release()
+	rec := b.NewRecord()
+	defer rec.Release()
+	enc, err := avroarrow.NewEncoder(rec.Schema())
+	c.Assert(err, qt.Equals, nil)
+
+	rType, err := avro.TypeOf(R{})
+	c.Assert(err, qt.Equals, nil)
+	w, err := avroocf.NewWriter(new(bytes.Buffer), rType, nil)
+	c.Assert(err, qt.Equals, nil)
+	err = enc.WriteOCF(w, rec)
+	c.Assert(err, qt.ErrorMatches, `file schema does not match encoder type`)
+}
+
+func TestFramedRoundTrip(t *testing.T) {
+	c := qt.New(t)
+	mem := memory.NewCheckedAllocator(memory.NewGoAllocator())
+	defer mem.AssertSize(t, 0)
+
+	var in []byte
+	for _, p := range []Point{{1, 2}, {3, 4}, {5, 6}} {
+		data, _, err := avro.Marshal(p)
+		c.Assert(err, qt.Equals, nil)
+		var size [binary.MaxVarintLen64]byte
+		in = append(in, size[:binary.PutVarint(size[:], int64(len(data)))]...)
+		in = append(in, data...)
+	}
+	at, err := avro.TypeOf(Point{})
+	c.Assert(err, qt.Equals, nil)
+	r, err := avroarrow.NewFramedReader(mem, bytes.NewReader(in), at, 2)
+	c.Assert(err, qt.Equals, nil)
+	defer r.Release()
+	enc, err := avroarrow.NewEncoder(r.Schema())
+	c.Assert(err, qt.Equals, nil)
+	var out bytes.Buffer
+	var rows []int64
+	for r.Next() {
+		rows = append(rows, r.Record().NumRows())
+		c.Assert(enc.WriteFramed(&out, r.Record()), qt.Equals, nil)
+	}
+	c.Assert(r.Err(), qt.Equals, nil)
+	c.Assert(rows, qt.DeepEquals, []int64{2, 1})
+	c.Assert(out.Bytes(), qt.DeepEquals, in)
+}
+
+var framedReaderErrorTests = []struct {
+	testName    string
+	in          []byte
+	expectError string
+}{{
+	testName:    "truncated-value",
+	in:          []byte{4, 2},
+	expectError: `cannot read value: unexpected EOF`,
+}, {
+	testName:    "truncated-length",
+	in:          []byte{0x80},
+	expectError: `cannot read value: unexpected EOF`,
+}, {
+	testName:    "negative-length",
+	in:          []byte{1},
+	expectError: `invalid value length -1`,
+}, {
+	testName:    "invalid-value",
+	in:          []byte{2, 2},
+	expectError: `cannot decode value: Y: unexpected EOF`,
+}}
+
+func TestFramedReaderError(t *testing.T) {
+	c := qt.New(t)
+	at, err := avro.TypeOf(Point{})
+	c.Assert(err, qt.Equals, nil)
+	for _, test := range framedReaderErrorTests {
+		c.Run(test.testName, func(c *qt.C) {
+			mem := memory.NewCheckedAllocator(memory.NewGoAllocator())
+			defer mem.AssertSize(c, 0)
+			// Precede the bad value by a good one to check
+			// that the partial batch is discarded.
+			in := append([]byte{4, 2, 4}, test.in...)
+			r, err := avroarrow.NewFramedReader(mem, bytes.NewReader(in), at, 10)
+			c.Assert(err, qt.Equals, nil)
+			defer r.Release()
+			c.Assert(r.Next(), qt.Equals, false)
+			c.Assert(r.Err(), qt.ErrorMatches, test.expectError)
+			c.Assert(r.Next(), qt.Equals, false)
+		})
+	}
+}
@@ -0,0 +1,422 @@
+package avroarrow
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"math"
+
+	"github.com/apache/arrow/go/arrow"
+	"github.com/apache/arrow/go/arrow/array"
+	"github.com/apache/arrow/go/arrow/decimal128"
+	"github.com/apache/arrow/go/arrow/memory"
+	"github.com/rogpeppe/gogen-avro/v7/schema"
+
+	"github.com/heetch/avro"
+	"github.com/heetch/avro/avroocf"
+)
+
+// Builder builds Arrow record batches from Avro-encoded values.
+type Builder struct {
+	def    *schema.RecordDefinition
+	schema *arrow.Schema
+	b      *array.RecordBuilder
+	n      int
+}
+
+// NewBuilder returns a Builder that builds record batches from the
+// binary encoding of values of the Avro record type t, using mem to
+// allocate memory. The schema of the batches is Schema(t).
+//
+// The Builder should be released with Release when it's
+// no longer needed.
+func NewBuilder(mem memory.Allocator, t *avro.Type) (*Builder, error) {
+	s, err := Schema(t)
+	if err != nil {
+		return nil, err
+	}
+	def, err := recordDefinition(t)
+	if err != nil {
+		return nil, err
+	}
+	return &Builder{
+		def:    def,
+		schema: s,
+		b:      array.NewRecordBuilder(mem, s),
+	}, nil
+}
+
+// Schema returns the schema of the record batches made by b.
+func (b *Builder) Schema() *arrow.Schema {
+	return b.schema
+}
+
+// Append appends a row holding the value in data, which must
+// hold the Avro binary encoding of a single value of the Builder's
+// type. If data is invalid, Append returns an error and leaves
+// the Builder unchanged.
+func (b *Builder) Append(data []byte) error {
+	return b.appendValues(data, 1)
+}
+
+// AppendBlock appends a row for each value in blk, which must
+// have been read from an object container file whose schema is
+// the Builder's type. If the block is invalid, AppendBlock returns
+// an error and leaves the Builder unchanged.
+func (b *Builder) AppendBlock(blk *avroocf.Block) error {
+	return b.appendValues(blk.Data, blk.Count)
+}
+
+// appendValues appends a row for each of the count values
+// encoded one after another in data.
+func (b *Builder) appendValues(data []byte, count int) error {
+	// Check the data before appending anything, so that
+	// an error doesn't leave some columns longer than others.
+	d := &decoder{data: data}
+	for i := 0; i < count && d.err == nil; i++ {
+		d.record(b.def, nil)
+	}
+	if d.err == nil && len(d.data) > 0 {
+		d.err = fmt.Errorf("unexpected data after value")
+	}
+	if d.err != nil {
+		return fmt.Errorf("cannot decode value: %v", d.err)
+	}
+	d = &decoder{data: data}
+	for i := 0; i < count; i++ {
+		d.record(b.def, b.b.Fields())
+	}
+	if d.err != nil {
+		// Can't happen because we've already checked the data.
+		panic(fmt.Errorf("unexpected error decoding checked data: %v", d.err))
+	}
+	b.n += count
+	return nil
+}
+
+// Len returns the number of rows appended since the Builder
+// was created or NewRecord was last called.
+func (b *Builder) Len() int {
+	return b.n
+}
+
+// NewRecord returns a record batch holding all the rows
+// appended so far and resets the Builder so that it
+// can be used to build another batch. The record should
+// be released with its Release method when it's no longer
+// needed.
+func (b *Builder) NewRecord() array.Record {
+	b.n = 0
+	return b.b.NewRecord()
+}
+
+// Release releases the memory held by the Builder.
+func (b *Builder) Release() {
+	b.b.Release()
+}
+
+// decoder reads Avro binary-encoded data. Each method appends
+// the value it reads to the Arrow builder it's given, or only
+// checks the value when the builder is nil.
+//
+// The first error encountered is recorded in err, after which
+// all reads return zero values.
+type decoder struct {
+	data []byte
+	err  error
+}
+
+func (d *decoder) record(def *schema.RecordDefinition, fields []array.Builder) {
+	for i, f := range def.Fields() {
+		var fb array.Builder
+		if fields != nil {
+			fb = fields[i]
+		}
+		d.value(f.Type(), fb)
+		if d.err != nil {
+			d.err = fmt.Errorf("%s: %v", f.Name(), d.err)
+			return
+		}
+	}
+}
+
+func (d *decoder) value(at schema.AvroType, b array.Builder) {
+	if elem, nullIndex, ok := nullableUnion(at); ok {
+		index := d.long()
+		switch {
+		case d.err != nil:
+		case index == int64(nullIndex):
+			if b != nil {
+				appendNull(b)
+			}
+		case index == int64(1-nullIndex):
+			d.value(elem, b)
+		default:
+			d.setError(fmt.Errorf("union index %d out of range", index))
+		}
+		return
+	}
+	switch at := at.(type) {
+	case *schema.NullField:
+		if b != nil {
+			b.AppendNull()
+		}
+	case *schema.BoolField:
+		x := d.byte()
+		if d.err == nil && x > 1 {
+			d.setError(fmt.Errorf("invalid boolean value %d", x))
+		}
+		if b != nil {
+			b.(*array.BooleanBuilder).Append(x != 0)
+		}
+	case *schema.IntField:
+		x := d.long()
+		if x < math.MinInt32 || x > math.MaxInt32 {
+			d.setError(fmt.Errorf("int value %d out of range", x))
+		}
+		if b == nil {
+			break
+		}
+		switch b := b.(type) {
+		case *array.Date32Builder:
+			b.Append(arrow.Date32(x))
+		case *array.Time32Builder:
+			b.Append(arrow.Time32(x))
+		default:
+			b.(*array.Int32Builder).Append(int32(x))
+		}
+	case *schema.LongField:
+		x := d.long()
+		if b == nil {
+			break
+		}
+		switch b := b.(type) {
+		case *array.TimestampBuilder:
+			b.Append(arrow.Timestamp(x))
+		case *array.Time64Builder:
+			b.Append(arrow.Time64(x))
+		default:
+			b.(*array.Int64Builder).Append(x)
+		}
+	case *schema.FloatField:
+		x := d.next(4)
+		if b != nil {
+			b.(*array.Float32Builder).Append(math.Float32frombits(binary.LittleEndian.Uint32(x)))
+		}
+	case *schema.DoubleField:
+		x := d.next(8)
+		if b != nil {
+			b.(*array.Float64Builder).Append(math.Float64frombits(binary.LittleEndian.Uint64(x)))
+		}
+	case *schema.BytesField:
+		x := d.bytes()
+		if logicalType(at) == "decimal" {
+			d.decimal(x, b)
+		} else if b != nil {
+			b.(*array.BinaryBuilder).Append(x)
+		}
+	case *schema.StringField:
+		x := d.bytes()
+		if b != nil {
+			b.(*array.StringBuilder).Append(string(x))
+		}
+	case *schema.ArrayField:
+		var vb array.Builder
+		if b != nil {
+			lb := b.(*array.ListBuilder)
+			lb.Append(true)
+			vb = lb.ValueBuilder()
+		}
+		d.blocks(at.ItemType(), func() {
+			d.value(at.ItemType(), vb)
+		})
+	case *schema.MapField:
+		var sb *array.StructBuilder
+		if b != nil {
+			lb := b.(*array.ListBuilder)
+			lb.Append(true)
+			sb = lb.ValueBuilder().(*array.StructBuilder)
+		}
+		d.blocks(at.ItemType(), func() {
+			key := d.bytes()
+			if sb == nil {
+				d.value(at.ItemType(), nil)
+				return
+			}
+			sb.Append(true)
+			sb.FieldBuilder(0).(*array.StringBuilder).Append(string(key))
+			d.value(at.ItemType(), sb.FieldBuilder(1))
+		})
+	case *schema.Reference:
+		switch def := at.Def.(type) {
+		case *schema.EnumDefinition:
+			index := d.long()
+			syms := def.Symbols()
+			if d.err == nil && (index < 0 || index >= int64(len(syms))) {
+				d.setError(fmt.Errorf("enum index %d out of range", index))
+			}
+			if b != nil && d.err == nil {
+				b.(*array.StringBuilder).Append(syms[index])
+			}
+		case *schema.FixedDefinition:
+			x := d.next(def.SizeBytes())
+			if logicalType(at) == "decimal" {
+				d.decimal(x, b)
+			} else if b != nil {
+				b.(*array.FixedSizeBinaryBuilder).Append(x)
+			}
+		case *schema.RecordDefinition:
+			if b == nil {
+				d.record(def, nil)
+				break
+			}
+			sb := b.(*array.StructBuilder)
+			sb.Append(true)
+			fields := make([]array.Builder, sb.NumField())
+			for i := range fields {
+				fields[i] = sb.FieldBuilder(i)
+			}
+			d.record(def, fields)
+		default:
+			d.setError(fmt.Errorf("unknown definition type %T", def))
+		}
+	default:
+		d.setError(fmt.Errorf("unknown Avro type %T", at))
+	}
+}
+
+// decimal appends the decimal value with the two's complement
+// big-endian unscaled value in x.
+func (d *decoder) decimal(x []byte, b array.Builder) {
+	n, ok := decimalFromBytes(x)
+	if d.err == nil && !ok {
+		d.setError(fmt.Errorf("decimal value out of range"))
+	}
+	if b != nil {
+		b.(*array.Decimal128Builder).Append(n)
+	}
+}
+
+// blocks reads the blocks of an array or map with items of type
+// itemType, calling f to read each item.
+func (d *decoder) blocks(itemType schema.AvroType, f func()) {
+	for d.err == nil {
+		count := d.long()
+		if count == 0 {
+			return
+		}
+		if count < 0 {
+			count = -count
+			// Ignore the block size.
+			d.long()
+		}
+		if _, ok := itemType.(*schema.NullField); !ok && count > int64(len(d.data)) {
+			// Every item other than null takes at least one byte.
+			d.setError(fmt.Errorf("invalid item count %d", count))
+		}
+		for i := int64(0); i < count && d.err == nil; i++ {
+			f()
+		}
+	}
+}
+
+func (d *decoder) long() int64 {
+	if d.err != nil {
+		return 0
+	}
+	x, n := binary.Varint(d.data)
+	if n <= 0 {
+		if n == 0 {
+			d.setError(io.ErrUnexpectedEOF)
+		} else {
+			d.setError(fmt.Errorf("varint overflow"))
+		}
+		return 0
+	}
+	d.data = d.data[n:]
+	return x
+}
+
+func (d *decoder) byte() byte {
+	x := d.next(1)
+	if x == nil {
+		return 0
+	}
+	return x[0]
+}
+
+func (d *decoder) bytes() []byte {
+	n := d.long()
+	if d.err == nil && n < 0 {
+		d.setError(fmt.Errorf("negative length %d", n))
+	}
+	if d.err == nil && n > int64(len(d.data)) {
+		d.setError(io.ErrUnexpectedEOF)
+	}
+	if d.err != nil {
+		return nil
+	}
+	return d.next(int(n))
+}
+
+// next returns the next n bytes of data. It returns a slice
+// of zero bytes if there's been an error, so that callers
+// can always use the result.
+func (d *decoder) next(n int) []byte {
+	if d.err == nil && n > len(d.data) {
+		d.setError(io.ErrUnexpectedEOF)
+	}
+	if d.err != nil {
+		return make([]byte, n)
+	}
+	x := d.data[:n]
+	d.data = d.data[n:]
+	return x
+}
+
+func (d *decoder) setError(err error) {
+	if d.err == nil {
+		d.err = err
+	}
+}
+
+// appendNull appends a null to b. Arrow requires the children
+// of a struct to be the same length as the struct, so nulls
+// are appended to the fields of a null struct too.
+func appendNull(b array.Builder) {
+	b.AppendNull()
+	if sb, ok := b.(*array.StructBuilder); ok {
+		for i := 0; i < sb.NumField(); i++ {
+			appendNull(sb.FieldBuilder(i))
+		}
+	}
+}
+
+// decimalFromBytes returns the decimal number with the
+// two's complement big-endian representation in x.
+// It reports whether the number fits in 128 bits.
+func decimalFromBytes(x []byte) (decimal128.Num, bool) {
+	if len(x) == 0 {
+		return decimal128.Num{}, true
+	}
+	var ext byte
+	if x[0]&0x80 != 0 {
+		ext = 0xff
+	}
+	var buf [16]byte
+	for len(x) > len(buf) {
+		if x[0] != ext {
+			return decimal128.Num{}, false
+		}
+		x = x[1:]
+	}
+	if x[0]&0x80 != ext&0x80 {
+		// The sign bit was lost when removing the sign extension.
+		return decimal128.Num{}, false
+	}
+	for i := range buf {
+		buf[i] = ext
+	}
+	copy(buf[len(buf)-len(x):], x)
+	return decimal128.New(int64(binary.BigEndian.Uint64(buf[:8])), binary.BigEndian.Uint64(buf[8:])), true
+}
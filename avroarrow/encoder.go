@@ -0,0 +1,394 @@
+package avroarrow
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"math"
+
+	"github.com/apache/arrow/go/arrow"
+	"github.com/apache/arrow/go/arrow/array"
+	"github.com/apache/arrow/go/arrow/decimal128"
+	"github.com/rogpeppe/gogen-avro/v7/schema"
+
+	"github.com/heetch/avro"
+)
+
+// DefaultRecordName holds the name of the Avro record type
+// returned by AvroType for an Arrow schema without an Avro
+// schema in its metadata.
+const DefaultRecordName = "Record"
+
+// AvroType returns the Avro record type for rows of record batches
+// with the Arrow schema s.
+//
+// If s holds an Avro schema in its metadata (see SchemaMetadataKey)
+// that type is returned, after checking that it corresponds to s.
+// Otherwise the type is derived from s: each column becomes a field
+// of a record named DefaultRecordName, and Arrow types map to Avro
+// types in the opposite direction to Schema, with the following
+// additions:
+//
+//   - int8, int16, uint8 and uint16 map to int
+//   - uint32 maps to long
+//   - a nullable column or struct field maps to a union
+//     of null and its type, with a null default
+//
+// Nested structs and fixed-size binary types are named after
+// their path from the top level record, for example Record_a_b.
+// Arrow list items are always nullable, but the nulls can't
+// be encoded in the derived schema.
+func AvroType(s *arrow.Schema) (*avro.Type, error) {
+	if i := s.Metadata().FindKey(SchemaMetadataKey); i >= 0 {
+		t, err := avro.ParseType(s.Metadata().Values()[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid Avro schema in Arrow metadata: %v", err)
+		}
+		s1, err := Schema(t)
+		if err != nil {
+			return nil, err
+		}
+		if !s1.Equal(s) {
+			return nil, fmt.Errorf("Avro schema in Arrow metadata does not match Arrow schema")
+		}
+		return t, nil
+	}
+	fields, err := avroFields(DefaultRecordName, s.Fields())
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(map[string]interface{}{
+		"type":   "record",
+		"name":   DefaultRecordName,
+		"fields": fields,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return avro.ParseType(string(data))
+}
+
+func avroFields(path string, fields []arrow.Field) ([]interface{}, error) {
+	afields := make([]interface{}, len(fields))
+	for i, f := range fields {
+		at, err := avroSchema(path+"_"+f.Name, f.Type)
+		if err != nil {
+			return nil, fmt.Errorf("cannot convert field %s: %v", f.Name, err)
+		}
+		af := map[string]interface{}{
+			"name": f.Name,
+			"type": at,
+		}
+		if f.Nullable && f.Type.ID() != arrow.NULL {
+			af["type"] = []interface{}{"null", at}
+			af["default"] = nil
+		}
+		afields[i] = af
+	}
+	return afields, nil
+}
+
+// avroSchema returns the JSON-marshalable Avro schema for the Arrow
+// type dt. Named types are given the name path.
+func avroSchema(path string, dt arrow.DataType) (interface{}, error) {
+	switch dt := dt.(type) {
+	case *arrow.NullType:
+		return "null", nil
+	case *arrow.BooleanType:
+		return "boolean", nil
+	case *arrow.Int8Type, *arrow.Int16Type, *arrow.Int32Type, *arrow.Uint8Type, *arrow.Uint16Type:
+		return "int", nil
+	case *arrow.Int64Type, *arrow.Uint32Type:
+		return "long", nil
+	case *arrow.Float32Type:
+		return "float", nil
+	case *arrow.Float64Type:
+		return "double", nil
+	case *arrow.BinaryType:
+		return "bytes", nil
+	case *arrow.StringType:
+		return "string", nil
+	case *arrow.FixedSizeBinaryType:
+		return map[string]interface{}{
+			"type": "fixed",
+			"name": path,
+			"size": dt.ByteWidth,
+		}, nil
+	case *arrow.Decimal128Type:
+		return map[string]interface{}{
+			"type":        "bytes",
+			"logicalType": "decimal",
+			"precision":   dt.Precision,
+			"scale":       dt.Scale,
+		}, nil
+	case *arrow.Date32Type:
+		return logicalSchema("int", "date"), nil
+	case *arrow.Time32Type:
+		if dt.Unit == arrow.Millisecond {
+			return logicalSchema("int", "time-millis"), nil
+		}
+	case *arrow.Time64Type:
+		if dt.Unit == arrow.Microsecond {
+			return logicalSchema("long", "time-micros"), nil
+		}
+	case *arrow.TimestampType:
+		var name string
+		switch dt.Unit {
+		case arrow.Millisecond:
+			name = "timestamp-millis"
+		case arrow.Microsecond:
+			name = "timestamp-micros"
+		default:
+			return nil, fmt.Errorf("unsupported Arrow type %s", dt)
+		}
+		if dt.TimeZone == "" {
+			name = "local-" + name
+		}
+		return logicalSchema("long", name), nil
+	case *arrow.ListType:
+		items, err := avroSchema(path+"_item", dt.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{
+			"type":  "array",
+			"items": items,
+		}, nil
+	case *arrow.StructType:
+		fields, err := avroFields(path, dt.Fields())
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{
+			"type":   "record",
+			"name":   path,
+			"fields": fields,
+		}, nil
+	}
+	return nil, fmt.Errorf("unsupported Arrow type %s", dt)
+}
+
+func logicalSchema(typ, logicalType string) interface{} {
+	return map[string]interface{}{
+		"type":        typ,
+		"logicalType": logicalType,
+	}
+}
+
+// Encoder encodes the rows of Arrow record batches as Avro values.
+type Encoder struct {
+	t      *avro.Type
+	def    *schema.RecordDefinition
+	schema *arrow.Schema
+}
+
+// NewEncoder returns an Encoder that encodes rows of record batches
+// with the schema s as values of the Avro type AvroType(s).
+func NewEncoder(s *arrow.Schema) (*Encoder, error) {
+	t, err := AvroType(s)
+	if err != nil {
+		return nil, err
+	}
+	def, err := recordDefinition(t)
+	if err != nil {
+		return nil, err
+	}
+	return &Encoder{
+		t:      t,
+		def:    def,
+		schema: s,
+	}, nil
+}
+
+// Type returns the Avro type of the values encoded by e.
+func (e *Encoder) Type() *avro.Type {
+	return e.t
+}
+
+// AppendRow appends the Avro binary encoding of row i of rec to buf
+// and returns the extended buffer. The schema of rec must be the
+// same as the schema passed to NewEncoder.
+func (e *Encoder) AppendRow(buf []byte, rec array.Record, i int) ([]byte, error) {
+	if !rec.Schema().Equal(e.schema) {
+		return buf, fmt.Errorf("record batch schema does not match encoder schema")
+	}
+	if i < 0 || int64(i) >= rec.NumRows() {
+		return buf, fmt.Errorf("row %d out of range", i)
+	}
+	buf1, err := appendRecord(buf, e.def, rec.Columns(), i)
+	if err != nil {
+		return buf, fmt.Errorf("cannot encode row %d: %v", i, err)
+	}
+	return buf1, nil
+}
+
+func appendRecord(buf []byte, def *schema.RecordDefinition, cols []array.Interface, i int) ([]byte, error) {
+	for j, f := range def.Fields() {
+		var err error
+		buf, err = appendValue(buf, f.Type(), cols[j], i)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %v", f.Name(), err)
+		}
+	}
+	return buf, nil
+}
+
+// appendValue appends the Avro encoding of element i of arr as a
+// value of type at. The Arrow type of arr must be one that maps to
+// at, as checked by AvroType.
+func appendValue(buf []byte, at schema.AvroType, arr array.Interface, i int) ([]byte, error) {
+	if elem, nullIndex, ok := nullableUnion(at); ok {
+		if arr.IsNull(i) {
+			return appendLong(buf, int64(nullIndex)), nil
+		}
+		return appendValue(appendLong(buf, int64(1-nullIndex)), elem, arr, i)
+	}
+	if _, ok := at.(*schema.NullField); ok {
+		return buf, nil
+	}
+	if arr.IsNull(i) {
+		return nil, fmt.Errorf("null value cannot be encoded as %s", schemaFragment(at))
+	}
+	switch arr := arr.(type) {
+	case *array.Boolean:
+		if arr.Value(i) {
+			return append(buf, 1), nil
+		}
+		return append(buf, 0), nil
+	case *array.Int8:
+		return appendLong(buf, int64(arr.Value(i))), nil
+	case *array.Int16:
+		return appendLong(buf, int64(arr.Value(i))), nil
+	case *array.Int32:
+		return appendLong(buf, int64(arr.Value(i))), nil
+	case *array.Int64:
+		return appendLong(buf, arr.Value(i)), nil
+	case *array.Uint8:
+		return appendLong(buf, int64(arr.Value(i))), nil
+	case *array.Uint16:
+		return appendLong(buf, int64(arr.Value(i))), nil
+	case *array.Uint32:
+		return appendLong(buf, int64(arr.Value(i))), nil
+	case *array.Date32:
+		return appendLong(buf, int64(arr.Value(i))), nil
+	case *array.Time32:
+		return appendLong(buf, int64(arr.Value(i))), nil
+	case *array.Time64:
+		return appendLong(buf, int64(arr.Value(i))), nil
+	case *array.Timestamp:
+		return appendLong(buf, int64(arr.Value(i))), nil
+	case *array.Float32:
+		var b [4]byte
+		binary.LittleEndian.PutUint32(b[:], math.Float32bits(arr.Value(i)))
+		return append(buf, b[:]...), nil
+	case *array.Float64:
+		var b [8]byte
+		binary.LittleEndian.PutUint64(b[:], math.Float64bits(arr.Value(i)))
+		return append(buf, b[:]...), nil
+	case *array.Binary:
+		return appendBytes(buf, arr.Value(i)), nil
+	case *array.String:
+		s := arr.Value(i)
+		if ref, ok := at.(*schema.Reference); ok {
+			if def, ok := ref.Def.(*schema.EnumDefinition); ok {
+				for index, sym := range def.Symbols() {
+					if sym == s {
+						return appendLong(buf, int64(index)), nil
+					}
+				}
+				return nil, fmt.Errorf("%q is not a symbol of enum %s", s, def.AvroName())
+			}
+		}
+		return appendBytes(buf, []byte(s)), nil
+	case *array.FixedSizeBinary:
+		return append(buf, arr.Value(i)...), nil
+	case *array.Decimal128:
+		if ref, ok := at.(*schema.Reference); ok {
+			if def, ok := ref.Def.(*schema.FixedDefinition); ok {
+				x, ok := decimalBytes(arr.Value(i), def.SizeBytes())
+				if !ok {
+					return nil, fmt.Errorf("decimal value out of range for %s", def.AvroName())
+				}
+				return append(buf, x...), nil
+			}
+		}
+		x, _ := decimalBytes(arr.Value(i), 0)
+		return appendBytes(buf, x), nil
+	case *array.List:
+		offsets := arr.Offsets()
+		start, end := int(offsets[i]), int(offsets[i+1])
+		if start == end {
+			return appendLong(buf, 0), nil
+		}
+		buf = appendLong(buf, int64(end-start))
+		values := arr.ListValues()
+		var err error
+		switch at := at.(type) {
+		case *schema.ArrayField:
+			for j := start; j < end; j++ {
+				if buf, err = appendValue(buf, at.ItemType(), values, j); err != nil {
+					return nil, err
+				}
+			}
+		case *schema.MapField:
+			entries := values.(*array.Struct)
+			keys, vals := entries.Field(0).(*array.String), entries.Field(1)
+			for j := start; j < end; j++ {
+				buf = appendBytes(buf, []byte(keys.Value(j)))
+				if buf, err = appendValue(buf, at.ItemType(), vals, j); err != nil {
+					return nil, err
+				}
+			}
+		default:
+			return nil, fmt.Errorf("cannot encode list as %s", schemaFragment(at))
+		}
+		return appendLong(buf, 0), nil
+	case *array.Struct:
+		cols := make([]array.Interface, arr.NumField())
+		for j := range cols {
+			cols[j] = arr.Field(j)
+		}
+		return appendRecord(buf, at.(*schema.Reference).Def.(*schema.RecordDefinition), cols, i)
+	}
+	return nil, fmt.Errorf("cannot encode Arrow %s value", arr.DataType())
+}
+
+func appendLong(buf []byte, x int64) []byte {
+	var b [binary.MaxVarintLen64]byte
+	return append(buf, b[:binary.PutVarint(b[:], x)]...)
+}
+
+func appendBytes(buf, x []byte) []byte {
+	return append(appendLong(buf, int64(len(x))), x...)
+}
+
+// decimalBytes returns the two's complement big-endian
+// representation of n in size bytes. If size is zero, the
+// shortest representation is returned. It reports whether
+// n fits in the given size.
+func decimalBytes(n decimal128.Num, size int) ([]byte, bool) {
+	var b [16]byte
+	binary.BigEndian.PutUint64(b[:8], uint64(n.HighBits()))
+	binary.BigEndian.PutUint64(b[8:], n.LowBits())
+	var ext byte
+	if n.Sign() < 0 {
+		ext = 0xff
+	}
+	// Find the shortest representation that keeps the sign bit.
+	x := b[:]
+	for len(x) > 1 && x[0] == ext && x[1]&0x80 == ext&0x80 {
+		x = x[1:]
+	}
+	if size == 0 {
+		return x, true
+	}
+	if len(x) > size {
+		return nil, false
+	}
+	r := make([]byte, size)
+	for i := range r[:size-len(x)] {
+		r[i] = ext
+	}
+	copy(r[size-len(x):], x)
+	return r, true
+}
@@ -0,0 +1,194 @@
+package avroarrow
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sync/atomic"
+
+	"github.com/apache/arrow/go/arrow"
+	"github.com/apache/arrow/go/arrow/array"
+	"github.com/apache/arrow/go/arrow/memory"
+
+	"github.com/heetch/avro"
+	"github.com/heetch/avro/avroocf"
+)
+
+// Reader reads record batches from a stream of Avro values.
+// It implements array.RecordReader.
+type Reader struct {
+	refCount int64
+	b        *Builder
+
+	// fill appends the rows of the next batch to b.
+	// It returns io.EOF when there are no more rows.
+	fill func() error
+
+	rec array.Record
+	err error
+}
+
+var _ array.RecordReader = (*Reader)(nil)
+
+// NewOCFReader returns a Reader that reads a record batch from
+// each block of the object container file read by r, using mem
+// to allocate memory. The schema of the batches is the Arrow
+// schema for the file's schema, as returned by Schema.
+func NewOCFReader(mem memory.Allocator, r *avroocf.Reader) (*Reader, error) {
+	b, err := NewBuilder(mem, r.Header().Schema)
+	if err != nil {
+		return nil, err
+	}
+	return &Reader{
+		refCount: 1,
+		b:        b,
+		fill: func() error {
+			blk, err := r.NextBlock()
+			if err != nil {
+				return err
+			}
+			return b.AppendBlock(blk)
+		},
+	}, nil
+}
+
+// NewFramedReader returns a Reader that reads values of the Avro
+// record type t from r in the framed binary format (see
+// avro.FramedBinary) and makes record batches of up to batchSize
+// rows from them, using mem to allocate memory.
+func NewFramedReader(mem memory.Allocator, r io.Reader, t *avro.Type, batchSize int) (*Reader, error) {
+	if batchSize <= 0 {
+		return nil, fmt.Errorf("batch size %d is not positive", batchSize)
+	}
+	b, err := NewBuilder(mem, t)
+	if err != nil {
+		return nil, err
+	}
+	br := bufio.NewReader(r)
+	return &Reader{
+		refCount: 1,
+		b:        b,
+		fill: func() error {
+			for b.Len() < batchSize {
+				size, err := binary.ReadVarint(br)
+				if err == io.EOF {
+					if b.Len() > 0 {
+						return nil
+					}
+					return io.EOF
+				}
+				if err != nil {
+					return fmt.Errorf("cannot read value: %v", err)
+				}
+				if size < 0 || size > avro.DefaultMaxValueSize {
+					return fmt.Errorf("invalid value length %d", size)
+				}
+				data := make([]byte, size)
+				if _, err := io.ReadFull(br, data); err != nil {
+					if err == io.EOF {
+						err = io.ErrUnexpectedEOF
+					}
+					return fmt.Errorf("cannot read value: %v", err)
+				}
+				if err := b.Append(data); err != nil {
+					return err
+				}
+			}
+			return nil
+		},
+	}, nil
+}
+
+// Schema returns the schema of the record batches read by r.
+func (r *Reader) Schema() *arrow.Schema {
+	return r.b.Schema()
+}
+
+// Next reads the next record batch, which is then available
+// from Record. It returns false when there are no more batches
+// or an error occurs; Err returns the error.
+func (r *Reader) Next() bool {
+	if r.rec != nil {
+		r.rec.Release()
+		r.rec = nil
+	}
+	if r.err != nil {
+		return false
+	}
+	if err := r.fill(); err != nil {
+		// Discard any rows appended before the error.
+		r.b.NewRecord().Release()
+		if err != io.EOF {
+			r.err = err
+		}
+		return false
+	}
+	r.rec = r.b.NewRecord()
+	return true
+}
+
+// Record returns the record batch read by the last call to Next.
+// It's only valid until the next call to Next or Release.
+func (r *Reader) Record() array.Record {
+	return r.rec
+}
+
+// Err returns any error encountered by Next.
+func (r *Reader) Err() error {
+	return r.err
+}
+
+// Retain increases the reference count of r by 1.
+func (r *Reader) Retain() {
+	atomic.AddInt64(&r.refCount, 1)
+}
+
+// Release decreases the reference count of r by 1. When it
+// reaches zero, the memory held by r is released.
+func (r *Reader) Release() {
+	if atomic.AddInt64(&r.refCount, -1) != 0 {
+		return
+	}
+	if r.rec != nil {
+		r.rec.Release()
+		r.rec = nil
+	}
+	r.b.Release()
+}
+
+// WriteOCF writes all the rows of rec to w as Avro values.
+// The file's schema must be e.Type().
+func (e *Encoder) WriteOCF(w *avroocf.Writer, rec array.Record) error {
+	if w.Header().Schema.CanonicalString(avro.RetainLogicalTypes) != e.t.CanonicalString(avro.RetainLogicalTypes) {
+		return fmt.Errorf("file schema does not match encoder type")
+	}
+	var buf []byte
+	for i := 0; i < int(rec.NumRows()); i++ {
+		data, err := e.AppendRow(buf[:0], rec, i)
+		if err != nil {
+			return err
+		}
+		if err := w.WriteEncoded(data); err != nil {
+			return err
+		}
+		buf = data
+	}
+	return nil
+}
+
+// WriteFramed writes all the rows of rec to w as Avro values
+// in the framed binary format (see avro.FramedBinary).
+func (e *Encoder) WriteFramed(w io.Writer, rec array.Record) error {
+	var buf, row []byte
+	for i := 0; i < int(rec.NumRows()); i++ {
+		data, err := e.AppendRow(row[:0], rec, i)
+		if err != nil {
+			return err
+		}
+		buf = appendBytes(buf, data)
+		row = data
+	}
+	_, err := w.Write(buf)
+	return err
+}
@@ -0,0 +1,278 @@
+// Package avrocloudevents implements the CloudEvents Avro event format,
+// as defined by https://github.com/cloudevents/spec/blob/v1.0.2/cloudevents/formats/avro-format.md.
+//
+// An Event holds the context attributes and data of a CloudEvent
+// and encodes with the standard CloudEvent record schema,
+// so it can be used with avro.Marshal, avro.Unmarshal and
+// the other encoders in the avro package.
+//
+// Data that's itself Avro-encoded is held as bytes; see
+// Event.SetAvroData and Event.AvroData.
+package avrocloudevents
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/heetch/avro"
+	"github.com/heetch/avro/avrotypegen"
+)
+
+// SpecVersion holds the version of the CloudEvents
+// specification implemented by this package.
+const SpecVersion = "1.0"
+
+// AvroContentType holds the datacontenttype attribute
+// set by Event.SetAvroData.
+const AvroContentType = "application/avro"
+
+// Schema holds the CloudEvent record schema
+// defined by the CloudEvents Avro format.
+const Schema = `{"doc":"Avro Event Format for CloudEvents","fields":[{"name":"attribute","type":{"type":"map","values":["null","boolean","int","string","bytes"]}},{"name":"data","type":["bytes","null","boolean",{"type":"map","values":["null","boolean",{"doc":"Representation of a JSON Value","fields":[{"name":"value","type":{"type":"map","values":"CloudEventData"}}],"name":"CloudEventData","type":"record"},"double","string"]},{"items":"CloudEventData","type":"array"},"double","string"]}],"name":"io.cloudevents.CloudEvent","type":"record","version":"1.0"}`
+
+// dataSchema holds the schema of the CloudEventData record on its own.
+const dataSchema = `{"doc":"Representation of a JSON Value","fields":[{"name":"value","type":{"type":"map","values":"CloudEventData"}}],"name":"io.cloudevents.CloudEventData","type":"record"}`
+
+// Event represents a CloudEvent.
+type Event struct {
+	// Attribute holds the context attributes of the event,
+	// including extension attributes.
+	//
+	// Allowed types for interface{} value:
+	// 	nil
+	// 	bool
+	// 	int
+	// 	string
+	// 	[]byte
+	//
+	// Attributes with the CloudEvents types URI, URI-reference
+	// and Timestamp are held as strings.
+	Attribute map[string]interface{} `json:"attribute"`
+
+	// Data holds the event payload.
+	//
+	// Allowed types for interface{} value:
+	// 	[]byte
+	// 	nil
+	// 	bool
+	// 	map[string]interface{}
+	// 	[]Data
+	// 	float64
+	// 	string
+	//
+	// Allowed types for the values of a map[string]interface{} value:
+	// 	nil
+	// 	bool
+	// 	Data
+	// 	float64
+	// 	string
+	Data interface{} `json:"data"`
+}
+
+// AvroRecord implements the avro.AvroRecord interface.
+func (Event) AvroRecord() avrotypegen.RecordInfo {
+	return avrotypegen.RecordInfo{
+		Schema: Schema,
+		Required: []bool{
+			0: true,
+			1: true,
+		},
+		Unions: []avrotypegen.UnionInfo{
+			0: {
+				Type: new(map[string]interface{}),
+				Union: []avrotypegen.UnionInfo{{
+					Type: nil,
+					Name: "null",
+				}, {
+					Type: new(bool),
+					Name: "boolean",
+				}, {
+					Type: new(int),
+					Name: "int",
+				}, {
+					Type: new(string),
+					Name: "string",
+				}, {
+					Type: new([]byte),
+					Name: "bytes",
+				}},
+			},
+			1: {
+				Type: new(interface{}),
+				Union: []avrotypegen.UnionInfo{{
+					Type: new([]byte),
+					Name: "bytes",
+				}, {
+					Type: nil,
+					Name: "null",
+				}, {
+					Type: new(bool),
+					Name: "boolean",
+				}, {
+					Type: new(map[string]interface{}),
+					Name: "map",
+					Union: []avrotypegen.UnionInfo{{
+						Type: nil,
+						Name: "null",
+					}, {
+						Type: new(bool),
+						Name: "boolean",
+					}, {
+						Type: new(Data),
+						Name: "io.cloudevents.CloudEventData",
+					}, {
+						Type: new(float64),
+						Name: "double",
+					}, {
+						Type: new(string),
+						Name: "string",
+					}},
+				}, {
+					Type: new([]Data),
+					Name: "array",
+				}, {
+					Type: new(float64),
+					Name: "double",
+				}, {
+					Type: new(string),
+					Name: "string",
+				}},
+			},
+		},
+	}
+}
+
+// Data represents the CloudEventData record used
+// for structured event data.
+type Data struct {
+	Value map[string]Data `json:"value"`
+}
+
+// AvroRecord implements the avro.AvroRecord interface.
+func (Data) AvroRecord() avrotypegen.RecordInfo {
+	return avrotypegen.RecordInfo{
+		Schema: dataSchema,
+		Required: []bool{
+			0: true,
+		},
+	}
+}
+
+// NewEvent returns an event with the given id, source and
+// type attributes and the specversion attribute set to SpecVersion.
+func NewEvent(id, source, eventType string) *Event {
+	return &Event{
+		Attribute: map[string]interface{}{
+			"specversion": SpecVersion,
+			"id":          id,
+			"source":      source,
+			"type":        eventType,
+		},
+	}
+}
+
+// requiredAttributes holds the attributes that every event must have.
+var requiredAttributes = []string{"id", "source", "specversion", "type"}
+
+// Validate checks that the event has all the required
+// attributes and that attribute names and values are
+// allowed by the specification.
+func (e *Event) Validate() error {
+	for _, name := range requiredAttributes {
+		if s, _ := e.Attribute[name].(string); s == "" {
+			return fmt.Errorf("missing required attribute %q", name)
+		}
+	}
+	if v := e.Attribute["specversion"]; v != SpecVersion {
+		return fmt.Errorf("unsupported specversion %q", v)
+	}
+	names := make([]string, 0, len(e.Attribute))
+	for name := range e.Attribute {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		if !validAttributeName(name) {
+			return fmt.Errorf("invalid attribute name %q", name)
+		}
+		switch e.Attribute[name].(type) {
+		case nil, bool, int, string, []byte:
+		default:
+			return fmt.Errorf("invalid type %T for attribute %q", e.Attribute[name], name)
+		}
+	}
+	return nil
+}
+
+// validAttributeName reports whether name consists
+// only of lower-case letters and digits.
+func validAttributeName(name string) bool {
+	if name == "" {
+		return false
+	}
+	for _, c := range name {
+		if !('a' <= c && c <= 'z' || '0' <= c && c <= '9') {
+			return false
+		}
+	}
+	return true
+}
+
+// SetTime sets the time attribute to t.
+func (e *Event) SetTime(t time.Time) {
+	e.setAttribute("time", t.Format(time.RFC3339Nano))
+}
+
+// Time returns the value of the time attribute.
+// It returns the zero time if the attribute isn't set.
+func (e *Event) Time() (time.Time, error) {
+	v, ok := e.Attribute["time"]
+	if !ok || v == nil {
+		return time.Time{}, nil
+	}
+	s, ok := v.(string)
+	if !ok {
+		return time.Time{}, fmt.Errorf("time attribute has unexpected type %T", v)
+	}
+	t, err := time.Parse(time.RFC3339Nano, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid time attribute: %v", err)
+	}
+	return t, nil
+}
+
+// SetAvroData sets the event's data to x encoded with avro.Marshal,
+// and sets the datacontenttype attribute to AvroContentType.
+// It returns the Avro type that x was encoded with, which
+// is needed to decode the data.
+func (e *Event) SetAvroData(x interface{}) (*avro.Type, error) {
+	data, wType, err := avro.Marshal(x)
+	if err != nil {
+		return nil, err
+	}
+	e.Data = data
+	e.setAttribute("datacontenttype", AvroContentType)
+	return wType, nil
+}
+
+// AvroData decodes the event's data, which must have been set
+// by SetAvroData, into x, using wType as the type that the data
+// was encoded with. It returns the type that was decoded into.
+func (e *Event) AvroData(x interface{}, wType *avro.Type) (*avro.Type, error) {
+	if ct, _ := e.Attribute["datacontenttype"].(string); ct != AvroContentType {
+		return nil, fmt.Errorf("unexpected data content type %q", ct)
+	}
+	data, ok := e.Data.([]byte)
+	if !ok {
+		return nil, fmt.Errorf("event data has unexpected type %T", e.Data)
+	}
+	return avro.Unmarshal(data, x, wType)
+}
+
+func (e *Event) setAttribute(name string, v interface{}) {
+	if e.Attribute == nil {
+		e.Attribute = make(map[string]interface{})
+	}
+	e.Attribute[name] = v
+}
@@ -0,0 +1,171 @@
+package avrocloudevents_test
+
+import (
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro"
+	"github.com/heetch/avro/avrocloudevents"
+)
+
+type order struct {
+	ID    string
+	Total float64
+}
+
+func TestAvroDataRoundTrip(t *testing.T) {
+	c := qt.New(t)
+	e := avrocloudevents.NewEvent("1234", "/orders", "com.example.order.created")
+	e.SetTime(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC))
+	e.Attribute["partitionkey"] = "abc"
+	dataType, err := e.SetAvroData(order{ID: "x", Total: 1.5})
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(e.Validate(), qt.Equals, nil)
+
+	data, wType, err := avro.Marshal(*e)
+	c.Assert(err, qt.Equals, nil)
+
+	var e1 avrocloudevents.Event
+	_, err = avro.Unmarshal(data, &e1, wType)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(e1.Attribute, qt.DeepEquals, map[string]interface{}{
+		"specversion":     "1.0",
+		"id":              "1234",
+		"source":          "/orders",
+		"type":            "com.example.order.created",
+		"time":            "2020-01-02T03:04:05Z",
+		"partitionkey":    "abc",
+		"datacontenttype": "application/avro",
+	})
+	tm, err := e1.Time()
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(tm.Equal(time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)), qt.Equals, true)
+
+	var x order
+	_, err = e1.AvroData(&x, dataType)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(x, qt.Equals, order{ID: "x", Total: 1.5})
+}
+
+func TestStructuredDataRoundTrip(t *testing.T) {
+	c := qt.New(t)
+	e := avrocloudevents.NewEvent("1", "/s", "t")
+	e.Data = map[string]interface{}{
+		"a": "x",
+		"b": 2.5,
+		"c": true,
+		"d": nil,
+		"e": avrocloudevents.Data{
+			Value: map[string]avrocloudevents.Data{
+				"f": {},
+			},
+		},
+	}
+	data, wType, err := avro.Marshal(*e)
+	c.Assert(err, qt.Equals, nil)
+
+	var e1 avrocloudevents.Event
+	_, err = avro.Unmarshal(data, &e1, wType)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(e1.Data, qt.DeepEquals, e.Data)
+}
+
+func TestStringDataRoundTrip(t *testing.T) {
+	c := qt.New(t)
+	// The data union holds bytes before string and the attribute
+	// union holds string before bytes, so check that values of
+	// both types keep their type when decoded.
+	e := avrocloudevents.NewEvent("1", "/s", "t")
+	e.Attribute["blob"] = []byte("x")
+	e.Data = "hello"
+	data, wType, err := avro.Marshal(*e)
+	c.Assert(err, qt.Equals, nil)
+
+	var e1 avrocloudevents.Event
+	_, err = avro.Unmarshal(data, &e1, wType)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(e1, qt.DeepEquals, *e)
+}
+
+func TestAvroDataWrongContentType(t *testing.T) {
+	c := qt.New(t)
+	e := avrocloudevents.NewEvent("1", "/s", "t")
+	e.Data = "hello"
+	var x order
+	_, err := e.AvroData(&x, nil)
+	c.Assert(err, qt.ErrorMatches, `unexpected data content type ""`)
+}
+
+var validateTests = []struct {
+	testName    string
+	attr        map[string]interface{}
+	expectError string
+}{{
+	testName: "ok",
+	attr: map[string]interface{}{
+		"specversion": "1.0",
+		"id":          "1",
+		"source":      "/s",
+		"type":        "t",
+		"count":       3,
+		"flag":        true,
+		"blob":        []byte("x"),
+		"empty":       nil,
+	},
+}, {
+	testName: "missing-id",
+	attr: map[string]interface{}{
+		"specversion": "1.0",
+		"source":      "/s",
+		"type":        "t",
+	},
+	expectError: `missing required attribute "id"`,
+}, {
+	testName: "bad-specversion",
+	attr: map[string]interface{}{
+		"specversion": "0.3",
+		"id":          "1",
+		"source":      "/s",
+		"type":        "t",
+	},
+	expectError: `unsupported specversion "0.3"`,
+}, {
+	testName: "bad-name",
+	attr: map[string]interface{}{
+		"specversion": "1.0",
+		"id":          "1",
+		"source":      "/s",
+		"type":        "t",
+		"Bad_Name":    "x",
+	},
+	expectError: `invalid attribute name "Bad_Name"`,
+}, {
+	testName: "bad-value",
+	attr: map[string]interface{}{
+		"specversion": "1.0",
+		"id":          "1",
+		"source":      "/s",
+		"type":        "t",
+		"x":           1.5,
+	},
+	expectError: `invalid type float64 for attribute "x"`,
+}}
+
+func TestValidate(t *testing.T) {
+	c := qt.New(t)
+	for _, test := range validateTests {
+		c.Run(test.testName, func(c *qt.C) {
+			e := &avrocloudevents.Event{
+				Attribute: test.attr,
+			}
+			err := e.Validate()
+			if test.expectError != "" {
+				c.Assert(err, qt.ErrorMatches, test.expectError)
+				return
+			}
+			c.Assert(err, qt.Equals, nil)
+		})
+	}
+}
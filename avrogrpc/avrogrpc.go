@@ -0,0 +1,173 @@
+// Package avrogrpc provides a codec that lets gRPC services use
+// Avro-encoded messages instead of protocol buffers.
+//
+// Codec implements google.golang.org/grpc/encoding.Codec without
+// depending on the gRPC module. It's registered with gRPC like this:
+//
+//	encoding.RegisterCodec(avrogrpc.NewCodec(nil, types...))
+//
+// and clients select it with the grpc.CallContentSubtype(avrogrpc.Name)
+// call option.
+//
+// Each message is written in Avro single-object encoding, which
+// identifies the writer's schema by its fingerprint (see avro.Type.Fingerprint).
+// The receiving side resolves the writer's schema against the schema
+// of the Go type it's decoding into, so messages can evolve as
+// long as the schemas remain compatible. Because gRPC has no
+// channel for exchanging schemas, the schemas a peer might write with
+// must be shared ahead of time and passed to NewCodec; the schemas of
+// the Go types used by the codec itself are always known.
+package avrogrpc
+
+import (
+	"context"
+	"encoding/binary"
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/heetch/avro"
+)
+
+// Name is the name of the codec, which is used as the
+// gRPC content subtype, as in "application/grpc+avro".
+const Name = "avro"
+
+// Codec encodes and decodes gRPC messages. It implements
+// google.golang.org/grpc/encoding.Codec.
+type Codec struct {
+	names    *avro.Names
+	registry *fingerprintRegistry
+	enc      *avro.SingleEncoder
+	dec      *avro.SingleDecoder
+
+	// known holds the Go types (reflect.Type) whose schemas
+	// have been added to the registry.
+	known sync.Map
+}
+
+// NewCodec returns a Codec that can decode messages written
+// with any of the given schemas or with the schema of any
+// Go type that it decodes into.
+//
+// Go values will have their Avro schemas translated with the given
+// Names instance. If names is nil, the global namespace will be used.
+func NewCodec(names *avro.Names, types ...*avro.Type) *Codec {
+	r := &fingerprintRegistry{}
+	for _, t := range types {
+		r.add(t)
+	}
+	return &Codec{
+		names:    names,
+		registry: r,
+		enc:      avro.NewSingleEncoder(r, names),
+		dec:      avro.NewSingleDecoder(r, names),
+	}
+}
+
+// Name implements encoding.Codec.Name.
+func (c *Codec) Name() string {
+	return Name
+}
+
+// Marshal implements encoding.Codec.Marshal by encoding v,
+// preceded by the fingerprint of its schema.
+func (c *Codec) Marshal(v interface{}) ([]byte, error) {
+	return c.enc.Marshal(context.Background(), v)
+}
+
+// Unmarshal implements encoding.Codec.Unmarshal by decoding
+// data into v, which must be a pointer.
+func (c *Codec) Unmarshal(data []byte, v interface{}) error {
+	t := reflect.TypeOf(v)
+	if t == nil || t.Kind() != reflect.Ptr {
+		return fmt.Errorf("cannot decode into non-pointer value %T", v)
+	}
+	if err := c.addType(t.Elem()); err != nil {
+		return err
+	}
+	_, err := c.dec.Unmarshal(context.Background(), data, v)
+	return err
+}
+
+// addType adds the schema of Go type t to the registry, so that
+// messages written with the same schema can be decoded even
+// when it hasn't been shared ahead of time.
+func (c *Codec) addType(t reflect.Type) error {
+	if _, ok := c.known.Load(t); ok {
+		return nil
+	}
+	x := reflect.Zero(t).Interface()
+	var at *avro.Type
+	var err error
+	if c.names != nil {
+		at, err = c.names.TypeOf(x)
+	} else {
+		at, err = avro.TypeOf(x)
+	}
+	if err != nil {
+		return err
+	}
+	c.registry.add(at)
+	c.known.Store(t, true)
+	return nil
+}
+
+// singleObjectMarker holds the two bytes that start
+// a message in Avro single-object encoding.
+var singleObjectMarker = [2]byte{0xc3, 0x01}
+
+// fingerprintRegistry implements avro.EncodingRegistry and
+// avro.DecodingRegistry by identifying schemas by their
+// fingerprints, using the header defined by Avro single-object
+// encoding.
+// See https://avro.apache.org/docs/1.9.1/spec.html#single_object_encoding.
+type fingerprintRegistry struct {
+	// types holds a map from fingerprint (int64) to *avro.Type.
+	types sync.Map
+}
+
+var (
+	_ avro.EncodingRegistry = (*fingerprintRegistry)(nil)
+	_ avro.DecodingRegistry = (*fingerprintRegistry)(nil)
+)
+
+func (r *fingerprintRegistry) add(t *avro.Type) int64 {
+	id := int64(t.Fingerprint())
+	r.types.LoadOrStore(id, t)
+	return id
+}
+
+// AppendSchemaID implements avro.EncodingRegistry.AppendSchemaID
+// by appending the single-object marker and the fingerprint.
+func (r *fingerprintRegistry) AppendSchemaID(buf []byte, id int64) []byte {
+	n := len(buf)
+	buf = append(buf, singleObjectMarker[0], singleObjectMarker[1], 0, 0, 0, 0, 0, 0, 0, 0)
+	binary.LittleEndian.PutUint64(buf[n+2:], uint64(id))
+	return buf
+}
+
+// IDForSchema implements avro.EncodingRegistry.IDForSchema
+// by returning the schema's fingerprint.
+func (r *fingerprintRegistry) IDForSchema(ctx context.Context, schema *avro.Type) (int64, error) {
+	return r.add(schema), nil
+}
+
+// DecodeSchemaID implements avro.DecodingRegistry.DecodeSchemaID
+// by stripping off the single-object header.
+func (r *fingerprintRegistry) DecodeSchemaID(msg []byte) (int64, []byte) {
+	if len(msg) < 10 || msg[0] != singleObjectMarker[0] || msg[1] != singleObjectMarker[1] {
+		return 0, nil
+	}
+	return int64(binary.LittleEndian.Uint64(msg[2:10])), msg[10:]
+}
+
+// SchemaForID implements avro.DecodingRegistry.SchemaForID
+// by looking up a schema that's been added to the registry.
+func (r *fingerprintRegistry) SchemaForID(ctx context.Context, id int64) (*avro.Type, error) {
+	t, ok := r.types.Load(id)
+	if !ok {
+		return nil, fmt.Errorf("unknown schema with fingerprint %#x", uint64(id))
+	}
+	return t.(*avro.Type), nil
+}
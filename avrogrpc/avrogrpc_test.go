@@ -0,0 +1,86 @@
+package avrogrpc_test
+
+import (
+	"encoding/binary"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro"
+	"github.com/heetch/avro/avrogrpc"
+)
+
+type Request struct {
+	A int
+}
+
+func TestRoundTrip(t *testing.T) {
+	c := qt.New(t)
+	codec := avrogrpc.NewCodec(nil)
+	c.Assert(codec.Name(), qt.Equals, "avro")
+	data, err := codec.Marshal(Request{A: 20})
+	c.Assert(err, qt.Equals, nil)
+
+	c.Assert(data[0:2], qt.DeepEquals, []byte{0xc3, 0x01})
+	c.Assert(binary.LittleEndian.Uint64(data[2:10]), qt.Equals, mustTypeOf(Request{}).Fingerprint())
+	c.Assert(data[10:], qt.DeepEquals, []byte{40})
+
+	// Decode with a separate codec, as a peer would.
+	var x Request
+	err = avrogrpc.NewCodec(nil).Unmarshal(data, &x)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(x, qt.Equals, Request{A: 20})
+}
+
+func TestSchemaEvolution(t *testing.T) {
+	c := qt.New(t)
+	data, err := avrogrpc.NewCodec(nil).Marshal(Request{A: 20})
+	c.Assert(err, qt.Equals, nil)
+	oldType := mustTypeOf(Request{})
+
+	// A newer version of Request, with the same
+	// name but an extra field.
+	type Request struct {
+		A int
+		B string
+	}
+	var x Request
+	err = avrogrpc.NewCodec(nil, oldType).Unmarshal(data, &x)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(x, qt.Equals, Request{A: 20})
+}
+
+func TestUnknownSchema(t *testing.T) {
+	c := qt.New(t)
+	data, err := avrogrpc.NewCodec(nil).Marshal(Request{A: 20})
+	c.Assert(err, qt.Equals, nil)
+
+	type Request struct {
+		A int
+		B string
+	}
+	var x Request
+	err = avrogrpc.NewCodec(nil).Unmarshal(data, &x)
+	c.Assert(err, qt.ErrorMatches, `cannot unmarshal: unknown schema with fingerprint 0x[0-9a-f]+`)
+}
+
+func TestUnmarshalBadHeader(t *testing.T) {
+	c := qt.New(t)
+	var x Request
+	err := avrogrpc.NewCodec(nil).Unmarshal([]byte{0, 1, 2}, &x)
+	c.Assert(err, qt.ErrorMatches, `cannot get schema ID from message`)
+}
+
+func TestUnmarshalNonPointer(t *testing.T) {
+	c := qt.New(t)
+	err := avrogrpc.NewCodec(nil).Unmarshal(nil, Request{})
+	c.Assert(err, qt.ErrorMatches, `cannot decode into non-pointer value avrogrpc_test.Request`)
+}
+
+func mustTypeOf(x interface{}) *avro.Type {
+	t, err := avro.TypeOf(x)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
@@ -0,0 +1,231 @@
+// Package avrointerop provides a test harness that checks Go values
+// against reference vectors produced by other Avro implementations,
+// such as the Java avro-tools, so that regressions in wire
+// compatibility are caught by go test.
+//
+// Vectors are held in a JSON file containing an array of objects,
+// each of which holds a vector name, the writer schema and the
+// hex-encoded binary encoding of a value:
+//
+//	[{
+//		"name": "simple-record",
+//		"schema": {"type": "record", "name": "R", "fields": [{"name": "A", "type": "long"}]},
+//		"binary": "28",
+//		"json": {"A": 20}
+//	}]
+//
+// The binary encoding of the value held in value.json can be produced
+// with avro-tools:
+//
+//	avro-tools jsontofrag --schema-file schema.avsc value.json | xxd -p
+//
+// A vector can also hold the value in the Avro JSON encoding, in
+// the "json" field, and as a base64-encoded object container file
+// produced by "avro-tools fromjson", in the "ocf" field. Both are
+// checked when present.
+//
+// A test checks the vectors against the Go values they should
+// decode to:
+//
+//	func TestInterop(t *testing.T) {
+//		vectors, err := avrointerop.ReadVectors("testdata/vectors.json")
+//		if err != nil {
+//			t.Fatal(err)
+//		}
+//		avrointerop.Run(t, vectors, map[string]interface{}{
+//			"simple-record": R{A: 20},
+//		})
+//	}
+package avrointerop
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"reflect"
+
+	"github.com/heetch/avro"
+	"github.com/heetch/avro/avroocf"
+)
+
+// Vector holds a reference encoding of a value.
+type Vector struct {
+	// Name holds the name of the vector.
+	Name string `json:"name"`
+
+	// Schema holds the schema that the value was written with.
+	Schema json.RawMessage `json:"schema"`
+
+	// Binary holds the value in the Avro binary encoding.
+	Binary HexBytes `json:"binary"`
+
+	// JSON holds the value in the Avro JSON encoding.
+	JSON json.RawMessage `json:"json,omitempty"`
+
+	// OCF holds an object container file holding
+	// the value as its only record.
+	OCF []byte `json:"ocf,omitempty"`
+}
+
+// HexBytes holds binary data that's represented
+// as a hex string in JSON.
+type HexBytes []byte
+
+// MarshalJSON implements json.Marshaler.
+func (b HexBytes) MarshalJSON() ([]byte, error) {
+	return json.Marshal(hex.EncodeToString(b))
+}
+
+// UnmarshalJSON implements json.Unmarshaler.
+func (b *HexBytes) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		return err
+	}
+	data, err := hex.DecodeString(s)
+	if err != nil {
+		return fmt.Errorf("invalid hex data: %v", err)
+	}
+	*b = data
+	return nil
+}
+
+// ReadVectors reads the vectors held in the JSON file at path.
+func ReadVectors(path string) ([]Vector, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var vectors []Vector
+	if err := json.Unmarshal(data, &vectors); err != nil {
+		return nil, fmt.Errorf("cannot parse vectors in %s: %v", path, err)
+	}
+	return vectors, nil
+}
+
+// Check checks that the binary data in v decodes to x, which must
+// not be a pointer, and, when the schema of x's type has the same
+// canonical form as the vector's schema, that x encodes to the
+// binary data in v.
+//
+// When v holds JSON data, Check also checks that it's equivalent to
+// the binary data in both directions. When v holds an object
+// container file, Check also checks that its only record decodes to x.
+func Check(v Vector, x interface{}) error {
+	wType, err := avro.ParseType(string(v.Schema))
+	if err != nil {
+		return fmt.Errorf("cannot parse schema: %v", err)
+	}
+	xv := reflect.New(reflect.TypeOf(x))
+	if _, err := avro.Unmarshal(v.Binary, xv.Interface(), wType); err != nil {
+		return fmt.Errorf("cannot decode: %v", err)
+	}
+	if got := xv.Elem().Interface(); !reflect.DeepEqual(got, x) {
+		return fmt.Errorf("decoded value mismatch; got %#v want %#v", got, x)
+	}
+	if v.JSON != nil {
+		if err := checkJSON(v, wType); err != nil {
+			return err
+		}
+	}
+	if v.OCF != nil {
+		if err := checkOCF(v, x); err != nil {
+			return err
+		}
+	}
+	data, xType, err := avro.Marshal(x)
+	if err != nil {
+		return fmt.Errorf("cannot encode: %v", err)
+	}
+	if xType.CanonicalString(0) != wType.CanonicalString(0) {
+		return nil
+	}
+	if !reflect.DeepEqual(data, []byte(v.Binary)) {
+		return fmt.Errorf("encoded data mismatch; got %x want %x", data, []byte(v.Binary))
+	}
+	return nil
+}
+
+// checkJSON checks that the JSON data in v holds the
+// same value as its binary data, which has type wType.
+func checkJSON(v Vector, wType *avro.Type) error {
+	data, err := avro.JSONToBinary(v.JSON, wType)
+	if err != nil {
+		return fmt.Errorf("cannot convert JSON to binary: %v", err)
+	}
+	if !bytes.Equal(data, v.Binary) {
+		return fmt.Errorf("binary data converted from JSON mismatch; got %x want %x", data, []byte(v.Binary))
+	}
+	data, err = avro.BinaryToJSON(v.Binary, wType)
+	if err != nil {
+		return fmt.Errorf("cannot convert binary to JSON: %v", err)
+	}
+	// Compare the JSON values rather than their text,
+	// which can differ in layout.
+	var got, want interface{}
+	if err := json.Unmarshal(data, &got); err != nil {
+		return fmt.Errorf("invalid JSON converted from binary: %v", err)
+	}
+	if err := json.Unmarshal(v.JSON, &want); err != nil {
+		return fmt.Errorf("invalid JSON data: %v", err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		return fmt.Errorf("JSON converted from binary mismatch; got %s want %s", data, v.JSON)
+	}
+	return nil
+}
+
+// checkOCF checks that the object container file in v
+// holds a single record that decodes to x.
+func checkOCF(v Vector, x interface{}) error {
+	r, err := avroocf.NewReader(bytes.NewReader(v.OCF))
+	if err != nil {
+		return fmt.Errorf("cannot read object container file: %v", err)
+	}
+	xs := reflect.New(reflect.SliceOf(reflect.TypeOf(x)))
+	for {
+		b, err := r.NextBlock()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return fmt.Errorf("cannot read object container file: %v", err)
+		}
+		if err := b.Decode(xs.Interface()); err != nil {
+			return fmt.Errorf("cannot read object container file: %v", err)
+		}
+	}
+	if n := xs.Elem().Len(); n != 1 {
+		return fmt.Errorf("object container file holds %d records, want 1", n)
+	}
+	if got := xs.Elem().Index(0).Interface(); !reflect.DeepEqual(got, x) {
+		return fmt.Errorf("value decoded from object container file mismatch; got %#v want %#v", got, x)
+	}
+	return nil
+}
+
+// Run calls Check for each vector with the value in values that has
+// the same name, reporting failures with t.Errorf. Vectors that have
+// no value are reported as failures too.
+func Run(t T, vectors []Vector, values map[string]interface{}) {
+	t.Helper()
+	for _, v := range vectors {
+		x, ok := values[v.Name]
+		if !ok {
+			t.Errorf("vector %q: no value provided", v.Name)
+			continue
+		}
+		if err := Check(v, x); err != nil {
+			t.Errorf("vector %q: %v", v.Name, err)
+		}
+	}
+}
+
+// T represents a test (the usual implementation being *testing.T).
+type T interface {
+	Helper()
+	Errorf(f string, a ...interface{})
+}
@@ -0,0 +1,95 @@
+package avrointerop_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro/avrointerop"
+)
+
+type R struct {
+	A int
+	B string
+}
+
+type U struct {
+	F *string
+}
+
+type A struct {
+	Xs []int
+}
+
+func TestRun(t *testing.T) {
+	c := qt.New(t)
+	vectors, err := avrointerop.ReadVectors("testdata/vectors.json")
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(vectors, qt.HasLen, 3)
+	hi := "hi"
+	avrointerop.Run(c, vectors, map[string]interface{}{
+		"record":         R{A: 20, B: "x"},
+		"union":          U{F: &hi},
+		"promoted-array": A{Xs: []int{1, 2, 3}},
+	})
+}
+
+func TestCheckMismatch(t *testing.T) {
+	c := qt.New(t)
+	vectors, err := avrointerop.ReadVectors("testdata/vectors.json")
+	c.Assert(err, qt.Equals, nil)
+	err = avrointerop.Check(vectors[0], R{A: 21, B: "x"})
+	c.Assert(err, qt.ErrorMatches, `decoded value mismatch; got avrointerop_test.R\{A:20, B:"x"\} want avrointerop_test.R\{A:21, B:"x"\}`)
+}
+
+func TestCheckJSONMismatch(t *testing.T) {
+	c := qt.New(t)
+	vectors, err := avrointerop.ReadVectors("testdata/vectors.json")
+	c.Assert(err, qt.Equals, nil)
+	v := vectors[0]
+	v.JSON = json.RawMessage(`{"A": 21, "B": "x"}`)
+	err = avrointerop.Check(v, R{A: 20, B: "x"})
+	c.Assert(err, qt.ErrorMatches, `binary data converted from JSON mismatch; got 2a0278 want 280278`)
+}
+
+func TestCheckBadOCF(t *testing.T) {
+	c := qt.New(t)
+	vectors, err := avrointerop.ReadVectors("testdata/vectors.json")
+	c.Assert(err, qt.Equals, nil)
+	v := vectors[0]
+	v.OCF = []byte("x")
+	err = avrointerop.Check(v, R{A: 20, B: "x"})
+	c.Assert(err, qt.ErrorMatches, `cannot read object container file: not an Avro object container file`)
+}
+
+func TestRunMissingValue(t *testing.T) {
+	c := qt.New(t)
+	vectors, err := avrointerop.ReadVectors("testdata/vectors.json")
+	c.Assert(err, qt.Equals, nil)
+	var rt recordingT
+	avrointerop.Run(&rt, vectors[:1], nil)
+	c.Assert(rt.errors, qt.DeepEquals, []string{`vector "record": no value provided`})
+}
+
+func TestReadVectorsBadHex(t *testing.T) {
+	c := qt.New(t)
+	path := filepath.Join(c.Mkdir(), "vectors.json")
+	err := ioutil.WriteFile(path, []byte(`[{"name": "x", "schema": "int", "binary": "zz"}]`), 0666)
+	c.Assert(err, qt.Equals, nil)
+	_, err = avrointerop.ReadVectors(path)
+	c.Assert(err, qt.ErrorMatches, `cannot parse vectors in .*: invalid hex data: .*`)
+}
+
+type recordingT struct {
+	errors []string
+}
+
+func (t *recordingT) Helper() {}
+
+func (t *recordingT) Errorf(f string, a ...interface{}) {
+	t.errors = append(t.errors, fmt.Sprintf(f, a...))
+}
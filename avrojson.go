@@ -0,0 +1,504 @@
+package avro
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+
+	"github.com/rogpeppe/gogen-avro/v7/schema"
+)
+
+// This file implements conversion between the Avro binary
+// encoding and the Avro JSON encoding described in
+// https://avro.apache.org/docs/1.9.1/spec.html#json_encoding.
+// The conversion is driven by the schema only, so it works for
+// any Go representation of the values.
+
+// BinaryToJSON returns the Avro JSON encoding of the value in data,
+// which must hold exactly one value of type t in the Avro binary
+// encoding.
+func BinaryToJSON(data []byte, t *Type) ([]byte, error) {
+	buf, rest, err := appendAvroJSON(nil, data, t.avroType)
+	if err != nil {
+		return nil, err
+	}
+	if len(rest) > 0 {
+		return nil, fmt.Errorf("unexpected data after value")
+	}
+	return buf, nil
+}
+
+// JSONToBinary returns the Avro binary encoding of the value in data,
+// which must hold exactly one value of type t in the Avro JSON encoding.
+func JSONToBinary(data []byte, t *Type) ([]byte, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	var x interface{}
+	if err := dec.Decode(&x); err != nil {
+		return nil, err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return nil, fmt.Errorf("unexpected data after JSON value")
+	}
+	return appendAvroBinary(nil, x, t.avroType)
+}
+
+// appendAvroJSON appends the Avro JSON encoding of the
+// binary-encoded value of type at at the start of data to buf.
+// It returns the extended buffer and the remaining data.
+func appendAvroJSON(buf, data []byte, at schema.AvroType) ([]byte, []byte, error) {
+	switch kindOf(at) {
+	case KindNull:
+		return append(buf, "null"...), data, nil
+	case KindBoolean:
+		if len(data) < 1 {
+			return nil, nil, io.ErrUnexpectedEOF
+		}
+		return strconv.AppendBool(buf, data[0] != 0), data[1:], nil
+	case KindInt, KindLong:
+		x, data, err := readLong(data)
+		if err != nil {
+			return nil, nil, err
+		}
+		return strconv.AppendInt(buf, x, 10), data, nil
+	case KindFloat:
+		if len(data) < 4 {
+			return nil, nil, io.ErrUnexpectedEOF
+		}
+		bits := uint32(data[0]) | uint32(data[1])<<8 | uint32(data[2])<<16 | uint32(data[3])<<24
+		buf, err := appendJSONFloat(buf, float64(math.Float32frombits(bits)), 32)
+		return buf, data[4:], err
+	case KindDouble:
+		if len(data) < 8 {
+			return nil, nil, io.ErrUnexpectedEOF
+		}
+		var bits uint64
+		for i := 7; i >= 0; i-- {
+			bits = bits<<8 | uint64(data[i])
+		}
+		buf, err := appendJSONFloat(buf, math.Float64frombits(bits), 64)
+		return buf, data[8:], err
+	case KindBytes:
+		b, data, err := readBytes(data)
+		if err != nil {
+			return nil, nil, err
+		}
+		return appendJSONBytes(buf, b), data, nil
+	case KindString:
+		b, data, err := readBytes(data)
+		if err != nil {
+			return nil, nil, err
+		}
+		return appendJSONString(buf, string(b)), data, nil
+	case KindFixed:
+		size := at.(*schema.Reference).Def.(*schema.FixedDefinition).SizeBytes()
+		if len(data) < size {
+			return nil, nil, io.ErrUnexpectedEOF
+		}
+		return appendJSONBytes(buf, data[:size]), data[size:], nil
+	case KindEnum:
+		syms := at.(*schema.Reference).Def.(*schema.EnumDefinition).Symbols()
+		i, data, err := readLong(data)
+		if err != nil {
+			return nil, nil, err
+		}
+		if i < 0 || i >= int64(len(syms)) {
+			return nil, nil, fmt.Errorf("enum index %d out of range", i)
+		}
+		return appendJSONString(buf, syms[i]), data, nil
+	case KindRecord:
+		buf = append(buf, '{')
+		for i, f := range at.(*schema.Reference).Def.(*schema.RecordDefinition).Fields() {
+			if i > 0 {
+				buf = append(buf, ',')
+			}
+			buf = appendJSONString(buf, f.Name())
+			buf = append(buf, ':')
+			var err error
+			buf, data, err = appendAvroJSON(buf, data, f.Type())
+			if err != nil {
+				return nil, nil, err
+			}
+		}
+		return append(buf, '}'), data, nil
+	case KindArray:
+		itemType := at.(*schema.ArrayField).ItemType()
+		buf = append(buf, '[')
+		buf, data, err := appendJSONBlocks(buf, data, func(buf, data []byte, i int) ([]byte, []byte, error) {
+			if i > 0 {
+				buf = append(buf, ',')
+			}
+			return appendAvroJSON(buf, data, itemType)
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		return append(buf, ']'), data, nil
+	case KindMap:
+		itemType := at.(*schema.MapField).ItemType()
+		buf = append(buf, '{')
+		buf, data, err := appendJSONBlocks(buf, data, func(buf, data []byte, i int) ([]byte, []byte, error) {
+			if i > 0 {
+				buf = append(buf, ',')
+			}
+			key, data, err := readBytes(data)
+			if err != nil {
+				return nil, nil, err
+			}
+			buf = appendJSONString(buf, string(key))
+			buf = append(buf, ':')
+			return appendAvroJSON(buf, data, itemType)
+		})
+		if err != nil {
+			return nil, nil, err
+		}
+		return append(buf, '}'), data, nil
+	case KindUnion:
+		items := at.(*schema.UnionField).ItemTypes()
+		i, data, err := readLong(data)
+		if err != nil {
+			return nil, nil, err
+		}
+		if i < 0 || i >= int64(len(items)) {
+			return nil, nil, fmt.Errorf("union index %d out of range", i)
+		}
+		if kindOf(items[i]) == KindNull {
+			return append(buf, "null"...), data, nil
+		}
+		// Other members are represented as an object with
+		// a single member named after the type.
+		buf = append(buf, '{')
+		buf = appendJSONString(buf, unionMemberName(items[i]))
+		buf = append(buf, ':')
+		buf, data, err = appendAvroJSON(buf, data, items[i])
+		if err != nil {
+			return nil, nil, err
+		}
+		return append(buf, '}'), data, nil
+	}
+	return nil, nil, fmt.Errorf("unknown Avro type %s", schemaFragment(at))
+}
+
+// appendJSONBlocks calls appendItem for each item in the
+// binary-encoded array or map blocks at the start of data.
+func appendJSONBlocks(buf, data []byte, appendItem func(buf, data []byte, i int) ([]byte, []byte, error)) ([]byte, []byte, error) {
+	i := 0
+	for {
+		n, rest, err := readLong(data)
+		if err != nil {
+			return nil, nil, err
+		}
+		data = rest
+		if n == 0 {
+			return buf, data, nil
+		}
+		if n < 0 {
+			// The count is followed by the size of the block in bytes.
+			n = -n
+			if _, data, err = readLong(data); err != nil {
+				return nil, nil, err
+			}
+		}
+		for ; n > 0; n-- {
+			buf, data, err = appendItem(buf, data, i)
+			if err != nil {
+				return nil, nil, err
+			}
+			i++
+		}
+	}
+}
+
+// appendAvroBinary appends the binary encoding of x to buf, where x
+// holds a value of type at in the Avro JSON encoding as decoded by
+// a json.Decoder with UseNumber set.
+func appendAvroBinary(buf []byte, x interface{}, at schema.AvroType) ([]byte, error) {
+	switch kindOf(at) {
+	case KindNull:
+		if x != nil {
+			return nil, jsonTypeError(x, at)
+		}
+		return buf, nil
+	case KindBoolean:
+		b, ok := x.(bool)
+		if !ok {
+			return nil, jsonTypeError(x, at)
+		}
+		if b {
+			return append(buf, 1), nil
+		}
+		return append(buf, 0), nil
+	case KindInt, KindLong:
+		bits := 64
+		if kindOf(at) == KindInt {
+			bits = 32
+		}
+		n, err := jsonInt(x, bits)
+		if err != nil {
+			return nil, jsonValueError(x, at, err)
+		}
+		return appendLong(buf, n), nil
+	case KindFloat:
+		f, err := jsonFloat(x, 32)
+		if err != nil {
+			return nil, jsonValueError(x, at, err)
+		}
+		return appendFloat(buf, float32(f)), nil
+	case KindDouble:
+		f, err := jsonFloat(x, 64)
+		if err != nil {
+			return nil, jsonValueError(x, at, err)
+		}
+		return appendDouble(buf, f), nil
+	case KindBytes:
+		b, err := jsonBytes(x)
+		if err != nil {
+			return nil, jsonValueError(x, at, err)
+		}
+		buf = appendLong(buf, int64(len(b)))
+		return append(buf, b...), nil
+	case KindString:
+		s, ok := x.(string)
+		if !ok {
+			return nil, jsonTypeError(x, at)
+		}
+		buf = appendLong(buf, int64(len(s)))
+		return append(buf, s...), nil
+	case KindFixed:
+		b, err := jsonBytes(x)
+		if err != nil {
+			return nil, jsonValueError(x, at, err)
+		}
+		if size := at.(*schema.Reference).Def.(*schema.FixedDefinition).SizeBytes(); len(b) != size {
+			return nil, jsonValueError(x, at, fmt.Errorf("got %d bytes, want %d", len(b), size))
+		}
+		return append(buf, b...), nil
+	case KindEnum:
+		s, ok := x.(string)
+		if !ok {
+			return nil, jsonTypeError(x, at)
+		}
+		for i, sym := range at.(*schema.Reference).Def.(*schema.EnumDefinition).Symbols() {
+			if sym == s {
+				return appendLong(buf, int64(i)), nil
+			}
+		}
+		return nil, jsonValueError(x, at, fmt.Errorf("unknown symbol"))
+	case KindRecord:
+		obj, ok := x.(map[string]interface{})
+		if !ok {
+			return nil, jsonTypeError(x, at)
+		}
+		ref := at.(*schema.Reference)
+		for _, f := range ref.Def.(*schema.RecordDefinition).Fields() {
+			fx, ok := obj[f.Name()]
+			if !ok {
+				if !f.HasDefault() {
+					return nil, fmt.Errorf("field %q of %s not present and has no default value", f.Name(), ref.TypeName)
+				}
+				var err error
+				buf, err = appendAvroDefault(buf, f.Default(), f.Type())
+				if err != nil {
+					return nil, fmt.Errorf("invalid default value for field %q of %s: %v", f.Name(), ref.TypeName, err)
+				}
+				continue
+			}
+			var err error
+			buf, err = appendAvroBinary(buf, fx, f.Type())
+			if err != nil {
+				return nil, fmt.Errorf("cannot encode field %q of %s: %v", f.Name(), ref.TypeName, err)
+			}
+		}
+		return buf, nil
+	case KindArray:
+		items, ok := x.([]interface{})
+		if !ok {
+			return nil, jsonTypeError(x, at)
+		}
+		itemType := at.(*schema.ArrayField).ItemType()
+		if len(items) > 0 {
+			buf = appendLong(buf, int64(len(items)))
+			for _, item := range items {
+				var err error
+				buf, err = appendAvroBinary(buf, item, itemType)
+				if err != nil {
+					return nil, err
+				}
+			}
+		}
+		return appendLong(buf, 0), nil
+	case KindMap:
+		obj, ok := x.(map[string]interface{})
+		if !ok {
+			return nil, jsonTypeError(x, at)
+		}
+		itemType := at.(*schema.MapField).ItemType()
+		if len(obj) > 0 {
+			keys := make([]string, 0, len(obj))
+			for key := range obj {
+				keys = append(keys, key)
+			}
+			sort.Strings(keys)
+			buf = appendLong(buf, int64(len(keys)))
+			for _, key := range keys {
+				buf = appendLong(buf, int64(len(key)))
+				buf = append(buf, key...)
+				var err error
+				buf, err = appendAvroBinary(buf, obj[key], itemType)
+				if err != nil {
+					return nil, fmt.Errorf("cannot encode map key %q: %v", key, err)
+				}
+			}
+		}
+		return appendLong(buf, 0), nil
+	case KindUnion:
+		items := at.(*schema.UnionField).ItemTypes()
+		if x == nil {
+			for i, item := range items {
+				if kindOf(item) == KindNull {
+					return appendLong(buf, int64(i)), nil
+				}
+			}
+			return nil, jsonTypeError(x, at)
+		}
+		obj, ok := x.(map[string]interface{})
+		if !ok || len(obj) != 1 {
+			return nil, jsonValueError(x, at, fmt.Errorf("union value must be null or an object with a single member"))
+		}
+		for name, mx := range obj {
+			for i, item := range items {
+				if unionMemberName(item) == name {
+					return appendAvroBinary(appendLong(buf, int64(i)), mx, item)
+				}
+			}
+			return nil, jsonValueError(x, at, fmt.Errorf("no member of union has type %q", name))
+		}
+	}
+	return nil, fmt.Errorf("unknown Avro type %s", schemaFragment(at))
+}
+
+// appendAvroDefault appends the binary encoding of the default value x,
+// as held in a schema, to buf. Default values are held in the Avro JSON
+// encoding, except that a union default holds a value of the union's
+// first member.
+func appendAvroDefault(buf []byte, x interface{}, at schema.AvroType) ([]byte, error) {
+	if at, ok := at.(*schema.UnionField); ok {
+		return appendAvroBinary(appendLong(buf, 0), x, at.ItemTypes()[0])
+	}
+	return appendAvroBinary(buf, x, at)
+}
+
+// readLong reads an Avro int or long from the start of data.
+func readLong(data []byte) (int64, []byte, error) {
+	var ux uint64
+	for i, shift := 0, uint(0); i < len(data) && i < 10; i, shift = i+1, shift+7 {
+		ux |= uint64(data[i]&0x7f) << shift
+		if data[i] < 0x80 {
+			return int64(ux>>1) ^ -int64(ux&1), data[i+1:], nil
+		}
+	}
+	if len(data) >= 10 {
+		return 0, nil, fmt.Errorf("invalid varint")
+	}
+	return 0, nil, io.ErrUnexpectedEOF
+}
+
+// readBytes reads an Avro bytes or string value from the start of data.
+func readBytes(data []byte) ([]byte, []byte, error) {
+	n, data, err := readLong(data)
+	if err != nil {
+		return nil, nil, err
+	}
+	if n < 0 {
+		return nil, nil, fmt.Errorf("negative length %d", n)
+	}
+	if n > int64(len(data)) {
+		return nil, nil, io.ErrUnexpectedEOF
+	}
+	return data[:n], data[n:], nil
+}
+
+// appendJSONString appends s to buf as a JSON string.
+func appendJSONString(buf []byte, s string) []byte {
+	// Marshaling a string never fails.
+	data, _ := json.Marshal(s)
+	return append(buf, data...)
+}
+
+// appendJSONBytes appends b to buf as a JSON string holding
+// a code point between U+0000 and U+00FF for each byte,
+// as Avro JSON represents bytes and fixed values.
+func appendJSONBytes(buf []byte, b []byte) []byte {
+	runes := make([]rune, len(b))
+	for i, c := range b {
+		runes[i] = rune(c)
+	}
+	return appendJSONString(buf, string(runes))
+}
+
+// appendJSONFloat appends the float x of the given bit size to buf.
+func appendJSONFloat(buf []byte, x float64, bits int) ([]byte, error) {
+	if math.IsNaN(x) || math.IsInf(x, 0) {
+		return nil, fmt.Errorf("cannot represent %v in JSON", x)
+	}
+	return strconv.AppendFloat(buf, x, 'g', -1, bits), nil
+}
+
+// jsonBytes returns the bytes represented by the JSON
+// value x, as described for appendJSONBytes.
+func jsonBytes(x interface{}) ([]byte, error) {
+	s, ok := x.(string)
+	if !ok {
+		return nil, fmt.Errorf("value is not a string")
+	}
+	b := make([]byte, 0, len(s))
+	for _, r := range s {
+		if r > 0xff {
+			return nil, fmt.Errorf("character %q out of range for bytes", r)
+		}
+		b = append(b, byte(r))
+	}
+	return b, nil
+}
+
+// jsonInt returns the value of the JSON number x
+// as an integer of the given bit size.
+func jsonInt(x interface{}, bits int) (int64, error) {
+	switch x := x.(type) {
+	case json.Number:
+		return strconv.ParseInt(string(x), 10, bits)
+	case float64:
+		// Default values in schemas are decoded as float64.
+		n := int64(x)
+		if float64(n) != x || bits == 32 && int64(int32(n)) != n {
+			return 0, fmt.Errorf("value out of range")
+		}
+		return n, nil
+	}
+	return 0, fmt.Errorf("value is not a number")
+}
+
+// jsonFloat returns the value of the JSON number x
+// as a float of the given bit size.
+func jsonFloat(x interface{}, bits int) (float64, error) {
+	switch x := x.(type) {
+	case json.Number:
+		return strconv.ParseFloat(string(x), bits)
+	case float64:
+		return x, nil
+	}
+	return 0, fmt.Errorf("value is not a number")
+}
+
+func jsonTypeError(x interface{}, at schema.AvroType) error {
+	return jsonValueError(x, at, fmt.Errorf("unexpected JSON value"))
+}
+
+func jsonValueError(x interface{}, at schema.AvroType, err error) error {
+	data, _ := json.Marshal(x)
+	return fmt.Errorf("cannot use %s as Avro type %s: %v", data, schemaFragment(at), err)
+}
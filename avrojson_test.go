@@ -0,0 +1,72 @@
+package avro_test
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro"
+)
+
+var avroJSONTests = []struct {
+	testName string
+	schema   string
+	binary   []byte
+	json     string
+}{{
+	testName: "int",
+	schema:   `"int"`,
+	binary:   []byte{0x28},
+	json:     `20`,
+}, {
+	testName: "union",
+	schema:   `["int", "string"]`,
+	binary:   []byte{2, 4, 'h', 'i'},
+	json:     `{"string":"hi"}`,
+}, {
+	testName: "array",
+	schema:   `{"type": "array", "items": "long"}`,
+	binary:   []byte{4, 2, 4, 0},
+	json:     `[1,2]`,
+}, {
+	testName: "record",
+	schema:   `{"type": "record", "name": "R", "fields": [{"name": "A", "type": ["null", "R"]}]}`,
+	binary:   []byte{2, 0},
+	json:     `{"A":{"R":{"A":null}}}`,
+}}
+
+func TestAvroJSON(t *testing.T) {
+	c := qt.New(t)
+	for _, test := range avroJSONTests {
+		c.Run(test.testName, func(c *qt.C) {
+			at, err := avro.ParseType(test.schema)
+			c.Assert(err, qt.Equals, nil)
+			data, err := avro.BinaryToJSON(test.binary, at)
+			c.Assert(err, qt.Equals, nil)
+			c.Assert(string(data), qt.Equals, test.json)
+			data, err = avro.JSONToBinary([]byte(test.json), at)
+			c.Assert(err, qt.Equals, nil)
+			c.Assert(data, qt.DeepEquals, test.binary)
+		})
+	}
+}
+
+func TestBinaryToJSONError(t *testing.T) {
+	c := qt.New(t)
+	at, err := avro.ParseType(`"string"`)
+	c.Assert(err, qt.Equals, nil)
+	_, err = avro.BinaryToJSON([]byte{4, 'h'}, at)
+	c.Assert(err, qt.ErrorMatches, `unexpected EOF`)
+	_, err = avro.BinaryToJSON([]byte{2, 'h', 'i'}, at)
+	c.Assert(err, qt.ErrorMatches, `unexpected data after value`)
+}
+
+func TestJSONToBinaryError(t *testing.T) {
+	c := qt.New(t)
+	at, err := avro.ParseType(`"int"`)
+	c.Assert(err, qt.Equals, nil)
+	_, err = avro.JSONToBinary([]byte(`"x"`), at)
+	c.Assert(err, qt.ErrorMatches, `cannot use "x" as Avro type .*`)
+	_, err = avro.JSONToBinary([]byte(`20 21`), at)
+	c.Assert(err, qt.ErrorMatches, `unexpected data after JSON value`)
+}
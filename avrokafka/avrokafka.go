@@ -0,0 +1,144 @@
+// Package avrokafka provides serializers and deserializers for Kafka
+// message values that use avro.SingleEncoder and avro.SingleDecoder, so
+// that messages carry the schema ID header of the registry they
+// were encoded with.
+//
+// The adapters work with both github.com/segmentio/kafka-go and
+// github.com/Shopify/sarama without depending on either of them.
+// With kafka-go, the serialized bytes are used as the Value of a
+// kafka.Message, and the Value of a message that has been read is
+// passed to Deserializer.Deserialize:
+//
+//	r, err := avroregistry.New(avroregistry.Params{ServerURL: "http://localhost:8084"})
+//	...
+//	ser, err := avrokafka.NewSerializer(ctx, avro.NewSingleEncoder(r.Encoder(avrokafka.ValueSubject("orders")), nil), Order{})
+//	...
+//	data, err := ser.Serialize(ctx, order)
+//	...
+//	err = w.WriteMessages(ctx, kafka.Message{Value: data})
+//
+// With sarama, the *Encoder returned by Serializer.Encoder implements
+// sarama.Encoder, so it can be used as the Value of a
+// sarama.ProducerMessage, and the Value of a sarama.ConsumerMessage
+// is passed to Deserializer.Deserialize:
+//
+//	value, err := ser.Encoder(ctx, order)
+//	...
+//	producer.Input() <- &sarama.ProducerMessage{Topic: "orders", Value: value}
+//
+// In a consumer group handler:
+//
+//	dec := avrokafka.NewDeserializer(avro.NewSingleDecoder(r.Decoder(), nil), Order{})
+//	for msg := range claim.Messages() {
+//		x, err := dec.Deserialize(ctx, msg.Value)
+//		...
+//		order := x.(Order)
+//	}
+package avrokafka
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/heetch/avro"
+)
+
+// ValueSubject returns the registry subject used for the schemas
+// of message values in the given topic, following the convention
+// used by the Confluent serializers.
+func ValueSubject(topic string) string {
+	return topic + "-value"
+}
+
+// KeySubject returns the registry subject used for the schemas
+// of message keys in the given topic.
+func KeySubject(topic string) string {
+	return topic + "-key"
+}
+
+// Serializer serializes values of a single Go type.
+type Serializer struct {
+	enc *avro.SingleEncoder
+	t   reflect.Type
+}
+
+// NewSerializer returns a Serializer that uses enc to serialize values
+// of the same type as x. It checks that the type can be encoded,
+// which finds its schema ID in the registry, so that problems
+// are found before any messages are produced.
+func NewSerializer(ctx context.Context, enc *avro.SingleEncoder, x interface{}) (*Serializer, error) {
+	if err := enc.CheckMarshalType(ctx, x); err != nil {
+		return nil, err
+	}
+	return &Serializer{
+		enc: enc,
+		t:   reflect.TypeOf(x),
+	}, nil
+}
+
+// Serialize returns x encoded with its schema ID header.
+// The type of x must be the type the Serializer was created with.
+func (s *Serializer) Serialize(ctx context.Context, x interface{}) ([]byte, error) {
+	if t := reflect.TypeOf(x); t != s.t {
+		return nil, fmt.Errorf("cannot serialize %v with serializer for %v", t, s.t)
+	}
+	return s.enc.Marshal(ctx, x)
+}
+
+// Encoder returns x serialized as by Serialize, in a form that
+// implements sarama.Encoder. The value is encoded immediately
+// because sarama.Encoder.Encode has no context argument and
+// its errors are only reported once the message has been sent
+// to the producer.
+func (s *Serializer) Encoder(ctx context.Context, x interface{}) (*Encoder, error) {
+	data, err := s.Serialize(ctx, x)
+	if err != nil {
+		return nil, err
+	}
+	return &Encoder{data: data}, nil
+}
+
+// Encoder holds a serialized value. It implements sarama.Encoder.
+type Encoder struct {
+	data []byte
+}
+
+// Encode implements sarama.Encoder.Encode by returning
+// the serialized value.
+func (e *Encoder) Encode() ([]byte, error) {
+	return e.data, nil
+}
+
+// Length implements sarama.Encoder.Length by returning
+// the length of the serialized value.
+func (e *Encoder) Length() int {
+	return len(e.data)
+}
+
+// Deserializer deserializes values of a single Go type.
+type Deserializer struct {
+	dec *avro.SingleDecoder
+	t   reflect.Type
+}
+
+// NewDeserializer returns a Deserializer that uses dec to
+// deserialize values into the type of x.
+func NewDeserializer(dec *avro.SingleDecoder, x interface{}) *Deserializer {
+	return &Deserializer{
+		dec: dec,
+		t:   reflect.TypeOf(x),
+	}
+}
+
+// Deserialize decodes data, which must start with a schema ID header,
+// and returns the resulting value, which has the type the Deserializer
+// was created with. The schema the data was written with is resolved
+// against the schema of that type.
+func (d *Deserializer) Deserialize(ctx context.Context, data []byte) (interface{}, error) {
+	v := reflect.New(d.t)
+	if _, err := d.dec.Unmarshal(ctx, data, v.Interface()); err != nil {
+		return nil, err
+	}
+	return v.Elem().Interface(), nil
+}
@@ -0,0 +1,121 @@
+package avrokafka_test
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro"
+	"github.com/heetch/avro/avrokafka"
+)
+
+type R struct {
+	A int
+	B string
+}
+
+// saramaEncoder mirrors the sarama.Encoder interface.
+type saramaEncoder interface {
+	Encode() ([]byte, error)
+	Length() int
+}
+
+var _ saramaEncoder = (*avrokafka.Encoder)(nil)
+
+func TestSerializeDeserialize(t *testing.T) {
+	c := qt.New(t)
+	ctx := context.Background()
+	registry := memRegistry{
+		3: mustTypeOf(R{}),
+	}
+	ser, err := avrokafka.NewSerializer(ctx, avro.NewSingleEncoder(registry, nil), R{})
+	c.Assert(err, qt.Equals, nil)
+	data, err := ser.Serialize(ctx, R{A: 20, B: "x"})
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(data, qt.DeepEquals, []byte{3, 40, 2, 'x'})
+
+	enc, err := ser.Encoder(ctx, R{A: 20, B: "x"})
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(enc.Length(), qt.Equals, len(data))
+	encData, err := enc.Encode()
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(encData, qt.DeepEquals, data)
+
+	dec := avrokafka.NewDeserializer(avro.NewSingleDecoder(registry, nil), R{})
+	x, err := dec.Deserialize(ctx, data)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(x, qt.Equals, R{A: 20, B: "x"})
+}
+
+func TestNewSerializerUnknownSchema(t *testing.T) {
+	c := qt.New(t)
+	_, err := avrokafka.NewSerializer(context.Background(), avro.NewSingleEncoder(memRegistry{}, nil), R{})
+	c.Assert(err, qt.ErrorMatches, `schema not found`)
+}
+
+func TestSerializeWrongType(t *testing.T) {
+	c := qt.New(t)
+	ctx := context.Background()
+	registry := memRegistry{
+		1: mustTypeOf(R{}),
+	}
+	ser, err := avrokafka.NewSerializer(ctx, avro.NewSingleEncoder(registry, nil), R{})
+	c.Assert(err, qt.Equals, nil)
+	_, err = ser.Serialize(ctx, &R{})
+	c.Assert(err, qt.ErrorMatches, `cannot serialize \*avrokafka_test.R with serializer for avrokafka_test.R`)
+}
+
+func TestDeserializeBadHeader(t *testing.T) {
+	c := qt.New(t)
+	dec := avrokafka.NewDeserializer(avro.NewSingleDecoder(memRegistry{}, nil), R{})
+	_, err := dec.Deserialize(context.Background(), nil)
+	c.Assert(err, qt.ErrorMatches, `cannot get schema ID from message`)
+}
+
+func TestSubjects(t *testing.T) {
+	c := qt.New(t)
+	c.Assert(avrokafka.ValueSubject("orders"), qt.Equals, "orders-value")
+	c.Assert(avrokafka.KeySubject("orders"), qt.Equals, "orders-key")
+}
+
+// memRegistry is a registry that prefixes messages with
+// a single byte schema ID.
+type memRegistry map[int64]*avro.Type
+
+func (m memRegistry) DecodeSchemaID(msg []byte) (int64, []byte) {
+	if len(msg) < 1 {
+		return 0, nil
+	}
+	return int64(msg[0]), msg[1:]
+}
+
+func (m memRegistry) SchemaForID(ctx context.Context, id int64) (*avro.Type, error) {
+	t, ok := m[id]
+	if !ok {
+		return nil, fmt.Errorf("schema not found for id %d", id)
+	}
+	return t, nil
+}
+
+func (m memRegistry) AppendSchemaID(buf []byte, id int64) []byte {
+	return append(buf, byte(id))
+}
+
+func (m memRegistry) IDForSchema(ctx context.Context, schema *avro.Type) (int64, error) {
+	for id, s := range m {
+		if s.String() == schema.String() {
+			return id, nil
+		}
+	}
+	return 0, fmt.Errorf("schema not found")
+}
+
+func mustTypeOf(x interface{}) *avro.Type {
+	t, err := avro.TypeOf(x)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
@@ -0,0 +1,70 @@
+// Package avrokinesis provides support for consuming Avro-encoded
+// data from AWS Kinesis streams: it implements the Kinesis Producer
+// Library (KPL) record aggregation format, and the message framing
+// used by the AWS Glue Schema Registry serializers.
+//
+// It doesn't depend on the AWS SDK. A Kinesis record's data
+// can be decoded into values in one call with a Deserializer:
+//
+//	registry := avrokinesis.NewGlueRegistry(avrokinesis.GlueParams{
+//		SchemaForVersionID: func(ctx context.Context, versionID [16]byte) (*avro.Type, error) {
+//			// Call glue.GetSchemaVersion and parse the result
+//			// with avro.ParseType.
+//		},
+//	})
+//	d := avrokinesis.NewDeserializer(avro.NewSingleDecoder(registry, nil), Order{})
+//	for _, rec := range out.Records {
+//		values, err := d.Deserialize(ctx, rec.Data)
+//		...
+//		for _, v := range values {
+//			order := v.(Order)
+//		}
+//	}
+package avrokinesis
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	"github.com/heetch/avro"
+)
+
+// Deserializer decodes the user records in Kinesis
+// records into values of a single Go type.
+type Deserializer struct {
+	dec *avro.SingleDecoder
+	t   reflect.Type
+}
+
+// NewDeserializer returns a Deserializer that uses dec to
+// decode user records into the type of x.
+func NewDeserializer(dec *avro.SingleDecoder, x interface{}) *Deserializer {
+	return &Deserializer{
+		dec: dec,
+		t:   reflect.TypeOf(x),
+	}
+}
+
+// Deserialize deaggregates data, which holds the data of a Kinesis
+// record, and decodes each user record within it, returning one value
+// for each, of the type the Deserializer was created with.
+// Data that isn't aggregated holds a single user record.
+func (d *Deserializer) Deserialize(ctx context.Context, data []byte) ([]interface{}, error) {
+	records, err := Deaggregate(data)
+	if err != nil {
+		return nil, err
+	}
+	values := make([]interface{}, len(records))
+	for i, r := range records {
+		v := reflect.New(d.t)
+		if _, err := d.dec.Unmarshal(ctx, r.Data, v.Interface()); err != nil {
+			if len(records) > 1 {
+				return nil, fmt.Errorf("cannot decode user record %d: %v", i, err)
+			}
+			return nil, err
+		}
+		values[i] = v.Elem().Interface()
+	}
+	return values, nil
+}
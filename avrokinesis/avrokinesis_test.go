@@ -0,0 +1,163 @@
+package avrokinesis_test
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"fmt"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro"
+	"github.com/heetch/avro/avrokinesis"
+)
+
+type R struct {
+	A int
+	B string
+}
+
+var versionID = [16]byte{0x12, 0x34, 15: 0x56}
+
+func TestAggregateDeaggregate(t *testing.T) {
+	c := qt.New(t)
+	records := []avrokinesis.Record{{
+		PartitionKey: "a",
+		Data:         []byte("one"),
+	}, {
+		PartitionKey:    "b",
+		ExplicitHashKey: "1234",
+		Data:            []byte("two"),
+	}, {
+		PartitionKey: "a",
+		Data:         []byte{},
+	}}
+	data := avrokinesis.Aggregate(records)
+	c.Assert(avrokinesis.IsAggregated(data), qt.Equals, true)
+	records1, err := avrokinesis.Deaggregate(data)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(records1, qt.DeepEquals, records)
+}
+
+func TestDeaggregateNotAggregated(t *testing.T) {
+	c := qt.New(t)
+	data := []byte("hello")
+	c.Assert(avrokinesis.IsAggregated(data), qt.Equals, false)
+	records, err := avrokinesis.Deaggregate(data)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(records, qt.DeepEquals, []avrokinesis.Record{{Data: data}})
+}
+
+func TestDeaggregateBadChecksum(t *testing.T) {
+	c := qt.New(t)
+	data := avrokinesis.Aggregate([]avrokinesis.Record{{
+		PartitionKey: "a",
+		Data:         []byte("one"),
+	}})
+	data[len(data)-1] ^= 0xff
+	_, err := avrokinesis.Deaggregate(data)
+	c.Assert(err, qt.ErrorMatches, `aggregated record has invalid checksum`)
+}
+
+func TestGlueRegistryDecodeSchemaID(t *testing.T) {
+	c := qt.New(t)
+	r := avrokinesis.NewGlueRegistry(avrokinesis.GlueParams{})
+	msg := glueMessage(0, []byte("body"))
+	id, body := r.DecodeSchemaID(msg)
+	c.Assert(id, qt.Equals, int64(1))
+	c.Assert(body, qt.DeepEquals, []byte("body"))
+
+	// The same schema version always gets the same ID.
+	id, _ = r.DecodeSchemaID(msg)
+	c.Assert(id, qt.Equals, int64(1))
+
+	// Compressed bodies are decompressed.
+	var buf bytes.Buffer
+	w := zlib.NewWriter(&buf)
+	w.Write([]byte("compressed body"))
+	w.Close()
+	id, body = r.DecodeSchemaID(glueMessage(5, buf.Bytes()))
+	c.Assert(id, qt.Equals, int64(1))
+	c.Assert(body, qt.DeepEquals, []byte("compressed body"))
+
+	// Invalid headers are rejected.
+	id, body = r.DecodeSchemaID([]byte{3, 0, 1})
+	c.Assert(id, qt.Equals, int64(0))
+	c.Assert(body, qt.IsNil)
+	id, body = r.DecodeSchemaID(glueMessage(2, []byte("body")))
+	c.Assert(id, qt.Equals, int64(0))
+	c.Assert(body, qt.IsNil)
+}
+
+func TestGlueRegistryUnknownID(t *testing.T) {
+	c := qt.New(t)
+	r := avrokinesis.NewGlueRegistry(avrokinesis.GlueParams{})
+	_, err := r.SchemaForID(context.Background(), 1)
+	c.Assert(err, qt.ErrorMatches, `unknown schema id 1`)
+}
+
+func TestDeserialize(t *testing.T) {
+	c := qt.New(t)
+	ctx := context.Background()
+	registry := avrokinesis.NewGlueRegistry(avrokinesis.GlueParams{
+		SchemaForVersionID: func(ctx context.Context, id [16]byte) (*avro.Type, error) {
+			if id != versionID {
+				return nil, fmt.Errorf("schema version not found")
+			}
+			return mustTypeOf(R{}), nil
+		},
+		VersionIDForSchema: func(ctx context.Context, schema *avro.Type) ([16]byte, error) {
+			return versionID, nil
+		},
+	})
+	enc := avro.NewSingleEncoder(registry, nil)
+	data1, err := enc.Marshal(ctx, R{A: 1, B: "x"})
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(data1, qt.DeepEquals, glueMessage(0, []byte{2, 2, 'x'}))
+	data2, err := enc.Marshal(ctx, R{A: 2, B: "y"})
+	c.Assert(err, qt.Equals, nil)
+
+	d := avrokinesis.NewDeserializer(avro.NewSingleDecoder(registry, nil), R{})
+	values, err := d.Deserialize(ctx, avrokinesis.Aggregate([]avrokinesis.Record{{
+		PartitionKey: "k",
+		Data:         data1,
+	}, {
+		PartitionKey: "k",
+		Data:         data2,
+	}}))
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(values, qt.DeepEquals, []interface{}{R{A: 1, B: "x"}, R{A: 2, B: "y"}})
+
+	// Records that aren't aggregated hold a single value.
+	values, err = d.Deserialize(ctx, data1)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(values, qt.DeepEquals, []interface{}{R{A: 1, B: "x"}})
+}
+
+func TestDeserializeBadRecord(t *testing.T) {
+	c := qt.New(t)
+	registry := avrokinesis.NewGlueRegistry(avrokinesis.GlueParams{})
+	d := avrokinesis.NewDeserializer(avro.NewSingleDecoder(registry, nil), R{})
+	_, err := d.Deserialize(context.Background(), avrokinesis.Aggregate([]avrokinesis.Record{{
+		PartitionKey: "k",
+		Data:         []byte("x"),
+	}, {
+		PartitionKey: "k",
+		Data:         []byte("y"),
+	}}))
+	c.Assert(err, qt.ErrorMatches, `cannot decode user record 0: cannot get schema ID from message`)
+}
+
+func glueMessage(compression byte, body []byte) []byte {
+	msg := append([]byte{3, compression}, versionID[:]...)
+	return append(msg, body...)
+}
+
+func mustTypeOf(x interface{}) *avro.Type {
+	t, err := avro.TypeOf(x)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
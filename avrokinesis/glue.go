@@ -0,0 +1,155 @@
+package avrokinesis
+
+import (
+	"bytes"
+	"compress/zlib"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"sync"
+
+	"github.com/heetch/avro"
+)
+
+// Bytes at the start of a message framed by the
+// AWS Glue Schema Registry serializers.
+const (
+	glueHeaderVersion   = 3
+	glueCompressionNone = 0
+	glueCompressionZlib = 5
+	glueHeaderSize      = 2 + 16
+)
+
+// GlueParams holds the functions used by a GlueRegistry to
+// talk to the AWS Glue Schema Registry. They're usually
+// implemented with the AWS SDK.
+type GlueParams struct {
+	// SchemaForVersionID returns the schema with the given
+	// schema version ID.
+	SchemaForVersionID func(ctx context.Context, versionID [16]byte) (*avro.Type, error)
+
+	// VersionIDForSchema returns the schema version ID for the
+	// given schema, registering it if needed. It's only needed
+	// when encoding.
+	VersionIDForSchema func(ctx context.Context, schema *avro.Type) ([16]byte, error)
+}
+
+// GlueRegistry implements avro.EncodingRegistry and avro.DecodingRegistry
+// for messages framed by the AWS Glue Schema Registry serializers,
+// which start with a header holding the 16-byte schema version ID.
+// Messages compressed with zlib are decompressed when decoding;
+// messages are never compressed when encoding.
+//
+// The avro package identifies schemas with int64 values, so a
+// GlueRegistry assigns an ID to each schema version ID it sees.
+// The IDs are only meaningful within a single GlueRegistry.
+type GlueRegistry struct {
+	params GlueParams
+
+	// mu guards the fields below.
+	mu         sync.Mutex
+	ids        map[[16]byte]int64
+	versionIDs [][16]byte
+}
+
+var (
+	_ avro.EncodingRegistry = (*GlueRegistry)(nil)
+	_ avro.DecodingRegistry = (*GlueRegistry)(nil)
+)
+
+// NewGlueRegistry returns a GlueRegistry that uses the given parameters.
+func NewGlueRegistry(p GlueParams) *GlueRegistry {
+	return &GlueRegistry{
+		params: p,
+		ids:    make(map[[16]byte]int64),
+	}
+}
+
+// idForVersionID returns the ID for the given schema version ID,
+// assigning a new one if needed. IDs start at 1 so
+// that they're never confused with an invalid message.
+func (r *GlueRegistry) idForVersionID(versionID [16]byte) int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if id, ok := r.ids[versionID]; ok {
+		return id
+	}
+	r.versionIDs = append(r.versionIDs, versionID)
+	id := int64(len(r.versionIDs))
+	r.ids[versionID] = id
+	return id
+}
+
+// versionIDForID returns the schema version ID for the given ID.
+func (r *GlueRegistry) versionIDForID(id int64) ([16]byte, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if id < 1 || id > int64(len(r.versionIDs)) {
+		return [16]byte{}, false
+	}
+	return r.versionIDs[id-1], true
+}
+
+// AppendSchemaID implements avro.EncodingRegistry.AppendSchemaID
+// by appending the Glue header for the schema version with the given ID.
+func (r *GlueRegistry) AppendSchemaID(buf []byte, id int64) []byte {
+	versionID, ok := r.versionIDForID(id)
+	if !ok {
+		panic("schema id out of range")
+	}
+	buf = append(buf, glueHeaderVersion, glueCompressionNone)
+	return append(buf, versionID[:]...)
+}
+
+// IDForSchema implements avro.EncodingRegistry.IDForSchema
+// by calling GlueParams.VersionIDForSchema.
+func (r *GlueRegistry) IDForSchema(ctx context.Context, schema *avro.Type) (int64, error) {
+	if r.params.VersionIDForSchema == nil {
+		return 0, fmt.Errorf("no VersionIDForSchema function provided")
+	}
+	versionID, err := r.params.VersionIDForSchema(ctx, schema)
+	if err != nil {
+		return 0, err
+	}
+	return r.idForVersionID(versionID), nil
+}
+
+// DecodeSchemaID implements avro.DecodingRegistry.DecodeSchemaID
+// by stripping off the Glue header, decompressing the
+// message body if needed.
+func (r *GlueRegistry) DecodeSchemaID(msg []byte) (int64, []byte) {
+	if len(msg) < glueHeaderSize || msg[0] != glueHeaderVersion {
+		return 0, nil
+	}
+	var versionID [16]byte
+	copy(versionID[:], msg[2:glueHeaderSize])
+	body := msg[glueHeaderSize:]
+	switch msg[1] {
+	case glueCompressionNone:
+	case glueCompressionZlib:
+		zr, err := zlib.NewReader(bytes.NewReader(body))
+		if err != nil {
+			return 0, nil
+		}
+		body, err = ioutil.ReadAll(zr)
+		if err != nil {
+			return 0, nil
+		}
+	default:
+		return 0, nil
+	}
+	return r.idForVersionID(versionID), body
+}
+
+// SchemaForID implements avro.DecodingRegistry.SchemaForID
+// by calling GlueParams.SchemaForVersionID.
+func (r *GlueRegistry) SchemaForID(ctx context.Context, id int64) (*avro.Type, error) {
+	versionID, ok := r.versionIDForID(id)
+	if !ok {
+		return nil, fmt.Errorf("unknown schema id %d", id)
+	}
+	if r.params.SchemaForVersionID == nil {
+		return nil, fmt.Errorf("no SchemaForVersionID function provided")
+	}
+	return r.params.SchemaForVersionID(ctx, versionID)
+}
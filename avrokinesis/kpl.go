@@ -0,0 +1,229 @@
+package avrokinesis
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/binary"
+	"fmt"
+)
+
+// Record holds a user record, as held inside a Kinesis record
+// aggregated by the Kinesis Producer Library (KPL).
+type Record struct {
+	PartitionKey    string
+	ExplicitHashKey string
+	Data            []byte
+}
+
+// kplMagic holds the bytes at the start of every aggregated record.
+var kplMagic = []byte{0xf3, 0x89, 0x9a, 0xc2}
+
+// Field numbers of the AggregatedRecord and Record protobuf messages.
+// See https://github.com/awslabs/amazon-kinesis-producer/blob/master/aggregation-format.md.
+const (
+	aggPartitionKeyTable    = 1
+	aggExplicitHashKeyTable = 2
+	aggRecords              = 3
+
+	recPartitionKeyIndex    = 1
+	recExplicitHashKeyIndex = 2
+	recData                 = 3
+)
+
+// Protobuf wire types.
+const (
+	wireVarint  = 0
+	wireFixed64 = 1
+	wireBytes   = 2
+	wireFixed32 = 5
+)
+
+// IsAggregated reports whether data holds a record
+// aggregated in the KPL format.
+func IsAggregated(data []byte) bool {
+	return len(data) >= len(kplMagic)+md5.Size && bytes.HasPrefix(data, kplMagic)
+}
+
+// Aggregate returns the given records aggregated into the data of a
+// single Kinesis record in the KPL format, so that they can be
+// consumed with any KPL-compatible consumer.
+func Aggregate(records []Record) []byte {
+	var keys, hashKeys []string
+	keyIndex := make(map[string]uint64)
+	hashKeyIndex := make(map[string]uint64)
+	var msg []byte
+	for _, r := range records {
+		i, ok := keyIndex[r.PartitionKey]
+		if !ok {
+			i = uint64(len(keys))
+			keyIndex[r.PartitionKey] = i
+			keys = append(keys, r.PartitionKey)
+		}
+		var rmsg []byte
+		rmsg = appendVarintField(rmsg, recPartitionKeyIndex, i)
+		if r.ExplicitHashKey != "" {
+			j, ok := hashKeyIndex[r.ExplicitHashKey]
+			if !ok {
+				j = uint64(len(hashKeys))
+				hashKeyIndex[r.ExplicitHashKey] = j
+				hashKeys = append(hashKeys, r.ExplicitHashKey)
+			}
+			rmsg = appendVarintField(rmsg, recExplicitHashKeyIndex, j)
+		}
+		rmsg = appendBytesField(rmsg, recData, r.Data)
+		msg = appendBytesField(msg, aggRecords, rmsg)
+	}
+	// The tables come first, as written by the KPL itself.
+	var tables []byte
+	for _, k := range keys {
+		tables = appendBytesField(tables, aggPartitionKeyTable, []byte(k))
+	}
+	for _, k := range hashKeys {
+		tables = appendBytesField(tables, aggExplicitHashKeyTable, []byte(k))
+	}
+	msg = append(tables, msg...)
+	sum := md5.Sum(msg)
+	data := make([]byte, 0, len(kplMagic)+len(msg)+len(sum))
+	data = append(data, kplMagic...)
+	data = append(data, msg...)
+	return append(data, sum[:]...)
+}
+
+// Deaggregate returns the user records held in data, which holds
+// the data of a Kinesis record. If data isn't in the KPL aggregated
+// format, it returns a single record holding data with no
+// partition key, because the partition key of a Kinesis record isn't
+// part of its data.
+//
+// The data of the returned records refers to the same underlying
+// bytes as data.
+func Deaggregate(data []byte) ([]Record, error) {
+	if !IsAggregated(data) {
+		return []Record{{Data: data}}, nil
+	}
+	msg := data[len(kplMagic) : len(data)-md5.Size]
+	if sum := md5.Sum(msg); !bytes.Equal(sum[:], data[len(data)-md5.Size:]) {
+		return nil, fmt.Errorf("aggregated record has invalid checksum")
+	}
+	var keys, hashKeys []string
+	type rawRecord struct {
+		keyIndex     uint64
+		hashKeyIndex uint64
+		hasHashKey   bool
+		data         []byte
+	}
+	var raws []rawRecord
+	err := parseMessage(msg, func(field int, v uint64, b []byte) error {
+		switch field {
+		case aggPartitionKeyTable:
+			keys = append(keys, string(b))
+		case aggExplicitHashKeyTable:
+			hashKeys = append(hashKeys, string(b))
+		case aggRecords:
+			var r rawRecord
+			err := parseMessage(b, func(field int, v uint64, b []byte) error {
+				switch field {
+				case recPartitionKeyIndex:
+					r.keyIndex = v
+				case recExplicitHashKeyIndex:
+					r.hashKeyIndex, r.hasHashKey = v, true
+				case recData:
+					r.data = b
+				}
+				return nil
+			})
+			if err != nil {
+				return fmt.Errorf("invalid user record: %v", err)
+			}
+			raws = append(raws, r)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("invalid aggregated record: %v", err)
+	}
+	records := make([]Record, len(raws))
+	for i, r := range raws {
+		if r.keyIndex >= uint64(len(keys)) {
+			return nil, fmt.Errorf("user record %d has out of range partition key index %d", i, r.keyIndex)
+		}
+		records[i] = Record{
+			PartitionKey: keys[r.keyIndex],
+			Data:         r.data,
+		}
+		if r.hasHashKey {
+			if r.hashKeyIndex >= uint64(len(hashKeys)) {
+				return nil, fmt.Errorf("user record %d has out of range explicit hash key index %d", i, r.hashKeyIndex)
+			}
+			records[i].ExplicitHashKey = hashKeys[r.hashKeyIndex]
+		}
+	}
+	return records, nil
+}
+
+func appendVarintField(buf []byte, field int, v uint64) []byte {
+	buf = appendUvarint(buf, uint64(field<<3|wireVarint))
+	return appendUvarint(buf, v)
+}
+
+func appendBytesField(buf []byte, field int, b []byte) []byte {
+	buf = appendUvarint(buf, uint64(field<<3|wireBytes))
+	buf = appendUvarint(buf, uint64(len(b)))
+	return append(buf, b...)
+}
+
+func appendUvarint(buf []byte, v uint64) []byte {
+	var tmp [binary.MaxVarintLen64]byte
+	n := binary.PutUvarint(tmp[:], v)
+	return append(buf, tmp[:n]...)
+}
+
+// parseMessage calls f for each field in the protobuf message msg,
+// with the value of varint fields in v and the contents
+// of length-delimited fields in b. Fields of other
+// wire types are skipped.
+func parseMessage(msg []byte, f func(field int, v uint64, b []byte) error) error {
+	for len(msg) > 0 {
+		key, n := binary.Uvarint(msg)
+		if n <= 0 {
+			return fmt.Errorf("invalid field key")
+		}
+		msg = msg[n:]
+		field := int(key >> 3)
+		var v uint64
+		var b []byte
+		switch key & 7 {
+		case wireVarint:
+			v, n = binary.Uvarint(msg)
+			if n <= 0 {
+				return fmt.Errorf("invalid varint in field %d", field)
+			}
+			msg = msg[n:]
+		case wireBytes:
+			size, n := binary.Uvarint(msg)
+			if n <= 0 || size > uint64(len(msg)-n) {
+				return fmt.Errorf("invalid length in field %d", field)
+			}
+			b = msg[n : n+int(size)]
+			msg = msg[n+int(size):]
+		case wireFixed64:
+			if len(msg) < 8 {
+				return fmt.Errorf("truncated field %d", field)
+			}
+			msg = msg[8:]
+			continue
+		case wireFixed32:
+			if len(msg) < 4 {
+				return fmt.Errorf("truncated field %d", field)
+			}
+			msg = msg[4:]
+			continue
+		default:
+			return fmt.Errorf("unsupported wire type %d in field %d", key&7, field)
+		}
+		if err := f(field, v, b); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,37 @@
+// Package avroocf reads and writes Avro object container files, as
+// described in https://avro.apache.org/docs/1.9.1/spec.html#Object+Container+Files
+//
+// Only the null and deflate codecs are currently supported.
+// The header of a file that uses another codec can still be read,
+// but its blocks can't.
+package avroocf
+
+import (
+	"github.com/heetch/avro"
+	"github.com/heetch/avro/internal/ocf"
+)
+
+const (
+	// NullCodec is the codec name used for uncompressed blocks.
+	NullCodec = ocf.NullCodec
+
+	// DeflateCodec is the codec name used for blocks compressed
+	// with the deflate algorithm (RFC 1951).
+	DeflateCodec = ocf.DeflateCodec
+)
+
+// Header holds the header of an object container file.
+type Header struct {
+	// Schema holds the schema of the values in the file.
+	Schema *avro.Type
+
+	// Codec holds the name of the codec used to compress blocks.
+	Codec string
+
+	// Meta holds all the metadata in the header, including
+	// the avro.schema and avro.codec entries.
+	Meta map[string][]byte
+
+	// Sync holds the sync marker that follows each block.
+	Sync [16]byte
+}
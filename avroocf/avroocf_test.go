@@ -0,0 +1,196 @@
+package avroocf_test
+
+import (
+	"bytes"
+	"io"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro"
+	"github.com/heetch/avro/avroocf"
+)
+
+type R struct {
+	A int
+	B string
+}
+
+type S struct {
+	A int
+}
+
+func TestRoundTrip(t *testing.T) {
+	c := qt.New(t)
+	rType, err := avro.TypeOf(R{})
+	c.Assert(err, qt.Equals, nil)
+	for _, codec := range []string{"", avroocf.NullCodec, avroocf.DeflateCodec} {
+		c.Run(codec, func(c *qt.C) {
+			var buf bytes.Buffer
+			w, err := avroocf.NewWriter(&buf, rType, &avroocf.WriterOptions{
+				Codec: codec,
+				Meta: map[string][]byte{
+					"user.key": []byte("value"),
+				},
+				BlockSize: 10,
+			})
+			c.Assert(err, qt.Equals, nil)
+			var values []R
+			for i := 0; i < 5; i++ {
+				v := R{A: i, B: "hello"}
+				c.Assert(w.Write(v), qt.Equals, nil)
+				values = append(values, v)
+			}
+			c.Assert(w.Close(), qt.Equals, nil)
+
+			r, err := avroocf.NewReader(&buf)
+			c.Assert(err, qt.Equals, nil)
+			h := r.Header()
+			c.Assert(avro.Equal(h.Schema, rType), qt.Equals, true)
+			if codec == "" {
+				codec = avroocf.NullCodec
+			}
+			c.Assert(h.Codec, qt.Equals, codec)
+			c.Assert(string(h.Meta["user.key"]), qt.Equals, "value")
+			c.Assert(h.Sync, qt.Equals, w.Header().Sync)
+
+			var got []R
+			nblocks := 0
+			for {
+				b, err := r.NextBlock()
+				if err == io.EOF {
+					break
+				}
+				c.Assert(err, qt.Equals, nil)
+				nblocks++
+				var vs []R
+				c.Assert(b.Decode(&vs), qt.Equals, nil)
+				c.Assert(vs, qt.HasLen, b.Count)
+				got = append(got, vs...)
+			}
+			// Each value is 7 bytes, so there are two in each block.
+			c.Assert(nblocks, qt.Equals, 3)
+			c.Assert(got, qt.DeepEquals, values)
+		})
+	}
+}
+
+func TestDecodeWithReaderType(t *testing.T) {
+	c := qt.New(t)
+	var buf bytes.Buffer
+	wType, err := avro.TypeOf(R{})
+	c.Assert(err, qt.Equals, nil)
+	w, err := avroocf.NewWriter(&buf, wType, nil)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(w.Write(R{A: 1, B: "x"}), qt.Equals, nil)
+	c.Assert(w.Close(), qt.Equals, nil)
+
+	type R struct {
+		B string
+	}
+	r, err := avroocf.NewReader(&buf)
+	c.Assert(err, qt.Equals, nil)
+	b, err := r.NextBlock()
+	c.Assert(err, qt.Equals, nil)
+	var vs []R
+	c.Assert(b.Decode(&vs), qt.Equals, nil)
+	c.Assert(vs, qt.DeepEquals, []R{{B: "x"}})
+}
+
+type Pet interface{}
+
+type Cat struct {
+	Lives int
+}
+
+type Dog struct {
+	Name string
+}
+
+func init() {
+	avro.RegisterUnion((*Pet)(nil), nil, Cat{}, Dog{})
+}
+
+func TestRoundTripWithUnions(t *testing.T) {
+	c := qt.New(t)
+	type U struct {
+		A avro.OptionalString
+		B Pet
+		C []Pet
+	}
+	uType, err := avro.TypeOf(U{})
+	c.Assert(err, qt.Equals, nil)
+	var buf bytes.Buffer
+	w, err := avroocf.NewWriter(&buf, uType, nil)
+	c.Assert(err, qt.Equals, nil)
+	values := []U{{
+		A: avro.OptionalString{Value: "x", Valid: true},
+		B: Cat{Lives: 9},
+		C: []Pet{Dog{Name: "rex"}, nil},
+	}, {
+		B: Dog{Name: "fido"},
+	}}
+	for _, v := range values {
+		c.Assert(w.Write(v), qt.Equals, nil)
+	}
+	c.Assert(w.Close(), qt.Equals, nil)
+
+	r, err := avroocf.NewReader(&buf)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(avro.Equal(r.Header().Schema, uType), qt.Equals, true)
+	b, err := r.NextBlock()
+	c.Assert(err, qt.Equals, nil)
+	var vs []U
+	c.Assert(b.Decode(&vs), qt.Equals, nil)
+	c.Assert(vs, qt.DeepEquals, values)
+}
+
+func TestWriterErrors(t *testing.T) {
+	c := qt.New(t)
+	rType, err := avro.TypeOf(R{})
+	c.Assert(err, qt.Equals, nil)
+	_, err = avroocf.NewWriter(new(bytes.Buffer), rType, &avroocf.WriterOptions{
+		Codec: "snappy",
+	})
+	c.Assert(err, qt.ErrorMatches, `unsupported codec "snappy"`)
+
+	_, err = avroocf.NewWriter(new(bytes.Buffer), rType, &avroocf.WriterOptions{
+		Meta: map[string][]byte{"avro.codec": nil},
+	})
+	c.Assert(err, qt.ErrorMatches, `metadata key "avro.codec" is reserved`)
+
+	w, err := avroocf.NewWriter(new(bytes.Buffer), rType, nil)
+	c.Assert(err, qt.Equals, nil)
+	err = w.Write(S{})
+	c.Assert(err, qt.ErrorMatches, `type of avroocf_test.S does not match file schema`)
+}
+
+func TestReaderErrors(t *testing.T) {
+	c := qt.New(t)
+	_, err := avroocf.NewReader(bytes.NewReader([]byte("Obj\x02")))
+	c.Assert(err, qt.ErrorMatches, `not an Avro object container file`)
+
+	_, err = avroocf.NewReader(bytes.NewReader([]byte("Obj\x01\x02")))
+	c.Assert(err, qt.ErrorMatches, `cannot read file header: unexpected EOF`)
+
+	rType, err := avro.TypeOf(R{})
+	c.Assert(err, qt.Equals, nil)
+	var buf bytes.Buffer
+	w, err := avroocf.NewWriter(&buf, rType, nil)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(w.Write(R{A: 1}), qt.Equals, nil)
+	c.Assert(w.Close(), qt.Equals, nil)
+
+	// Corrupt the sync marker at the end of the block.
+	data := buf.Bytes()
+	data[len(data)-1] ^= 1
+	r, err := avroocf.NewReader(bytes.NewReader(data))
+	c.Assert(err, qt.Equals, nil)
+	_, err = r.NextBlock()
+	c.Assert(err, qt.ErrorMatches, `block has invalid sync marker`)
+
+	r, err = avroocf.NewReader(bytes.NewReader(data[:len(data)-5]))
+	c.Assert(err, qt.Equals, nil)
+	_, err = r.NextBlock()
+	c.Assert(err, qt.ErrorMatches, `cannot read block: unexpected EOF`)
+}
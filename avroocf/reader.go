@@ -0,0 +1,110 @@
+package avroocf
+
+import (
+	"bufio"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/heetch/avro"
+	"github.com/heetch/avro/internal/ocf"
+)
+
+// Reader reads values from an object container file.
+type Reader struct {
+	r      *bufio.Reader
+	header Header
+	oh     *ocf.Header
+
+	// arrayType holds the type of an array of values in the
+	// file. It's created when first needed by Block.Decode.
+	arrayType *avro.Type
+}
+
+// Block holds a block of values read from an object container file.
+type Block struct {
+	// Count holds the number of values in the block.
+	Count int
+
+	// Size holds the size of the block's data in the file,
+	// after compression.
+	Size int
+
+	// Data holds the binary encoding of the values in the block,
+	// one after another, after decompression.
+	Data []byte
+
+	r *Reader
+}
+
+// NewReader returns a Reader that reads from r.
+// It reads the file header immediately.
+func NewReader(r io.Reader) (*Reader, error) {
+	br := bufio.NewReader(r)
+	oh, err := ocf.ReadHeader(br)
+	if err != nil {
+		return nil, err
+	}
+	schema, err := avro.ParseType(string(oh.Schema))
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema in file header: %v", err)
+	}
+	return &Reader{
+		r: br,
+		header: Header{
+			Schema: schema,
+			Codec:  oh.Codec,
+			Meta:   oh.Meta,
+			Sync:   oh.Sync,
+		},
+		oh: oh,
+	}, nil
+}
+
+// Header returns the header of the file.
+func (r *Reader) Header() *Header {
+	return &r.header
+}
+
+// NextBlock reads the next block from the file.
+// It returns io.EOF when there are no more blocks.
+func (r *Reader) NextBlock() (*Block, error) {
+	count, size, data, err := ocf.ReadBlock(r.r, r.oh)
+	if err != nil {
+		return nil, err
+	}
+	return &Block{
+		Count: count,
+		Size:  size,
+		Data:  data,
+		r:     r,
+	}, nil
+}
+
+// Decode decodes all the values in the block into x, which must be
+// a pointer to a slice. The element type of the slice must be
+// compatible with the file's schema, as for avro.Unmarshal.
+func (b *Block) Decode(x interface{}) error {
+	if v := reflect.ValueOf(x); v.Kind() != reflect.Ptr || v.Elem().Kind() != reflect.Slice {
+		return fmt.Errorf("destination is not a pointer to a slice %T", x)
+	}
+	if b.r.arrayType == nil {
+		t, err := avro.ParseType(fmt.Sprintf(`{"type": "array", "items": %s}`, b.r.header.Schema))
+		if err != nil {
+			return err
+		}
+		b.r.arrayType = t
+	}
+	// The values in a block are encoded in the same way as the
+	// items in a single block of an array, so decode the block
+	// as an array of values.
+	buf := make([]byte, binary.MaxVarintLen64, binary.MaxVarintLen64+len(b.Data)+1)
+	buf = buf[:binary.PutVarint(buf, int64(b.Count))]
+	buf = append(buf, b.Data...)
+	buf = append(buf, 0)
+	if _, err := avro.Unmarshal(buf, x, b.r.arrayType); err != nil {
+		return fmt.Errorf("cannot decode block: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,149 @@
+package avroocf
+
+import (
+	"crypto/rand"
+	"fmt"
+	"io"
+
+	"github.com/heetch/avro"
+	"github.com/heetch/avro/internal/ocf"
+)
+
+// DefaultBlockSize holds the default approximate size in bytes of
+// the uncompressed data in each block written by a Writer.
+const DefaultBlockSize = 64 * 1024
+
+// WriterOptions holds options for NewWriter.
+type WriterOptions struct {
+	// Codec holds the codec used to compress blocks.
+	// If it's empty, NullCodec is used.
+	Codec string
+
+	// Meta holds additional metadata to store in the header.
+	// Keys starting with "avro." are reserved.
+	Meta map[string][]byte
+
+	// BlockSize holds the approximate size of the uncompressed
+	// data in each block. If it's zero, DefaultBlockSize is used.
+	BlockSize int
+}
+
+// Writer writes values to an object container file.
+type Writer struct {
+	w         io.Writer
+	header    Header
+	oh        ocf.Header
+	canonical string
+	blockSize int
+
+	// block holds the encoded values in the current block.
+	block []byte
+	count int
+}
+
+// NewWriter returns a Writer that writes values of type t to w.
+// It writes the file header immediately.
+// If opts is nil, the zero value is used.
+func NewWriter(w io.Writer, t *avro.Type, opts *WriterOptions) (*Writer, error) {
+	if opts == nil {
+		opts = new(WriterOptions)
+	}
+	codec := opts.Codec
+	if codec == "" {
+		codec = NullCodec
+	}
+	if err := ocf.CheckCodec(codec); err != nil {
+		return nil, err
+	}
+	meta := make(map[string][]byte)
+	for key, val := range opts.Meta {
+		if len(key) >= 5 && key[:5] == "avro." {
+			return nil, fmt.Errorf("metadata key %q is reserved", key)
+		}
+		meta[key] = val
+	}
+	meta[ocf.SchemaKey] = []byte(t.String())
+	meta[ocf.CodecKey] = []byte(codec)
+	ow := &Writer{
+		w: w,
+		header: Header{
+			Schema: t,
+			Codec:  codec,
+			Meta:   meta,
+		},
+		canonical: t.CanonicalString(avro.RetainLogicalTypes),
+		blockSize: opts.BlockSize,
+	}
+	if ow.blockSize <= 0 {
+		ow.blockSize = DefaultBlockSize
+	}
+	if _, err := rand.Read(ow.header.Sync[:]); err != nil {
+		return nil, err
+	}
+	ow.oh = ocf.Header{
+		Codec: codec,
+		Meta:  meta,
+		Sync:  ow.header.Sync,
+	}
+	if _, err := w.Write(ocf.AppendHeader(nil, &ow.oh)); err != nil {
+		return nil, err
+	}
+	return ow, nil
+}
+
+// Header returns the header of the file.
+func (w *Writer) Header() *Header {
+	return &w.header
+}
+
+// Write writes a value to the file. The Avro type of x,
+// as returned by avro.TypeOf, must be the same as the
+// file's schema.
+//
+// The value is buffered until the current block is full
+// or Flush is called.
+func (w *Writer) Write(x interface{}) error {
+	data, t, err := avro.Marshal(x)
+	if err != nil {
+		return err
+	}
+	if t.CanonicalString(avro.RetainLogicalTypes) != w.canonical {
+		return fmt.Errorf("type of %T does not match file schema", x)
+	}
+	return w.WriteEncoded(data)
+}
+
+// WriteEncoded is like Write except that data holds a value that's
+// already in the Avro binary encoding of the file's schema.
+// The data isn't checked.
+func (w *Writer) WriteEncoded(data []byte) error {
+	w.block = append(w.block, data...)
+	w.count++
+	if len(w.block) >= w.blockSize {
+		return w.Flush()
+	}
+	return nil
+}
+
+// Flush writes any buffered values to the file as a block.
+func (w *Writer) Flush() error {
+	if w.count == 0 {
+		return nil
+	}
+	buf, err := ocf.AppendBlock(nil, &w.oh, w.count, w.block)
+	if err != nil {
+		return err
+	}
+	if _, err := w.w.Write(buf); err != nil {
+		return err
+	}
+	w.block = w.block[:0]
+	w.count = 0
+	return nil
+}
+
+// Close flushes any buffered values. It does not
+// close the underlying writer.
+func (w *Writer) Close() error {
+	return w.Flush()
+}
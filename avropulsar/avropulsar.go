@@ -0,0 +1,141 @@
+// Package avropulsar provides support for Apache Pulsar's Avro schema
+// conventions. Pulsar carries the schema version of each message in
+// the message metadata, so message payloads hold plain Avro binary
+// data, and producers and consumers describe their schema with a
+// SchemaInfo that holds the schema's JSON definition.
+//
+// Schema mirrors the Schema interface of
+// github.com/apache/pulsar-client-go/pulsar without depending on it.
+// Its methods have the same signatures, apart from GetSchemaInfo,
+// which returns a *SchemaInfo from this package, so a small
+// adapter is needed to use it with the Pulsar client:
+//
+//	type pulsarSchema struct {
+//		*avropulsar.Schema
+//	}
+//
+//	func (s pulsarSchema) GetSchemaInfo() *pulsar.SchemaInfo {
+//		info := s.Schema.GetSchemaInfo()
+//		return &pulsar.SchemaInfo{
+//			Name:       info.Name,
+//			Schema:     info.Schema,
+//			Type:       pulsar.SchemaType(info.Type),
+//			Properties: info.Properties,
+//		}
+//	}
+//
+//	s, err := avropulsar.NewSchema(Order{})
+//	...
+//	producer, err := client.CreateProducer(pulsar.ProducerOptions{
+//		Topic:  "orders",
+//		Schema: pulsarSchema{s},
+//	})
+package avropulsar
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/heetch/avro"
+)
+
+// AvroSchemaType holds the Pulsar schema type for Avro schemas.
+// It's the same as the value of pulsar.AVRO.
+const AvroSchemaType = 4
+
+// SchemaInfo holds the information Pulsar stores for a schema.
+// It has the same fields as pulsar.SchemaInfo.
+type SchemaInfo struct {
+	Name       string
+	Schema     string
+	Type       int
+	Properties map[string]string
+}
+
+// NewSchemaInfo returns the Pulsar schema information for t.
+// The name is the full name of t, or empty
+// when t isn't a named type.
+func NewSchemaInfo(t *avro.Type) *SchemaInfo {
+	return &SchemaInfo{
+		Name:       t.Name(),
+		Schema:     t.String(),
+		Type:       AvroSchemaType,
+		Properties: make(map[string]string),
+	}
+}
+
+// Schema implements Pulsar's Schema interface for values
+// of a single Go type.
+type Schema struct {
+	t        reflect.Type
+	avroType *avro.Type
+	info     *SchemaInfo
+}
+
+// NewSchema returns a Schema for values of the same
+// type as x.
+func NewSchema(x interface{}) (*Schema, error) {
+	avroType, err := avro.TypeOf(x)
+	if err != nil {
+		return nil, err
+	}
+	return &Schema{
+		t:        reflect.TypeOf(x),
+		avroType: avroType,
+		info:     NewSchemaInfo(avroType),
+	}, nil
+}
+
+// Encode implements pulsar.Schema.Encode by returning v encoded as
+// Avro binary data. The type of v must be the type the Schema was
+// created with, or a pointer to it.
+func (s *Schema) Encode(v interface{}) ([]byte, error) {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() == reflect.Ptr && rv.Type().Elem() == s.t {
+		if rv.IsNil() {
+			return nil, fmt.Errorf("cannot encode nil %v", rv.Type())
+		}
+		v = rv.Elem().Interface()
+	} else if t := reflect.TypeOf(v); t != s.t {
+		return nil, fmt.Errorf("cannot encode %v with schema for %v", t, s.t)
+	}
+	data, _, err := avro.Marshal(v)
+	return data, err
+}
+
+// Decode implements pulsar.Schema.Decode by decoding data, which
+// must have been written with the Schema's Avro type, into v,
+// which must be a pointer to the type the Schema was created with.
+func (s *Schema) Decode(data []byte, v interface{}) error {
+	return s.DecodeWithType(data, v, s.avroType)
+}
+
+// DecodeWithType is like Decode except that data was written
+// with wType, which is resolved against the Schema's type. This can
+// be used to decode messages produced with older versions of
+// the schema.
+func (s *Schema) DecodeWithType(data []byte, v interface{}, wType *avro.Type) error {
+	if t := reflect.TypeOf(v); t != reflect.PtrTo(s.t) {
+		return fmt.Errorf("cannot decode into %v with schema for %v", t, s.t)
+	}
+	_, err := avro.Unmarshal(data, v, wType)
+	return err
+}
+
+// Validate implements pulsar.Schema.Validate by checking
+// that message can be decoded.
+func (s *Schema) Validate(message []byte) error {
+	return s.Decode(message, reflect.New(s.t).Interface())
+}
+
+// GetSchemaInfo returns the schema information for the Schema.
+// Unlike pulsar.Schema.GetSchemaInfo, it returns a *SchemaInfo from
+// this package; see the package documentation for how to adapt it.
+func (s *Schema) GetSchemaInfo() *SchemaInfo {
+	return s.info
+}
+
+// Type returns the Avro type of the Schema.
+func (s *Schema) Type() *avro.Type {
+	return s.avroType
+}
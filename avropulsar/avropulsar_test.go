@@ -0,0 +1,100 @@
+package avropulsar_test
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro"
+	"github.com/heetch/avro/avropulsar"
+)
+
+type R struct {
+	A int
+	B string
+}
+
+// pulsarSchema mirrors the pulsar.Schema interface,
+// apart from GetSchemaInfo.
+type pulsarSchema interface {
+	Encode(v interface{}) ([]byte, error)
+	Decode(data []byte, v interface{}) error
+	Validate(message []byte) error
+}
+
+var _ pulsarSchema = (*avropulsar.Schema)(nil)
+
+func TestEncodeDecode(t *testing.T) {
+	c := qt.New(t)
+	s, err := avropulsar.NewSchema(R{})
+	c.Assert(err, qt.Equals, nil)
+	data, err := s.Encode(R{A: 20, B: "x"})
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(data, qt.DeepEquals, []byte{40, 2, 'x'})
+
+	data1, err := s.Encode(&R{A: 20, B: "x"})
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(data1, qt.DeepEquals, data)
+
+	c.Assert(s.Validate(data), qt.Equals, nil)
+	var x R
+	err = s.Decode(data, &x)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(x, qt.Equals, R{A: 20, B: "x"})
+}
+
+func TestDecodeWithType(t *testing.T) {
+	c := qt.New(t)
+	data, wType := func() ([]byte, *avro.Type) {
+		// An older version of R without the B field.
+		type R struct {
+			A int
+		}
+		data, wType, err := avro.Marshal(R{A: 20})
+		c.Assert(err, qt.Equals, nil)
+		return data, wType
+	}()
+	s, err := avropulsar.NewSchema(R{})
+	c.Assert(err, qt.Equals, nil)
+	var x R
+	err = s.DecodeWithType(data, &x, wType)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(x, qt.Equals, R{A: 20})
+}
+
+func TestSchemaInfo(t *testing.T) {
+	c := qt.New(t)
+	s, err := avropulsar.NewSchema(R{})
+	c.Assert(err, qt.Equals, nil)
+	info := s.GetSchemaInfo()
+	c.Assert(info.Name, qt.Equals, "R")
+	c.Assert(info.Type, qt.Equals, avropulsar.AvroSchemaType)
+	c.Assert(info.Schema, qt.JSONEquals, map[string]interface{}{
+		"type": "record",
+		"name": "R",
+		"fields": []interface{}{
+			map[string]interface{}{"name": "A", "type": "long", "default": 0},
+			map[string]interface{}{"name": "B", "type": "string", "default": ""},
+		},
+	})
+	c.Assert(info.Properties, qt.DeepEquals, map[string]string{})
+}
+
+func TestEncodeWrongType(t *testing.T) {
+	c := qt.New(t)
+	s, err := avropulsar.NewSchema(R{})
+	c.Assert(err, qt.Equals, nil)
+	_, err = s.Encode("x")
+	c.Assert(err, qt.ErrorMatches, `cannot encode string with schema for avropulsar_test.R`)
+	_, err = s.Encode((*R)(nil))
+	c.Assert(err, qt.ErrorMatches, `cannot encode nil \*avropulsar_test.R`)
+}
+
+func TestDecodeWrongType(t *testing.T) {
+	c := qt.New(t)
+	s, err := avropulsar.NewSchema(R{})
+	c.Assert(err, qt.Equals, nil)
+	var x string
+	err = s.Decode([]byte{40, 2, 'x'}, &x)
+	c.Assert(err, qt.ErrorMatches, `cannot decode into \*string with schema for avropulsar_test.R`)
+}
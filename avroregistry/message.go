@@ -88,7 +88,7 @@ func (r decodingRegistry) SchemaForID(ctx context.Context, id int64) (*avro.Type
 	}
 	t, err := avro.ParseType(resp.Schema)
 	if err != nil {
-		return nil, fmt.Errorf("invalid schema (%q) in response: %v", resp.Schema, err)
+		return nil, fmt.Errorf("invalid schema (%q) in response: %w", resp.Schema, err)
 	}
 	return t, nil
 }
@@ -99,6 +99,40 @@ func (r *Registry) Register(ctx context.Context, subject string, schema *avro.Ty
 	return resp.ID, nil
 }
 
+// Versions returns the versions of the schemas registered
+// with the given subject, in ascending order.
+//
+// See https://docs.confluent.io/current/schema-registry/develop/api.html#get--subjects-(string-%20subject)-versions
+func (r *Registry) Versions(ctx context.Context, subject string) ([]int, error) {
+	var versions []int
+	if err := r.doRequest(r.newRequest(ctx, "GET", fmt.Sprintf("/subjects/%s/versions", subject), nil), &versions); err != nil {
+		return nil, err
+	}
+	return versions, nil
+}
+
+// Schema returns the schema registered with the given subject
+// and version. If version is zero, the latest version is returned.
+//
+// See https://docs.confluent.io/current/schema-registry/develop/api.html#get--subjects-(string-%20subject)-versions-(versionId-%20version)
+func (r *Registry) Schema(ctx context.Context, subject string, version int) (*avro.Type, error) {
+	v := "latest"
+	if version != 0 {
+		v = fmt.Sprint(version)
+	}
+	var resp struct {
+		Schema string `json:"schema"`
+	}
+	if err := r.doRequest(r.newRequest(ctx, "GET", fmt.Sprintf("/subjects/%s/versions/%s", subject, v), nil), &resp); err != nil {
+		return nil, err
+	}
+	t, err := avro.ParseType(resp.Schema)
+	if err != nil {
+		return nil, fmt.Errorf("invalid schema (%q) in response: %w", resp.Schema, err)
+	}
+	return t, nil
+}
+
 // SetCompatibility sets the compatibility mode for the registry's subject to mode.
 //
 // See https://docs.confluent.io/current/schema-registry/develop/api.html#put--config-(string-%20subject)
@@ -155,7 +189,7 @@ func (r *Registry) doRequest(req *http.Request, result interface{}) error {
 		if !attempt.More() {
 			return err
 		}
-		if err, ok := err.(*apiError); ok && err.StatusCode/100 != 5 {
+		if err, ok := err.(*Error); ok && err.StatusCode/100 != 5 {
 			// It's not a 5xx error. We want to retry on 5xx
 			// errors, because the Confluent Avro registry
 			// can occasionally return them as a matter of
@@ -186,7 +220,7 @@ func unmarshalResponse(req *http.Request, resp *http.Response, result interface{
 		}
 		return nil
 	}
-	var apiErr apiError
+	var apiErr Error
 	if err := httprequest.UnmarshalJSONResponse(resp, &apiErr); err != nil {
 		return fmt.Errorf("cannot unmarshal JSON error response from %v: %v", req.URL, err)
 	}
@@ -194,14 +228,25 @@ func unmarshalResponse(req *http.Request, resp *http.Response, result interface{
 	return &apiErr
 }
 
-// https://docs.confluent.io/current/schema-registry/develop/api.html#errors
-type apiError struct {
-	ErrorCode  int    `json:"error_code"`
-	Message    string `json:"message"`
-	StatusCode int    `json:"-"`
+// Error represents an error returned by the registry server.
+// Errors returned by the Registry methods will wrap an *Error
+// when the server responds with an error status.
+//
+// See https://docs.confluent.io/current/schema-registry/develop/api.html#errors
+type Error struct {
+	// ErrorCode holds the error code returned by the registry,
+	// for example 40401 when a subject is not found.
+	ErrorCode int `json:"error_code"`
+
+	// Message holds the error message returned by the registry.
+	Message string `json:"message"`
+
+	// StatusCode holds the HTTP status code of the response.
+	StatusCode int `json:"-"`
 }
 
-func (e *apiError) Error() string {
+// Error implements the error interface.
+func (e *Error) Error() string {
 	if e.StatusCode != e.ErrorCode {
 		return fmt.Sprintf("Avro registry error (code %d; HTTP status %d): %v", e.ErrorCode, e.StatusCode, e.Message)
 	}
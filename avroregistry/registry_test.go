@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/rand"
 	"encoding/binary"
+	"errors"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -92,6 +93,40 @@ func TestSchemaCompatibility(t *testing.T) {
 	c.Assert(err, qt.ErrorMatches, `Avro registry error \(HTTP status 409\): Schema being registered is incompatible with an earlier schema`)
 }
 
+func TestVersionsAndSchema(t *testing.T) {
+	c := qt.New(t)
+	defer c.Done()
+	r, subject := newTestRegistry(c)
+	ctx := context.Background()
+	type R struct {
+		X int
+	}
+	type R1 struct {
+		X int
+		Y string
+	}
+	_, err := r.Register(ctx, subject, schemaOf(nil, R{}))
+	c.Assert(err, qt.Equals, nil)
+	names := new(avro.Names).RenameType(R1{}, "R")
+	_, err = r.Register(ctx, subject, schemaOf(names, R1{}))
+	c.Assert(err, qt.Equals, nil)
+
+	versions, err := r.Versions(ctx, subject)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(versions, qt.DeepEquals, []int{1, 2})
+
+	schema, err := r.Schema(ctx, subject, 1)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(schema.CanonicalString(0), qt.Equals, schemaOf(nil, R{}).CanonicalString(0))
+
+	schema, err = r.Schema(ctx, subject, 0)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(schema.CanonicalString(0), qt.Equals, schemaOf(names, R1{}).CanonicalString(0))
+
+	_, err = r.Schema(ctx, subject, 3)
+	c.Assert(err, qt.ErrorMatches, `Avro registry error .*`)
+}
+
 func TestSchemasRetainLogicalTypes(t *testing.T) {
 	c := qt.New(t)
 	defer c.Done()
@@ -253,6 +288,10 @@ func TestNoRetryOnNon5XXStatus(t *testing.T) {
 	err = registry.SetCompatibility(context.Background(), "x", avro.BackwardTransitive)
 	c.Assert(err, qt.ErrorMatches, `Avro registry error \(HTTP status 409\): incompatible wotsit`)
 	c.Assert(calls, qt.Equals, 1)
+	var rerr *avroregistry.Error
+	c.Assert(errors.As(err, &rerr), qt.Equals, true)
+	c.Assert(rerr.StatusCode, qt.Equals, 409)
+	c.Assert(rerr.ErrorCode, qt.Equals, 409)
 }
 
 func TestUnavailableError(t *testing.T) {
@@ -0,0 +1,92 @@
+// Package avrorpc implements Avro RPC, as defined by
+// https://avro.apache.org/docs/1.11.1/specification/#protocol-wire-format,
+// so that Go programs can act as clients and servers of
+// Avro RPC services written in other languages, such as Java.
+//
+// Both the HTTP transport and the stateful transport used over raw
+// sockets are supported, including the handshake that lets
+// clients and servers use different versions of a protocol.
+//
+// Request parameters are represented by a Go value whose type has the
+// same schema as the message's request record (see Message.Request).
+// The request types generated by avrogo -rpc satisfy that, so a
+// client of the protocol Greeter with a message Greet can do:
+//
+//	proto, err := avrorpc.ParseProtocol(greeterProtocol)
+//	...
+//	client := avrorpc.NewClient(proto, &avrorpc.HTTPTransport{URL: "http://localhost:8080/"})
+//	var greeting Greeting
+//	err = client.Call(ctx, "Greet", GreeterGreetRequest{Name: "Bob"}, &greeting)
+//
+// and a server of the same protocol can do:
+//
+//	srv := avrorpc.NewServer(proto, avrorpc.HandlerMap{
+//		"Greet": func(ctx context.Context, call *avrorpc.Call) (interface{}, error) {
+//			var req GreeterGreetRequest
+//			if err := call.Decode(&req); err != nil {
+//				return nil, err
+//			}
+//			return Greeting{Text: "Hello " + req.Name}, nil
+//		},
+//	})
+//	http.ListenAndServe(":8080", srv)
+package avrorpc
+
+import (
+	"fmt"
+
+	"github.com/heetch/avro"
+)
+
+// Error represents an error returned by an Avro RPC call.
+//
+// A handler can return an *Error with Value set to send one of the
+// errors declared by the message; any other error is sent as a string.
+// A client returns an *Error for any error sent by the server.
+type Error struct {
+	// Message holds the error message for errors
+	// that aren't declared by the message.
+	Message string
+
+	// Value holds the value of a declared error
+	// returned by a handler.
+	Value interface{}
+
+	// typ and data hold a declared error received by a client.
+	typ  *avro.Type
+	data []byte
+}
+
+// Error implements the error interface.
+func (e *Error) Error() string {
+	switch {
+	case e.typ != nil:
+		return fmt.Sprintf("remote error of type %s", e.typ.Name())
+	case e.Value != nil:
+		return fmt.Sprintf("remote error %v", e.Value)
+	}
+	return e.Message
+}
+
+// Type returns the type of a declared error received by a client,
+// or nil if the error wasn't declared.
+func (e *Error) Type() *avro.Type {
+	return e.typ
+}
+
+// Decode decodes a declared error received by a client into x,
+// which must be a pointer to a value whose type has a schema that's
+// compatible with the error's type.
+func (e *Error) Decode(x interface{}) error {
+	if e.typ == nil {
+		return fmt.Errorf("error is not a declared error")
+	}
+	_, err := avro.Unmarshal(e.data, x, e.typ)
+	return err
+}
+
+// sameEncoding reports whether values of the types t1 and t2
+// have the same binary encoding.
+func sameEncoding(t1, t2 *avro.Type) bool {
+	return t1.CanonicalString(0) == t2.CanonicalString(0)
+}
@@ -0,0 +1,227 @@
+package avrorpc_test
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http/httptest"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro/avrorpc"
+)
+
+const greeterProtocol = `{
+	"protocol": "Greeter",
+	"types": [{
+		"type": "record",
+		"name": "Greeting",
+		"fields": [{"name": "Text", "type": "string"}]
+	}, {
+		"type": "error",
+		"name": "Unknown",
+		"fields": [{"name": "Name", "type": "string"}]
+	}],
+	"messages": {
+		"Greet": {
+			"request": [{"name": "Name", "type": "string"}],
+			"response": "Greeting",
+			"errors": ["Unknown"]
+		},
+		"Notify": {
+			"request": [{"name": "Name", "type": "string"}],
+			"response": "null",
+			"one-way": true
+		}
+	}
+}`
+
+type GreeterGreetRequest struct {
+	Name string
+}
+
+type GreeterNotifyRequest struct {
+	Name string
+}
+
+type Greeting struct {
+	Text string
+}
+
+type Unknown struct {
+	Name string
+}
+
+func newServer(c *qt.C, notified chan<- string) *avrorpc.Server {
+	proto, err := avrorpc.ParseProtocol(greeterProtocol)
+	c.Assert(err, qt.Equals, nil)
+	return avrorpc.NewServer(proto, avrorpc.HandlerMap{
+		"Greet": func(ctx context.Context, call *avrorpc.Call) (interface{}, error) {
+			var req GreeterGreetRequest
+			if err := call.Decode(&req); err != nil {
+				return nil, err
+			}
+			switch req.Name {
+			case "":
+				return nil, &avrorpc.Error{Value: Unknown{Name: req.Name}}
+			case "fail":
+				return nil, fmt.Errorf("greeting failed")
+			}
+			return Greeting{Text: "Hello " + req.Name}, nil
+		},
+		"Notify": func(ctx context.Context, call *avrorpc.Call) (interface{}, error) {
+			var req GreeterNotifyRequest
+			if err := call.Decode(&req); err != nil {
+				return nil, err
+			}
+			notified <- req.Name
+			return nil, nil
+		},
+	})
+}
+
+func TestHTTPTransport(t *testing.T) {
+	c := qt.New(t)
+	ctx := context.Background()
+	notified := make(chan string, 1)
+	srv := httptest.NewServer(newServer(c, notified))
+	defer srv.Close()
+
+	proto, err := avrorpc.ParseProtocol(greeterProtocol)
+	c.Assert(err, qt.Equals, nil)
+	client := avrorpc.NewClient(proto, &avrorpc.HTTPTransport{URL: srv.URL})
+	testCalls(c, ctx, client, notified)
+}
+
+func TestConnTransport(t *testing.T) {
+	c := qt.New(t)
+	ctx := context.Background()
+	notified := make(chan string, 1)
+	clientConn, serverConn := net.Pipe()
+	defer clientConn.Close()
+	done := make(chan error)
+	go func() {
+		done <- newServer(c, notified).ServeConn(ctx, serverConn)
+	}()
+
+	proto, err := avrorpc.ParseProtocol(greeterProtocol)
+	c.Assert(err, qt.Equals, nil)
+	client := avrorpc.NewClient(proto, avrorpc.NewConnTransport(clientConn))
+	testCalls(c, ctx, client, notified)
+
+	clientConn.Close()
+	c.Assert(<-done, qt.Equals, nil)
+}
+
+func testCalls(c *qt.C, ctx context.Context, client *avrorpc.Client, notified <-chan string) {
+	for i := 0; i < 2; i++ {
+		var g Greeting
+		err := client.Call(ctx, "Greet", GreeterGreetRequest{Name: "Bob"}, &g)
+		c.Assert(err, qt.Equals, nil)
+		c.Assert(g, qt.Equals, Greeting{Text: "Hello Bob"})
+	}
+
+	err := client.Call(ctx, "Greet", GreeterGreetRequest{}, new(Greeting))
+	c.Assert(err, qt.ErrorMatches, `remote error of type Unknown`)
+	var unknown Unknown
+	c.Assert(err.(*avrorpc.Error).Decode(&unknown), qt.Equals, nil)
+	c.Assert(unknown, qt.Equals, Unknown{})
+
+	err = client.Call(ctx, "Greet", GreeterGreetRequest{Name: "fail"}, new(Greeting))
+	c.Assert(err, qt.ErrorMatches, `greeting failed`)
+	c.Assert(err.(*avrorpc.Error).Type(), qt.IsNil)
+
+	err = client.Call(ctx, "Notify", GreeterNotifyRequest{Name: "Alice"}, nil)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(<-notified, qt.Equals, "Alice")
+}
+
+func TestProtocolEvolution(t *testing.T) {
+	c := qt.New(t)
+	srv := httptest.NewServer(newEvolvedServer(c))
+	defer srv.Close()
+
+	proto, err := avrorpc.ParseProtocol(greeterProtocol)
+	c.Assert(err, qt.Equals, nil)
+	client := avrorpc.NewClient(proto, &avrorpc.HTTPTransport{URL: srv.URL})
+	var g Greeting
+	err = client.Call(context.Background(), "Greet", GreeterGreetRequest{Name: "Bob"}, &g)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(g, qt.Equals, Greeting{Text: "Hello Bob"})
+}
+
+// newEvolvedServer returns a server for a newer version of
+// the Greeter protocol, with extra fields in the request
+// and the response.
+func newEvolvedServer(c *qt.C) *avrorpc.Server {
+	type GreeterGreetRequest struct {
+		Name   string
+		Polite bool
+	}
+	type Greeting struct {
+		Text     string
+		Language string
+	}
+	proto, err := avrorpc.ParseProtocol(`{
+		"protocol": "Greeter",
+		"types": [{
+			"type": "record",
+			"name": "Greeting",
+			"fields": [{"name": "Text", "type": "string"}, {"name": "Language", "type": "string"}]
+		}],
+		"messages": {
+			"Greet": {
+				"request": [{"name": "Name", "type": "string"}, {"name": "Polite", "type": "boolean", "default": false}],
+				"response": "Greeting"
+			}
+		}
+	}`)
+	c.Assert(err, qt.Equals, nil)
+	return avrorpc.NewServer(proto, avrorpc.HandlerFunc(func(ctx context.Context, call *avrorpc.Call) (interface{}, error) {
+		var req GreeterGreetRequest
+		if err := call.Decode(&req); err != nil {
+			return nil, err
+		}
+		return Greeting{Text: "Hello " + req.Name, Language: "en"}, nil
+	}))
+}
+
+func TestCallErrors(t *testing.T) {
+	c := qt.New(t)
+	proto, err := avrorpc.ParseProtocol(greeterProtocol)
+	c.Assert(err, qt.Equals, nil)
+	client := avrorpc.NewClient(proto, &avrorpc.HTTPTransport{URL: "http://0.1.2.3"})
+	err = client.Call(context.Background(), "Other", GreeterGreetRequest{}, nil)
+	c.Assert(err, qt.ErrorMatches, `unknown message "Other"`)
+	err = client.Call(context.Background(), "Greet", Greeting{}, nil)
+	c.Assert(err, qt.ErrorMatches, `request type avrorpc_test.Greeting does not match schema of message "Greet"`)
+}
+
+var parseProtocolErrorTests = []struct {
+	testName    string
+	protocol    string
+	expectError string
+}{{
+	testName:    "invalid-json",
+	protocol:    `{`,
+	expectError: `invalid protocol: unexpected end of JSON input`,
+}, {
+	testName:    "no-name",
+	protocol:    `{}`,
+	expectError: `invalid protocol: no protocol name found`,
+}, {
+	testName:    "no-response",
+	protocol:    `{"protocol": "P", "messages": {"M": {"request": []}}}`,
+	expectError: `invalid protocol: no response for message "M"`,
+}}
+
+func TestParseProtocolError(t *testing.T) {
+	c := qt.New(t)
+	for _, test := range parseProtocolErrorTests {
+		c.Run(test.testName, func(c *qt.C) {
+			_, err := avrorpc.ParseProtocol(test.protocol)
+			c.Assert(err, qt.ErrorMatches, test.expectError)
+		})
+	}
+}
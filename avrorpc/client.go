@@ -0,0 +1,295 @@
+package avrorpc
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/heetch/avro"
+)
+
+// Transport is used by a Client to send messages to a server.
+type Transport interface {
+	// RoundTrip sends the request message req and returns the
+	// response message. If noResponse is true, the server won't
+	// send a response, so RoundTrip returns as soon as the request
+	// has been sent.
+	RoundTrip(ctx context.Context, req []byte, noResponse bool) ([]byte, error)
+
+	// Stateful reports whether the transport sends all messages
+	// over a single connection, in which case the handshake
+	// only happens on the first call.
+	Stateful() bool
+}
+
+// Client makes calls to an Avro RPC server.
+type Client struct {
+	proto     *Protocol
+	transport Transport
+
+	// callMu is held for the duration of calls
+	// made with a stateful transport.
+	callMu sync.Mutex
+
+	// mu guards the fields below.
+	mu sync.Mutex
+	// serverProto holds the server's protocol, if known.
+	serverProto *Protocol
+	// serverHash holds the hash of the server's protocol,
+	// or our own before the server's is known.
+	serverHash [16]byte
+	// sendProtocol holds whether the client protocol must
+	// be sent in the handshake.
+	sendProtocol bool
+	// connected holds whether a handshake has succeeded
+	// on a stateful transport.
+	connected bool
+}
+
+// NewClient returns a client that makes calls to the protocol p
+// using the given transport.
+func NewClient(p *Protocol, t Transport) *Client {
+	return &Client{
+		proto:      p,
+		transport:  t,
+		serverHash: p.hash,
+	}
+}
+
+// Call calls the message with the given name. The request parameters
+// are held in req, which must have the same schema as the message's
+// request record. The response is decoded into resp, which must be a
+// pointer, or nil for messages with a null response and
+// one-way messages.
+//
+// If the server returns an error, Call returns an *Error.
+func (c *Client) Call(ctx context.Context, message string, req, resp interface{}) error {
+	m := c.proto.messages[message]
+	if m == nil {
+		return fmt.Errorf("unknown message %q", message)
+	}
+	params, reqType, err := avro.Marshal(req)
+	if err != nil {
+		return fmt.Errorf("cannot encode request: %v", err)
+	}
+	if !sameEncoding(reqType, m.Request) {
+		return fmt.Errorf("request type %T does not match schema of message %q", req, message)
+	}
+	if c.transport.Stateful() {
+		c.callMu.Lock()
+		defer c.callMu.Unlock()
+	}
+	// The handshake can fail once when the server doesn't
+	// know our protocol, after which we send it.
+	for i := 0; i < 2; i++ {
+		done, err := c.call(ctx, m, params, resp)
+		if done || err != nil {
+			return err
+		}
+	}
+	return fmt.Errorf("handshake failed")
+}
+
+// call makes a single attempt at a call. It returns false if the
+// call must be retried because the handshake failed.
+func (c *Client) call(ctx context.Context, m *Message, params []byte, resp interface{}) (bool, error) {
+	c.mu.Lock()
+	needHandshake := !c.transport.Stateful() || !c.connected
+	hreq := handshakeRequest{
+		clientHash: c.proto.hash,
+		serverHash: c.serverHash,
+	}
+	if c.sendProtocol {
+		hreq.clientProtocol = &c.proto.text
+	}
+	c.mu.Unlock()
+
+	e := &encoder{}
+	if needHandshake {
+		hreq.encode(e)
+	}
+	e.writeMap(nil)
+	e.writeString(m.Name)
+	e.buf = append(e.buf, params...)
+	noResponse := m.OneWay && !needHandshake
+	data, err := c.transport.RoundTrip(ctx, e.buf, noResponse)
+	if err != nil {
+		return false, err
+	}
+	if noResponse {
+		return true, nil
+	}
+	d := &decoder{buf: data}
+	if needHandshake {
+		var hresp handshakeResponse
+		hresp.decode(d)
+		if d.err != nil {
+			return false, fmt.Errorf("invalid handshake response: %v", d.err)
+		}
+		ok, err := c.handshakeDone(&hresp)
+		if !ok || err != nil {
+			return false, err
+		}
+	}
+	d.readMap()
+	if m.OneWay {
+		return true, d.err
+	}
+	c.mu.Lock()
+	sm := c.serverProto.messages[m.Name]
+	c.mu.Unlock()
+	if sm == nil {
+		return false, fmt.Errorf("message %q not found in server protocol", m.Name)
+	}
+	if d.readBool() {
+		i := d.readUnionIndex(len(sm.Errors) + 1)
+		if i == 0 {
+			msg := d.readString()
+			if d.err != nil {
+				return false, fmt.Errorf("invalid response: %v", d.err)
+			}
+			return true, &Error{Message: msg}
+		}
+		if d.err != nil {
+			return false, fmt.Errorf("invalid response: %v", d.err)
+		}
+		return true, &Error{
+			typ:  sm.Errors[i-1],
+			data: d.rest(),
+		}
+	}
+	if d.err != nil {
+		return false, fmt.Errorf("invalid response: %v", d.err)
+	}
+	if resp == nil {
+		return true, nil
+	}
+	if _, err := avro.Unmarshal(d.rest(), resp, sm.Response); err != nil {
+		return false, fmt.Errorf("cannot decode response: %v", err)
+	}
+	return true, nil
+}
+
+// handshakeDone updates the client state from the handshake
+// response. It returns false if the handshake must be retried.
+func (c *Client) handshakeDone(hresp *handshakeResponse) (bool, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if hresp.serverProtocol != nil {
+		p, err := ParseProtocol(*hresp.serverProtocol)
+		if err != nil {
+			return false, fmt.Errorf("invalid server protocol: %v", err)
+		}
+		c.serverProto = p
+		c.serverHash = p.hash
+	}
+	switch hresp.match {
+	case matchNone:
+		if c.sendProtocol {
+			return false, fmt.Errorf("server did not accept client protocol")
+		}
+		c.sendProtocol = true
+		return false, nil
+	case matchClient, matchBoth:
+		if c.serverProto == nil {
+			// The server has the same protocol as us.
+			c.serverProto = c.proto
+		}
+		c.sendProtocol = false
+		c.connected = true
+	}
+	return true, nil
+}
+
+// HTTPTransport is a Transport that sends messages in
+// HTTP POST requests.
+type HTTPTransport struct {
+	// URL holds the URL of the server.
+	URL string
+
+	// Client holds the HTTP client to use.
+	// If it's nil, http.DefaultClient is used.
+	Client *http.Client
+}
+
+// contentType holds the content type of Avro RPC HTTP
+// requests and responses.
+const contentType = "avro/binary"
+
+// RoundTrip implements Transport.RoundTrip.
+func (t *HTTPTransport) RoundTrip(ctx context.Context, req []byte, noResponse bool) ([]byte, error) {
+	var body bytes.Buffer
+	if err := writeMessage(&body, req); err != nil {
+		return nil, err
+	}
+	hreq, err := http.NewRequest("POST", t.URL, &body)
+	if err != nil {
+		return nil, err
+	}
+	hreq = hreq.WithContext(ctx)
+	hreq.Header.Set("Content-Type", contentType)
+	client := t.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+	hresp, err := client.Do(hreq)
+	if err != nil {
+		return nil, err
+	}
+	defer hresp.Body.Close()
+	if hresp.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(io.LimitReader(hresp.Body, 1024))
+		return nil, fmt.Errorf("HTTP status %s: %s", hresp.Status, bytes.TrimSpace(msg))
+	}
+	return readMessage(hresp.Body)
+}
+
+// Stateful implements Transport.Stateful by returning false.
+func (t *HTTPTransport) Stateful() bool {
+	return false
+}
+
+// ConnTransport is a Transport that sends messages over a single
+// connection, such as a TCP connection.
+type ConnTransport struct {
+	mu   sync.Mutex
+	conn io.ReadWriter
+}
+
+// NewConnTransport returns a transport that sends messages
+// over conn. If conn has a SetDeadline method, such as net.Conn,
+// it's used to honor context deadlines.
+func NewConnTransport(conn io.ReadWriter) *ConnTransport {
+	return &ConnTransport{
+		conn: conn,
+	}
+}
+
+// RoundTrip implements Transport.RoundTrip.
+func (t *ConnTransport) RoundTrip(ctx context.Context, req []byte, noResponse bool) ([]byte, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if conn, ok := t.conn.(interface{ SetDeadline(time.Time) error }); ok {
+		deadline, _ := ctx.Deadline()
+		if err := conn.SetDeadline(deadline); err != nil {
+			return nil, err
+		}
+	}
+	if err := writeMessage(t.conn, req); err != nil {
+		return nil, err
+	}
+	if noResponse {
+		return nil, nil
+	}
+	return readMessage(t.conn)
+}
+
+// Stateful implements Transport.Stateful by returning true.
+func (t *ConnTransport) Stateful() bool {
+	return true
+}
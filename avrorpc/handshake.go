@@ -0,0 +1,123 @@
+package avrorpc
+
+// handshakeMatch holds the values of the HandshakeMatch enum.
+type handshakeMatch int
+
+const (
+	matchBoth handshakeMatch = iota
+	matchClient
+	matchNone
+)
+
+// handshakeRequest holds an org.apache.avro.ipc.HandshakeRequest record:
+//
+//	{
+//		"type": "record",
+//		"name": "HandshakeRequest", "namespace": "org.apache.avro.ipc",
+//		"fields": [
+//			{"name": "clientHash", "type": {"type": "fixed", "name": "MD5", "size": 16}},
+//			{"name": "clientProtocol", "type": ["null", "string"]},
+//			{"name": "serverHash", "type": "MD5"},
+//			{"name": "meta", "type": ["null", {"type": "map", "values": "bytes"}]}
+//		]
+//	}
+type handshakeRequest struct {
+	clientHash     [16]byte
+	clientProtocol *string
+	serverHash     [16]byte
+	meta           map[string][]byte
+}
+
+func (r *handshakeRequest) encode(e *encoder) {
+	e.writeFixed(r.clientHash[:])
+	if r.clientProtocol == nil {
+		e.writeLong(0)
+	} else {
+		e.writeLong(1)
+		e.writeString(*r.clientProtocol)
+	}
+	e.writeFixed(r.serverHash[:])
+	writeOptionalMap(e, r.meta)
+}
+
+func (r *handshakeRequest) decode(d *decoder) {
+	copy(r.clientHash[:], d.readFixed(16))
+	if d.readUnionIndex(2) == 1 {
+		s := d.readString()
+		r.clientProtocol = &s
+	}
+	copy(r.serverHash[:], d.readFixed(16))
+	r.meta = readOptionalMap(d)
+}
+
+// handshakeResponse holds an org.apache.avro.ipc.HandshakeResponse record:
+//
+//	{
+//		"type": "record",
+//		"name": "HandshakeResponse", "namespace": "org.apache.avro.ipc",
+//		"fields": [
+//			{"name": "match", "type": {"type": "enum", "name": "HandshakeMatch", "symbols": ["BOTH", "CLIENT", "NONE"]}},
+//			{"name": "serverProtocol", "type": ["null", "string"]},
+//			{"name": "serverHash", "type": ["null", {"type": "fixed", "name": "MD5", "size": 16}]},
+//			{"name": "meta", "type": ["null", {"type": "map", "values": "bytes"}]}
+//		]
+//	}
+type handshakeResponse struct {
+	match          handshakeMatch
+	serverProtocol *string
+	serverHash     *[16]byte
+	meta           map[string][]byte
+}
+
+func (r *handshakeResponse) encode(e *encoder) {
+	e.writeLong(int64(r.match))
+	if r.serverProtocol == nil {
+		e.writeLong(0)
+	} else {
+		e.writeLong(1)
+		e.writeString(*r.serverProtocol)
+	}
+	if r.serverHash == nil {
+		e.writeLong(0)
+	} else {
+		e.writeLong(1)
+		e.writeFixed(r.serverHash[:])
+	}
+	writeOptionalMap(e, r.meta)
+}
+
+func (r *handshakeResponse) decode(d *decoder) {
+	r.match = handshakeMatch(d.readUnionIndex(3))
+	if d.readUnionIndex(2) == 1 {
+		s := d.readString()
+		r.serverProtocol = &s
+	}
+	if d.readUnionIndex(2) == 1 {
+		var h [16]byte
+		copy(h[:], d.readFixed(16))
+		r.serverHash = &h
+	}
+	r.meta = readOptionalMap(d)
+}
+
+// writeOptionalMap writes m as a union of null and
+// a map of bytes, writing null when m is nil.
+func writeOptionalMap(e *encoder, m map[string][]byte) {
+	if m == nil {
+		e.writeLong(0)
+		return
+	}
+	e.writeLong(1)
+	e.writeMap(m)
+}
+
+func readOptionalMap(d *decoder) map[string][]byte {
+	if d.readUnionIndex(2) == 0 {
+		return nil
+	}
+	m := d.readMap()
+	if m == nil {
+		m = make(map[string][]byte)
+	}
+	return m
+}
@@ -0,0 +1,312 @@
+package avrorpc
+
+import (
+	"crypto/md5"
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/heetch/avro"
+)
+
+// Protocol represents an Avro protocol.
+type Protocol struct {
+	text      string
+	hash      [16]byte
+	name      string
+	namespace string
+	messages  map[string]*Message
+}
+
+// Message represents a message in an Avro protocol.
+type Message struct {
+	// Name holds the name of the message.
+	Name string
+
+	// Request holds the type of a record holding the
+	// message's parameters. The record is named
+	// in the same way as the request types generated
+	// by avrogo -rpc; for example, the parameters of the
+	// message Get in protocol Foo are held in the
+	// record FooGetRequest.
+	Request *avro.Type
+
+	// Response holds the type of the message's response.
+	Response *avro.Type
+
+	// Errors holds the types of the errors declared
+	// by the message.
+	Errors []*avro.Type
+
+	// OneWay holds whether the message is one-way.
+	OneWay bool
+}
+
+// ParseProtocol parses the Avro protocol held in text in the JSON
+// format defined by the Avro specification. Protocols written in Avro
+// IDL can be converted to that format with "avro-tools idl".
+func ParseProtocol(text string) (*Protocol, error) {
+	var p struct {
+		Protocol  string                   `json:"protocol"`
+		Namespace string                   `json:"namespace"`
+		Types     []map[string]interface{} `json:"types"`
+		Messages  map[string]struct {
+			Request  []interface{} `json:"request"`
+			Response interface{}   `json:"response"`
+			Errors   []interface{} `json:"errors"`
+			OneWay   bool          `json:"one-way"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal([]byte(text), &p); err != nil {
+		return nil, fmt.Errorf("invalid protocol: %v", err)
+	}
+	if p.Protocol == "" {
+		return nil, fmt.Errorf("invalid protocol: no protocol name found")
+	}
+	ns := p.Namespace
+	if i := strings.LastIndex(p.Protocol, "."); i >= 0 {
+		ns = p.Protocol[:i]
+	}
+	defs := make(map[string]map[string]interface{})
+	for _, t := range p.Types {
+		collectDefs(defs, t, ns)
+	}
+	proto := &Protocol{
+		text:      text,
+		hash:      md5.Sum([]byte(text)),
+		name:      p.Protocol,
+		namespace: ns,
+		messages:  make(map[string]*Message),
+	}
+	for name, m := range p.Messages {
+		if m.Response == nil {
+			return nil, fmt.Errorf("invalid protocol: no response for message %q", name)
+		}
+		msg := &Message{
+			Name:   name,
+			OneWay: m.OneWay,
+		}
+		b := newSchemaBuilder(defs)
+		request := map[string]interface{}{
+			"type":   "record",
+			"name":   fullName(goName(p.Protocol)+goName(name)+"Request", ns),
+			"fields": b.expandFields(m.Request, ns),
+		}
+		var err error
+		if msg.Request, err = parseType(request); err != nil {
+			return nil, fmt.Errorf("invalid request for message %q: %v", name, err)
+		}
+		if msg.Response, err = parseType(newSchemaBuilder(defs).expand(m.Response, ns)); err != nil {
+			return nil, fmt.Errorf("invalid response for message %q: %v", name, err)
+		}
+		for _, e := range m.Errors {
+			t, err := parseType(newSchemaBuilder(defs).expand(e, ns))
+			if err != nil {
+				return nil, fmt.Errorf("invalid error for message %q: %v", name, err)
+			}
+			msg.Errors = append(msg.Errors, t)
+		}
+		proto.messages[name] = msg
+	}
+	return proto, nil
+}
+
+// String returns the text the protocol was parsed from.
+func (p *Protocol) String() string {
+	return p.text
+}
+
+// Name returns the name of the protocol.
+func (p *Protocol) Name() string {
+	return p.name
+}
+
+// Hash returns the MD5 hash of the protocol's text,
+// which identifies it in the Avro RPC handshake.
+func (p *Protocol) Hash() [16]byte {
+	return p.hash
+}
+
+// Message returns the message with the given name,
+// or nil if there is none.
+func (p *Protocol) Message(name string) *Message {
+	return p.messages[name]
+}
+
+func parseType(schema interface{}) (*avro.Type, error) {
+	data, err := json.Marshal(schema)
+	if err != nil {
+		return nil, err
+	}
+	return avro.ParseType(string(data))
+}
+
+// goName returns the exported Go name that avrogo uses for the
+// Avro name s.
+func goName(s string) string {
+	return strings.Title(strings.Trim(s[strings.LastIndex(s, ".")+1:], "_"))
+}
+
+var primitiveTypes = map[string]bool{
+	"null":    true,
+	"boolean": true,
+	"int":     true,
+	"long":    true,
+	"float":   true,
+	"double":  true,
+	"bytes":   true,
+	"string":  true,
+}
+
+// fullName returns the full name for name in the namespace ns.
+func fullName(name, ns string) string {
+	if strings.Contains(name, ".") || ns == "" {
+		return name
+	}
+	return ns + "." + name
+}
+
+// namespaceOf returns the namespace of the full name name.
+func namespaceOf(name string) string {
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		return name[:i]
+	}
+	return ""
+}
+
+// definedName returns the full name of the named type t
+// defined within the namespace ns.
+func definedName(t map[string]interface{}, ns string) string {
+	name, _ := t["name"].(string)
+	if tns, ok := t["namespace"].(string); ok {
+		ns = tns
+	}
+	return fullName(name, ns)
+}
+
+// collectDefs adds all the named types defined by the schema t,
+// which is defined within the namespace ns, to defs.
+func collectDefs(defs map[string]map[string]interface{}, t interface{}, ns string) {
+	switch t := t.(type) {
+	case []interface{}:
+		for _, u := range t {
+			collectDefs(defs, u, ns)
+		}
+	case map[string]interface{}:
+		switch t["type"] {
+		case "record", "error", "enum", "fixed":
+			name := definedName(t, ns)
+			def := make(map[string]interface{}, len(t))
+			for k, v := range t {
+				def[k] = v
+			}
+			def["name"] = name
+			delete(def, "namespace")
+			defs[name] = def
+			if fields, ok := t["fields"].([]interface{}); ok {
+				for _, f := range fields {
+					if f, ok := f.(map[string]interface{}); ok {
+						collectDefs(defs, f["type"], namespaceOf(name))
+					}
+				}
+			}
+		case "array":
+			collectDefs(defs, t["items"], ns)
+		case "map":
+			collectDefs(defs, t["values"], ns)
+		default:
+			collectDefs(defs, t["type"], ns)
+		}
+	}
+}
+
+// schemaBuilder builds standalone schemas from parts of a protocol
+// by replacing the first reference to each named type with its
+// definition.
+type schemaBuilder struct {
+	defs    map[string]map[string]interface{}
+	defined map[string]bool
+}
+
+func newSchemaBuilder(defs map[string]map[string]interface{}) *schemaBuilder {
+	return &schemaBuilder{
+		defs:    defs,
+		defined: make(map[string]bool),
+	}
+}
+
+// expand returns the schema t, which is used within the namespace ns,
+// with the named types it uses defined where they're first used.
+func (b *schemaBuilder) expand(t interface{}, ns string) interface{} {
+	switch t := t.(type) {
+	case string:
+		if primitiveTypes[t] {
+			return t
+		}
+		name := fullName(t, ns)
+		def, ok := b.defs[name]
+		if !ok || b.defined[name] {
+			return name
+		}
+		return b.expand(def, ns)
+	case []interface{}:
+		u := make([]interface{}, len(t))
+		for i, member := range t {
+			u[i] = b.expand(member, ns)
+		}
+		return u
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(t))
+		for k, v := range t {
+			out[k] = v
+		}
+		switch typ := t["type"]; typ {
+		case "record", "error", "enum", "fixed":
+			name := definedName(t, ns)
+			if b.defined[name] {
+				return name
+			}
+			b.defined[name] = true
+			out["name"] = name
+			delete(out, "namespace")
+			if typ == "record" || typ == "error" {
+				// Error types are records as far as
+				// the encoding is concerned.
+				out["type"] = "record"
+				fields, _ := t["fields"].([]interface{})
+				out["fields"] = b.expandFields(fields, namespaceOf(name))
+			}
+		case "array":
+			out["items"] = b.expand(t["items"], ns)
+		case "map":
+			out["values"] = b.expand(t["values"], ns)
+		default:
+			out["type"] = b.expand(typ, ns)
+		}
+		return out
+	}
+	return t
+}
+
+// expandFields returns the record fields with their types expanded.
+func (b *schemaBuilder) expandFields(fields []interface{}, ns string) []interface{} {
+	out := make([]interface{}, len(fields))
+	for i, f := range fields {
+		fm, ok := f.(map[string]interface{})
+		if !ok {
+			out[i] = f
+			continue
+		}
+		f1 := make(map[string]interface{}, len(fm))
+		for k, v := range fm {
+			f1[k] = v
+		}
+		f1["type"] = b.expand(fm["type"], ns)
+		out[i] = f1
+	}
+	if out == nil {
+		// A message with no parameters has an empty request record.
+		out = []interface{}{}
+	}
+	return out
+}
@@ -0,0 +1,314 @@
+package avrorpc
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync"
+
+	"github.com/heetch/avro"
+)
+
+// Handler handles calls made to a Server.
+type Handler interface {
+	// HandleCall handles a call. It returns the response, which must
+	// have the same schema as the message's response type, or nil
+	// if the message has a null response or is one-way.
+	//
+	// To send one of the errors declared by the message, it can
+	// return an *Error with Value set. Any other error
+	// is sent as a string.
+	HandleCall(ctx context.Context, call *Call) (interface{}, error)
+}
+
+// HandlerFunc implements Handler by calling the function.
+type HandlerFunc func(ctx context.Context, call *Call) (interface{}, error)
+
+// HandleCall implements Handler.HandleCall.
+func (f HandlerFunc) HandleCall(ctx context.Context, call *Call) (interface{}, error) {
+	return f(ctx, call)
+}
+
+// HandlerMap implements Handler by calling the function
+// with the same name as the message called.
+type HandlerMap map[string]HandlerFunc
+
+// HandleCall implements Handler.HandleCall.
+func (m HandlerMap) HandleCall(ctx context.Context, call *Call) (interface{}, error) {
+	f := m[call.Message]
+	if f == nil {
+		return nil, fmt.Errorf("no handler for message %q", call.Message)
+	}
+	return f(ctx, call)
+}
+
+// Call holds a call received by a Server.
+type Call struct {
+	// Message holds the name of the message called.
+	Message string
+
+	// Meta holds the call metadata sent by the client.
+	Meta map[string][]byte
+
+	params []byte
+	wType  *avro.Type
+}
+
+// Decode decodes the request parameters into x, which must be a
+// pointer to a value whose type has a schema that's compatible with
+// the client's request record for the message.
+func (c *Call) Decode(x interface{}) error {
+	if _, err := avro.Unmarshal(c.params, x, c.wType); err != nil {
+		return fmt.Errorf("cannot decode request: %v", err)
+	}
+	return nil
+}
+
+// Server serves Avro RPC calls. It implements http.Handler
+// for the HTTP transport, and ServeConn and Serve can be used
+// to serve connections.
+type Server struct {
+	proto   *Protocol
+	handler Handler
+
+	// mu guards the fields below.
+	mu sync.Mutex
+	// clients holds the client protocols that
+	// have been seen, keyed by hash.
+	clients map[[16]byte]*Protocol
+}
+
+// NewServer returns a server for the protocol p
+// that handles calls with h.
+func NewServer(p *Protocol, h Handler) *Server {
+	return &Server{
+		proto:   p,
+		handler: h,
+		clients: map[[16]byte]*Protocol{
+			p.hash: p,
+		},
+	}
+}
+
+// ServeHTTP implements http.Handler by serving the call held
+// in the request body.
+func (s *Server) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	if req.Method != "POST" {
+		http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		return
+	}
+	msg, err := readMessage(req.Body)
+	if err != nil {
+		http.Error(w, fmt.Sprintf("cannot read request: %v", err), http.StatusBadRequest)
+		return
+	}
+	resp, err := s.respond(req.Context(), msg, nil)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	w.Header().Set("Content-Type", contentType)
+	writeMessage(w, resp)
+}
+
+// Serve accepts connections on l and serves each one on its own
+// goroutine with ServeConn. It returns when l.Accept fails.
+func (s *Server) Serve(l net.Listener) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go func() {
+			defer conn.Close()
+			s.ServeConn(context.Background(), conn)
+		}()
+	}
+}
+
+// connState holds the state of a connection served by ServeConn.
+type connState struct {
+	// clientProto holds the client's protocol once
+	// the handshake has succeeded.
+	clientProto *Protocol
+}
+
+// ServeConn serves calls made over conn, using the stateful
+// transport, until it reaches EOF or ctx is done.
+func (s *Server) ServeConn(ctx context.Context, conn io.ReadWriter) error {
+	var state connState
+	for {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		msg, err := readMessage(conn)
+		if err != nil {
+			if err == io.EOF {
+				return nil
+			}
+			return err
+		}
+		resp, err := s.respond(ctx, msg, &state)
+		if err != nil {
+			return err
+		}
+		if resp == nil {
+			continue
+		}
+		if err := writeMessage(conn, resp); err != nil {
+			return err
+		}
+	}
+}
+
+// respond returns the response to the request message msg. The state
+// is nil for stateless transports. It returns a nil response if no
+// response should be sent.
+func (s *Server) respond(ctx context.Context, msg []byte, state *connState) ([]byte, error) {
+	d := &decoder{buf: msg}
+	e := &encoder{}
+	var clientProto *Protocol
+	handshake := state == nil || state.clientProto == nil
+	if handshake {
+		var hreq handshakeRequest
+		hreq.decode(d)
+		if d.err != nil {
+			return nil, fmt.Errorf("invalid handshake request: %v", d.err)
+		}
+		p, hresp, err := s.handshake(&hreq)
+		if err != nil {
+			return nil, err
+		}
+		hresp.encode(e)
+		if p == nil {
+			// The client must try again with its protocol.
+			return e.buf, nil
+		}
+		clientProto = p
+		if state != nil {
+			state.clientProto = p
+		}
+		if d.atEnd() {
+			// Handshake only.
+			return e.buf, nil
+		}
+	} else {
+		clientProto = state.clientProto
+	}
+	meta := d.readMap()
+	name := d.readString()
+	if d.err != nil {
+		return nil, fmt.Errorf("invalid request: %v", d.err)
+	}
+	cm := clientProto.messages[name]
+	sm := s.proto.messages[name]
+	if cm == nil || sm == nil {
+		e.writeMap(nil)
+		e.writeBool(true)
+		e.writeLong(0)
+		e.writeString(fmt.Sprintf("unknown message %q", name))
+		return e.buf, nil
+	}
+	call := &Call{
+		Message: name,
+		Meta:    meta,
+		params:  d.rest(),
+		wType:   cm.Request,
+	}
+	resp, err := s.handler.HandleCall(ctx, call)
+	if sm.OneWay {
+		if !handshake {
+			return nil, nil
+		}
+		e.writeMap(nil)
+		return e.buf, nil
+	}
+	e.writeMap(nil)
+	if err != nil {
+		e.writeBool(true)
+		s.encodeError(e, sm, err)
+		return e.buf, nil
+	}
+	if resp == nil {
+		if sm.Response.CanonicalString(0) != `"null"` {
+			e.writeBool(true)
+			e.writeLong(0)
+			e.writeString(fmt.Sprintf("no response returned for message %q", name))
+			return e.buf, nil
+		}
+		e.writeBool(false)
+		return e.buf, nil
+	}
+	data, respType, err := avro.Marshal(resp)
+	if err == nil && !sameEncoding(respType, sm.Response) {
+		err = fmt.Errorf("response type %T does not match schema of message %q", resp, name)
+	}
+	if err != nil {
+		e.writeBool(true)
+		e.writeLong(0)
+		e.writeString(err.Error())
+		return e.buf, nil
+	}
+	e.writeBool(false)
+	e.buf = append(e.buf, data...)
+	return e.buf, nil
+}
+
+// encodeError writes err as a member of the error union of
+// message m, which holds the string type followed by the
+// message's declared errors.
+func (s *Server) encodeError(e *encoder, m *Message, err error) {
+	if rerr, ok := err.(*Error); ok && rerr.Value != nil {
+		data, t, merr := avro.Marshal(rerr.Value)
+		if merr == nil {
+			for i, et := range m.Errors {
+				if sameEncoding(t, et) {
+					e.writeLong(int64(i + 1))
+					e.buf = append(e.buf, data...)
+					return
+				}
+			}
+			merr = fmt.Errorf("error type %T is not declared by message %q", rerr.Value, m.Name)
+		}
+		err = merr
+	}
+	e.writeLong(0)
+	e.writeString(err.Error())
+}
+
+// handshake returns the response to the handshake request hreq
+// and the client's protocol, or nil if it isn't known.
+func (s *Server) handshake(hreq *handshakeRequest) (*Protocol, *handshakeResponse, error) {
+	s.mu.Lock()
+	clientProto := s.clients[hreq.clientHash]
+	s.mu.Unlock()
+	if clientProto == nil && hreq.clientProtocol != nil {
+		p, err := ParseProtocol(*hreq.clientProtocol)
+		if err != nil {
+			return nil, nil, fmt.Errorf("invalid client protocol: %v", err)
+		}
+		if p.hash != hreq.clientHash {
+			return nil, nil, fmt.Errorf("client protocol does not match its hash")
+		}
+		s.mu.Lock()
+		s.clients[p.hash] = p
+		s.mu.Unlock()
+		clientProto = p
+	}
+	hresp := &handshakeResponse{}
+	switch {
+	case clientProto == nil:
+		hresp.match = matchNone
+	case hreq.serverHash == s.proto.hash:
+		hresp.match = matchBoth
+		return clientProto, hresp, nil
+	default:
+		hresp.match = matchClient
+	}
+	hresp.serverProtocol = &s.proto.text
+	hash := s.proto.hash
+	hresp.serverHash = &hash
+	return clientProto, hresp, nil
+}
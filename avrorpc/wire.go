@@ -0,0 +1,232 @@
+package avrorpc
+
+import (
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+)
+
+// maxFrameSize holds the largest frame that will be read.
+// The Java implementation writes frames of at most 8KiB.
+const maxFrameSize = 16 << 20
+
+// writeMessage writes the message data to w as a sequence of
+// frames, each prefixed by its big-endian four-byte length, followed
+// by an empty frame.
+func writeMessage(w io.Writer, data []byte) error {
+	buf := make([]byte, 0, len(data)+8)
+	if len(data) > 0 {
+		buf = appendFrameLength(buf, len(data))
+		buf = append(buf, data...)
+	}
+	buf = appendFrameLength(buf, 0)
+	_, err := w.Write(buf)
+	return err
+}
+
+func appendFrameLength(buf []byte, n int) []byte {
+	var b [4]byte
+	binary.BigEndian.PutUint32(b[:], uint32(n))
+	return append(buf, b[:]...)
+}
+
+// readMessage reads a message written by writeMessage from r
+// and returns its data.
+func readMessage(r io.Reader) ([]byte, error) {
+	var data []byte
+	var b [4]byte
+	for {
+		if _, err := io.ReadFull(r, b[:]); err != nil {
+			if err == io.ErrUnexpectedEOF || err == io.EOF && data != nil {
+				return nil, fmt.Errorf("truncated message")
+			}
+			return nil, err
+		}
+		n := binary.BigEndian.Uint32(b[:])
+		if n == 0 {
+			if data == nil {
+				data = []byte{}
+			}
+			return data, nil
+		}
+		if n > maxFrameSize {
+			return nil, fmt.Errorf("frame too large (%d bytes)", n)
+		}
+		start := len(data)
+		data = append(data, make([]byte, n)...)
+		if _, err := io.ReadFull(r, data[start:]); err != nil {
+			return nil, fmt.Errorf("truncated message")
+		}
+	}
+}
+
+// encoder appends values in the Avro binary encoding
+// to a buffer.
+type encoder struct {
+	buf []byte
+}
+
+func (e *encoder) writeLong(x int64) {
+	var b [binary.MaxVarintLen64]byte
+	n := binary.PutVarint(b[:], x)
+	e.buf = append(e.buf, b[:n]...)
+}
+
+func (e *encoder) writeBool(x bool) {
+	if x {
+		e.buf = append(e.buf, 1)
+	} else {
+		e.buf = append(e.buf, 0)
+	}
+}
+
+func (e *encoder) writeBytes(x []byte) {
+	e.writeLong(int64(len(x)))
+	e.buf = append(e.buf, x...)
+}
+
+func (e *encoder) writeString(x string) {
+	e.writeLong(int64(len(x)))
+	e.buf = append(e.buf, x...)
+}
+
+func (e *encoder) writeFixed(x []byte) {
+	e.buf = append(e.buf, x...)
+}
+
+// writeMap writes m as an Avro map of bytes,
+// with its keys in sorted order.
+func (e *encoder) writeMap(m map[string][]byte) {
+	if len(m) > 0 {
+		keys := make([]string, 0, len(m))
+		for k := range m {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		e.writeLong(int64(len(keys)))
+		for _, k := range keys {
+			e.writeString(k)
+			e.writeBytes(m[k])
+		}
+	}
+	e.writeLong(0)
+}
+
+// decoder reads values in the Avro binary encoding from
+// a buffer. The first error encountered is stored in err
+// and all subsequent reads return zero values.
+type decoder struct {
+	buf []byte
+	err error
+}
+
+func (d *decoder) fail(err error) {
+	if d.err == nil {
+		d.err = err
+	}
+	d.buf = nil
+}
+
+func (d *decoder) readLong() int64 {
+	if d.err != nil {
+		return 0
+	}
+	x, n := binary.Varint(d.buf)
+	if n <= 0 {
+		d.fail(fmt.Errorf("invalid long"))
+		return 0
+	}
+	d.buf = d.buf[n:]
+	return x
+}
+
+func (d *decoder) readBool() bool {
+	b := d.readFixed(1)
+	if b == nil {
+		return false
+	}
+	switch b[0] {
+	case 0:
+		return false
+	case 1:
+		return true
+	}
+	d.fail(fmt.Errorf("invalid boolean"))
+	return false
+}
+
+func (d *decoder) readBytes() []byte {
+	n := d.readLong()
+	if n < 0 {
+		d.fail(fmt.Errorf("invalid length"))
+		return nil
+	}
+	return d.readFixed(n)
+}
+
+func (d *decoder) readString() string {
+	return string(d.readBytes())
+}
+
+func (d *decoder) readFixed(n int64) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n > int64(len(d.buf)) {
+		d.fail(fmt.Errorf("unexpected end of data"))
+		return nil
+	}
+	b := d.buf[:n]
+	d.buf = d.buf[n:]
+	return b
+}
+
+// readMap reads an Avro map of bytes. It returns
+// nil if the map is empty.
+func (d *decoder) readMap() map[string][]byte {
+	var m map[string][]byte
+	for d.err == nil {
+		n := d.readLong()
+		if n == 0 {
+			break
+		}
+		if n < 0 {
+			// A negative count is followed by the
+			// size of the block in bytes.
+			n = -n
+			d.readLong()
+		}
+		if m == nil {
+			m = make(map[string][]byte)
+		}
+		for i := int64(0); i < n && d.err == nil; i++ {
+			k := d.readString()
+			m[k] = d.readBytes()
+		}
+	}
+	return m
+}
+
+// readUnionIndex reads the index of a union
+// member, checking that it's less than n.
+func (d *decoder) readUnionIndex(n int) int {
+	i := d.readLong()
+	if i < 0 || i >= int64(n) {
+		d.fail(fmt.Errorf("union index %d out of range", i))
+		return 0
+	}
+	return int(i)
+}
+
+// rest returns all the data that hasn't been read.
+func (d *decoder) rest() []byte {
+	b := d.buf
+	d.buf = nil
+	return b
+}
+
+// atEnd reports whether all the data has been read.
+func (d *decoder) atEnd() bool {
+	return d.err == nil && len(d.buf) == 0
+}
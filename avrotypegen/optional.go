@@ -0,0 +1,56 @@
+package avrotypegen
+
+import "time"
+
+// The Optional types represent a union of null and another type,
+// like the nullable types in database/sql: Value holds the value
+// of the non-null member of the union and Valid reports whether
+// the value is non-null.
+
+// OptionalBool represents a union of null and boolean.
+type OptionalBool struct {
+	Value bool
+	Valid bool
+}
+
+// OptionalInt represents a union of null and an integer held as int.
+type OptionalInt struct {
+	Value int
+	Valid bool
+}
+
+// OptionalInt64 represents a union of null and an integer held as int64.
+type OptionalInt64 struct {
+	Value int64
+	Valid bool
+}
+
+// OptionalFloat32 represents a union of null and float.
+type OptionalFloat32 struct {
+	Value float32
+	Valid bool
+}
+
+// OptionalFloat64 represents a union of null and double.
+type OptionalFloat64 struct {
+	Value float64
+	Valid bool
+}
+
+// OptionalString represents a union of null and string.
+type OptionalString struct {
+	Value string
+	Valid bool
+}
+
+// OptionalBytes represents a union of null and bytes.
+type OptionalBytes struct {
+	Value []byte
+	Valid bool
+}
+
+// OptionalTime represents a union of null and a timestamp.
+type OptionalTime struct {
+	Value time.Time
+	Valid bool
+}
@@ -36,6 +36,33 @@ type RecordInfo struct {
 	Unions []UnionInfo
 }
 
+// AvroEnum can be implemented by a Go integer type to declare
+// that it represents an Avro enum, so that its symbols don't need
+// to be guessed from its String method. The value i represents
+// the symbol returned by SymbolName(i).
+type AvroEnum interface {
+	// NumSymbols returns the number of symbols in the enum.
+	NumSymbols() int
+
+	// SymbolName returns the symbol at index i, where
+	// 0 <= i < NumSymbols().
+	SymbolName(i int) string
+}
+
+// AvroEnumValues can be implemented as well as AvroEnum by an enum
+// type whose values aren't the indexes of its symbols, such as one
+// whose values start at 1 or are sparse. The value SymbolValue(i)
+// represents the symbol returned by SymbolName(i), and other values
+// can't be encoded. When no symbol has the zero value, the first
+// symbol is used as the default in schemas.
+type AvroEnumValues interface {
+	AvroEnum
+
+	// SymbolValue returns the value of the symbol at index i,
+	// where 0 <= i < NumSymbols().
+	SymbolValue(i int) int64
+}
+
 type UnionInfo struct {
 	// Type holds a value of type *T where T is
 	// the type described by the TypeInfo,
@@ -43,6 +70,17 @@ type UnionInfo struct {
 	// type, in which case Type will be nil.
 	Type interface{}
 
+	// Name holds the Avro name of the union member that
+	// the UnionInfo describes: the full name of a named type,
+	// or the type name, such as "int" or "array", otherwise.
+	// When every member of a union has a name, members are
+	// matched by name rather than by position, so a member
+	// can still be found when several members have the same
+	// Go type or the union's members are reordered.
+	// It's empty when the UnionInfo doesn't describe a
+	// union member or the name isn't known.
+	Name string
+
 	// When the UnionInfo describes a union,
 	// Union holds an entry for each member
 	// of the union.
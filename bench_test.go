@@ -32,6 +32,59 @@ func BenchmarkMarshal(b *testing.B) {
 	}
 }
 
+func BenchmarkMarshalAppend(b *testing.B) {
+	type R struct {
+		A *string
+		B *string
+		C []int
+	}
+	type T struct {
+		R R
+	}
+	x := T{
+		R: R{
+			A: newString("hello"),
+			B: newString("goodbye"),
+			C: []int{1, 3, 1 << 20},
+		},
+	}
+	var buf []byte
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		data, _, err := avro.MarshalAppend(buf[:0], x)
+		if err != nil {
+			b.Fatal(err)
+		}
+		buf = data
+	}
+}
+
+func BenchmarkUnmarshal(b *testing.B) {
+	type T struct {
+		A int64
+		B float64
+		C string
+		D []int
+	}
+	data, wType, err := avro.Marshal(T{
+		A: 1 << 40,
+		B: 3.5,
+		C: "hello",
+		D: []int{1, 3, 1 << 20, -1},
+	})
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		var x T
+		if _, err := avro.Unmarshal(data, &x, wType); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
 func BenchmarkSingleDecoderUnmarshal(b *testing.B) {
 	c := qt.New(b)
 	type R struct {
@@ -69,6 +122,40 @@ func BenchmarkSingleDecoderUnmarshal(b *testing.B) {
 	}
 }
 
+func BenchmarkSingleDecoderUnmarshalArena(b *testing.B) {
+	c := qt.New(b)
+	type R struct {
+		A string
+		B []string
+	}
+	at, err := avro.TypeOf(R{})
+	c.Assert(err, qt.Equals, nil)
+	r := memRegistry{
+		1: at,
+	}
+	ctx := context.Background()
+	data, err := avro.NewSingleEncoder(r, nil).Marshal(ctx, R{
+		A: "hello",
+		B: []string{"one", "two", "three", "four"},
+	})
+	c.Assert(err, qt.Equals, nil)
+
+	dec := avro.NewSingleDecoder(r, nil)
+	var a avro.Arena
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if i%1000 == 0 {
+			a.Reset()
+		}
+		var x R
+		_, err := dec.UnmarshalArena(ctx, data, &x, &a)
+		if err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
 func newString(s string) *string {
 	return &s
 }
@@ -0,0 +1,85 @@
+package avro
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+)
+
+// Binary wraps a Go value so that it can be used wherever
+// encoding.BinaryMarshaler and encoding.BinaryUnmarshaler are
+// accepted, such as in caches and key-value stores, with the
+// value stored as Avro binary-encoded data.
+//
+// For example:
+//
+//	err := cache.Set(key, avro.Binary{Value: order, Type: orderType})
+//	...
+//	var order Order
+//	err := cache.Get(key, &avro.Binary{Value: &order, Type: orderType})
+type Binary struct {
+	// Value holds the value to encode. When unmarshaling,
+	// it must be a pointer to the value to decode into.
+	Value interface{}
+
+	// Type holds the Avro type of the encoded data.
+	// When unmarshaling, data is decoded with Type as the
+	// writer's schema, so the Go type of Value only needs to be
+	// compatible with it. When marshaling, the schema of Value
+	// must be the same as Type, so that the data can be read back.
+	// If Type is nil, the schema of Value is used in both cases.
+	Type *Type
+}
+
+var (
+	_ encoding.BinaryMarshaler   = Binary{}
+	_ encoding.BinaryUnmarshaler = (*Binary)(nil)
+)
+
+// MarshalBinary implements encoding.BinaryMarshaler
+// by returning b.Value encoded as Avro binary data.
+func (b Binary) MarshalBinary() ([]byte, error) {
+	xv := reflect.ValueOf(b.Value)
+	if !xv.IsValid() || xv.Kind() == reflect.Ptr && xv.IsNil() {
+		return nil, fmt.Errorf("cannot marshal nil value")
+	}
+	return marshalWithType(reflect.Indirect(xv), b.Type)
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler
+// by decoding the Avro binary data into b.Value.
+func (b *Binary) UnmarshalBinary(data []byte) error {
+	return unmarshalWithType(data, b.Value, b.Type)
+}
+
+// marshalWithType returns xv encoded as Avro binary data,
+// checking that its schema matches t if t is non-nil.
+func marshalWithType(xv reflect.Value, t *Type) ([]byte, error) {
+	data, wType, err := marshalAppend(globalNames, nil, xv)
+	if err != nil {
+		return nil, err
+	}
+	if t != nil && wType.CanonicalString(0) != t.CanonicalString(0) {
+		return nil, fmt.Errorf("cannot encode %s: its schema does not match the expected type", xv.Type())
+	}
+	return data, nil
+}
+
+// unmarshalWithType decodes data into x, which must be a non-nil
+// pointer, using t as the writer's schema. If t is nil, the
+// schema of the value that x points to is used.
+func unmarshalWithType(data []byte, x interface{}, t *Type) error {
+	xv := reflect.ValueOf(x)
+	if xv.Kind() != reflect.Ptr || xv.IsNil() {
+		return fmt.Errorf("cannot decode into non-pointer value %T", x)
+	}
+	if t == nil {
+		var err error
+		t, err = avroTypeOf(globalNames, xv.Type().Elem())
+		if err != nil {
+			return err
+		}
+	}
+	_, err := Unmarshal(data, x, t)
+	return err
+}
@@ -0,0 +1,60 @@
+package avro_test
+
+import (
+	"encoding"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro"
+)
+
+type binaryRecord struct {
+	A int
+	B string
+}
+
+func TestBinaryRoundTrip(t *testing.T) {
+	c := qt.New(t)
+	var m encoding.BinaryMarshaler = avro.Binary{Value: binaryRecord{A: 20, B: "x"}}
+	data, err := m.MarshalBinary()
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(data, qt.DeepEquals, []byte{40, 2, 'x'})
+
+	var x binaryRecord
+	var u encoding.BinaryUnmarshaler = &avro.Binary{Value: &x}
+	err = u.UnmarshalBinary(data)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(x, qt.Equals, binaryRecord{A: 20, B: "x"})
+}
+
+func TestBinaryWithType(t *testing.T) {
+	c := qt.New(t)
+	wType := mustTypeOf(binaryRecord{})
+	data, err := avro.Binary{Value: &binaryRecord{A: 20, B: "x"}, Type: wType}.MarshalBinary()
+	c.Assert(err, qt.Equals, nil)
+
+	// Decode into a type that only has some of the fields.
+	type binaryRecord struct {
+		B string
+	}
+	var x binaryRecord
+	err = (&avro.Binary{Value: &x, Type: wType}).UnmarshalBinary(data)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(x, qt.Equals, binaryRecord{B: "x"})
+
+	_, err = avro.Binary{Value: x, Type: wType}.MarshalBinary()
+	c.Assert(err, qt.ErrorMatches, `cannot encode avro_test.binaryRecord: its schema does not match the expected type`)
+}
+
+func TestBinaryErrors(t *testing.T) {
+	c := qt.New(t)
+	_, err := avro.Binary{}.MarshalBinary()
+	c.Assert(err, qt.ErrorMatches, `cannot marshal nil value`)
+
+	_, err = avro.Binary{Value: (*binaryRecord)(nil)}.MarshalBinary()
+	c.Assert(err, qt.ErrorMatches, `cannot marshal nil value`)
+
+	err = (&avro.Binary{Value: binaryRecord{}}).UnmarshalBinary(nil)
+	c.Assert(err, qt.ErrorMatches, `cannot decode into non-pointer value avro_test.binaryRecord`)
+}
@@ -0,0 +1,104 @@
+package main
+
+import (
+	"context"
+	stdflag "flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/heetch/avro"
+)
+
+func lint(flag *stdflag.FlagSet, args []string) error {
+	if err := parseArgs(flag, args, 1, len(args)); err != nil {
+		return err
+	}
+	invalid := 0
+	for _, file := range flag.Args() {
+		data, err := ioutil.ReadFile(file)
+		if err != nil {
+			return err
+		}
+		if _, err := avro.ParseType(string(data)); err != nil {
+			invalid++
+			if err, ok := err.(*avro.SchemaError); ok && err.Line > 0 {
+				msg := err.Message
+				if err.Path != "" {
+					msg += " (" + err.Path + ")"
+				}
+				fmt.Fprintf(os.Stderr, "%s:%d:%d: %s\n", file, err.Line, err.Column, msg)
+			} else {
+				fmt.Fprintf(os.Stderr, "%s: %v\n", file, err)
+			}
+		}
+	}
+	if invalid > 0 {
+		return fmt.Errorf("%d of %d schemas are invalid", invalid, flag.NArg())
+	}
+	return nil
+}
+
+func compat(flag *stdflag.FlagSet, args []string) error {
+	mode := avro.Backward
+	flag.Var(&mode, "mode", "compatibility mode, such as BACKWARD or FULL_TRANSITIVE")
+	registryURL := flag.String("registry", "", "URL of the schema registry to use with -against-registry")
+	subject := flag.String("against-registry", "", "check against the schemas registered with this subject instead of files")
+	if err := parseArgs(flag, args, 1, len(args)); err != nil {
+		return err
+	}
+	if (*subject != "") != (*registryURL != "") {
+		fmt.Fprintf(os.Stderr, "avro compat: -registry and -against-registry must be specified together\n")
+		flag.Usage()
+		return errUsage
+	}
+	if *subject != "" && flag.NArg() != 1 {
+		flag.Usage()
+		return errUsage
+	}
+	if *subject == "" && flag.NArg() < 2 {
+		flag.Usage()
+		return errUsage
+	}
+	newFile := flag.Arg(flag.NArg() - 1)
+	newType, err := readSchema(newFile)
+	if err != nil {
+		return err
+	}
+	var oldTypes []*avro.Type
+	var oldNames []string
+	if *subject != "" {
+		sf := schemaFlags{registryURL: registryURL}
+		r, err := sf.registry()
+		if err != nil {
+			return err
+		}
+		ctx := context.Background()
+		versions, err := r.Versions(ctx, *subject)
+		if err != nil {
+			return fmt.Errorf("cannot get versions of %q: %v", *subject, err)
+		}
+		for _, v := range versions {
+			t, err := r.Schema(ctx, *subject, v)
+			if err != nil {
+				return fmt.Errorf("cannot get version %d of %q: %v", v, *subject, err)
+			}
+			oldTypes = append(oldTypes, t)
+			oldNames = append(oldNames, fmt.Sprintf("%s version %d", *subject, v))
+		}
+	} else {
+		for _, file := range flag.Args()[:flag.NArg()-1] {
+			t, err := readSchema(file)
+			if err != nil {
+				return err
+			}
+			oldTypes = append(oldTypes, t)
+			oldNames = append(oldNames, file)
+		}
+	}
+	if err := mode.Check(newType, oldTypes...); err != nil {
+		return fmt.Errorf("%s is not %v compatible with [%s]: %v", newFile, mode, strings.Join(oldNames, ", "), err)
+	}
+	return nil
+}
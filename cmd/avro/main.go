@@ -0,0 +1,411 @@
+// The avro command provides tools for inspecting Avro schemas
+// and converting Avro-encoded data.
+package main
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	stdflag "flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/heetch/avro"
+	"github.com/heetch/avro/avroregistry"
+)
+
+// command represents an avro subcommand.
+type command struct {
+	run   func(flag *stdflag.FlagSet, args []string) error
+	args  string
+	about string
+}
+
+var commands = map[string]*command{
+	"tojson": {
+		run:   tojson,
+		args:  "[file]",
+		about: "convert a binary-encoded value to JSON",
+	},
+	"fromjson": {
+		run:   fromjson,
+		args:  "[file]",
+		about: "convert a JSON value to the binary encoding",
+	},
+	"fingerprint": {
+		run:   fingerprint,
+		args:  "file.avsc",
+		about: "print the CRC-64-AVRO fingerprint of a schema",
+	},
+	"canonical": {
+		run:   canonical,
+		args:  "file.avsc",
+		about: "print the Parsing Canonical Form of a schema",
+	},
+	"random": {
+		run:   random,
+		args:  "",
+		about: "generate random values of a schema",
+	},
+	"lint": {
+		run:   lint,
+		args:  "file.avsc...",
+		about: "check that schemas are valid",
+	},
+	"compat": {
+		run:   compat,
+		args:  "old.avsc... new.avsc",
+		about: "check that a schema is compatible with older versions",
+	},
+	"registry": {
+		run:   registry,
+		args:  "push|pull|versions|set-compat ...",
+		about: "manage schemas in a schema registry",
+	},
+	"ocf": {
+		run:   ocf,
+		args:  "info|cat file.avro",
+		about: "inspect an object container file",
+	},
+}
+
+func init() {
+	// frombinary is another name for tojson, as the
+	// conversion goes in both directions.
+	commands["frombinary"] = commands["tojson"]
+}
+
+func main() {
+	os.Exit(main1())
+}
+
+// main1 is the internal version of main that returns a status
+// code instead of calling os.Exit.
+func main1() int {
+	if len(os.Args) < 2 {
+		usage()
+		return 2
+	}
+	name := os.Args[1]
+	cmd := commands[name]
+	if cmd == nil {
+		fmt.Fprintf(os.Stderr, "avro: unknown command %q\n", name)
+		usage()
+		return 2
+	}
+	flag := stdflag.NewFlagSet(name, stdflag.ContinueOnError)
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: avro %s [flags] %s\n", name, cmd.args)
+		flag.PrintDefaults()
+	}
+	if err := cmd.run(flag, os.Args[2:]); err != nil {
+		if err == errUsage {
+			return 2
+		}
+		fmt.Fprintf(os.Stderr, "avro %s: %v\n", name, err)
+		return 1
+	}
+	return 0
+}
+
+func usage() {
+	var names []string
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	fmt.Fprintf(os.Stderr, "usage: avro <command> [flags] [args]\n\nThe commands are:\n\n")
+	for _, name := range names {
+		fmt.Fprintf(os.Stderr, "\t%-12s %s\n", name, commands[name].about)
+	}
+}
+
+// errUsage is returned by a command when its arguments
+// are wrong and a usage message has been printed.
+var errUsage = fmt.Errorf("usage error")
+
+// parseArgs parses the flags in args and checks that
+// there are at most maxArgs remaining arguments.
+func parseArgs(flag *stdflag.FlagSet, args []string, minArgs, maxArgs int) error {
+	if flag.Parse(args) != nil {
+		return errUsage
+	}
+	if flag.NArg() < minArgs || flag.NArg() > maxArgs {
+		flag.Usage()
+		return errUsage
+	}
+	return nil
+}
+
+// schemaFlags holds the flags used to find the schema
+// of some binary data.
+type schemaFlags struct {
+	schemaFile  *string
+	registryURL *string
+}
+
+func addSchemaFlags(flag *stdflag.FlagSet) schemaFlags {
+	return schemaFlags{
+		schemaFile:  flag.String("schema", "", "file holding the Avro schema of the data"),
+		registryURL: flag.String("registry", "", "URL of a schema registry; the data holds a schema id in the Confluent wire format"),
+	}
+}
+
+// check checks that exactly one of the schema flags is set.
+func (f schemaFlags) check(flag *stdflag.FlagSet) error {
+	if (*f.schemaFile == "") == (*f.registryURL == "") {
+		fmt.Fprintf(os.Stderr, "avro %s: exactly one of -schema or -registry must be specified\n", flag.Name())
+		flag.Usage()
+		return errUsage
+	}
+	return nil
+}
+
+func (f schemaFlags) registry() (*avroregistry.Registry, error) {
+	return avroregistry.New(avroregistry.Params{
+		ServerURL: *f.registryURL,
+	})
+}
+
+func tojson(flag *stdflag.FlagSet, args []string) error {
+	sf := addSchemaFlags(flag)
+	indent := flag.Bool("indent", false, "indent the JSON output")
+	if err := parseArgs(flag, args, 0, 1); err != nil {
+		return err
+	}
+	if err := sf.check(flag); err != nil {
+		return err
+	}
+	data, err := readInput(flag.Arg(0))
+	if err != nil {
+		return err
+	}
+	var t *avro.Type
+	if *sf.schemaFile != "" {
+		t, err = readSchema(*sf.schemaFile)
+		if err != nil {
+			return err
+		}
+	} else {
+		r, err := sf.registry()
+		if err != nil {
+			return err
+		}
+		dec := r.Decoder()
+		id, body := dec.DecodeSchemaID(data)
+		if body == nil {
+			return fmt.Errorf("data does not hold a schema id")
+		}
+		t, err = dec.SchemaForID(context.Background(), id)
+		if err != nil {
+			return fmt.Errorf("cannot get schema for id %d: %v", id, err)
+		}
+		data = body
+	}
+	goType, err := avro.StructOf(t)
+	if err != nil {
+		return err
+	}
+	v := reflect.New(goType)
+	if _, err := avro.Unmarshal(data, v.Interface(), t); err != nil {
+		return fmt.Errorf("cannot decode value: %v", err)
+	}
+	var out []byte
+	if *indent {
+		out, err = json.MarshalIndent(v.Interface(), "", "\t")
+	} else {
+		out, err = json.Marshal(v.Interface())
+	}
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Printf("%s\n", out)
+	return err
+}
+
+func fromjson(flag *stdflag.FlagSet, args []string) error {
+	sf := addSchemaFlags(flag)
+	subject := flag.String("subject", "", "registry subject whose latest schema is used to encode the data (required with -registry)")
+	outFile := flag.String("o", "", "output filename (default stdout)")
+	if err := parseArgs(flag, args, 0, 1); err != nil {
+		return err
+	}
+	if err := sf.check(flag); err != nil {
+		return err
+	}
+	if *sf.registryURL != "" && *subject == "" {
+		fmt.Fprintf(os.Stderr, "avro fromjson: -subject must be specified with -registry\n")
+		flag.Usage()
+		return errUsage
+	}
+	data, err := readInput(flag.Arg(0))
+	if err != nil {
+		return err
+	}
+	ctx := context.Background()
+	var (
+		t *avro.Type
+		r *avroregistry.Registry
+	)
+	if *sf.schemaFile != "" {
+		t, err = readSchema(*sf.schemaFile)
+		if err != nil {
+			return err
+		}
+	} else {
+		r, err = sf.registry()
+		if err != nil {
+			return err
+		}
+		t, err = r.Schema(ctx, *subject, 0)
+		if err != nil {
+			return fmt.Errorf("cannot get latest schema for subject %q: %v", *subject, err)
+		}
+	}
+	goType, err := avro.StructOf(t)
+	if err != nil {
+		return err
+	}
+	v := reflect.New(goType)
+	if err := json.Unmarshal(data, v.Interface()); err != nil {
+		return fmt.Errorf("cannot decode JSON value: %v", err)
+	}
+	var out []byte
+	if r != nil {
+		out, err = avro.NewSingleEncoder(r.Encoder(*subject), nil).Marshal(ctx, v.Elem().Interface())
+		if err != nil {
+			return err
+		}
+	} else {
+		out, _, err = avro.Marshal(v.Elem().Interface())
+		if err != nil {
+			return err
+		}
+	}
+	return writeOutput(*outFile, out)
+}
+
+func fingerprint(flag *stdflag.FlagSet, args []string) error {
+	if err := parseArgs(flag, args, 1, 1); err != nil {
+		return err
+	}
+	t, err := readSchema(flag.Arg(0))
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Printf("%016x\n", t.Fingerprint())
+	return err
+}
+
+func canonical(flag *stdflag.FlagSet, args []string) error {
+	if err := parseArgs(flag, args, 1, 1); err != nil {
+		return err
+	}
+	t, err := readSchema(flag.Arg(0))
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Println(t.CanonicalString(0))
+	return err
+}
+
+func random(flag *stdflag.FlagSet, args []string) error {
+	schemaFile := flag.String("schema", "", "file holding the Avro schema of the values (required)")
+	count := flag.Int("n", 1, "number of values to generate")
+	seed := flag.Int64("seed", 0, "random seed (default based on the current time)")
+	format := flag.String("format", "jsonl", "format of the output (framed, single-object, ocf or jsonl)")
+	outFile := flag.String("o", "", "output filename (default stdout)")
+	if err := parseArgs(flag, args, 0, 0); err != nil {
+		return err
+	}
+	if *schemaFile == "" {
+		flag.Usage()
+		return errUsage
+	}
+	to, err := avro.ParseTranscodeFormat(*format)
+	if err != nil {
+		return err
+	}
+	t, err := readSchema(*schemaFile)
+	if err != nil {
+		return err
+	}
+	if *seed == 0 {
+		*seed = time.Now().UnixNano()
+	}
+	g, err := newRandGen(t, rand.New(rand.NewSource(*seed)))
+	if err != nil {
+		return err
+	}
+	// Generate the values in the framed binary format and let
+	// the transcoder convert them to the requested format.
+	pr, pw := io.Pipe()
+	go func() {
+		var size [binary.MaxVarintLen64]byte
+		for i := 0; i < *count; i++ {
+			data, _, err := avro.Marshal(g.value())
+			if err != nil {
+				pw.CloseWithError(err)
+				return
+			}
+			n := binary.PutVarint(size[:], int64(len(data)))
+			if _, err := pw.Write(append(size[:n:n], data...)); err != nil {
+				return
+			}
+		}
+		pw.Close()
+	}()
+	var out io.Writer = os.Stdout
+	if *outFile != "" {
+		f, err := os.Create(*outFile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+	tc, err := avro.NewTranscoder(pr, out, avro.TranscoderOptions{
+		Type: t,
+		From: avro.FramedBinary,
+		To:   to,
+	})
+	if err != nil {
+		return err
+	}
+	return tc.Transcode()
+}
+
+func readSchema(file string) (*avro.Type, error) {
+	data, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	t, err := avro.ParseType(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse schema from %q: %v", file, err)
+	}
+	return t, nil
+}
+
+// readInput reads all of file, or of stdin if file is empty.
+func readInput(file string) ([]byte, error) {
+	if file == "" {
+		return ioutil.ReadAll(os.Stdin)
+	}
+	return ioutil.ReadFile(file)
+}
+
+// writeOutput writes data to file, or to stdout if file is empty.
+func writeOutput(file string, data []byte) error {
+	if file == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	return ioutil.WriteFile(file, data, 0666)
+}
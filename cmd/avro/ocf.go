@@ -0,0 +1,147 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	stdflag "flag"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/heetch/avro"
+	"github.com/heetch/avro/avroocf"
+)
+
+var ocfCommands = map[string]func(flag *stdflag.FlagSet, args []string) error{
+	"info": ocfInfo,
+	"cat":  ocfCat,
+}
+
+func ocf(flag *stdflag.FlagSet, args []string) error {
+	if len(args) == 0 || ocfCommands[args[0]] == nil {
+		flag.Usage()
+		return errUsage
+	}
+	name := "ocf " + args[0]
+	subflag := stdflag.NewFlagSet(name, stdflag.ContinueOnError)
+	subflag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: avro %s [flags] file.avro\n", name)
+		subflag.PrintDefaults()
+	}
+	return ocfCommands[args[0]](subflag, args[1:])
+}
+
+func ocfInfo(flag *stdflag.FlagSet, args []string) error {
+	showBlocks := flag.Bool("blocks", false, "print the size of each block")
+	if err := parseArgs(flag, args, 1, 1); err != nil {
+		return err
+	}
+	f, err := os.Open(flag.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	r, err := avroocf.NewReader(f)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(os.Stdout)
+	defer w.Flush()
+	h := r.Header()
+	var schema bytes.Buffer
+	if err := json.Compact(&schema, []byte(h.Schema.String())); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "schema: %s\n", schema.Bytes())
+	fmt.Fprintf(w, "codec: %s\n", h.Codec)
+	var keys []string
+	for key := range h.Meta {
+		if key != "avro.schema" && key != "avro.codec" {
+			keys = append(keys, key)
+		}
+	}
+	if len(keys) > 0 {
+		sort.Strings(keys)
+		fmt.Fprintf(w, "metadata:\n")
+		for _, key := range keys {
+			fmt.Fprintf(w, "\t%s: %q\n", key, h.Meta[key])
+		}
+	}
+	var nblocks, nrecords, size, dataSize int
+	for {
+		b, err := r.NextBlock()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		if *showBlocks {
+			fmt.Fprintf(w, "block %d: %d records, %d bytes (%d uncompressed)\n", nblocks, b.Count, b.Size, len(b.Data))
+		}
+		nblocks++
+		nrecords += b.Count
+		size += b.Size
+		dataSize += len(b.Data)
+	}
+	fmt.Fprintf(w, "blocks: %d\n", nblocks)
+	fmt.Fprintf(w, "records: %d\n", nrecords)
+	fmt.Fprintf(w, "block bytes: %d (%d uncompressed)\n", size, dataSize)
+	return nil
+}
+
+func ocfCat(flag *stdflag.FlagSet, args []string) error {
+	fields := flag.String("fields", "", "comma-separated list of dot-separated field paths to print (default all)")
+	limit := flag.Int("n", 0, "maximum number of records to print (default all)")
+	if err := parseArgs(flag, args, 1, 1); err != nil {
+		return err
+	}
+	f, err := os.Open(flag.Arg(0))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	r, err := avroocf.NewReader(f)
+	if err != nil {
+		return err
+	}
+	t := r.Header().Schema
+	if *fields != "" {
+		t, err = t.Project(strings.Split(*fields, ","))
+		if err != nil {
+			return err
+		}
+	}
+	goType, err := avro.StructOf(t)
+	if err != nil {
+		return err
+	}
+	w := bufio.NewWriter(os.Stdout)
+	defer w.Flush()
+	enc := json.NewEncoder(w)
+	n := 0
+	for *limit <= 0 || n < *limit {
+		b, err := r.NextBlock()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return err
+		}
+		vs := reflect.New(reflect.SliceOf(goType))
+		if err := b.Decode(vs.Interface()); err != nil {
+			return err
+		}
+		for i := 0; i < vs.Elem().Len() && (*limit <= 0 || n < *limit); i++ {
+			if err := enc.Encode(vs.Elem().Index(i).Interface()); err != nil {
+				return err
+			}
+			n++
+		}
+	}
+	return nil
+}
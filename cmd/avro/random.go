@@ -0,0 +1,169 @@
+package main
+
+import (
+	"encoding/json"
+	"math/rand"
+	"reflect"
+	"time"
+
+	"github.com/heetch/avro"
+)
+
+var (
+	timeType     = reflect.TypeOf(time.Time{})
+	durationType = reflect.TypeOf(avro.Duration{})
+)
+
+// randGen generates random values of an Avro record type,
+// held in the Go type returned by avro.StructOf.
+type randGen struct {
+	rand   *rand.Rand
+	goType reflect.Type
+
+	// schema holds the Parsing Canonical Form of the type,
+	// including logical types, so all names are fully
+	// qualified and each named type is defined at its first use.
+	schema interface{}
+
+	// defs holds the named types seen so far.
+	defs map[string]interface{}
+}
+
+func newRandGen(t *avro.Type, r *rand.Rand) (*randGen, error) {
+	goType, err := avro.StructOf(t)
+	if err != nil {
+		return nil, err
+	}
+	var schema interface{}
+	if err := json.Unmarshal([]byte(t.CanonicalString(avro.RetainLogicalTypes)), &schema); err != nil {
+		return nil, err
+	}
+	return &randGen{
+		rand:   r,
+		goType: goType,
+		schema: schema,
+		defs:   make(map[string]interface{}),
+	}, nil
+}
+
+// value returns a new random value.
+func (g *randGen) value() interface{} {
+	v := reflect.New(g.goType).Elem()
+	g.fill(v, g.schema)
+	return v.Interface()
+}
+
+// fill sets v to a random value of the Avro type
+// represented by the schema s.
+func (g *randGen) fill(v reflect.Value, s interface{}) {
+	switch v.Type() {
+	case timeType:
+		if s, ok := s.(map[string]interface{}); ok && s["type"] == "int" {
+			// A date.
+			v.Set(reflect.ValueOf(time.Unix(g.rand.Int63n(40000)*24*60*60, 0).UTC()))
+			return
+		}
+		ms := g.rand.Int63n(4e12)
+		v.Set(reflect.ValueOf(time.Unix(ms/1e3, ms%1e3*1e6).UTC()))
+		return
+	case durationType:
+		v.Set(reflect.ValueOf(avro.Duration{
+			Months:       uint32(g.rand.Intn(24)),
+			Days:         uint32(g.rand.Intn(31)),
+			Milliseconds: uint32(g.rand.Intn(24 * 60 * 60 * 1000)),
+		}))
+		return
+	}
+	switch s := s.(type) {
+	case string:
+		if def, ok := g.defs[s]; ok {
+			g.fill(v, def)
+			return
+		}
+		g.fillPrimitive(v)
+	case []interface{}:
+		// StructOf only supports unions of null and one other type.
+		if v.Kind() != reflect.Ptr || len(s) != 2 || g.rand.Intn(4) == 0 {
+			return
+		}
+		v.Set(reflect.New(v.Type().Elem()))
+		g.fill(v.Elem(), s[1])
+	case map[string]interface{}:
+		if name, ok := s["name"].(string); ok {
+			g.defs[name] = s
+		}
+		switch s["type"] {
+		case "record":
+			fields, _ := s["fields"].([]interface{})
+			for i, f := range fields {
+				if f, ok := f.(map[string]interface{}); ok && i < v.NumField() {
+					g.fill(v.Field(i), f["type"])
+				}
+			}
+		case "enum":
+			symbols, _ := s["symbols"].([]interface{})
+			if len(symbols) > 0 && v.Kind() == reflect.Int {
+				v.SetInt(int64(g.rand.Intn(len(symbols))))
+			}
+		case "fixed":
+			if v.Kind() == reflect.Array {
+				for i := 0; i < v.Len(); i++ {
+					v.Index(i).SetUint(uint64(g.rand.Intn(256)))
+				}
+			}
+		case "array":
+			if v.Kind() != reflect.Slice {
+				return
+			}
+			n := g.rand.Intn(4)
+			v.Set(reflect.MakeSlice(v.Type(), n, n))
+			for i := 0; i < n; i++ {
+				g.fill(v.Index(i), s["items"])
+			}
+		case "map":
+			if v.Kind() != reflect.Map {
+				return
+			}
+			v.Set(reflect.MakeMap(v.Type()))
+			for i := g.rand.Intn(4); i > 0; i-- {
+				elem := reflect.New(v.Type().Elem()).Elem()
+				g.fill(elem, s["values"])
+				v.SetMapIndex(reflect.ValueOf(g.word()), elem)
+			}
+		default:
+			// A primitive type with a logical type.
+			g.fillPrimitive(v)
+		}
+	}
+}
+
+// fillPrimitive sets v to a random value of its Go type.
+// Values of types that aren't used for primitive Avro
+// types are left unchanged.
+func (g *randGen) fillPrimitive(v reflect.Value) {
+	switch v.Kind() {
+	case reflect.Bool:
+		v.SetBool(g.rand.Intn(2) == 0)
+	case reflect.Int32, reflect.Int64:
+		v.SetInt(g.rand.Int63n(2000) - 1000)
+	case reflect.Float32, reflect.Float64:
+		v.SetFloat(float64(g.rand.Int63n(200000)-100000) / 100)
+	case reflect.String:
+		v.SetString(g.word())
+	case reflect.Slice:
+		if v.Type().Elem().Kind() == reflect.Uint8 {
+			data := make([]byte, g.rand.Intn(8))
+			g.rand.Read(data)
+			v.SetBytes(data)
+		}
+	}
+}
+
+// word returns a random lower-case word.
+func (g *randGen) word() string {
+	b := make([]byte, 1+g.rand.Intn(8))
+	for i := range b {
+		b[i] = byte('a' + g.rand.Intn(26))
+	}
+	return string(b)
+}
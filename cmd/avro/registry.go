@@ -0,0 +1,128 @@
+package main
+
+import (
+	"context"
+	stdflag "flag"
+	"fmt"
+	"os"
+	"strconv"
+
+	"github.com/heetch/avro"
+	"github.com/heetch/avro/avroregistry"
+)
+
+// registryCommand represents an avro registry subcommand.
+type registryCommand struct {
+	run              func(ctx context.Context, r *avroregistry.Registry, args []string) error
+	args             string
+	minArgs, maxArgs int
+}
+
+var registryCommands = map[string]*registryCommand{
+	"push": {
+		run:     registryPush,
+		args:    "subject file.avsc",
+		minArgs: 2,
+		maxArgs: 2,
+	},
+	"pull": {
+		run:     registryPull,
+		args:    "subject [version]",
+		minArgs: 1,
+		maxArgs: 2,
+	},
+	"versions": {
+		run:     registryVersions,
+		args:    "subject",
+		minArgs: 1,
+		maxArgs: 1,
+	},
+	"set-compat": {
+		run:     registrySetCompat,
+		args:    "subject mode",
+		minArgs: 2,
+		maxArgs: 2,
+	},
+}
+
+func registry(flag *stdflag.FlagSet, args []string) error {
+	if len(args) == 0 || registryCommands[args[0]] == nil {
+		flag.Usage()
+		return errUsage
+	}
+	name := "registry " + args[0]
+	cmd := registryCommands[args[0]]
+	subflag := stdflag.NewFlagSet(name, stdflag.ContinueOnError)
+	subflag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: avro %s [flags] %s\n", name, cmd.args)
+		subflag.PrintDefaults()
+	}
+	serverURL := subflag.String("registry", os.Getenv("AVRO_REGISTRY_URL"), "URL of the schema registry (default $AVRO_REGISTRY_URL)")
+	if err := parseArgs(subflag, args[1:], cmd.minArgs, cmd.maxArgs); err != nil {
+		return err
+	}
+	r, err := avroregistry.New(avroregistry.Params{
+		ServerURL: *serverURL,
+	})
+	if err != nil {
+		return err
+	}
+	return cmd.run(context.Background(), r, subflag.Args())
+}
+
+// registryPush registers the schema in a file with a subject
+// and prints its id.
+func registryPush(ctx context.Context, r *avroregistry.Registry, args []string) error {
+	t, err := readSchema(args[1])
+	if err != nil {
+		return err
+	}
+	id, err := r.Register(ctx, args[0], t)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Println(id)
+	return err
+}
+
+// registryPull prints a schema registered with a subject.
+func registryPull(ctx context.Context, r *avroregistry.Registry, args []string) error {
+	version := 0
+	if len(args) > 1 && args[1] != "latest" {
+		v, err := strconv.Atoi(args[1])
+		if err != nil || v <= 0 {
+			return fmt.Errorf("invalid version %q", args[1])
+		}
+		version = v
+	}
+	t, err := r.Schema(ctx, args[0], version)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Println(t)
+	return err
+}
+
+// registryVersions prints the versions of the schemas
+// registered with a subject, one per line.
+func registryVersions(ctx context.Context, r *avroregistry.Registry, args []string) error {
+	versions, err := r.Versions(ctx, args[0])
+	if err != nil {
+		return err
+	}
+	for _, v := range versions {
+		if _, err := fmt.Println(v); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// registrySetCompat sets the compatibility mode of a subject.
+func registrySetCompat(ctx context.Context, r *avroregistry.Registry, args []string) error {
+	mode, err := avro.ParseCompatMode(args[1])
+	if err != nil {
+		return err
+	}
+	return r.SetCompatibility(ctx, args[0], mode)
+}
@@ -0,0 +1,231 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	stdflag "flag"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/rogpeppe/go-internal/testscript"
+
+	"github.com/heetch/avro"
+	"github.com/heetch/avro/avroocf"
+)
+
+var updateScripts = stdflag.Bool("update-scripts", false, "update testdata/*.txt files with actual command output")
+
+func TestScript(t *testing.T) {
+	testscript.Run(t, testscript.Params{
+		Dir:           "testdata",
+		UpdateScripts: *updateScripts,
+		Setup: func(env *testscript.Env) error {
+			// Each script gets its own registry so that
+			// schema ids don't depend on other scripts.
+			srv := httptest.NewServer(&fakeRegistry{
+				ids:      make(map[string]int),
+				subjects: make(map[string][]string),
+				compat:   make(map[string]string),
+			})
+			env.Defer(srv.Close)
+			env.Vars = append(env.Vars, "AVRO_REGISTRY_URL="+srv.URL)
+			return nil
+		},
+	})
+}
+
+func TestMain(m *testing.M) {
+	os.Exit(testscript.RunMain(m, map[string]func() int{
+		"avro":  main1,
+		"mkocf": mkocf,
+	}))
+}
+
+// mkocf creates an object container file for tests.
+// Usage: mkocf [-codec codec] [-blocksize n] schema.avsc values.jsonl out.avro
+func mkocf() int {
+	flag := stdflag.NewFlagSet("mkocf", stdflag.ContinueOnError)
+	codec := flag.String("codec", "", "codec")
+	blockSize := flag.Int("blocksize", 0, "block size")
+	if flag.Parse(os.Args[1:]) != nil || flag.NArg() != 3 {
+		return 2
+	}
+	if err := mkocf1(flag.Arg(0), flag.Arg(1), flag.Arg(2), *codec, *blockSize); err != nil {
+		fmt.Fprintf(os.Stderr, "mkocf: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+func mkocf1(schemaFile, valuesFile, outFile, codec string, blockSize int) error {
+	data, err := ioutil.ReadFile(schemaFile)
+	if err != nil {
+		return err
+	}
+	t, err := avro.ParseType(string(data))
+	if err != nil {
+		return err
+	}
+	goType, err := avro.StructOf(t)
+	if err != nil {
+		return err
+	}
+	in, err := os.Open(valuesFile)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+	out, err := os.Create(outFile)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	w, err := avroocf.NewWriter(out, t, &avroocf.WriterOptions{
+		Codec:     codec,
+		BlockSize: blockSize,
+		Meta: map[string][]byte{
+			"user.origin": []byte("mkocf"),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bufio.NewReader(in))
+	for dec.More() {
+		v := reflect.New(goType)
+		if err := dec.Decode(v.Interface()); err != nil {
+			return err
+		}
+		if err := w.Write(v.Elem().Interface()); err != nil {
+			return err
+		}
+	}
+	return w.Close()
+}
+
+// fakeRegistry implements enough of the schema registry API
+// for the avro registry subcommands and the -registry flags.
+type fakeRegistry struct {
+	mu sync.Mutex
+	// ids holds the id of each registered schema.
+	ids map[string]int
+	// subjects holds the schemas registered with each subject,
+	// indexed by version-1.
+	subjects map[string][]string
+	// compat holds the compatibility mode of each subject.
+	compat map[string]string
+}
+
+func (r *fakeRegistry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	path := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	switch {
+	case req.Method == "POST" && len(path) == 3 && path[0] == "subjects" && path[2] == "versions":
+		var body struct {
+			Schema string `json:"schema"`
+		}
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			writeJSON(w, http.StatusUnprocessableEntity, map[string]interface{}{"error_code": 42201, "message": err.Error()})
+			return
+		}
+		id, ok := r.ids[body.Schema]
+		if !ok {
+			id = len(r.ids) + 1
+			r.ids[body.Schema] = id
+		}
+		versions := r.subjects[path[1]]
+		if len(versions) == 0 || versions[len(versions)-1] != body.Schema {
+			r.subjects[path[1]] = append(versions, body.Schema)
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{"id": id})
+	case req.Method == "POST" && len(path) == 2 && path[0] == "subjects":
+		// Look up the version of a schema registered with the subject.
+		var body struct {
+			Schema string `json:"schema"`
+		}
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			writeJSON(w, http.StatusUnprocessableEntity, map[string]interface{}{"error_code": 42201, "message": err.Error()})
+			return
+		}
+		for i, schema := range r.subjects[path[1]] {
+			if schema == body.Schema {
+				writeJSON(w, http.StatusOK, map[string]interface{}{
+					"subject": path[1],
+					"version": i + 1,
+					"id":      r.ids[schema],
+					"schema":  schema,
+				})
+				return
+			}
+		}
+		writeJSON(w, http.StatusNotFound, map[string]interface{}{"error_code": 40403, "message": "Schema not found."})
+	case req.Method == "GET" && len(path) == 3 && path[0] == "schemas" && path[1] == "ids":
+		id, _ := strconv.Atoi(path[2])
+		for schema, schemaID := range r.ids {
+			if schemaID == id {
+				writeJSON(w, http.StatusOK, map[string]interface{}{"schema": schema})
+				return
+			}
+		}
+		writeJSON(w, http.StatusNotFound, map[string]interface{}{"error_code": 40403, "message": "Schema not found."})
+	case req.Method == "GET" && len(path) >= 3 && path[0] == "subjects" && path[2] == "versions":
+		versions := r.subjects[path[1]]
+		if len(versions) == 0 {
+			writeJSON(w, http.StatusNotFound, map[string]interface{}{"error_code": 40401, "message": "Subject not found."})
+			return
+		}
+		if len(path) == 3 {
+			vs := make([]int, len(versions))
+			for i := range vs {
+				vs[i] = i + 1
+			}
+			writeJSON(w, http.StatusOK, vs)
+			return
+		}
+		v := len(versions)
+		if path[3] != "latest" {
+			v, _ = strconv.Atoi(path[3])
+		}
+		if v < 1 || v > len(versions) {
+			writeJSON(w, http.StatusNotFound, map[string]interface{}{"error_code": 40402, "message": "Version not found."})
+			return
+		}
+		writeJSON(w, http.StatusOK, map[string]interface{}{
+			"subject": path[1],
+			"version": v,
+			"id":      r.ids[versions[v-1]],
+			"schema":  versions[v-1],
+		})
+	case req.Method == "PUT" && len(path) == 2 && path[0] == "config":
+		var body struct {
+			Compatibility string `json:"compatibility"`
+		}
+		if err := json.NewDecoder(req.Body).Decode(&body); err != nil {
+			writeJSON(w, http.StatusUnprocessableEntity, map[string]interface{}{"error_code": 42203, "message": err.Error()})
+			return
+		}
+		r.compat[path[1]] = body.Compatibility
+		writeJSON(w, http.StatusOK, body)
+	default:
+		writeJSON(w, http.StatusNotFound, map[string]interface{}{"error_code": 404, "message": "Not found."})
+	}
+}
+
+func writeJSON(w http.ResponseWriter, status int, v interface{}) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		panic(err)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	w.Write(data)
+}
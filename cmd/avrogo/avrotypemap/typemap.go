@@ -103,7 +103,7 @@ func (w *walker) walk(at schema.AvroType, t reflect.Type, info typeinfo.Info) er
 				return fmt.Errorf("field count mismatch")
 			}
 			for i, f := range def.Fields() {
-				ft := t.Field(info.Entries[i].FieldIndex)
+				ft := t.FieldByIndex(info.Entries[i].FieldIndex)
 				err := w.walk(f.Type(), ft.Type, info.Entries[i])
 				if err != nil {
 					return err
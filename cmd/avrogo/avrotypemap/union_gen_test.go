@@ -1,4 +1,9 @@
 // Code generated by avrogen. DO NOT EDIT.
+//
+// Schema fingerprints:
+//	U 0x1e15ec1f1cdd0000
+//	UR1 0x8fc01124cfd3a44e
+//	UR2 0xbc36ecb9528bf447
 
 package avrotypemap_test
 
@@ -25,8 +30,10 @@ func (U) AvroRecord() avrotypegen.RecordInfo {
 				Type: new(interface{}),
 				Union: []avrotypegen.UnionInfo{{
 					Type: new(UR1),
+					Name: "UR1",
 				}, {
 					Type: new(UR2),
+					Name: "UR2",
 				}},
 			},
 		},
@@ -0,0 +1,264 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rogpeppe/gogen-avro/v7/schema"
+)
+
+// maxRandomDepth holds the depth of nested records beyond which
+// the generated randomize methods stop choosing non-null union
+// members and non-empty arrays and maps, so random values of
+// recursive types are always finite.
+const maxRandomDepth = 4
+
+// Builder returns the source of the RBuilder type for the record R,
+// which has a WithF method for each field F and a Random method that
+// sets all the fields to random values, or the empty string if builders
+// aren't enabled.
+func (gc *generateContext) Builder(t *schema.RecordDefinition) (string, error) {
+	if !gc.opts.builders {
+		return "", nil
+	}
+	name := defName(t)
+	rand := gc.addImport("math/rand")
+	var w strings.Builder
+	fprintf(&w, `
+// %[1]sBuilder builds %[1]s values, for example as test fixtures.
+type %[1]sBuilder struct {
+	r %[1]s
+}
+
+// New%[1]sBuilder returns a builder for %[1]s values.
+`, name)
+	if gc.opts.constructors && !hasField(t, "SetDefaults") {
+		fprintf(&w, `// The fields start with their default values.
+func New%[1]sBuilder() *%[1]sBuilder {
+	b := new(%[1]sBuilder)
+	b.r.SetDefaults()
+	return b
+}
+`, name)
+	} else {
+		fprintf(&w, `func New%[1]sBuilder() *%[1]sBuilder {
+	return new(%[1]sBuilder)
+}
+`, name)
+	}
+	fprintf(&w, `
+// Build returns the value built so far.
+func (b *%[1]sBuilder) Build() %[1]s {
+	return b.r
+}
+
+// Random sets all the fields to random values chosen with rnd.
+// Enum values are always valid symbols, and fixed values and
+// union members always fit the schema. Fields whose Go types
+// aren't generated from the schema are left unchanged.
+func (b *%[1]sBuilder) Random(rnd *%[2]s.Rand) *%[1]sBuilder {
+	b.r.randomize(rnd, 0)
+	return b
+}
+`, name, rand)
+	for _, f := range t.Fields() {
+		fname, err := fieldGoName(f.Name())
+		if err != nil {
+			return "", err
+		}
+		fprintf(&w, `
+// With%[2]s sets the %[2]s field to v.
+func (b *%[1]sBuilder) With%[2]s(v %[3]s) *%[1]sBuilder {
+	b.r.%[2]s = v
+	return b
+}
+`, name, fname, gc.GoTypeOf(f.Type()).GoType)
+	}
+	g := &randomGen{
+		gc: gc,
+	}
+	g.printf(`
+// randomize sets the fields of r to random values chosen with rnd.
+// The depth holds how deeply r is nested inside other records.
+func (r *%s) randomize(rnd *%s.Rand, depth int) {
+`, name, rand)
+	for _, f := range t.Fields() {
+		fname, _ := fieldGoName(f.Name())
+		g.random(f.Type(), "r."+fname)
+	}
+	g.printf("}\n")
+	w.WriteString(g.w.String())
+	return w.String(), nil
+}
+
+// hasField reports whether the record t has a field
+// with the given Go name.
+func hasField(t *schema.RecordDefinition, goName string) bool {
+	for _, f := range t.Fields() {
+		if name, err := fieldGoName(f.Name()); err == nil && name == goName {
+			return true
+		}
+	}
+	return false
+}
+
+// randomGen generates the body of the randomize
+// method for a record.
+type randomGen struct {
+	gc *generateContext
+	w  strings.Builder
+	// n is used to generate unique names for local variables.
+	n int
+}
+
+func (g *randomGen) printf(f string, a ...interface{}) {
+	fmt.Fprintf(&g.w, f, a...)
+}
+
+// newVar returns a new local variable name with the given prefix.
+func (g *randomGen) newVar(prefix string) string {
+	g.n++
+	return fmt.Sprintf("%s%d", prefix, g.n)
+}
+
+// random generates code to set the addressable Go expression v,
+// of Avro type at, to a random value. Values of external types
+// and types specified with -logicaltype are left unchanged.
+func (g *randomGen) random(at schema.AvroType, v string) {
+	if _, ok := g.gc.logicalGoType(at); ok {
+		return
+	}
+	switch at := at.(type) {
+	case *schema.NullField:
+	case *schema.BoolField:
+		g.printf("%s = rnd.Intn(2) == 1\n", v)
+	case *schema.IntField:
+		g.printf("%s = int(int32(rnd.Uint32()))\n", v)
+	case *schema.LongField:
+		if unit := timestampUnit(at); unit != "" {
+			// Choose a time within about 35 years of the epoch.
+			if unit == "Millis" {
+				g.printf("%[1]s = %[2]s.Unix(0, rnd.Int63n(1<<40)*int64(%[2]s.Millisecond)).UTC()\n", v, g.gc.addImport("time"))
+			} else {
+				g.printf("%[1]s = %[2]s.Unix(0, rnd.Int63n(1<<50)*int64(%[2]s.Microsecond)).UTC()\n", v, g.gc.addImport("time"))
+			}
+		} else {
+			g.printf("%s = int64(rnd.Uint64())\n", v)
+		}
+	case *schema.FloatField:
+		g.printf("%s = rnd.Float32()\n", v)
+	case *schema.DoubleField:
+		g.printf("%s = rnd.Float64()\n", v)
+	case *schema.BytesField:
+		g.printf("%s = make([]byte, rnd.Intn(8))\n", v)
+		g.printf("rnd.Read(%s)\n", v)
+	case *schema.StringField:
+		g.randomString(v)
+	case *schema.ArrayField:
+		n, x := g.newVar("n"), g.newVar("x")
+		g.printf("%s = nil\n", v)
+		g.printf("if depth < %d {\n", maxRandomDepth)
+		g.printf("for %[1]s := rnd.Intn(3); %[1]s > 0; %[1]s-- {\n", n)
+		g.printf("var %s %s\n", x, g.gc.GoTypeOf(at.ItemType()).GoType)
+		g.random(at.ItemType(), x)
+		g.printf("%[1]s = append(%[1]s, %[2]s)\n", v, x)
+		g.printf("}\n}\n")
+	case *schema.MapField:
+		n, k, x := g.newVar("n"), g.newVar("k"), g.newVar("x")
+		itemType := g.gc.GoTypeOf(at.ItemType()).GoType
+		g.printf("%s = make(map[string]%s)\n", v, itemType)
+		g.printf("if depth < %d {\n", maxRandomDepth)
+		g.printf("for %[1]s := rnd.Intn(3); %[1]s > 0; %[1]s-- {\n", n)
+		g.printf("var %s string\n", k)
+		g.randomString(k)
+		g.printf("var %s %s\n", x, itemType)
+		g.random(at.ItemType(), x)
+		g.printf("%s[%s] = %s\n", v, k, x)
+		g.printf("}\n}\n")
+	case *schema.UnionField:
+		g.randomUnion(at, v)
+	case *schema.Reference:
+		if _, ok := g.gc.extTypes[at.TypeName]; ok {
+			return
+		}
+		switch def := at.Def.(type) {
+		case *schema.EnumDefinition:
+			if len(def.Symbols()) > 0 {
+				g.printf("%s = %s(rnd.Intn(%d))\n", v, g.gc.GoTypeOf(at).GoType, len(def.Symbols()))
+			}
+		case *schema.FixedDefinition:
+			g.printf("rnd.Read(%s[:])\n", v)
+		case *schema.RecordDefinition:
+			g.printf("%s.randomize(rnd, depth+1)\n", v)
+		}
+	}
+}
+
+// randomString generates code to set the addressable Go
+// expression v to a short random string of lower case letters.
+func (g *randomGen) randomString(v string) {
+	buf, i := g.newVar("buf"), g.newVar("i")
+	g.printf("%s := make([]byte, rnd.Intn(8))\n", buf)
+	g.printf("for %s := range %s {\n", i, buf)
+	g.printf("%s[%s] = 'a' + byte(rnd.Intn(26))\n", buf, i)
+	g.printf("}\n")
+	g.printf("%s = string(%s)\n", v, buf)
+}
+
+func (g *randomGen) randomUnion(at *schema.UnionField, v string) {
+	types := at.AvroTypes()
+	info := g.gc.GoTypeOf(at)
+	if info.GoType != "interface{}" {
+		// It's a union of null and one other type.
+		index := 1
+		if isNullField(types[1]) {
+			index = 0
+		}
+		if nt, ok := g.gc.nullableType(g.gc.GoTypeOf(types[index])); ok {
+			g.printf("%s = %s{}\n", v, info.GoType)
+			g.printf("if rnd.Intn(2) == 0 {\n")
+			g.printf("%s.Valid = true\n", v)
+			if nt.GoType == "int32" {
+				// It's a sql.NullInt32 so the value isn't an int.
+				g.printf("%s.%s = int32(rnd.Uint32())\n", v, nt.Field)
+			} else {
+				g.random(types[index], v+"."+nt.Field)
+			}
+			g.printf("}\n")
+			return
+		}
+		g.printf("%s = nil\n", v)
+		g.printf("if depth < %d && rnd.Intn(2) == 0 {\n", maxRandomDepth)
+		g.printf("%s = new(%s)\n", v, strings.TrimPrefix(info.GoType, "*"))
+		g.random(types[index], "(*"+v+")")
+		g.printf("}\n")
+		return
+	}
+	hasNull := false
+	for _, t := range types {
+		if isNullField(t) {
+			hasNull = true
+		}
+	}
+	if hasNull {
+		g.printf("if depth >= %d {\n", maxRandomDepth)
+		g.printf("%s = nil\n", v)
+		g.printf("} else {\n")
+	}
+	g.printf("switch rnd.Intn(%d) {\n", len(types))
+	for i, t := range types {
+		g.printf("case %d:\n", i)
+		if isNullField(t) {
+			g.printf("%s = nil\n", v)
+			continue
+		}
+		x := g.newVar("x")
+		g.printf("var %s %s\n", x, g.gc.GoTypeOf(t).GoType)
+		g.random(t, x)
+		g.printf("%s = %s\n", v, x)
+	}
+	g.printf("}\n")
+	if hasNull {
+		g.printf("}\n")
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/rogpeppe/gogen-avro/v7/schema"
+)
+
+// FixedMethods returns the source of the Hex, Bytes and SetBytes
+// methods for the given fixed type, or the empty string if they
+// aren't enabled.
+func (gc *generateContext) FixedMethods(t *schema.FixedDefinition) (string, error) {
+	if !gc.opts.byteHelpers {
+		return "", nil
+	}
+	var w strings.Builder
+	fprintf(&w, `
+// Hex returns the hexadecimal encoding of f.
+func (f %[1]s) Hex() string {
+	return %[3]s.EncodeToString(f[:])
+}
+
+// Bytes returns the contents of f in a new slice.
+func (f %[1]s) Bytes() []byte {
+	return append([]byte(nil), f[:]...)
+}
+
+// SetBytes sets f to the contents of b, which
+// must hold exactly %[2]d bytes.
+func (f *%[1]s) SetBytes(b []byte) error {
+	if len(b) != len(f) {
+		return %[4]s.Errorf("%[1]s value has wrong length (got %%d; want %[2]d)", len(b))
+	}
+	copy(f[:], b)
+	return nil
+}
+`, defName(t), t.SizeBytes(), gc.addImport("encoding/hex"), gc.addImport("fmt"))
+	return w.String(), nil
+}
+
+// BytesAccessors returns the source of the FHex methods for all
+// the bytes fields F in the given record, or the empty string if
+// they aren't enabled. Fields for which the method names would
+// clash with other names are omitted.
+func (gc *generateContext) BytesAccessors(t *schema.RecordDefinition) (string, error) {
+	if !gc.opts.byteHelpers {
+		return "", nil
+	}
+	used, err := gc.recordNames(t)
+	if err != nil {
+		return "", err
+	}
+	name := defName(t)
+	var w strings.Builder
+	for _, f := range t.Fields() {
+		if _, ok := f.Type().(*schema.BytesField); !ok {
+			continue
+		}
+		if _, ok := gc.logicalGoType(f.Type()); ok {
+			continue
+		}
+		fname, _ := fieldGoName(f.Name())
+		if !addNames(used, []string{fname + "Hex"}) {
+			continue
+		}
+		fprintf(&w, `
+// %[2]sHex returns the hexadecimal encoding of %[2]s.
+func (r %[1]s) %[2]sHex() string {
+	return %[3]s.EncodeToString(r.%[2]s)
+}
+`, name, fname, gc.addImport("encoding/hex"))
+	}
+	return w.String(), nil
+}
@@ -0,0 +1,310 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rogpeppe/gogen-avro/v7/schema"
+)
+
+// CloneMethods returns the source of the Clone and Equal methods
+// for the given record, or the empty string if they aren't enabled
+// or can't be generated for it.
+func (gc *generateContext) CloneMethods(t *schema.RecordDefinition) (string, error) {
+	if !gc.opts.clone || !gc.canClone(t) {
+		return "", nil
+	}
+	name := defName(t)
+	g := &cloneGen{
+		gc: gc,
+	}
+	g.printf(`
+// Clone returns a deep copy of r that doesn't share any
+// slices, maps or pointers with it. Values of types that
+// aren't generated from the schema are copied by assignment.
+func (r %s) Clone() %s {
+	c := r
+`, name, name)
+	for _, f := range t.Fields() {
+		fname, err := fieldGoName(f.Name())
+		if err != nil {
+			return "", err
+		}
+		if g.gc.needsClone(f.Type()) {
+			g.clone(f.Type(), "c."+fname, "r."+fname)
+		}
+	}
+	g.printf("return c\n}\n")
+
+	g.printf(`
+// Equal reports whether r and other hold the same values,
+// following pointers and comparing union members by their
+// dynamic types. Nil and empty slices and maps are considered
+// equal because they encode the same way.
+func (r %s) Equal(other %s) bool {
+`, name, name)
+	for _, f := range t.Fields() {
+		fname, _ := fieldGoName(f.Name())
+		g.equal(f.Type(), "r."+fname, "other."+fname)
+	}
+	g.printf("return true\n}\n")
+	return g.w.String(), nil
+}
+
+// canClone reports whether Clone and Equal methods can be
+// generated for the record t. That's not possible if one
+// of its Go field names would clash with the methods.
+func (gc *generateContext) canClone(t *schema.RecordDefinition) bool {
+	for _, f := range t.Fields() {
+		name, err := fieldGoName(f.Name())
+		if err != nil || name == "Clone" || name == "Equal" {
+			return false
+		}
+	}
+	return true
+}
+
+// hasClone reports whether values of Avro type at have
+// generated Clone and Equal methods.
+func (gc *generateContext) hasClone(at schema.AvroType) bool {
+	ref, ok := at.(*schema.Reference)
+	if !ok {
+		return false
+	}
+	if _, ok := gc.extTypes[ref.TypeName]; ok {
+		return false
+	}
+	def, ok := ref.Def.(*schema.RecordDefinition)
+	return ok && gc.canClone(def)
+}
+
+// needsClone reports whether Go values of Avro type at
+// can share memory with copies made by assignment.
+func (gc *generateContext) needsClone(at schema.AvroType) bool {
+	if _, ok := gc.logicalGoType(at); ok {
+		return false
+	}
+	switch at := at.(type) {
+	case *schema.BytesField, *schema.ArrayField, *schema.MapField:
+		return true
+	case *schema.UnionField:
+		info := gc.GoTypeOf(at)
+		if info.GoType == "interface{}" {
+			for _, t := range at.AvroTypes() {
+				if gc.needsClone(t) {
+					return true
+				}
+			}
+			return false
+		}
+		// The database/sql nullable types and the Optional
+		// types only hold values that can be copied by assignment.
+		return !isNullableType(info.GoType)
+	case *schema.Reference:
+		return gc.hasClone(at)
+	}
+	return false
+}
+
+// cloneGen generates the bodies of the Clone
+// and Equal methods for a record.
+type cloneGen struct {
+	gc *generateContext
+	w  strings.Builder
+	// n is used to generate unique names for local variables.
+	n int
+}
+
+func (g *cloneGen) printf(f string, a ...interface{}) {
+	fmt.Fprintf(&g.w, f, a...)
+}
+
+// newVar returns a new local variable name with the given prefix.
+func (g *cloneGen) newVar(prefix string) string {
+	g.n++
+	return fmt.Sprintf("%s%d", prefix, g.n)
+}
+
+// clone generates code to make the addressable Go expression dst,
+// of Avro type at, which holds a copy of src made by assignment,
+// into a deep copy of src. It's only called for types for which
+// needsClone returns true.
+func (g *cloneGen) clone(at schema.AvroType, dst, src string) {
+	switch at := at.(type) {
+	case *schema.BytesField:
+		g.printf("if %s != nil {\n", src)
+		g.printf("%s = append([]byte{}, %s...)\n", dst, src)
+		g.printf("}\n")
+	case *schema.ArrayField:
+		g.printf("if %s != nil {\n", src)
+		g.printf("%s = make(%s, len(%s))\n", dst, g.gc.GoTypeOf(at).GoType, src)
+		g.printf("copy(%s, %s)\n", dst, src)
+		if g.gc.needsClone(at.ItemType()) {
+			i := g.newVar("i")
+			g.printf("for %s := range %s {\n", i, src)
+			g.clone(at.ItemType(), dst+"["+i+"]", src+"["+i+"]")
+			g.printf("}\n")
+		}
+		g.printf("}\n")
+	case *schema.MapField:
+		m, k, x := g.newVar("m"), g.newVar("k"), g.newVar("x")
+		g.printf("if %s != nil {\n", src)
+		g.printf("%s := make(%s, len(%s))\n", m, g.gc.GoTypeOf(at).GoType, src)
+		g.printf("for %s, %s := range %s {\n", k, x, src)
+		g.printf("%s[%s] = %s\n", m, k, g.cloneOf(at.ItemType(), x))
+		g.printf("}\n")
+		g.printf("%s = %s\n", dst, m)
+		g.printf("}\n")
+	case *schema.UnionField:
+		g.cloneUnion(at, dst, src)
+	case *schema.Reference:
+		g.printf("%s = %s.Clone()\n", dst, src)
+	}
+}
+
+func (g *cloneGen) cloneUnion(at *schema.UnionField, dst, src string) {
+	types := at.AvroTypes()
+	info := g.gc.GoTypeOf(at)
+	if info.GoType != "interface{}" {
+		// It's a pointer to the non-null member of the union.
+		index := 1
+		if isNullField(types[1]) {
+			index = 0
+		}
+		p := g.newVar("p")
+		g.printf("if %s != nil {\n", src)
+		g.printf("%s := new(%s)\n", p, strings.TrimPrefix(info.GoType, "*"))
+		g.printf("*%s = %s\n", p, g.cloneOf(types[index], "(*"+src+")"))
+		g.printf("%s = %s\n", dst, p)
+		g.printf("}\n")
+		return
+	}
+	x := g.newVar("x")
+	g.printf("switch %s := %s.(type) {\n", x, src)
+	for _, t := range types {
+		if !g.gc.needsClone(t) {
+			continue
+		}
+		g.printf("case %s:\n", g.gc.GoTypeOf(t).GoType)
+		g.printf("%s = %s\n", dst, g.cloneOf(t, x))
+	}
+	g.printf("}\n")
+}
+
+// cloneOf returns an expression holding a deep copy of the
+// value of the Go expression v, of Avro type at, generating
+// code to make the copy if needed.
+func (g *cloneGen) cloneOf(at schema.AvroType, v string) string {
+	if !g.gc.needsClone(at) {
+		return v
+	}
+	if _, ok := at.(*schema.Reference); ok {
+		return v + ".Clone()"
+	}
+	y := g.newVar("y")
+	g.printf("%s := %s\n", y, v)
+	g.clone(at, y, v)
+	return y
+}
+
+// equal generates code that returns false if the values of the
+// Go expressions a and b, of Avro type at, aren't equal.
+func (g *cloneGen) equal(at schema.AvroType, a, b string) {
+	if _, ok := g.gc.logicalGoType(at); ok {
+		g.deepEqual(a, b)
+		return
+	}
+	switch at := at.(type) {
+	case *schema.NullField:
+	case *schema.LongField:
+		if timestampUnit(at) != "" {
+			g.printf("if !%s.Equal(%s) {\nreturn false\n}\n", a, b)
+		} else {
+			g.printf("if %s != %s {\nreturn false\n}\n", a, b)
+		}
+	case *schema.BytesField:
+		g.printf("if !%s.Equal(%s, %s) {\nreturn false\n}\n", g.gc.addImport("bytes"), a, b)
+	case *schema.ArrayField:
+		i := g.newVar("i")
+		g.printf("if len(%s) != len(%s) {\nreturn false\n}\n", a, b)
+		g.printf("for %s := range %s {\n", i, a)
+		g.equal(at.ItemType(), a+"["+i+"]", b+"["+i+"]")
+		g.printf("}\n")
+	case *schema.MapField:
+		k, x, y, ok := g.newVar("k"), g.newVar("x"), g.newVar("y"), g.newVar("ok")
+		g.printf("if len(%s) != len(%s) {\nreturn false\n}\n", a, b)
+		g.printf("for %s, %s := range %s {\n", k, x, a)
+		g.printf("%s, %s := %s[%s]\n", y, ok, b, k)
+		g.printf("if !%s {\nreturn false\n}\n", ok)
+		g.equal(at.ItemType(), x, y)
+		g.printf("}\n")
+	case *schema.UnionField:
+		g.equalUnion(at, a, b)
+	case *schema.Reference:
+		if _, ok := g.gc.extTypes[at.TypeName]; ok {
+			g.deepEqual(a, b)
+			return
+		}
+		switch def := at.Def.(type) {
+		case *schema.RecordDefinition:
+			if g.gc.canClone(def) {
+				g.printf("if !%s.Equal(%s) {\nreturn false\n}\n", a, b)
+			} else {
+				g.deepEqual(a, b)
+			}
+		default:
+			g.printf("if %s != %s {\nreturn false\n}\n", a, b)
+		}
+	default:
+		g.printf("if %s != %s {\nreturn false\n}\n", a, b)
+	}
+}
+
+func (g *cloneGen) equalUnion(at *schema.UnionField, a, b string) {
+	types := at.AvroTypes()
+	info := g.gc.GoTypeOf(at)
+	if info.GoType != "interface{}" {
+		// It's a union of null and one other type.
+		if isNullableType(info.GoType) {
+			g.printf("if %s != %s {\nreturn false\n}\n", a, b)
+			return
+		}
+		index := 1
+		if isNullField(types[1]) {
+			index = 0
+		}
+		g.printf("if (%s == nil) != (%s == nil) {\nreturn false\n}\n", a, b)
+		g.printf("if %s != nil {\n", a)
+		g.equal(types[index], "(*"+a+")", "(*"+b+")")
+		g.printf("}\n")
+		return
+	}
+	x := g.newVar("x")
+	g.printf("switch %s := %s.(type) {\n", x, a)
+	for _, t := range types {
+		if isNullField(t) {
+			null := g.gc.typegenName("Null")
+			g.printf("case nil, %s:\n", null)
+			g.printf("switch %s.(type) {\n", b)
+			g.printf("case nil, %s:\n", null)
+			g.printf("default:\nreturn false\n}\n")
+			continue
+		}
+		y, ok := g.newVar("y"), g.newVar("ok")
+		g.printf("case %s:\n", g.gc.GoTypeOf(t).GoType)
+		g.printf("%s, %s := %s.(%s)\n", y, ok, b, g.gc.GoTypeOf(t).GoType)
+		g.printf("if !%s {\nreturn false\n}\n", ok)
+		g.equal(t, x, y)
+	}
+	g.printf("default:\n")
+	// The union holds a value of a type that isn't in the schema.
+	g.deepEqual(a, b)
+	g.printf("}\n")
+}
+
+// deepEqual generates code that returns false if the values of
+// the Go expressions a and b aren't equal according to
+// reflect.DeepEqual.
+func (g *cloneGen) deepEqual(a, b string) {
+	g.printf("if !%s.DeepEqual(%s, %s) {\nreturn false\n}\n", g.gc.addImport("reflect"), a, b)
+}
@@ -0,0 +1,375 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rogpeppe/gogen-avro/v7/schema"
+)
+
+// BinaryMethods returns the source of the MarshalBinary and
+// UnmarshalBinary methods for the given record, or the empty
+// string if the methods aren't enabled or can't be generated
+// for the record. In self-contained code, where the methods are
+// the only way to encode and decode records, it's an error if they
+// can't be generated. Reflection-free code also gets an
+// AppendBinary method.
+func (gc *generateContext) BinaryMethods(t *schema.RecordDefinition) (string, error) {
+	if !gc.opts.binary {
+		return "", nil
+	}
+	if !gc.canGenerateCodec(t, make(map[schema.QualifiedName]bool)) {
+		if gc.opts.selfContained {
+			return "", fmt.Errorf("cannot generate self-contained code for %s because it uses external types, Go types specified for logical types, or fields named MarshalBinary or UnmarshalBinary", t.AvroName())
+		}
+		return "", nil
+	}
+	if gc.opts.noReflect {
+		if err := gc.checkNoReflect(t); err != nil {
+			return "", err
+		}
+	}
+	name := defName(t)
+	g := &codecGen{
+		gc: gc,
+	}
+	g.printf("\n// avroEncode writes the Avro binary encoding of r to e.\n")
+	g.printf("func (r *%s) avroEncode(e *%s) {\n", name, gc.typegenName("Encoder"))
+	for _, f := range t.Fields() {
+		fname, err := fieldGoName(f.Name())
+		if err != nil {
+			return "", err
+		}
+		g.encode(f.Type(), "r."+fname)
+	}
+	g.printf("}\n")
+
+	g.printf("\n// avroDecode reads Avro binary data written\n")
+	g.printf("// with the schema of %s from d into r.\n", name)
+	g.printf("func (r *%s) avroDecode(d *%s) {\n", name, gc.typegenName("Decoder"))
+	for _, f := range t.Fields() {
+		fname, _ := fieldGoName(f.Name())
+		g.decode(f.Type(), "r."+fname)
+	}
+	g.printf("}\n")
+
+	g.printf(`
+// MarshalBinary implements encoding.BinaryMarshaler
+// by returning the Avro binary encoding of r using
+// the schema of %[1]s.
+func (r %[1]s) MarshalBinary() ([]byte, error) {
+	var e %[2]s
+	r.avroEncode(&e)
+	return e.Bytes()
+}
+
+// UnmarshalBinary implements encoding.BinaryUnmarshaler
+// by decoding Avro binary data written with the schema
+// of %[1]s.
+func (r *%[1]s) UnmarshalBinary(data []byte) error {
+	d := %[3]s(data)
+	r.avroDecode(d)
+	return d.Finish()
+}
+`, name, gc.typegenName("Encoder"), gc.typegenName("NewDecoder"))
+	if gc.opts.noReflect {
+		g.printf(`
+// AppendBinary appends the Avro binary encoding of r using
+// the schema of %[1]s to buf and returns the extended slice,
+// so that a buffer can be reused for many records.
+func (r %[1]s) AppendBinary(buf []byte) ([]byte, error) {
+	e := %[2]s{buf: buf}
+	r.avroEncode(&e)
+	return e.Bytes()
+}
+`, name, gc.typegenName("Encoder"))
+	}
+	return g.w.String(), nil
+}
+
+// checkNoReflect returns an error if reflection-free code
+// can't be generated for the record t because one of its
+// fields is represented by interface{} or is named AppendBinary.
+func (gc *generateContext) checkNoReflect(t *schema.RecordDefinition) error {
+	for _, f := range t.Fields() {
+		name, _ := fieldGoName(f.Name())
+		if name == "AppendBinary" {
+			return fmt.Errorf("cannot generate reflection-free code for %s because it has a field named AppendBinary", t.AvroName())
+		}
+		if strings.Contains(gc.GoTypeOf(f.Type()).GoType, "interface{}") {
+			return fmt.Errorf("cannot generate reflection-free code for %s because field %s is represented by interface{}", t.AvroName(), f.Name())
+		}
+	}
+	return nil
+}
+
+// canGenerateCodec reports whether encode and decode methods
+// can be generated for the record t. That's not possible if
+// it uses types defined elsewhere, Go types specified for logical
+// types, or if its Go field names would clash with the methods.
+// The visited map holds the records that have already been checked.
+func (gc *generateContext) canGenerateCodec(t *schema.RecordDefinition, visited map[schema.QualifiedName]bool) bool {
+	if visited[t.AvroName()] {
+		return true
+	}
+	visited[t.AvroName()] = true
+	for _, f := range t.Fields() {
+		name, err := fieldGoName(f.Name())
+		if err != nil || name == "MarshalBinary" || name == "UnmarshalBinary" {
+			return false
+		}
+		if !gc.canGenerateTypeCodec(f.Type(), visited) {
+			return false
+		}
+	}
+	return true
+}
+
+func (gc *generateContext) canGenerateTypeCodec(at schema.AvroType, visited map[schema.QualifiedName]bool) bool {
+	if _, ok := gc.logicalGoType(at); ok {
+		return false
+	}
+	switch at := at.(type) {
+	case *schema.ArrayField:
+		return gc.canGenerateTypeCodec(at.ItemType(), visited)
+	case *schema.MapField:
+		return gc.canGenerateTypeCodec(at.ItemType(), visited)
+	case *schema.UnionField:
+		goTypes := make(map[string]bool)
+		for _, t := range at.AvroTypes() {
+			if !gc.canGenerateTypeCodec(t, visited) {
+				return false
+			}
+			// The Go types are used in a type switch,
+			// so they must all be different.
+			goType := gc.GoTypeOf(t).GoType
+			if goTypes[goType] {
+				return false
+			}
+			goTypes[goType] = true
+		}
+	case *schema.Reference:
+		if _, ok := gc.extTypes[at.TypeName]; ok {
+			return false
+		}
+		if def, ok := at.Def.(*schema.RecordDefinition); ok {
+			return gc.canGenerateCodec(def, visited)
+		}
+	}
+	return true
+}
+
+// codecGen generates the bodies of the encode
+// and decode methods for a record.
+type codecGen struct {
+	gc *generateContext
+	w  strings.Builder
+	// n is used to generate unique names for local variables.
+	n int
+}
+
+func (g *codecGen) printf(f string, a ...interface{}) {
+	fmt.Fprintf(&g.w, f, a...)
+}
+
+// newVar returns a new local variable name with the given prefix.
+func (g *codecGen) newVar(prefix string) string {
+	g.n++
+	return fmt.Sprintf("%s%d", prefix, g.n)
+}
+
+// encode generates code to encode the value of the addressable
+// Go expression v, of Avro type at.
+func (g *codecGen) encode(at schema.AvroType, v string) {
+	switch at := at.(type) {
+	case *schema.NullField:
+	case *schema.BoolField:
+		g.printf("e.WriteBool(%s)\n", v)
+	case *schema.IntField:
+		g.printf("e.WriteLong(int64(%s))\n", v)
+	case *schema.LongField:
+		if unit := timestampUnit(at); unit != "" {
+			g.printf("e.WriteTimestamp%s(%s)\n", unit, v)
+		} else {
+			g.printf("e.WriteLong(%s)\n", v)
+		}
+	case *schema.FloatField:
+		g.printf("e.WriteFloat(%s)\n", v)
+	case *schema.DoubleField:
+		g.printf("e.WriteDouble(%s)\n", v)
+	case *schema.BytesField:
+		g.printf("e.WriteBytes(%s)\n", v)
+	case *schema.StringField:
+		g.printf("e.WriteString(%s)\n", v)
+	case *schema.ArrayField:
+		i := g.newVar("i")
+		g.printf("e.WriteLong(int64(len(%s)))\n", v)
+		g.printf("for %s := range %s {\n", i, v)
+		g.encode(at.ItemType(), v+"["+i+"]")
+		g.printf("}\n")
+		g.printf("if len(%s) > 0 {\ne.WriteLong(0)\n}\n", v)
+	case *schema.MapField:
+		k, x := g.newVar("k"), g.newVar("x")
+		g.printf("e.WriteLong(int64(len(%s)))\n", v)
+		g.printf("for %s, %s := range %s {\n", k, x, v)
+		g.printf("e.WriteString(%s)\n", k)
+		g.encode(at.ItemType(), x)
+		g.printf("}\n")
+		g.printf("if len(%s) > 0 {\ne.WriteLong(0)\n}\n", v)
+	case *schema.UnionField:
+		g.encodeUnion(at, v)
+	case *schema.Reference:
+		switch at.Def.(type) {
+		case *schema.EnumDefinition:
+			g.printf("e.WriteLong(int64(%s))\n", v)
+		case *schema.FixedDefinition:
+			g.printf("e.WriteFixed(%s[:])\n", v)
+		case *schema.RecordDefinition:
+			g.printf("%s.avroEncode(e)\n", v)
+		}
+	}
+}
+
+func (g *codecGen) encodeUnion(at *schema.UnionField, v string) {
+	types := at.AvroTypes()
+	info := g.gc.GoTypeOf(at)
+	if info.GoType != "interface{}" {
+		// It's a union of null and one other type.
+		nullIndex, index := 0, 1
+		if isNullField(types[1]) {
+			nullIndex, index = 1, 0
+		}
+		if nt, ok := g.gc.nullableType(g.gc.GoTypeOf(types[index])); ok {
+			g.printf("if !%s.Valid {\n", v)
+			g.printf("e.WriteLong(%d)\n", nullIndex)
+			g.printf("} else {\n")
+			g.printf("e.WriteLong(%d)\n", index)
+			g.encode(types[index], v+"."+nt.Field)
+			g.printf("}\n")
+			return
+		}
+		g.printf("if %s == nil {\n", v)
+		g.printf("e.WriteLong(%d)\n", nullIndex)
+		g.printf("} else {\n")
+		g.printf("e.WriteLong(%d)\n", index)
+		g.encode(types[index], "(*"+v+")")
+		g.printf("}\n")
+		return
+	}
+	x := g.newVar("x")
+	g.printf("switch %s := %s.(type) {\n", x, v)
+	for i, t := range types {
+		if isNullField(t) {
+			g.printf("case nil, %s:\n", g.gc.typegenName("Null"))
+			g.printf("e.WriteLong(%d)\n", i)
+			continue
+		}
+		g.printf("case %s:\n", g.gc.GoTypeOf(t).GoType)
+		g.printf("e.WriteLong(%d)\n", i)
+		g.encode(t, x)
+	}
+	g.printf("default:\n")
+	g.printf("e.Error(%s.Errorf(\"cannot encode %%T in union\", %s))\n", g.gc.addImport("fmt"), x)
+	g.printf("}\n")
+}
+
+// decode generates code to decode a value of Avro
+// type at into the addressable Go expression v.
+func (g *codecGen) decode(at schema.AvroType, v string) {
+	switch at := at.(type) {
+	case *schema.NullField:
+	case *schema.BoolField:
+		g.printf("%s = d.ReadBool()\n", v)
+	case *schema.IntField:
+		g.printf("%s = int(d.ReadLong())\n", v)
+	case *schema.LongField:
+		if unit := timestampUnit(at); unit != "" {
+			g.printf("%s = d.ReadTimestamp%s()\n", v, unit)
+		} else {
+			g.printf("%s = d.ReadLong()\n", v)
+		}
+	case *schema.FloatField:
+		g.printf("%s = d.ReadFloat()\n", v)
+	case *schema.DoubleField:
+		g.printf("%s = d.ReadDouble()\n", v)
+	case *schema.BytesField:
+		g.printf("%s = d.ReadBytes()\n", v)
+	case *schema.StringField:
+		g.printf("%s = d.ReadString()\n", v)
+	case *schema.ArrayField:
+		n, x := g.newVar("n"), g.newVar("x")
+		g.printf("%s = nil\n", v)
+		g.printf("for %[1]s := d.ReadBlockCount(); %[1]s > 0; %[1]s = d.ReadBlockCount() {\n", n)
+		g.printf("for ; %[1]s > 0; %[1]s-- {\n", n)
+		g.printf("var %s %s\n", x, g.gc.GoTypeOf(at.ItemType()).GoType)
+		g.decode(at.ItemType(), x)
+		g.printf("%[1]s = append(%[1]s, %[2]s)\n", v, x)
+		g.printf("}\n}\n")
+	case *schema.MapField:
+		n, k, x := g.newVar("n"), g.newVar("k"), g.newVar("x")
+		itemType := g.gc.GoTypeOf(at.ItemType()).GoType
+		g.printf("%s = make(map[string]%s)\n", v, itemType)
+		g.printf("for %[1]s := d.ReadBlockCount(); %[1]s > 0; %[1]s = d.ReadBlockCount() {\n", n)
+		g.printf("for ; %[1]s > 0; %[1]s-- {\n", n)
+		g.printf("%s := d.ReadString()\n", k)
+		g.printf("var %s %s\n", x, itemType)
+		g.decode(at.ItemType(), x)
+		g.printf("%s[%s] = %s\n", v, k, x)
+		g.printf("}\n}\n")
+	case *schema.UnionField:
+		g.decodeUnion(at, v)
+	case *schema.Reference:
+		switch def := at.Def.(type) {
+		case *schema.EnumDefinition:
+			g.printf("%s = %s(d.ReadIndex(%d))\n", v, g.gc.GoTypeOf(at).GoType, len(def.Symbols()))
+		case *schema.FixedDefinition:
+			g.printf("d.ReadFixed(%s[:])\n", v)
+		case *schema.RecordDefinition:
+			g.printf("%s.avroDecode(d)\n", v)
+		}
+	}
+}
+
+func (g *codecGen) decodeUnion(at *schema.UnionField, v string) {
+	types := at.AvroTypes()
+	info := g.gc.GoTypeOf(at)
+	g.printf("switch d.ReadIndex(%d) {\n", len(types))
+	if info.GoType != "interface{}" {
+		// It's a union of null and one other type.
+		nullIndex, index := 0, 1
+		if isNullField(types[1]) {
+			nullIndex, index = 1, 0
+		}
+		g.printf("case %d:\n", nullIndex)
+		if nt, ok := g.gc.nullableType(g.gc.GoTypeOf(types[index])); ok {
+			g.printf("%s = %s{}\n", v, info.GoType)
+			g.printf("case %d:\n", index)
+			g.printf("%s.Valid = true\n", v)
+			if nt.GoType == "int32" {
+				// It's a sql.NullInt32 so the value isn't an int.
+				g.printf("%s.%s = int32(d.ReadLong())\n", v, nt.Field)
+			} else {
+				g.decode(types[index], v+"."+nt.Field)
+			}
+		} else {
+			g.printf("%s = nil\n", v)
+			g.printf("case %d:\n", index)
+			g.printf("%s = new(%s)\n", v, strings.TrimPrefix(info.GoType, "*"))
+			g.decode(types[index], "(*"+v+")")
+		}
+		g.printf("}\n")
+		return
+	}
+	for i, t := range types {
+		g.printf("case %d:\n", i)
+		if isNullField(t) {
+			g.printf("%s = nil\n", v)
+			continue
+		}
+		x := g.newVar("x")
+		g.printf("var %s %s\n", x, g.gc.GoTypeOf(t).GoType)
+		g.decode(t, x)
+		g.printf("%s = %s\n", v, x)
+	}
+	g.printf("}\n")
+}
@@ -0,0 +1,181 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// config holds the contents of the file specified
+// with the -config flag.
+type config struct {
+	// LogicalTypes maps from logical type name to the
+	// Go type used to represent it, like the -logicaltype flag.
+	LogicalTypes map[string]logicalTypeConfig `json:"logicalTypes"`
+
+	// Header holds the name of a file holding a template for
+	// a comment added to the top of each generated Go file,
+	// relative to the directory of the configuration file, like
+	// the -header flag.
+	Header string `json:"header"`
+
+	// BuildTags holds a build constraint expression added
+	// to each generated Go file, like the -buildtags flag.
+	BuildTags string `json:"buildTags"`
+}
+
+// logicalTypeConfig holds the configuration for a logical type.
+type logicalTypeConfig struct {
+	// GoType holds the Go type, in the same form as
+	// for the -logicaltype flag, such as github.com/google/uuid.UUID.
+	GoType string `json:"goType"`
+
+	// Converter specifies how values are converted to and from
+	// the underlying Avro representation. If it's "text", a
+	// converter is generated that uses the MarshalText and
+	// UnmarshalText methods of the Go type to convert to and
+	// from an Avro string. If it's empty, the program using the
+	// generated code must register a converter itself.
+	Converter string `json:"converter"`
+}
+
+// textConverters maps from the name of each logical type configured
+// with a "text" converter to the Go type that it converts.
+var textConverters = make(map[string]goType)
+
+// readConfig reads the configuration file f and adds the
+// logical types it specifies to those given with the -logicaltype
+// flag. Types specified with the flag take precedence. It also sets
+// the header and build constraint for generated files, which can be
+// overridden by the -header and -buildtags flags.
+func readConfig(f string) error {
+	data, err := ioutil.ReadFile(f)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var cfg config
+	if err := dec.Decode(&cfg); err != nil {
+		return fmt.Errorf("invalid configuration in %s: %v", f, err)
+	}
+	for name, lt := range cfg.LogicalTypes {
+		if _, ok := logicalTypes[name]; ok {
+			continue
+		}
+		gt, err := parseGoType(lt.GoType, name)
+		if err != nil {
+			return fmt.Errorf("invalid configuration in %s: %v", f, err)
+		}
+		switch lt.Converter {
+		case "":
+		case "text":
+			if gt.PkgPath == "" {
+				return fmt.Errorf("invalid configuration in %s: cannot generate text converter for predeclared type %s for logical type %q", f, gt.Name, name)
+			}
+			textConverters[name] = gt
+		default:
+			return fmt.Errorf("invalid configuration in %s: unknown converter %q for logical type %q", f, lt.Converter, name)
+		}
+		logicalTypes[name] = gt
+	}
+	if cfg.Header != "" {
+		if err := readHeader(filepath.Join(filepath.Dir(f), cfg.Header)); err != nil {
+			return fmt.Errorf("invalid configuration in %s: %v", f, err)
+		}
+	}
+	if cfg.BuildTags != "" {
+		if err := parseBuildTags(cfg.BuildTags); err != nil {
+			return fmt.Errorf("invalid configuration in %s: %v", f, err)
+		}
+	}
+	return nil
+}
+
+// generateConverters writes the source of a Go file in package pkg
+// to w that registers the generated text converters, or
+// writes nothing if there are none.
+func generateConverters(w *bytes.Buffer, pkg string) error {
+	if len(textConverters) == 0 {
+		return nil
+	}
+	var names []string
+	for name := range textConverters {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	gc := &generateContext{
+		imports: make(map[string]string),
+	}
+	avro := gc.addImport("github.com/heetch/avro")
+	reflect := gc.addImport("reflect")
+	var body strings.Builder
+	for _, name := range names {
+		fprintf(&body, `
+// %[1]s converts between %[2]s and the string
+// representation of the %[3]s logical type using
+// the MarshalText and UnmarshalText methods of %[2]s.
+type %[1]s struct{}
+
+// GoType implements %[4]s.Converter.GoType.
+func (%[1]s) GoType() %[5]s.Type {
+	return %[5]s.TypeOf((*%[2]s)(nil)).Elem()
+}
+
+// ToAvro implements %[4]s.Converter.ToAvro.
+func (%[1]s) ToAvro(x interface{}) (interface{}, error) {
+	v := x.(%[2]s)
+	data, err := v.MarshalText()
+	if err != nil {
+		return nil, err
+	}
+	return string(data), nil
+}
+
+// FromAvro implements %[4]s.Converter.FromAvro.
+func (%[1]s) FromAvro(x interface{}) (interface{}, error) {
+	var v %[2]s
+	if err := v.UnmarshalText([]byte(x.(string))); err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+`, converterName(name), gc.goTypeName(textConverters[name]), name, avro, reflect)
+	}
+	fprintf(&body, "\nfunc init() {\n")
+	for _, name := range names {
+		fprintf(&body, "%s.RegisterLogicalType(%q, %s.KindString, %s{})\n", avro, name, avro, converterName(name))
+	}
+	fprintf(&body, "}\n")
+	return gc.writeFile(w, pkg, []byte(body.String()))
+}
+
+// converterName returns the name of the generated converter
+// type for the given logical type; for example, the converter
+// for timestamp-micros is named timestampMicrosConverter.
+func converterName(logicalType string) string {
+	var buf strings.Builder
+	upper := false
+	for _, r := range logicalType {
+		switch {
+		case !unicode.IsLetter(r) && !unicode.IsDigit(r):
+			upper = buf.Len() > 0
+		case upper:
+			buf.WriteRune(unicode.ToUpper(r))
+			upper = false
+		case buf.Len() == 0:
+			if unicode.IsDigit(r) {
+				buf.WriteString("x")
+			}
+			buf.WriteRune(unicode.ToLower(r))
+		default:
+			buf.WriteRune(r)
+		}
+	}
+	return buf.String() + "Converter"
+}
@@ -0,0 +1,25 @@
+package main
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+var converterNameTests = []struct {
+	logicalType string
+	expect      string
+}{
+	{"uuid", "uuidConverter"},
+	{"timestamp-micros", "timestampMicrosConverter"},
+	{"big_decimal", "bigDecimalConverter"},
+	{"-x-", "xConverter"},
+	{"1d", "x1dConverter"},
+}
+
+func TestConverterName(t *testing.T) {
+	c := qt.New(t)
+	for _, test := range converterNameTests {
+		c.Check(converterName(test.logicalType), qt.Equals, test.expect, qt.Commentf("%q", test.logicalType))
+	}
+}
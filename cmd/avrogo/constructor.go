@@ -0,0 +1,71 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/rogpeppe/gogen-avro/v7/schema"
+)
+
+// Constructor returns the source of the NewT function and the
+// SetDefaults method for the given record, or the empty string
+// if they aren't enabled or would clash with a field name.
+func (gc *generateContext) Constructor(t *schema.RecordDefinition) (string, error) {
+	if !gc.opts.constructors {
+		return "", nil
+	}
+	name := defName(t)
+	var body strings.Builder
+	needZero, needDefaults := false, false
+	for i, f := range t.Fields() {
+		fname, err := fieldGoName(f.Name())
+		if err != nil {
+			return "", err
+		}
+		if fname == "SetDefaults" {
+			return "", nil
+		}
+		if !f.HasDefault() {
+			continue
+		}
+		if isZeroDefault(f.Default(), f.Type()) {
+			fprintf(&body, "r.%s = zero.%s\n", fname, fname)
+			needZero = true
+			continue
+		}
+		needDefaults = true
+		goType := gc.GoTypeOf(f.Type()).GoType
+		switch {
+		case goType == "interface{}":
+			fprintf(&body, "r.%s = defaults[%d]()\n", fname, i)
+		case strings.HasPrefix(goType, "*"):
+			// The default value of a union has the type of its
+			// first member, so it's not a pointer.
+			fprintf(&body, "if v, ok := defaults[%d]().(%s); ok {\nr.%s = &v\n}\n", i, goType[1:], fname)
+		default:
+			fprintf(&body, "r.%s = defaults[%d]().(%s)\n", fname, i, goType)
+		}
+	}
+	var w strings.Builder
+	fprintf(&w, `
+// New%[1]s returns a new %[1]s with its fields
+// set to their default values.
+func New%[1]s() *%[1]s {
+	r := new(%[1]s)
+	r.SetDefaults()
+	return r
+}
+
+// SetDefaults sets each field of r that has a default
+// value in the schema to that value.
+func (r *%[1]s) SetDefaults() {
+`, name)
+	if needZero {
+		fprintf(&w, "var zero %s\n", name)
+	}
+	if needDefaults {
+		fprintf(&w, "defaults := r.AvroRecord().Defaults\n")
+	}
+	w.WriteString(body.String())
+	fprintf(&w, "}\n")
+	return w.String(), nil
+}
@@ -0,0 +1,176 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// diffContext holds the number of unchanged lines
+// shown around each change in a diff.
+const diffContext = 3
+
+// maxDiffCells bounds the size of the table used to find the
+// differences between the changed parts of two files. When the
+// table would be bigger, the changed parts are shown as replaced
+// in their entirety.
+const maxDiffCells = 4e6
+
+// diffOp holds a line in a diff, with its kind: ' ' for
+// a line in both files, '-' for a line only in the old file
+// and '+' for a line only in the new file.
+type diffOp struct {
+	kind byte
+	line string
+}
+
+// lineDiff returns a unified diff from the old contents of the
+// named file to its new contents, or the empty string if they're
+// the same.
+func lineDiff(name, old, new string) string {
+	if old == new {
+		return ""
+	}
+	ops := diffOps(splitLines(old), splitLines(new))
+	// oldLine and newLine hold the number of lines in each
+	// file before each op.
+	oldLine := make([]int, len(ops)+1)
+	newLine := make([]int, len(ops)+1)
+	for i, op := range ops {
+		oldLine[i+1], newLine[i+1] = oldLine[i], newLine[i]
+		if op.kind != '+' {
+			oldLine[i+1]++
+		}
+		if op.kind != '-' {
+			newLine[i+1]++
+		}
+	}
+	var w strings.Builder
+	fmt.Fprintf(&w, "--- %s (existing)\n+++ %s (generated)\n", name, name)
+	for i := 0; i < len(ops); {
+		if ops[i].kind == ' ' {
+			i++
+			continue
+		}
+		start := i - diffContext
+		if start < 0 {
+			start = 0
+		}
+		end := i
+		for {
+			for end < len(ops) && ops[end].kind != ' ' {
+				end++
+			}
+			// Join the next change into the same hunk
+			// if their context would overlap.
+			j := end
+			for j < len(ops) && ops[j].kind == ' ' && j-end < 2*diffContext {
+				j++
+			}
+			if j < len(ops) && ops[j].kind != ' ' {
+				end = j
+				continue
+			}
+			break
+		}
+		stop := end + diffContext
+		if stop > len(ops) {
+			stop = len(ops)
+		}
+		fmt.Fprintf(&w, "@@ -%s +%s @@\n", hunkRange(oldLine[start], oldLine[stop]), hunkRange(newLine[start], newLine[stop]))
+		for _, op := range ops[start:stop] {
+			fmt.Fprintf(&w, "%c%s\n", op.kind, op.line)
+		}
+		i = stop
+	}
+	return w.String()
+}
+
+// hunkRange returns the range of lines in a hunk header
+// for the lines after line start up to line end.
+func hunkRange(start, end int) string {
+	if end == start {
+		return fmt.Sprintf("%d,0", start)
+	}
+	return fmt.Sprintf("%d,%d", start+1, end-start)
+}
+
+// diffOps returns the ops that turn the lines a into the lines b.
+func diffOps(a, b []string) []diffOp {
+	var ops []diffOp
+	// Lines common to the start and end of both
+	// don't need to be compared any further.
+	prefix := 0
+	for prefix < len(a) && prefix < len(b) && a[prefix] == b[prefix] {
+		prefix++
+	}
+	suffix := 0
+	for suffix < len(a)-prefix && suffix < len(b)-prefix && a[len(a)-1-suffix] == b[len(b)-1-suffix] {
+		suffix++
+	}
+	for _, line := range a[:prefix] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	ops = append(ops, lcsOps(a[prefix:len(a)-suffix], b[prefix:len(b)-suffix])...)
+	for _, line := range a[len(a)-suffix:] {
+		ops = append(ops, diffOp{' ', line})
+	}
+	return ops
+}
+
+// lcsOps returns the ops that turn a into b, keeping
+// a longest common subsequence of their lines.
+func lcsOps(a, b []string) []diffOp {
+	var ops []diffOp
+	if float64(len(a))*float64(len(b)) > maxDiffCells {
+		for _, line := range a {
+			ops = append(ops, diffOp{'-', line})
+		}
+		for _, line := range b {
+			ops = append(ops, diffOp{'+', line})
+		}
+		return ops
+	}
+	// n[i][j] holds the length of the longest common
+	// subsequence of a[i:] and b[j:].
+	n := make([][]int, len(a)+1)
+	for i := range n {
+		n[i] = make([]int, len(b)+1)
+	}
+	for i := len(a) - 1; i >= 0; i-- {
+		for j := len(b) - 1; j >= 0; j-- {
+			switch {
+			case a[i] == b[j]:
+				n[i][j] = n[i+1][j+1] + 1
+			case n[i+1][j] >= n[i][j+1]:
+				n[i][j] = n[i+1][j]
+			default:
+				n[i][j] = n[i][j+1]
+			}
+		}
+	}
+	i, j := 0, 0
+	for i < len(a) || j < len(b) {
+		switch {
+		case i < len(a) && j < len(b) && a[i] == b[j]:
+			ops = append(ops, diffOp{' ', a[i]})
+			i++
+			j++
+		case j == len(b) || i < len(a) && n[i+1][j] >= n[i][j+1]:
+			ops = append(ops, diffOp{'-', a[i]})
+			i++
+		default:
+			ops = append(ops, diffOp{'+', b[j]})
+			j++
+		}
+	}
+	return ops
+}
+
+// splitLines splits s into lines, without their
+// trailing newlines.
+func splitLines(s string) []string {
+	if s == "" {
+		return nil
+	}
+	return strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+}
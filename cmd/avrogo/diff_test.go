@@ -0,0 +1,107 @@
+package main
+
+import (
+	"strings"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+var lineDiffTests = []struct {
+	testName string
+	old      string
+	new      string
+	expect   string
+}{{
+	testName: "same",
+	old:      "a\nb\n",
+	new:      "a\nb\n",
+	expect:   "",
+}, {
+	testName: "new-file",
+	old:      "",
+	new:      "a\nb\n",
+	expect: `
+--- f (existing)
++++ f (generated)
+@@ -0,0 +1,2 @@
++a
++b
+`,
+}, {
+	testName: "changed-line",
+	old:      "1\n2\n3\n4\n5\n6\n7\n8\n9\n",
+	new:      "1\n2\n3\n4\nfive\n6\n7\n8\n9\n",
+	expect: `
+--- f (existing)
++++ f (generated)
+@@ -2,7 +2,7 @@
+ 2
+ 3
+ 4
+-5
++five
+ 6
+ 7
+ 8
+`,
+}, {
+	testName: "separate-hunks",
+	old:      "a\n1\n2\n3\n4\n5\n6\n7\nb\n",
+	new:      "A\n1\n2\n3\n4\n5\n6\n7\nB\n",
+	expect: `
+--- f (existing)
++++ f (generated)
+@@ -1,4 +1,4 @@
+-a
++A
+ 1
+ 2
+ 3
+@@ -6,4 +6,4 @@
+ 5
+ 6
+ 7
+-b
++B
+`,
+}, {
+	testName: "joined-hunks",
+	old:      "a\n1\n2\n3\nb\n",
+	new:      "A\n1\n2\n3\nB\n",
+	expect: `
+--- f (existing)
++++ f (generated)
+@@ -1,5 +1,5 @@
+-a
++A
+ 1
+ 2
+ 3
+-b
++B
+`,
+}, {
+	testName: "insert-and-delete",
+	old:      "a\nb\nc\n",
+	new:      "a\nx\nc\nd\n",
+	expect: `
+--- f (existing)
++++ f (generated)
+@@ -1,3 +1,4 @@
+ a
+-b
++x
+ c
++d
+`,
+}}
+
+func TestLineDiff(t *testing.T) {
+	c := qt.New(t)
+	for _, test := range lineDiffTests {
+		c.Run(test.testName, func(c *qt.C) {
+			c.Assert(lineDiff("f", test.old, test.new), qt.Equals, strings.TrimPrefix(test.expect, "\n"))
+		})
+	}
+}
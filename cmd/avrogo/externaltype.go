@@ -10,6 +10,7 @@ import (
 	"os/exec"
 	"path/filepath"
 	"reflect"
+	"sort"
 
 	"github.com/rogpeppe/gogen-avro/v7/parser"
 	"github.com/rogpeppe/gogen-avro/v7/schema"
@@ -44,7 +45,8 @@ func externalTypeMap(ns *parser.Namespace) (map[schema.QualifiedName]goType, err
 	// a different go type, it's an error.
 
 	avroToGo := make(map[schema.QualifiedName]goType)
-	for name, def := range ns.Definitions {
+	for _, name := range sortedDefinitionNames(ns) {
+		def := ns.Definitions[name]
 		gt := goTypeForDefinition(def)
 		if gt.PkgPath == "" {
 			continue
@@ -108,9 +110,21 @@ func externalTypeInfoForGoTypes(gts map[goType]bool) (map[goType]avrotypemap.Ext
 			pkgPaths = append(pkgPaths, pkgPath)
 		}
 	}
+	// Sort the types so that the introspection program
+	// and any errors it produces are always the same.
+	types := make([]goType, 0, len(gts))
+	for gt := range gts {
+		types = append(types, gt)
+	}
+	sort.Slice(types, func(i, j int) bool {
+		if types[i].PkgPath != types[j].PkgPath {
+			return types[i].PkgPath < types[j].PkgPath
+		}
+		return types[i].Name < types[j].Name
+	})
 	addPkg("github.com/heetch/avro")
 	addPkg("github.com/heetch/avro/cmd/avrogo/avrotypemap")
-	for gt := range gts {
+	for _, gt := range types {
 		addPkg(gt.PkgPath)
 	}
 	mp := typeInfoMainParams{
@@ -121,9 +135,7 @@ func externalTypeInfoForGoTypes(gts map[goType]bool) (map[goType]avrotypemap.Ext
 		// TODO make sure there's no duplicate name.
 		mp.ImportIDs[p] = importPathToName(p)
 	}
-	for gt := range gts {
-		mp.Types = append(mp.Types, gt)
-	}
+	mp.Types = types
 	var buf bytes.Buffer
 	if err := typeInfoMainTemplate.Execute(&buf, mp); err != nil {
 		return nil, fmt.Errorf("cannot execute type info main template: %v", err)
@@ -133,7 +145,7 @@ func externalTypeInfoForGoTypes(gts map[goType]bool) (map[goType]avrotypemap.Ext
 		fmt.Printf("%s\n", buf.Bytes())
 		return nil, fmt.Errorf("cannot format typeinfo source: %v", err)
 	}
-	f, err := ioutil.TempFile(*dirFlag, "avro-introspect*.go")
+	f, err := ioutil.TempFile(outputDir(), "avro-introspect*.go")
 	if err != nil {
 		return nil, err
 	}
@@ -146,7 +158,7 @@ func externalTypeInfoForGoTypes(gts map[goType]bool) (map[goType]avrotypemap.Ext
 	f.Close()
 	var runStdout bytes.Buffer
 	cmd := exec.Command("go", "run", filepath.Base(prog))
-	cmd.Dir = *dirFlag
+	cmd.Dir = outputDir()
 	cmd.Stdout = &runStdout
 	cmd.Stderr = os.Stderr
 	if err := cmd.Run(); err != nil {
@@ -166,6 +178,19 @@ func externalTypeInfoForGoTypes(gts map[goType]bool) (map[goType]avrotypemap.Ext
 	return results, nil
 }
 
+// sortedDefinitionNames returns the names of all the
+// definitions in ns in alphabetical order.
+func sortedDefinitionNames(ns *parser.Namespace) []schema.QualifiedName {
+	names := make([]schema.QualifiedName, 0, len(ns.Definitions))
+	for name := range ns.Definitions {
+		names = append(names, name)
+	}
+	sort.Slice(names, func(i, j int) bool {
+		return names[i].String() < names[j].String()
+	})
+	return names
+}
+
 type typeInfoMainParams struct {
 	Imports   []string
 	ImportIDs map[string]string
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rogpeppe/gogen-avro/v7/parser"
+	"github.com/rogpeppe/gogen-avro/v7/schema"
+
+	"github.com/heetch/avro"
+)
+
+// SchemaConstants returns the source of the RSchemaCanonical and
+// RSchemaFingerprint constants for the record R, which hold the
+// Parsing Canonical Form of its schema and the CRC-64-AVRO
+// fingerprint of that, or the empty string if they aren't enabled.
+func (gc *generateContext) SchemaConstants(t *schema.RecordDefinition) (string, error) {
+	if !gc.opts.fingerprint {
+		return "", nil
+	}
+	schemaStr, err := t.Schema()
+	if err != nil {
+		return "", err
+	}
+	at, err := avro.ParseType(schemaStr)
+	if err != nil {
+		return "", fmt.Errorf("cannot parse schema of %v: %v", t.AvroName(), err)
+	}
+	var w strings.Builder
+	fprintf(&w, `
+// %[1]sSchemaCanonical holds the Parsing Canonical
+// Form of the schema of %[1]s.
+const %[1]sSchemaCanonical = %[2]s
+
+// %[1]sSchemaFingerprint holds the CRC-64-AVRO
+// fingerprint of %[1]sSchemaCanonical.
+const %[1]sSchemaFingerprint uint64 = 0x%016[3]x
+`, defName(t), quote(at.CanonicalString(0)), at.Fingerprint())
+	return w.String(), nil
+}
+
+// fingerprintsHeading holds the line that precedes the schema
+// fingerprints in the header of a generated file.
+const fingerprintsHeading = "Schema fingerprints:"
+
+// schemaFingerprint holds the CRC-64-AVRO fingerprint of
+// the Parsing Canonical Form of a definition's schema.
+type schemaFingerprint struct {
+	Name        string
+	Fingerprint uint64
+}
+
+// schemaFingerprints returns the fingerprints of the schemas of
+// the given definitions, sorted by name, for stamping in the
+// header of the file they're generated in.
+func schemaFingerprints(ns *parser.Namespace, definitions []schema.QualifiedName) ([]schemaFingerprint, error) {
+	fps := make([]schemaFingerprint, 0, len(definitions))
+	for _, name := range definitions {
+		def, err := ns.Definitions[name].Definition(make(map[schema.QualifiedName]interface{}))
+		if err != nil {
+			return nil, err
+		}
+		data, err := json.Marshal(def)
+		if err != nil {
+			return nil, err
+		}
+		at, err := avro.ParseType(string(data))
+		if err != nil {
+			return nil, fmt.Errorf("cannot parse schema of %v: %v", name, err)
+		}
+		fps = append(fps, schemaFingerprint{
+			Name:        name.String(),
+			Fingerprint: at.Fingerprint(),
+		})
+	}
+	sort.Slice(fps, func(i, j int) bool {
+		return fps[i].Name < fps[j].Name
+	})
+	return fps, nil
+}
+
+// stampedFingerprints returns the schema fingerprints stamped in
+// the header of the generated Go source src, keyed by definition
+// name. Each fingerprint is held as it's written in the file.
+func stampedFingerprints(src []byte) map[string]string {
+	fps := make(map[string]string)
+	scanner := bufio.NewScanner(bytes.NewReader(src))
+	inFingerprints := false
+	for scanner.Scan() {
+		line := scanner.Text()
+		if !strings.HasPrefix(line, "//") {
+			if line == "" {
+				continue
+			}
+			// The header comments are before any code.
+			break
+		}
+		if line == "// "+fingerprintsHeading {
+			inFingerprints = true
+			continue
+		}
+		if !inFingerprints {
+			continue
+		}
+		fields := strings.Fields(strings.TrimPrefix(line, "//"))
+		if !strings.HasPrefix(line, "//\t") || len(fields) != 2 {
+			break
+		}
+		fps[fields[0]] = fields[1]
+	}
+	return fps
+}
+
+// fingerprintChanges returns a description of each definition whose
+// schema fingerprint stamped in the generated Go source old differs
+// from the one stamped in src.
+func fingerprintChanges(old, src []byte) []string {
+	oldFps := stampedFingerprints(old)
+	var changes []string
+	for name, fp := range stampedFingerprints(src) {
+		if oldFp, ok := oldFps[name]; ok && oldFp != fp {
+			changes = append(changes, fmt.Sprintf("schema of %s has changed (fingerprint %s, was %s)", name, fp, oldFp))
+		}
+	}
+	sort.Strings(changes)
+	return changes
+}
@@ -21,11 +21,94 @@ const (
 
 const nullType = "avrotypegen.Null"
 
-func generate(w io.Writer, pkg string, ns *parser.Namespace, definitions []schema.QualifiedName) error {
-	extTypes, err := externalTypeMap(ns)
-	if err != nil {
-		return err
-	}
+// generateOptions holds options that affect the generated code.
+type generateOptions struct {
+	// logicalTypes maps from logical type name to the
+	// Go type used to represent it.
+	logicalTypes map[string]goType
+
+	// sqlNull specifies that unions of null and another type
+	// are represented by database/sql nullable types such as
+	// sql.NullString where possible, rather than by pointers.
+	sqlNull bool
+
+	// optionalNull specifies that unions of null and another type
+	// are represented by the avrotypegen Optional types such as
+	// avrotypegen.OptionalString where possible, rather than by pointers.
+	optionalNull bool
+
+	// binary specifies that MarshalBinary and UnmarshalBinary
+	// methods are generated for records.
+	binary bool
+
+	// constructors specifies that NewT functions and
+	// SetDefaults methods are generated for records.
+	constructors bool
+
+	// getters specifies that GetF and GetFOr methods are
+	// generated for optional record fields.
+	getters bool
+
+	// unionAccessors specifies that typed accessor methods
+	// are generated for record fields holding unions
+	// represented as interface{}.
+	unionAccessors bool
+
+	// byteHelpers specifies that Hex, Bytes and SetBytes
+	// methods are generated for fixed types and FHex methods
+	// for bytes fields of records.
+	byteHelpers bool
+
+	// interfaces specifies that an RInterface type, with
+	// GetF and AvroSchema methods to implement it, is
+	// generated for each record R.
+	interfaces bool
+
+	// validate specifies that Validate methods are
+	// generated for records and enums.
+	validate bool
+
+	// builders specifies that RBuilder types are
+	// generated for records.
+	builders bool
+
+	// fingerprint specifies that constants holding the
+	// canonical schema and its fingerprint are generated
+	// for records.
+	fingerprint bool
+
+	// messages specifies that EncodeR and DecodeR functions
+	// are generated for records.
+	messages bool
+
+	// clone specifies that Clone and Equal methods
+	// are generated for records.
+	clone bool
+
+	// tags holds the struct tags generated for
+	// every record field.
+	tags []structTag
+
+	// omitEmpty specifies that the values of the
+	// generated struct tags include omitempty.
+	omitEmpty bool
+
+	// selfContained specifies that the generated code doesn't
+	// depend on the avro module: records get MarshalBinary and
+	// UnmarshalBinary methods but no AvroRecord method, and the
+	// code they use is written to a separate file.
+	selfContained bool
+
+	// noReflect specifies that the generated code doesn't use
+	// reflection or interface{} values. It's only set along with
+	// selfContained. Records also get AppendBinary methods, and
+	// it's an error if a record field is represented by interface{}.
+	noReflect bool
+}
+
+// generate writes Go code for the given definitions to w, along
+// with the interface for the protocol proto if it's non-nil.
+func generate(w io.Writer, pkg string, ns *parser.Namespace, extTypes map[schema.QualifiedName]goType, definitions []schema.QualifiedName, proto *rpcProtocol, opts generateOptions) error {
 	// Select only those definitions which aren't external.
 	var localDefinitions []schema.QualifiedName
 	for _, name := range definitions {
@@ -33,14 +116,26 @@ func generate(w io.Writer, pkg string, ns *parser.Namespace, definitions []schem
 			localDefinitions = append(localDefinitions, name)
 		}
 	}
-	if len(localDefinitions) == 0 {
+	if len(localDefinitions) == 0 && proto == nil {
 		return nil
 	}
 	gc := &generateContext{
 		imports:  make(map[string]string),
 		extTypes: extTypes,
+		opts:     opts,
+	}
+	fingerprints, err := schemaFingerprints(ns, localDefinitions)
+	if err != nil {
+		return err
+	}
+	gc.fingerprints = fingerprints
+	for _, name := range localDefinitions {
+		if _, ok := ns.Definitions[name].(*schema.RecordDefinition); ok && !opts.selfContained {
+			// The avrotypegen package is only used by records.
+			gc.addImport(avrotypegenPkg)
+			break
+		}
 	}
-	gc.addImport("github.com/heetch/avro/avrotypegen")
 	var body bytes.Buffer
 	if err := bodyTemplate.Execute(&body, bodyTemplateParams{
 		Definitions: localDefinitions,
@@ -49,6 +144,19 @@ func generate(w io.Writer, pkg string, ns *parser.Namespace, definitions []schem
 	}); err != nil {
 		return err
 	}
+	if proto != nil {
+		src, err := gc.RPCInterface(ns, proto)
+		if err != nil {
+			return fmt.Errorf("cannot generate interface for protocol %s: %v", proto.name, err)
+		}
+		body.WriteString(src)
+	}
+	return gc.writeFile(w, pkg, body.Bytes())
+}
+
+// writeFile writes a Go file in package pkg to w with
+// the given body, preceded by the imports that it uses.
+func (gc *generateContext) writeFile(w io.Writer, pkg string, body []byte) error {
 	var importList []string
 	for imp := range gc.imports {
 		importList = append(importList, imp)
@@ -58,18 +166,19 @@ func generate(w io.Writer, pkg string, ns *parser.Namespace, definitions []schem
 	// TODO look at the actual identifier used by the
 	// package to avoid the explicit identifer in more cases.
 	for pkg := range gc.imports {
-		if !strings.Contains(pkg, ".") || strings.HasPrefix(pkg, "github.com/heetch/avro/") {
+		if !strings.Contains(pkg, ".") || pkg == "github.com/heetch/avro" || strings.HasPrefix(pkg, "github.com/heetch/avro/") {
 			gc.imports[pkg] = ""
 		}
 	}
 	if err := headerTemplate.Execute(w, headerTemplateParams{
-		Pkg:       pkg,
-		Imports:   importList,
-		ImportIds: gc.imports,
+		Pkg:          pkg,
+		Imports:      importList,
+		ImportIds:    gc.imports,
+		Fingerprints: gc.fingerprints,
 	}); err != nil {
 		return fmt.Errorf("cannot execute header template: %v", err)
 	}
-	if _, err := w.Write(body.Bytes()); err != nil {
+	if _, err := w.Write(body); err != nil {
 		return err
 	}
 	return nil
@@ -78,11 +187,16 @@ func generate(w io.Writer, pkg string, ns *parser.Namespace, definitions []schem
 type typeInfo struct {
 	// GoType holds the name of the type used
 	// in Go. The "null" type is represented by
-	// "avrotypegen.Null" (the value of the nullType constant).
+	// "avrotypegen.Null" (the value of the nullType constant),
+	// or by avroNull in self-contained code.
 	GoType string
 
 	// Union holds type info for all the members of a union.
 	Union []typeInfo
+
+	// UnionName holds the Avro name of the type when it's
+	// a member of a union, as recorded in avrotypegen.UnionInfo.
+	UnionName string
 }
 
 func (info typeInfo) Doc() string {
@@ -167,7 +281,28 @@ func canOmitUnionInfo(u typeInfo) bool {
 	// (the default union type for a pointer) and the Go type is also
 	// a pointer, meaning the avro package can infer that it's a
 	// pointer union.
-	return len(u.Union) == 0 || (len(u.Union) == 2 && u.Union[0].GoType == nullType && u.GoType[0] == '*')
+	return len(u.Union) == 0 || (len(u.Union) == 2 && isNullGoType(u.Union[0].GoType) && (u.GoType[0] == '*' || isNullableType(u.GoType)))
+}
+
+// isNullableType reports whether the Go type t is one of
+// the database/sql nullable types or avrotypegen Optional types.
+func isNullableType(t string) bool {
+	_, ok := nullableTypeOf(t)
+	return ok
+}
+
+// nullableTypeOf returns information on the database/sql
+// nullable type or avrotypegen Optional type with the
+// Go type t, if it is one.
+func nullableTypeOf(t string) (nullableType, bool) {
+	for _, types := range []map[string]nullableType{sqlNullTypes, optionalTypes} {
+		for _, nt := range types {
+			if t == importPathToName(nt.Pkg)+"."+nt.Name {
+				return nt, true
+			}
+		}
+	}
+	return nullableType{}, false
 }
 
 func writeUnionInfo(w io.Writer, info typeInfo) {
@@ -185,6 +320,9 @@ func writeUnionInfo(w io.Writer, info typeInfo) {
 	} else {
 		fprintf(w, "Type: new(%s),\n", info.GoType)
 	}
+	if info.UnionName != "" {
+		fprintf(w, "Name: %q,\n", info.UnionName)
+	}
 	if len(info.Union) > 0 {
 		fprintf(w, "Union: []avrotypegen.UnionInfo{")
 		for i, u := range info.Union {
@@ -251,16 +389,27 @@ func isZeroDefault(x interface{}, t schema.AvroType) bool {
 // defaultFuncLiteral returns a Go function definition that
 // returns the default value v as a Go value.
 func (gc *generateContext) defaultFuncLiteral(v interface{}, t schema.AvroType) (string, error) {
+	if _, ok := gc.logicalGoType(t); ok {
+		return "", fmt.Errorf("non-zero default value %s not supported for logical type %q", jsonMarshal(v), logicalType(t))
+	}
 	switch t := t.(type) {
 	case *schema.UnionField:
 		// Defaults for unions fields always use the first member
 		// of the union.
-		return gc.defaultFuncLiteral(v, t.AvroTypes()[0])
+		first := t.AvroTypes()[0]
+		lit, err := gc.defaultFuncLiteral(v, first)
+		if err != nil || isNullField(first) {
+			return lit, err
+		}
+		if nt, ok := gc.nullableType(gc.GoTypeOf(first)); ok {
+			return fmt.Sprintf("%s.%s{%s: %s, Valid: true}", gc.addImport(nt.Pkg), nt.Name, nt.Field, lit), nil
+		}
+		return lit, nil
 	case *schema.NullField:
 		if v != nil {
 			return "", fmt.Errorf("must be null but got %s", jsonMarshal(v))
 		}
-		return nullType + "{}", nil
+		return gc.typegenName("Null") + "{}", nil
 	case *schema.BoolField:
 		v, ok := v.(bool)
 		if !ok {
@@ -338,6 +487,9 @@ func (gc *generateContext) defaultFuncLiteral(v interface{}, t schema.AvroType)
 			}
 			for _, sym := range def.Symbols() {
 				if sym == s {
+					if gt, ok := gc.extTypes[t.TypeName]; ok && gt.PkgPath != "" {
+						return gc.addImport(gt.PkgPath) + "." + def.SymbolName(s), nil
+					}
 					return def.SymbolName(s), nil
 				}
 			}
@@ -355,7 +507,7 @@ func (gc *generateContext) defaultFuncLiteral(v interface{}, t schema.AvroType)
 				return "", fmt.Errorf("fixed value %s is wrong length (got %d; want %d)", jsonMarshal(v), len(b), def.SizeBytes())
 			}
 			var buf bytes.Buffer
-			fmt.Fprintf(&buf, "%s{", def.Name())
+			fmt.Fprintf(&buf, "%s{", gc.defaultTypeName(t))
 			for _, x := range b {
 				fmt.Fprintf(&buf, "%#x, ", x)
 			}
@@ -367,7 +519,7 @@ func (gc *generateContext) defaultFuncLiteral(v interface{}, t schema.AvroType)
 				return "", fmt.Errorf("invalid record default value %s", jsonMarshal(v))
 			}
 			var buf bytes.Buffer
-			fmt.Fprintf(&buf, "%s{\n", def.Name())
+			fmt.Fprintf(&buf, "%s{\n", gc.defaultTypeName(t))
 			for _, field := range def.Fields() {
 				fieldVal, ok := m[field.Name()]
 				var lit string
@@ -395,6 +547,15 @@ func (gc *generateContext) defaultFuncLiteral(v interface{}, t schema.AvroType)
 	}
 }
 
+// defaultTypeName returns the name of the Go type to use
+// in a default value literal for the definition referred to by t.
+func (gc *generateContext) defaultTypeName(t *schema.Reference) string {
+	if gt, ok := gc.extTypes[t.TypeName]; ok && gt.PkgPath != "" {
+		return gc.goTypeName(gt)
+	}
+	return t.Def.Name()
+}
+
 // goName returns an exported Go identifier for the Avro name s.
 func goName(s string) (string, error) {
 	lastIndex := strings.LastIndex(s, ".")
@@ -443,7 +604,7 @@ func writeUnionComment(w io.Writer, union []typeInfo, indent string) {
 	if len(union) == 0 {
 		return
 	}
-	if len(union) == 2 && (union[0].GoType == nullType || union[1].GoType == nullType) {
+	if len(union) == 2 && (isNullGoType(union[0].GoType) || isNullGoType(union[1].GoType)) {
 		// No need to comment a nil union.
 		// TODO we may want to document whether a map or array may
 		// be nil though. https://github.com/heetch/avro/issues/19
@@ -462,21 +623,29 @@ func writeUnionComment(w io.Writer, union []typeInfo, indent string) {
 type generateContext struct {
 	imports  map[string]string
 	extTypes map[schema.QualifiedName]goType
+	opts     generateOptions
+
+	// fingerprints holds the schema fingerprints
+	// stamped in the header of the generated file.
+	fingerprints []schemaFingerprint
 }
 
 func (gc *generateContext) GoTypeOf(t schema.AvroType) typeInfo {
 	var info typeInfo
+	if gt, ok := gc.logicalGoType(t); ok {
+		info.GoType = gc.goTypeName(gt)
+		return info
+	}
 	switch t := t.(type) {
 	case *schema.NullField:
-		info.GoType = "avrotypegen.Null"
+		info.GoType = gc.typegenName("Null")
 	case *schema.BoolField:
 		info.GoType = "bool"
 	case *schema.IntField:
 		// Note: Go int is at least 32 bits.
 		info.GoType = "int"
 	case *schema.LongField:
-		// TODO support timestampMillis. https://github.com/heetch/avro/issues/3
-		if logicalType(t) == timestampMicros {
+		if timestampUnit(t) != "" {
 			info.GoType = "time.Time"
 			gc.addImport("time")
 		} else {
@@ -499,20 +668,24 @@ func (gc *generateContext) GoTypeOf(t schema.AvroType) typeInfo {
 			// values in Go.
 			// https://github.com/heetch/avro/issues/19
 			inner := gc.GoTypeOf(types[1])
-			info.GoType = "*" + inner.GoType
+			info.GoType, inner = gc.nullableGoType(inner)
+			inner.UnionName = avroTypeName(types[1])
 			info.Union = []typeInfo{
 				{
-					GoType: nullType,
+					GoType:    gc.typegenName("Null"),
+					UnionName: "null",
 				},
 				inner,
 			}
 		case len(types) == 2 && isNullField(types[1]):
 			inner := gc.GoTypeOf(types[0])
-			info.GoType = "*" + inner.GoType
+			info.GoType, inner = gc.nullableGoType(inner)
+			inner.UnionName = avroTypeName(types[0])
 			info.Union = []typeInfo{
 				inner,
 				{
-					GoType: nullType,
+					GoType:    gc.typegenName("Null"),
+					UnionName: "null",
 				},
 			}
 		default:
@@ -520,6 +693,7 @@ func (gc *generateContext) GoTypeOf(t schema.AvroType) typeInfo {
 			info.Union = make([]typeInfo, len(types))
 			for i, t := range types {
 				info.Union[i] = gc.GoTypeOf(t)
+				info.Union[i].UnionName = avroTypeName(t)
 			}
 		}
 	case *schema.ArrayField:
@@ -535,18 +709,233 @@ func (gc *generateContext) GoTypeOf(t schema.AvroType) typeInfo {
 		if !ok {
 			gt = goTypeForDefinition(t.Def)
 		}
-		name := gt.Name
-		if gt.PkgPath != "" {
-			ident := gc.addImport(gt.PkgPath)
-			name = ident + "." + name
-		}
-		info.GoType = name
+		info.GoType = gc.goTypeName(gt)
 	default:
 		panic(fmt.Sprintf("unknown avro type %T", t))
 	}
 	return info
 }
 
+// unionField holds information about a record field that
+// holds a union represented as interface{}.
+type unionField struct {
+	// Field holds the Go name of the field.
+	Field string
+	// Branches holds an entry for each member of the union.
+	Branches []unionBranch
+}
+
+type unionBranch struct {
+	// Name holds the name used for the branch in
+	// accessor method names.
+	Name string
+	// GoType holds the Go type of the branch.
+	GoType string
+}
+
+// UnionFields returns information on all the fields in t that
+// are represented as interface{} so that typed accessor methods
+// can be generated for them, or nil if they aren't enabled.
+// Fields for which the accessor names would clash with other
+// names are omitted.
+func (gc *generateContext) UnionFields(t *schema.RecordDefinition) ([]unionField, error) {
+	if !gc.opts.unionAccessors {
+		return nil, nil
+	}
+	used := map[string]bool{
+		"AvroRecord": true,
+	}
+	for _, f := range t.Fields() {
+		name, err := fieldGoName(f.Name())
+		if err != nil {
+			return nil, err
+		}
+		used[name] = true
+	}
+	var fields []unionField
+	for _, f := range t.Fields() {
+		info := gc.GoTypeOf(f.Type())
+		if info.GoType != "interface{}" {
+			continue
+		}
+		name, _ := fieldGoName(f.Name())
+		uf := unionField{
+			Field: name,
+		}
+		var methods []string
+		for _, at := range f.Type().(*schema.UnionField).AvroTypes() {
+			b := unionBranch{
+				Name:   unionBranchName(at),
+				GoType: gc.GoTypeOf(at).GoType,
+			}
+			if isNullGoType(b.GoType) {
+				methods = append(methods, name+"IsNull", "Set"+name+"Null")
+			} else {
+				methods = append(methods, name+"As"+b.Name, "Set"+name+b.Name)
+			}
+			uf.Branches = append(uf.Branches, b)
+		}
+		if !addNames(used, methods) {
+			continue
+		}
+		fields = append(fields, uf)
+	}
+	return fields, nil
+}
+
+// addNames adds all the given names to used and returns true,
+// or returns false without changing used if any of the names
+// are already present or duplicated.
+func addNames(used map[string]bool, names []string) bool {
+	seen := make(map[string]bool)
+	for _, name := range names {
+		if used[name] || seen[name] {
+			return false
+		}
+		seen[name] = true
+	}
+	for _, name := range names {
+		used[name] = true
+	}
+	return true
+}
+
+// unionBranchName returns the name used to identify
+// a union member of type t in accessor method names.
+func unionBranchName(t schema.AvroType) string {
+	switch t := t.(type) {
+	case *schema.NullField:
+		return "Null"
+	case *schema.BoolField:
+		return "Boolean"
+	case *schema.IntField:
+		return "Int"
+	case *schema.LongField:
+		return "Long"
+	case *schema.FloatField:
+		return "Float"
+	case *schema.DoubleField:
+		return "Double"
+	case *schema.BytesField:
+		return "Bytes"
+	case *schema.StringField:
+		return "String"
+	case *schema.ArrayField:
+		return "Array"
+	case *schema.MapField:
+		return "Map"
+	case *schema.Reference:
+		return strings.Title(goTypeForDefinition(t.Def).Name)
+	default:
+		panic(fmt.Sprintf("unknown avro type %T", t))
+	}
+}
+
+// fieldGoName returns the Go name used for
+// the record field with the given Avro name.
+func fieldGoName(name string) (string, error) {
+	if isExportedGoIdentifier(name) {
+		return name, nil
+	}
+	return goName(name)
+}
+
+// nullableType holds information about one of the nullable
+// types defined by database/sql or one of the Optional types
+// defined by avrotypegen.
+type nullableType struct {
+	// Pkg holds the import path of the package defining the type.
+	Pkg string
+	// Name holds the name of the type.
+	Name string
+	// Field holds the name of the field holding the value.
+	Field string
+	// GoType holds the type of the value.
+	GoType string
+}
+
+// sqlNullTypes maps from Go type to the database/sql
+// nullable type that can represent it.
+var sqlNullTypes = map[string]nullableType{
+	"bool":      {"database/sql", "NullBool", "Bool", "bool"},
+	"int":       {"database/sql", "NullInt32", "Int32", "int32"},
+	"int64":     {"database/sql", "NullInt64", "Int64", "int64"},
+	"float64":   {"database/sql", "NullFloat64", "Float64", "float64"},
+	"string":    {"database/sql", "NullString", "String", "string"},
+	"time.Time": {"database/sql", "NullTime", "Time", "time.Time"},
+}
+
+// optionalTypes maps from Go type to the avrotypegen
+// Optional type that can represent it. Like the database/sql
+// types, there's no entry for []byte, so that all Optional
+// values can be copied and compared by assignment.
+var optionalTypes = map[string]nullableType{
+	"bool":      {avrotypegenPkg, "OptionalBool", "Value", "bool"},
+	"int":       {avrotypegenPkg, "OptionalInt", "Value", "int"},
+	"int64":     {avrotypegenPkg, "OptionalInt64", "Value", "int64"},
+	"float32":   {avrotypegenPkg, "OptionalFloat32", "Value", "float32"},
+	"float64":   {avrotypegenPkg, "OptionalFloat64", "Value", "float64"},
+	"string":    {avrotypegenPkg, "OptionalString", "Value", "string"},
+	"time.Time": {avrotypegenPkg, "OptionalTime", "Value", "time.Time"},
+}
+
+const avrotypegenPkg = "github.com/heetch/avro/avrotypegen"
+
+// nullableGoType returns the Go type to use for a union of null
+// and the given type, and the type info to use for the non-null
+// member of the union.
+func (gc *generateContext) nullableGoType(inner typeInfo) (string, typeInfo) {
+	if nt, ok := gc.nullableType(inner); ok {
+		return gc.addImport(nt.Pkg) + "." + nt.Name, typeInfo{
+			GoType: nt.GoType,
+		}
+	}
+	return "*" + inner.GoType, inner
+}
+
+// nullableType returns the database/sql nullable type or
+// avrotypegen Optional type to use to represent a union
+// of null and the given type, if any.
+func (gc *generateContext) nullableType(inner typeInfo) (nullableType, bool) {
+	if len(inner.Union) > 0 {
+		return nullableType{}, false
+	}
+	var nt nullableType
+	var ok bool
+	switch {
+	case gc.opts.sqlNull:
+		nt, ok = sqlNullTypes[inner.GoType]
+	case gc.opts.optionalNull:
+		nt, ok = optionalTypes[inner.GoType]
+	}
+	return nt, ok
+}
+
+// logicalGoType returns the Go type specified for the logical
+// type of t, if any. Only primitive types can have a Go type specified
+// in this way.
+func (gc *generateContext) logicalGoType(t schema.AvroType) (goType, bool) {
+	switch t.(type) {
+	case *schema.Reference, *schema.UnionField, *schema.ArrayField, *schema.MapField:
+		return goType{}, false
+	}
+	lt := logicalType(t)
+	if lt == "" {
+		return goType{}, false
+	}
+	gt, ok := gc.opts.logicalTypes[lt]
+	return gt, ok
+}
+
+// goTypeName returns the name of the Go type gt as used
+// in the generated code, adding an import if needed.
+func (gc *generateContext) goTypeName(gt goType) string {
+	if gt.PkgPath == "" {
+		return gt.Name
+	}
+	return gc.addImport(gt.PkgPath) + "." + gt.Name
+}
+
 func isNullField(t schema.AvroType) bool {
 	_, ok := t.(*schema.NullField)
 	return ok
@@ -557,6 +946,23 @@ func logicalType(t schema.AvroType) string {
 	return s
 }
 
+// timestampUnit returns the unit of the timestamp logical
+// type of t, "Millis" or "Micros", as used in the names of the
+// avrotypegen methods that read and write it, or the empty
+// string if t isn't a timestamp represented as time.Time.
+func timestampUnit(t schema.AvroType) string {
+	if _, ok := t.(*schema.LongField); !ok {
+		return ""
+	}
+	switch logicalType(t) {
+	case timestampMillis:
+		return "Millis"
+	case timestampMicros:
+		return "Micros"
+	}
+	return ""
+}
+
 // addImport adds a package to the required imports.
 func (gc *generateContext) addImport(pkg string) string {
 	if id := gc.imports[pkg]; id != "" {
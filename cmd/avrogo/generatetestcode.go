@@ -118,7 +118,9 @@ func main() {
 				schemaFiles = append(schemaFiles, f)
 			}
 			var buf bytes.Buffer
-			args := []string{"-p", test.TestName, "schema.avsc"}
+			args := []string{"-p", test.TestName}
+			args = append(args, test.AvrogoFlags...)
+			args = append(args, "schema.avsc")
 			args = append(args, schemaFiles...)
 			cmd := exec.Command("avrogo", args...)
 			cmd.Stderr = &buf
@@ -0,0 +1,102 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/rogpeppe/gogen-avro/v7/schema"
+)
+
+// Getters returns the source of the GetF and GetFOr methods
+// for all the optional fields in the given record, or the empty
+// string if they aren't enabled. A field is optional if it's
+// represented by a pointer, a database/sql nullable type or
+// an avrotypegen Optional type.
+// Fields for which the method names would clash with other
+// names are omitted.
+func (gc *generateContext) Getters(t *schema.RecordDefinition) (string, error) {
+	if !gc.opts.getters {
+		return "", nil
+	}
+	used, err := gc.recordNames(t)
+	if err != nil {
+		return "", err
+	}
+	name := defName(t)
+	var w strings.Builder
+	for _, f := range t.Fields() {
+		fname, _ := fieldGoName(f.Name())
+		valueType, isSet, value, ok := optionalField(fname, gc.GoTypeOf(f.Type()).GoType)
+		if !ok {
+			continue
+		}
+		if !addNames(used, []string{"Get" + fname, "Get" + fname + "Or"}) {
+			continue
+		}
+		fprintf(&w, `
+// Get%[2]s returns the value of %[2]s
+// and reports whether it's set.
+func (r %[1]s) Get%[2]s() (v %[3]s, ok bool) {
+	if %[4]s {
+		return %[5]s, true
+	}
+	return v, false
+}
+
+// Get%[2]sOr returns the value of %[2]s,
+// or def if it's not set.
+func (r %[1]s) Get%[2]sOr(def %[3]s) %[3]s {
+	if %[4]s {
+		return %[5]s
+	}
+	return def
+}
+`, name, fname, valueType, isSet, value)
+	}
+	return w.String(), nil
+}
+
+// optionalField reports whether the record field with Go name
+// fname and Go type goType is optional. If it is, it returns the
+// type of its value, an expression that reports whether the field
+// is set and an expression for its value.
+func optionalField(fname, goType string) (valueType, isSet, value string, ok bool) {
+	if nt, ok := nullableTypeOf(goType); ok {
+		return nt.GoType, "r." + fname + ".Valid", "r." + fname + "." + nt.Field, true
+	}
+	if strings.HasPrefix(goType, "*") {
+		return goType[1:], "r." + fname + " != nil", "*r." + fname, true
+	}
+	return "", "", "", false
+}
+
+// recordNames returns the set of names already used by the fields
+// and the union accessor methods of the given record, so that
+// other generated methods can avoid clashing with them.
+func (gc *generateContext) recordNames(t *schema.RecordDefinition) (map[string]bool, error) {
+	used := map[string]bool{
+		"AvroRecord": true,
+	}
+	for _, f := range t.Fields() {
+		name, err := fieldGoName(f.Name())
+		if err != nil {
+			return nil, err
+		}
+		used[name] = true
+	}
+	unionFields, err := gc.UnionFields(t)
+	if err != nil {
+		return nil, err
+	}
+	for _, f := range unionFields {
+		for _, b := range f.Branches {
+			if isNullGoType(b.GoType) {
+				used[f.Field+"IsNull"] = true
+				used["Set"+f.Field+"Null"] = true
+			} else {
+				used[f.Field+"As"+b.Name] = true
+				used["Set"+f.Field+b.Name] = true
+			}
+		}
+	}
+	return used, nil
+}
@@ -0,0 +1,106 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/build/constraint"
+	"io/ioutil"
+	"strings"
+	"text/template"
+	"time"
+)
+
+var (
+	// headerTmpl holds the template for the comment added to the
+	// top of each generated Go file, as specified with the -header
+	// flag or in the configuration file.
+	headerTmpl *template.Template
+
+	// buildExpr holds the build constraint added to each generated
+	// Go file, as specified with the -buildtags flag or in the
+	// configuration file.
+	buildExpr constraint.Expr
+)
+
+// headerParams holds the value that the header template
+// is executed with.
+type headerParams struct {
+	// Package holds the name of the Go package.
+	Package string
+
+	// File holds the name of the generated file.
+	File string
+
+	// Year holds the current year.
+	Year int
+}
+
+// readHeader reads the header template from the file f.
+func readHeader(f string) error {
+	data, err := ioutil.ReadFile(f)
+	if err != nil {
+		return err
+	}
+	tmpl, err := template.New(f).Parse(string(data))
+	if err != nil {
+		return fmt.Errorf("cannot parse header template: %v", err)
+	}
+	headerTmpl = tmpl
+	return nil
+}
+
+// parseBuildTags parses the build constraint expression expr,
+// such as "linux && !race".
+func parseBuildTags(expr string) error {
+	x, err := constraint.Parse("//go:build " + expr)
+	if err != nil {
+		return fmt.Errorf("invalid build constraint %q: %v", expr, err)
+	}
+	buildExpr = x
+	return nil
+}
+
+// addHeader returns src, the source of the Go file outFile
+// in pkg, with the configured header comment and build
+// constraint added to the start.
+func addHeader(pkg *outputPackage, outFile string, src []byte) ([]byte, error) {
+	if headerTmpl == nil && buildExpr == nil {
+		return src, nil
+	}
+	var buf bytes.Buffer
+	if headerTmpl != nil {
+		var text bytes.Buffer
+		if err := headerTmpl.Execute(&text, headerParams{
+			Package: pkg.name,
+			File:    outFile,
+			Year:    time.Now().Year(),
+		}); err != nil {
+			return nil, fmt.Errorf("cannot execute header template: %v", err)
+		}
+		for _, line := range strings.Split(strings.TrimRight(text.String(), "\n"), "\n") {
+			line = strings.TrimRight(line, " \t")
+			switch {
+			case strings.HasPrefix(line, "//"):
+			case line == "":
+				line = "//"
+			default:
+				line = "// " + line
+			}
+			buf.WriteString(line + "\n")
+		}
+		buf.WriteString("\n")
+	}
+	if buildExpr != nil {
+		buf.WriteString("//go:build " + buildExpr.String() + "\n")
+		lines, err := constraint.PlusBuildLines(buildExpr)
+		if err != nil {
+			return nil, fmt.Errorf("cannot write build constraint: %v", err)
+		}
+		for _, line := range lines {
+			buf.WriteString(line + "\n")
+		}
+		buf.WriteString("\n")
+	}
+	buf.Write(src)
+	return buf.Bytes(), nil
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+
+	"github.com/rogpeppe/gogen-avro/v7/parser"
+
+	"github.com/heetch/avro/internal/avdl"
+)
+
+// parseIDLFile parses the Avro IDL file f and adds all the types it
+// defines to each of the given namespaces. It returns the parsed
+// protocol.
+//
+// Imported files are parsed in the same way, relative to the directory
+// of the importing file, unless they're already present in imported, which
+// holds the absolute paths of all files that have been parsed or that
+// will be parsed independently.
+func parseIDLFile(f string, namespaces []*parser.Namespace, imported map[string]bool) (*avdl.Protocol, error) {
+	data, err := ioutil.ReadFile(f)
+	if err != nil {
+		return nil, err
+	}
+	proto, err := avdl.Parse(f, data)
+	if err != nil {
+		return nil, fmt.Errorf("invalid IDL: %v", err)
+	}
+	for _, imp := range proto.Imports {
+		path := filepath.Join(filepath.Dir(f), filepath.FromSlash(imp.Path))
+		absPath, err := filepath.Abs(path)
+		if err != nil {
+			return nil, err
+		}
+		if imported[absPath] {
+			continue
+		}
+		imported[absPath] = true
+		switch imp.Kind {
+		case "idl":
+			if _, err := parseIDLFile(path, namespaces, imported); err != nil {
+				return nil, err
+			}
+		case "protocol":
+			if _, err := parseProtocolFile(path, namespaces); err != nil {
+				return nil, err
+			}
+		case "schema":
+			data, err := ioutil.ReadFile(path)
+			if err != nil {
+				return nil, fmt.Errorf("cannot import schema: %v", err)
+			}
+			if err := addSchema(path, data, namespaces); err != nil {
+				return nil, err
+			}
+		default:
+			return nil, fmt.Errorf("cannot import %s from %s: %s imports not supported", imp.Path, f, imp.Kind)
+		}
+	}
+	if err := addProtocolTypes(f, proto, namespaces); err != nil {
+		return nil, err
+	}
+	return proto, nil
+}
+
+// addSchema parses the schema in data, which was read
+// from the file f, into all the given namespaces.
+func addSchema(f string, data []byte, namespaces []*parser.Namespace) error {
+	for _, ns := range namespaces {
+		if _, err := ns.TypeForSchema(data); err != nil {
+			return fmt.Errorf("invalid schema in %s: %v", f, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"strings"
+
+	"github.com/rogpeppe/gogen-avro/v7/schema"
+)
+
+// Interface returns the source of the RInterface type for the
+// given record R, along with the GetF and AvroSchema methods that
+// implement it, or the empty string if it isn't enabled or can't
+// be generated because the method names would clash with other
+// names.
+//
+// When getters are enabled, the interface uses their GetF methods
+// for optional fields instead of generating new ones.
+func (gc *generateContext) Interface(t *schema.RecordDefinition) (string, error) {
+	if !gc.opts.interfaces {
+		return "", nil
+	}
+	used, err := gc.recordNames(t)
+	if err != nil {
+		return "", err
+	}
+	type getter struct {
+		field  string
+		goType string
+		// fromGetters holds whether the method is
+		// generated by Getters.
+		fromGetters bool
+	}
+	var getters []getter
+	names := []string{"AvroSchema"}
+	for _, f := range t.Fields() {
+		fname, _ := fieldGoName(f.Name())
+		goType := gc.GoTypeOf(f.Type()).GoType
+		names = append(names, "Get"+fname)
+		if valueType, _, _, ok := optionalField(fname, goType); ok && gc.opts.getters {
+			names = append(names, "Get"+fname+"Or")
+			getters = append(getters, getter{
+				field:       fname,
+				goType:      "(" + valueType + ", bool)",
+				fromGetters: true,
+			})
+			continue
+		}
+		getters = append(getters, getter{
+			field:  fname,
+			goType: goType,
+		})
+	}
+	if !addNames(used, names) {
+		return "", nil
+	}
+	name := defName(t)
+	var w strings.Builder
+	fprintf(&w, `
+// %[1]sInterface is implemented by %[1]s. Code that only reads
+// records can accept it instead, so that other implementations,
+// such as mocks in tests, can be used.
+type %[1]sInterface interface {
+`, name)
+	for _, g := range getters {
+		if g.fromGetters {
+			fprintf(&w, "\t// Get%[1]s returns the value of the %[1]s field\n\t// and reports whether it's set.\n", g.field)
+		} else {
+			fprintf(&w, "\t// Get%[1]s returns the value of the %[1]s field.\n", g.field)
+		}
+		fprintf(&w, "\tGet%s() %s\n\n", g.field, g.goType)
+	}
+	fprintf(&w, `	// AvroSchema returns the Avro schema of the record.
+	AvroSchema() string
+}
+
+var _ %[1]sInterface = %[1]s{}
+`, name)
+	for _, g := range getters {
+		if g.fromGetters {
+			continue
+		}
+		fprintf(&w, `
+// Get%[2]s returns the value of %[2]s.
+func (r %[1]s) Get%[2]s() %[3]s {
+	return r.%[2]s
+}
+`, name, g.field, g.goType)
+	}
+	schemaStr, err := t.Schema()
+	if err != nil {
+		return "", err
+	}
+	fprintf(&w, `
+// AvroSchema returns the Avro schema of %[1]s.
+func (%[1]s) AvroSchema() string {
+	return %[2]s
+}
+`, name, quote(schemaStr))
+	return w.String(), nil
+}
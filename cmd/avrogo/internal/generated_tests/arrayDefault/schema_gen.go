@@ -1,4 +1,7 @@
 // Code generated by avrogen. DO NOT EDIT.
+//
+// Schema fingerprints:
+//	R 0x3322689bfceffbf5
 
 package arrayDefault
 
@@ -1,4 +1,7 @@
 // Code generated by avrogen. DO NOT EDIT.
+//
+// Schema fingerprints:
+//	R 0x36863fc451ab786e
 
 package arrayOfUnion
 
@@ -25,8 +28,10 @@ func (R) AvroRecord() avrotypegen.RecordInfo {
 				Type: new([]interface{}),
 				Union: []avrotypegen.UnionInfo{{
 					Type: new(int),
+					Name: "int",
 				}, {
 					Type: new(string),
+					Name: "string",
 				}},
 			},
 		},
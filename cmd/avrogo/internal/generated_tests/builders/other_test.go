@@ -0,0 +1,62 @@
+package builders
+
+import (
+	"math/rand"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro"
+)
+
+func TestBuilderWith(t *testing.T) {
+	c := qt.New(t)
+	n := NewNodeBuilder().
+		WithName("x").
+		WithKind(KindBranch).
+		WithCount(3).
+		WithValue("v").
+		Build()
+	c.Assert(n, qt.DeepEquals, Node{
+		Name:  "x",
+		Kind:  KindBranch,
+		Count: 3,
+		Value: "v",
+	})
+}
+
+func TestBuilderRandom(t *testing.T) {
+	c := qt.New(t)
+	for seed := int64(0); seed < 50; seed++ {
+		n := NewNodeBuilder().Random(rand.New(rand.NewSource(seed))).Build()
+		checkRandomNode(c, n, 0)
+		_, _, err := avro.Marshal(n)
+		c.Assert(err, qt.IsNil)
+
+		// The same seed always produces the same value.
+		n1 := NewNodeBuilder().Random(rand.New(rand.NewSource(seed))).Build()
+		c.Assert(n1, qt.DeepEquals, n)
+	}
+}
+
+func checkRandomNode(c *qt.C, n Node, depth int) {
+	c.Assert(n.Kind.Valid(), qt.IsTrue)
+	switch v := n.Value.(type) {
+	case nil, int, string:
+	case Kind:
+		c.Assert(v.Valid(), qt.IsTrue)
+	default:
+		c.Fatalf("unexpected type %T in union", v)
+	}
+	if depth >= 4 {
+		c.Assert(n.Next, qt.IsNil)
+		c.Assert(n.Children, qt.HasLen, 0)
+		c.Assert(n.Attrs, qt.HasLen, 0)
+	}
+	if n.Next != nil {
+		checkRandomNode(c, *n.Next, depth+1)
+	}
+	for _, child := range n.Children {
+		checkRandomNode(c, child, depth+1)
+	}
+}
@@ -1,4 +1,9 @@
 // Code generated by avrogen. DO NOT EDIT.
+//
+// Schema fingerprints:
+//	com.heetch.CloudEvent 0xf44066cd0215569b
+//	com.heetch.Message 0x39d430d272dc679a
+//	com.heetch.Metadata 0x354c42091b37e50b
 
 package cloudEvent
 
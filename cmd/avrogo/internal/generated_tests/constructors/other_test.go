@@ -0,0 +1,38 @@
+package constructors
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestNewR(t *testing.T) {
+	c := qt.New(t)
+	r := NewR()
+	c.Assert(r.A, qt.Equals, 5)
+	c.Assert(r.B, qt.Equals, "")
+	c.Assert(r.C, qt.Not(qt.IsNil))
+	c.Assert(*r.C, qt.Equals, "x")
+	c.Assert(r.D, qt.IsNil)
+	c.Assert(r.E, qt.Equals, ColorGreen)
+	c.Assert(r.F, qt.Equals, 0)
+	c.Assert(r.H, qt.Equals, 3)
+	c.Assert(r.N, qt.Equals, Inner{X: 2})
+	c.Assert(NewInner().X, qt.Equals, 1)
+}
+
+func TestSetDefaults(t *testing.T) {
+	c := qt.New(t)
+	r := R{
+		A: 1,
+		B: "b",
+		D: "d",
+		F: 8,
+	}
+	r.SetDefaults()
+	c.Assert(r.A, qt.Equals, 5)
+	c.Assert(r.B, qt.Equals, "")
+	c.Assert(*r.C, qt.Equals, "x")
+	c.Assert(r.D, qt.IsNil)
+	c.Assert(r.F, qt.Equals, 8)
+}
@@ -1,4 +1,8 @@
 // Code generated by avrogen. DO NOT EDIT.
+//
+// Schema fingerprints:
+//	R1 0xf932c7685b0c9608
+//	R2 0x8c4b15a7b9d4faec
 
 package duplicateRecord
 
@@ -1,4 +1,8 @@
 // Code generated by avrogen. DO NOT EDIT.
+//
+// Schema fingerprints:
+//	Foo 0x6ee146f18e8b9f39
+//	R 0x3d77278a4473f34c
 
 package enumDefault
 
@@ -30,6 +34,12 @@ func (e Foo) String() string {
 	return _Foo_strings[e]
 }
 
+// Valid reports whether e holds one of the
+// symbols defined for Foo.
+func (e Foo) Valid() bool {
+	return e >= 0 && int(e) < len(_Foo_strings)
+}
+
 // MarshalText implements encoding.TextMarshaler
 // by returning the textual representation of Foo.
 func (e Foo) MarshalText() ([]byte, error) {
@@ -1,4 +1,8 @@
 // Code generated by avrogen. DO NOT EDIT.
+//
+// Schema fingerprints:
+//	R 0x623914de682dc8be
+//	five 0x9ec7f35d6ee322fa
 
 package fixedDefault
 
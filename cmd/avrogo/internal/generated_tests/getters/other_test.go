@@ -0,0 +1,37 @@
+package getters
+
+import (
+	"database/sql"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestGetters(t *testing.T) {
+	c := qt.New(t)
+	var r R
+	_, ok := r.GetP()
+	c.Assert(ok, qt.Equals, false)
+	c.Assert(r.GetPOr("def"), qt.Equals, "def")
+	_, ok = r.GetB()
+	c.Assert(ok, qt.Equals, false)
+	c.Assert(r.GetBOr([]byte("def")), qt.DeepEquals, []byte("def"))
+	_, ok = r.GetN()
+	c.Assert(ok, qt.Equals, false)
+	c.Assert(r.GetNOr(Inner{X: 1}), qt.Equals, Inner{X: 1})
+
+	b := []byte("b")
+	r = R{
+		P: sql.NullString{String: "p", Valid: true},
+		B: &b,
+		N: &Inner{X: 2},
+	}
+	p, ok := r.GetP()
+	c.Assert(ok, qt.Equals, true)
+	c.Assert(p, qt.Equals, "p")
+	c.Assert(r.GetPOr("def"), qt.Equals, "p")
+	c.Assert(r.GetBOr(nil), qt.DeepEquals, []byte("b"))
+	n, ok := r.GetN()
+	c.Assert(ok, qt.Equals, true)
+	c.Assert(n, qt.Equals, Inner{X: 2})
+}
@@ -1,4 +1,9 @@
 // Code generated by avrogen. DO NOT EDIT.
+//
+// Schema fingerprints:
+//	M 0x1e3839991ad533f9
+//	e 0x849c5dbd4f5a83b3
+//	f 0xf9d07f398076128d
 
 package goTypeCustomName
 
@@ -44,6 +49,12 @@ func (e customEnum) String() string {
 	return _customEnum_strings[e]
 }
 
+// Valid reports whether e holds one of the
+// symbols defined for customEnum.
+func (e customEnum) Valid() bool {
+	return e >= 0 && int(e) < len(_customEnum_strings)
+}
+
 // MarshalText implements encoding.TextMarshaler
 // by returning the textual representation of customEnum.
 func (e customEnum) MarshalText() ([]byte, error) {
@@ -1,4 +1,7 @@
 // Code generated by avrogen. DO NOT EDIT.
+//
+// Schema fingerprints:
+//	R 0xeaeb6d6b538bb7d0
 
 package goTypeExternal
 
@@ -0,0 +1,47 @@
+package interfaces
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro"
+)
+
+type mockR struct {
+	a string
+}
+
+func (m mockR) GetA() string {
+	return m.a
+}
+
+func (m mockR) GetO() *int64 {
+	return nil
+}
+
+func (m mockR) AvroSchema() string {
+	return R{}.AvroSchema()
+}
+
+func describe(r RInterface) string {
+	s := r.GetA()
+	if o := r.GetO(); o != nil {
+		s += "!"
+	}
+	return s
+}
+
+func TestInterface(t *testing.T) {
+	c := qt.New(t)
+	o := int64(1)
+	c.Assert(describe(R{A: "x", O: &o}), qt.Equals, "x!")
+	c.Assert(describe(mockR{a: "y"}), qt.Equals, "y")
+}
+
+func TestAvroSchema(t *testing.T) {
+	c := qt.New(t)
+	at, err := avro.TypeOf(R{})
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(R{}.AvroSchema(), qt.Equals, at.String())
+}
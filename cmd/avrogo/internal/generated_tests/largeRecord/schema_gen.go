@@ -1,4 +1,13 @@
 // Code generated by avrogen. DO NOT EDIT.
+//
+// Schema fingerprints:
+//	bodyworks.Data1 0x7e1d8791b6d174b7
+//	bodyworks.Trace1 0x9b91cbe93c1f2cdf
+//	bodyworks.datatype.UUID1 0x8f6910e42abdab34
+//	com.avro.test.sample 0x6901391eca11a032
+//	headerworks.Data0 0x7341657c52b69093
+//	headerworks.Trace0 0xee901679e7a2d5c9
+//	headerworks.datatype.UUID0 0xad10c4fa9330f2a5
 
 package largeRecord
 
@@ -1,4 +1,7 @@
 // Code generated by avrogen. DO NOT EDIT.
+//
+// Schema fingerprints:
+//	List 0x3f5c0bc504512c2a
 
 package linkedList
 
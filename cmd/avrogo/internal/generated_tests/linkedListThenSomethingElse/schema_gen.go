@@ -1,4 +1,8 @@
 // Code generated by avrogen. DO NOT EDIT.
+//
+// Schema fingerprints:
+//	List 0x3f5c0bc504512c2a
+//	R 0xe5160078033f9a1d
 
 package linkedListThenSomethingElse
 
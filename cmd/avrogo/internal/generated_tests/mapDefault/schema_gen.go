@@ -1,4 +1,7 @@
 // Code generated by avrogen. DO NOT EDIT.
+//
+// Schema fingerprints:
+//	R 0x9c8bbd7ef22327c1
 
 package mapDefault
 
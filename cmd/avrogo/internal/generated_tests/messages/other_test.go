@@ -0,0 +1,72 @@
+package messages
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro"
+)
+
+func TestEncodeDecode(t *testing.T) {
+	c := qt.New(t)
+	ctx := context.Background()
+	registry := memRegistry{
+		1: mustTypeOf(Order{}),
+	}
+	data, err := EncodeOrder(ctx, avro.NewSingleEncoder(registry, nil), Order{ID: 20, Item: "x"})
+	c.Assert(err, qt.IsNil)
+	c.Assert(data, qt.DeepEquals, []byte{1, 40, 2, 'x'})
+
+	x, err := DecodeOrder(ctx, avro.NewSingleDecoder(registry, nil), data)
+	c.Assert(err, qt.IsNil)
+	c.Assert(x, qt.DeepEquals, Order{ID: 20, Item: "x"})
+
+	_, err = DecodeOrder(ctx, avro.NewSingleDecoder(registry, nil), []byte{2, 40, 2, 'x'})
+	c.Assert(err, qt.ErrorMatches, `.*schema not found for id 2`)
+}
+
+func mustTypeOf(x interface{}) *avro.Type {
+	t, err := avro.TypeOf(x)
+	if err != nil {
+		panic(err)
+	}
+	return t
+}
+
+// memRegistry implements avro.DecodingRegistry and avro.EncodingRegistry
+// by associating a single-byte schema ID with schemas.
+type memRegistry map[int64]*avro.Type
+
+func (m memRegistry) DecodeSchemaID(msg []byte) (int64, []byte) {
+	if len(msg) < 1 {
+		return 0, nil
+	}
+	return int64(msg[0]), msg[1:]
+}
+
+func (m memRegistry) SchemaForID(ctx context.Context, id int64) (*avro.Type, error) {
+	t, ok := m[id]
+	if !ok {
+		return nil, fmt.Errorf("schema not found for id %d", id)
+	}
+	return t, nil
+}
+
+func (m memRegistry) AppendSchemaID(buf []byte, id int64) []byte {
+	if id < 0 || id > 256 {
+		panic("schema ID out of range")
+	}
+	return append(buf, byte(id))
+}
+
+func (m memRegistry) IDForSchema(ctx context.Context, schema *avro.Type) (int64, error) {
+	for id, s := range m {
+		if s.String() == schema.String() {
+			return id, nil
+		}
+	}
+	return 0, fmt.Errorf("schema not found")
+}
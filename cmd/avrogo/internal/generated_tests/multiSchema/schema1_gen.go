@@ -1,4 +1,7 @@
 // Code generated by avrogen. DO NOT EDIT.
+//
+// Schema fingerprints:
+//	S 0xfeb4968e9f29a223
 
 package multiSchema
 
@@ -1,4 +1,7 @@
 // Code generated by avrogen. DO NOT EDIT.
+//
+// Schema fingerprints:
+//	R 0x2d31493d10be97cc
 
 package multiSchemaExternalType
 
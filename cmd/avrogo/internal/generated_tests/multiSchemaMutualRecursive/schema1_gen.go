@@ -1,4 +1,7 @@
 // Code generated by avrogen. DO NOT EDIT.
+//
+// Schema fingerprints:
+//	S 0xfe2e8a0559d9d2f2
 
 package multiSchemaMutualRecursive
 
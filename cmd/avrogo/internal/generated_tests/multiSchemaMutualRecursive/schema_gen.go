@@ -1,4 +1,7 @@
 // Code generated by avrogen. DO NOT EDIT.
+//
+// Schema fingerprints:
+//	R 0x22597f2e52990a19
 
 package multiSchemaMutualRecursive
 
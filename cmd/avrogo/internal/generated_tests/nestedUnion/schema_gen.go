@@ -1,4 +1,7 @@
 // Code generated by avrogen. DO NOT EDIT.
+//
+// Schema fingerprints:
+//	R 0x4aba87eb555723c6
 
 package nestedUnion
 
@@ -25,12 +28,16 @@ func (R) AvroRecord() avrotypegen.RecordInfo {
 				Type: new([]interface{}),
 				Union: []avrotypegen.UnionInfo{{
 					Type: new(int),
+					Name: "int",
 				}, {
 					Type: new([]*string),
+					Name: "array",
 					Union: []avrotypegen.UnionInfo{{
 						Type: nil,
+						Name: "null",
 					}, {
 						Type: new(string),
+						Name: "string",
 					}},
 				}},
 			},
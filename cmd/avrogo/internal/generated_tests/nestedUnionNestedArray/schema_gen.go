@@ -1,4 +1,7 @@
 // Code generated by avrogen. DO NOT EDIT.
+//
+// Schema fingerprints:
+//	R 0x8e4b91a1a9a41bb4
 
 package nestedUnionNestedArray
 
@@ -22,8 +25,10 @@ func (R) AvroRecord() avrotypegen.RecordInfo {
 				Type: new([][]*string),
 				Union: []avrotypegen.UnionInfo{{
 					Type: nil,
+					Name: "null",
 				}, {
 					Type: new(string),
+					Name: "string",
 				}},
 			},
 		},
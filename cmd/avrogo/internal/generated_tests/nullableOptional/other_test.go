@@ -0,0 +1,21 @@
+package nullableOptional
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro"
+)
+
+func TestOptional(t *testing.T) {
+	c := qt.New(t)
+	r := R{
+		A: avro.OptionalString{Value: "a", Valid: true},
+	}
+	a, ok := r.GetA()
+	c.Assert(ok, qt.Equals, true)
+	c.Assert(a, qt.Equals, "a")
+	c.Assert(r.GetBOr(99), qt.Equals, 99)
+	c.Assert(r.D, qt.Equals, avro.OptionalFloat64{})
+}
@@ -1,4 +1,8 @@
 // Code generated by avrogen. DO NOT EDIT.
+//
+// Schema fingerprints:
+//	Foo 0xcd1dd0b676ae918b
+//	R 0x9eff2389e05aff6d
 
 package recordDefault
 
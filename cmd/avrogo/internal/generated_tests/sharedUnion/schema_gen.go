@@ -1,4 +1,7 @@
 // Code generated by avrogen. DO NOT EDIT.
+//
+// Schema fingerprints:
+//	R 0x1e3112e276f96781
 
 package sharedUnion
 
@@ -33,20 +36,26 @@ func (R) AvroRecord() avrotypegen.RecordInfo {
 				Type: new(interface{}),
 				Union: []avrotypegen.UnionInfo{{
 					Type: new(int),
+					Name: "int",
 				}, {
 					Type: new(string),
+					Name: "string",
 				}, {
 					Type: new(float32),
+					Name: "float",
 				}},
 			},
 			1: {
 				Type: new(interface{}),
 				Union: []avrotypegen.UnionInfo{{
 					Type: new(int),
+					Name: "int",
 				}, {
 					Type: new(string),
+					Name: "string",
 				}, {
 					Type: new(float32),
+					Name: "float",
 				}},
 			},
 		},
@@ -18,6 +18,14 @@ func TestString(t *testing.T) {
 	c.Assert(MyEnum(3).String(), qt.Equals, "MyEnum(3)")
 }
 
+func TestValid(t *testing.T) {
+	c := qt.New(t)
+	c.Assert(MyEnumA.Valid(), qt.Equals, true)
+	c.Assert(MyEnumC.Valid(), qt.Equals, true)
+	c.Assert(MyEnum(-1).Valid(), qt.Equals, false)
+	c.Assert(MyEnum(3).Valid(), qt.Equals, false)
+}
+
 func TestMarshalText(t *testing.T) {
 	c := qt.New(t)
 	data, err := MyEnumA.MarshalText()
@@ -1,4 +1,8 @@
 // Code generated by avrogen. DO NOT EDIT.
+//
+// Schema fingerprints:
+//	MyEnum 0x81121a9c542e7257
+//	R 0x493ab135ac6c4645
 
 package simpleEnum
 
@@ -30,6 +34,12 @@ func (e MyEnum) String() string {
 	return _MyEnum_strings[e]
 }
 
+// Valid reports whether e holds one of the
+// symbols defined for MyEnum.
+func (e MyEnum) Valid() bool {
+	return e >= 0 && int(e) < len(_MyEnum_strings)
+}
+
 // MarshalText implements encoding.TextMarshaler
 // by returning the textual representation of MyEnum.
 func (e MyEnum) MarshalText() ([]byte, error) {
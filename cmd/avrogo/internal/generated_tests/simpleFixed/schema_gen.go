@@ -1,4 +1,8 @@
 // Code generated by avrogen. DO NOT EDIT.
+//
+// Schema fingerprints:
+//	R 0xf82f9171b30a72b0
+//	five 0x9ec7f35d6ee322fa
 
 package simpleFixed
 
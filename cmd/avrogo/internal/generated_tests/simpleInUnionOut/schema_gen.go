@@ -1,4 +1,7 @@
 // Code generated by avrogen. DO NOT EDIT.
+//
+// Schema fingerprints:
+//	R 0x844c8212d88c9fb2
 
 package simpleInUnionOut
 
@@ -32,18 +35,25 @@ func (R) AvroRecord() avrotypegen.RecordInfo {
 				Type: new(interface{}),
 				Union: []avrotypegen.UnionInfo{{
 					Type: new(int),
+					Name: "int",
 				}, {
 					Type: new(int64),
+					Name: "long",
 				}, {
 					Type: new(float32),
+					Name: "float",
 				}, {
 					Type: new(float64),
+					Name: "double",
 				}, {
 					Type: new(string),
+					Name: "string",
 				}, {
 					Type: new(bool),
+					Name: "boolean",
 				}, {
 					Type: nil,
+					Name: "null",
 				}},
 			},
 		},
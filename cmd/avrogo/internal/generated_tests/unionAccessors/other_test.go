@@ -0,0 +1,30 @@
+package unionAccessors
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestUnionAccessors(t *testing.T) {
+	c := qt.New(t)
+	var r PrimitiveUnionTestRecord
+	c.Assert(r.UnionFieldIsNull(), qt.Equals, true)
+
+	r.SetUnionFieldLong(99)
+	c.Assert(r.UnionField, qt.Equals, int64(99))
+	c.Assert(r.UnionFieldIsNull(), qt.Equals, false)
+	v, ok := r.UnionFieldAsLong()
+	c.Assert(ok, qt.Equals, true)
+	c.Assert(v, qt.Equals, int64(99))
+	_, ok = r.UnionFieldAsInt()
+	c.Assert(ok, qt.Equals, false)
+
+	r.SetUnionFieldString("hello")
+	s, ok := r.UnionFieldAsString()
+	c.Assert(ok, qt.Equals, true)
+	c.Assert(s, qt.Equals, "hello")
+
+	r.SetUnionFieldNull()
+	c.Assert(r.UnionField, qt.IsNil)
+}
@@ -1,4 +1,7 @@
 // Code generated by avrogen. DO NOT EDIT.
+//
+// Schema fingerprints:
+//	R 0xdac361b49812e7df
 
 package unionIntVsLong
 
@@ -28,10 +31,13 @@ func (R) AvroRecord() avrotypegen.RecordInfo {
 				Type: new(interface{}),
 				Union: []avrotypegen.UnionInfo{{
 					Type: new(int64),
+					Name: "long",
 				}, {
 					Type: new(int),
+					Name: "int",
 				}, {
 					Type: new(string),
+					Name: "string",
 				}},
 			},
 		},
@@ -1,4 +1,7 @@
 // Code generated by avrogen. DO NOT EDIT.
+//
+// Schema fingerprints:
+//	R 0x216db4b59f84ef27
 
 package unionNullStringReverse
 
@@ -22,8 +25,10 @@ func (R) AvroRecord() avrotypegen.RecordInfo {
 				Type: new(*string),
 				Union: []avrotypegen.UnionInfo{{
 					Type: new(string),
+					Name: "string",
 				}, {
 					Type: nil,
+					Name: "null",
 				}},
 			},
 		},
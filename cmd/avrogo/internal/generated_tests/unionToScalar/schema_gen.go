@@ -1,4 +1,7 @@
 // Code generated by avrogen. DO NOT EDIT.
+//
+// Schema fingerprints:
+//	PrimitiveUnionTestRecord 0x0a0d0f22ac1de3da
 
 package unionToScalar
 
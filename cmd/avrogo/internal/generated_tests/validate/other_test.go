@@ -0,0 +1,66 @@
+package validate
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+var validateTests = []struct {
+	testName    string
+	r           R
+	expectError string
+}{{
+	testName: "valid",
+	r: R{
+		E: ColorGreen,
+		U: ColorRed,
+		A: []Inner{{C: ColorRed}},
+		M: map[string]Color{"x": ColorGreen},
+		P: &Inner{C: ColorGreen},
+	},
+}, {
+	testName:    "bad-enum",
+	r:           R{E: 5},
+	expectError: `E: invalid value 5 for Color`,
+}, {
+	testName:    "bad-union-type",
+	r:           R{U: "x"},
+	expectError: `U: invalid type string in union`,
+}, {
+	testName:    "bad-enum-in-union",
+	r:           R{U: Color(5)},
+	expectError: `U: invalid value 5 for Color`,
+}, {
+	testName: "bad-enum-in-array",
+	r: R{
+		A: []Inner{{C: ColorRed}, {C: -1}},
+	},
+	expectError: `A\[1\]: C: invalid value -1 for Color`,
+}, {
+	testName: "bad-enum-in-map",
+	r: R{
+		M: map[string]Color{"x": 2},
+	},
+	expectError: `M\["x"\]: invalid value 2 for Color`,
+}, {
+	testName: "bad-enum-in-pointer",
+	r: R{
+		P: &Inner{C: 3},
+	},
+	expectError: `P: C: invalid value 3 for Color`,
+}}
+
+func TestValidate(t *testing.T) {
+	c := qt.New(t)
+	for _, test := range validateTests {
+		c.Run(test.testName, func(c *qt.C) {
+			err := test.r.Validate()
+			if test.expectError == "" {
+				c.Assert(err, qt.IsNil)
+			} else {
+				c.Assert(err, qt.ErrorMatches, test.expectError)
+			}
+		})
+	}
+}
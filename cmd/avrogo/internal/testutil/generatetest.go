@@ -1,6 +1,7 @@
 package testutil
 
 import (
+	"encoding"
 	"encoding/json"
 	"reflect"
 	"testing"
@@ -79,6 +80,34 @@ func (subtest RoundTripSubtest) runTest(c *qt.C, test RoundTripTest, inCodec *go
 	c.Assert(err, qt.Equals, nil)
 	c.Check(nativeJSON, qt.JSONEquals, json.RawMessage(subtest.OutDataJSON))
 	c.Check(remaining, qt.HasLen, 0)
+
+	if m, ok := x.Elem().Interface().(encoding.BinaryMarshaler); ok {
+		subtest.checkBinaryMethods(c, m, outCodec)
+	}
+}
+
+// checkBinaryMethods checks that the MarshalBinary and UnmarshalBinary
+// methods generated with the avrogo -binary flag agree with avro.Marshal.
+func (subtest RoundTripSubtest) checkBinaryMethods(c *qt.C, m encoding.BinaryMarshaler, outCodec *goavro.Codec) {
+	checkData := func(data []byte) {
+		native, remaining, err := outCodec.NativeFromBinary(data)
+		c.Assert(err, qt.Equals, nil)
+		nativeJSON, err := outCodec.TextualFromNative(nil, native)
+		c.Assert(err, qt.Equals, nil)
+		c.Check(nativeJSON, qt.JSONEquals, json.RawMessage(subtest.OutDataJSON))
+		c.Check(remaining, qt.HasLen, 0)
+	}
+	data, err := m.MarshalBinary()
+	c.Assert(err, qt.Equals, nil)
+	c.Logf("MarshalBinary data: %x", data)
+	checkData(data)
+
+	y := reflect.New(reflect.TypeOf(m))
+	err = y.Interface().(encoding.BinaryUnmarshaler).UnmarshalBinary(data)
+	c.Assert(err, qt.Equals, nil)
+	data, _, err = avro.Marshal(y.Elem().Interface())
+	c.Assert(err, qt.Equals, nil)
+	checkData(data)
 }
 
 func (subtest RoundTripSubtest) checkError(c *qt.C, kind ErrorType, err error, extra ...interface{}) {
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"go/token"
+	"sort"
+	"strings"
+)
+
+// logicalTypeFlag implements flag.Value for the -logicaltype flag.
+// It maps from logical type name to the Go type used to represent it.
+type logicalTypeFlag map[string]goType
+
+func (f logicalTypeFlag) String() string {
+	var names []string
+	for name := range f {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var buf strings.Builder
+	for i, name := range names {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		gt := f[name]
+		buf.WriteString(name + "=")
+		if gt.PkgPath != "" {
+			buf.WriteString(gt.PkgPath + ".")
+		}
+		buf.WriteString(gt.Name)
+	}
+	return buf.String()
+}
+
+// Set implements flag.Value.Set by parsing a
+// value of the form name=type, where type is either
+// a predeclared Go type name or an import path followed
+// by a dot and a type name, such as github.com/google/uuid.UUID.
+func (f logicalTypeFlag) Set(s string) error {
+	i := strings.Index(s, "=")
+	if i <= 0 {
+		return fmt.Errorf("logical type mapping %q is not in the form name=type", s)
+	}
+	name, typ := s[:i], s[i+1:]
+	gt, err := parseGoType(typ, name)
+	if err != nil {
+		return err
+	}
+	f[name] = gt
+	return nil
+}
+
+// parseGoType parses the Go type typ specified for the given
+// logical type. It's either a predeclared Go type name or an
+// import path followed by a dot and a type name.
+func parseGoType(typ, logicalType string) (goType, error) {
+	var gt goType
+	if j := strings.LastIndex(typ, "."); j > strings.LastIndex(typ, "/") {
+		gt.PkgPath, gt.Name = typ[:j], typ[j+1:]
+		if !isExportedGoIdentifier(gt.Name) {
+			return goType{}, fmt.Errorf("invalid type name %q for logical type %q", gt.Name, logicalType)
+		}
+	} else {
+		gt.Name = typ
+		if !token.IsIdentifier(gt.Name) {
+			return goType{}, fmt.Errorf("invalid type name %q for logical type %q", gt.Name, logicalType)
+		}
+	}
+	return gt, nil
+}
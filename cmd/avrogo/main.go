@@ -5,22 +5,319 @@
 //
 // Type names within different schemas may refer to one another;
 // for example to put a shared definition in a separate .avsc file.
+// References are resolved after all the files have been read, so
+// the files can be given in any order.
+//
+// A directory can be given instead of a schema file, in which case
+// all the .avsc, .avdl and .avpr files in it (but not in its
+// subdirectories) are used. Arguments containing glob metacharacters
+// are expanded with filepath.Glob, so patterns such as "schemas/*.avsc"
+// work even where there's no shell to expand them, as in go:generate
+// directives. A file named by more than one argument is only read once.
+//
+// Files with a .avdl extension are read as Avro IDL. All the types
+// defined in an IDL file result in Go types, along with the types
+// from any files it imports unless those files are also named on the
+// command line. Files with a .avpr extension are read as Avro protocols
+// in JSON format in the same way.
+//
+// Messages in protocols are ignored unless the -rpc flag is given.
+// In that case, each message M in a protocol P has a record type
+// PMRequest holding its parameters, which encodes in the same way
+// as an Avro RPC request, and the protocol has a Go interface type P
+// with a method for each message that takes a context and the request
+// and returns the response and an error. The interface can be
+// implemented by servers and by clients. Transports and the Avro RPC
+// handshake are left to the program.
 //
 // Usage:
 //
-//	usage: avrogo [flags] schema-file...
+//	usage: avrogo [flags] schema-file|dir|pattern...
+//	  -binary
+//	    	generate MarshalBinary and UnmarshalBinary methods for records
+//	  -buildtags string
+//	    	build constraint expression added to each generated Go file, such as "linux && !race"
+//	  -builders
+//	    	generate builder types with random value generators for records
+//	  -bytehelpers
+//	    	generate Hex, Bytes and SetBytes methods for fixed types and FHex methods for bytes fields
+//	  -clone
+//	    	generate Clone and Equal methods for records
+//	  -config string
+//	    	JSON file configuring the Go types used for logical types
+//	  -constructors
+//	    	generate NewT functions and SetDefaults methods for records
 //	  -d string
-//	    	directory to write Go files to (default ".")
+//	    	directory to write Go files to, or - to write to standard output (default ".")
 //	  -p string
-//	    	package name (defaults to $GOPACKAGE)
+//	    	package name (defaults to $GOPACKAGE, or is inferred from the output directory)
+//	  -fingerprint
+//	    	generate constants holding the canonical schema and fingerprint of records
+//	  -getters
+//	    	generate GetF and GetFOr methods for optional record fields
+//	  -header string
+//	    	file holding a template for a comment added to the top of each generated Go file, such as a license header
+//	  -interfaces
+//	    	generate an RInterface type with GetF and AvroSchema methods for each record R
+//	  -logicaltype value
+//	    	map from logical type to Go type in the form name=type (can be repeated)
+//	  -map value
+//	    	map from Avro namespace to Go package directory in the form namespace=dir (can be repeated)
+//	  -messages
+//	    	generate EncodeR and DecodeR functions for records using avro.SingleEncoder and avro.SingleDecoder
+//	  -noreflect
+//	    	generate code that uses neither reflection nor interface{} values, with AppendBinary methods for records (implies -selfcontained)
+//	  -nullable string
+//	    	representation of unions of null and another type: "pointer", "sql" or "optional" (default "pointer")
+//	  -omitempty
+//	    	add omitempty to the struct tags specified with -tags
+//	  -rpc
+//	    	generate request types and interfaces for the messages in Avro protocols
+//	  -selfcontained
+//	    	generate code that doesn't depend on the avro module (implies -binary)
+//	  -split
+//	    	write each generated type to its own file
 //	  -t	generated files will have _test.go suffix
-//	  -map string
-//	    	map from Avro namespace to Go package.
+//	  -tags value
+//	    	struct tags to generate for record fields, as a comma-separated list of key[:style] where style is "avro" or "snake" (can be repeated)
+//	  -template value
+//	    	template file to execute for each output package, writing a file named after it without the .tmpl extension (can be repeated)
+//	  -unionaccessors
+//	    	generate typed accessor methods for record fields holding unions represented as interface{}
+//	  -validate
+//	    	generate Validate methods for records and enums
+//	  -verify
+//	    	check that the generated files are up to date instead of writing them, printing a diff of any that aren't
+//	  -watch
+//	    	regenerate the code whenever the schema files change
 //
 // By default, a type is generated for each Avro definition
 // in the schema. Some additional metadata fields are
 // recognized:
 //
+// Avro types with a logical type are represented by the Go type
+// given for that logical type with the -logicaltype flag; for example
+// -logicaltype uuid=github.com/google/uuid.UUID. The program using
+// the generated code must register a converter for the type with
+// avro.RegisterLogicalType. The timestamp-millis and timestamp-micros
+// logical types are represented as time.Time by default.
+//
+// Logical types can also be configured in a JSON file specified
+// with the -config flag, which can also ask for converters to be
+// generated. For example:
+//
+//	{
+//		"logicalTypes": {
+//			"uuid": {
+//				"goType": "github.com/gofrs/uuid.UUID",
+//				"converter": "text"
+//			},
+//			"decimal": {
+//				"goType": "github.com/shopspring/decimal.Decimal"
+//			}
+//		}
+//	}
+//
+// With "converter": "text", the generated code includes a converter
+// for the logical type that uses the MarshalText and UnmarshalText
+// methods of the Go type to convert to and from an Avro string, and
+// registers it with avro.RegisterLogicalType, so the program doesn't
+// need to. Types given with the -logicaltype flag take precedence
+// over the configuration file.
+//
+// The -header flag names a file holding a Go text/template for a
+// comment, such as a license header, to add to the top of every
+// generated Go file. It's executed with a value with fields Package,
+// File (the name of the generated file) and Year (the current year),
+// and lines that aren't already comments are turned into comments.
+// The -buildtags flag adds a build constraint, such as
+// "linux && !race", to every generated Go file. Both can also be
+// given in the configuration file as "header", a file name relative
+// to the configuration file, and "buildTags"; the flags take
+// precedence.
+//
+// The name of the generated package is given by the -p flag, or by
+// $GOPACKAGE when avrogo is run by go generate. When neither is set,
+// it's inferred from the output directory: if the directory already
+// holds Go files, their package name is used; otherwise the name is
+// the last element of the directory's import path, as determined
+// from the go.mod file of the module that contains it. The output
+// directory is created if it doesn't exist. With -d -, the generated
+// code is written to standard output instead, which is only possible
+// when there's just one file to write.
+//
+// By default, all the types are generated into a single package.
+// The -map flag puts the types in an Avro namespace into their own
+// package instead; for example, with -map com.acme.billing=billing,
+// the types in the com.acme.billing namespace are written to the
+// billing directory inside the output directory, with package name
+// billing. Types in namespaces nested inside a mapped namespace go
+// into nested packages, so com.acme.billing.invoice types are written
+// to billing/invoice. The import paths of the packages are determined
+// from the go.mod file of the module holding the output directory.
+// The mapped packages mustn't refer to one another cyclically.
+//
+// With the -binary flag, each generated record has MarshalBinary and
+// UnmarshalBinary methods that encode and decode Avro binary data
+// using the record's own schema without using reflection. They're
+// not generated for records that refer to types from other
+// packages or that use the -logicaltype flag.
+//
+// With the -selfcontained flag, the generated code doesn't import
+// any package from the avro module, so it can be copied into programs
+// that don't otherwise depend on it. Records have MarshalBinary and
+// UnmarshalBinary methods as with -binary, but no AvroRecord method,
+// so they can't be used with avro.Marshal or avro.Unmarshal, and the
+// code that the methods use is written to avro_support_gen.go. It's
+// an error if the methods can't be generated for a record, and
+// -selfcontained can't be used with -messages or with converters
+// generated by the -config flag.
+//
+// The -noreflect flag generates code for constrained runtimes, such
+// as TinyGo and WebAssembly targets, and for hot paths where even
+// the cached reflection-based programs used by avro.Marshal are too
+// slow. It implies -selfcontained, and it's an error if any record
+// field would be represented by interface{}, so unions other than
+// those of null and one other type can't be used. Each record R also
+// has an AppendBinary method that appends its encoding to a slice,
+// so that buffers can be reused.
+//
+// With the -split flag, each generated type is written to its own file
+// named after the type (for example, a record R is written to
+// r_gen.go) instead of one file per schema file, and the file
+// avro_gen.go holds the package documentation.
+//
+// With the -constructors flag, each generated record type R also has
+// a NewR function and a SetDefaults method that set its fields to the
+// default values specified in the schema.
+//
+// With the -validate flag, each generated record and enum type also
+// has a Validate method that returns an error if the value can't be
+// encoded with the schema; for example, if an enum value is out of
+// range or a union field holds a type that's not in the union.
+//
+// With the -builders flag, each generated record type R also has an
+// RBuilder type, made with NewRBuilder, for building values in tests.
+// It has a WithF method to set each field F, a Build method to return
+// the value, and a Random method that sets every field to a random
+// value that fits the schema: enum values are valid symbols, fixed
+// values have the right size, and union fields hold one of the
+// union's members. Random values of recursive types are always finite.
+//
+// With the -interfaces flag, each generated record type R also has
+// an interface type RInterface, which R implements, with a GetF
+// method returning the value of each field F and an AvroSchema
+// method returning the record's schema, so that code that reads
+// records can accept other implementations, such as mocks in tests.
+// With the -getters flag too, the interface includes its GetF
+// methods for optional fields, which also report whether the field
+// is set. No interface is generated for a record if the method names
+// would clash with its field names.
+//
+// With the -bytehelpers flag, each generated fixed type also has
+// a Hex method that returns its contents in hexadecimal, a Bytes
+// method that returns them in a new slice, and a SetBytes method
+// that sets them from a slice, returning an error if the slice
+// has the wrong length. Each record with a bytes field F also has
+// an FHex method that returns the field in hexadecimal.
+//
+// With the -clone flag, each generated record type also has a Clone
+// method that returns a deep copy of the record, sharing no slices,
+// maps or pointers with the original, and an Equal method that
+// reports whether two records hold the same values, following
+// pointers and comparing union members by their dynamic types.
+// Nil and empty slices and maps are considered equal. Values of
+// external types and of Go types specified for logical types are
+// copied by assignment and compared with reflect.DeepEqual.
+//
+// With the -fingerprint flag, each generated record type R also has
+// constants RSchemaCanonical, holding the Parsing Canonical Form of its
+// schema, and RSchemaFingerprint, holding the CRC-64-AVRO fingerprint
+// of that, as used by Avro single-object encoding.
+//
+// With the -messages flag, each generated record type R also has
+// functions EncodeR and DecodeR that encode and decode messages holding
+// the ID of their schema, such as Kafka messages, with avro.SingleEncoder
+// and avro.SingleDecoder, saving the type assertions and boilerplate
+// otherwise needed by producers and consumers.
+//
+// The -tags flag specifies struct tags to generate for every record
+// field, such as -tags json,bson:snake,db:snake. The value of each tag
+// is the Avro field name, or the name converted to snake_case with the
+// "snake" style. With the -omitempty flag, the tag values also include
+// omitempty. When the json tag doesn't hold the Avro field name, an
+// avro tag is generated too, so the Avro field names are unchanged.
+//
+// The -template flag, which can be repeated, names a Go text/template
+// file to execute for each output package, for generating other
+// artifacts from the schemas such as SQL DDL or API documentation.
+// The output is written to a file in the package directory named after
+// the template file without its .tmpl extension, so ddl.sql.tmpl
+// produces ddl.sql. The template is executed with a value that has
+// these fields:
+//
+//	Package  the name of the Go package
+//	Records  the generated records, each with Name, GoName, Doc,
+//	         Schema and Fields
+//	Enums    the generated enums, each with Name, GoName, Doc
+//	         and Symbols
+//
+// Each field has Name, GoName, Doc, Type (a primitive type name, the
+// name of a named type, or "array", "map" or "union"), LogicalType,
+// GoType, Nullable, HasDefault and Default. The functions lower,
+// upper, snake (which converts to snake_case), join and json are
+// also available.
+//
+// By default, a union of null and another type T is represented as *T.
+// With -nullable sql, the nullable types from database/sql, such as
+// sql.NullString, are used instead when there's one that can hold T.
+// With -nullable optional, the Optional types such as
+// avrotypegen.OptionalString (also available as avro.OptionalString)
+// are used in the same way; they can't be used with -selfcontained.
+// With the -getters flag, a record with such a field F also has
+// methods GetF, which returns the value of F and whether it's set,
+// and GetFOr, which returns the value of F or a default if it's not set.
+//
+// Other unions are represented as interface{}. With the -unionaccessors
+// flag, a record with such a field F also has typed accessor methods
+// for each member of the union: for example FAsString, which returns
+// the string held in F and whether it holds one, and SetFString for a
+// "string" member, and FIsNull and SetFNull for a "null" member.
+//
+// With the -verify flag, avrogo doesn't write any files. Instead it
+// checks that the files it would write already exist with the same
+// contents, printing a diff for each one that doesn't and exiting
+// with a non-zero status if there are any, so that a CI job can
+// detect checked-in generated code that's out of date with respect
+// to its schemas. Files that are no longer generated at all aren't
+// detected.
+//
+// The header of each generated file is stamped with the CRC-64-AVRO
+// fingerprint of the Parsing Canonical Form of the schema of each
+// type defined in it, for example:
+//
+//	// Code generated by avrogen. DO NOT EDIT.
+//	//
+//	// Schema fingerprints:
+//	//	R 0x36d17db6d03ae7ad
+//
+// With -verify, the definitions whose fingerprints differ from the
+// ones stamped in the existing files are reported before the diff,
+// which distinguishes schema changes from changes in the generated
+// code only. The stamped fingerprints can also be compared with those
+// of the schemas in use, such as those in a schema registry; to do that
+// at run time, use the -fingerprint flag and compare the generated
+// fingerprint constants.
+//
+// With the -watch flag, avrogo generates the code and then keeps
+// running, regenerating it whenever one of the schema files changes,
+// for a quick development loop while schemas are being changed
+// alongside the Go code that uses them. Directories and patterns
+// are expanded again each time, so files added to or removed from
+// them are noticed. Files imported by IDL files aren't watched
+// unless they're named too. Errors are printed and the watch
+// carries on.
+//
 // See the README for a full description of how schemas
 // map to generated Go types: https://github.com/heetch/avro/blob/master/README.md
 package main
@@ -32,6 +329,7 @@ import (
 	"go/format"
 	"io/ioutil"
 	"os"
+	"path"
 	"path/filepath"
 	"sort"
 	"strings"
@@ -39,6 +337,8 @@ import (
 	"github.com/rogpeppe/gogen-avro/v7/parser"
 	"github.com/rogpeppe/gogen-avro/v7/resolver"
 	"github.com/rogpeppe/gogen-avro/v7/schema"
+
+	"github.com/heetch/avro/internal/avdl"
 )
 
 // Generate the tests.
@@ -46,11 +346,47 @@ import (
 //go:generate go run ./generatetestcode.go
 
 var (
-	dirFlag  = flag.String("d", ".", "directory to write Go files to")
-	pkgFlag  = flag.String("p", os.Getenv("GOPACKAGE"), "package name (defaults to $GOPACKAGE)")
+	dirFlag  = flag.String("d", ".", "directory to write Go files to, or - to write to standard output")
+	pkgFlag  = flag.String("p", os.Getenv("GOPACKAGE"), "package name (defaults to $GOPACKAGE, or is inferred from the output directory)")
 	testFlag = flag.Bool("t", strings.HasSuffix(os.Getenv("GOFILE"), "_test.go"), "generated files will have _test.go suffix (defaults to true if $GOFILE is a test file)")
+
+	binaryFlag   = flag.Bool("binary", false, "generate MarshalBinary and UnmarshalBinary methods for records")
+	bytesFlag    = flag.Bool("bytehelpers", false, "generate Hex, Bytes and SetBytes methods for fixed types and FHex methods for bytes fields")
+	buildersFlag = flag.Bool("builders", false, "generate builder types with random value generators for records")
+	cloneFlag    = flag.Bool("clone", false, "generate Clone and Equal methods for records")
+	fpFlag       = flag.Bool("fingerprint", false, "generate constants holding the canonical schema and fingerprint of records")
+	ctorFlag     = flag.Bool("constructors", false, "generate NewT functions and SetDefaults methods for records")
+	msgFlag      = flag.Bool("messages", false, "generate EncodeR and DecodeR functions for records using avro.SingleEncoder and avro.SingleDecoder")
+	ifaceFlag    = flag.Bool("interfaces", false, "generate an RInterface type with GetF and AvroSchema methods for each record R")
+	gettersFlag  = flag.Bool("getters", false, "generate GetF and GetFOr methods for optional record fields")
+	unionFlag    = flag.Bool("unionaccessors", false, "generate typed accessor methods for record fields holding unions represented as interface{}")
+	splitFlag    = flag.Bool("split", false, "write each generated type to its own file")
+	validateFlag = flag.Bool("validate", false, "generate Validate methods for records and enums")
+	rpcFlag      = flag.Bool("rpc", false, "generate request types and interfaces for the messages in Avro protocols")
+	noReflFlag   = flag.Bool("noreflect", false, "generate code that uses neither reflection nor interface{} values, with AppendBinary methods for records (implies -selfcontained)")
+	selfFlag     = flag.Bool("selfcontained", false, "generate code that doesn't depend on the avro module (implies -binary)")
+	verifyFlag   = flag.Bool("verify", false, "check that the generated files are up to date instead of writing them, printing a diff of any that aren't")
+	watchFlag    = flag.Bool("watch", false, "regenerate the code whenever the schema files change")
+	omitFlag     = flag.Bool("omitempty", false, "add omitempty to the struct tags specified with -tags")
+	headerFlag   = flag.String("header", "", "file holding a template for a comment added to the top of each generated Go file, such as a license header")
+	buildFlag    = flag.String("buildtags", "", "build constraint expression added to each generated Go file, such as \"linux && !race\"")
+	configFlag   = flag.String("config", "", "JSON file configuring the Go types used for logical types")
+	nullableFlag = flag.String("nullable", "pointer", `representation of unions of null and another type: "pointer", "sql" or "optional"`)
+
+	logicalTypes = make(logicalTypeFlag)
+	namespaceMap = make(namespaceMapFlag)
+	structTags   structTagsFlag
+
+	templateFiles templateFilesFlag
 )
 
+func init() {
+	flag.Var(logicalTypes, "logicaltype", "map from logical type to Go type in the form name=type (can be repeated)")
+	flag.Var(&structTags, "tags", `struct tags to generate for record fields, as a comma-separated list of key[:style] where style is "avro" or "snake" (can be repeated)`)
+	flag.Var(&templateFiles, "template", "template file to execute for each output package, writing a file named after it without the .tmpl extension (can be repeated)")
+	flag.Var(namespaceMap, "map", "map from Avro namespace to Go package directory in the form namespace=dir (can be repeated)")
+}
+
 var flag = stdflag.NewFlagSet("", stdflag.ContinueOnError)
 
 func main() {
@@ -59,7 +395,7 @@ func main() {
 
 func main1() int {
 	flag.Usage = func() {
-		fmt.Fprintf(os.Stderr, "usage: avrogo [flags] schema-file...\n")
+		fmt.Fprintf(os.Stderr, "usage: avrogo [flags] schema-file|dir|pattern...\n")
 		flag.PrintDefaults()
 	}
 	if flag.Parse(os.Args[1:]) != nil {
@@ -71,33 +407,220 @@ func main1() int {
 		return 2
 	}
 	if *pkgFlag == "" {
-		fmt.Fprintf(os.Stderr, "avrogo: -p flag must specify a package name or set $GOPACKAGE\n")
+		name, err := packageName(outputDir())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "avrogo: cannot infer package name (use the -p flag or set $GOPACKAGE): %v\n", err)
+			return 1
+		}
+		*pkgFlag = name
+	}
+	switch *nullableFlag {
+	case "pointer", "sql", "optional":
+	default:
+		fmt.Fprintf(os.Stderr, "avrogo: -nullable flag must be \"pointer\", \"sql\" or \"optional\"\n")
+		return 2
+	}
+	if *configFlag != "" {
+		if err := readConfig(*configFlag); err != nil {
+			fmt.Fprintf(os.Stderr, "avrogo: %v\n", err)
+			return 1
+		}
+	}
+	if *headerFlag != "" {
+		if err := readHeader(*headerFlag); err != nil {
+			fmt.Fprintf(os.Stderr, "avrogo: %v\n", err)
+			return 1
+		}
+	}
+	if *buildFlag != "" {
+		if err := parseBuildTags(*buildFlag); err != nil {
+			fmt.Fprintf(os.Stderr, "avrogo: %v\n", err)
+			return 2
+		}
+	}
+	if *noReflFlag {
+		// Reflection-free code is always self-contained.
+		*selfFlag = true
+	}
+	if *selfFlag {
+		if *msgFlag {
+			fmt.Fprintf(os.Stderr, "avrogo: -selfcontained cannot be used with -messages\n")
+			return 2
+		}
+		if len(textConverters) > 0 {
+			fmt.Fprintf(os.Stderr, "avrogo: -selfcontained cannot be used with generated converters\n")
+			return 2
+		}
+		if *nullableFlag == "optional" {
+			fmt.Fprintf(os.Stderr, "avrogo: -selfcontained cannot be used with -nullable optional\n")
+			return 2
+		}
+	}
+	if *verifyFlag {
+		if *watchFlag || *dirFlag == "-" {
+			fmt.Fprintf(os.Stderr, "avrogo: -verify cannot be used with -watch or -d -\n")
+			return 2
+		}
+	}
+	if *watchFlag {
+		if err := watch(files); err != nil {
+			fmt.Fprintf(os.Stderr, "avrogo: %v\n", err)
+		}
+		return 1
+	}
+	files, err := schemaFiles(files)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "avrogo: %v\n", err)
+		return 1
+	}
+	if len(files) == 0 {
+		fmt.Fprintf(os.Stderr, "avrogo: no schema files found\n")
 		return 1
 	}
 	if err := generateFiles(files); err != nil {
 		fmt.Fprintf(os.Stderr, "avrogo: %v\n", err)
 		return 1
 	}
+	if len(staleFiles) > 0 {
+		fmt.Fprintf(os.Stderr, "avrogo: generated files are out of date: %s\n", strings.Join(staleFiles, ", "))
+		return 1
+	}
 	return 0
 }
 
 func generateFiles(files []string) error {
-	ns, fileDefinitions, err := parseFiles(files)
+	stdoutFile = ""
+	ns, fileDefinitions, fileProtocols, err := parseFiles(files)
+	if err != nil {
+		return err
+	}
+	extTypes, err := externalTypeMap(ns)
+	if err != nil {
+		return err
+	}
+	if err := checkRecursiveRecords(ns, extTypes); err != nil {
+		return err
+	}
+	pkgs, err := outputPackages(ns, extTypes)
 	if err != nil {
 		return err
 	}
+	for _, pkg := range pkgs {
+		if err := writeConverters(pkg); err != nil {
+			return err
+		}
+		if err := writeSupport(pkg); err != nil {
+			return err
+		}
+		if err := executeUserTemplates(pkg, ns); err != nil {
+			return err
+		}
+	}
+	if *splitFlag {
+		for _, pkg := range pkgs {
+			if err := generateSplitFiles(pkg, ns, fileDefinitions, fileProtocols); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
 	outfiles, err := outputPaths(files, *testFlag)
 	if err != nil {
 		return err
 	}
-	for i, f := range files {
-		if err := generateFile(f, outfiles[f], ns, fileDefinitions[i]); err != nil {
-			return fmt.Errorf("cannot generate code for %s: %v", f, err)
+	for _, pkg := range pkgs {
+		for i, f := range files {
+			if err := generateFile(pkg, outfiles[f], ns, fileDefinitions[i], fileProtocols[i]); err != nil {
+				return fmt.Errorf("cannot generate code for %s: %v", f, err)
+			}
 		}
 	}
 	return nil
 }
 
+// generateSplitFiles writes each type generated in pkg to a file named
+// after the type, the interface for each protocol to a file named after
+// the protocol, and a file holding the package documentation.
+func generateSplitFiles(pkg *outputPackage, ns *parser.Namespace, fileDefinitions [][]schema.QualifiedName, fileProtocols []*rpcProtocol) error {
+	suffix := "_gen.go"
+	if *testFlag {
+		suffix = "_gen_test.go"
+	}
+	outFiles := make(map[string]schema.QualifiedName)
+	for _, definitions := range fileDefinitions {
+		for _, name := range definitions {
+			if _, ok := pkg.extTypes[name]; ok {
+				continue
+			}
+			outFile := strings.ToLower(defName(ns.Definitions[name])) + suffix
+			if name0, ok := outFiles[outFile]; ok {
+				return fmt.Errorf("cannot write both %s and %s to %s", name0, name, outFile)
+			}
+			outFiles[outFile] = name
+			if err := generateFile(pkg, outFile, ns, []schema.QualifiedName{name}, nil); err != nil {
+				return fmt.Errorf("cannot generate code for %s: %v", name, err)
+			}
+		}
+	}
+	for _, proto := range fileProtocols {
+		if proto == nil || namespaceMap.packageDir(proto.namespace) != pkg.dir {
+			continue
+		}
+		outFile := strings.ToLower(proto.name) + "_protocol" + suffix
+		if name0, ok := outFiles[outFile]; ok {
+			return fmt.Errorf("cannot write both %s and protocol %s to %s", name0, proto.name, outFile)
+		}
+		outFiles[outFile] = schema.QualifiedName{}
+		if err := generateFile(pkg, outFile, ns, nil, proto); err != nil {
+			return fmt.Errorf("cannot generate code for protocol %s: %v", proto.name, err)
+		}
+	}
+	docFile := "avro" + suffix
+	if name, ok := outFiles[docFile]; ok {
+		return fmt.Errorf("cannot write both %s and package documentation to %s", name, docFile)
+	}
+	var buf bytes.Buffer
+	if err := docTemplate.Execute(&buf, pkg.name); err != nil {
+		return fmt.Errorf("cannot execute doc template: %v", err)
+	}
+	return writeGoFile(pkg, docFile, buf.Bytes())
+}
+
+// writeConverters writes the text converters configured
+// with the -config flag to a file in pkg, if there are any.
+func writeConverters(pkg *outputPackage) error {
+	var buf bytes.Buffer
+	if err := generateConverters(&buf, pkg.name); err != nil {
+		return fmt.Errorf("cannot generate converters: %v", err)
+	}
+	if buf.Len() == 0 {
+		return nil
+	}
+	outFile := "avro_converters_gen.go"
+	if *testFlag {
+		outFile = "avro_converters_gen_test.go"
+	}
+	return writeGoFile(pkg, outFile, buf.Bytes())
+}
+
+// writeSupport writes the code used by self-contained
+// generated code to a file in pkg if the -selfcontained
+// flag is set.
+func writeSupport(pkg *outputPackage) error {
+	if !*selfFlag {
+		return nil
+	}
+	var buf bytes.Buffer
+	if err := generateSupport(&buf, pkg.name); err != nil {
+		return fmt.Errorf("cannot generate support code: %v", err)
+	}
+	outFile := "avro_support_gen.go"
+	if *testFlag {
+		outFile = "avro_support_gen_test.go"
+	}
+	return writeGoFile(pkg, outFile, buf.Bytes())
+}
+
 func outputPaths(files []string, testFile bool) (map[string]string, error) {
 	fileset := make(map[string]string)
 	for _, file := range files {
@@ -175,9 +698,32 @@ func baseN(name string, n int) (string, bool) {
 	return strings.Join(parts, "_"), ok
 }
 
-func generateFile(f, outFile string, ns *parser.Namespace, definitions []schema.QualifiedName) error {
+func generateFile(pkg *outputPackage, outFile string, ns *parser.Namespace, definitions []schema.QualifiedName, proto *rpcProtocol) error {
+	if proto != nil && namespaceMap.packageDir(proto.namespace) != pkg.dir {
+		// The protocol's interface belongs in another package.
+		proto = nil
+	}
 	var buf bytes.Buffer
-	if err := generate(&buf, *pkgFlag, ns, definitions); err != nil {
+	if err := generate(&buf, pkg.name, ns, pkg.extTypes, definitions, proto, generateOptions{
+		logicalTypes:   logicalTypes,
+		sqlNull:        *nullableFlag == "sql",
+		optionalNull:   *nullableFlag == "optional",
+		binary:         *binaryFlag || *selfFlag,
+		constructors:   *ctorFlag,
+		getters:        *gettersFlag,
+		unionAccessors: *unionFlag,
+		byteHelpers:    *bytesFlag,
+		interfaces:     *ifaceFlag,
+		validate:       *validateFlag,
+		builders:       *buildersFlag,
+		fingerprint:    *fpFlag,
+		messages:       *msgFlag,
+		clone:          *cloneFlag,
+		tags:           structTags,
+		omitEmpty:      *omitFlag,
+		selfContained:  *selfFlag,
+		noReflect:      *noReflFlag,
+	}); err != nil {
 		return err
 	}
 	if buf.Len() == 0 {
@@ -185,16 +731,79 @@ func generateFile(f, outFile string, ns *parser.Namespace, definitions []schema.
 		// avsc file are external).
 		return nil
 	}
-	resultData, err := format.Source(buf.Bytes())
+	return writeGoFile(pkg, outFile, buf.Bytes())
+}
+
+// writeGoFile formats the Go source in src and writes it to
+// outFile within the directory of pkg.
+func writeGoFile(pkg *outputPackage, outFile string, src []byte) error {
+	src, err := addHeader(pkg, outFile, src)
+	if err != nil {
+		return err
+	}
+	resultData, err := format.Source(src)
 	if err != nil {
-		fmt.Printf("%s\n", buf.Bytes())
+		fmt.Printf("%s\n", src)
 		return fmt.Errorf("cannot format source: %v", err)
 	}
-	if err := os.MkdirAll(*dirFlag, 0777); err != nil {
+	return writeOutputFile(pkg, outFile, resultData)
+}
+
+// stdoutFile holds the name of the file written to
+// standard output with -d -, if there is one.
+var stdoutFile string
+
+// outputDir returns the directory that generated code is written
+// to. When it's written to standard output, that's the current
+// directory, which is used to infer the package name and to find
+// the external types used by the schemas.
+func outputDir() string {
+	if *dirFlag == "-" {
+		return "."
+	}
+	return *dirFlag
+}
+
+// staleFiles holds the files found to be out of date with -verify.
+var staleFiles []string
+
+// writeOutputFile writes data to outFile within the directory of
+// pkg, or to standard output with -d -, in which case it's an error
+// if more than one file is written. With -verify, it compares data
+// with the existing contents of the file instead, printing any
+// changed schema fingerprints and a diff and adding the file to
+// staleFiles if they're different.
+func writeOutputFile(pkg *outputPackage, outFile string, data []byte) error {
+	if *dirFlag == "-" {
+		outFile = path.Join(pkg.dir, outFile)
+		if stdoutFile != "" {
+			return fmt.Errorf("cannot write both %s and %s to standard output", stdoutFile, outFile)
+		}
+		stdoutFile = outFile
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+	dir := filepath.Join(*dirFlag, filepath.FromSlash(pkg.dir))
+	if *verifyFlag {
+		outFile = filepath.Join(dir, outFile)
+		old, err := ioutil.ReadFile(outFile)
+		if err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		if diff := lineDiff(outFile, string(old), string(data)); diff != "" {
+			for _, change := range fingerprintChanges(old, data) {
+				fmt.Printf("%s: %s\n", outFile, change)
+			}
+			fmt.Print(diff)
+			staleFiles = append(staleFiles, outFile)
+		}
+		return nil
+	}
+	if err := os.MkdirAll(dir, 0777); err != nil {
 		return fmt.Errorf("cannot create output directory: %v", err)
 	}
-	outFile = filepath.Join(*dirFlag, outFile)
-	if err := ioutil.WriteFile(outFile, resultData, 0666); err != nil {
+	outFile = filepath.Join(dir, outFile)
+	if err := ioutil.WriteFile(outFile, data, 0666); err != nil {
 		return err
 	}
 	return nil
@@ -203,33 +812,77 @@ func generateFile(f, outFile string, ns *parser.Namespace, definitions []schema.
 // parseFiles parses the Avro schemas in the given files and returns
 // a namespace containing all of the definitions in all of the files
 // and a slice with an element for each file holding a slice
-// of all the definitions within that file.
-func parseFiles(files []string) (*parser.Namespace, [][]schema.QualifiedName, error) {
+// of all the definitions within that file. With the -rpc flag,
+// it also returns a slice holding the protocol, if any, defined
+// in each file.
+//
+// Files with a .avdl extension are parsed as Avro IDL and files
+// with a .avpr extension as JSON Avro protocols. Definitions
+// imported by an IDL file are treated as if they were defined in
+// that file unless the imported file is also named on the command line.
+func parseFiles(files []string) (*parser.Namespace, [][]schema.QualifiedName, []*rpcProtocol, error) {
 	var fileDefinitions [][]schema.QualifiedName
+	fileProtocols := make([]*rpcProtocol, len(files))
 	ns := parser.NewNamespace(false)
+	imported := make(map[string]bool)
 	for _, f := range files {
-		data, err := ioutil.ReadFile(f)
+		path, err := filepath.Abs(f)
 		if err != nil {
-			return nil, nil, err
+			return nil, nil, nil, err
 		}
+		imported[path] = true
+	}
+	for i, f := range files {
 		var definitions []schema.QualifiedName
 		// Make a new namespace just for this file only
 		// so we can tell which names are defined in this
 		// file alone.
 		singleNS := parser.NewNamespace(false)
-		avroType, err := singleNS.TypeForSchema(data)
-		if err != nil {
-			return nil, nil, fmt.Errorf("invalid schema in %s: %v", f, err)
+		var proto *avdl.Protocol
+		switch filepath.Ext(f) {
+		case ".avdl":
+			p, err := parseIDLFile(f, []*parser.Namespace{singleNS, ns}, imported)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			proto = p
+		case ".avpr":
+			p, err := parseProtocolFile(f, []*parser.Namespace{singleNS, ns})
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			proto = p
+		default:
+			data, err := ioutil.ReadFile(f)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			avroType, err := singleNS.TypeForSchema(data)
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("invalid schema in %s: %v", f, err)
+			}
+			if _, ok := avroType.(*schema.Reference); !ok {
+				// The schema doesn't have a top-level name.
+				// TODO how should we cope with a schema that's not
+				// a definition? In that case we don't have
+				// a name for the type, and we may not be able to define
+				// methods on it because it might be a union type which
+				// is represented by an interface type in Go.
+				// See https://github.com/heetch/avro/issues/13
+				return nil, nil, nil, fmt.Errorf("cannot generate code for schema %q which hasn't got a name (%T)", f, avroType)
+			}
+			// Parse the schema again but use the global namespace
+			// this time so all the schemas can share the same definitions.
+			if _, err := ns.TypeForSchema(data); err != nil {
+				return nil, nil, nil, fmt.Errorf("cannot parse schema in %s: %v", f, err)
+			}
 		}
-		if _, ok := avroType.(*schema.Reference); !ok {
-			// The schema doesn't have a top-level name.
-			// TODO how should we cope with a schema that's not
-			// a definition? In that case we don't have
-			// a name for the type, and we may not be able to define
-			// methods on it because it might be a union type which
-			// is represented by an interface type in Go.
-			// See https://github.com/heetch/avro/issues/13
-			return nil, nil, fmt.Errorf("cannot generate code for schema %q which hasn't got a name (%T)", f, avroType)
+		if *rpcFlag && proto != nil && len(proto.Messages) > 0 {
+			p, err := addProtocolMessages(f, proto, singleNS, ns)
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			fileProtocols[i] = p
 		}
 		for name, def := range singleNS.Definitions {
 			if name != def.AvroName() {
@@ -245,11 +898,6 @@ func parseFiles(files []string) (*parser.Namespace, [][]schema.QualifiedName, er
 			return definitions[i].String() < definitions[j].String()
 		})
 		fileDefinitions = append(fileDefinitions, definitions)
-		// Parse the schema again but use the global namespace
-		// this time so all the schemas can share the same definitions.
-		if _, err := ns.TypeForSchema(data); err != nil {
-			return nil, nil, fmt.Errorf("cannot parse schema in %s: %v", f, err)
-		}
 	}
 	// Now we've accumulated all the available types,
 	// resolve the names with respect to the complete
@@ -258,8 +906,8 @@ func parseFiles(files []string) (*parser.Namespace, [][]schema.QualifiedName, er
 		if err := resolver.ResolveDefinition(def, ns.Definitions); err != nil {
 			// TODO find out which file(s) the definition came from
 			// and include that file name in the error.
-			return nil, nil, fmt.Errorf("cannot resolve reference %q: %v", name, err)
+			return nil, nil, nil, fmt.Errorf("cannot resolve reference %q: %v", name, err)
 		}
 	}
-	return ns, fileDefinitions, nil
+	return ns, fileDefinitions, fileProtocols, nil
 }
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/rogpeppe/gogen-avro/v7/schema"
+)
+
+// MessageFuncs returns the source of the EncodeR and DecodeR
+// functions for the record R, which encode and decode messages
+// that hold the ID of their schema, such as Kafka messages, or the
+// empty string if they aren't enabled.
+func (gc *generateContext) MessageFuncs(t *schema.RecordDefinition) (string, error) {
+	if !gc.opts.messages {
+		return "", nil
+	}
+	return fmt.Sprintf(`
+// Encode%[1]s encodes x with enc, which adds the ID of
+// the schema of %[1]s to the message, for example to produce
+// a Kafka message.
+func Encode%[1]s(ctx %[2]s.Context, enc *%[3]s.SingleEncoder, x %[1]s) ([]byte, error) {
+	return enc.Marshal(ctx, x)
+}
+
+// Decode%[1]s decodes msg, which holds the ID of the
+// schema it was encoded with, as a %[1]s using dec, for example
+// to consume a Kafka message. The writer's schema must be
+// compatible with the schema of %[1]s.
+func Decode%[1]s(ctx %[2]s.Context, dec *%[3]s.SingleDecoder, msg []byte) (%[1]s, error) {
+	var x %[1]s
+	if _, err := dec.Unmarshal(ctx, msg, &x); err != nil {
+		return %[1]s{}, err
+	}
+	return x, nil
+}
+`, defName(t), gc.addImport("context"), gc.addImport("github.com/heetch/avro")), nil
+}
@@ -0,0 +1,187 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/rogpeppe/go-internal/modfile"
+	"github.com/rogpeppe/gogen-avro/v7/parser"
+	"github.com/rogpeppe/gogen-avro/v7/schema"
+)
+
+// namespaceMapFlag implements the -map flag. It maps from
+// Avro namespace to the directory, relative to the output
+// directory, of the Go package that holds the types defined
+// in that namespace.
+type namespaceMapFlag map[string]string
+
+func (f namespaceMapFlag) String() string {
+	var names []string
+	for name := range f {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var buf strings.Builder
+	for i, name := range names {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		buf.WriteString(name + "=" + f[name])
+	}
+	return buf.String()
+}
+
+// Set implements flag.Value.Set by parsing a value of
+// the form namespace=dir, where dir is a /-separated path
+// relative to the output directory, such as billing or
+// acme/billing. The last element of the path is used as
+// the package name.
+func (f namespaceMapFlag) Set(s string) error {
+	i := strings.Index(s, "=")
+	if i <= 0 {
+		return fmt.Errorf("namespace mapping %q is not in the form namespace=dir", s)
+	}
+	ns, dir := s[:i], s[i+1:]
+	if dir == "" || path.IsAbs(dir) || path.Clean(dir) != dir || dir == ".." || strings.HasPrefix(dir, "../") {
+		return fmt.Errorf("invalid package directory %q for namespace %q", dir, ns)
+	}
+	f[ns] = dir
+	return nil
+}
+
+// packageDir returns the directory of the Go package holding the
+// types defined in the given Avro namespace, relative to the output
+// directory. A namespace inside a mapped namespace maps to a
+// subdirectory, so if com.example maps to example, com.example.foo.bar
+// maps to example/foo/bar. Namespaces that aren't mapped at all
+// map to the output directory itself.
+func (f namespaceMapFlag) packageDir(namespace string) string {
+	for ns := namespace; ns != ""; {
+		if dir, ok := f[ns]; ok {
+			rest := strings.TrimPrefix(strings.TrimPrefix(namespace, ns), ".")
+			if rest == "" {
+				return dir
+			}
+			return path.Join(dir, strings.ToLower(strings.Replace(rest, ".", "/", -1)))
+		}
+		i := strings.LastIndex(ns, ".")
+		if i < 0 {
+			break
+		}
+		ns = ns[:i]
+	}
+	return "."
+}
+
+// outputPackage holds a Go package that generated code
+// is written to.
+type outputPackage struct {
+	// name holds the Go package name.
+	name string
+
+	// dir holds the directory of the package relative
+	// to the output directory.
+	dir string
+
+	// extTypes maps each definition that isn't generated
+	// in this package to the Go type that represents it.
+	extTypes map[schema.QualifiedName]goType
+}
+
+// outputPackages returns the Go packages that the definitions
+// in ns are generated into, as determined by the -map flag.
+// The extTypes map holds the definitions that refer to existing
+// Go types and so aren't generated at all.
+func outputPackages(ns *parser.Namespace, extTypes map[schema.QualifiedName]goType) ([]*outputPackage, error) {
+	if len(namespaceMap) == 0 {
+		return []*outputPackage{{
+			name:     *pkgFlag,
+			dir:      ".",
+			extTypes: extTypes,
+		}}, nil
+	}
+	// Note that the definitions include aliases as well as
+	// primary names, so references through an alias
+	// refer to the right package too.
+	defDirs := make(map[schema.QualifiedName]string)
+	dirSet := make(map[string]bool)
+	for name, def := range ns.Definitions {
+		if _, ok := extTypes[name]; ok {
+			continue
+		}
+		dir := namespaceMap.packageDir(def.AvroName().Namespace)
+		defDirs[name] = dir
+		dirSet[dir] = true
+	}
+	rootPath, err := dirImportPath(outputDir())
+	if err != nil {
+		return nil, err
+	}
+	var dirs []string
+	for dir := range dirSet {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+	pkgs := make([]*outputPackage, 0, len(dirs))
+	for _, dir := range dirs {
+		pkg := &outputPackage{
+			name:     path.Base(dir),
+			dir:      dir,
+			extTypes: make(map[schema.QualifiedName]goType),
+		}
+		if dir == "." {
+			pkg.name = *pkgFlag
+		}
+		for name, gt := range extTypes {
+			pkg.extTypes[name] = gt
+		}
+		for name, defDir := range defDirs {
+			if defDir != dir {
+				pkg.extTypes[name] = goType{
+					PkgPath: path.Join(rootPath, defDir),
+					Name:    defName(ns.Definitions[name]),
+				}
+			}
+		}
+		pkgs = append(pkgs, pkg)
+	}
+	return pkgs, nil
+}
+
+// dirImportPath returns the import path of the Go package in
+// the directory dir, which need not exist yet, by finding the
+// go.mod file of the module that contains it.
+func dirImportPath(dir string) (string, error) {
+	absDir, err := filepath.Abs(dir)
+	if err != nil {
+		return "", err
+	}
+	for modDir := absDir; ; {
+		modFile := filepath.Join(modDir, "go.mod")
+		data, err := ioutil.ReadFile(modFile)
+		if err == nil {
+			modPath := modfile.ModulePath(data)
+			if modPath == "" {
+				return "", fmt.Errorf("no module path found in %s", modFile)
+			}
+			rel, err := filepath.Rel(modDir, absDir)
+			if err != nil {
+				return "", err
+			}
+			return path.Join(modPath, filepath.ToSlash(rel)), nil
+		}
+		if !os.IsNotExist(err) {
+			return "", err
+		}
+		parent := filepath.Dir(modDir)
+		if parent == modDir {
+			return "", fmt.Errorf("cannot determine import path of %s: no go.mod file found", dir)
+		}
+		modDir = parent
+	}
+}
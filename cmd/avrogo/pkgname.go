@@ -0,0 +1,64 @@
+package main
+
+import (
+	"fmt"
+	"go/parser"
+	"go/token"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// majorVersionPat matches the final element of the import
+// path of a package at the root of a module with a major
+// version suffix, such as example.com/foo/v2.
+var majorVersionPat = regexp.MustCompile(`^v[0-9]+$`)
+
+// packageName returns the name of the Go package in the directory
+// dir, which need not exist yet. It's used when the package name
+// isn't given with the -p flag or $GOPACKAGE. If dir already holds
+// Go files, it's the name of their package; otherwise it's the last
+// element of the import path of dir, as determined from the go.mod
+// file of the module that contains it, ignoring any major version
+// suffix.
+func packageName(dir string) (string, error) {
+	infos, err := ioutil.ReadDir(dir)
+	if err != nil && !os.IsNotExist(err) {
+		return "", err
+	}
+	testName := ""
+	for _, info := range infos {
+		name := info.Name()
+		if info.IsDir() || !strings.HasSuffix(name, ".go") || strings.HasPrefix(name, "avro-introspect") {
+			continue
+		}
+		f, err := parser.ParseFile(token.NewFileSet(), filepath.Join(dir, name), nil, parser.PackageClauseOnly)
+		if err != nil {
+			return "", err
+		}
+		if !strings.HasSuffix(name, "_test.go") {
+			return f.Name.Name, nil
+		}
+		if testName == "" {
+			testName = strings.TrimSuffix(f.Name.Name, "_test")
+		}
+	}
+	if testName != "" {
+		return testName, nil
+	}
+	importPath, err := dirImportPath(dir)
+	if err != nil {
+		return "", err
+	}
+	for majorVersionPat.MatchString(path.Base(importPath)) && path.Dir(importPath) != "." {
+		importPath = path.Dir(importPath)
+	}
+	name := path.Base(importPath)
+	if !token.IsIdentifier(name) {
+		return "", fmt.Errorf("%q is not a valid package name", name)
+	}
+	return name, nil
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strings"
+
+	"github.com/rogpeppe/gogen-avro/v7/parser"
+	"github.com/rogpeppe/gogen-avro/v7/schema"
+
+	"github.com/heetch/avro/internal/avdl"
+)
+
+// rpcProtocol holds an Avro protocol for which
+// a Go interface is generated.
+type rpcProtocol struct {
+	// name holds the name of the protocol.
+	name string
+
+	// namespace holds the namespace of the protocol.
+	namespace string
+
+	// doc holds the protocol's documentation.
+	doc string
+
+	messages []rpcMessage
+}
+
+// rpcMessage holds a message in an Avro protocol.
+type rpcMessage struct {
+	// name holds the name of the message.
+	name string
+
+	// doc holds the message's documentation.
+	doc string
+
+	// request holds the name of the record
+	// that holds the message's parameters.
+	request schema.QualifiedName
+
+	// response holds the name of a record with a
+	// single field that holds the message's response,
+	// or the zero name if there's no response.
+	response schema.QualifiedName
+
+	// oneWay holds whether the message is one-way.
+	oneWay bool
+}
+
+// parseProtocolFile parses the Avro protocol in the JSON file f
+// and adds all the types it defines to each of the given namespaces.
+func parseProtocolFile(f string, namespaces []*parser.Namespace) (*avdl.Protocol, error) {
+	data, err := ioutil.ReadFile(f)
+	if err != nil {
+		return nil, err
+	}
+	var p struct {
+		Protocol  string                   `json:"protocol"`
+		Namespace string                   `json:"namespace"`
+		Doc       string                   `json:"doc"`
+		Types     []map[string]interface{} `json:"types"`
+		Messages  map[string]struct {
+			Doc      string        `json:"doc"`
+			Request  []interface{} `json:"request"`
+			Response interface{}   `json:"response"`
+			Errors   []interface{} `json:"errors"`
+			OneWay   bool          `json:"one-way"`
+		} `json:"messages"`
+	}
+	if err := json.Unmarshal(data, &p); err != nil {
+		return nil, fmt.Errorf("invalid protocol in %s: %v", f, err)
+	}
+	if p.Protocol == "" {
+		return nil, fmt.Errorf("invalid protocol in %s: no protocol name found", f)
+	}
+	proto := &avdl.Protocol{
+		Name:      p.Protocol,
+		Namespace: p.Namespace,
+		Doc:       p.Doc,
+		Types:     p.Types,
+	}
+	for _, t := range proto.Types {
+		name, _ := t["name"].(string)
+		if _, ok := t["namespace"]; !ok && proto.Namespace != "" && !strings.Contains(name, ".") {
+			// Types inherit the namespace of the protocol.
+			t["namespace"] = proto.Namespace
+		}
+	}
+	// JSON objects aren't ordered, so use the
+	// message names to make the order deterministic.
+	for name, m := range p.Messages {
+		if m.Response == nil {
+			return nil, fmt.Errorf("invalid protocol in %s: no response for message %q", f, name)
+		}
+		proto.Messages = append(proto.Messages, avdl.Message{
+			Name:     name,
+			Doc:      m.Doc,
+			Request:  m.Request,
+			Response: m.Response,
+			Errors:   m.Errors,
+			OneWay:   m.OneWay,
+		})
+	}
+	sort.Slice(proto.Messages, func(i, j int) bool {
+		return proto.Messages[i].Name < proto.Messages[j].Name
+	})
+	if err := addProtocolTypes(f, proto, namespaces); err != nil {
+		return nil, err
+	}
+	return proto, nil
+}
+
+// addProtocolTypes adds all the types defined in proto, which was
+// read from the file f, to each of the given namespaces.
+func addProtocolTypes(f string, proto *avdl.Protocol, namespaces []*parser.Namespace) error {
+	for _, t := range proto.Types {
+		if t["type"] == "error" {
+			// Error types are records as far as
+			// Go is concerned.
+			t["type"] = "record"
+		}
+		data, err := json.Marshal(t)
+		if err != nil {
+			return fmt.Errorf("cannot marshal schema for %v in %s: %v", t["name"], f, err)
+		}
+		if err := addSchema(f, data, namespaces); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// addProtocolMessages adds a record to ns and fileNS for each message
+// in proto that holds the message's parameters, and a record to ns only
+// that holds its response, and returns the resulting rpcProtocol.
+// The records are named after the protocol and the message; for
+// example, the parameters of the message get in protocol Foo are
+// held in the record FooGetRequest.
+func addProtocolMessages(f string, proto *avdl.Protocol, fileNS, ns *parser.Namespace) (*rpcProtocol, error) {
+	protoName, err := goName(proto.Name)
+	if err != nil {
+		return nil, fmt.Errorf("invalid protocol name in %s: %v", f, err)
+	}
+	p := &rpcProtocol{
+		name:      proto.Name,
+		namespace: proto.Namespace,
+		doc:       proto.Doc,
+	}
+	for _, m := range proto.Messages {
+		msgName, err := goName(m.Name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid message name in %s: %v", f, err)
+		}
+		msg := rpcMessage{
+			name:   m.Name,
+			doc:    m.Doc,
+			oneWay: m.OneWay,
+		}
+		request := map[string]interface{}{
+			"type":   "record",
+			"name":   protoName + msgName + "Request",
+			"fields": m.Request,
+		}
+		if proto.Namespace != "" {
+			request["namespace"] = proto.Namespace
+		}
+		if msg.request, err = addMessageRecord(f, request, fileNS, ns); err != nil {
+			return nil, err
+		}
+		if m.Response != "null" {
+			response := map[string]interface{}{
+				"type": "record",
+				"name": protoName + msgName + "Response",
+				"fields": []interface{}{
+					map[string]interface{}{
+						"name": "Response",
+						"type": m.Response,
+					},
+				},
+			}
+			if proto.Namespace != "" {
+				response["namespace"] = proto.Namespace
+			}
+			if msg.response, err = addMessageRecord(f, response, ns); err != nil {
+				return nil, err
+			}
+		}
+		p.messages = append(p.messages, msg)
+	}
+	return p, nil
+}
+
+// addMessageRecord adds the record schema r to all the given
+// namespaces and returns its name.
+func addMessageRecord(f string, r map[string]interface{}, namespaces ...*parser.Namespace) (schema.QualifiedName, error) {
+	data, err := json.Marshal(r)
+	if err != nil {
+		return schema.QualifiedName{}, fmt.Errorf("cannot marshal schema for %v in %s: %v", r["name"], f, err)
+	}
+	var name schema.QualifiedName
+	for _, ns := range namespaces {
+		t, err := ns.TypeForSchema(data)
+		if err != nil {
+			return schema.QualifiedName{}, fmt.Errorf("invalid message schema for %v in %s: %v", r["name"], f, err)
+		}
+		name = t.(*schema.Reference).TypeName
+	}
+	return name, nil
+}
+
+// RPCInterface returns the source of the Go interface for the protocol p,
+// which has a method for each message in the protocol.
+func (gc *generateContext) RPCInterface(ns *parser.Namespace, p *rpcProtocol) (string, error) {
+	name, err := goName(p.name)
+	if err != nil {
+		return "", err
+	}
+	ctx := gc.addImport("context")
+	var w strings.Builder
+	if p.doc != "" {
+		fprintf(&w, "\n%s\n", indent(trimAVDLDoc(p.doc), "// "))
+	} else {
+		fprintf(&w, "\n// %s is implemented by servers and clients\n// of the %s protocol.\n", name, p.name)
+	}
+	fprintf(&w, "type %s interface {\n", name)
+	for i, m := range p.messages {
+		if i > 0 {
+			w.WriteString("\n")
+		}
+		methodName, err := goName(m.name)
+		if err != nil {
+			return "", err
+		}
+		if m.doc != "" {
+			fprintf(&w, "%s\n", indent(trimAVDLDoc(m.doc), "// "))
+		}
+		if m.oneWay {
+			if m.doc != "" {
+				w.WriteString("//\n")
+			}
+			fprintf(&w, "// %s is a one-way message, so no response is sent.\n", methodName)
+		}
+		req := gc.GoTypeOf(&schema.Reference{
+			TypeName: m.request,
+			Def:      ns.Definitions[m.request],
+		}).GoType
+		if m.response == (schema.QualifiedName{}) {
+			fprintf(&w, "%s(ctx %s.Context, req %s) error\n", methodName, ctx, req)
+			continue
+		}
+		resp := ns.Definitions[m.response].(*schema.RecordDefinition)
+		fprintf(&w, "%s(ctx %s.Context, req %s) (%s, error)\n", methodName, ctx, req, gc.GoTypeOf(resp.Fields()[0].Type()).GoType)
+	}
+	w.WriteString("}\n")
+	return w.String(), nil
+}
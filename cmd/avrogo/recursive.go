@@ -0,0 +1,73 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rogpeppe/gogen-avro/v7/parser"
+	"github.com/rogpeppe/gogen-avro/v7/schema"
+)
+
+// checkRecursiveRecords returns an error if any record defined in ns
+// contains itself through fields that refer directly to records.
+//
+// Records that refer to themselves through a union, array or map are
+// fine because those are represented by pointers, interfaces, slices
+// and maps, which can be empty, but a record that contains
+// itself directly can't hold a finite value, and its Go struct
+// type would be invalid.
+func checkRecursiveRecords(ns *parser.Namespace, extTypes map[schema.QualifiedName]goType) error {
+	// done holds the records known not to lead to a cycle.
+	done := make(map[schema.QualifiedName]bool)
+	for _, name := range sortedDefinitionNames(ns) {
+		def, ok := ns.Definitions[name].(*schema.RecordDefinition)
+		if !ok || name != def.AvroName() {
+			continue
+		}
+		if _, ok := extTypes[name]; ok {
+			continue
+		}
+		if cycle := recordCycle(def, nil, done, extTypes); cycle != nil {
+			var steps []string
+			for i := 0; i+1 < len(cycle); i += 2 {
+				steps = append(steps, cycle[i]+"."+cycle[i+1])
+			}
+			steps = append(steps, cycle[len(cycle)-1])
+			return fmt.Errorf("record %s contains itself (%s) without a union, array or map in between, so it can't hold a finite value", cycle[0], strings.Join(steps, " -> "))
+		}
+	}
+	return nil
+}
+
+// recordCycle returns a cycle of direct record references reachable
+// from t, or nil if there's no such cycle. The path holds alternating
+// record and field names leading to t, and so does the returned cycle,
+// which starts and ends with the same record name.
+func recordCycle(t *schema.RecordDefinition, path []string, done map[schema.QualifiedName]bool, extTypes map[schema.QualifiedName]goType) []string {
+	name := t.AvroName()
+	if done[name] {
+		return nil
+	}
+	for i := 0; i < len(path); i += 2 {
+		if path[i] == name.String() {
+			return append(path[i:len(path):len(path)], name.String())
+		}
+	}
+	path = append(path, name.String())
+	for _, f := range t.Fields() {
+		ref, ok := f.Type().(*schema.Reference)
+		if !ok {
+			continue
+		}
+		if _, ok := extTypes[ref.TypeName]; ok {
+			continue
+		}
+		if def, ok := ref.Def.(*schema.RecordDefinition); ok {
+			if cycle := recordCycle(def, append(path, f.Name()), done, extTypes); cycle != nil {
+				return cycle
+			}
+		}
+	}
+	done[name] = true
+	return nil
+}
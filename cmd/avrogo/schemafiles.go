@@ -0,0 +1,70 @@
+package main
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// schemaExts holds the extensions of the schema files
+// that are read from directories named on the command line.
+var schemaExts = map[string]bool{
+	".avsc": true,
+	".avdl": true,
+	".avpr": true,
+}
+
+// schemaFiles returns the schema files named by args. Each directory
+// is replaced by the schema files inside it in lexical order, without
+// searching subdirectories, and each glob pattern, as understood by
+// filepath.Glob, is replaced by the files that match it, so that
+// patterns work even when there's no shell to expand them, as with
+// go:generate. A file is only returned once even if it's named by
+// more than one argument.
+func schemaFiles(args []string) ([]string, error) {
+	var files []string
+	seen := make(map[string]bool)
+	add := func(f string) {
+		if key := filepath.Clean(f); !seen[key] {
+			seen[key] = true
+			files = append(files, f)
+		}
+	}
+	for _, arg := range args {
+		if strings.ContainsAny(arg, "*?[") {
+			matches, err := filepath.Glob(arg)
+			if err != nil {
+				return nil, fmt.Errorf("invalid pattern %q: %v", arg, err)
+			}
+			if len(matches) == 0 {
+				return nil, fmt.Errorf("no files match %q", arg)
+			}
+			for _, f := range matches {
+				if info, err := os.Stat(f); err == nil && !info.IsDir() {
+					add(f)
+				}
+			}
+			continue
+		}
+		info, err := os.Stat(arg)
+		if err != nil {
+			return nil, err
+		}
+		if !info.IsDir() {
+			add(arg)
+			continue
+		}
+		infos, err := ioutil.ReadDir(arg)
+		if err != nil {
+			return nil, err
+		}
+		for _, info := range infos {
+			if !info.IsDir() && schemaExts[filepath.Ext(info.Name())] {
+				add(filepath.Join(arg, info.Name()))
+			}
+		}
+	}
+	return files, nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+var schemaFilesTests = []struct {
+	testName    string
+	args        []string
+	expect      []string
+	expectError string
+}{{
+	testName: "files",
+	args:     []string{"b.avsc", "notes.txt"},
+	expect:   []string{"b.avsc", "notes.txt"},
+}, {
+	testName: "directory",
+	args:     []string{"."},
+	expect:   []string{"a.avdl", "b.avsc", "c.avpr"},
+}, {
+	testName: "subdirectory",
+	args:     []string{"sub"},
+	expect:   []string{"sub/d.avsc"},
+}, {
+	testName: "glob",
+	args:     []string{"*.avsc", "sub/*"},
+	expect:   []string{"b.avsc", "sub/d.avsc"},
+}, {
+	testName: "duplicates",
+	args:     []string{"b.avsc", ".", "./b.avsc", "*.avsc"},
+	expect:   []string{"b.avsc", "a.avdl", "c.avpr"},
+}, {
+	testName:    "no-match",
+	args:        []string{"*.json"},
+	expectError: `no files match "\*.json"`,
+}, {
+	testName:    "bad-pattern",
+	args:        []string{"[.avsc"},
+	expectError: `invalid pattern "\[.avsc": syntax error in pattern`,
+}, {
+	testName:    "not-found",
+	args:        []string{"nothere.avsc"},
+	expectError: `stat nothere.avsc: no such file or directory`,
+}}
+
+func TestSchemaFiles(t *testing.T) {
+	c := qt.New(t)
+	dir := c.Mkdir()
+	for _, f := range []string{"b.avsc", "a.avdl", "c.avpr", "notes.txt", "sub/d.avsc"} {
+		path := filepath.Join(dir, f)
+		err := os.MkdirAll(filepath.Dir(path), 0777)
+		c.Assert(err, qt.Equals, nil)
+		err = ioutil.WriteFile(path, nil, 0666)
+		c.Assert(err, qt.Equals, nil)
+	}
+	wd, err := os.Getwd()
+	c.Assert(err, qt.Equals, nil)
+	err = os.Chdir(dir)
+	c.Assert(err, qt.Equals, nil)
+	defer os.Chdir(wd)
+
+	for _, test := range schemaFilesTests {
+		c.Run(test.testName, func(c *qt.C) {
+			files, err := schemaFiles(test.args)
+			if test.expectError != "" {
+				c.Assert(err, qt.ErrorMatches, test.expectError)
+				return
+			}
+			c.Assert(err, qt.Equals, nil)
+			for i := range test.expect {
+				test.expect[i] = filepath.FromSlash(test.expect[i])
+			}
+			c.Assert(files, qt.DeepEquals, test.expect)
+		})
+	}
+}
@@ -0,0 +1,326 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+)
+
+// selfContainedNames maps from the name of each identifier in the
+// avrotypegen package that generated code uses to the name of the
+// unexported identifier that replaces it in self-contained code.
+var selfContainedNames = map[string]string{
+	"Encoder":    "avroEncoder",
+	"Decoder":    "avroDecoder",
+	"NewDecoder": "newAvroDecoder",
+	"Null":       "avroNull",
+}
+
+// typegenName returns the Go expression that generated code uses
+// to refer to the given identifier from the avrotypegen package.
+func (gc *generateContext) typegenName(name string) string {
+	if gc.opts.selfContained {
+		return selfContainedNames[name]
+	}
+	return "avrotypegen." + name
+}
+
+// isNullGoType reports whether t is the Go type
+// that represents the Avro null type.
+func isNullGoType(t string) bool {
+	return t == nullType || t == selfContainedNames["Null"]
+}
+
+// SelfContained reports whether the generated code
+// is self-contained, as specified by the -selfcontained flag.
+func (gc *generateContext) SelfContained() bool {
+	return gc.opts.selfContained
+}
+
+// generateSupport writes the source of a Go file in package pkg
+// to w that holds the code that self-contained generated code
+// uses instead of the avrotypegen package.
+func generateSupport(w *bytes.Buffer, pkg string) error {
+	gc := &generateContext{
+		imports: make(map[string]string),
+	}
+	for _, imp := range []string{"encoding/binary", "fmt", "math", "time"} {
+		gc.addImport(imp)
+	}
+	var body bytes.Buffer
+	fmt.Fprintf(&body, "%s\n// %s represents the Avro null type.\ntype %[2]s struct{}\n", supportCode, selfContainedNames["Null"])
+	return gc.writeFile(w, pkg, body.Bytes())
+}
+
+// supportCode holds the source of avrotypegen/codec.go without
+// its package clause and imports, with identifiers renamed as
+// specified by selfContainedNames. TestSupportCode checks that
+// it's up to date.
+const supportCode = `
+// avroEncoder is used by generated code to write Avro binary data.
+// The zero value is ready to use.
+type avroEncoder struct {
+	buf     []byte
+	err     error
+	scratch [binary.MaxVarintLen64]byte
+}
+
+// Bytes returns the data written so far
+// and the first error encountered, if any.
+func (e *avroEncoder) Bytes() ([]byte, error) {
+	if e.err != nil {
+		return nil, e.err
+	}
+	return e.buf, nil
+}
+
+// Error records an encoding error. Only the first
+// error is recorded.
+func (e *avroEncoder) Error(err error) {
+	if e.err == nil {
+		e.err = err
+	}
+}
+
+// WriteBool writes an Avro boolean.
+func (e *avroEncoder) WriteBool(x bool) {
+	if x {
+		e.buf = append(e.buf, 1)
+	} else {
+		e.buf = append(e.buf, 0)
+	}
+}
+
+// WriteLong writes an Avro int or long.
+func (e *avroEncoder) WriteLong(x int64) {
+	n := binary.PutVarint(e.scratch[:], x)
+	e.buf = append(e.buf, e.scratch[:n]...)
+}
+
+// WriteFloat writes an Avro float.
+func (e *avroEncoder) WriteFloat(x float32) {
+	binary.LittleEndian.PutUint32(e.scratch[:], math.Float32bits(x))
+	e.buf = append(e.buf, e.scratch[:4]...)
+}
+
+// WriteDouble writes an Avro double.
+func (e *avroEncoder) WriteDouble(x float64) {
+	binary.LittleEndian.PutUint64(e.scratch[:], math.Float64bits(x))
+	e.buf = append(e.buf, e.scratch[:8]...)
+}
+
+// WriteBytes writes an Avro bytes value.
+func (e *avroEncoder) WriteBytes(x []byte) {
+	e.WriteLong(int64(len(x)))
+	e.buf = append(e.buf, x...)
+}
+
+// WriteString writes an Avro string.
+func (e *avroEncoder) WriteString(x string) {
+	e.WriteLong(int64(len(x)))
+	e.buf = append(e.buf, x...)
+}
+
+// WriteFixed writes an Avro fixed value.
+func (e *avroEncoder) WriteFixed(x []byte) {
+	e.buf = append(e.buf, x...)
+}
+
+// WriteTimestampMillis writes a long with the timestamp-millis
+// logical type. The zero time is written as zero.
+func (e *avroEncoder) WriteTimestampMillis(t time.Time) {
+	if t.IsZero() {
+		e.WriteLong(0)
+	} else {
+		e.WriteLong(t.Unix()*1e3 + int64(t.Nanosecond())/int64(time.Millisecond))
+	}
+}
+
+// WriteTimestampMicros writes a long with the timestamp-micros
+// logical type. The zero time is written as zero.
+func (e *avroEncoder) WriteTimestampMicros(t time.Time) {
+	if t.IsZero() {
+		e.WriteLong(0)
+	} else {
+		e.WriteLong(t.Unix()*1e6 + int64(t.Nanosecond())/int64(time.Microsecond))
+	}
+}
+
+// avroDecoder is used by generated code to read Avro binary data.
+// After the first error, all reads return zero values.
+type avroDecoder struct {
+	data []byte
+	err  error
+}
+
+// newAvroDecoder returns a decoder that reads from data.
+func newAvroDecoder(data []byte) *avroDecoder {
+	return &avroDecoder{
+		data: data,
+	}
+}
+
+// Finish returns the first error encountered when decoding,
+// or an error if not all the data has been read.
+func (d *avroDecoder) Finish() error {
+	if d.err != nil {
+		return d.err
+	}
+	if len(d.data) > 0 {
+		return fmt.Errorf("%d bytes of unexpected trailing data", len(d.data))
+	}
+	return nil
+}
+
+// Error records a decoding error. Only the first
+// error is recorded.
+func (d *avroDecoder) Error(err error) {
+	if d.err == nil {
+		d.err = err
+		d.data = nil
+	}
+}
+
+// ReadBool reads an Avro boolean.
+func (d *avroDecoder) ReadBool() bool {
+	b := d.next(1)
+	if b == nil {
+		return false
+	}
+	switch b[0] {
+	case 0:
+		return false
+	case 1:
+		return true
+	}
+	d.Error(fmt.Errorf("invalid boolean value %d", b[0]))
+	return false
+}
+
+// ReadLong reads an Avro int or long.
+func (d *avroDecoder) ReadLong() int64 {
+	if d.err != nil {
+		return 0
+	}
+	x, n := binary.Varint(d.data)
+	if n <= 0 {
+		d.Error(fmt.Errorf("invalid varint"))
+		return 0
+	}
+	d.data = d.data[n:]
+	return x
+}
+
+// ReadFloat reads an Avro float.
+func (d *avroDecoder) ReadFloat() float32 {
+	b := d.next(4)
+	if b == nil {
+		return 0
+	}
+	return math.Float32frombits(binary.LittleEndian.Uint32(b))
+}
+
+// ReadDouble reads an Avro double.
+func (d *avroDecoder) ReadDouble() float64 {
+	b := d.next(8)
+	if b == nil {
+		return 0
+	}
+	return math.Float64frombits(binary.LittleEndian.Uint64(b))
+}
+
+// ReadBytes reads an Avro bytes value. The returned slice
+// does not refer to the decoder's data.
+func (d *avroDecoder) ReadBytes() []byte {
+	b := d.next(d.readLength())
+	if b == nil {
+		return nil
+	}
+	return append([]byte{}, b...)
+}
+
+// ReadString reads an Avro string.
+func (d *avroDecoder) ReadString() string {
+	return string(d.next(d.readLength()))
+}
+
+// ReadFixed reads an Avro fixed value into buf,
+// which must be the size of the fixed type.
+func (d *avroDecoder) ReadFixed(buf []byte) {
+	copy(buf, d.next(len(buf)))
+}
+
+// ReadTimestampMillis reads a long with the timestamp-millis
+// logical type.
+func (d *avroDecoder) ReadTimestampMillis() time.Time {
+	x := d.ReadLong()
+	return time.Unix(x/1e3, x%1e3*1e6)
+}
+
+// ReadTimestampMicros reads a long with the timestamp-micros
+// logical type.
+func (d *avroDecoder) ReadTimestampMicros() time.Time {
+	x := d.ReadLong()
+	return time.Unix(x/1e6, x%1e6*1e3)
+}
+
+// ReadIndex reads an enum symbol or union member index
+// and checks that it's less than n.
+func (d *avroDecoder) ReadIndex(n int) int {
+	x := d.ReadLong()
+	if x < 0 || x >= int64(n) {
+		d.Error(fmt.Errorf("index %d out of range [0, %d)", x, n))
+		return 0
+	}
+	return int(x)
+}
+
+// ReadBlockCount reads the count of items in the
+// next block of an Avro array or map. It returns
+// zero at the end of the items or on error.
+func (d *avroDecoder) ReadBlockCount() int {
+	n := d.ReadLong()
+	if n < 0 {
+		// A negative count is followed by the
+		// size of the block in bytes, which we
+		// don't need.
+		n = -n
+		d.ReadLong()
+	}
+	if n > int64(len(d.data)) {
+		// Every item takes at least one byte except for
+		// items of the null type, which we don't expect to
+		// find in large numbers.
+		d.Error(fmt.Errorf("block count %d too large", n))
+		return 0
+	}
+	return int(n)
+}
+
+func (d *avroDecoder) readLength() int {
+	n := d.ReadLong()
+	if n < 0 {
+		d.Error(fmt.Errorf("negative length %d", n))
+		return 0
+	}
+	if n > int64(len(d.data)) {
+		d.Error(fmt.Errorf("length %d out of range", n))
+		return 0
+	}
+	return int(n)
+}
+
+// next returns the next n bytes of data, or nil
+// if there aren't enough.
+func (d *avroDecoder) next(n int) []byte {
+	if d.err != nil {
+		return nil
+	}
+	if n > len(d.data) {
+		d.Error(fmt.Errorf("unexpected end of data"))
+		return nil
+	}
+	b := d.data[:n]
+	d.data = d.data[n:]
+	return b
+}
+`
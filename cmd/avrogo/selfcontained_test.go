@@ -0,0 +1,25 @@
+package main
+
+import (
+	"io/ioutil"
+	"regexp"
+	"strings"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestSupportCode(t *testing.T) {
+	c := qt.New(t)
+	data, err := ioutil.ReadFile("../../avrotypegen/codec.go")
+	c.Assert(err, qt.Equals, nil)
+	src := string(data)
+	// Remove the package clause and imports.
+	i := strings.Index(src, "\n)\n")
+	c.Assert(i, qt.Not(qt.Equals), -1)
+	src = src[i+len("\n)\n"):]
+	for name, newName := range selfContainedNames {
+		src = regexp.MustCompile(`\b`+name+`\b`).ReplaceAllString(src, newName)
+	}
+	c.Assert(supportCode, qt.Equals, src)
+}
@@ -0,0 +1,123 @@
+package main
+
+import (
+	"fmt"
+	"go/token"
+	"strings"
+	"unicode"
+
+	"github.com/rogpeppe/gogen-avro/v7/schema"
+)
+
+// structTag describes a struct tag generated for every
+// record field, as specified with the -tags flag.
+type structTag struct {
+	// Key holds the tag key, such as "json" or "bson".
+	Key string
+
+	// Style holds how the tag value is derived from the
+	// Avro field name: "avro" uses it unchanged and "snake"
+	// converts it to snake_case.
+	Style string
+}
+
+// structTagsFlag implements the -tags flag.
+type structTagsFlag []structTag
+
+func (f *structTagsFlag) String() string {
+	var buf strings.Builder
+	for i, tag := range *f {
+		if i > 0 {
+			buf.WriteString(",")
+		}
+		buf.WriteString(tag.Key + ":" + tag.Style)
+	}
+	return buf.String()
+}
+
+// Set implements flag.Value.Set by parsing a comma-separated
+// list of tags, each of the form key or key:style.
+func (f *structTagsFlag) Set(s string) error {
+	for _, item := range strings.Split(s, ",") {
+		tag := structTag{
+			Key:   item,
+			Style: "avro",
+		}
+		if i := strings.Index(item, ":"); i >= 0 {
+			tag.Key, tag.Style = item[:i], item[i+1:]
+		}
+		if !token.IsIdentifier(tag.Key) || tag.Key == "avro" {
+			return fmt.Errorf("invalid struct tag key %q", tag.Key)
+		}
+		if tag.Style != "avro" && tag.Style != "snake" {
+			return fmt.Errorf("invalid style %q for struct tag %q; must be \"avro\" or \"snake\"", tag.Style, tag.Key)
+		}
+		for _, tag0 := range *f {
+			if tag0.Key == tag.Key {
+				return fmt.Errorf("struct tag %q specified more than once", tag.Key)
+			}
+		}
+		*f = append(*f, tag)
+	}
+	return nil
+}
+
+// FieldTag returns the struct tag, with a leading space and
+// surrounding backquotes, for the Go field that represents
+// the Avro field f, or the empty string if no tag is needed.
+//
+// The Avro runtime takes the Avro name of a field from its "avro"
+// tag or its "json" tag, so one of those is generated when the
+// Go name isn't the same as the Avro name, or when the "json"
+// tag holds a different name.
+func (gc *generateContext) FieldTag(f *schema.Field) (string, error) {
+	goName, err := fieldGoName(f.Name())
+	if err != nil {
+		return "", err
+	}
+	var tags []string
+	jsonStyle := ""
+	for _, tag := range gc.opts.tags {
+		value := f.Name()
+		if tag.Style == "snake" {
+			value = snakeCase(value)
+		}
+		if gc.opts.omitEmpty {
+			value += ",omitempty"
+		}
+		tags = append(tags, fmt.Sprintf("%s:%q", tag.Key, value))
+		if tag.Key == "json" {
+			jsonStyle = tag.Style
+		}
+	}
+	switch {
+	case jsonStyle == "avro":
+	case jsonStyle != "":
+		tags = append([]string{fmt.Sprintf("avro:%q", f.Name())}, tags...)
+	case goName != f.Name():
+		tags = append([]string{fmt.Sprintf("json:%q", f.Name())}, tags...)
+	}
+	if len(tags) == 0 {
+		return "", nil
+	}
+	return " `" + strings.Join(tags, " ") + "`", nil
+}
+
+// snakeCase returns s converted to snake_case. For example,
+// "fooBar", "FooBar" and "foo_bar" all become "foo_bar",
+// and "HTTPServer" becomes "http_server".
+func snakeCase(s string) string {
+	rs := []rune(s)
+	var buf strings.Builder
+	for i, r := range rs {
+		if unicode.IsUpper(r) {
+			if i > 0 && rs[i-1] != '_' && (unicode.IsLower(rs[i-1]) || unicode.IsDigit(rs[i-1]) ||
+				i+1 < len(rs) && unicode.IsLower(rs[i+1])) {
+				buf.WriteByte('_')
+			}
+			r = unicode.ToLower(r)
+		}
+		buf.WriteRune(r)
+	}
+	return buf.String()
+}
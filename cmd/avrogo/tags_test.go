@@ -0,0 +1,29 @@
+package main
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+var snakeCaseTests = []struct {
+	s      string
+	expect string
+}{
+	{"foo", "foo"},
+	{"fooBar", "foo_bar"},
+	{"FooBar", "foo_bar"},
+	{"foo_bar", "foo_bar"},
+	{"Foo_Bar", "foo_bar"},
+	{"ID", "id"},
+	{"userID", "user_id"},
+	{"HTTPServer", "http_server"},
+	{"version2Name", "version2_name"},
+}
+
+func TestSnakeCase(t *testing.T) {
+	c := qt.New(t)
+	for _, test := range snakeCaseTests {
+		c.Check(snakeCase(test.s), qt.Equals, test.expect, qt.Commentf("%q", test.s))
+	}
+}
@@ -28,6 +28,7 @@ var templateFuncs = template.FuncMap{
 	"goName":                 goName,
 	"indent":                 indent,
 	"doc":                    doc,
+	"isNullGoType":           isNullGoType,
 	"import": func(gc *generateContext, pkg string) string {
 		gc.addImport(pkg)
 		return ""
@@ -35,15 +36,19 @@ var templateFuncs = template.FuncMap{
 }
 
 type headerTemplateParams struct {
-	Pkg       string
-	Imports   []string
-	ImportIds map[string]string
+	Pkg          string
+	Imports      []string
+	ImportIds    map[string]string
+	Fingerprints []schemaFingerprint
 }
 
 // TODO avoid explicit package identifiers
 var headerTemplate = newTemplate(`
 // Code generated by avrogen. DO NOT EDIT.
-
+«if .Fingerprints»//
+// ` + fingerprintsHeading + `
+«range .Fingerprints»//	«.Name» «printf "0x%016x" .Fingerprint»
+«end»«end»
 package «.Pkg»
 
 import (
@@ -51,6 +56,13 @@ import (
 «end»)
 `)
 
+var docTemplate = newTemplate(`
+// Code generated by avrogen. DO NOT EDIT.
+
+// Package «.» holds Go types generated from Avro schemas.
+package «.»
+`)
+
 type bodyTemplateParams struct {
 	Definitions []schema.QualifiedName
 	NS          *parser.Namespace
@@ -69,17 +81,58 @@ var bodyTemplate = newTemplate(`
 			«- $type := $.Ctx.GoTypeOf .Type»
 			«- doc "\t// " $type»
 			«- if isExportedGoIdentifier .Name»
-				«- .Name» «$type.GoType»
+				«- .Name» «$type.GoType»«$.Ctx.FieldTag .»
 			«- else»
-				«- goName .Name» «$type.GoType» ` + "`" + `json:«printf "%q" .Name»` + "`" + `
+				«- goName .Name» «$type.GoType»«$.Ctx.FieldTag .»
 			«- end»
 		«end»
 		}
+		«- if not $.Ctx.SelfContained»
 
 		// AvroRecord implements the avro.AvroRecord interface.
 		func («defName .») AvroRecord() avrotypegen.RecordInfo {
 			return «$.Ctx.RecordInfoLiteral .»
 		}
+		«- end»
+		«- $.Ctx.SchemaConstants .»
+		«- range $f := $.Ctx.UnionFields .»
+		«- range $f.Branches»
+		«- if isNullGoType .GoType»
+
+		// «$f.Field»IsNull reports whether «$f.Field» holds null.
+		func (r «defName $def») «$f.Field»IsNull() bool {
+			return r.«$f.Field» == nil
+		}
+
+		// Set«$f.Field»Null sets «$f.Field» to null.
+		func (r *«defName $def») Set«$f.Field»Null() {
+			r.«$f.Field» = nil
+		}
+		«- else»
+
+		// «$f.Field»As«.Name» returns the value of «$f.Field»
+		// and reports whether it holds a value of type «.GoType».
+		func (r «defName $def») «$f.Field»As«.Name»() («.GoType», bool) {
+			v, ok := r.«$f.Field».(«.GoType»)
+			return v, ok
+		}
+
+		// Set«$f.Field»«.Name» sets «$f.Field» to v.
+		func (r *«defName $def») Set«$f.Field»«.Name»(v «.GoType») {
+			r.«$f.Field» = v
+		}
+		«- end»
+		«- end»
+		«- end»
+		«$.Ctx.Getters .»
+		«$.Ctx.BytesAccessors .»
+		«$.Ctx.Interface .»
+		«$.Ctx.Constructor .»
+		«$.Ctx.BinaryMethods .»
+		«$.Ctx.ValidateMethod .»
+		«$.Ctx.Builder .»
+		«$.Ctx.MessageFuncs .»
+		«$.Ctx.CloneMethods .»
 	«else if eq (typeof .) "EnumDefinition"»
 		«- import $.Ctx "strconv"»
 		«- import $.Ctx "fmt"»
@@ -104,6 +157,12 @@ var bodyTemplate = newTemplate(`
 			return _«defName .»_strings[e]
 		}
 
+		// Valid reports whether e holds one of the
+		// symbols defined for «defName .».
+		func (e «defName .») Valid() bool {
+			return e >= 0 && int(e) < len(_«defName .»_strings)
+		}
+
 		// MarshalText implements encoding.TextMarshaler
 		// by returning the textual representation of «defName .».
 		func (e «defName .») MarshalText() ([]byte, error) {
@@ -125,9 +184,11 @@ var bodyTemplate = newTemplate(`
 			}
 			return fmt.Errorf("unknown value %q for «defName .»", data)
 		}
+		«$.Ctx.ValidateMethod .»
 	«else if eq (typeof .) "FixedDefinition"»
 		«- doc "// " . -»
 		type «defName .» [«.SizeBytes»]byte
+		«$.Ctx.FixedMethods .»
 	«else»
 		// unknown definition type «printf "%T; name %q" . (typeof .)» .
 	«end»
@@ -154,11 +215,25 @@ type documented interface {
 	Doc() string
 }
 
+// attributed is implemented by definitions, such as fixed types,
+// that don't have a Doc method but keep the attributes of their
+// schema definition.
+type attributed interface {
+	Attribute(name string) interface{}
+}
+
 func doc(indentStr string, d interface{}) string {
-	if d, ok := d.(documented); ok && d.Doc() != "" {
-		return "\n" + indent(trimAVDLDoc(d.Doc()), indentStr) + "\n"
+	var s string
+	switch d := d.(type) {
+	case documented:
+		s = d.Doc()
+	case attributed:
+		s, _ = d.Attribute("doc").(string)
 	}
-	return ""
+	if s == "" {
+		return ""
+	}
+	return "\n" + indent(trimAVDLDoc(s), indentStr) + "\n"
 }
 
 // trimAVDLDoc removes indentation from a doc string
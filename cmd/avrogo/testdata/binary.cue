@@ -0,0 +1,159 @@
+package roundtrip
+
+tests: binaryMethods: {
+	avrogoFlags: ["-binary"]
+	inSchema: {
+		type: "record"
+		name: "R"
+		fields: [{
+			name: "B"
+			type: "boolean"
+		}, {
+			name: "I"
+			type: "int"
+		}, {
+			name: "L"
+			type: "long"
+		}, {
+			name: "F"
+			type: "float"
+		}, {
+			name: "D"
+			type: "double"
+		}, {
+			name: "S"
+			type: "string"
+		}, {
+			name: "By"
+			type: "bytes"
+		}, {
+			name: "T"
+			type: {
+				type:        "long"
+				logicalType: "timestamp-micros"
+			}
+		}, {
+			name: "E"
+			type: {
+				type: "enum"
+				name: "Color"
+				symbols: ["red", "green", "blue"]
+			}
+		}, {
+			name: "X"
+			type: {
+				type: "fixed"
+				name: "Hash"
+				size: 4
+			}
+		}, {
+			name: "A"
+			type: {
+				type:  "array"
+				items: "int"
+			}
+		}, {
+			name: "M"
+			type: {
+				type:   "map"
+				values: "string"
+			}
+		}, {
+			name: "P"
+			type: ["null", "string"]
+		}, {
+			name: "U"
+			type: ["null", "int", "string", "Color"]
+		}, {
+			name: "N"
+			type: {
+				type: "record"
+				name: "Node"
+				fields: [{
+					name: "Name"
+					type: "string"
+				}, {
+					name: "Next"
+					type: ["null", "Node"]
+					default: null
+				}]
+			}
+		}]
+	}
+	outSchema: inSchema
+}
+
+tests: binaryMethods: subtests: values: {
+	inData: {
+		B:  true
+		I:  -12
+		L:  1234567890123
+		F:  1.5
+		D:  -2.25
+		S:  "hello"
+		By: "\u0001\u0002\u007f"
+		T:  1579114028888888
+		E:  "green"
+		X:  "abcd"
+		A: [1, 2, 3]
+		M: a: "x"
+		P: string: "p"
+		U: Color: "blue"
+		N: {
+			Name: "a"
+			Next: Node: {
+				Name: "b"
+				Next: null
+			}
+		}
+	}
+	outData: inData
+}
+
+tests: binaryMethods: subtests: zero: {
+	inData: {
+		B:  false
+		I:  0
+		L:  0
+		F:  0
+		D:  0
+		S:  ""
+		By: ""
+		T:  0
+		E:  "red"
+		X:  "\u0000\u0000\u0000\u0000"
+		A: []
+		M: {}
+		P: null
+		U: null
+		N: {
+			Name: ""
+			Next: null
+		}
+	}
+	outData: inData
+}
+
+tests: binaryMethods: subtests: withInt: {
+	inData: {
+		B:  false
+		I:  0
+		L:  0
+		F:  0
+		D:  0
+		S:  ""
+		By: ""
+		T:  0
+		E:  "red"
+		X:  "\u0000\u0000\u0000\u0000"
+		A: []
+		M: {}
+		P: null
+		U: int: 99
+		N: {
+			Name: ""
+			Next: null
+		}
+	}
+	outData: inData
+}
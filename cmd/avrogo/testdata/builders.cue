@@ -0,0 +1,147 @@
+package roundtrip
+
+tests: builders: {
+	avrogoFlags: ["-builders"]
+	inSchema: {
+		type: "record"
+		name: "Node"
+		fields: [{
+			name: "Name"
+			type: "string"
+		}, {
+			name: "Kind"
+			type: {
+				type: "enum"
+				name: "Kind"
+				symbols: ["leaf", "branch"]
+			}
+		}, {
+			name: "ID"
+			type: {
+				type: "fixed"
+				name: "Hash"
+				size: 4
+			}
+		}, {
+			name: "Data"
+			type: "bytes"
+		}, {
+			name: "Count"
+			type: "int"
+		}, {
+			name: "Total"
+			type: "long"
+		}, {
+			name: "Value"
+			type: ["null", "int", "string", "Kind"]
+		}, {
+			name: "Next"
+			type: ["null", "Node"]
+			default: null
+		}, {
+			name: "Children"
+			type: {
+				type:  "array"
+				items: "Node"
+			}
+		}, {
+			name: "Attrs"
+			type: {
+				type:   "map"
+				values: "string"
+			}
+		}]
+	}
+	outSchema: inSchema
+	otherTests: """
+	package builders
+
+	import (
+		"math/rand"
+		"testing"
+
+		qt "github.com/frankban/quicktest"
+
+		"github.com/heetch/avro"
+	)
+
+	func TestBuilderWith(t *testing.T) {
+		c := qt.New(t)
+		n := NewNodeBuilder().
+			WithName("x").
+			WithKind(KindBranch).
+			WithCount(3).
+			WithValue("v").
+			Build()
+		c.Assert(n, qt.DeepEquals, Node{
+			Name:  "x",
+			Kind:  KindBranch,
+			Count: 3,
+			Value: "v",
+		})
+	}
+
+	func TestBuilderRandom(t *testing.T) {
+		c := qt.New(t)
+		for seed := int64(0); seed < 50; seed++ {
+			n := NewNodeBuilder().Random(rand.New(rand.NewSource(seed))).Build()
+			checkRandomNode(c, n, 0)
+			_, _, err := avro.Marshal(n)
+			c.Assert(err, qt.IsNil)
+
+			// The same seed always produces the same value.
+			n1 := NewNodeBuilder().Random(rand.New(rand.NewSource(seed))).Build()
+			c.Assert(n1, qt.DeepEquals, n)
+		}
+	}
+
+	func checkRandomNode(c *qt.C, n Node, depth int) {
+		c.Assert(n.Kind.Valid(), qt.IsTrue)
+		switch v := n.Value.(type) {
+		case nil, int, string:
+		case Kind:
+			c.Assert(v.Valid(), qt.IsTrue)
+		default:
+			c.Fatalf("unexpected type %T in union", v)
+		}
+		if depth >= 4 {
+			c.Assert(n.Next, qt.IsNil)
+			c.Assert(n.Children, qt.HasLen, 0)
+			c.Assert(n.Attrs, qt.HasLen, 0)
+		}
+		if n.Next != nil {
+			checkRandomNode(c, *n.Next, depth+1)
+		}
+		for _, child := range n.Children {
+			checkRandomNode(c, child, depth+1)
+		}
+	}
+	"""
+}
+
+tests: builders: subtests: values: {
+	inData: {
+		Name:  "root"
+		Kind:  "branch"
+		ID:    "abcd"
+		Data:  "xyz"
+		Count: 1
+		Total: 2
+		Value: string: "v"
+		Next: Node: {
+			Name:  "next"
+			Kind:  "leaf"
+			ID:    "efgh"
+			Data:  ""
+			Count: 3
+			Total: 4
+			Value: null
+			Next:  null
+			Children: []
+			Attrs: {}
+		}
+		Children: []
+		Attrs: a: "b"
+	}
+	outData: inData
+}
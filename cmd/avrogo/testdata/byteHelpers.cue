@@ -0,0 +1,69 @@
+package roundtrip
+
+tests: byteHelpers: {
+	avrogoFlags: ["-bytehelpers"]
+	inSchema: {
+		name: "R"
+		type: "record"
+		fields: [{
+			name: "H"
+			type: {
+				name: "hash"
+				type: "fixed"
+				size: 4
+			}
+		}, {
+			name: "B"
+			type: "bytes"
+		}]
+	}
+	outSchema: inSchema
+	otherTests: """
+	package byteHelpers
+
+	import (
+		"testing"
+
+		qt "github.com/frankban/quicktest"
+	)
+
+	func TestFixedMethods(t *testing.T) {
+		c := qt.New(t)
+		h := Hash{0xde, 0xad, 0xbe, 0xef}
+		c.Assert(h.Hex(), qt.Equals, "deadbeef")
+
+		b := h.Bytes()
+		c.Assert(b, qt.DeepEquals, []byte{0xde, 0xad, 0xbe, 0xef})
+		b[0] = 0
+		c.Assert(h[0], qt.Equals, byte(0xde))
+
+		var h1 Hash
+		err := h1.SetBytes([]byte{1, 2, 3, 4})
+		c.Assert(err, qt.Equals, nil)
+		c.Assert(h1, qt.Equals, Hash{1, 2, 3, 4})
+
+		err = h1.SetBytes([]byte{1, 2, 3})
+		c.Assert(err, qt.ErrorMatches, `Hash value has wrong length \\(got 3; want 4\\)`)
+		c.Assert(h1, qt.Equals, Hash{1, 2, 3, 4})
+
+		r := R{H: h}
+		err = r.H.SetBytes([]byte{5, 6, 7, 8})
+		c.Assert(err, qt.Equals, nil)
+		c.Assert(r.H, qt.Equals, Hash{5, 6, 7, 8})
+	}
+
+	func TestBytesAccessors(t *testing.T) {
+		c := qt.New(t)
+		c.Assert(R{B: []byte{0x01, 0xab}}.BHex(), qt.Equals, "01ab")
+		c.Assert(R{}.BHex(), qt.Equals, "")
+	}
+	"""
+}
+
+tests: byteHelpers: subtests: values: {
+	inData: {
+		H: "\u0001\u0002\u0003\u0004"
+		B: "\u0005"
+	}
+	outData: inData
+}
@@ -0,0 +1,197 @@
+package roundtrip
+
+tests: clone: {
+	avrogoFlags: ["-clone"]
+	inSchema: {
+		name: "R"
+		type: "record"
+		fields: [{
+			name: "By"
+			type: "bytes"
+		}, {
+			name: "A"
+			type: {
+				type: "array"
+				items: {
+					name: "Inner"
+					type: "record"
+					fields: [{
+						name: "Name"
+						type: "string"
+					}, {
+						name: "Tags"
+						type: {
+							type:  "array"
+							items: "string"
+						}
+					}]
+				}
+			}
+		}, {
+			name: "M"
+			type: {
+				type:   "map"
+				values: "bytes"
+			}
+		}, {
+			name: "P"
+			type: ["null", "Inner"]
+			default: null
+		}, {
+			name: "U"
+			type: ["null", "int", "Inner"]
+		}, {
+			name: "T"
+			type: {
+				type:        "long"
+				logicalType: "timestamp-micros"
+			}
+		}]
+	}
+	outSchema: inSchema
+	otherTests: """
+	package clone
+
+	import (
+		"testing"
+		"time"
+
+		qt "github.com/frankban/quicktest"
+	)
+
+	func newR() R {
+		return R{
+			By: []byte("by"),
+			A:  []Inner{{Name: "a", Tags: []string{"x", "y"}}},
+			M:  map[string][]byte{"k": []byte("v")},
+			P:  &Inner{Name: "p", Tags: []string{"z"}},
+			U:  Inner{Name: "u", Tags: []string{"w"}},
+			T:  time.Unix(1579114028, 0),
+		}
+	}
+
+	func TestClone(t *testing.T) {
+		c := qt.New(t)
+		r := newR()
+		r1 := r.Clone()
+		c.Assert(r1, qt.DeepEquals, r)
+		c.Assert(r1.Equal(r), qt.IsTrue)
+
+		// Changing the clone doesn't change the original.
+		r1.By[0] = 'x'
+		r1.A[0].Tags[0] = "changed"
+		r1.M["k"][0] = 'x'
+		r1.P.Tags[0] = "changed"
+		r1.U.(Inner).Tags[0] = "changed"
+		c.Assert(r, qt.DeepEquals, newR())
+		c.Assert(r1.Equal(r), qt.IsFalse)
+	}
+
+	func TestCloneNil(t *testing.T) {
+		c := qt.New(t)
+		var r R
+		r1 := r.Clone()
+		c.Assert(r1.By, qt.IsNil)
+		c.Assert(r1.A, qt.IsNil)
+		c.Assert(r1.M, qt.IsNil)
+		c.Assert(r1.P, qt.IsNil)
+		c.Assert(r1.U, qt.IsNil)
+	}
+
+	var equalTests = []struct {
+		testName string
+		change   func(r *R)
+		expect   bool
+	}{{
+		testName: "same",
+		change:   func(r *R) {},
+		expect:   true,
+	}, {
+		testName: "bytes-nil",
+		change: func(r *R) {
+			r.By = nil
+		},
+	}, {
+		testName: "bytes",
+		change: func(r *R) {
+			r.By = []byte("other")
+		},
+	}, {
+		testName: "array-item",
+		change: func(r *R) {
+			r.A[0].Tags = append(r.A[0].Tags, "extra")
+		},
+	}, {
+		testName: "map-key",
+		change: func(r *R) {
+			r.M = map[string][]byte{"other": []byte("v")}
+		},
+	}, {
+		testName: "pointer-nil",
+		change: func(r *R) {
+			r.P = nil
+		},
+	}, {
+		testName: "pointer-value",
+		change: func(r *R) {
+			r.P = &Inner{Name: "p", Tags: []string{"z"}}
+		},
+		expect: true,
+	}, {
+		testName: "union-type",
+		change: func(r *R) {
+			r.U = 1
+		},
+	}, {
+		testName: "union-value",
+		change: func(r *R) {
+			r.U = Inner{Name: "other"}
+		},
+	}, {
+		testName: "time-location",
+		change: func(r *R) {
+			r.T = r.T.UTC()
+		},
+		expect: true,
+	}}
+
+	func TestEqual(t *testing.T) {
+		c := qt.New(t)
+		for _, test := range equalTests {
+			c.Run(test.testName, func(c *qt.C) {
+				r := newR()
+				test.change(&r)
+				c.Assert(r.Equal(newR()), qt.Equals, test.expect)
+				c.Assert(newR().Equal(r), qt.Equals, test.expect)
+			})
+		}
+	}
+
+	func TestEqualNilAndEmpty(t *testing.T) {
+		c := qt.New(t)
+		c.Assert(R{}.Equal(R{By: []byte{}, A: []Inner{}, M: map[string][]byte{}}), qt.IsTrue)
+		c.Assert(R{U: nil}.Equal(R{U: 0}), qt.IsFalse)
+	}
+	"""
+}
+
+tests: clone: subtests: values: {
+	inData: {
+		By: "\u0001\u0002"
+		A: [{
+			Name: "a"
+			Tags: ["x", "y"]
+		}]
+		M: k: "\u0003"
+		P: Inner: {
+			Name: "p"
+			Tags: []
+		}
+		U: Inner: {
+			Name: "u"
+			Tags: ["z"]
+		}
+		T: 1579114028888888
+	}
+	outData: inData
+}
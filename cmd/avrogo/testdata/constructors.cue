@@ -0,0 +1,122 @@
+package roundtrip
+
+tests: constructors: {
+	avrogoFlags: ["-constructors"]
+	inSchema: {
+		type: "record"
+		name: "R"
+		fields: [{
+			name:    "A"
+			type:    "int"
+			default: 5
+		}, {
+			name:    "B"
+			type:    "string"
+			default: ""
+		}, {
+			name: "C"
+			type: ["string", "null"]
+			default: "x"
+		}, {
+			name: "D"
+			type: ["null", "int", "string"]
+			default: null
+		}, {
+			name: "E"
+			type: {
+				type: "enum"
+				name: "Color"
+				symbols: ["red", "green"]
+			}
+			default: "green"
+		}, {
+			name: "F"
+			type: "int"
+		}, {
+			name: "H"
+			type: ["int", "string"]
+			default: 3
+		}, {
+			name: "N"
+			type: {
+				type: "record"
+				name: "Inner"
+				fields: [{
+					name:    "X"
+					type:    "int"
+					default: 1
+				}]
+			}
+			default: X: 2
+		}]
+	}
+	outSchema: inSchema
+	otherTests: """
+	package constructors
+
+	import (
+		"testing"
+
+		qt "github.com/frankban/quicktest"
+	)
+
+	func TestNewR(t *testing.T) {
+		c := qt.New(t)
+		r := NewR()
+		c.Assert(r.A, qt.Equals, 5)
+		c.Assert(r.B, qt.Equals, "")
+		c.Assert(r.C, qt.Not(qt.IsNil))
+		c.Assert(*r.C, qt.Equals, "x")
+		c.Assert(r.D, qt.IsNil)
+		c.Assert(r.E, qt.Equals, ColorGreen)
+		c.Assert(r.F, qt.Equals, 0)
+		c.Assert(r.H, qt.Equals, 3)
+		c.Assert(r.N, qt.Equals, Inner{X: 2})
+		c.Assert(NewInner().X, qt.Equals, 1)
+	}
+
+	func TestSetDefaults(t *testing.T) {
+		c := qt.New(t)
+		r := R{
+			A: 1,
+			B: "b",
+			D: "d",
+			F: 8,
+		}
+		r.SetDefaults()
+		c.Assert(r.A, qt.Equals, 5)
+		c.Assert(r.B, qt.Equals, "")
+		c.Assert(*r.C, qt.Equals, "x")
+		c.Assert(r.D, qt.IsNil)
+		c.Assert(r.F, qt.Equals, 8)
+	}
+	"""
+}
+
+tests: constructors: subtests: values: {
+	inData: {
+		A: 1
+		B: "b"
+		C: string: "c"
+		D: int: 4
+		E: "red"
+		F: 6
+		H: string: "h"
+		N: X: 7
+	}
+	outData: inData
+}
+
+tests: constructors: subtests: nulls: {
+	inData: {
+		A: 0
+		B: ""
+		C: null
+		D: null
+		E: "green"
+		F: 0
+		H: int: 0
+		N: X: 0
+	}
+	outData: inData
+}
@@ -0,0 +1,92 @@
+package roundtrip
+
+tests: getters: {
+	avrogoFlags: ["-getters", "-nullable", "sql"]
+	inSchema: {
+		type: "record"
+		name: "R"
+		fields: [{
+			name: "P"
+			type: ["null", "string"]
+			default: null
+		}, {
+			name: "B"
+			type: ["null", "bytes"]
+			default: null
+		}, {
+			name: "N"
+			type: ["null", {
+				type: "record"
+				name: "Inner"
+				fields: [{
+					name: "X"
+					type: "int"
+				}]
+			}]
+			default: null
+		}, {
+			name: "S"
+			type: "string"
+		}]
+	}
+	outSchema: inSchema
+	otherTests: """
+	package getters
+
+	import (
+		"database/sql"
+		"testing"
+
+		qt "github.com/frankban/quicktest"
+	)
+
+	func TestGetters(t *testing.T) {
+		c := qt.New(t)
+		var r R
+		_, ok := r.GetP()
+		c.Assert(ok, qt.Equals, false)
+		c.Assert(r.GetPOr("def"), qt.Equals, "def")
+		_, ok = r.GetB()
+		c.Assert(ok, qt.Equals, false)
+		c.Assert(r.GetBOr([]byte("def")), qt.DeepEquals, []byte("def"))
+		_, ok = r.GetN()
+		c.Assert(ok, qt.Equals, false)
+		c.Assert(r.GetNOr(Inner{X: 1}), qt.Equals, Inner{X: 1})
+
+		b := []byte("b")
+		r = R{
+			P: sql.NullString{String: "p", Valid: true},
+			B: &b,
+			N: &Inner{X: 2},
+		}
+		p, ok := r.GetP()
+		c.Assert(ok, qt.Equals, true)
+		c.Assert(p, qt.Equals, "p")
+		c.Assert(r.GetPOr("def"), qt.Equals, "p")
+		c.Assert(r.GetBOr(nil), qt.DeepEquals, []byte("b"))
+		n, ok := r.GetN()
+		c.Assert(ok, qt.Equals, true)
+		c.Assert(n, qt.Equals, Inner{X: 2})
+	}
+	"""
+}
+
+tests: getters: subtests: values: {
+	inData: {
+		P: string: "p"
+		B: bytes: "b"
+		N: Inner: X: 1
+		S: "s"
+	}
+	outData: inData
+}
+
+tests: getters: subtests: nulls: {
+	inData: {
+		P: null
+		B: null
+		N: null
+		S: ""
+	}
+	outData: inData
+}
@@ -0,0 +1,70 @@
+package roundtrip
+
+tests: nullableOptional: {
+	avrogoFlags: ["-getters", "-nullable", "optional"]
+	inSchema: {
+		type: "record"
+		name: "R"
+		fields: [{
+			name: "A"
+			type: ["null", "string"]
+			default: null
+		}, {
+			name: "B"
+			type: ["int", "null"]
+			default: 5
+		}, {
+			name: "C"
+			type: ["null", "bytes"]
+			default: null
+		}, {
+			name: "D"
+			type: ["null", "double"]
+			default: null
+		}]
+	}
+	outSchema: inSchema
+	otherTests: """
+	package nullableOptional
+
+	import (
+		"testing"
+
+		qt "github.com/frankban/quicktest"
+
+		"github.com/heetch/avro"
+	)
+
+	func TestOptional(t *testing.T) {
+		c := qt.New(t)
+		r := R{
+			A: avro.OptionalString{Value: "a", Valid: true},
+		}
+		a, ok := r.GetA()
+		c.Assert(ok, qt.Equals, true)
+		c.Assert(a, qt.Equals, "a")
+		c.Assert(r.GetBOr(99), qt.Equals, 99)
+		c.Assert(r.D, qt.Equals, avro.OptionalFloat64{})
+	}
+	"""
+}
+
+tests: nullableOptional: subtests: values: {
+	inData: {
+		A: string: "a"
+		B: int: 1
+		C: bytes: "c"
+		D: double: 1.5
+	}
+	outData: inData
+}
+
+tests: nullableOptional: subtests: nulls: {
+	inData: {
+		A: null
+		B: null
+		C: null
+		D: null
+	}
+	outData: inData
+}
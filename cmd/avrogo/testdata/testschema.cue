@@ -30,6 +30,8 @@ roundTripTest :: {
 	// generateError holds the error expected from invoking avrogo.
 	// If this is specified, there will be no generated test package.
 	generateError?: string
+	// avrogoFlags holds extra flags to pass to avrogo.
+	avrogoFlags?: [...string]
 	inData?:        _
 	outData?:       _
 	expectError?: [errorKind]: string
@@ -28,6 +28,58 @@ tests: unionInOut: subtests: withNull: {
 	outData: inData
 }
 
+tests: unionAccessors: {
+	avrogoFlags: ["-unionaccessors"]
+	inSchema: {
+		type: "record"
+		name: "PrimitiveUnionTestRecord"
+		fields: [{
+			name: "UnionField"
+			type: ["int", "long", "float", "double", "string", "boolean", "null"]
+			default: 1234
+		}]
+	}
+	outSchema: inSchema
+}
+
+tests: unionAccessors: subtests: withInt: {
+	inData: UnionField: int: 999
+	outData: inData
+}
+
+tests: unionAccessors: otherTests: """
+	package unionAccessors
+
+	import (
+		"testing"
+
+		qt "github.com/frankban/quicktest"
+	)
+
+	func TestUnionAccessors(t *testing.T) {
+		c := qt.New(t)
+		var r PrimitiveUnionTestRecord
+		c.Assert(r.UnionFieldIsNull(), qt.Equals, true)
+
+		r.SetUnionFieldLong(99)
+		c.Assert(r.UnionField, qt.Equals, int64(99))
+		c.Assert(r.UnionFieldIsNull(), qt.Equals, false)
+		v, ok := r.UnionFieldAsLong()
+		c.Assert(ok, qt.Equals, true)
+		c.Assert(v, qt.Equals, int64(99))
+		_, ok = r.UnionFieldAsInt()
+		c.Assert(ok, qt.Equals, false)
+
+		r.SetUnionFieldString("hello")
+		s, ok := r.UnionFieldAsString()
+		c.Assert(ok, qt.Equals, true)
+		c.Assert(s, qt.Equals, "hello")
+
+		r.SetUnionFieldNull()
+		c.Assert(r.UnionField, qt.IsNil)
+	}
+	"""
+
 tests: unionInSimpleOut: {
 	inSchema: {
 		type: "record"
@@ -0,0 +1,262 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/rogpeppe/gogen-avro/v7/parser"
+	"github.com/rogpeppe/gogen-avro/v7/schema"
+)
+
+// templateFilesFlag implements the -template flag.
+// It holds the template files in the order they were given.
+type templateFilesFlag []string
+
+func (f *templateFilesFlag) String() string {
+	return strings.Join(*f, ",")
+}
+
+func (f *templateFilesFlag) Set(s string) error {
+	*f = append(*f, s)
+	return nil
+}
+
+// templateModel holds the data that user templates specified with
+// the -template flag are executed with. There's one for each
+// output package.
+type templateModel struct {
+	// Package holds the name of the Go package.
+	Package string
+
+	// Records holds the records generated in the package,
+	// sorted by full Avro name.
+	Records []*templateRecord
+
+	// Enums holds the enums generated in the package,
+	// sorted by full Avro name.
+	Enums []*templateEnum
+}
+
+// templateRecord holds information on a generated record.
+type templateRecord struct {
+	// Name holds the full Avro name of the record.
+	Name string
+
+	// GoName holds the name of the generated Go type.
+	GoName string
+
+	// Doc holds the record's documentation.
+	Doc string
+
+	// Schema holds the record's Avro schema in JSON format.
+	Schema string
+
+	Fields []*templateField
+}
+
+// templateField holds information on a field of a generated record.
+type templateField struct {
+	// Name holds the Avro name of the field.
+	Name string
+
+	// GoName holds the name of the Go struct field.
+	GoName string
+
+	// Doc holds the field's documentation.
+	Doc string
+
+	// Type holds the Avro type of the field: the name of a primitive
+	// type, the full name of a named type, or "array", "map" or "union".
+	Type string
+
+	// LogicalType holds the logical type of the field, if any.
+	LogicalType string
+
+	// GoType holds the Go type of the struct field.
+	GoType string
+
+	// Nullable holds whether the field's type is a union that
+	// includes null.
+	Nullable bool
+
+	// HasDefault holds whether the field has a default value,
+	// and Default holds the value, as decoded from JSON.
+	HasDefault bool
+	Default    interface{}
+}
+
+// templateEnum holds information on a generated enum.
+type templateEnum struct {
+	// Name holds the full Avro name of the enum.
+	Name string
+
+	// GoName holds the name of the generated Go type.
+	GoName string
+
+	// Doc holds the enum's documentation.
+	Doc string
+
+	Symbols []string
+}
+
+// userTemplateFuncs holds the functions available
+// to user templates.
+var userTemplateFuncs = template.FuncMap{
+	"lower": strings.ToLower,
+	"upper": strings.ToUpper,
+	"snake": snakeCase,
+	"join":  strings.Join,
+	"json": func(x interface{}) (string, error) {
+		data, err := json.Marshal(x)
+		return string(data), err
+	},
+}
+
+// executeUserTemplates executes each template file given
+// with the -template flag for pkg and writes the result
+// to a file in the package directory named after the
+// template file without its .tmpl extension.
+func executeUserTemplates(pkg *outputPackage, ns *parser.Namespace) error {
+	if len(templateFiles) == 0 {
+		return nil
+	}
+	model, err := newTemplateModel(pkg, ns)
+	if err != nil {
+		return err
+	}
+	for _, f := range templateFiles {
+		tmpl, err := template.New(filepath.Base(f)).Funcs(userTemplateFuncs).ParseFiles(f)
+		if err != nil {
+			return fmt.Errorf("cannot parse template: %v", err)
+		}
+		var buf bytes.Buffer
+		if err := tmpl.Execute(&buf, model); err != nil {
+			return fmt.Errorf("cannot execute template: %v", err)
+		}
+		outFile := strings.TrimSuffix(filepath.Base(f), ".tmpl")
+		if err := writeOutputFile(pkg, outFile, buf.Bytes()); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// newTemplateModel returns the data that user templates
+// are executed with for the package pkg.
+func newTemplateModel(pkg *outputPackage, ns *parser.Namespace) (*templateModel, error) {
+	gc := &generateContext{
+		imports:  make(map[string]string),
+		extTypes: pkg.extTypes,
+		opts: generateOptions{
+			logicalTypes: logicalTypes,
+			sqlNull:      *nullableFlag == "sql",
+			optionalNull: *nullableFlag == "optional",
+		},
+	}
+	model := &templateModel{
+		Package: pkg.name,
+	}
+	for _, name := range sortedDefinitionNames(ns) {
+		def := ns.Definitions[name]
+		if name != def.AvroName() {
+			// It's an alias.
+			continue
+		}
+		if _, ok := pkg.extTypes[name]; ok {
+			continue
+		}
+		switch def := def.(type) {
+		case *schema.RecordDefinition:
+			r, err := gc.templateRecord(def)
+			if err != nil {
+				return nil, err
+			}
+			model.Records = append(model.Records, r)
+		case *schema.EnumDefinition:
+			model.Enums = append(model.Enums, &templateEnum{
+				Name:    name.String(),
+				GoName:  defName(def),
+				Doc:     def.Doc(),
+				Symbols: def.Symbols(),
+			})
+		}
+	}
+	return model, nil
+}
+
+func (gc *generateContext) templateRecord(t *schema.RecordDefinition) (*templateRecord, error) {
+	schemaStr, err := t.Schema()
+	if err != nil {
+		return nil, err
+	}
+	r := &templateRecord{
+		Name:   t.AvroName().String(),
+		GoName: defName(t),
+		Doc:    t.Doc(),
+		Schema: schemaStr,
+	}
+	for _, f := range t.Fields() {
+		goName, err := fieldGoName(f.Name())
+		if err != nil {
+			return nil, err
+		}
+		tf := &templateField{
+			Name:        f.Name(),
+			GoName:      goName,
+			Doc:         f.Doc(),
+			LogicalType: logicalType(f.Type()),
+			GoType:      gc.GoTypeOf(f.Type()).GoType,
+			HasDefault:  f.HasDefault(),
+		}
+		if tf.HasDefault {
+			tf.Default = f.Default()
+		}
+		tf.Type = avroTypeName(f.Type())
+		if u, ok := f.Type().(*schema.UnionField); ok {
+			for _, t := range u.AvroTypes() {
+				if isNullField(t) {
+					tf.Nullable = true
+				}
+			}
+		}
+		r.Fields = append(r.Fields, tf)
+	}
+	return r, nil
+}
+
+// avroTypeName returns the name of the Avro type at: the name of
+// a primitive type, the full name of a named type, or "array",
+// "map" or "union".
+func avroTypeName(at schema.AvroType) string {
+	switch at := at.(type) {
+	case *schema.NullField:
+		return "null"
+	case *schema.BoolField:
+		return "boolean"
+	case *schema.IntField:
+		return "int"
+	case *schema.LongField:
+		return "long"
+	case *schema.FloatField:
+		return "float"
+	case *schema.DoubleField:
+		return "double"
+	case *schema.BytesField:
+		return "bytes"
+	case *schema.StringField:
+		return "string"
+	case *schema.ArrayField:
+		return "array"
+	case *schema.MapField:
+		return "map"
+	case *schema.UnionField:
+		return "union"
+	case *schema.Reference:
+		return at.TypeName.String()
+	}
+	return ""
+}
@@ -0,0 +1,218 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rogpeppe/gogen-avro/v7/schema"
+)
+
+// ValidateMethod returns the source of the Validate method for the
+// given enum or record definition, or the empty string if validation
+// methods aren't enabled or can't be generated for it.
+func (gc *generateContext) ValidateMethod(def schema.Definition) (string, error) {
+	if !gc.opts.validate {
+		return "", nil
+	}
+	switch def := def.(type) {
+	case *schema.EnumDefinition:
+		return fmt.Sprintf(`
+// Validate returns an error if e isn't one of
+// the symbols defined for %[1]s.
+func (e %[1]s) Validate() error {
+	if !e.Valid() {
+		return %[2]s.Errorf("invalid value %%d for %[1]s", e)
+	}
+	return nil
+}
+`, defName(def), gc.addImport("fmt")), nil
+	case *schema.RecordDefinition:
+		if !gc.canValidate(def) {
+			return "", nil
+		}
+		g := &validateGen{
+			gc: gc,
+		}
+		g.printf(`
+// Validate returns an error if r holds values that
+// can't be encoded with the schema of %[1]s.
+func (r %[1]s) Validate() error {
+`, defName(def))
+		for _, f := range def.Fields() {
+			name, err := fieldGoName(f.Name())
+			if err != nil {
+				return "", err
+			}
+			g.validate(f.Type(), "r."+name, f.Name(), nil)
+		}
+		g.printf("return nil\n}\n")
+		return g.w.String(), nil
+	}
+	return "", nil
+}
+
+// canValidate reports whether a Validate method can be
+// generated for the record t. That's not possible if one
+// of its Go field names would clash with the method.
+func (gc *generateContext) canValidate(t *schema.RecordDefinition) bool {
+	for _, f := range t.Fields() {
+		name, err := fieldGoName(f.Name())
+		if err != nil || name == "Validate" {
+			return false
+		}
+	}
+	return true
+}
+
+// hasValidate reports whether values of Avro type at
+// have a generated Validate method.
+func (gc *generateContext) hasValidate(at schema.AvroType) bool {
+	ref, ok := at.(*schema.Reference)
+	if !ok {
+		return false
+	}
+	if _, ok := gc.extTypes[ref.TypeName]; ok {
+		return false
+	}
+	switch def := ref.Def.(type) {
+	case *schema.EnumDefinition:
+		return true
+	case *schema.RecordDefinition:
+		return gc.canValidate(def)
+	}
+	return false
+}
+
+// needsValidation reports whether Go values of Avro type at
+// can hold values that aren't valid according to the schema.
+// Fixed types don't need checking because the length of
+// their Go array type is always right.
+func (gc *generateContext) needsValidation(at schema.AvroType) bool {
+	if _, ok := gc.logicalGoType(at); ok {
+		return false
+	}
+	switch at := at.(type) {
+	case *schema.ArrayField:
+		return gc.needsValidation(at.ItemType())
+	case *schema.MapField:
+		return gc.needsValidation(at.ItemType())
+	case *schema.UnionField:
+		if gc.GoTypeOf(at).GoType == "interface{}" {
+			// The dynamic type of the value must be checked.
+			return true
+		}
+		for _, t := range at.AvroTypes() {
+			if gc.needsValidation(t) {
+				return true
+			}
+		}
+		return false
+	case *schema.Reference:
+		return gc.hasValidate(at)
+	}
+	return false
+}
+
+// validateGen generates the body of the Validate method
+// for a record.
+type validateGen struct {
+	gc *generateContext
+	w  strings.Builder
+	// n is used to generate unique names for local variables.
+	n int
+}
+
+func (g *validateGen) printf(f string, a ...interface{}) {
+	fmt.Fprintf(&g.w, f, a...)
+}
+
+// errorf generates code to return an error made with
+// the given format and argument expressions.
+func (g *validateGen) errorf(format string, args ...string) {
+	g.printf("return %s.Errorf(%q, %s)\n", g.gc.addImport("fmt"), format, strings.Join(args, ", "))
+}
+
+// newVar returns a new local variable name with the given prefix.
+func (g *validateGen) newVar(prefix string) string {
+	g.n++
+	return fmt.Sprintf("%s%d", prefix, g.n)
+}
+
+// validate generates code to check the value of the Go expression v,
+// of Avro type at. The path format and its arguments describe
+// the location of the value for use in error messages.
+func (g *validateGen) validate(at schema.AvroType, v string, path string, args []string) {
+	if !g.gc.needsValidation(at) {
+		return
+	}
+	switch at := at.(type) {
+	case *schema.ArrayField:
+		i := g.newVar("i")
+		g.printf("for %s := range %s {\n", i, v)
+		g.validate(at.ItemType(), v+"["+i+"]", path+"[%d]", append(args[:len(args):len(args)], i))
+		g.printf("}\n")
+	case *schema.MapField:
+		k, x := g.newVar("k"), g.newVar("x")
+		g.printf("for %s, %s := range %s {\n", k, x, v)
+		g.validate(at.ItemType(), x, path+"[%q]", append(args[:len(args):len(args)], k))
+		g.printf("}\n")
+	case *schema.UnionField:
+		g.validateUnion(at, v, path, args)
+	case *schema.Reference:
+		g.printf("if err := %s.Validate(); err != nil {\n", v)
+		g.errorf(path+": %v", append(args[:len(args):len(args)], "err")...)
+		g.printf("}\n")
+	}
+}
+
+func (g *validateGen) validateUnion(at *schema.UnionField, v string, path string, args []string) {
+	types := at.AvroTypes()
+	info := g.gc.GoTypeOf(at)
+	if info.GoType != "interface{}" {
+		// It's a union of null and one other type. Only
+		// pointer types can need validation.
+		index := 0
+		if isNullField(types[0]) {
+			index = 1
+		}
+		g.printf("if %s != nil {\n", v)
+		g.validate(types[index], "(*"+v+")", path, args)
+		g.printf("}\n")
+		return
+	}
+	x := g.newVar("x")
+	g.printf("switch %s := %s.(type) {\n", x, v)
+	// Types that don't need validation are all
+	// listed in a single case.
+	var plain []string
+	var checked []schema.AvroType
+	seen := make(map[string]bool)
+	for _, t := range types {
+		goType := g.gc.typegenName("Null")
+		if !isNullField(t) {
+			goType = g.gc.GoTypeOf(t).GoType
+		}
+		if seen[goType] {
+			continue
+		}
+		seen[goType] = true
+		switch {
+		case isNullGoType(goType):
+			plain = append(plain, "nil", goType)
+		case g.gc.needsValidation(t):
+			checked = append(checked, t)
+		default:
+			plain = append(plain, goType)
+		}
+	}
+	if len(plain) > 0 {
+		g.printf("case %s:\n", strings.Join(plain, ", "))
+	}
+	for _, t := range checked {
+		g.printf("case %s:\n", g.gc.GoTypeOf(t).GoType)
+		g.validate(t, x, path, args)
+	}
+	g.printf("default:\n")
+	g.errorf(path+": invalid type %T in union", append(args[:len(args):len(args)], x)...)
+	g.printf("}\n")
+}
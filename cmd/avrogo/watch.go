@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+)
+
+// watchInterval holds how often the -watch flag checks
+// the schema files for changes.
+const watchInterval = 500 * time.Millisecond
+
+// watch generates code for the schema files named by args, as
+// expanded by schemaFiles, then regenerates it each time one of
+// them changes, is added or is removed. It only returns if the
+// files can't be listed.
+// Errors from generating code are printed and then ignored until
+// the files next change, so a schema that's being edited
+// doesn't stop the watch.
+func watch(args []string) error {
+	var stamps map[string]fileStamp
+	for {
+		files, err := schemaFiles(args)
+		if err != nil {
+			return err
+		}
+		newStamps := fileStamps(files)
+		if stamps == nil || !sameStamps(stamps, newStamps) {
+			stamps = newStamps
+			if len(files) == 0 {
+				fmt.Fprintf(os.Stderr, "avrogo: no schema files found\n")
+			} else if err := generateFiles(files); err != nil {
+				fmt.Fprintf(os.Stderr, "avrogo: %v\n", err)
+			} else {
+				fmt.Fprintf(os.Stderr, "avrogo: generated code for %d schema files\n", len(files))
+			}
+		}
+		time.Sleep(watchInterval)
+	}
+}
+
+// fileStamp holds the information used to tell
+// whether a file has changed.
+type fileStamp struct {
+	modTime time.Time
+	size    int64
+}
+
+// fileStamps returns the current stamp of each of the given
+// files. Files that can't be read have the zero stamp, so
+// they're regenerated when they appear again.
+func fileStamps(files []string) map[string]fileStamp {
+	stamps := make(map[string]fileStamp)
+	for _, f := range files {
+		var stamp fileStamp
+		if info, err := os.Stat(f); err == nil {
+			stamp = fileStamp{
+				modTime: info.ModTime(),
+				size:    info.Size(),
+			}
+		}
+		stamps[f] = stamp
+	}
+	return stamps
+}
+
+// sameStamps reports whether a and b hold the
+// same files with the same stamps.
+func sameStamps(a, b map[string]fileStamp) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for f, stamp := range a {
+		stamp1, ok := b[f]
+		if !ok || !stamp1.modTime.Equal(stamp.modTime) || stamp1.size != stamp.size {
+			return false
+		}
+	}
+	return true
+}
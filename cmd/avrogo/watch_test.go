@@ -0,0 +1,41 @@
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestFileStamps(t *testing.T) {
+	c := qt.New(t)
+	dir := c.Mkdir()
+	a := filepath.Join(dir, "a.avsc")
+	err := ioutil.WriteFile(a, []byte(`"int"`), 0666)
+	c.Assert(err, qt.Equals, nil)
+
+	stamps := fileStamps([]string{a})
+	c.Assert(sameStamps(stamps, fileStamps([]string{a})), qt.Equals, true)
+
+	// A change of size is noticed even when the
+	// modification time doesn't change.
+	err = ioutil.WriteFile(a, []byte(`"long"`), 0666)
+	c.Assert(err, qt.Equals, nil)
+	mtime := stamps[a].modTime
+	err = os.Chtimes(a, mtime, mtime)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(sameStamps(stamps, fileStamps([]string{a})), qt.Equals, false)
+
+	stamps = fileStamps([]string{a})
+	err = os.Chtimes(a, mtime.Add(time.Second), mtime.Add(time.Second))
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(sameStamps(stamps, fileStamps([]string{a})), qt.Equals, false)
+
+	// Added and removed files are noticed too.
+	b := filepath.Join(dir, "b.avsc")
+	c.Assert(sameStamps(stamps, fileStamps([]string{a, b})), qt.Equals, false)
+	c.Assert(sameStamps(fileStamps([]string{a, b}), fileStamps([]string{a})), qt.Equals, false)
+}
@@ -0,0 +1,96 @@
+// The avrotranscode command converts a stream of Avro values
+// from one representation to another. See avro.NewTranscoder
+// for details of the supported formats.
+package main
+
+import (
+	stdflag "flag"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+
+	"github.com/heetch/avro"
+)
+
+var flag = stdflag.NewFlagSet("", stdflag.ContinueOnError)
+
+var (
+	schemaFile = flag.String("schema", "", "file holding the Avro schema of the values (required)")
+	fromFormat = flag.String("from", "framed", "format of the input (framed, single-object, ocf or jsonl)")
+	toFormat   = flag.String("to", "jsonl", "format of the output (framed, single-object, ocf or jsonl)")
+	outFile    = flag.String("o", "", "output filename (default stdout)")
+)
+
+func main() {
+	os.Exit(main1())
+}
+
+// main1 is the internal version of main that returns a status
+// code instead of calling os.Exit.
+func main1() int {
+	flag.Usage = func() {
+		fmt.Fprintf(os.Stderr, "usage: avrotranscode -schema file.avsc [flags] [file]\n")
+		flag.PrintDefaults()
+	}
+	if flag.Parse(os.Args[1:]) != nil {
+		return 2
+	}
+	if *schemaFile == "" || flag.NArg() > 1 {
+		flag.Usage()
+		return 2
+	}
+	if err := transcode(flag.Arg(0), *outFile); err != nil {
+		fmt.Fprintf(os.Stderr, "avrotranscode: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// transcode transcodes the values in inFile (or stdin if inFile is empty)
+// and writes them to outFile (or stdout if outFile is empty).
+func transcode(inFile, outFile string) error {
+	data, err := ioutil.ReadFile(*schemaFile)
+	if err != nil {
+		return err
+	}
+	t, err := avro.ParseType(string(data))
+	if err != nil {
+		return fmt.Errorf("cannot parse schema from %q: %v", *schemaFile, err)
+	}
+	from, err := avro.ParseTranscodeFormat(*fromFormat)
+	if err != nil {
+		return err
+	}
+	to, err := avro.ParseTranscodeFormat(*toFormat)
+	if err != nil {
+		return err
+	}
+	var in io.Reader = os.Stdin
+	if inFile != "" {
+		f, err := os.Open(inFile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+	var out io.Writer = os.Stdout
+	if outFile != "" {
+		f, err := os.Create(outFile)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		out = f
+	}
+	tc, err := avro.NewTranscoder(in, out, avro.TranscoderOptions{
+		Type: t,
+		From: from,
+		To:   to,
+	})
+	if err != nil {
+		return err
+	}
+	return tc.Transcode()
+}
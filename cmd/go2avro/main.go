@@ -17,6 +17,8 @@ import (
 
 var flag = stdflag.NewFlagSet("", stdflag.ContinueOnError)
 
+var dirFlag = flag.String("d", "", "write a schema file for each annotated type in the given packages to this directory")
+
 func main() {
 	os.Exit(main1())
 }
@@ -24,6 +26,7 @@ func main() {
 func main1() int {
 	flag.Usage = func() {
 		fmt.Fprintf(os.Stderr, `usage: go2avro [package.]type
+       go2avro -d dir [package...]
 
 This command prints the Avro schema for a given Go type on the
 standard output.
@@ -35,11 +38,34 @@ be generated for exported Go types.
 For example:
 
 	go2avro foo.com/bar/somepkg.Foo
+
+With the -d flag, go2avro instead looks in the given packages
+(by default, the package in the current directory) for exported
+struct types with an avro:schema comment directive, like this:
+
+	// Order is an order.
+	//
+	//avro:schema
+	type Order struct {
+		...
+	}
+
+and writes the schema for each of them to a file in dir named after
+its full Avro name, such as com.example.Order.avsc. Types with the
+same Avro name must have the same schema, and their schema is only
+written once.
 `[1:])
 	}
 	if flag.Parse(os.Args[1:]) != nil {
 		return 2
 	}
+	if *dirFlag != "" {
+		if err := writeSchemas(*dirFlag, flag.Args()); err != nil {
+			fmt.Fprintf(os.Stderr, "go2avro: %v\n", err)
+			return 1
+		}
+		return 0
+	}
 	if len(flag.Args()) != 1 {
 		flag.Usage()
 		return 2
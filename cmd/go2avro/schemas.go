@@ -0,0 +1,260 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/heetch/avro"
+)
+
+// schemaDirective marks the Go types that go2avro -d
+// writes schemas for.
+const schemaDirective = "//avro:schema"
+
+// annotatedType holds a Go type marked with schemaDirective.
+type annotatedType struct {
+	// PkgPath holds the import path of the type's package.
+	PkgPath string
+
+	// Name holds the name of the type.
+	Name string
+}
+
+func (t annotatedType) String() string {
+	return t.PkgPath + "." + t.Name
+}
+
+// writeSchemas writes a schema file to dir for each annotated
+// type in the given packages.
+func writeSchemas(dir string, pkgs []string) error {
+	types, err := annotatedTypes(pkgs)
+	if err != nil {
+		return err
+	}
+	if len(types) == 0 {
+		return fmt.Errorf("no types with %s directive found", schemaDirective)
+	}
+	schemas, err := schemasForTypes(types)
+	if err != nil {
+		return err
+	}
+	var names []string
+	byName := make(map[string]string)
+	from := make(map[string]annotatedType)
+	for i, s := range schemas {
+		t, err := avro.ParseType(s)
+		if err != nil {
+			return fmt.Errorf("invalid schema for %s: %v", types[i], err)
+		}
+		name := t.Name()
+		if name == "" {
+			return fmt.Errorf("%s does not have a named Avro type", types[i])
+		}
+		if s0, ok := byName[name]; ok {
+			if s0 != s {
+				return fmt.Errorf("%s and %s have different schemas for Avro name %s", from[name], types[i], name)
+			}
+			continue
+		}
+		names = append(names, name)
+		byName[name] = s
+		from[name] = types[i]
+	}
+	if err := os.MkdirAll(dir, 0777); err != nil {
+		return fmt.Errorf("cannot create output directory: %v", err)
+	}
+	for _, name := range names {
+		var buf bytes.Buffer
+		if err := json.Indent(&buf, []byte(byName[name]), "", "    "); err != nil {
+			return fmt.Errorf("cannot indent JSON: %v", err)
+		}
+		buf.WriteString("\n")
+		if err := ioutil.WriteFile(filepath.Join(dir, name+".avsc"), buf.Bytes(), 0666); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// annotatedTypes returns all the types marked with schemaDirective
+// in the given packages, in the order they're declared.
+func annotatedTypes(pkgs []string) ([]annotatedType, error) {
+	var stdout bytes.Buffer
+	cmd := exec.Command("go", append([]string{"list", "-json"}, pkgs...)...)
+	cmd.Stdout = &stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		return nil, fmt.Errorf("cannot list packages: %v", err)
+	}
+	var types []annotatedType
+	dec := json.NewDecoder(&stdout)
+	for {
+		var pkg struct {
+			ImportPath string
+			Dir        string
+			GoFiles    []string
+		}
+		if err := dec.Decode(&pkg); err == io.EOF {
+			break
+		} else if err != nil {
+			return nil, fmt.Errorf("cannot decode package list: %v", err)
+		}
+		fset := token.NewFileSet()
+		for _, f := range pkg.GoFiles {
+			file, err := parser.ParseFile(fset, filepath.Join(pkg.Dir, f), nil, parser.ParseComments)
+			if err != nil {
+				return nil, err
+			}
+			for _, decl := range file.Decls {
+				decl, ok := decl.(*ast.GenDecl)
+				if !ok || decl.Tok != token.TYPE {
+					continue
+				}
+				for _, spec := range decl.Specs {
+					spec := spec.(*ast.TypeSpec)
+					doc := spec.Doc
+					if doc == nil && len(decl.Specs) == 1 {
+						doc = decl.Doc
+					}
+					if !hasSchemaDirective(doc) {
+						continue
+					}
+					if !spec.Name.IsExported() {
+						return nil, fmt.Errorf("%s: cannot generate schema for unexported type %s", fset.Position(spec.Pos()), spec.Name.Name)
+					}
+					types = append(types, annotatedType{
+						PkgPath: pkg.ImportPath,
+						Name:    spec.Name.Name,
+					})
+				}
+			}
+		}
+	}
+	return types, nil
+}
+
+func hasSchemaDirective(doc *ast.CommentGroup) bool {
+	if doc == nil {
+		return false
+	}
+	for _, c := range doc.List {
+		if strings.TrimSpace(c.Text) == schemaDirective {
+			return true
+		}
+	}
+	return false
+}
+
+// schemasForTypes returns the Avro schema for each of the
+// given types, by building and running a temporary program
+// that calls avro.TypeOf.
+func schemasForTypes(types []annotatedType) ([]string, error) {
+	p := schemasTmplParams{
+		ImportIDs: make(map[string]string),
+	}
+	for _, t := range types {
+		if _, ok := p.ImportIDs[t.PkgPath]; !ok {
+			p.ImportIDs[t.PkgPath] = fmt.Sprintf("pkg%d", len(p.Imports))
+			p.Imports = append(p.Imports, t.PkgPath)
+		}
+	}
+	p.Types = types
+	var codeBuf bytes.Buffer
+	if err := schemasTmpl.Execute(&codeBuf, p); err != nil {
+		return nil, fmt.Errorf("cannot execute template: %v", err)
+	}
+	code, err := format.Source(codeBuf.Bytes())
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "invalid temporary Go code:\n-------\n%s-----\n", codeBuf.String())
+		return nil, fmt.Errorf("invalid template code: %v", err)
+	}
+	exe, err := buildGo(code)
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(exe)
+
+	var outBuf bytes.Buffer
+	var errBuf bytes.Buffer
+	cmd := exec.Command(exe)
+	cmd.Stdout = &outBuf
+	cmd.Stderr = &errBuf
+	if err := cmd.Run(); err != nil {
+		if errBuf.Len() > 0 {
+			return nil, fmt.Errorf("cannot get Avro type: %s", strings.TrimSpace(errBuf.String()))
+		}
+		return nil, err
+	}
+	var schemas []string
+	if err := json.Unmarshal(outBuf.Bytes(), &schemas); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal schemas: %v", err)
+	}
+	if len(schemas) != len(types) {
+		return nil, fmt.Errorf("unexpected schema count, got %d want %d", len(schemas), len(types))
+	}
+	return schemas, nil
+}
+
+type schemasTmplParams struct {
+	Imports   []string
+	ImportIDs map[string]string
+	Types     []annotatedType
+}
+
+var schemasTmpl = template.Must(template.New("").Parse(`
+// Code generated by avrogen. DO NOT EDIT.
+
+// This should be treated as a temporary file. Remove it if you find it.
+
+// +build ignore
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"reflect"
+
+	"github.com/heetch/avro"
+{{range $imp := .Imports}}
+	{{index $.ImportIDs $imp}} {{printf "%q" $imp}}
+{{- end}}
+)
+
+var types = []interface{}{
+{{- range .Types}}
+	new({{index $.ImportIDs .PkgPath}}.{{.Name}}),
+{{- end}}
+}
+
+func main() {
+	var schemas []string
+	for _, x := range types {
+		t := reflect.TypeOf(x).Elem()
+		at, err := avro.TypeOf(reflect.Zero(t).Interface())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "cannot get type for %s: %v\n", t, err)
+			os.Exit(1)
+		}
+		schemas = append(schemas, at.String())
+	}
+	data, err := json.Marshal(schemas)
+	if err != nil {
+		panic(err)
+	}
+	fmt.Printf("%s\n", data)
+}
+`))
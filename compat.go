@@ -1,5 +1,7 @@
 package avro
 
+import "fmt"
+
 // CompatMode defines a compatiblity mode used for checking Avro
 // type compatibility.
 type CompatMode int
@@ -37,3 +39,93 @@ func (m CompatMode) String() string {
 	}
 	return s
 }
+
+// ParseCompatMode returns the compatibility mode with the given
+// name, as returned by CompatMode.String. For example
+// ParseCompatMode("BACKWARD_TRANSITIVE") returns BackwardTransitive.
+func ParseCompatMode(s string) (CompatMode, error) {
+	for _, m := range []CompatMode{
+		0,
+		Backward,
+		Forward,
+		Full,
+		BackwardTransitive,
+		ForwardTransitive,
+		FullTransitive,
+	} {
+		if m.String() == s {
+			return m, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown compatibility mode %q", s)
+}
+
+// MarshalText implements encoding.TextMarshaler
+// by returning m.String.
+func (m CompatMode) MarshalText() ([]byte, error) {
+	if m.String() == "UNKNOWN" {
+		return nil, fmt.Errorf("invalid compatibility mode %d", int(m))
+	}
+	return []byte(m.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler
+// by calling ParseCompatMode.
+func (m *CompatMode) UnmarshalText(data []byte) error {
+	m1, err := ParseCompatMode(string(data))
+	if err != nil {
+		return err
+	}
+	*m = m1
+	return nil
+}
+
+// Set implements flag.Value by calling ParseCompatMode,
+// so a *CompatMode can be used with flag.Var.
+func (m *CompatMode) Set(s string) error {
+	return m.UnmarshalText([]byte(s))
+}
+
+// Check checks whether newType is compatible with the previous
+// versions of a schema in oldTypes, ordered from oldest to newest,
+// according to the compatibility mode m. It returns nil if
+// the types are compatible.
+//
+// Backward compatibility means that newType can be used to read
+// data written with the old types; forward compatibility means that
+// the old types can be used to read data written with newType.
+// When m is not transitive, only the last of oldTypes is checked.
+//
+// The resolution rules are those of the Avro specification, which are
+// also used by the Confluent schema registry, including enum defaults,
+// union members, fixed sizes, aliases and primitive type promotion.
+//
+// If the types are not compatible, the returned error will
+// wrap a *ResolutionError describing all the problems found
+// with the most recent incompatible type.
+func (m CompatMode) Check(newType *Type, oldTypes ...*Type) error {
+	start := 0
+	if m&Transitive == 0 && len(oldTypes) > 0 {
+		start = len(oldTypes) - 1
+	}
+	// Check the most recent types first as they're the most
+	// relevant to the new type.
+	for i := len(oldTypes) - 1; i >= start; i-- {
+		oldType := oldTypes[i]
+		if m&Backward != 0 {
+			if mismatches := resolutionMismatches(oldType.avroType, newType.avroType); len(mismatches) > 0 {
+				return fmt.Errorf("new type cannot read data written with old type %d: %w", i, &ResolutionError{
+					Mismatches: mismatches,
+				})
+			}
+		}
+		if m&Forward != 0 {
+			if mismatches := resolutionMismatches(newType.avroType, oldType.avroType); len(mismatches) > 0 {
+				return fmt.Errorf("old type %d cannot read data written with new type: %w", i, &ResolutionError{
+					Mismatches: mismatches,
+				})
+			}
+		}
+	}
+	return nil
+}
@@ -1,6 +1,10 @@
 package avro_test
 
 import (
+	"encoding/json"
+	"errors"
+	"flag"
+	"io/ioutil"
 	"testing"
 
 	qt "github.com/frankban/quicktest"
@@ -30,3 +34,274 @@ func TestCompatString(t *testing.T) {
 		})
 	}
 }
+
+func TestParseCompatMode(t *testing.T) {
+	c := qt.New(t)
+	for _, test := range compatStringTests {
+		c.Run(test.s, func(c *qt.C) {
+			m, err := avro.ParseCompatMode(test.s)
+			if test.s == "UNKNOWN" {
+				c.Assert(err, qt.ErrorMatches, `unknown compatibility mode "UNKNOWN"`)
+				return
+			}
+			c.Assert(err, qt.Equals, nil)
+			c.Assert(m, qt.Equals, test.m)
+		})
+	}
+}
+
+func TestCompatModeText(t *testing.T) {
+	c := qt.New(t)
+	var cfg struct {
+		Mode avro.CompatMode
+	}
+	err := json.Unmarshal([]byte(`{"Mode": "FORWARD_TRANSITIVE"}`), &cfg)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(cfg.Mode, qt.Equals, avro.ForwardTransitive)
+
+	data, err := json.Marshal(cfg)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(string(data), qt.Equals, `{"Mode":"FORWARD_TRANSITIVE"}`)
+
+	err = json.Unmarshal([]byte(`{"Mode": "SIDEWAYS"}`), &cfg)
+	c.Assert(err, qt.ErrorMatches, `unknown compatibility mode "SIDEWAYS"`)
+
+	_, err = json.Marshal(struct{ Mode avro.CompatMode }{1 << 10})
+	c.Assert(err, qt.ErrorMatches, `json: error calling MarshalText for type .*: invalid compatibility mode 1024`)
+}
+
+func TestCompatModeFlag(t *testing.T) {
+	c := qt.New(t)
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	fs.SetOutput(ioutil.Discard)
+	var m avro.CompatMode
+	fs.Var(&m, "compat", "compatibility mode")
+	err := fs.Parse([]string{"-compat", "FULL"})
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(m, qt.Equals, avro.Full)
+
+	err = fs.Parse([]string{"-compat", "other"})
+	c.Assert(err, qt.ErrorMatches, `invalid value "other" for flag -compat: unknown compatibility mode "other"`)
+}
+
+// The following schemas mirror those used in the Confluent
+// schema registry compatibility tests.
+var (
+	compatSchema1 = `{"type": "record", "name": "myrecord", "fields": [
+		{"type": "string", "name": "f1"}
+	]}`
+	// compatSchema2 adds a field with a default.
+	compatSchema2 = `{"type": "record", "name": "myrecord", "fields": [
+		{"type": "string", "name": "f1"},
+		{"type": "string", "name": "f2", "default": "foo"}
+	]}`
+	// compatSchema3 adds a field without a default.
+	compatSchema3 = `{"type": "record", "name": "myrecord", "fields": [
+		{"type": "string", "name": "f1"},
+		{"type": "string", "name": "f2"}
+	]}`
+	// compatSchema4 renames a field using an alias.
+	compatSchema4 = `{"type": "record", "name": "myrecord", "fields": [
+		{"type": "string", "name": "f1_new", "aliases": ["f1"]}
+	]}`
+	// compatSchema6 changes a field to a union.
+	compatSchema6 = `{"type": "record", "name": "myrecord", "fields": [
+		{"type": ["null", "string"], "name": "f1", "doc": "doc of f1"}
+	]}`
+	// compatSchema7 adds a member to the union.
+	compatSchema7 = `{"type": "record", "name": "myrecord", "fields": [
+		{"type": ["null", "string", "int"], "name": "f1", "doc": "doc of f1"}
+	]}`
+	// compatSchema8 adds two fields with defaults.
+	compatSchema8 = `{"type": "record", "name": "myrecord", "fields": [
+		{"type": "string", "name": "f1"},
+		{"type": "string", "name": "f2", "default": "foo"},
+		{"type": "string", "name": "f3", "default": "bar"}
+	]}`
+	compatIntRecord = `{"type": "record", "name": "myrecord", "fields": [
+		{"type": "int", "name": "f1"}
+	]}`
+	compatLongRecord = `{"type": "record", "name": "myrecord", "fields": [
+		{"type": "long", "name": "f1"}
+	]}`
+	compatOtherRecord = `{"type": "record", "name": "otherrecord", "fields": [
+		{"type": "string", "name": "f1"}
+	]}`
+	compatAliasedRecord = `{"type": "record", "name": "otherrecord", "aliases": ["myrecord"], "fields": [
+		{"type": "string", "name": "f1"}
+	]}`
+	compatNamespacedRecord = `{"type": "record", "name": "myrecord", "namespace": "com.example", "fields": [
+		{"type": "string", "name": "f1"}
+	]}`
+	compatNamespacedAliasedRecord = `{"type": "record", "name": "otherrecord", "namespace": "com.example", "aliases": ["org.example.myrecord"], "fields": [
+		{"type": "string", "name": "f1"}
+	]}`
+	compatEnum1       = `{"type": "enum", "name": "E", "symbols": ["A", "B"]}`
+	compatEnum2       = `{"type": "enum", "name": "E", "symbols": ["A", "B", "C"]}`
+	compatEnumDefault = `{"type": "enum", "name": "E", "symbols": ["A", "B"], "default": "A"}`
+	compatFixed2      = `{"type": "fixed", "name": "F", "size": 2}`
+	compatFixed3      = `{"type": "fixed", "name": "F", "size": 3}`
+)
+
+var compatCheckTests = []struct {
+	testName    string
+	mode        avro.CompatMode
+	newType     string
+	oldTypes    []string
+	expectError string
+}{{
+	testName: "backward-add-field-with-default",
+	mode:     avro.Backward,
+	newType:  compatSchema2,
+	oldTypes: []string{compatSchema1},
+}, {
+	testName:    "backward-add-field-without-default",
+	mode:        avro.Backward,
+	newType:     compatSchema3,
+	oldTypes:    []string{compatSchema1},
+	expectError: `new type cannot read data written with old type 0: incompatible schemas: myrecord.f2: field not present in writer and has no default value \(reader type "string"\)`,
+}, {
+	testName: "backward-field-alias",
+	mode:     avro.Backward,
+	newType:  compatSchema4,
+	oldTypes: []string{compatSchema1},
+}, {
+	testName: "backward-evolve-to-union",
+	mode:     avro.Backward,
+	newType:  compatSchema6,
+	oldTypes: []string{compatSchema1},
+}, {
+	testName: "backward-add-union-member",
+	mode:     avro.Backward,
+	newType:  compatSchema7,
+	oldTypes: []string{compatSchema6},
+}, {
+	testName:    "backward-remove-union-member",
+	mode:        avro.Backward,
+	newType:     compatSchema6,
+	oldTypes:    []string{compatSchema7},
+	expectError: `new type cannot read data written with old type 0: incompatible schemas: myrecord.f1\[u2\]: no member of reader union matches writer type \(writer type "int"; reader type \["null","string"\]\)`,
+}, {
+	testName: "backward-non-transitive",
+	mode:     avro.Backward,
+	newType:  compatSchema3,
+	oldTypes: []string{compatSchema1, compatSchema2},
+}, {
+	testName:    "backward-transitive",
+	mode:        avro.BackwardTransitive,
+	newType:     compatSchema3,
+	oldTypes:    []string{compatSchema1, compatSchema2},
+	expectError: `new type cannot read data written with old type 0: .*`,
+}, {
+	testName: "forward-add-field-without-default",
+	mode:     avro.Forward,
+	newType:  compatSchema3,
+	oldTypes: []string{compatSchema1},
+}, {
+	testName:    "forward-remove-field-without-default",
+	mode:        avro.Forward,
+	newType:     compatSchema1,
+	oldTypes:    []string{compatSchema3},
+	expectError: `old type 0 cannot read data written with new type: incompatible schemas: myrecord.f2: field not present in writer and has no default value \(reader type "string"\)`,
+}, {
+	testName: "full-add-fields-with-defaults",
+	mode:     avro.Full,
+	newType:  compatSchema8,
+	oldTypes: []string{compatSchema1, compatSchema2},
+}, {
+	testName:    "full-add-field-without-default",
+	mode:        avro.Full,
+	newType:     compatSchema3,
+	oldTypes:    []string{compatSchema1},
+	expectError: `new type cannot read data written with old type 0: .*`,
+}, {
+	testName:    "full-transitive",
+	mode:        avro.FullTransitive,
+	newType:     compatSchema3,
+	oldTypes:    []string{compatSchema1, compatSchema3},
+	expectError: `new type cannot read data written with old type 0: .*`,
+}, {
+	testName: "none",
+	newType:  compatSchema1,
+	oldTypes: []string{compatSchema3},
+}, {
+	testName: "backward-promote-int-to-long",
+	mode:     avro.Backward,
+	newType:  compatLongRecord,
+	oldTypes: []string{compatIntRecord},
+}, {
+	testName:    "backward-demote-long-to-int",
+	mode:        avro.Backward,
+	newType:     compatIntRecord,
+	oldTypes:    []string{compatLongRecord},
+	expectError: `new type cannot read data written with old type 0: incompatible schemas: myrecord.f1: type mismatch \(writer type "long"; reader type "int"\)`,
+}, {
+	testName:    "backward-record-renamed",
+	mode:        avro.Backward,
+	newType:     compatOtherRecord,
+	oldTypes:    []string{compatSchema1},
+	expectError: `new type cannot read data written with old type 0: incompatible schemas: type mismatch \(writer type myrecord; reader type otherrecord\)`,
+}, {
+	testName: "backward-record-renamed-with-alias",
+	mode:     avro.Backward,
+	newType:  compatAliasedRecord,
+	oldTypes: []string{compatSchema1},
+}, {
+	testName: "full-record-namespace-changed",
+	mode:     avro.Full,
+	newType:  compatNamespacedRecord,
+	oldTypes: []string{compatSchema1},
+}, {
+	testName: "backward-record-renamed-with-alias-in-other-namespace",
+	mode:     avro.Backward,
+	newType:  compatNamespacedAliasedRecord,
+	oldTypes: []string{compatSchema1},
+}, {
+	testName:    "forward-record-renamed-with-alias-in-other-namespace",
+	mode:        avro.Forward,
+	newType:     compatNamespacedAliasedRecord,
+	oldTypes:    []string{compatSchema1},
+	expectError: `old type 0 cannot read data written with new type: incompatible schemas: type mismatch \(writer type com.example.otherrecord; reader type myrecord\)`,
+}, {
+	testName: "backward-enum-add-symbol",
+	mode:     avro.Backward,
+	newType:  compatEnum2,
+	oldTypes: []string{compatEnum1},
+}, {
+	testName:    "forward-enum-add-symbol",
+	mode:        avro.Forward,
+	newType:     compatEnum2,
+	oldTypes:    []string{compatEnum1},
+	expectError: `old type 0 cannot read data written with new type: incompatible schemas: E: writer symbols C not present in reader enum \(writer type E; reader type E\)`,
+}, {
+	testName: "backward-enum-remove-symbol-with-default",
+	mode:     avro.Backward,
+	newType:  compatEnumDefault,
+	oldTypes: []string{compatEnum2},
+}, {
+	testName:    "backward-fixed-size-change",
+	mode:        avro.Backward,
+	newType:     compatFixed3,
+	oldTypes:    []string{compatFixed2},
+	expectError: `new type cannot read data written with old type 0: incompatible schemas: F: fixed size mismatch \(writer 2; reader 3\) \(writer type F; reader type F\)`,
+}}
+
+func TestCompatCheck(t *testing.T) {
+	c := qt.New(t)
+	for _, test := range compatCheckTests {
+		c.Run(test.testName, func(c *qt.C) {
+			oldTypes := make([]*avro.Type, len(test.oldTypes))
+			for i, s := range test.oldTypes {
+				oldTypes[i] = mustParseType(s)
+			}
+			err := test.mode.Check(mustParseType(test.newType), oldTypes...)
+			if test.expectError == "" {
+				c.Assert(err, qt.Equals, nil)
+				return
+			}
+			c.Assert(err, qt.ErrorMatches, test.expectError)
+			var rerr *avro.ResolutionError
+			c.Assert(errors.As(err, &rerr), qt.Equals, true)
+		})
+	}
+}
@@ -0,0 +1,73 @@
+package avro
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"time"
+)
+
+var dateType = reflect.TypeOf(Date{})
+
+// Date represents a calendar date without a time of day or time zone,
+// such as a birthday, as held in the Avro date logical type.
+//
+// When encoding and decoding, a Date value can be used for any
+// int type with the date logical type. TypeOf represents it as
+// {"type": "int", "logicalType": "date"}.
+//
+// The zero Date is encoded as 0, the same as 1970-01-01, so that
+// it matches the default value in schemas. This means that it
+// doesn't round-trip: it's decoded as 1970-01-01, not as the zero
+// Date.
+type Date struct {
+	Year  int
+	Month time.Month
+	Day   int
+}
+
+// DateOf returns the date of t in its location.
+func DateOf(t time.Time) Date {
+	year, month, day := t.Date()
+	return Date{
+		Year:  year,
+		Month: month,
+		Day:   day,
+	}
+}
+
+// In returns the time of midnight at the start of d in loc.
+func (d Date) In(loc *time.Location) time.Time {
+	return time.Date(d.Year, d.Month, d.Day, 0, 0, 0, 0, loc)
+}
+
+// String returns d in RFC 3339 full-date format, such as "2006-01-02".
+func (d Date) String() string {
+	return fmt.Sprintf("%04d-%02d-%02d", d.Year, d.Month, d.Day)
+}
+
+// dateValueConverter converts between Date and an int
+// holding the number of days since the Unix epoch.
+// The zero Date is encoded as 0, so it's decoded as 1970-01-01.
+type dateValueConverter struct{}
+
+func (dateValueConverter) GoType() reflect.Type {
+	return dateType
+}
+
+func (dateValueConverter) ToAvro(x interface{}) (interface{}, error) {
+	d := x.(Date)
+	if d == (Date{}) {
+		return int64(0), nil
+	}
+	// Midnight UTC is always a whole number of days from the epoch.
+	days := d.In(time.UTC).Unix() / secondsPerDay
+	if days > math.MaxInt32 || days < math.MinInt32 {
+		return nil, fmt.Errorf("date %v out of range", d)
+	}
+	return days, nil
+}
+
+func (dateValueConverter) FromAvro(x interface{}) (interface{}, error) {
+	return DateOf(time.Unix(x.(int64)*secondsPerDay, 0).UTC()), nil
+}
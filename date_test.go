@@ -0,0 +1,79 @@
+package avro_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro"
+)
+
+func TestDateType(t *testing.T) {
+	c := qt.New(t)
+	type R struct {
+		D avro.Date
+		O *avro.Date
+	}
+	at, err := avro.TypeOf(R{})
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(at.String(), qt.JSONEquals, json.RawMessage(`{
+		"type": "record",
+		"name": "R",
+		"fields": [{
+			"name": "D",
+			"default": 0,
+			"type": {"type": "int", "logicalType": "date"}
+		}, {
+			"name": "O",
+			"default": null,
+			"type": ["null", {"type": "int", "logicalType": "date"}]
+		}]
+	}`))
+
+	before := avro.Date{Year: 1969, Month: time.December, Day: 31}
+	x := R{
+		D: avro.Date{Year: 1970, Month: time.January, Day: 3},
+		O: &before,
+	}
+	data, wType, err := avro.Marshal(x)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(data, qt.DeepEquals, []byte{
+		// D: 2
+		4,
+		// O: union index 1, -1
+		2, 1,
+	})
+	var y R
+	_, err = avro.Unmarshal(data, &y, wType)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(y, qt.DeepEquals, x)
+}
+
+func TestDateZero(t *testing.T) {
+	c := qt.New(t)
+	type R struct {
+		D avro.Date
+	}
+	data, wType, err := avro.Marshal(R{})
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(data, qt.DeepEquals, []byte{0})
+	// The zero Date is encoded as the Unix epoch,
+	// so it doesn't round-trip.
+	var x R
+	_, err = avro.Unmarshal(data, &x, wType)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(x, qt.Equals, R{
+		D: avro.Date{Year: 1970, Month: time.January, Day: 1},
+	})
+}
+
+func TestDateOf(t *testing.T) {
+	c := qt.New(t)
+	loc := time.FixedZone("UTC-5", -5*60*60)
+	d := avro.DateOf(time.Date(2021, 2, 28, 23, 0, 0, 0, loc))
+	c.Assert(d, qt.Equals, avro.Date{Year: 2021, Month: time.February, Day: 28})
+	c.Assert(d.String(), qt.Equals, "2021-02-28")
+	c.Assert(d.In(loc), qt.Equals, time.Date(2021, 2, 28, 0, 0, 0, 0, loc))
+}
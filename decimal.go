@@ -0,0 +1,366 @@
+package avro
+
+import (
+	"fmt"
+	"math/big"
+	"reflect"
+	"sync"
+	"sync/atomic"
+
+	"github.com/rogpeppe/gogen-avro/v7/schema"
+)
+
+var ratType = reflect.TypeOf((*big.Rat)(nil))
+
+// DecimalConverter converts between a Go type and the values of
+// the decimal logical type. It makes it possible to use decimal
+// types from other packages, such as github.com/shopspring/decimal,
+// instead of *big.Rat. For example:
+//
+//	type shopspringConverter struct{}
+//
+//	func (shopspringConverter) GoType() reflect.Type {
+//		return reflect.TypeOf(decimal.Decimal{})
+//	}
+//
+//	func (shopspringConverter) ToRat(x interface{}) (*big.Rat, error) {
+//		return x.(decimal.Decimal).Rat(), nil
+//	}
+//
+//	func (shopspringConverter) FromUnscaled(unscaled *big.Int, scale int) (interface{}, error) {
+//		return decimal.NewFromBigInt(unscaled, -int32(scale)), nil
+//	}
+type DecimalConverter interface {
+	// GoType returns the Go type that decimal values
+	// are represented as.
+	GoType() reflect.Type
+
+	// ToRat converts x, which will be of type GoType(),
+	// to a rational number.
+	ToRat(x interface{}) (*big.Rat, error)
+
+	// FromUnscaled returns the value of type GoType()
+	// that represents unscaled × 10^-scale.
+	FromUnscaled(unscaled *big.Int, scale int) (interface{}, error)
+}
+
+var decimalTypes struct {
+	mu sync.RWMutex
+	// byGoType maps from Go type to the converter for that type.
+	byGoType map[reflect.Type]DecimalConverter
+}
+
+// RegisterDecimalType registers conv so that values of
+// conv.GoType() can be encoded and decoded as Avro types
+// with the decimal logical type, in the same way as *big.Rat.
+// It panics if the converter's Go type is nil or *big.Rat.
+//
+// As with RegisterLogicalType, registration should be done before
+// any values are encoded or decoded.
+func RegisterDecimalType(conv DecimalConverter) {
+	goType := conv.GoType()
+	if goType == nil || goType == ratType {
+		panic(fmt.Errorf("invalid Go type %v for decimal converter", goType))
+	}
+	decimalTypes.mu.Lock()
+	defer decimalTypes.mu.Unlock()
+	if decimalTypes.byGoType == nil {
+		decimalTypes.byGoType = make(map[reflect.Type]DecimalConverter)
+	}
+	decimalTypes.byGoType[goType] = conv
+}
+
+// isDecimalGoType reports whether t is *big.Rat or
+// has been registered with RegisterDecimalType.
+func isDecimalGoType(t reflect.Type) bool {
+	if t == ratType {
+		return true
+	}
+	decimalTypes.mu.RLock()
+	defer decimalTypes.mu.RUnlock()
+	_, ok := decimalTypes.byGoType[t]
+	return ok
+}
+
+// DecimalRounding specifies what happens when a value encoded
+// as a decimal has more digits after the decimal point than its
+// scale allows.
+type DecimalRounding int32
+
+const (
+	// DecimalRoundExact causes an error to be returned.
+	DecimalRoundExact DecimalRounding = iota
+
+	// DecimalRoundHalfEven rounds to the nearest value,
+	// with ties going to the even value.
+	DecimalRoundHalfEven
+
+	// DecimalRoundHalfUp rounds to the nearest value,
+	// with ties going away from zero.
+	DecimalRoundHalfUp
+
+	// DecimalRoundDown truncates towards zero.
+	DecimalRoundDown
+)
+
+var decimalRounding int32
+
+// SetDecimalRounding sets the rounding used when encoding
+// decimal values. The default is DecimalRoundExact.
+func SetDecimalRounding(r DecimalRounding) {
+	atomic.StoreInt32(&decimalRounding, int32(r))
+}
+
+// DecimalParams returns the precision and scale of t
+// if it's a decimal logical type (a bytes or fixed type with
+// a "decimal" logical type); otherwise it returns false.
+func (t *Type) DecimalParams() (precision, scale int, ok bool) {
+	return decimalParams(t.avroType)
+}
+
+// decimalParams returns the decimal precision and scale of at,
+// which must already have been checked with checkDecimals.
+func decimalParams(at schema.AvroType) (precision, scale int, ok bool) {
+	if logicalType(at) != "decimal" {
+		return 0, 0, false
+	}
+	switch kindOf(at) {
+	case KindBytes, KindFixed:
+	default:
+		return 0, 0, false
+	}
+	p, _ := at.Attribute("precision").(float64)
+	s, _ := at.Attribute("scale").(float64)
+	return int(p), int(s), true
+}
+
+// checkDecimals checks that all the decimal logical types
+// inside at have valid precision and scale attributes.
+func checkDecimals(at schema.AvroType) error {
+	return checkDecimals1(at, make(map[schema.QualifiedName]bool))
+}
+
+func checkDecimals1(at schema.AvroType, visited map[schema.QualifiedName]bool) error {
+	if logicalType(at) == "decimal" {
+		if err := checkDecimalAttrs(at); err != nil {
+			return err
+		}
+	}
+	switch at := at.(type) {
+	case *schema.ArrayField:
+		return checkDecimals1(at.ItemType(), visited)
+	case *schema.MapField:
+		return checkDecimals1(at.ItemType(), visited)
+	case *schema.UnionField:
+		for _, t := range at.ItemTypes() {
+			if err := checkDecimals1(t, visited); err != nil {
+				return err
+			}
+		}
+	case *schema.Reference:
+		if visited[at.TypeName] {
+			return nil
+		}
+		visited[at.TypeName] = true
+		if def, ok := at.Def.(*schema.RecordDefinition); ok {
+			for _, f := range def.Fields() {
+				if err := checkDecimals1(f.Type(), visited); err != nil {
+					return fmt.Errorf("field %q of %s: %v", f.Name(), at.TypeName, err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func checkDecimalAttrs(at schema.AvroType) error {
+	switch kindOf(at) {
+	case KindBytes, KindFixed:
+	default:
+		// The specification says that invalid logical
+		// types should be ignored.
+		return nil
+	}
+	precision, ok := at.Attribute("precision").(float64)
+	if !ok || precision != float64(int(precision)) || precision <= 0 {
+		return fmt.Errorf("decimal precision must be a positive integer, not %v", at.Attribute("precision"))
+	}
+	scale := 0.0
+	if s := at.Attribute("scale"); s != nil {
+		scale, ok = s.(float64)
+		if !ok || scale != float64(int(scale)) || scale < 0 {
+			return fmt.Errorf("decimal scale must be a non-negative integer, not %v", s)
+		}
+	}
+	if scale > precision {
+		return fmt.Errorf("decimal scale %v is greater than precision %v", scale, precision)
+	}
+	return nil
+}
+
+// decimalConverter converts between decimal values and the two's
+// complement big-endian representation of their unscaled value.
+type decimalConverter struct {
+	precision int
+	scale     int
+	// size holds the size of the fixed type holding the value,
+	// or zero if it's held in bytes.
+	size int
+	// conv holds the converter for the Go type,
+	// or nil if it's *big.Rat.
+	conv DecimalConverter
+}
+
+// newDecimalConverter returns the converter to use for values of
+// Go type t encoded with the decimal Avro type at, or nil if t isn't
+// a decimal Go type or at isn't a decimal.
+func newDecimalConverter(at schema.AvroType, t reflect.Type) Converter {
+	precision, scale, ok := decimalParams(at)
+	if !ok {
+		return nil
+	}
+	c := &decimalConverter{
+		precision: precision,
+		scale:     scale,
+	}
+	if ref, ok := at.(*schema.Reference); ok {
+		c.size = ref.Def.(*schema.FixedDefinition).SizeBytes()
+	}
+	if isUintKind(t.Kind()) {
+		c.conv = uintDecimalConverter{t}
+	} else if t != ratType {
+		decimalTypes.mu.RLock()
+		c.conv = decimalTypes.byGoType[t]
+		decimalTypes.mu.RUnlock()
+		if c.conv == nil {
+			return nil
+		}
+	}
+	return c
+}
+
+func (c *decimalConverter) GoType() reflect.Type {
+	if c.conv != nil {
+		return c.conv.GoType()
+	}
+	return ratType
+}
+
+func (c *decimalConverter) ToAvro(x interface{}) (interface{}, error) {
+	var r *big.Rat
+	if c.conv != nil {
+		var err error
+		r, err = c.conv.ToRat(x)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		r = x.(*big.Rat)
+	}
+	if r == nil {
+		return nil, fmt.Errorf("cannot encode nil decimal value")
+	}
+	unscaled, err := c.unscaled(r)
+	if err != nil {
+		return nil, err
+	}
+	if unscaled.CmpAbs(pow10(c.precision)) >= 0 {
+		return nil, fmt.Errorf("decimal value %s does not fit in precision %d", r.FloatString(c.scale), c.precision)
+	}
+	data := twosComplementBytes(unscaled)
+	if c.size == 0 {
+		return data, nil
+	}
+	if len(data) > c.size {
+		return nil, fmt.Errorf("decimal value %s does not fit in %d bytes", r.FloatString(c.scale), c.size)
+	}
+	fixed := make([]byte, c.size)
+	if unscaled.Sign() < 0 {
+		for i := range fixed {
+			fixed[i] = 0xff
+		}
+	}
+	copy(fixed[c.size-len(data):], data)
+	return fixed, nil
+}
+
+func (c *decimalConverter) FromAvro(x interface{}) (interface{}, error) {
+	unscaled := twosComplementInt(x.([]byte))
+	if c.conv != nil {
+		return c.conv.FromUnscaled(unscaled, c.scale)
+	}
+	return new(big.Rat).SetFrac(unscaled, pow10(c.scale)), nil
+}
+
+// unscaled returns r × 10^scale as an integer, rounded as
+// specified by SetDecimalRounding.
+func (c *decimalConverter) unscaled(r *big.Rat) (*big.Int, error) {
+	num := new(big.Int).Mul(r.Num(), pow10(c.scale))
+	q, m := new(big.Int).QuoRem(num, r.Denom(), new(big.Int))
+	if m.Sign() == 0 {
+		return q, nil
+	}
+	// cmpHalf holds the comparison of the remainder with half
+	// the denominator.
+	cmpHalf := new(big.Int).Lsh(new(big.Int).Abs(m), 1).Cmp(r.Denom())
+	roundAway := false
+	switch DecimalRounding(atomic.LoadInt32(&decimalRounding)) {
+	case DecimalRoundHalfEven:
+		roundAway = cmpHalf > 0 || cmpHalf == 0 && q.Bit(0) == 1
+	case DecimalRoundHalfUp:
+		roundAway = cmpHalf >= 0
+	case DecimalRoundDown:
+	default:
+		return nil, fmt.Errorf("decimal value %s cannot be represented exactly with scale %d", r.RatString(), c.scale)
+	}
+	if roundAway {
+		q.Add(q, big.NewInt(int64(m.Sign())))
+	}
+	return q, nil
+}
+
+// pow10 returns 10^n.
+func pow10(n int) *big.Int {
+	return new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(n)), nil)
+}
+
+// twosComplementBytes returns the shortest two's complement
+// big-endian representation of x.
+func twosComplementBytes(x *big.Int) []byte {
+	if x.Sign() >= 0 {
+		return padBytes(x)
+	}
+	// The two's complement of a negative x is the bitwise
+	// inverse of -x-1.
+	y := new(big.Int).Neg(x)
+	data := padBytes(y.Sub(y, big.NewInt(1)))
+	for i := range data {
+		data[i] = ^data[i]
+	}
+	return data
+}
+
+// padBytes returns the big-endian bytes of the non-negative x
+// with enough leading zero bits to leave room for a sign bit.
+func padBytes(x *big.Int) []byte {
+	data := make([]byte, x.BitLen()/8+1)
+	b := x.Bytes()
+	copy(data[len(data)-len(b):], b)
+	return data
+}
+
+// twosComplementInt returns the integer represented by
+// the two's complement big-endian bytes in data.
+func twosComplementInt(data []byte) *big.Int {
+	x := new(big.Int)
+	if len(data) == 0 || data[0]&0x80 == 0 {
+		return x.SetBytes(data)
+	}
+	inv := make([]byte, len(data))
+	for i, b := range data {
+		inv[i] = ^b
+	}
+	x.SetBytes(inv)
+	x.Add(x, big.NewInt(1))
+	return x.Neg(x)
+}
@@ -0,0 +1,275 @@
+package avro_test
+
+import (
+	"encoding/json"
+	"math/big"
+	"reflect"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro"
+	"github.com/heetch/avro/avrotypegen"
+)
+
+// decimalRecord holds decimals represented
+// as both bytes and fixed.
+type decimalRecord struct {
+	B *big.Rat
+	F *big.Rat
+}
+
+func (decimalRecord) AvroRecord() avrotypegen.RecordInfo {
+	return avrotypegen.RecordInfo{
+		Schema: `{"fields":[{"name":"B","type":{"logicalType":"decimal","precision":6,"scale":2,"type":"bytes"}},{"name":"F","type":{"logicalType":"decimal","name":"F4","precision":6,"scale":2,"size":4,"type":"fixed"}}],"name":"R","type":"record"}`,
+		Required: []bool{
+			0: true,
+			1: true,
+		},
+	}
+}
+
+var decimalTests = []struct {
+	testName   string
+	b, f       string
+	expectData []byte
+}{{
+	testName: "zero",
+	b:        "0",
+	f:        "0",
+	expectData: []byte{
+		2, 0x00,
+		0x00, 0x00, 0x00, 0x00,
+	},
+}, {
+	testName: "positive",
+	b:        "1.2",
+	f:        "2.56",
+	expectData: []byte{
+		2, 0x78,
+		0x00, 0x00, 0x01, 0x00,
+	},
+}, {
+	testName: "negative",
+	b:        "-1.28",
+	f:        "-3.5",
+	expectData: []byte{
+		2, 0x80,
+		0xff, 0xff, 0xfe, 0xa2,
+	},
+}, {
+	testName: "sign-bit",
+	b:        "1.28",
+	f:        "9999.99",
+	expectData: []byte{
+		4, 0x00, 0x80,
+		0x00, 0x0f, 0x42, 0x3f,
+	},
+}}
+
+func TestDecimal(t *testing.T) {
+	c := qt.New(t)
+	for _, test := range decimalTests {
+		c.Run(test.testName, func(c *qt.C) {
+			data, wType, err := avro.Marshal(decimalRecord{
+				B: mustParseRat(test.b),
+				F: mustParseRat(test.f),
+			})
+			c.Assert(err, qt.Equals, nil)
+			c.Assert(data, qt.DeepEquals, test.expectData)
+
+			var x decimalRecord
+			_, err = avro.Unmarshal(data, &x, wType)
+			c.Assert(err, qt.Equals, nil)
+			c.Assert(x.B.Cmp(mustParseRat(test.b)), qt.Equals, 0)
+			c.Assert(x.F.Cmp(mustParseRat(test.f)), qt.Equals, 0)
+		})
+	}
+}
+
+var decimalErrorTests = []struct {
+	testName    string
+	val         decimalRecord
+	expectError string
+}{{
+	testName:    "precision",
+	val:         decimalRecord{B: mustParseRat("10000"), F: mustParseRat("0")},
+	expectError: `invalid decimal value at B: decimal value 10000.00 does not fit in precision 6`,
+}, {
+	testName:    "scale",
+	val:         decimalRecord{B: mustParseRat("1.234"), F: mustParseRat("0")},
+	expectError: `invalid decimal value at B: decimal value 617/500 cannot be represented exactly with scale 2`,
+}, {
+	testName:    "nil",
+	val:         decimalRecord{B: mustParseRat("0")},
+	expectError: `invalid decimal value at F: cannot encode nil decimal value`,
+}}
+
+func TestDecimalError(t *testing.T) {
+	c := qt.New(t)
+	for _, test := range decimalErrorTests {
+		c.Run(test.testName, func(c *qt.C) {
+			_, _, err := avro.Marshal(test.val)
+			c.Assert(err, qt.ErrorMatches, test.expectError)
+		})
+	}
+}
+
+var decimalRoundingTests = []struct {
+	rounding avro.DecimalRounding
+	in       []string
+	expect   []string
+}{{
+	rounding: avro.DecimalRoundHalfEven,
+	in:       []string{"1.235", "1.245", "-1.245", "1.2451"},
+	expect:   []string{"1.24", "1.24", "-1.24", "1.25"},
+}, {
+	rounding: avro.DecimalRoundHalfUp,
+	in:       []string{"1.235", "1.245", "-1.245", "1.2449"},
+	expect:   []string{"1.24", "1.25", "-1.25", "1.24"},
+}, {
+	rounding: avro.DecimalRoundDown,
+	in:       []string{"1.235", "1.249", "-1.249"},
+	expect:   []string{"1.23", "1.24", "-1.24"},
+}}
+
+func TestDecimalRounding(t *testing.T) {
+	c := qt.New(t)
+	defer avro.SetDecimalRounding(avro.DecimalRoundExact)
+	for _, test := range decimalRoundingTests {
+		avro.SetDecimalRounding(test.rounding)
+		for i, in := range test.in {
+			data, wType, err := avro.Marshal(decimalRecord{
+				B: mustParseRat(in),
+				F: mustParseRat(in),
+			})
+			c.Assert(err, qt.Equals, nil)
+			var x decimalRecord
+			_, err = avro.Unmarshal(data, &x, wType)
+			c.Assert(err, qt.Equals, nil)
+			c.Assert(x.B.FloatString(2), qt.Equals, test.expect[i], qt.Commentf("rounding %d; in %s", test.rounding, in))
+			c.Assert(x.F.FloatString(2), qt.Equals, test.expect[i], qt.Commentf("rounding %d; in %s", test.rounding, in))
+		}
+	}
+}
+
+// testCents represents an amount in hundredths.
+type testCents int64
+
+type testCentsConverter struct{}
+
+func (testCentsConverter) GoType() reflect.Type {
+	return reflect.TypeOf(testCents(0))
+}
+
+func (testCentsConverter) ToRat(x interface{}) (*big.Rat, error) {
+	return big.NewRat(int64(x.(testCents)), 100), nil
+}
+
+func (testCentsConverter) FromUnscaled(unscaled *big.Int, scale int) (interface{}, error) {
+	r := new(big.Rat).SetFrac(unscaled, new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(scale)), nil))
+	r.Mul(r, big.NewRat(100, 1))
+	return testCents(r.Num().Int64()), nil
+}
+
+func init() {
+	avro.RegisterDecimalType(testCentsConverter{})
+}
+
+type centsRecord struct {
+	B testCents
+}
+
+func (centsRecord) AvroRecord() avrotypegen.RecordInfo {
+	return avrotypegen.RecordInfo{
+		Schema: `{"fields":[{"name":"B","type":{"logicalType":"decimal","precision":6,"scale":2,"type":"bytes"}}],"name":"R","type":"record"}`,
+		Required: []bool{
+			0: true,
+		},
+	}
+}
+
+func TestRegisterDecimalType(t *testing.T) {
+	c := qt.New(t)
+	data, wType, err := avro.Marshal(centsRecord{B: 256})
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(data, qt.DeepEquals, []byte{4, 0x01, 0x00})
+
+	var y centsRecord
+	_, err = avro.Unmarshal(data, &y, wType)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(y, qt.Equals, centsRecord{B: 256})
+}
+
+func TestDecimalTypeOf(t *testing.T) {
+	c := qt.New(t)
+	type R struct {
+		D *big.Rat
+	}
+	_, err := avro.TypeOf(R{})
+	c.Assert(err, qt.ErrorMatches, `cannot determine decimal precision for \*big.Rat; use a precision tag option or a type with an AvroRecord method`)
+
+	type S struct {
+		B  *big.Rat `json:"b,precision=4,scale=2"`
+		F1 *big.Rat `json:"f1,precision=6,scale=2,size=4"`
+		F2 *big.Rat `json:"f2,precision=6,scale=2,size=4"`
+	}
+	at, err := avro.TypeOf(S{})
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(at.String(), qt.JSONEquals, json.RawMessage(`{
+		"type": "record",
+		"name": "S",
+		"fields": [{
+			"name": "b",
+			"default": "",
+			"type": {"type": "bytes", "logicalType": "decimal", "precision": 4, "scale": 2}
+		}, {
+			"name": "f1",
+			"default": "\u0000\u0000\u0000\u0000",
+			"type": {
+				"type": "fixed",
+				"name": "go.Decimal_6_2_4",
+				"size": 4,
+				"logicalType": "decimal",
+				"precision": 6,
+				"scale": 2
+			}
+		}, {
+			"name": "f2",
+			"default": "\u0000\u0000\u0000\u0000",
+			"type": "go.Decimal_6_2_4"
+		}]
+	}`))
+	x := S{
+		B:  mustParseRat("-1.28"),
+		F1: mustParseRat("9999.99"),
+		F2: mustParseRat("-0.01"),
+	}
+	data, wType, err := avro.Marshal(x)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(data, qt.DeepEquals, []byte{
+		2, 0x80,
+		0x00, 0x0f, 0x42, 0x3f,
+		0xff, 0xff, 0xff, 0xff,
+	})
+	var y S
+	_, err = avro.Unmarshal(data, &y, wType)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(y.B.Cmp(x.B), qt.Equals, 0)
+	c.Assert(y.F1.Cmp(x.F1), qt.Equals, 0)
+	c.Assert(y.F2.Cmp(x.F2), qt.Equals, 0)
+
+	type T struct {
+		F *big.Rat `json:",precision=10,size=4"`
+	}
+	_, err = avro.TypeOf(T{})
+	c.Assert(err, qt.ErrorMatches, `decimal precision 10 does not fit in 4 bytes in field F`)
+}
+
+func mustParseRat(s string) *big.Rat {
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		panic("invalid rational " + s)
+	}
+	return r
+}
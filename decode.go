@@ -23,9 +23,22 @@ func Unmarshal(data []byte, x interface{}, wType *Type) (*Type, error) {
 	return globalNames.Unmarshal(data, x, wType)
 }
 
+// UnmarshalArena is like Unmarshal except that strings and byte
+// slices in the result are allocated from the given arena.
+func UnmarshalArena(data []byte, x interface{}, wType *Type, a *Arena) (*Type, error) {
+	return globalNames.UnmarshalArena(data, x, wType, a)
+}
+
 // Unmarshal is like the Unmarshal function except that names
 // in the schema for x are renamed according to names.
 func (names *Names) Unmarshal(data []byte, x interface{}, wType *Type) (*Type, error) {
+	return names.UnmarshalArena(data, x, wType, nil)
+}
+
+// UnmarshalArena is like the UnmarshalArena function except that names
+// in the schema for x are renamed according to names.
+// If a is nil, no arena is used.
+func (names *Names) UnmarshalArena(data []byte, x interface{}, wType *Type, a *Arena) (*Type, error) {
 	v := reflect.ValueOf(x)
 	t := v.Type()
 	if t.Kind() != reflect.Ptr {
@@ -36,7 +49,7 @@ func (names *Names) Unmarshal(data []byte, x interface{}, wType *Type) (*Type, e
 		return nil, err
 	}
 	v = v.Elem()
-	return unmarshal(nil, data, prog, v)
+	return unmarshal(nil, data, prog, v, a)
 }
 
 // stackFrame represents the registers that are mutated by the VM interpreter.
@@ -56,26 +69,51 @@ type decoder struct {
 	// buf holds bytes read from r to be consumed
 	// by the decoder. The unconsumed bytes are
 	// in d.buf[d.scan:].
-	buf     []byte
-	scan    int
-	r       io.Reader
-	readErr error
+	buf  []byte
+	scan int
+	// consumed holds the number of bytes that were
+	// consumed before the start of buf.
+	consumed int64
+	r        io.Reader
+	readErr  error
+	// arena holds the arena to allocate strings
+	// and bytes from, or nil if there is none.
+	arena *Arena
 }
 
-type decodeError struct {
-	err error
+// DecodeError is returned by Unmarshal and related functions
+// when Avro binary data cannot be decoded. Its message is that of
+// the underlying error.
+type DecodeError struct {
+	// Offset holds the offset in the data at which
+	// the problem was found.
+	Offset int64
+
+	// Err holds the underlying error.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *DecodeError) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the underlying error.
+func (e *DecodeError) Unwrap() error {
+	return e.Err
 }
 
 // unmarshal unmarshals Avro binary data from r and writes it to target
-// following the given program.
-func unmarshal(r io.Reader, buf []byte, prog *decodeProgram, target reflect.Value) (_ *Type, err error) {
+// following the given program. If arena is non-nil, strings and byte
+// slices are allocated from it.
+func unmarshal(r io.Reader, buf []byte, prog *decodeProgram, target reflect.Value, arena *Arena) (_ *Type, err error) {
 	if debugging {
 		debugf("unmarshal %x into %s", buf, target.Type())
 	}
 	defer func() {
 		switch panicErr := recover().(type) {
-		case *decodeError:
-			err = panicErr.err
+		case *DecodeError:
+			err = panicErr
 		case nil:
 		default:
 			panic(panicErr)
@@ -84,6 +122,7 @@ func unmarshal(r io.Reader, buf []byte, prog *decodeProgram, target reflect.Valu
 	d := decoder{
 		r:       r,
 		program: prog,
+		arena:   arena,
 	}
 	if r == nil {
 		d.buf = buf
@@ -95,6 +134,66 @@ func unmarshal(r io.Reader, buf []byte, prog *decodeProgram, target reflect.Valu
 	return prog.readerType, nil
 }
 
+// decodePrefix decodes a single value from the start of buf into
+// target following the given program, and returns the number of bytes
+// it used. If buf holds only the start of a value, the error wraps
+// io.ErrUnexpectedEOF or io.EOF.
+func decodePrefix(buf []byte, prog *decodeProgram, target reflect.Value) (_ int, err error) {
+	defer func() {
+		switch panicErr := recover().(type) {
+		case *DecodeError:
+			err = panicErr
+		case nil:
+		default:
+			panic(panicErr)
+		}
+	}()
+	d := decoder{
+		r:       eofReader{},
+		program: prog,
+		buf:     buf,
+		readErr: io.EOF,
+	}
+	d.eval(target)
+	return d.scan, nil
+}
+
+// eofReader is an io.Reader that's always at EOF.
+type eofReader struct{}
+
+func (eofReader) Read([]byte) (int, error) {
+	return 0, io.EOF
+}
+
+// setLogical sets target to the result of converting the
+// frame value for the given Set operand with conv.
+func (d *decoder) setLogical(target reflect.Value, conv Converter, operand int, frame *stackFrame) {
+	var x interface{}
+	switch operand {
+	case vm.Boolean:
+		x = frame.Boolean
+	case vm.Int, vm.Long:
+		x = frame.Int
+	case vm.Float, vm.Double:
+		x = frame.Float
+	case vm.Bytes:
+		x = d.copyBytes(frame.Bytes)
+	case vm.String:
+		x = frame.String
+	default:
+		d.error(fmt.Errorf("cannot convert %v to %s", operandString(operand), target.Type()))
+	}
+	v, err := conv.FromAvro(x)
+	if err != nil {
+		d.error(err)
+	}
+	xv := reflect.ValueOf(v)
+	if !xv.IsValid() || !xv.Type().AssignableTo(target.Type()) {
+		d.error(fmt.Errorf("logical type converter returned %T, which is not assignable to %s", v, target.Type()))
+	}
+	target.Set(xv)
+}
+
 func (d *decoder) eval(target reflect.Value) {
 	if debugging {
 		if target.IsValid() {
@@ -137,20 +236,20 @@ func (d *decoder) eval(target reflect.Value) {
 			if debugging {
 				debugf("%v on %s", inst, target.Type())
 			}
+			if conv := d.program.convert[d.pc]; conv != nil {
+				d.setLogical(target, conv, inst.Operand, &frame)
+				break
+			}
 			switch inst.Operand {
 			case vm.Null:
 			case vm.Boolean:
 				target.SetBool(frame.Boolean)
 			case vm.Long:
-				// TODO support timestamp-millis.
-				// Unfortunately we can't tell whether the instruction
-				// is setting milliseconds or microseconds. We'll need
-				// need more information from the VM to be able to
-				// do that, so support only timestamp-micros for now.
-				// See https://github.com/heetch/avro/issues/3
+				// Timestamp logical types are converted by setLogical,
+				// so this is only reached for a long without one,
+				// which we treat as timestamp-micros.
 				if target.Type() == timeType {
-					// timestamp-micros
-					target.Set(reflect.ValueOf(time.Unix(frame.Int/1e6, frame.Int%1e6*1e3)))
+					target.Set(reflect.ValueOf(time.Unix(frame.Int/1e6, frame.Int%1e6*1e3).UTC()))
 					break
 				}
 				target.SetInt(frame.Int)
@@ -165,11 +264,13 @@ func (d *decoder) eval(target reflect.Value) {
 						d.error(fmt.Errorf("copied too little"))
 					}
 				} else {
-					data := make([]byte, len(frame.Bytes))
-					copy(data, frame.Bytes)
-					target.SetBytes(data)
+					target.SetBytes(d.copyBytes(frame.Bytes))
 				}
 			case vm.String:
+				if target.Type() == rawMessageType {
+					target.SetBytes([]byte(frame.String))
+					break
+				}
 				target.SetString(frame.String)
 			}
 		case vm.SetDefault:
@@ -179,13 +280,18 @@ func (d *decoder) eval(target reflect.Value) {
 			v := d.program.makeDefault[d.pc]()
 			target.Field(inst.Operand).Set(v)
 		case vm.Enter:
-			val, isRef := d.program.enter[d.pc](target)
+			pc := d.pc
+			val, isRef := d.program.enter[pc](target)
 			if debugging {
 				debugf("enter %d -> %#v (isRef %v) {", inst.Operand, val, isRef)
 			}
 			d.pc++
 			d.eval(val)
-			if !isRef {
+			if store := d.program.store[pc]; store != nil {
+				if err := store(target, val); err != nil {
+					d.error(err)
+				}
+			} else if !isRef {
 				target.Set(val)
 			}
 		case vm.Exit:
@@ -198,6 +304,7 @@ func (d *decoder) eval(target reflect.Value) {
 			d.pc++
 			d.eval(target.Index(target.Len() - 1))
 		case vm.AppendMap:
+			mapKey := d.program.mapKey[d.pc]
 			d.pc++
 			elem := reflect.New(target.Type().Elem()).Elem()
 			d.eval(elem)
@@ -211,7 +318,15 @@ func (d *decoder) eval(target reflect.Value) {
 				// See https://github.com/heetch/avro/issues/19
 				target.Set(reflect.MakeMap(target.Type()))
 			}
-			target.SetMapIndex(reflect.ValueOf(frame.String), elem)
+			key := reflect.ValueOf(frame.String)
+			if mapKey != nil {
+				var err error
+				key, err = mapKey(frame.String)
+				if err != nil {
+					d.error(err)
+				}
+			}
+			target.SetMapIndex(key, elem)
 		case vm.Call:
 			curr := d.pc
 			d.pc = inst.Operand
@@ -254,8 +369,24 @@ func (d *decoder) eval(target reflect.Value) {
 	}
 }
 
+// copyBytes returns a copy of data, allocated
+// from the arena if there is one.
+func (d *decoder) copyBytes(data []byte) []byte {
+	if d.arena == nil {
+		data1 := make([]byte, len(data))
+		copy(data1, data)
+		return data1
+	}
+	data1, err := d.arena.newBytes(data)
+	if err != nil {
+		d.error(err)
+	}
+	return data1
+}
+
 func (d *decoder) error(err error) {
-	panic(&decodeError{
-		err: err,
+	panic(&DecodeError{
+		Offset: d.consumed + int64(d.scan),
+		Err:    err,
 	})
 }
@@ -0,0 +1,96 @@
+package avro
+
+import (
+	"encoding/binary"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/rogpeppe/gogen-avro/v7/schema"
+)
+
+var durationType = reflect.TypeOf(Duration{})
+
+// Duration represents a value of the Avro duration logical type,
+// which is held in a fixed type of size 12. The three parts are
+// independent of each other, because the length of a month or a day
+// varies, so a duration can't be converted to a time.Duration
+// without knowing the time it starts at; see the AddTo method.
+//
+// When encoding and decoding, a Duration value is used for any
+// fixed type with the duration logical type. TypeOf represents it as
+// {"type": "fixed", "name": "avro.Duration", "size": 12, "logicalType": "duration"}.
+type Duration struct {
+	Months       uint32
+	Days         uint32
+	Milliseconds uint32
+}
+
+// DurationFromBytes returns the duration held in the contents
+// of a fixed value with the duration logical type, such as
+// a [12]byte type generated by avrogo.
+func DurationFromBytes(b [12]byte) Duration {
+	return Duration{
+		Months:       binary.LittleEndian.Uint32(b[0:4]),
+		Days:         binary.LittleEndian.Uint32(b[4:8]),
+		Milliseconds: binary.LittleEndian.Uint32(b[8:12]),
+	}
+}
+
+// Bytes returns the contents of the fixed value that
+// represents d. It's the inverse of DurationFromBytes.
+func (d Duration) Bytes() [12]byte {
+	var b [12]byte
+	binary.LittleEndian.PutUint32(b[0:4], d.Months)
+	binary.LittleEndian.PutUint32(b[4:8], d.Days)
+	binary.LittleEndian.PutUint32(b[8:12], d.Milliseconds)
+	return b
+}
+
+// AddTo returns t with the months and days of d added
+// as by t.AddDate, followed by the milliseconds.
+func (d Duration) AddTo(t time.Time) time.Time {
+	t = t.AddDate(0, int(d.Months), int(d.Days))
+	return t.Add(time.Duration(d.Milliseconds) * time.Millisecond)
+}
+
+// String returns d in a form such as "1 months 2 days 3ms".
+func (d Duration) String() string {
+	return fmt.Sprintf("%d months %d days %dms", d.Months, d.Days, d.Milliseconds)
+}
+
+// durationConverter converts between Duration and
+// the contents of a fixed value.
+type durationConverter struct{}
+
+// newDurationConverter returns the converter to use for values
+// of Go type t encoded with the duration Avro type at, or nil
+// if t isn't Duration or at doesn't hold 12 bytes.
+func newDurationConverter(at schema.AvroType, t reflect.Type) Converter {
+	ref, ok := at.(*schema.Reference)
+	if !ok || t != durationType {
+		return nil
+	}
+	def, ok := ref.Def.(*schema.FixedDefinition)
+	if !ok || def.SizeBytes() != 12 {
+		return nil
+	}
+	return durationConverter{}
+}
+
+func (durationConverter) GoType() reflect.Type {
+	return durationType
+}
+
+func (durationConverter) ToAvro(x interface{}) (interface{}, error) {
+	b := x.(Duration).Bytes()
+	return b[:], nil
+}
+
+func (durationConverter) FromAvro(x interface{}) (interface{}, error) {
+	var b [12]byte
+	if copy(b[:], x.([]byte)) != len(b) {
+		return nil, fmt.Errorf("duration value has wrong length")
+	}
+	return DurationFromBytes(b), nil
+}
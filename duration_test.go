@@ -0,0 +1,95 @@
+package avro_test
+
+import (
+	"encoding/json"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro"
+	"github.com/heetch/avro/avrotypegen"
+)
+
+// durationRecord holds a duration as
+// generated code might.
+type durationRecord struct {
+	D avro.Duration
+	O *avro.Duration
+}
+
+func (durationRecord) AvroRecord() avrotypegen.RecordInfo {
+	return avrotypegen.RecordInfo{
+		Schema: `{"fields":[{"name":"D","type":{"logicalType":"duration","name":"D","size":12,"type":"fixed"}},{"default":null,"name":"O","type":["null","D"]}],"name":"R","type":"record"}`,
+		Required: []bool{
+			0: true,
+		},
+	}
+}
+
+func TestDuration(t *testing.T) {
+	c := qt.New(t)
+	x := durationRecord{
+		D: avro.Duration{Months: 1, Days: 2, Milliseconds: 3000},
+		O: &avro.Duration{Days: 256},
+	}
+	data, wType, err := avro.Marshal(x)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(data, qt.DeepEquals, []byte{
+		1, 0, 0, 0, 2, 0, 0, 0, 0xb8, 0x0b, 0, 0,
+		2, 0, 0, 0, 0, 0, 1, 0, 0, 0, 0, 0, 0,
+	})
+
+	var x1 durationRecord
+	_, err = avro.Unmarshal(data, &x1, wType)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(x1, qt.DeepEquals, x)
+}
+
+func TestDurationTypeOf(t *testing.T) {
+	c := qt.New(t)
+	type R struct {
+		D avro.Duration
+	}
+	at, err := avro.TypeOf(R{})
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(at.String(), qt.JSONEquals, json.RawMessage(`{
+		"type": "record",
+		"name": "R",
+		"fields": [{
+			"name": "D",
+			"default": "\u0000\u0000\u0000\u0000\u0000\u0000\u0000\u0000\u0000\u0000\u0000\u0000",
+			"type": {
+				"type": "fixed",
+				"name": "avro.Duration",
+				"size": 12,
+				"logicalType": "duration"
+			}
+		}]
+	}`))
+	x := R{
+		D: avro.Duration{Months: 5},
+	}
+	data, wType, err := avro.Marshal(x)
+	c.Assert(err, qt.Equals, nil)
+	var x1 R
+	_, err = avro.Unmarshal(data, &x1, wType)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(x1, qt.Equals, x)
+}
+
+func TestDurationBytes(t *testing.T) {
+	c := qt.New(t)
+	d := avro.Duration{Months: 1, Days: 2, Milliseconds: 3000}
+	b := d.Bytes()
+	c.Assert(b, qt.Equals, [12]byte{1, 0, 0, 0, 2, 0, 0, 0, 0xb8, 0x0b, 0, 0})
+	c.Assert(avro.DurationFromBytes(b), qt.Equals, d)
+	c.Assert(d.String(), qt.Equals, "1 months 2 days 3000ms")
+}
+
+func TestDurationAddTo(t *testing.T) {
+	c := qt.New(t)
+	d := avro.Duration{Months: 1, Days: 2, Milliseconds: 3000}
+	t0 := time.Date(2020, 1, 10, 0, 0, 0, 0, time.UTC)
+	c.Assert(d.AddTo(t0), qt.Equals, time.Date(2020, 2, 12, 0, 0, 3, 0, time.UTC))
+}
@@ -1,13 +1,10 @@
 package avro
 
 import (
-	"bytes"
-	"encoding/binary"
 	"fmt"
 	"math"
 	"reflect"
 	"sort"
-	"time"
 
 	"github.com/rogpeppe/gogen-avro/v7/schema"
 
@@ -33,10 +30,23 @@ func Marshal(x interface{}) ([]byte, *Type, error) {
 	return globalNames.Marshal(x)
 }
 
-func marshalAppend(names *Names, buf []byte, xv reflect.Value) (_ []byte, _ *Type, marshalErr error) {
+// MarshalAppend is like Marshal except that it appends the
+// encoded data to buf and returns the extended slice. Reusing
+// buf between calls avoids allocating a new buffer for each value.
+func MarshalAppend(buf []byte, x interface{}) ([]byte, *Type, error) {
+	return globalNames.MarshalAppend(buf, x)
+}
+
+func marshalAppend(names *Names, buf []byte, xv reflect.Value) ([]byte, *Type, error) {
 	avroType, enc := typeEncoder(names, xv.Type())
+	return encodeAppend(buf, xv, avroType, enc)
+}
+
+// encodeAppend appends the encoding of xv with enc to buf.
+// The avroType argument is returned as the type of the data.
+func encodeAppend(buf []byte, xv reflect.Value, avroType *Type, enc encoderFunc) (_ []byte, _ *Type, marshalErr error) {
 	e := &encodeState{
-		Buffer: bytes.NewBuffer(buf),
+		buf: buf,
 	}
 	defer func() {
 		if r := recover(); r != nil {
@@ -48,7 +58,7 @@ func marshalAppend(names *Names, buf []byte, xv reflect.Value) (_ []byte, _ *Typ
 		}
 	}()
 	enc(e, xv)
-	return e.Bytes(), avroType, nil
+	return e.buf, avroType, nil
 }
 
 func typeEncoder(names *Names, t reflect.Type) (*Type, encoderFunc) {
@@ -76,8 +86,9 @@ func typeEncoder(names *Names, t reflect.Type) (*Type, encoderFunc) {
 }
 
 type encodeState struct {
-	*bytes.Buffer
-	scratch [64]byte
+	// buf holds the encoded data. Encoders
+	// append to it directly.
+	buf []byte
 }
 
 // error aborts the encoding by panicking with err wrapped in encodeError.
@@ -108,6 +119,39 @@ func (b *encoderBuilder) typeEncoder(at schema.AvroType, t reflect.Type, info ty
 	if enc := b.typeEncoders[t]; enc != nil {
 		return enc
 	}
+	if t.Kind() == reflect.Interface && !info.IsUnion {
+		// It's an interface placeholder with a type taken from
+		// the writer type (see TypeOfWithWriter).
+		if u, ok := at.(*schema.UnionField); ok {
+			entries, err := placeholderUnionEntries(u)
+			if err != nil {
+				return errorEncoder(err)
+			}
+			info = typeinfo.Info{
+				Type:    t,
+				IsUnion: true,
+				Entries: entries,
+			}
+		} else {
+			vt, err := placeholderGoType(at)
+			if err != nil {
+				return errorEncoder(err)
+			}
+			return interfaceEncoder{
+				typ: vt,
+				enc: b.typeEncoder(at, vt, typeinfo.Info{}),
+			}.encode
+		}
+	}
+	if conv := logicalConverter(at, t); conv != nil {
+		return newLogicalEncoder(conv, logicalType(at), kindOf(at))
+	}
+	if kindOf(at) == KindString && isTextType(t) {
+		return textEncoder
+	}
+	if kindOf(at) == KindBytes && isBinaryType(t) {
+		return binaryEncoder
+	}
 	switch at := at.(type) {
 	case *schema.Reference:
 		switch def := at.Def.(type) {
@@ -138,30 +182,50 @@ func (b *encoderBuilder) typeEncoder(at schema.AvroType, t reflect.Type, info ty
 				enc(e, v)
 			}
 			fieldEncoders := make([]encoderFunc, len(def.Fields()))
-			indexes := make([]int, len(def.Fields()))
+			indexes := make([][]int, len(def.Fields()))
+			names := make([]string, len(def.Fields()))
 			for i, f := range def.Fields() {
 				fieldInfo, ok := entryByName(info.Entries, f.Name())
 				if !ok {
 					return errorEncoder(fmt.Errorf("field %q not found in %s", f.Name(), t))
 				}
 				fieldIndex := fieldInfo.FieldIndex
-				fieldEncoders[i] = b.typeEncoder(f.Type(), t.Field(fieldIndex).Type, info.Entries[i])
+				fieldEncoders[i] = b.typeEncoder(f.Type(), t.FieldByIndex(fieldIndex).Type, fieldInfo)
 				indexes[i] = fieldIndex
+				names[i] = f.Name()
 			}
 			enc = structEncoder{
 				fieldEncoders: fieldEncoders,
 				fieldIndexes:  indexes,
+				fieldNames:    names,
 			}.encode
 			return enc
 		case *schema.EnumDefinition:
+			if isIntKind(t.Kind()) {
+				if table, err := enumValuesOf(t); err != nil {
+					return errorEncoder(err)
+				} else if table != nil {
+					return enumEncoder(t, table)
+				}
+			}
 			return longEncoder
 		case *schema.FixedDefinition:
+			if isUintKind(t.Kind()) {
+				if def.SizeBytes() != 8 {
+					return errorEncoder(fmt.Errorf("cannot encode %s as fixed type of size %d", t, def.SizeBytes()))
+				}
+				return uint64FixedEncoder
+			}
 			return fixedEncoder{def.SizeBytes()}.encode
 		default:
 			return errorEncoder(fmt.Errorf("unknown definition type %T", def))
 		}
 	case *schema.UnionField:
 		atypes := at.ItemTypes()
+		entries, err := unionEntries(at, info.Entries)
+		if err != nil {
+			return errorEncoder(err)
+		}
 		switch t.Kind() {
 		case reflect.Ptr:
 			// It's a union of null and one other type, represented by a Go pointer.
@@ -169,15 +233,15 @@ func (b *encoderBuilder) typeEncoder(at schema.AvroType, t reflect.Type, info ty
 				return errorEncoder(fmt.Errorf("unexpected item type count in union"))
 			}
 			switch {
-			case info.Entries[0].Type == nil:
+			case entries[0].Type == nil:
 				return ptrUnionEncoder{
 					indexes:    [2]byte{0, 1},
-					encodeElem: b.typeEncoder(atypes[1], info.Entries[1].Type, info.Entries[1]),
+					encodeElem: b.typeEncoder(atypes[1], entries[1].Type, entries[1]),
 				}.encode
-			case info.Entries[1].Type == nil:
+			case entries[1].Type == nil:
 				return ptrUnionEncoder{
 					indexes:    [2]byte{1, 0},
-					encodeElem: b.typeEncoder(atypes[0], info.Entries[0].Type, info.Entries[0]),
+					encodeElem: b.typeEncoder(atypes[0], entries[0].Type, entries[0]),
 				}.encode
 			default:
 				return errorEncoder(fmt.Errorf("unexpected types in union"))
@@ -185,9 +249,9 @@ func (b *encoderBuilder) typeEncoder(at schema.AvroType, t reflect.Type, info ty
 		case reflect.Interface:
 			enc := unionEncoder{
 				nullIndex: -1,
-				choices:   make([]unionEncoderChoice, len(info.Entries)),
+				choices:   make([]unionEncoderChoice, len(entries)),
 			}
-			for i, entry := range info.Entries {
+			for i, entry := range entries {
 				if entry.Type == nil {
 					enc.nullIndex = i
 				} else {
@@ -198,11 +262,34 @@ func (b *encoderBuilder) typeEncoder(at schema.AvroType, t reflect.Type, info ty
 				}
 			}
 			return enc.encode
+		case reflect.Struct:
+			// It's a union of null and one other type, represented by
+			// one of the database/sql nullable types.
+			if _, ok := typeinfo.NullableValueType(t); !ok || len(atypes) != 2 {
+				return errorEncoder(fmt.Errorf("union type is not pointer or interface"))
+			}
+			switch {
+			case entries[0].Type == nil:
+				return nullableUnionEncoder{
+					indexes:    [2]byte{0, 1},
+					encodeElem: b.typeEncoder(atypes[1], entries[1].Type, entries[1]),
+				}.encode
+			case entries[1].Type == nil:
+				return nullableUnionEncoder{
+					indexes:    [2]byte{1, 0},
+					encodeElem: b.typeEncoder(atypes[0], entries[0].Type, entries[0]),
+				}.encode
+			default:
+				return errorEncoder(fmt.Errorf("unexpected types in union"))
+			}
 		default:
 			return errorEncoder(fmt.Errorf("union type is not pointer or interface"))
 		}
 	case *schema.MapField:
-		return mapEncoder{b.typeEncoder(at.ItemType(), t.Elem(), info)}.encode
+		return mapEncoder{
+			encodeElem: b.typeEncoder(at.ItemType(), t.Elem(), info),
+			keyString:  mapKeyEncoder(t.Key()),
+		}.encode
 	case *schema.ArrayField:
 		return arrayEncoder{b.typeEncoder(at.ItemType(), t.Elem(), info)}.encode
 	case *schema.BoolField:
@@ -214,20 +301,26 @@ func (b *encoderBuilder) typeEncoder(at schema.AvroType, t reflect.Type, info ty
 	case *schema.FloatField:
 		return floatEncoder
 	case *schema.IntField:
+		if isUintKind(t.Kind()) {
+			return uintEncoder
+		}
 		return longEncoder
 	case *schema.NullField:
 		return nullEncoder
 	case *schema.LongField:
 		if t == timeType {
-			if lt := logicalType(at); lt == timestampMicros {
-				return timestampMicrosEncoder
-			} else {
-				// TODO timestamp-millis support.
-				return errorEncoder(fmt.Errorf("cannot encode time.Time as long with logical type %q", lt))
-			}
+			// Timestamp logical types are handled by logicalConverter.
+			return errorEncoder(fmt.Errorf("cannot encode time.Time as long with logical type %q", logicalType(at)))
+		}
+		if isUintKind(t.Kind()) {
+			return uintEncoder
 		}
 		return longEncoder
 	case *schema.StringField:
+		if t == rawMessageType {
+			// The JSON text is encoded as it is.
+			return bytesEncoder
+		}
 		return stringEncoder
 	default:
 		return errorEncoder(fmt.Errorf("unknown avro schema type %T", at))
@@ -239,41 +332,28 @@ func logicalType(t schema.AvroType) string {
 	return s
 }
 
-func timestampMillisEncoder(e *encodeState, v reflect.Value) {
-	t := v.Interface().(time.Time)
-	if t.IsZero() {
-		e.writeLong(0)
-	} else {
-		e.writeLong(t.Unix()*1e3 + int64(t.Nanosecond())/int64(time.Millisecond))
-	}
-}
-
-func timestampMicrosEncoder(e *encodeState, v reflect.Value) {
-	t := v.Interface().(time.Time)
-	if t.IsZero() {
-		e.writeLong(0)
-	} else {
-		e.writeLong(t.Unix()*1e6 + int64(t.Nanosecond())/int64(time.Microsecond))
-	}
-}
-
 type fixedEncoder struct {
 	size int
 }
 
 func (fe fixedEncoder) encode(e *encodeState, v reflect.Value) {
 	if v.CanAddr() {
-		e.Write(v.Slice(0, fe.size).Bytes())
+		e.buf = append(e.buf, v.Slice(0, fe.size).Bytes()...)
 	} else {
-		// TODO use a sync.Pool?
-		buf := make([]byte, fe.size)
-		reflect.Copy(reflect.ValueOf(buf), v)
-		e.Write(buf)
+		// Copy directly into the space at the end of the buffer.
+		// Note: the compiler recognizes this append idiom
+		// and doesn't allocate the temporary slice.
+		n := len(e.buf)
+		e.buf = append(e.buf, make([]byte, fe.size)...)
+		reflect.Copy(reflect.ValueOf(e.buf[n:]), v)
 	}
 }
 
 type mapEncoder struct {
 	encodeElem encoderFunc
+	// keyString converts a map key to its string form,
+	// or is nil if the key is a string already.
+	keyString func(reflect.Value) (string, error)
 }
 
 func (me mapEncoder) encode(e *encodeState, v reflect.Value) {
@@ -282,26 +362,54 @@ func (me mapEncoder) encode(e *encodeState, v reflect.Value) {
 	if n == 0 {
 		return
 	}
+	var key string
+	defer addErrorPath(func() string {
+		return "{" + key + "}"
+	})
 	if sortMapKeys {
-		keys := make([]string, 0, n)
+		type entry struct {
+			key string
+			val reflect.Value
+		}
+		entries := make([]entry, 0, n)
 		for iter := v.MapRange(); iter.Next(); {
-			keys = append(keys, iter.Key().String())
+			entries = append(entries, entry{me.key(e, iter.Key()), iter.Value()})
 		}
-		sort.Strings(keys)
-		for _, k := range keys {
-			kv := reflect.ValueOf(k)
-			stringEncoder(e, kv)
-			me.encodeElem(e, v.MapIndex(kv))
+		sort.Slice(entries, func(i, j int) bool {
+			return entries[i].key < entries[j].key
+		})
+		for _, ent := range entries {
+			key = ent.key
+			stringEncoder(e, reflect.ValueOf(key))
+			me.encodeElem(e, ent.val)
 		}
 	} else {
 		for iter := v.MapRange(); iter.Next(); {
-			stringEncoder(e, iter.Key())
+			if me.keyString == nil {
+				key = iter.Key().String()
+				stringEncoder(e, iter.Key())
+			} else {
+				key = me.key(e, iter.Key())
+				stringEncoder(e, reflect.ValueOf(key))
+			}
 			me.encodeElem(e, iter.Value())
 		}
 	}
 	e.writeLong(0)
 }
 
+// key returns the string form of the map key k.
+func (me mapEncoder) key(e *encodeState, k reflect.Value) string {
+	if me.keyString == nil {
+		return k.String()
+	}
+	s, err := me.keyString(k)
+	if err != nil {
+		e.error(err)
+	}
+	return s
+}
+
 type arrayEncoder struct {
 	encodeElem encoderFunc
 }
@@ -312,7 +420,11 @@ func (ae arrayEncoder) encode(e *encodeState, v reflect.Value) {
 	if n == 0 {
 		return
 	}
-	for i := 0; i < n; i++ {
+	i := 0
+	defer addErrorPath(func() string {
+		return indexPathElem(i)
+	})
+	for ; i < n; i++ {
 		ae.encodeElem(e, v.Index(i))
 	}
 	e.writeLong(0)
@@ -320,9 +432,9 @@ func (ae arrayEncoder) encode(e *encodeState, v reflect.Value) {
 
 func boolEncoder(e *encodeState, v reflect.Value) {
 	if v.Bool() {
-		e.WriteByte(1)
+		e.buf = append(e.buf, 1)
 	} else {
-		e.WriteByte(0)
+		e.buf = append(e.buf, 0)
 	}
 }
 
@@ -334,40 +446,76 @@ func longEncoder(e *encodeState, v reflect.Value) {
 }
 
 func (e *encodeState) writeLong(x int64) {
-	n := binary.PutVarint(e.scratch[:], x)
-	e.Write(e.scratch[:n])
+	e.buf = appendLong(e.buf, x)
 }
 
 func floatEncoder(e *encodeState, v reflect.Value) {
-	binary.LittleEndian.PutUint32(e.scratch[:], math.Float32bits(float32(v.Float())))
-	e.Write(e.scratch[:4])
+	e.buf = appendFloat(e.buf, float32(v.Float()))
 }
 
 func doubleEncoder(e *encodeState, v reflect.Value) {
-	binary.LittleEndian.PutUint64(e.scratch[:], math.Float64bits(v.Float()))
-	e.Write(e.scratch[:8])
+	e.buf = appendDouble(e.buf, v.Float())
 }
 
 func bytesEncoder(e *encodeState, v reflect.Value) {
 	data := v.Bytes()
-	e.writeLong(int64(len(data)))
-	e.Write(data)
+	e.buf = appendLong(e.buf, int64(len(data)))
+	e.buf = append(e.buf, data...)
+}
+
+// fixedBytesEncoder encodes a byte slice that
+// holds the contents of a fixed value.
+func fixedBytesEncoder(e *encodeState, v reflect.Value) {
+	e.buf = append(e.buf, v.Bytes()...)
 }
 
 func stringEncoder(e *encodeState, v reflect.Value) {
 	s := v.String()
-	e.writeLong(int64(len(s)))
-	e.WriteString(s)
+	e.buf = appendLong(e.buf, int64(len(s)))
+	e.buf = append(e.buf, s...)
+}
+
+// appendLong appends the Avro encoding of the int or long x to buf.
+func appendLong(buf []byte, x int64) []byte {
+	ux := uint64(x<<1) ^ uint64(x>>63)
+	for ux >= 0x80 {
+		buf = append(buf, byte(ux)|0x80)
+		ux >>= 7
+	}
+	return append(buf, byte(ux))
+}
+
+// appendFloat appends the Avro encoding of the float x to buf.
+func appendFloat(buf []byte, x float32) []byte {
+	bits := math.Float32bits(x)
+	return append(buf, byte(bits), byte(bits>>8), byte(bits>>16), byte(bits>>24))
+}
+
+// appendDouble appends the Avro encoding of the double x to buf.
+func appendDouble(buf []byte, x float64) []byte {
+	bits := math.Float64bits(x)
+	return append(buf,
+		byte(bits), byte(bits>>8), byte(bits>>16), byte(bits>>24),
+		byte(bits>>32), byte(bits>>40), byte(bits>>48), byte(bits>>56),
+	)
 }
 
 type structEncoder struct {
-	fieldIndexes  []int
+	// fieldIndexes holds the index sequence
+	// of each field within the struct.
+	fieldIndexes  [][]int
 	fieldEncoders []encoderFunc
+	// fieldNames holds the Avro name of each field.
+	fieldNames []string
 }
 
 func (se structEncoder) encode(e *encodeState, v reflect.Value) {
-	for i, index := range se.fieldIndexes {
-		se.fieldEncoders[i](e, v.Field(index))
+	i := 0
+	defer addErrorPath(func() string {
+		return se.fieldNames[i]
+	})
+	for ; i < len(se.fieldIndexes); i++ {
+		se.fieldEncoders[i](e, v.FieldByIndex(se.fieldIndexes[i]))
 	}
 }
 
@@ -418,3 +566,19 @@ func (pe ptrUnionEncoder) encode(e *encodeState, v reflect.Value) {
 	e.writeLong(int64(pe.indexes[1]))
 	pe.encodeElem(e, v.Elem())
 }
+
+// nullableUnionEncoder encodes a union of null and one other type
+// represented by a database/sql nullable type such as sql.NullString.
+type nullableUnionEncoder struct {
+	indexes    [2]byte
+	encodeElem encoderFunc
+}
+
+func (ne nullableUnionEncoder) encode(e *encodeState, v reflect.Value) {
+	if !v.Field(1).Bool() {
+		e.writeLong(int64(ne.indexes[0]))
+		return
+	}
+	e.writeLong(int64(ne.indexes[1]))
+	ne.encodeElem(e, v.Field(0))
+}
@@ -0,0 +1,191 @@
+package avro
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/rogpeppe/gogen-avro/v7/schema"
+
+	"github.com/heetch/avro/avrotypegen"
+)
+
+// avroEnumOf returns the avrotypegen.AvroEnum implementation
+// of the zero value of t, or nil if t doesn't implement it.
+func avroEnumOf(t reflect.Type) avrotypegen.AvroEnum {
+	e, _ := reflect.Zero(t).Interface().(avrotypegen.AvroEnum)
+	return e
+}
+
+// declaredEnumSymbols returns the enum symbols declared by
+// the type t, which implements avrotypegen.AvroEnum.
+func declaredEnumSymbols(t reflect.Type, e avrotypegen.AvroEnum) ([]string, error) {
+	if !isIntKind(t.Kind()) {
+		return nil, fmt.Errorf("enum type %s must have a signed integer kind", t)
+	}
+	n := e.NumSymbols()
+	if n <= 0 {
+		return nil, fmt.Errorf("enum type %s has no symbols", t)
+	}
+	syms := make([]string, n)
+	found := make(map[string]bool)
+	for i := range syms {
+		sym := e.SymbolName(i)
+		if !isValidEnumSymbol(sym) {
+			return nil, fmt.Errorf("invalid symbol %q for enum type %s", sym, t)
+		}
+		if found[sym] {
+			return nil, fmt.Errorf("duplicate symbol %q for enum type %s", sym, t)
+		}
+		found[sym] = true
+		syms[i] = sym
+	}
+	if _, err := enumValuesOf(t); err != nil {
+		return nil, err
+	}
+	return syms, nil
+}
+
+// enumValueTable holds the values of an enum type
+// that implements avrotypegen.AvroEnumValues.
+type enumValueTable struct {
+	// values holds the value of each symbol,
+	// indexed by symbol index.
+	values []int64
+	// indexes maps from value to symbol index.
+	indexes map[int64]int
+}
+
+// enumValuesOf returns the value table for the enum type t,
+// or nil if its values are the indexes of its symbols.
+func enumValuesOf(t reflect.Type) (*enumValueTable, error) {
+	ev, ok := reflect.Zero(t).Interface().(avrotypegen.AvroEnumValues)
+	if !ok {
+		return nil, nil
+	}
+	n := ev.NumSymbols()
+	table := &enumValueTable{
+		values:  make([]int64, n),
+		indexes: make(map[int64]int),
+	}
+	zero := reflect.Zero(t)
+	for i := range table.values {
+		v := ev.SymbolValue(i)
+		if zero.OverflowInt(v) {
+			return nil, fmt.Errorf("value %d of symbol %q out of range for enum type %s", v, ev.SymbolName(i), t)
+		}
+		if _, ok := table.indexes[v]; ok {
+			return nil, fmt.Errorf("duplicate value %d for enum type %s", v, t)
+		}
+		table.values[i] = v
+		table.indexes[v] = i
+	}
+	return table, nil
+}
+
+// enumEncoder returns an encoder for the enum type t with
+// the given value table that encodes the index of the symbol
+// for each value.
+func enumEncoder(t reflect.Type, table *enumValueTable) encoderFunc {
+	conv := enumConverter{
+		t:     t,
+		table: table,
+	}
+	return func(e *encodeState, v reflect.Value) {
+		x, err := conv.ToAvro(v.Interface())
+		if err != nil {
+			e.error(err)
+		}
+		e.writeLong(x.(int64))
+	}
+}
+
+// newEnumConverter returns the converter used to decode values of
+// the Avro enum type at into the Go type t, or nil if the values of
+// t are the indexes of its symbols.
+func newEnumConverter(at schema.AvroType, t reflect.Type) Converter {
+	if kindOf(at) != KindEnum || !isIntKind(t.Kind()) {
+		return nil
+	}
+	// Any error has already been reported when
+	// determining the schema for t.
+	table, _ := enumValuesOf(t)
+	if table == nil {
+		return nil
+	}
+	return enumConverter{
+		t:     t,
+		table: table,
+	}
+}
+
+// enumConverter converts between the values of an enum type
+// with a value table and the indexes of its symbols.
+type enumConverter struct {
+	t     reflect.Type
+	table *enumValueTable
+}
+
+func (c enumConverter) GoType() reflect.Type {
+	return c.t
+}
+
+func (c enumConverter) ToAvro(x interface{}) (interface{}, error) {
+	n := reflect.ValueOf(x).Int()
+	i, ok := c.table.indexes[n]
+	if !ok {
+		return nil, fmt.Errorf("value %d is not a valid value of enum type %s", n, c.t)
+	}
+	return int64(i), nil
+}
+
+func (c enumConverter) FromAvro(x interface{}) (interface{}, error) {
+	i := x.(int64)
+	if i < 0 || i >= int64(len(c.table.values)) {
+		return nil, fmt.Errorf("enum index %d out of range for %s", i, c.t)
+	}
+	v := reflect.New(c.t).Elem()
+	v.SetInt(c.table.values[i])
+	return v.Interface(), nil
+}
+
+// EnumSymbolMapper can be implemented by a Go integer type whose
+// enum symbols are taken from its String method (see TypeOf) to map
+// each string returned by String to the Avro symbol used for it.
+// The returned symbols must be valid Avro symbols and distinct
+// from one another. For example:
+//
+//	func (Status) AvroEnumSymbol(s string) string {
+//		return strings.ToUpper(strings.ReplaceAll(s, " ", "_"))
+//	}
+//
+// When a type doesn't implement EnumSymbolMapper, each character
+// that isn't allowed in an Avro symbol is replaced with an underscore,
+// and an underscore is added to the start of a symbol that starts
+// with a digit.
+type EnumSymbolMapper interface {
+	AvroEnumSymbol(s string) string
+}
+
+// sanitizeEnumSymbol returns s converted to a valid Avro symbol
+// as described for EnumSymbolMapper.
+func sanitizeEnumSymbol(s string) string {
+	var buf strings.Builder
+	for i, r := range s {
+		switch {
+		case r >= utf8.RuneSelf:
+			buf.WriteByte('_')
+		case isDigit(byte(r)):
+			if i == 0 {
+				buf.WriteByte('_')
+			}
+			buf.WriteRune(r)
+		case r == '_' || isAlpha(byte(r)):
+			buf.WriteRune(r)
+		default:
+			buf.WriteByte('_')
+		}
+	}
+	return buf.String()
+}
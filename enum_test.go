@@ -0,0 +1,291 @@
+package avro_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro"
+)
+
+type declaredEnum int
+
+var declaredEnumSymbols = []string{"Red", "Green", "Blue"}
+
+func (declaredEnum) NumSymbols() int {
+	return len(declaredEnumSymbols)
+}
+
+func (declaredEnum) SymbolName(i int) string {
+	return declaredEnumSymbols[i]
+}
+
+// String would confuse the heuristic used for types
+// that don't implement avrotypegen.AvroEnum.
+func (e declaredEnum) String() string {
+	return "colour"
+}
+
+func TestDeclaredEnum(t *testing.T) {
+	c := qt.New(t)
+	type R struct {
+		E declaredEnum
+	}
+	at, err := avro.TypeOf(R{})
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(at.String(), qt.JSONEquals, json.RawMessage(`{
+		"type": "record",
+		"name": "R",
+		"fields": [{
+			"name": "E",
+			"default": "Red",
+			"type": {
+				"type": "enum",
+				"name": "declaredEnum",
+				"symbols": ["Red", "Green", "Blue"]
+			}
+		}]
+	}`))
+	data, wType, err := avro.Marshal(R{
+		E: 2,
+	})
+	c.Assert(err, qt.Equals, nil)
+	var x R
+	_, err = avro.Unmarshal(data, &x, wType)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(x, qt.Equals, R{
+		E: 2,
+	})
+}
+
+type invalidDeclaredEnum int
+
+func (invalidDeclaredEnum) NumSymbols() int {
+	return 1
+}
+
+func (invalidDeclaredEnum) SymbolName(i int) string {
+	return "not valid"
+}
+
+type emptyDeclaredEnum int
+
+func (emptyDeclaredEnum) NumSymbols() int {
+	return 0
+}
+
+func (emptyDeclaredEnum) SymbolName(i int) string {
+	panic("unreachable")
+}
+
+func TestDeclaredEnumErrors(t *testing.T) {
+	c := qt.New(t)
+	{
+		type R struct {
+			E invalidDeclaredEnum
+		}
+		_, err := avro.TypeOf(R{})
+		c.Assert(err, qt.ErrorMatches, `invalid symbol "not valid" for enum type avro_test.invalidDeclaredEnum`)
+	}
+	{
+		type R struct {
+			E emptyDeclaredEnum
+		}
+		_, err := avro.TypeOf(R{})
+		c.Assert(err, qt.ErrorMatches, `enum type avro_test.emptyDeclaredEnum has no symbols`)
+	}
+}
+
+type sanitizedEnum int
+
+var sanitizedEnumStrings = []string{"in progress", "done-ish", "2nd try", "héllo"}
+
+func (e sanitizedEnum) String() string {
+	return sanitizedEnumStrings[e]
+}
+
+type mappedEnum int
+
+func (e mappedEnum) String() string {
+	return sanitizedEnumStrings[e]
+}
+
+func (mappedEnum) AvroEnumSymbol(s string) string {
+	switch s {
+	case "in progress":
+		return "IN_PROGRESS"
+	case "done-ish":
+		return "DONE"
+	case "2nd try":
+		return "RETRY"
+	}
+	return "OTHER"
+}
+
+func TestEnumSymbolMapping(t *testing.T) {
+	c := qt.New(t)
+	type R struct {
+		S sanitizedEnum
+		M mappedEnum
+	}
+	at, err := avro.TypeOf(R{})
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(at.String(), qt.JSONEquals, json.RawMessage(`{
+		"type": "record",
+		"name": "R",
+		"fields": [{
+			"name": "S",
+			"default": "in_progress",
+			"type": {
+				"type": "enum",
+				"name": "sanitizedEnum",
+				"symbols": ["in_progress", "done_ish", "_2nd_try", "h_llo"]
+			}
+		}, {
+			"name": "M",
+			"default": "IN_PROGRESS",
+			"type": {
+				"type": "enum",
+				"name": "mappedEnum",
+				"symbols": ["IN_PROGRESS", "DONE", "RETRY", "OTHER"]
+			}
+		}]
+	}`))
+	x := R{
+		S: 2,
+		M: 1,
+	}
+	data, wType, err := avro.Marshal(x)
+	c.Assert(err, qt.Equals, nil)
+	var x1 R
+	_, err = avro.Unmarshal(data, &x1, wType)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(x1, qt.Equals, x)
+}
+
+type collidingEnum int
+
+func (e collidingEnum) String() string {
+	return []string{"a b", "a-b"}[e]
+}
+
+type badMappedEnum int
+
+func (e badMappedEnum) String() string {
+	return []string{"a", "b"}[e]
+}
+
+func (badMappedEnum) AvroEnumSymbol(s string) string {
+	return s + "!"
+}
+
+func TestEnumSymbolMappingErrors(t *testing.T) {
+	c := qt.New(t)
+	{
+		type R struct {
+			E collidingEnum
+		}
+		_, err := avro.TypeOf(R{})
+		c.Assert(err, qt.ErrorMatches, `duplicate symbol "a_b" for enum type avro_test.collidingEnum \(use EnumSymbolMapper to disambiguate\)`)
+	}
+	{
+		type R struct {
+			E badMappedEnum
+		}
+		_, err := avro.TypeOf(R{})
+		c.Assert(err, qt.ErrorMatches, `invalid symbol "a!" for "a" in enum type avro_test.badMappedEnum`)
+	}
+}
+
+// sparseEnum has values that start at 1 and aren't contiguous.
+type sparseEnum int
+
+const (
+	sparseLow    sparseEnum = 1
+	sparseMedium sparseEnum = 5
+	sparseHigh   sparseEnum = 10
+)
+
+var sparseEnumTable = []struct {
+	symbol string
+	value  sparseEnum
+}{
+	{"Low", sparseLow},
+	{"Medium", sparseMedium},
+	{"High", sparseHigh},
+}
+
+func (sparseEnum) NumSymbols() int {
+	return len(sparseEnumTable)
+}
+
+func (sparseEnum) SymbolName(i int) string {
+	return sparseEnumTable[i].symbol
+}
+
+func (sparseEnum) SymbolValue(i int) int64 {
+	return int64(sparseEnumTable[i].value)
+}
+
+func TestSparseEnum(t *testing.T) {
+	c := qt.New(t)
+	type R struct {
+		E sparseEnum
+	}
+	at, err := avro.TypeOf(R{})
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(at.String(), qt.JSONEquals, json.RawMessage(`{
+		"type": "record",
+		"name": "R",
+		"fields": [{
+			"name": "E",
+			"default": "Low",
+			"type": {
+				"type": "enum",
+				"name": "sparseEnum",
+				"symbols": ["Low", "Medium", "High"]
+			}
+		}]
+	}`))
+	for i, entry := range sparseEnumTable {
+		data, wType, err := avro.Marshal(R{
+			E: entry.value,
+		})
+		c.Assert(err, qt.Equals, nil)
+		// The index of the symbol is encoded, not the value.
+		c.Assert(data, qt.DeepEquals, []byte{byte(i * 2)})
+		var x R
+		_, err = avro.Unmarshal(data, &x, wType)
+		c.Assert(err, qt.Equals, nil)
+		c.Assert(x.E, qt.Equals, entry.value)
+	}
+
+	_, _, err = avro.Marshal(R{
+		E: 3,
+	})
+	c.Assert(err, qt.ErrorMatches, `value 3 is not a valid value of enum type avro_test.sparseEnum`)
+}
+
+type duplicateValueEnum int
+
+func (duplicateValueEnum) NumSymbols() int {
+	return 2
+}
+
+func (duplicateValueEnum) SymbolName(i int) string {
+	return []string{"A", "B"}[i]
+}
+
+func (duplicateValueEnum) SymbolValue(i int) int64 {
+	return 1
+}
+
+func TestSparseEnumDuplicateValue(t *testing.T) {
+	c := qt.New(t)
+	type R struct {
+		E duplicateValueEnum
+	}
+	_, err := avro.TypeOf(R{})
+	c.Assert(err, qt.ErrorMatches, `duplicate value 1 for enum type avro_test.duplicateValueEnum`)
+}
@@ -0,0 +1,149 @@
+package avro
+
+import (
+	"reflect"
+
+	"github.com/rogpeppe/gogen-avro/v7/schema"
+)
+
+// Equal reports whether a and b represent the same schema.
+// Unlike comparing the result of String, the comparison
+// does not depend on formatting, attribute ordering or
+// whether names are written relative to their enclosing namespace.
+//
+// All attributes, including documentation and custom
+// properties, are taken into account.
+func Equal(a, b *Type) bool {
+	return equal(a, b, 0)
+}
+
+// EqualIgnoringDocs is like Equal except that
+// "doc" attributes are ignored.
+func EqualIgnoringDocs(a, b *Type) bool {
+	return equal(a, b, ignoreDocs)
+}
+
+// EqualIgnoringProperties is like Equal except that custom
+// attributes (see Type.Properties) are ignored. Logical type
+// attributes are still compared.
+func EqualIgnoringProperties(a, b *Type) bool {
+	return equal(a, b, ignoreProperties)
+}
+
+type equalOpts int
+
+const (
+	ignoreDocs equalOpts = 1 << iota
+	ignoreProperties
+)
+
+func equal(a, b *Type, opts equalOpts) bool {
+	na := &normalizer{
+		opts:    opts,
+		defined: make(map[schema.QualifiedName]bool),
+	}
+	nb := &normalizer{
+		opts:    opts,
+		defined: make(map[schema.QualifiedName]bool),
+	}
+	return reflect.DeepEqual(na.value(a.avroType), nb.value(b.avroType))
+}
+
+// reservedFieldAttrs holds the attributes of a record field
+// that are defined by the specification.
+var reservedFieldAttrs = map[string]bool{
+	"name":    true,
+	"type":    true,
+	"doc":     true,
+	"default": true,
+	"order":   true,
+	"aliases": true,
+}
+
+// logicalTypeAttrs holds the attributes that are part of
+// a logical type definition.
+var logicalTypeAttrs = map[string]bool{
+	"logicalType": true,
+	"precision":   true,
+	"scale":       true,
+}
+
+// normalizer produces a normalized JSON value for an Avro type
+// that can be compared with reflect.DeepEqual.
+type normalizer struct {
+	opts    equalOpts
+	defined map[schema.QualifiedName]bool
+}
+
+func (n *normalizer) value(at schema.AvroType) interface{} {
+	switch at := at.(type) {
+	case *schema.Reference:
+		if n.defined[at.TypeName] {
+			return at.TypeName.String()
+		}
+		n.defined[at.TypeName] = true
+		obj := n.filter(copyOfSchemaObj(at), reservedAttrs)
+		delete(obj, "namespace")
+		obj["name"] = at.TypeName.String()
+		delete(obj, "aliases")
+		if aliases := at.Def.Aliases(); len(aliases) > 0 {
+			names := make([]interface{}, len(aliases))
+			for i, alias := range aliases {
+				names[i] = alias.String()
+			}
+			obj["aliases"] = names
+		}
+		if def, ok := at.Def.(*schema.RecordDefinition); ok {
+			fields := make([]interface{}, len(def.Fields()))
+			for i, f := range def.Fields() {
+				fobj := n.filter(copyOfSchemaObj(f), reservedFieldAttrs)
+				fobj["type"] = n.value(f.Type())
+				fields[i] = fobj
+			}
+			obj["fields"] = fields
+		}
+		return obj
+	case *schema.UnionField:
+		items := make([]interface{}, len(at.ItemTypes()))
+		for i, item := range at.ItemTypes() {
+			items[i] = n.value(item)
+		}
+		return items
+	case *schema.ArrayField:
+		obj := n.filter(copyOfSchemaObj(at), reservedAttrs)
+		obj["items"] = n.value(at.ItemType())
+		return obj
+	case *schema.MapField:
+		obj := n.filter(copyOfSchemaObj(at), reservedAttrs)
+		obj["values"] = n.value(at.ItemType())
+		return obj
+	}
+	def, _ := at.Definition(emptyScope())
+	obj, ok := def.(map[string]interface{})
+	if !ok {
+		return def
+	}
+	obj = n.filter(obj, reservedAttrs)
+	if len(obj) == 1 {
+		// {"type": "int"} is the same as "int".
+		return obj["type"]
+	}
+	return obj
+}
+
+// filter returns a copy of obj without the attributes
+// that are ignored by n. The reserved parameter holds the
+// attributes defined by the specification for the kind of object.
+func (n *normalizer) filter(obj map[string]interface{}, reserved map[string]bool) map[string]interface{} {
+	obj1 := make(map[string]interface{})
+	for name, val := range obj {
+		if name == "doc" && n.opts&ignoreDocs != 0 {
+			continue
+		}
+		if !reserved[name] && !logicalTypeAttrs[name] && n.opts&ignoreProperties != 0 {
+			continue
+		}
+		obj1[name] = val
+	}
+	return obj1
+}
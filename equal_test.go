@@ -0,0 +1,73 @@
+package avro_test
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro"
+)
+
+var equalTests = []struct {
+	testName                      string
+	a, b                          string
+	expectEqual                   bool
+	expectEqualIgnoringDocs       bool
+	expectEqualIgnoringProperties bool
+}{{
+	testName:                      "formatting-and-order",
+	a:                             `{"type": "record", "name": "R", "fields": [{"name": "a", "type": "int"}]}`,
+	b:                             `{"fields":[{"type":{"type":"int"},"name":"a"}],"name":"R","type":"record"}`,
+	expectEqual:                   true,
+	expectEqualIgnoringDocs:       true,
+	expectEqualIgnoringProperties: true,
+}, {
+	testName: "relative-names",
+	a: `{"type": "record", "name": "com.example.R", "fields": [
+		{"name": "a", "type": {"type": "enum", "name": "E", "symbols": ["x"]}},
+		{"name": "b", "type": "E"}
+	]}`,
+	b: `{"type": "record", "name": "R", "namespace": "com.example", "fields": [
+		{"name": "a", "type": {"type": "enum", "name": "com.example.E", "symbols": ["x"]}},
+		{"name": "b", "type": "com.example.E"}
+	]}`,
+	expectEqual:                   true,
+	expectEqualIgnoringDocs:       true,
+	expectEqualIgnoringProperties: true,
+}, {
+	testName:                "docs",
+	a:                       `{"type": "record", "name": "R", "doc": "x", "fields": [{"name": "a", "type": "int", "doc": "y"}]}`,
+	b:                       `{"type": "record", "name": "R", "fields": [{"name": "a", "type": "int"}]}`,
+	expectEqualIgnoringDocs: true,
+}, {
+	testName:                      "properties",
+	a:                             `{"type": "record", "name": "R", "confluent:tags": ["PII"], "fields": [{"name": "a", "type": "int", "x": 1}]}`,
+	b:                             `{"type": "record", "name": "R", "fields": [{"name": "a", "type": "int"}]}`,
+	expectEqualIgnoringProperties: true,
+}, {
+	testName: "logical-types",
+	a:        `{"type": "long", "logicalType": "timestamp-micros"}`,
+	b:        `"long"`,
+}, {
+	testName: "different-fields",
+	a:        `{"type": "record", "name": "R", "fields": [{"name": "a", "type": "int"}]}`,
+	b:        `{"type": "record", "name": "R", "fields": [{"name": "a", "type": "long"}]}`,
+}, {
+	testName: "different-defaults",
+	a:        `{"type": "record", "name": "R", "fields": [{"name": "a", "type": "int", "default": 1}]}`,
+	b:        `{"type": "record", "name": "R", "fields": [{"name": "a", "type": "int", "default": 2}]}`,
+}}
+
+func TestEqual(t *testing.T) {
+	c := qt.New(t)
+	for _, test := range equalTests {
+		c.Run(test.testName, func(c *qt.C) {
+			a, b := mustParseType(test.a), mustParseType(test.b)
+			c.Check(avro.Equal(a, b), qt.Equals, test.expectEqual)
+			c.Check(avro.Equal(b, a), qt.Equals, test.expectEqual)
+			c.Check(avro.EqualIgnoringDocs(a, b), qt.Equals, test.expectEqualIgnoringDocs)
+			c.Check(avro.EqualIgnoringProperties(a, b), qt.Equals, test.expectEqualIgnoringProperties)
+			c.Check(avro.Equal(a, a), qt.Equals, true)
+		})
+	}
+}
@@ -0,0 +1,36 @@
+package avro
+
+// fingerprintEmpty holds the CRC-64-AVRO fingerprint of empty data,
+// which is also the polynomial used to compute fingerprints.
+const fingerprintEmpty = 0xc15d213aa4d7a795
+
+var fingerprintTable = func() *[256]uint64 {
+	var table [256]uint64
+	for i := range table {
+		fp := uint64(i)
+		for j := 0; j < 8; j++ {
+			fp = (fp >> 1) ^ (fingerprintEmpty & -(fp & 1))
+		}
+		table[i] = fp
+	}
+	return &table
+}()
+
+// Fingerprint returns the CRC-64-AVRO fingerprint of the Parsing
+// Canonical Form of the type (see CanonicalString), as documented
+// here: https://avro.apache.org/docs/1.9.1/spec.html#schema_fingerprints
+//
+// This is the fingerprint used to identify the schema in
+// Avro single-object encoding.
+func (t *Type) Fingerprint() uint64 {
+	return fingerprint64(t.CanonicalString(0))
+}
+
+// fingerprint64 returns the CRC-64-AVRO fingerprint of s.
+func fingerprint64(s string) uint64 {
+	fp := uint64(fingerprintEmpty)
+	for i := 0; i < len(s); i++ {
+		fp = (fp >> 8) ^ fingerprintTable[byte(fp)^s[i]]
+	}
+	return fp
+}
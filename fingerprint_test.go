@@ -0,0 +1,47 @@
+package avro_test
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro"
+)
+
+var fingerprintTests = []struct {
+	testName string
+	in       string
+	expect   uint64
+}{{
+	testName: "null",
+	in:       `"null"`,
+	expect:   7195948357588979594,
+}, {
+	testName: "int",
+	in:       `{"type": "int"}`,
+	expect:   8247732601305521295,
+}, {
+	testName: "fixed",
+	in:       `{"type": "fixed", "name": "foo", "size": 15}`,
+	expect:   1756455273707447556,
+}, {
+	testName: "record-ignores-non-canonical-attributes",
+	in: `{
+	"type": "record",
+	"name": "R",
+	"doc": "A record.",
+	"fields": [{"name": "a", "type": "int", "default": 1}]
+}`,
+	expect: 0x35b061f31ba01537,
+}}
+
+func TestFingerprint(t *testing.T) {
+	c := qt.New(t)
+	for _, test := range fingerprintTests {
+		c.Run(test.testName, func(c *qt.C) {
+			t, err := avro.ParseType(test.in)
+			c.Assert(err, qt.IsNil)
+			c.Assert(t.Fingerprint(), qt.Equals, test.expect)
+		})
+	}
+}
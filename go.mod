@@ -3,6 +3,7 @@ module github.com/heetch/avro
 go 1.14
 
 require (
+	github.com/apache/arrow/go/arrow v0.0.0-20200730104253-651201b0f516
 	github.com/frankban/quicktest v1.10.0
 	github.com/kr/pretty v0.2.0
 	github.com/linkedin/goavro/v2 v2.9.7
@@ -3,8 +3,11 @@ package avro
 import (
 	"encoding/json"
 	"fmt"
+	"math/big"
 	"reflect"
+	"strconv"
 	"strings"
+	"sync"
 
 	"github.com/rogpeppe/gogen-avro/v7/schema"
 
@@ -13,8 +16,10 @@ import (
 )
 
 const (
-	timestampMicros = "timestamp-micros"
-	timestampMillis = "timestamp-millis"
+	timestampMicros      = "timestamp-micros"
+	timestampMillis      = "timestamp-millis"
+	localTimestampMicros = "local-timestamp-micros"
+	localTimestampMillis = "local-timestamp-millis"
 )
 
 // globalNames holds the default namespace which maps all Go types
@@ -34,32 +39,100 @@ type errorSchema struct {
 //
 // If the type was generated by avrogo, the returned schema
 // will be the same as the schema it was generated from.
+// Likewise, if a schema has been registered for the type
+// with RegisterSchema, that schema is used.
 //
 // Otherwise TypeOf(T) is derived according to
 // the following rules:
 //
 //	- int, int64 and uint32 encode as "long"
 //	- int32, int16, uint16, int8 and uint8 encode as "int"
+//	- uint64 and uint are disallowed unless a Uint64Policy has been
+//		chosen with Names.WithUint64Policy, which determines their encoding.
 //	- float32 encodes as "float"
 //	- float64 encodes as "double"
 //	- string encodes as "string"
+//	- json.RawMessage encodes as "string", holding the JSON text.
+//	- a type that implements encoding.TextMarshaler encodes as "string",
+//		holding the text returned by its MarshalText method. Decoding uses
+//		its UnmarshalText method. This doesn't apply to string types,
+//		pointer types or time.Time.
+//	- otherwise, a type that implements encoding.BinaryMarshaler encodes as
+//		"bytes", holding the data returned by its MarshalBinary method. Decoding
+//		uses its UnmarshalBinary method. This doesn't apply to byte slices or
+//		pointer types.
 //	- Null{} encodes as "null"
 //	- time.Time encodes as {"type": "long", "logicalType": "timestamp-micros"}
+//	- Date encodes as {"type": "int", "logicalType": "date"}
+//	- Duration encodes as {"type": "fixed", "name": "avro.Duration", "size": 12, "logicalType": "duration"}
 //	- [N]byte encodes as {"type": "fixed", "name": "go.FixedN", "size": N}
 //	- a named type with underlying type [N]byte encodes as [N]byte but typeName(T) for the name.
 //	- []T encodes as {"type": "array", "items": TypeOf(T)}
-//	- map[string]T encodes as {"type": "map", "values": TypeOf(T)}
+//	- [N]T where T isn't byte encodes as []T. When decoding, the array must
+//		be a struct field or pointed to, and the data must hold exactly N items.
+//	- map[K]T encodes as {"type": "map", "values": TypeOf(T)}, where K is a string
+//		or integer type, or a type that implements encoding.TextMarshaler (and
+//		encoding.TextUnmarshaler for decoding), with keys converted to strings
+//		as the encoding/json package does.
 //	- *T encodes as ["null", TypeOf(T)]
+//	- an integer type that implements avrotypegen.AvroEnum encodes as
+//		{"type": "enum", "name": typeName(T), "symbols": ...} with the symbols
+//		it declares. If it also implements avrotypegen.AvroEnumValues, each
+//		symbol is represented by the value it declares for it rather than by
+//		its index. Otherwise, an integer type with a String method that
+//		looks like one generated by the stringer tool encodes as an enum
+//		with the symbols returned by that method, converted to valid Avro
+//		symbols as described for EnumSymbolMapper.
+//	- the nullable types in database/sql, such as sql.NullString, and
+//		the Optional types, such as OptionalString, encode
+//		as ["null", TypeOf(T)] where T is the type of the value they hold.
 //	- a named struct type encodes as {"type": "record", "name": typeName(T), "fields": ...}
 //		where the fields are encoded as described below.
-//	- interface types are disallowed.
+//		A struct type that refers to itself, directly or through other types
+//		such as slices, maps and pointers, refers to its definition by name.
+//	- an interface type registered with RegisterUnion encodes as a union
+//		of TypeOf(M) for each member type M.
+//	- other interface types are disallowed, except when the schema is
+//		derived with respect to a writer type; see TypeOfWithWriter.
+//	- *big.Rat and types registered with RegisterDecimalType are only allowed
+//		as struct fields, and encode as {"type": "bytes", "logicalType": "decimal", ...}
+//		with the precision and scale taken from the field's tag options; see below.
+//	- a type registered with RegisterLogicalType encodes as
+//		{"type": underlying, "logicalType": name}.
+//
+// Here typeName(T) is the name registered for T with RegisterName
+// if there is one, or the Go name of T otherwise, qualified by the
+// namespace returned by its AvroNamespace method if it has one;
+// see NamespaceProvider.
 //
 // Struct fields are encoded as follows:
 //
 //	- unexported struct fields are ignored
-//	- the field name is taken from the Go field name, or from a "json" tag for the field if present.
+//	- the field name is taken from the Go field name, or from an "avro" tag for the field
+//		if present, or otherwise from a "json" tag for the field if present.
+//	- a field with the tag avro:"-", or with the tag json:"-" and no avro tag, is ignored.
 //	- the default value for the field is the zero value for the type.
-//	- anonymous struct fields are disallowed (this restriction may be lifted in the future).
+//	- the default value can be overridden with an "avrodefault" tag holding
+//		the default as JSON, for example `avrodefault:"42"`. As for any default
+//		in a schema, the value must be valid for the field's Avro type: for example,
+//		an enum default holds a symbol, a bytes or fixed default holds a string of
+//		code points up to U+00FF, and a time.Time default holds the number of
+//		microseconds since the Unix epoch.
+//	- the field's "doc" attribute is taken from an "avrodoc" tag if present.
+//		A record's "doc" attribute is taken from its AvroDoc method; see DocProvider.
+//	- the field's aliases are taken from a comma-separated "avroalias" tag if
+//		present, so data written with an earlier name for the field can still be read.
+//	- a decimal field takes its precision and scale from "precision=P" and
+//		"scale=S" tag options; a "size=N" option holds it in a fixed type of size N
+//		rather than bytes. For example: `avro:"price,precision=10,scale=2"`.
+//	- a time.Time field with the "millis" tag option encodes with the
+//		timestamp-millis logical type.
+//	- a json.RawMessage field with the "bytes" tag option encodes as "bytes"
+//		rather than "string".
+//	- the fields of embedded structs are flattened into the record as the encoding/json
+//		package does: a field hides more deeply embedded fields with the same name,
+//		and an embedded struct with a name in its tag is treated as a named field.
+//		Structs embedded by pointer are disallowed.
 func TypeOf(x interface{}) (*Type, error) {
 	return globalNames.TypeOf(x)
 }
@@ -73,7 +146,7 @@ func avroTypeOf(names *Names, t reflect.Type) (*Type, error) {
 		}
 		return rType, nil
 	}
-	rType, err := avroTypeOfUncached(names, t)
+	rType, err := avroTypeOfUncached(names, t, nil)
 	if err != nil {
 		names.goTypeToAvroType.LoadOrStore(t, &Type{
 			avroType: errorSchema{err: err},
@@ -84,15 +157,15 @@ func avroTypeOf(names *Names, t reflect.Type) (*Type, error) {
 	return rType, nil
 }
 
-func avroTypeOfUncached(names *Names, t reflect.Type) (*Type, error) {
+// avroTypeOfUncached returns the Avro type for t. If wType is non-nil,
+// the types of interface values in t are taken from the corresponding
+// parts of it.
+func avroTypeOfUncached(names *Names, t reflect.Type, wType schema.AvroType) (*Type, error) {
 	gts := &goTypeSchema{
 		names: names,
 		defs:  make(map[reflect.Type]goTypeDef),
 	}
-	// TODO pass in wType so that we can determine a schema
-	// even for partially specified Go types (e.g. interface{} values)
-	// See https://github.com/heetch/avro/issues/34
-	schemaVal, err := gts.schemaForGoType(t, false)
+	schemaVal, err := gts.schemaForGoType(t, wType)
 	if err != nil {
 		return nil, err
 	}
@@ -104,6 +177,9 @@ func avroTypeOfUncached(names *Names, t reflect.Type) (*Type, error) {
 	if err != nil {
 		return nil, err
 	}
+	if err := checkTagDefaults(at, gts.tagDefaults); err != nil {
+		return nil, err
+	}
 	if len(names.renames) == 0 {
 		// There are no renames, so we don't need to rename and parse again.
 		return at, nil
@@ -133,24 +209,82 @@ type goTypeSchema struct {
 	// defs maps from Go type to Avro definition for all
 	// types being traversed by schemaForGoType..
 	defs  map[reflect.Type]goTypeDef
+	// fixedNames holds the names of the fixed types defined
+	// for values that don't have a Go type of their own, such as
+	// decimal fields with a size tag option.
+	fixedNames map[string]bool
+	// writerDefs holds the names of the writer type definitions
+	// that have been used for interface types.
+	writerDefs map[schema.QualifiedName]bool
+	// tagDefaults holds the fields with defaults taken from
+	// avrodefault tags, which are checked when the schema
+	// has been parsed.
+	tagDefaults []tagDefaultField
 }
 
-// `ignoreCache` parameter prevents reusing registered type for an Anonymous field in a struct
-// This is helpful since the Anonymous fields must be merged to the current struct
-func (gts *goTypeSchema) schemaForGoType(t reflect.Type, ignoreCache bool) (interface{}, error) {
-	d, ok := gts.defs[t]
-	if !ignoreCache && ok {
+// tagDefaultField holds a struct field with an avrodefault tag.
+type tagDefaultField struct {
+	// record holds the schema for the record holding the field.
+	record map[string]interface{}
+	// name holds the Avro name of the field.
+	name string
+	// field holds the Go struct field.
+	field reflect.StructField
+}
+
+// schemaForGoType returns the JSON-marshalable schema for t.
+// The w argument holds the corresponding part of the writer type,
+// or nil if there is none.
+func (gts *goTypeSchema) schemaForGoType(t reflect.Type, w schema.AvroType) (interface{}, error) {
+	if d, ok := gts.defs[t]; ok {
 		// We've already defined a name for this type, so use it.
 		return d.name, nil
 	}
 	if t == nil {
 		return "null", nil
 	}
+	if rt := registeredSchema(t); rt != nil {
+		// It's a type with a schema registered by RegisterSchema.
+		return gts.define(t, json.RawMessage(rt.String()), "")
+	}
+	if name := registeredName(t); name != "" {
+		shared, err := gts.sharedDefinition(t, name)
+		if err != nil {
+			return nil, err
+		}
+		if shared {
+			// Another Go type registered with the same name has
+			// already been defined, so refer to its definition.
+			gts.defs[t] = goTypeDef{
+				name: name,
+			}
+			return name, nil
+		}
+	}
 	if r := avroRecordOf(t); r != nil {
 		// It's a generated type which comes with its own schema.
 		return gts.define(t, json.RawMessage(r.AvroRecord().Schema), "")
 	}
-	if syms := enumSymbols(t); len(syms) > 0 {
+	if rt, ok := runtimeTypes.Load(t); ok {
+		// It's a type created by StructOf.
+		return gts.define(t, json.RawMessage(rt.(*Type).String()), "")
+	}
+	if e := avroEnumOf(t); e != nil {
+		// It declares itself to be an enum.
+		syms, err := declaredEnumSymbols(t, e)
+		if err != nil {
+			return nil, err
+		}
+		return gts.define(t, map[string]interface{}{
+			"type":    "enum",
+			"symbols": syms,
+		}, "")
+	}
+	syms, err := enumSymbols(t)
+	if err != nil {
+		return nil, err
+	}
+	if len(syms) > 0 {
 		// It looks like an enum.
 		// TODO should we include a default here?
 		return gts.define(t, map[string]interface{}{
@@ -158,6 +292,23 @@ func (gts *goTypeSchema) schemaForGoType(t reflect.Type, ignoreCache bool) (inte
 			"symbols": syms,
 		}, "")
 	}
+	if isDecimalGoType(t) {
+		// The precision is required, so it must come from a schema
+		// or a field tag.
+		return nil, fmt.Errorf("cannot determine decimal precision for %s; use a precision tag option or a type with an AvroRecord method", t)
+	}
+	if name, info, ok := logicalTypeForGoType(t); ok {
+		return map[string]interface{}{
+			"type":        info.underlying.String(),
+			"logicalType": name,
+		}, nil
+	}
+	if isTextType(t) {
+		return "string", nil
+	}
+	if isBinaryType(t) {
+		return "bytes", nil
+	}
 	switch t.Kind() {
 	case reflect.Bool:
 		return "boolean", nil
@@ -167,15 +318,20 @@ func (gts *goTypeSchema) schemaForGoType(t reflect.Type, ignoreCache bool) (inte
 		return "long", nil
 	case reflect.Int32, reflect.Int16, reflect.Uint16, reflect.Int8, reflect.Uint8:
 		return "int", nil
+	case reflect.Uint64, reflect.Uint:
+		return gts.schemaForUint64(t)
 	case reflect.Float32:
 		return "float", nil
 	case reflect.Float64:
 		return "double", nil
 	case reflect.Slice:
+		if t == rawMessageType {
+			return "string", nil
+		}
 		if t.Elem() == byteType {
 			return "bytes", nil
 		}
-		items, err := gts.schemaForGoType(t.Elem(), false)
+		items, err := gts.schemaForGoType(t.Elem(), writerItemType(w))
 		if err != nil {
 			return nil, err
 		}
@@ -184,11 +340,10 @@ func (gts *goTypeSchema) schemaForGoType(t reflect.Type, ignoreCache bool) (inte
 			"items": items,
 		}, nil
 	case reflect.Map:
-		// TODO support the same map keys types that JSON does.
-		if t.Key().Kind() != reflect.String {
-			return nil, fmt.Errorf("map must have string key")
+		if err := checkMapKeyType(t.Key()); err != nil {
+			return nil, err
 		}
-		values, err := gts.schemaForGoType(t.Elem(), false)
+		values, err := gts.schemaForGoType(t.Elem(), writerItemType(w))
 		if err != nil {
 			return nil, err
 		}
@@ -203,90 +358,133 @@ func (gts *goTypeSchema) schemaForGoType(t reflect.Type, ignoreCache bool) (inte
 				"type":        "long",
 				"logicalType": timestampMicros,
 			}, nil
+		case dateType:
+			return map[string]interface{}{
+				"type":        "int",
+				"logicalType": "date",
+			}, nil
+		case durationType:
+			return gts.define(t, map[string]interface{}{
+				"type":        "fixed",
+				"name":        "avro.Duration",
+				"size":        12,
+				"logicalType": "duration",
+			}, "")
 		case nullType:
 			return "null", nil
 		}
+		if vt, ok := typeinfo.NullableValueType(t); ok {
+			elem, err := gts.schemaForGoType(vt, writerNonNull(w))
+			if err != nil {
+				return nil, err
+			}
+			return []interface{}{
+				"null",
+				elem,
+			}, nil
+		}
 
 		// Define the struct type before filling in the definition
 		// so that we'll find the definition if there's a recursive type.
 		// The map returned by the define method holds a reference
 		// to the same object held in gts.defs, so changing it
 		// below will update the final definition.
-		def := map[string]interface{}{}
-		if !ignoreCache {
-			var err error
-			def, err = gts.define(t, map[string]interface{}{
-				"type": "record",
-			}, "")
-			if err != nil {
-				return nil, err
-			}
+		def, err := gts.define(t, map[string]interface{}{
+			"type": "record",
+		}, "")
+		if err != nil {
+			return nil, err
 		}
 
 		// Note: don't start with nil fields because gogen-avro
 		// doesn't like the nil value.
 		fields := []interface{}{}
-		for i := 0; i < t.NumField(); i++ {
-			f := t.Field(i)
-			if f.Anonymous {
-				if err := gts.schemaForAnonymousField(f, &fields); err != nil {
-					return nil, err
-				}
-
-				continue
-			}
-
+		goFields, err := typeinfo.Fields(t)
+		if err != nil {
+			return nil, err
+		}
+		for _, f := range goFields {
 			// Technically in Go, every field is optional because
 			// that's the way that the encoding/json package works,
 			// so we'll make them all optional.
 			// TODO  experiment by making optional only the fields that
 			// specify omitempty.
-			name, _ := typeinfo.JSONFieldName(f)
-			if name == "" {
-				continue
+			name, _ := typeinfo.FieldName(f)
+			var aliases []string
+			if tag := f.Tag.Get("avroalias"); tag != "" {
+				aliases = strings.Split(tag, ",")
+			}
+			wf := writerField(w, name, aliases)
+			var wftype schema.AvroType
+			if wf != nil {
+				wftype = wf.Type()
 			}
 
-			ftype, err := gts.schemaForGoType(f.Type, false)
+			var ftype, d interface{}
+			hasDefault := true
+			isDecimal := isDecimalGoType(f.Type)
+			if isDecimal {
+				ftype, d, err = gts.schemaForDecimalField(f)
+			} else {
+				ftype, err = gts.schemaForGoType(f.Type, wftype)
+			}
 			if err != nil {
 				return nil, err
 			}
+			if typeinfo.HasOption(f, "millis") {
+				useTimestampMillis(ftype)
+			}
+			if typeinfo.HasOption(f, "bytes") && isRawMessageField(f) {
+				ftype = useRawJSONBytes(ftype)
+			}
 
-			// Check if the same property has already been added by an anonymous struct
-			exactSameProperty := false
-			for _, definedField := range fields {
-				castedDefinedField := definedField.(map[string]interface{})
-
-				if name == castedDefinedField["name"] {
-					// If it's also the same type, we can ignore this duplicate
-					if err := gts.ensureCompatibleTypes(name, ftype, castedDefinedField["type"]); err != nil {
-						return nil, err
-					} else {
-						exactSameProperty = true
-					}
-
-					break
+			if tag, ok := f.Tag.Lookup("avrodefault"); ok {
+				d, err = defaultFromTag(f, tag)
+				if err != nil {
+					return nil, err
+				}
+				gts.tagDefaults = append(gts.tagDefaults, tagDefaultField{
+					record: def,
+					name:   name,
+					field:  f,
+				})
+			} else if _, ok := typeinfo.UnionMembers(f.Type); f.Type.Kind() == reflect.Interface && !ok {
+				// The type came from the writer, so
+				// the default does too.
+				d, hasDefault = copyOfSchemaObj(wf)["default"]
+			} else if !isDecimal {
+				d, err = gts.defaultForType(f.Type)
+				if err != nil {
+					return nil, err
 				}
 			}
-
-			if exactSameProperty {
-				continue
+			field := map[string]interface{}{
+				"name": name,
+				"type": ftype,
 			}
-
-			d, err := gts.defaultForType(f.Type)
-			if err != nil {
-				return nil, err
+			if hasDefault {
+				field["default"] = d
+			}
+			if doc := f.Tag.Get("avrodoc"); doc != "" {
+				field["doc"] = doc
 			}
-			fields = append(fields, map[string]interface{}{
-				"name":    name,
-				"default": d,
-				"type":    ftype,
-			})
+			if len(aliases) > 0 {
+				field["aliases"] = aliases
+			}
+			fields = append(fields, field)
 		}
 		def["fields"] = fields
 		return def, nil
 	case reflect.Array:
-		if t.Elem() != reflect.TypeOf(byte(0)) {
-			return nil, fmt.Errorf("the only array type supported is [...]byte, not %s", t)
+		if t.Elem() != byteType {
+			items, err := gts.schemaForGoType(t.Elem(), writerItemType(w))
+			if err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{
+				"type":  "array",
+				"items": items,
+			}, nil
 		}
 		return gts.define(t, map[string]interface{}{
 			"type": "fixed",
@@ -296,7 +494,7 @@ func (gts *goTypeSchema) schemaForGoType(t reflect.Type, ignoreCache bool) (inte
 		if t.Elem().Kind() == reflect.Ptr {
 			return nil, fmt.Errorf("can only cope with a single level of pointer indirection")
 		}
-		elem, err := gts.schemaForGoType(t.Elem(), false)
+		elem, err := gts.schemaForGoType(t.Elem(), writerNonNull(w))
 		if err != nil {
 			return nil, err
 		}
@@ -305,73 +503,233 @@ func (gts *goTypeSchema) schemaForGoType(t reflect.Type, ignoreCache bool) (inte
 			elem,
 		}, nil
 	case reflect.Interface:
-		// TODO fill in from the writer schema.
-		return nil, fmt.Errorf("interface types (%s) not yet supported (use avrogo instead)", t)
+		if members, ok := typeinfo.UnionMembers(t); ok {
+			union := make([]interface{}, len(members))
+			for i, mt := range members {
+				member, err := gts.schemaForGoType(mt, nil)
+				if err != nil {
+					return nil, err
+				}
+				union[i] = member
+			}
+			return union, nil
+		}
+		if w == nil {
+			return nil, fmt.Errorf("cannot determine Avro type for interface type %s without a writer type (use TypeOfWithWriter, RegisterUnion or avrogo instead)", t)
+		}
+		// Use the writer's type, including the definitions
+		// of any names the first time they're used.
+		if gts.writerDefs == nil {
+			gts.writerDefs = make(map[schema.QualifiedName]bool)
+		}
+		return renameSchema(w, "", gts.writerDefs, originalName), nil
 	default:
 		return nil, fmt.Errorf("cannot make Avro schema for Go type %s", t)
 	}
 }
 
-func (gts *goTypeSchema) schemaForAnonymousField(field reflect.StructField, fields *[]interface{}) error {
-	// Analyze the Anonymous struct as for others (it will end in the switch case "Struct" in all cases)
-	anonymousDefinition, err := gts.schemaForGoType(field.Type, true)
-	if err != nil {
-		return err
-	}
-
-	// Cast to process it in the loop
-	castedAnonymousDefinition := anonymousDefinition.(map[string]interface{})
-	castedAnonymousDefinitionFields := castedAnonymousDefinition["fields"].([]interface{})
-
-	// Merge anonymous fields with the parent ones
-	for _, definitionField := range castedAnonymousDefinitionFields {
-		// Before merging we make sure nested anonymous structures do not define an already existing property with different type
-		// This could come from the parent structure, or a sibling anonymous structure
-		exactSameProperty := false
-		for _, parentField := range *fields {
-			castedDefinitionField := definitionField.(map[string]interface{})
-			castedParentField := parentField.(map[string]interface{})
-
-			if castedDefinitionField["name"] == castedParentField["name"] {
-				if err := gts.ensureCompatibleTypes(castedDefinitionField["name"].(string), castedDefinitionField["type"], castedParentField["type"]); err != nil {
-					return err
-				} else {
-					exactSameProperty = true
-				}
-
-				break
+// schemaForDecimalField returns the schema and default value for
+// a struct field with a decimal Go type, such as *big.Rat. The
+// precision and scale of the decimal are taken from the "precision"
+// and "scale" tag options. If there's a "size" tag option, the value
+// is held in a fixed type of that size; otherwise it's held in bytes.
+func (gts *goTypeSchema) schemaForDecimalField(f reflect.StructField) (ftype, def interface{}, err error) {
+	intOption := func(key string, required bool) (int, error) {
+		s, ok := typeinfo.OptionValue(f, key)
+		if !ok {
+			if required {
+				return 0, fmt.Errorf("cannot determine decimal precision for %s; use a precision tag option or a type with an AvroRecord method", f.Type)
 			}
+			return 0, nil
 		}
-
-		if exactSameProperty {
-			continue
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("invalid decimal %s %q in field %s", key, s, f.Name)
 		}
+		return n, nil
+	}
+	precision, err := intOption("precision", true)
+	if err != nil {
+		return nil, nil, err
+	}
+	scale, err := intOption("scale", false)
+	if err != nil {
+		return nil, nil, err
+	}
+	size, err := intOption("size", false)
+	if err != nil {
+		return nil, nil, err
+	}
+	if size == 0 {
+		return map[string]interface{}{
+			"type":        "bytes",
+			"logicalType": "decimal",
+			"precision":   precision,
+			"scale":       scale,
+		}, "", nil
+	}
+	// The largest unscaled value and its sign bit must fit in size bytes.
+	if bits := new(big.Int).Sub(pow10(precision), big.NewInt(1)).BitLen() + 1; bits > size*8 {
+		return nil, nil, fmt.Errorf("decimal precision %d does not fit in %d bytes in field %s", precision, size, f.Name)
+	}
+	name := fmt.Sprintf("go.Decimal_%d_%d_%d", precision, scale, size)
+	return gts.defineFixed(map[string]interface{}{
+		"type":        "fixed",
+		"name":        name,
+		"size":        size,
+		"logicalType": "decimal",
+		"precision":   precision,
+		"scale":       scale,
+	}), strings.Repeat("\u0000", size), nil
+}
 
-		*fields = append(*fields, definitionField)
+// defineFixed returns def, the definition of a fixed type, the first
+// time it's called for the type's name, and the name subsequently.
+func (gts *goTypeSchema) defineFixed(def map[string]interface{}) interface{} {
+	name := def["name"].(string)
+	if gts.fixedNames[name] {
+		return name
+	}
+	if gts.fixedNames == nil {
+		gts.fixedNames = make(map[string]bool)
 	}
+	gts.fixedNames[name] = true
+	return def
+}
 
-	return err
+// defaultFromTag returns the default value for the field f
+// held as JSON in its avrodefault tag. The value is checked
+// against the field's Avro type by checkTagDefaults.
+func defaultFromTag(f reflect.StructField, tag string) (interface{}, error) {
+	var d interface{}
+	if err := json.Unmarshal([]byte(tag), &d); err != nil {
+		return nil, fmt.Errorf("invalid avrodefault tag on field %s: %v", f.Name, err)
+	}
+	if isDecimalGoType(f.Type) {
+		return nil, fmt.Errorf("avrodefault tag not supported on decimal field %s", f.Name)
+	}
+	_, nullable := typeinfo.NullableValueType(f.Type)
+	if (f.Type.Kind() == reflect.Ptr || nullable) && d != nil {
+		// The default for a union must be a value of its
+		// first member, which is null.
+		return nil, fmt.Errorf("avrodefault tag on nullable field %s must be null", f.Name)
+	}
+	return d, nil
 }
 
-func (gts *goTypeSchema) ensureCompatibleTypes(propertyName string, type1 interface{}, type2 interface{}) error {
-	// We have to manage the 2 Avro type representations (string and record)
-	type1Name, ok := type1.(string)
-	if !ok {
-		recordType := type1.(map[string]interface{})
-		type1Name = recordType["name"].(string)
+// checkTagDefaults checks that the defaults taken from avrodefault
+// tags are valid for the types of their fields in at, following the
+// same rules as the defaults in any schema.
+func checkTagDefaults(at *Type, fields []tagDefaultField) error {
+	if len(fields) == 0 {
+		return nil
+	}
+	defs := namedDefinitions(at.avroType)
+	for _, tf := range fields {
+		for _, ref := range defs {
+			if ref.TypeName.String() != tf.record["name"] {
+				continue
+			}
+			f := ref.Def.(*schema.RecordDefinition).FieldByName(tf.name)
+			if _, err := appendAvroDefault(nil, f.Default(), f.Type()); err != nil {
+				return fmt.Errorf("invalid avrodefault tag on field %s: %v", tf.field.Name, err)
+			}
+		}
 	}
+	return nil
+}
 
-	type2Name, ok := type2.(string)
-	if !ok {
-		recordType := type2.(map[string]interface{})
-		type2Name = recordType["name"].(string)
+// tagDefault returns a function that returns the default value for
+// the Avro field f, decoded into a value of type t. It's used for
+// struct fields with an avrodefault tag, whose defaults are held in
+// the Avro JSON encoding as in any schema: the default is encoded
+// as binary data in the same way as a missing field in JSONToBinary
+// and then decoded as usual. It's decoded afresh each time so that
+// slice and map defaults aren't shared between values.
+func tagDefault(names *Names, f *schema.Field, t reflect.Type) (func() reflect.Value, error) {
+	data, err := appendAvroDefault(nil, f.Default(), f.Type())
+	if err != nil {
+		return nil, fmt.Errorf("invalid default for field %q: %v", f.Name(), err)
 	}
+	wType, err := standaloneType(f.Type())
+	if err != nil {
+		return nil, err
+	}
+	// Compile the decoder lazily because t might
+	// contain the record that holds the field.
+	var (
+		once    sync.Once
+		prog    *decodeProgram
+		progErr error
+	)
+	return func() reflect.Value {
+		once.Do(func() {
+			prog, progErr = compileDecoder(names, t, wType)
+		})
+		v := reflect.New(t).Elem()
+		if progErr == nil {
+			_, progErr = unmarshal(nil, data, prog, v, nil)
+		}
+		if progErr != nil {
+			// This should never happen because the default
+			// has been checked against the type derived from t.
+			panic(fmt.Errorf("cannot decode default for field %q: %v", f.Name(), progErr))
+		}
+		return v
+	}, nil
+}
 
-	if type2Name != type1Name {
-		return fmt.Errorf("the field %q has already been added by an anonymous structure with a different type (current: %q, defined: %q)", propertyName, type2Name, type1Name)
+// useTimestampMillis changes any timestamp-micros logical types
+// in the schema for a field to timestamp-millis, including those
+// inside arrays, maps and unions.
+func useTimestampMillis(ftype interface{}) {
+	switch ftype := ftype.(type) {
+	case []interface{}:
+		for _, t := range ftype {
+			useTimestampMillis(t)
+		}
+	case map[string]interface{}:
+		switch ftype["type"] {
+		case "long":
+			if ftype["logicalType"] == timestampMicros {
+				ftype["logicalType"] = timestampMillis
+			}
+		case "array":
+			useTimestampMillis(ftype["items"])
+		case "map":
+			useTimestampMillis(ftype["values"])
+		}
 	}
+}
 
-	return nil
+// DocProvider can be implemented by a Go type to provide
+// the "doc" attribute of the Avro definition that TypeOf
+// derives for it. For example:
+//
+//	func (Person) AvroDoc() string {
+//		return "A person known to the system."
+//	}
+//
+// It's ignored for types generated by avrogo, which
+// take their documentation from their schemas.
+type DocProvider interface {
+	AvroDoc() string
+}
+
+// NamespaceProvider can be implemented by a Go type to provide
+// the namespace of the Avro definition that TypeOf derives for it,
+// so that names can follow a naming convention rather than
+// being unqualified. For example:
+//
+//	func (Person) AvroNamespace() string {
+//		return "com.example.people"
+//	}
+//
+// It's ignored for types generated by avrogo, which take their
+// names from their schemas, and for types with a name registered
+// with RegisterName, which is already fully qualified.
+type NamespaceProvider interface {
+	AvroNamespace() string
 }
 
 func (gts *goTypeSchema) define(t reflect.Type, def0 interface{}, defaultName string) (map[string]interface{}, error) {
@@ -380,22 +738,31 @@ func (gts *goTypeSchema) define(t reflect.Type, def0 interface{}, defaultName st
 		if err := json.Unmarshal(def0.(json.RawMessage), &def); err != nil {
 			return nil, err
 		}
+	} else if dp, ok := reflect.Zero(t).Interface().(DocProvider); ok {
+		if doc := dp.AvroDoc(); doc != "" {
+			def["doc"] = doc
+		}
 	}
 	name, _ := def["name"].(string)
 	if name == "" {
 		// TODO use a fully qualified name derived from the Go package path
 		// as well as the type name. See https://github.com/heetch/avro/issues/35
-		if name = t.Name(); name == "" {
-			if name = defaultName; name == "" {
-				return nil, fmt.Errorf("cannot use unnamed type %s as Avro type", t)
+		if name = registeredName(t); name == "" {
+			if name = t.Name(); name == "" {
+				if name = defaultName; name == "" {
+					return nil, fmt.Errorf("cannot use unnamed type %s as Avro type", t)
+				}
+			} else if np, ok := reflect.Zero(t).Interface().(NamespaceProvider); ok {
+				if ns := np.AvroNamespace(); ns != "" {
+					name = ns + "." + name
+				}
 			}
 		}
 		def["name"] = name
 	}
 	for _, def := range gts.defs {
 		if def.name == name {
-			// TODO use package path to disambiguate. See https://github.com/heetch/avro/issues/35
-			return nil, fmt.Errorf("duplicate struct type name %q", name)
+			return nil, fmt.Errorf("duplicate struct type name %q (use RegisterName to disambiguate)", name)
 		}
 	}
 	gts.defs[t] = goTypeDef{
@@ -409,15 +776,19 @@ const maxEnum = 250
 
 // enumSymbols returns the enum symbols represented by the given
 // type. If the type doesn't represent an enum it returns no symbols.
-func enumSymbols(t reflect.Type) []string {
+//
+// Symbols are taken from the type's String method and converted to
+// valid Avro symbols with its AvroEnumSymbol method if it implements
+// EnumSymbolMapper, or with sanitizeEnumSymbol otherwise.
+func enumSymbols(t reflect.Type) ([]string, error) {
 	k := t.Kind()
 	isSignedInt := reflect.Int <= k && k <= reflect.Int64
 	isUnsignedInt := reflect.Uint <= k && k <= reflect.Uint64
 	if !isSignedInt && !isUnsignedInt {
-		return nil
+		return nil, nil
 	}
 	if _, ok := reflect.Zero(t).Interface().(fmt.Stringer); !ok {
-		return nil
+		return nil, nil
 	}
 	v := reflect.New(t)
 	vs := v.Interface().(fmt.Stringer) // Note: pointer type will also include String method.
@@ -468,42 +839,58 @@ func enumSymbols(t reflect.Type) []string {
 	default:
 		// All our heuristics for detecting out-of-bounds values
 		// are exhausted.
-		return nil
+		return nil, nil
+	}
+	mapSymbol := func(sym string) (string, error) {
+		return sanitizeEnumSymbol(sym), nil
+	}
+	if m, ok := reflect.Zero(t).Interface().(EnumSymbolMapper); ok {
+		mapSymbol = func(sym string) (string, error) {
+			sym1 := m.AvroEnumSymbol(sym)
+			if !isValidEnumSymbol(sym1) {
+				return "", fmt.Errorf("invalid symbol %q for %q in enum type %s", sym1, sym, t)
+			}
+			return sym1, nil
+		}
 	}
 	prev := ""
 	var syms []string
+	found := make(map[string]bool)
 	for i := 0; i < maxEnum; i++ {
 		sym, actual, ok := symOf(int64(i))
 		if !ok || sym == "" {
 			// Panic or empty value are never acceptable.
-			return syms
+			return syms, nil
 		}
 		switch oobStyle {
 		case oobParen:
 			if strings.Contains(sym, "(") {
-				return syms
+				return syms, nil
 			}
 		case oobNumber:
 			if sym == fmt.Sprint(actual) {
-				return syms
+				return syms, nil
 			}
 		}
 		if sym == prev {
 			// If it's the same as the previous value, it might be "unknown"
 			// or something, so treat both it and the previous value as
 			// out-of-bounds.
-			return syms[0 : len(syms)-1]
+			return syms[0 : len(syms)-1], nil
 		}
-		if !isValidEnumSymbol(sym) {
-			// TODO convert to a valid symbol somehow?
-			// https://github.com/heetch/avro/issues/36
-			return nil
+		prev = sym
+		sym, err := mapSymbol(sym)
+		if err != nil {
+			return nil, err
 		}
+		if found[sym] {
+			return nil, fmt.Errorf("duplicate symbol %q for enum type %s (use EnumSymbolMapper to disambiguate)", sym, t)
+		}
+		found[sym] = true
 		syms = append(syms, sym)
-		prev = sym
 	}
 	// Too many values.
-	return nil
+	return nil, nil
 }
 
 // From https://avro.apache.org/docs/1.9.1/spec.html#Enums :
@@ -532,36 +919,74 @@ func isDigit(c byte) bool {
 func (gts *goTypeSchema) defaultForType(t reflect.Type) (interface{}, error) {
 	// TODO perhaps a Go slice/map should accept a union
 	// of null and array/map? See https://github.com/heetch/avro/issues/19
+	if rt := registeredSchema(t); rt != nil {
+		return gts.defaultForRegistered(t, rt)
+	}
+	if _, info, ok := logicalTypeForGoType(t); ok {
+		return zeroUnderlyingDefault(info.underlying), nil
+	}
+	if isTextType(t) {
+		return textDefault(t)
+	}
+	if isBinaryType(t) {
+		return binaryDefault(t)
+	}
 	switch t.Kind() {
+	case reflect.Uint64, reflect.Uint:
+		switch gts.names.uint64Policy {
+		case Uint64Fixed:
+			return strings.Repeat("\u0000", 8), nil
+		case Uint64Decimal:
+			return "", nil
+		}
+		return 0, nil
 	case reflect.Slice:
+		if t == rawMessageType {
+			return "", nil
+		}
 		return reflect.MakeSlice(t, 0, 0).Interface(), nil
 	case reflect.Map:
 		return reflect.MakeMap(t).Interface(), nil
 	case reflect.Array:
+		if t.Elem() != byteType {
+			elem, err := gts.defaultForType(t.Elem())
+			if err != nil {
+				return nil, err
+			}
+			items := make([]interface{}, t.Len())
+			for i := range items {
+				items[i] = elem
+			}
+			return items, nil
+		}
 		return strings.Repeat("\u0000", t.Len()), nil
 	case reflect.Struct:
 		switch t {
 		case timeType:
 			return 0, nil
+		case dateType:
+			return 0, nil
+		case durationType:
+			return strings.Repeat("\u0000", 12), nil
 		case nullType:
 			return nil, nil
 		}
+		if _, ok := typeinfo.NullableValueType(t); ok {
+			return nil, nil
+		}
 		if avroRecordOf(t) != nil {
 			// It's a generated type - producing a correctly formed default value
 			// for it needs a bit more work so we punt on doing it for now.
 			// TODO make default values for struct-typed fields work in all cases.
 			return nil, fmt.Errorf("value fields of struct types generated by avrogo are not yet supported (type %s)", t)
 		}
+		goFields, err := typeinfo.Fields(t)
+		if err != nil {
+			return nil, err
+		}
 		fields := make(map[string]interface{})
-		for i := 0; i < t.NumField(); i++ {
-			f := t.Field(i)
-			if f.Anonymous {
-				return nil, fmt.Errorf("anonymous fields not yet supported (in %s)", t)
-			}
-			name, _ := typeinfo.JSONFieldName(f)
-			if name == "" {
-				continue
-			}
+		for _, f := range goFields {
+			name, _ := typeinfo.FieldName(f)
 			v, err := gts.defaultForType(f.Type)
 			if err != nil {
 				return nil, err
@@ -569,10 +994,24 @@ func (gts *goTypeSchema) defaultForType(t reflect.Type) (interface{}, error) {
 			fields[name] = v
 		}
 		return fields, nil
+	case reflect.Interface:
+		// The default for a union must be a value of its first member.
+		if members, ok := typeinfo.UnionMembers(t); ok && members[0] != nil {
+			return gts.defaultForType(members[0])
+		}
+		return nil, nil
 	default:
 		if def, ok := gts.defs[t]; ok {
 			if o, ok := def.schema.(map[string]interface{}); ok && o["type"] == "enum" {
-				return reflect.Zero(t).Interface().(fmt.Stringer).String(), nil
+				syms := o["symbols"].([]string)
+				if table, _ := enumValuesOf(t); table != nil {
+					// The default is the symbol for the zero
+					// value, or the first symbol if there's none.
+					if i, ok := table.indexes[0]; ok {
+						return syms[i], nil
+					}
+				}
+				return syms[0], nil
 			}
 		}
 		return reflect.Zero(t).Interface(), nil
@@ -1,7 +1,10 @@
 package avro_test
 
 import (
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"io"
 	"sync"
 	"testing"
 	"time"
@@ -149,6 +152,39 @@ func TestGoTypeWithJSONTags(t *testing.T) {
 	c.Assert(r, qt.Equals, R{A: 1, B: "hello"})
 }
 
+func TestGoTypeWithAvroTags(t *testing.T) {
+	c := qt.New(t)
+	type R struct {
+		A int    `avro:"something" json:"a"`
+		B string `json:"other"`
+		C int    `avro:"-" json:"c"`
+	}
+	data, wType, err := avro.Marshal(R{
+		A: 1,
+		B: "hello",
+		C: 2,
+	})
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(wType.String(), qt.JSONEquals, json.RawMessage(`{
+		"type": "record",
+		"name": "R",
+		"fields": [{
+			"default": 0,
+			"name": "something",
+			"type": "long"
+		}, {
+			"default": "",
+			"name": "other",
+			"type": "string"
+		}]
+	}`))
+
+	var r R
+	_, err = avro.Unmarshal(data, &r, wType)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(r, qt.Equals, R{A: 1, B: "hello"})
+}
+
 func TestGoTypeWithTime(t *testing.T) {
 	c := qt.New(t)
 	type R struct {
@@ -198,6 +234,78 @@ func TestGoTypeWithZeroTime(t *testing.T) {
 	}
 }
 
+func TestGoTypeWithSQLNullTypes(t *testing.T) {
+	c := qt.New(t)
+	type R struct {
+		A sql.NullString
+		B sql.NullInt64
+		C sql.NullInt32
+		D sql.NullFloat64
+		E sql.NullBool
+		F sql.NullTime
+	}
+	c.Assert(mustTypeOf(R{}).String(), qt.JSONEquals, json.RawMessage(`{
+		"type": "record",
+		"name": "R",
+		"fields": [{
+			"name": "A",
+			"default": null,
+			"type": ["null", "string"]
+		}, {
+			"name": "B",
+			"default": null,
+			"type": ["null", "long"]
+		}, {
+			"name": "C",
+			"default": null,
+			"type": ["null", "int"]
+		}, {
+			"name": "D",
+			"default": null,
+			"type": ["null", "double"]
+		}, {
+			"name": "E",
+			"default": null,
+			"type": ["null", "boolean"]
+		}, {
+			"name": "F",
+			"default": null,
+			"type": ["null", {"type": "long", "logicalType": "timestamp-micros"}]
+		}]
+	}`))
+	r := R{
+		A: sql.NullString{String: "hello", Valid: true},
+		C: sql.NullInt32{Int32: 99, Valid: true},
+		E: sql.NullBool{Bool: false, Valid: true},
+		F: sql.NullTime{Time: time.Date(2020, 1, 15, 18, 47, 8, 888888000, time.UTC), Valid: true},
+	}
+	data, wType, err := avro.Marshal(r)
+	c.Assert(err, qt.Equals, nil)
+	var x R
+	_, err = avro.Unmarshal(data, &x, wType)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(x, qt.DeepEquals, r)
+
+	// The encoding is the same as for pointers.
+	type P struct {
+		A *string
+		B *int64
+		C *int32
+		D *float64
+		E *bool
+		F *time.Time
+	}
+	var p P
+	_, err = avro.Unmarshal(data, &p, wType)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(*p.A, qt.Equals, "hello")
+	c.Assert(p.B, qt.IsNil)
+	c.Assert(*p.C, qt.Equals, int32(99))
+	c.Assert(p.D, qt.IsNil)
+	c.Assert(*p.E, qt.Equals, false)
+	c.Assert(p.F.Equal(r.F.Time), qt.Equals, true)
+}
+
 func TestGoTypeWithStructField(t *testing.T) {
 	c := qt.New(t)
 	type F2 struct {
@@ -402,6 +510,25 @@ func TestUnmarshalDoesNotCorruptData(t *testing.T) {
 	c.Assert(data, qt.DeepEquals, []byte(origData))
 }
 
+func TestUnmarshalTruncatedData(t *testing.T) {
+	c := qt.New(t)
+	type R struct {
+		A int
+		B string
+	}
+	data, wType, err := avro.Marshal(R{A: 1, B: "hello"})
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(data, qt.HasLen, 7)
+	var x R
+	_, err = avro.Unmarshal(data[:4], &x, wType)
+	c.Assert(err, qt.ErrorMatches, `unexpected EOF`)
+	c.Assert(errors.Is(err, io.ErrUnexpectedEOF), qt.Equals, true)
+	var derr *avro.DecodeError
+	c.Assert(errors.As(err, &derr), qt.Equals, true)
+	// The string length has been read, but not its contents.
+	c.Assert(derr.Offset, qt.Equals, int64(2))
+}
+
 type OOBPanicEnum int
 
 var enumValues = []string{"a", "b"}
@@ -418,3 +545,378 @@ func (e OOBEmptyEnum) String() string {
 	}
 	return enumValues[e]
 }
+
+func TestGoTypeWithDefaultTags(t *testing.T) {
+	c := qt.New(t)
+	type R struct {
+		A int      `avrodefault:"42"`
+		B string   `json:"b" avrodefault:"\"unknown\""`
+		C []string `avrodefault:"[\"x\", \"y\"]"`
+		D *int     `avrodefault:"null"`
+	}
+	at, err := avro.TypeOf(R{})
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(at.String(), qt.JSONEquals, json.RawMessage(`{
+		"type": "record",
+		"name": "R",
+		"fields": [{
+			"name": "A",
+			"default": 42,
+			"type": "long"
+		}, {
+			"name": "b",
+			"default": "unknown",
+			"type": "string"
+		}, {
+			"name": "C",
+			"default": ["x", "y"],
+			"type": {"type": "array", "items": "string"}
+		}, {
+			"name": "D",
+			"default": null,
+			"type": ["null", "long"]
+		}]
+	}`))
+
+	// Data written without the fields gets the
+	// defaults from the tags.
+	wType := mustParseType(`{"type": "record", "name": "R", "fields": []}`)
+	var x R
+	_, err = avro.Unmarshal(nil, &x, wType)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(x, qt.DeepEquals, R{
+		A: 42,
+		B: "unknown",
+		C: []string{"x", "y"},
+	})
+}
+
+func TestGoTypeWithDefaultTagsForAvroTypes(t *testing.T) {
+	c := qt.New(t)
+	// The tags hold defaults as Avro JSON, so an enum default
+	// is a symbol, bytes and fixed defaults are strings of code
+	// points and a timestamp default is a number.
+	type R struct {
+		E testtypes.Enum `avrodefault:"\"Two\""`
+		B []byte         `avrodefault:"\"\\u0001\\u00ff\""`
+		F [2]byte        `avrodefault:"\"a\\u00e9\""`
+		T time.Time      `avrodefault:"1500"`
+	}
+	at, err := avro.TypeOf(R{})
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(at.String(), qt.JSONEquals, json.RawMessage(`{
+		"type": "record",
+		"name": "R",
+		"fields": [{
+			"name": "E",
+			"default": "Two",
+			"type": {
+				"type": "enum",
+				"name": "Enum",
+				"symbols": ["One", "Two", "Three"]
+			}
+		}, {
+			"name": "B",
+			"default": "\u0001ÿ",
+			"type": "bytes"
+		}, {
+			"name": "F",
+			"default": "aé",
+			"type": {"type": "fixed", "name": "go.Fixed2", "size": 2}
+		}, {
+			"name": "T",
+			"default": 1500,
+			"type": {"type": "long", "logicalType": "timestamp-micros"}
+		}]
+	}`))
+
+	wType := mustParseType(`{"type": "record", "name": "R", "fields": []}`)
+	var x R
+	_, err = avro.Unmarshal(nil, &x, wType)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(x.E, qt.Equals, testtypes.EnumTwo)
+	c.Assert(x.B, qt.DeepEquals, []byte{1, 0xff})
+	c.Assert(x.F, qt.Equals, [2]byte{'a', 0xe9})
+	c.Assert(x.T.Equal(time.Unix(0, 1500*1000)), qt.Equals, true)
+
+	// Each decode gets its own copy of a default,
+	// so changing one doesn't affect the next.
+	x.B[0] = 99
+	var y R
+	_, err = avro.Unmarshal(nil, &y, wType)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(y.B, qt.DeepEquals, []byte{1, 0xff})
+}
+
+func TestGoTypeWithInvalidDefaultTags(t *testing.T) {
+	c := qt.New(t)
+	type R1 struct {
+		A int `avrodefault:"{"`
+	}
+	_, err := avro.TypeOf(R1{})
+	c.Assert(err, qt.ErrorMatches, `invalid avrodefault tag on field A: .*`)
+
+	type R2 struct {
+		A int `avrodefault:"\"x\""`
+	}
+	_, err = avro.TypeOf(R2{})
+	c.Assert(err, qt.ErrorMatches, `invalid avrodefault tag on field A: cannot use "x" as Avro type "long": .*`)
+
+	type R3 struct {
+		A *int `avrodefault:"1"`
+	}
+	_, err = avro.TypeOf(R3{})
+	c.Assert(err, qt.ErrorMatches, `avrodefault tag on nullable field A must be null`)
+
+	type R4 struct {
+		A testtypes.Enum `avrodefault:"\"Four\""`
+	}
+	_, err = avro.TypeOf(R4{})
+	c.Assert(err, qt.ErrorMatches, `invalid avrodefault tag on field A: .*`)
+
+	type R5 struct {
+		A []byte `avrodefault:"\"\u20ac\""`
+	}
+	_, err = avro.TypeOf(R5{})
+	c.Assert(err, qt.ErrorMatches, `invalid avrodefault tag on field A: .*`)
+}
+
+type documentedRecord struct {
+	A int    `avrodoc:"The number of things."`
+	B string `json:"b"`
+}
+
+func (documentedRecord) AvroDoc() string {
+	return "A record with documentation."
+}
+
+func TestGoTypeWithDoc(t *testing.T) {
+	c := qt.New(t)
+	at, err := avro.TypeOf(documentedRecord{})
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(at.String(), qt.JSONEquals, json.RawMessage(`{
+		"type": "record",
+		"name": "documentedRecord",
+		"doc": "A record with documentation.",
+		"fields": [{
+			"name": "A",
+			"doc": "The number of things.",
+			"default": 0,
+			"type": "long"
+		}, {
+			"name": "b",
+			"default": "",
+			"type": "string"
+		}]
+	}`))
+}
+
+type namespacedRecord struct {
+	A int
+}
+
+func (namespacedRecord) AvroNamespace() string {
+	return "com.example"
+}
+
+func TestGoTypeWithNamespace(t *testing.T) {
+	c := qt.New(t)
+	at, err := avro.TypeOf(namespacedRecord{})
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(at.Name(), qt.Equals, "com.example.namespacedRecord")
+	c.Assert(at.String(), qt.JSONEquals, json.RawMessage(`{
+		"type": "record",
+		"name": "com.example.namespacedRecord",
+		"fields": [{
+			"name": "A",
+			"default": 0,
+			"type": "long"
+		}]
+	}`))
+
+	data, wType, err := avro.Marshal(namespacedRecord{A: 99})
+	c.Assert(err, qt.Equals, nil)
+	var x namespacedRecord
+	_, err = avro.Unmarshal(data, &x, wType)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(x, qt.Equals, namespacedRecord{A: 99})
+}
+
+func TestGoTypeWithAliasTags(t *testing.T) {
+	c := qt.New(t)
+	type R struct {
+		Name  string `avroalias:"fullName,name"`
+		Count int    `json:"count" avroalias:"n"`
+	}
+	at, err := avro.TypeOf(R{})
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(at.String(), qt.JSONEquals, json.RawMessage(`{
+		"type": "record",
+		"name": "R",
+		"fields": [{
+			"name": "Name",
+			"aliases": ["fullName", "name"],
+			"default": "",
+			"type": "string"
+		}, {
+			"name": "count",
+			"aliases": ["n"],
+			"default": 0,
+			"type": "long"
+		}]
+	}`))
+
+	// Data written with the old field names is
+	// read into the renamed fields.
+	type OldR struct {
+		FullName string `json:"fullName"`
+		N        int    `json:"n"`
+	}
+	data, _, err := avro.Marshal(OldR{
+		FullName: "bob",
+		N:        3,
+	})
+	c.Assert(err, qt.Equals, nil)
+	wType := mustParseType(`{
+		"type": "record",
+		"name": "R",
+		"fields": [
+			{"name": "fullName", "type": "string"},
+			{"name": "n", "type": "long"}
+		]
+	}`)
+	var x R
+	_, err = avro.Unmarshal(data, &x, wType)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(x, qt.Equals, R{
+		Name:  "bob",
+		Count: 3,
+	})
+}
+
+func TestGoTypeWithEmbeddedStructs(t *testing.T) {
+	c := qt.New(t)
+	type Base struct {
+		ID   int
+		Name string
+	}
+	type Extra struct {
+		Name  string `json:"extraName"`
+		Count int
+	}
+	type R struct {
+		Base
+		Extra `avro:"extra"`
+		Count string
+	}
+	at, err := avro.TypeOf(R{})
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(at.String(), qt.JSONEquals, json.RawMessage(`{
+		"type": "record",
+		"name": "R",
+		"fields": [{
+			"name": "ID",
+			"default": 0,
+			"type": "long"
+		}, {
+			"name": "Name",
+			"default": "",
+			"type": "string"
+		}, {
+			"name": "extra",
+			"default": {"extraName": "", "Count": 0},
+			"type": {
+				"type": "record",
+				"name": "Extra",
+				"fields": [{
+					"name": "extraName",
+					"default": "",
+					"type": "string"
+				}, {
+					"name": "Count",
+					"default": 0,
+					"type": "long"
+				}]
+			}
+		}, {
+			"name": "Count",
+			"default": "",
+			"type": "string"
+		}]
+	}`))
+	x := R{
+		Base: Base{
+			ID:   1,
+			Name: "a",
+		},
+		Extra: Extra{
+			Name:  "b",
+			Count: 2,
+		},
+		Count: "c",
+	}
+	data, wType, err := avro.Marshal(x)
+	c.Assert(err, qt.Equals, nil)
+	var y R
+	_, err = avro.Unmarshal(data, &y, wType)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(y, qt.DeepEquals, x)
+}
+
+func TestGoTypeWithShadowedEmbeddedFields(t *testing.T) {
+	c := qt.New(t)
+	type Inner struct {
+		A int
+		B int
+	}
+	type R struct {
+		Inner
+		B string
+	}
+	at, err := avro.TypeOf(R{})
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(at.String(), qt.JSONEquals, json.RawMessage(`{
+		"type": "record",
+		"name": "R",
+		"fields": [
+			{"name": "A", "default": 0, "type": "long"},
+			{"name": "B", "default": "", "type": "string"}
+		]
+	}`))
+	x := R{
+		Inner: Inner{A: 1, B: 2},
+		B:     "b",
+	}
+	data, wType, err := avro.Marshal(x)
+	c.Assert(err, qt.Equals, nil)
+	var y R
+	_, err = avro.Unmarshal(data, &y, wType)
+	c.Assert(err, qt.Equals, nil)
+	// The shadowed field isn't encoded.
+	c.Assert(y, qt.DeepEquals, R{
+		Inner: Inner{A: 1},
+		B:     "b",
+	})
+}
+
+func TestGoTypeWithInvalidEmbeddedStructs(t *testing.T) {
+	c := qt.New(t)
+	type X1 struct {
+		A int
+	}
+	type X2 struct {
+		A string
+	}
+	type R1 struct {
+		X1
+		X2
+	}
+	_, err := avro.TypeOf(R1{})
+	c.Assert(err, qt.ErrorMatches, `ambiguous field name "A" in .*R1`)
+
+	type R2 struct {
+		*X1
+	}
+	_, err = avro.TypeOf(R2{})
+	c.Assert(err, qt.ErrorMatches, `embedded pointer field X1 in .*R2 not supported`)
+}
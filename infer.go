@@ -0,0 +1,271 @@
+package avro
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// InferOptions holds options for InferType.
+type InferOptions struct {
+	// Name holds the name of the top level record.
+	// If it's empty, "Record" is used.
+	Name string
+
+	// Namespace holds the namespace for all the
+	// inferred record types.
+	Namespace string
+}
+
+// InferType proposes a record type that can represent all the given
+// sample JSON documents, each of which must hold a JSON object. It's
+// intended to help bootstrap schemas for existing JSON data; the
+// result will usually need to be reviewed by hand.
+//
+// Types are inferred as follows:
+//
+//   - JSON strings and booleans map to "string" and "boolean".
+//   - JSON numbers map to "long" if all the values seen are
+//     integers, and "double" otherwise.
+//   - JSON arrays map to arrays with an item type inferred from
+//     all their elements.
+//   - JSON objects map to records, named after the field that holds them.
+//   - when values of several kinds are seen, a union is used.
+//   - a field that is null or absent in some samples is
+//     made optional with a union with null and a null default.
+//
+// If opts is nil, the zero value is used.
+func InferType(samples [][]byte, opts *InferOptions) (*Type, error) {
+	if opts == nil {
+		opts = &InferOptions{}
+	}
+	if len(samples) == 0 {
+		return nil, fmt.Errorf("no samples provided")
+	}
+	var top inferredType
+	for i, sample := range samples {
+		dec := json.NewDecoder(bytes.NewReader(sample))
+		dec.UseNumber()
+		var v interface{}
+		if err := dec.Decode(&v); err != nil {
+			return nil, fmt.Errorf("invalid JSON in sample %d: %v", i, err)
+		}
+		if _, ok := v.(map[string]interface{}); !ok {
+			return nil, fmt.Errorf("sample %d is not a JSON object", i)
+		}
+		if err := top.add(v); err != nil {
+			return nil, fmt.Errorf("sample %d: %v", i, err)
+		}
+	}
+	name := opts.Name
+	if name == "" {
+		name = "Record"
+	}
+	inf := &inferrer{
+		names: make(map[string]bool),
+	}
+	rec := inf.recordSchema(top.record, name)
+	if opts.Namespace != "" {
+		rec["namespace"] = opts.Namespace
+	}
+	data, err := json.Marshal(rec)
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal inferred schema: %v", err)
+	}
+	t, err := ParseType(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse inferred schema: %v", err)
+	}
+	return t, nil
+}
+
+// inferredType records all the kinds of JSON value
+// seen in a given position.
+type inferredType struct {
+	null    bool
+	boolean bool
+	long    bool
+	double  bool
+	string  bool
+	// array holds the inferred item type if an array has been seen.
+	array *inferredType
+	// record holds the inferred record if an object has been seen.
+	record *inferredRecord
+}
+
+type inferredRecord struct {
+	// count holds the number of objects seen.
+	count int
+	// fields holds the field names in the order they were first
+	// seen. Fields first seen in the same object are sorted
+	// because JSON object order is not preserved.
+	fields []string
+	byName map[string]*inferredField
+}
+
+type inferredField struct {
+	// count holds the number of objects that contained the field.
+	count int
+	t     inferredType
+}
+
+func (t *inferredType) add(v interface{}) error {
+	switch v := v.(type) {
+	case nil:
+		t.null = true
+	case bool:
+		t.boolean = true
+	case string:
+		t.string = true
+	case json.Number:
+		if _, err := v.Int64(); err == nil {
+			t.long = true
+		} else {
+			t.double = true
+		}
+	case []interface{}:
+		if t.array == nil {
+			t.array = &inferredType{}
+		}
+		for _, elem := range v {
+			if err := t.array.add(elem); err != nil {
+				return err
+			}
+		}
+	case map[string]interface{}:
+		if t.record == nil {
+			t.record = &inferredRecord{
+				byName: make(map[string]*inferredField),
+			}
+		}
+		r := t.record
+		r.count++
+		// Iterate over the fields in a deterministic order.
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			// Field names follow the same rules as enum symbols.
+			if !isValidEnumSymbol(name) {
+				return fmt.Errorf("cannot use %q as an Avro field name", name)
+			}
+			f := r.byName[name]
+			if f == nil {
+				f = &inferredField{}
+				r.byName[name] = f
+				r.fields = append(r.fields, name)
+			}
+			f.count++
+			if err := f.t.add(v[name]); err != nil {
+				return fmt.Errorf("field %q: %v", name, err)
+			}
+		}
+	default:
+		return fmt.Errorf("unexpected JSON value %T", v)
+	}
+	return nil
+}
+
+type inferrer struct {
+	// names holds all the record names used so far.
+	names map[string]bool
+}
+
+// schema returns the JSON-marshalable schema for t.
+// The name is used for any record type.
+func (inf *inferrer) schema(t *inferredType, name string) interface{} {
+	var members []interface{}
+	if t.null {
+		members = append(members, "null")
+	}
+	if t.boolean {
+		members = append(members, "boolean")
+	}
+	switch {
+	case t.double:
+		members = append(members, "double")
+	case t.long:
+		members = append(members, "long")
+	}
+	if t.string {
+		members = append(members, "string")
+	}
+	if t.array != nil {
+		members = append(members, map[string]interface{}{
+			"type":  "array",
+			"items": inf.schema(t.array, name),
+		})
+	}
+	if t.record != nil {
+		members = append(members, inf.recordSchema(t.record, name))
+	}
+	switch len(members) {
+	case 0:
+		// No values have been seen (for example the items of
+		// an empty array), so there's nothing better to use.
+		return "null"
+	case 1:
+		return members[0]
+	}
+	return members
+}
+
+func (inf *inferrer) recordSchema(r *inferredRecord, name string) map[string]interface{} {
+	name = inf.uniqueName(name)
+	fields := make([]interface{}, len(r.fields))
+	for i, fname := range r.fields {
+		f := r.byName[fname]
+		ftype := inf.schema(&f.t, strings.ToUpper(fname[:1])+fname[1:])
+		field := map[string]interface{}{
+			"name": fname,
+			"type": ftype,
+		}
+		if f.t.null || f.count < r.count {
+			field["type"] = optionalSchema(ftype)
+			field["default"] = nil
+		}
+		fields[i] = field
+	}
+	return map[string]interface{}{
+		"type":   "record",
+		"name":   name,
+		"fields": fields,
+	}
+}
+
+// uniqueName returns a record name based on name that
+// hasn't been used before.
+func (inf *inferrer) uniqueName(name string) string {
+	if name[0] == '_' {
+		name = "R" + name
+	}
+	name1 := name
+	for i := 2; inf.names[name1]; i++ {
+		name1 = fmt.Sprintf("%s%d", name, i)
+	}
+	inf.names[name1] = true
+	return name1
+}
+
+// optionalSchema returns a union schema with null as the
+// first member and the members of t following it.
+func optionalSchema(t interface{}) interface{} {
+	members, ok := t.([]interface{})
+	if !ok {
+		members = []interface{}{t}
+	}
+	result := []interface{}{"null"}
+	for _, m := range members {
+		if m != "null" {
+			result = append(result, m)
+		}
+	}
+	if len(result) == 1 {
+		return "null"
+	}
+	return result
+}
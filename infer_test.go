@@ -0,0 +1,111 @@
+package avro_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro"
+)
+
+var inferTypeTests = []struct {
+	testName    string
+	samples     []string
+	opts        *avro.InferOptions
+	expect      string
+	expectError string
+}{{
+	testName: "primitives",
+	samples:  []string{`{"a": 1, "b": "x", "c": true, "d": 1.5}`},
+	expect: `{
+	"type": "record",
+	"name": "Record",
+	"fields": [
+		{"name": "a", "type": "long"},
+		{"name": "b", "type": "string"},
+		{"name": "c", "type": "boolean"},
+		{"name": "d", "type": "double"}
+	]
+}`,
+}, {
+	testName: "optional-and-widened",
+	samples: []string{
+		`{"a": 1, "b": "x", "c": null}`,
+		`{"a": 2.5, "c": null, "e": [1, 2]}`,
+	},
+	opts: &avro.InferOptions{
+		Name:      "Event",
+		Namespace: "com.example",
+	},
+	expect: `{
+	"type": "record",
+	"name": "Event",
+	"namespace": "com.example",
+	"fields": [
+		{"name": "a", "type": "double"},
+		{"name": "b", "type": ["null", "string"], "default": null},
+		{"name": "c", "type": "null", "default": null},
+		{"name": "e", "type": ["null", {"type": "array", "items": "long"}], "default": null}
+	]
+}`,
+}, {
+	testName: "nested-records-and-unions",
+	samples: []string{
+		`{"addr": {"city": "x"}, "v": "x", "items": [{"id": 1}]}`,
+		`{"addr": {"city": "y", "zip": 1}, "v": 2, "items": []}`,
+	},
+	expect: `{
+	"type": "record",
+	"name": "Record",
+	"fields": [
+		{"name": "addr", "type": {
+			"type": "record",
+			"name": "Addr",
+			"fields": [
+				{"name": "city", "type": "string"},
+				{"name": "zip", "type": ["null", "long"], "default": null}
+			]
+		}},
+		{"name": "items", "type": {
+			"type": "array",
+			"items": {
+				"type": "record",
+				"name": "Items",
+				"fields": [{"name": "id", "type": "long"}]
+			}
+		}},
+		{"name": "v", "type": ["long", "string"]}
+	]
+}`,
+}, {
+	testName:    "not-object",
+	samples:     []string{`[1]`},
+	expectError: `sample 0 is not a JSON object`,
+}, {
+	testName:    "invalid-field-name",
+	samples:     []string{`{"a": {"first-name": "x"}}`},
+	expectError: `sample 0: field "a": cannot use "first-name" as an Avro field name`,
+}, {
+	testName:    "no-samples",
+	expectError: `no samples provided`,
+}}
+
+func TestInferType(t *testing.T) {
+	c := qt.New(t)
+	for _, test := range inferTypeTests {
+		c.Run(test.testName, func(c *qt.C) {
+			samples := make([][]byte, len(test.samples))
+			for i, s := range test.samples {
+				samples[i] = []byte(s)
+			}
+			at, err := avro.InferType(samples, test.opts)
+			if test.expectError != "" {
+				c.Assert(err, qt.ErrorMatches, test.expectError)
+				return
+			}
+			c.Assert(err, qt.Equals, nil)
+			c.Assert(at.String(), qt.JSONEquals, json.RawMessage(test.expect))
+		})
+	}
+}
@@ -0,0 +1,866 @@
+// Package avdl implements a parser for Avro IDL files.
+//
+// It understands protocols, imports, records, errors, enums,
+// fixed types, messages, doc comments and annotations.
+package avdl
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Protocol holds the result of parsing an IDL file.
+type Protocol struct {
+	// Name holds the name of the protocol.
+	Name string
+
+	// Namespace holds the namespace of the protocol, if any.
+	Namespace string
+
+	// Doc holds the documentation for the protocol, if any.
+	Doc string
+
+	// Imports holds the imports in the file, in the order
+	// they were declared.
+	Imports []Import
+
+	// Types holds the JSON-marshalable schema of each
+	// named type defined in the file, in the order they
+	// were declared. Every definition holds its full namespace,
+	// so each one can be parsed independently.
+	Types []map[string]interface{}
+
+	// Messages holds the messages defined by the protocol,
+	// in the order they were declared.
+	Messages []Message
+}
+
+// Message represents a message in a protocol. Its fields
+// correspond to those of a message in a JSON protocol
+// declaration.
+type Message struct {
+	// Name holds the name of the message.
+	Name string
+
+	// Doc holds the documentation for the message, if any.
+	Doc string
+
+	// Request holds the JSON-marshalable schema of each
+	// parameter of the message, in the same form as record
+	// fields.
+	Request []interface{}
+
+	// Response holds the JSON-marshalable schema of
+	// the response. It's "null" when no response is returned.
+	Response interface{}
+
+	// Errors holds the JSON-marshalable schema of each
+	// error that the message can return.
+	Errors []interface{}
+
+	// OneWay holds whether the message is one-way,
+	// which means that no response is sent.
+	OneWay bool
+}
+
+// Import represents an import statement.
+type Import struct {
+	// Kind holds the kind of import: "idl", "schema" or "protocol".
+	Kind string
+
+	// Path holds the path of the imported file as written
+	// in the import statement.
+	Path string
+}
+
+// Parse parses the IDL in data. The filename is
+// used for error messages only.
+func Parse(filename string, data []byte) (*Protocol, error) {
+	p := &parser{
+		data: data,
+	}
+	proto, err := p.protocol()
+	if err != nil {
+		line := bytes.Count(data[:p.tokPos], []byte("\n")) + 1
+		return nil, fmt.Errorf("%s:%d: %v", filename, line, err)
+	}
+	return proto, nil
+}
+
+// token kinds.
+const (
+	tokEOF = iota
+	tokIdent
+	tokString
+	tokNumber
+	tokPunct
+)
+
+type parser struct {
+	data []byte
+	pos  int
+
+	// tok holds the current token.
+	tok     int
+	tokText string
+	// tokPos holds the offset of the start of the current token.
+	tokPos int
+	// doc holds the text of the most recent doc comment
+	// seen before the current token.
+	doc string
+}
+
+// annotations holds annotations, in the order seen.
+type annotations struct {
+	names  []string
+	values map[string]interface{}
+}
+
+func (a *annotations) add(name string, val interface{}) {
+	if a.values == nil {
+		a.values = make(map[string]interface{})
+	}
+	if _, ok := a.values[name]; !ok {
+		a.names = append(a.names, name)
+	}
+	a.values[name] = val
+}
+
+// take removes the annotation with the given name
+// and returns its value.
+func (a *annotations) take(name string) (interface{}, bool) {
+	val, ok := a.values[name]
+	if !ok {
+		return nil, false
+	}
+	delete(a.values, name)
+	for i, n := range a.names {
+		if n == name {
+			a.names = append(a.names[:i:i], a.names[i+1:]...)
+			break
+		}
+	}
+	return val, true
+}
+
+// setAll sets all the annotations as attributes of m.
+func (a *annotations) setAll(m map[string]interface{}) {
+	for _, name := range a.names {
+		m[name] = a.values[name]
+	}
+}
+
+func (p *parser) protocol() (*Protocol, error) {
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	doc := p.doc
+	annots, err := p.annotations()
+	if err != nil {
+		return nil, err
+	}
+	if p.doc != "" {
+		doc = p.doc
+	}
+	if err := p.keyword("protocol"); err != nil {
+		return nil, err
+	}
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	proto := &Protocol{
+		Name: name,
+		Doc:  doc,
+	}
+	if ns, ok := annots.take("namespace"); ok {
+		s, ok := ns.(string)
+		if !ok {
+			return nil, fmt.Errorf("namespace annotation must hold a string")
+		}
+		proto.Namespace = s
+	}
+	if err := p.punct("{"); err != nil {
+		return nil, err
+	}
+	for !p.isPunct("}") {
+		if p.tok == tokEOF {
+			return nil, fmt.Errorf("unexpected EOF in protocol")
+		}
+		if err := p.declaration(proto); err != nil {
+			return nil, err
+		}
+	}
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	if p.tok != tokEOF {
+		return nil, fmt.Errorf("unexpected %s after protocol", p.tokString())
+	}
+	return proto, nil
+}
+
+// declaration parses a single declaration inside a protocol.
+func (p *parser) declaration(proto *Protocol) error {
+	doc := p.doc
+	annots, err := p.annotations()
+	if err != nil {
+		return err
+	}
+	if p.doc != "" {
+		doc = p.doc
+	}
+	if p.tok == tokIdent {
+		switch p.tokText {
+		case "import":
+			if err := p.next(); err != nil {
+				return err
+			}
+			kind, err := p.ident()
+			if err != nil {
+				return err
+			}
+			switch kind {
+			case "idl", "schema", "protocol":
+			default:
+				return fmt.Errorf("unknown import kind %q", kind)
+			}
+			path, err := p.str()
+			if err != nil {
+				return err
+			}
+			proto.Imports = append(proto.Imports, Import{
+				Kind: kind,
+				Path: path,
+			})
+			return p.punct(";")
+		case "record", "error", "enum", "fixed":
+			def, err := p.namedType(doc, annots, proto.Namespace)
+			if err != nil {
+				return err
+			}
+			proto.Types = append(proto.Types, def)
+			return nil
+		}
+	}
+	m, err := p.message(doc)
+	if err != nil {
+		return err
+	}
+	proto.Messages = append(proto.Messages, m)
+	return nil
+}
+
+// namedType parses a record, error, enum or fixed declaration.
+func (p *parser) namedType(doc string, annots annotations, namespace string) (map[string]interface{}, error) {
+	kind := p.tokText
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	def := map[string]interface{}{
+		"type": kind,
+		"name": name,
+	}
+	if ns, ok := annots.take("namespace"); ok {
+		s, ok := ns.(string)
+		if !ok {
+			return nil, fmt.Errorf("namespace annotation must hold a string")
+		}
+		namespace = s
+	}
+	if namespace != "" && !strings.Contains(name, ".") {
+		def["namespace"] = namespace
+	}
+	if doc != "" {
+		def["doc"] = doc
+	}
+	annots.setAll(def)
+	switch kind {
+	case "record", "error":
+		fields, err := p.fields()
+		if err != nil {
+			return nil, err
+		}
+		def["fields"] = fields
+	case "enum":
+		syms, err := p.symbols()
+		if err != nil {
+			return nil, err
+		}
+		def["symbols"] = syms
+		if p.isPunct("=") {
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+			sym, err := p.ident()
+			if err != nil {
+				return nil, err
+			}
+			def["default"] = sym
+			if err := p.punct(";"); err != nil {
+				return nil, err
+			}
+		}
+	case "fixed":
+		if err := p.punct("("); err != nil {
+			return nil, err
+		}
+		size, err := p.number()
+		if err != nil {
+			return nil, err
+		}
+		def["size"] = size
+		if err := p.punct(")"); err != nil {
+			return nil, err
+		}
+		if err := p.punct(";"); err != nil {
+			return nil, err
+		}
+	}
+	return def, nil
+}
+
+func (p *parser) fields() ([]interface{}, error) {
+	if err := p.punct("{"); err != nil {
+		return nil, err
+	}
+	fields := []interface{}{}
+	for !p.isPunct("}") {
+		doc := p.doc
+		t, err := p.typ()
+		if err != nil {
+			return nil, err
+		}
+		for {
+			field, err := p.variable(t, doc)
+			if err != nil {
+				return nil, err
+			}
+			fields = append(fields, field)
+			if !p.isPunct(",") {
+				break
+			}
+			if err := p.next(); err != nil {
+				return nil, err
+			}
+		}
+		if err := p.punct(";"); err != nil {
+			return nil, err
+		}
+	}
+	return fields, p.next()
+}
+
+// variable parses the name, annotations and default value of
+// a field or message parameter of type t. The doc comment
+// before the type is used unless there's one before the name.
+func (p *parser) variable(t interface{}, doc string) (map[string]interface{}, error) {
+	if p.doc != "" {
+		doc = p.doc
+	}
+	annots, err := p.annotations()
+	if err != nil {
+		return nil, err
+	}
+	name, err := p.ident()
+	if err != nil {
+		return nil, err
+	}
+	field := map[string]interface{}{
+		"name": name,
+		"type": t,
+	}
+	if doc != "" {
+		field["doc"] = doc
+	}
+	annots.setAll(field)
+	if p.isPunct("=") {
+		val, err := p.jsonValue()
+		if err != nil {
+			return nil, err
+		}
+		field["default"] = val
+	}
+	return field, nil
+}
+
+func (p *parser) symbols() ([]interface{}, error) {
+	if err := p.punct("{"); err != nil {
+		return nil, err
+	}
+	syms := []interface{}{}
+	for !p.isPunct("}") {
+		if len(syms) > 0 {
+			if err := p.punct(","); err != nil {
+				return nil, err
+			}
+		}
+		sym, err := p.ident()
+		if err != nil {
+			return nil, err
+		}
+		syms = append(syms, sym)
+	}
+	return syms, p.next()
+}
+
+// message parses a message declaration.
+func (p *parser) message(doc string) (Message, error) {
+	m := Message{
+		Doc:     doc,
+		Request: []interface{}{},
+	}
+	if p.isKeyword("void") {
+		if err := p.next(); err != nil {
+			return Message{}, err
+		}
+		m.Response = "null"
+	} else {
+		t, err := p.typ()
+		if err != nil {
+			return Message{}, err
+		}
+		m.Response = t
+	}
+	name, err := p.ident()
+	if err != nil {
+		return Message{}, err
+	}
+	m.Name = name
+	if err := p.punct("("); err != nil {
+		return Message{}, err
+	}
+	for !p.isPunct(")") {
+		if len(m.Request) > 0 {
+			if err := p.punct(","); err != nil {
+				return Message{}, err
+			}
+		}
+		doc := p.doc
+		t, err := p.typ()
+		if err != nil {
+			return Message{}, err
+		}
+		param, err := p.variable(t, doc)
+		if err != nil {
+			return Message{}, err
+		}
+		m.Request = append(m.Request, param)
+	}
+	if err := p.next(); err != nil {
+		return Message{}, err
+	}
+	switch {
+	case p.isKeyword("oneway"):
+		if err := p.next(); err != nil {
+			return Message{}, err
+		}
+		m.OneWay = true
+	case p.isKeyword("throws"):
+		if err := p.next(); err != nil {
+			return Message{}, err
+		}
+		for {
+			t, err := p.typ()
+			if err != nil {
+				return Message{}, err
+			}
+			m.Errors = append(m.Errors, t)
+			if !p.isPunct(",") {
+				break
+			}
+			if err := p.next(); err != nil {
+				return Message{}, err
+			}
+		}
+	}
+	if err := p.punct(";"); err != nil {
+		return Message{}, err
+	}
+	return m, nil
+}
+
+// logicalTypes maps from IDL logical type keywords
+// to their equivalent schemas.
+var logicalTypes = map[string]map[string]interface{}{
+	"date": {
+		"type":        "int",
+		"logicalType": "date",
+	},
+	"time_ms": {
+		"type":        "int",
+		"logicalType": "time-millis",
+	},
+	"timestamp_ms": {
+		"type":        "long",
+		"logicalType": "timestamp-millis",
+	},
+	"local_timestamp_ms": {
+		"type":        "long",
+		"logicalType": "local-timestamp-millis",
+	},
+	"uuid": {
+		"type":        "string",
+		"logicalType": "uuid",
+	},
+}
+
+// typ parses a type, including any annotations before it.
+func (p *parser) typ() (interface{}, error) {
+	annots, err := p.annotations()
+	if err != nil {
+		return nil, err
+	}
+	t, err := p.typ1()
+	if err != nil {
+		return nil, err
+	}
+	if p.isPunct("?") {
+		if err := p.next(); err != nil {
+			return nil, err
+		}
+		t = []interface{}{"null", t}
+	}
+	if len(annots.names) == 0 {
+		return t, nil
+	}
+	m, ok := t.(map[string]interface{})
+	if !ok {
+		s, ok := t.(string)
+		if !ok {
+			return nil, fmt.Errorf("annotations not allowed on union type")
+		}
+		m = map[string]interface{}{
+			"type": s,
+		}
+	}
+	annots.setAll(m)
+	return m, nil
+}
+
+func (p *parser) typ1() (interface{}, error) {
+	if p.tok != tokIdent {
+		return nil, fmt.Errorf("expected type, found %s", p.tokString())
+	}
+	name := p.tokText
+	if err := p.next(); err != nil {
+		return nil, err
+	}
+	switch name {
+	case "null", "boolean", "int", "long", "float", "double", "bytes", "string":
+		return name, nil
+	case "array", "map":
+		if err := p.punct("<"); err != nil {
+			return nil, err
+		}
+		elem, err := p.typ()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.punct(">"); err != nil {
+			return nil, err
+		}
+		if name == "array" {
+			return map[string]interface{}{
+				"type":  "array",
+				"items": elem,
+			}, nil
+		}
+		return map[string]interface{}{
+			"type":   "map",
+			"values": elem,
+		}, nil
+	case "union":
+		if err := p.punct("{"); err != nil {
+			return nil, err
+		}
+		members := []interface{}{}
+		for !p.isPunct("}") {
+			if len(members) > 0 {
+				if err := p.punct(","); err != nil {
+					return nil, err
+				}
+			}
+			t, err := p.typ()
+			if err != nil {
+				return nil, err
+			}
+			members = append(members, t)
+		}
+		return members, p.next()
+	case "decimal":
+		if err := p.punct("("); err != nil {
+			return nil, err
+		}
+		precision, err := p.number()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.punct(","); err != nil {
+			return nil, err
+		}
+		scale, err := p.number()
+		if err != nil {
+			return nil, err
+		}
+		if err := p.punct(")"); err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{
+			"type":        "bytes",
+			"logicalType": "decimal",
+			"precision":   precision,
+			"scale":       scale,
+		}, nil
+	}
+	if lt, ok := logicalTypes[name]; ok {
+		m := make(map[string]interface{})
+		for k, v := range lt {
+			m[k] = v
+		}
+		return m, nil
+	}
+	// It's a reference to a named type.
+	return name, nil
+}
+
+// annotations parses any annotations at the current position.
+func (p *parser) annotations() (annotations, error) {
+	var a annotations
+	for p.isPunct("@") {
+		name, err := p.annotationName()
+		if err != nil {
+			return a, err
+		}
+		if err := p.punct("("); err != nil {
+			return a, err
+		}
+		// The opening parenthesis has been consumed, so
+		// the current token is the start of the value.
+		val, err := p.jsonValueAt(p.tokPos)
+		if err != nil {
+			return a, err
+		}
+		if err := p.punct(")"); err != nil {
+			return a, err
+		}
+		a.add(name, val)
+	}
+	return a, nil
+}
+
+// annotationName reads the name of an annotation directly
+// after the @ token. Annotation names can contain
+// dots and hyphens as well as identifier characters.
+func (p *parser) annotationName() (string, error) {
+	start := p.pos
+	for p.pos < len(p.data) {
+		r, n := utf8.DecodeRune(p.data[p.pos:])
+		if !isIdentRune(r) && r != '.' && r != '-' {
+			break
+		}
+		p.pos += n
+	}
+	name := string(p.data[start:p.pos])
+	if name == "" {
+		return "", fmt.Errorf("expected annotation name after @")
+	}
+	return name, p.next()
+}
+
+// jsonValue parses the JSON value following the current token.
+func (p *parser) jsonValue() (interface{}, error) {
+	return p.jsonValueAt(p.pos)
+}
+
+// jsonValueAt parses a JSON value starting at the given offset
+// and moves to the token following it.
+func (p *parser) jsonValueAt(off int) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(p.data[off:]))
+	var val interface{}
+	if err := dec.Decode(&val); err != nil {
+		return nil, fmt.Errorf("invalid JSON value: %v", err)
+	}
+	p.pos = off + int(dec.InputOffset())
+	return val, p.next()
+}
+
+func (p *parser) keyword(k string) error {
+	if !p.isKeyword(k) {
+		return fmt.Errorf("expected %q, found %s", k, p.tokString())
+	}
+	return p.next()
+}
+
+func (p *parser) isKeyword(k string) bool {
+	return p.tok == tokIdent && p.tokText == k
+}
+
+func (p *parser) punct(s string) error {
+	if !p.isPunct(s) {
+		return fmt.Errorf("expected %q, found %s", s, p.tokString())
+	}
+	return p.next()
+}
+
+func (p *parser) isPunct(s string) bool {
+	return p.tok == tokPunct && p.tokText == s
+}
+
+func (p *parser) ident() (string, error) {
+	if p.tok != tokIdent {
+		return "", fmt.Errorf("expected identifier, found %s", p.tokString())
+	}
+	s := p.tokText
+	return s, p.next()
+}
+
+func (p *parser) str() (string, error) {
+	if p.tok != tokString {
+		return "", fmt.Errorf("expected string, found %s", p.tokString())
+	}
+	var s string
+	if err := json.Unmarshal([]byte(p.tokText), &s); err != nil {
+		return "", fmt.Errorf("invalid string %s: %v", p.tokText, err)
+	}
+	return s, p.next()
+}
+
+func (p *parser) number() (int, error) {
+	if p.tok != tokNumber {
+		return 0, fmt.Errorf("expected number, found %s", p.tokString())
+	}
+	var n int
+	if _, err := fmt.Sscan(p.tokText, &n); err != nil {
+		return 0, fmt.Errorf("invalid number %s", p.tokText)
+	}
+	return n, p.next()
+}
+
+func (p *parser) tokString() string {
+	switch p.tok {
+	case tokEOF:
+		return "EOF"
+	case tokIdent:
+		return fmt.Sprintf("identifier %q", p.tokText)
+	}
+	return fmt.Sprintf("%q", p.tokText)
+}
+
+// next reads the next token.
+func (p *parser) next() error {
+	p.doc = ""
+	if err := p.skipSpace(); err != nil {
+		return err
+	}
+	p.tokPos = p.pos
+	if p.pos >= len(p.data) {
+		p.tok, p.tokText = tokEOF, ""
+		return nil
+	}
+	r, n := utf8.DecodeRune(p.data[p.pos:])
+	switch {
+	case r == '`':
+		end := bytes.IndexByte(p.data[p.pos+1:], '`')
+		if end < 0 {
+			return fmt.Errorf("unterminated quoted identifier")
+		}
+		p.tok, p.tokText = tokIdent, string(p.data[p.pos+1:p.pos+1+end])
+		p.pos += end + 2
+	case isIdentRune(r) && !unicode.IsDigit(r):
+		start := p.pos
+		for p.pos < len(p.data) {
+			r, n := utf8.DecodeRune(p.data[p.pos:])
+			if !isIdentRune(r) && r != '.' {
+				break
+			}
+			p.pos += n
+		}
+		p.tok, p.tokText = tokIdent, string(p.data[start:p.pos])
+	case unicode.IsDigit(r) || r == '-':
+		start := p.pos
+		p.pos += n
+		for p.pos < len(p.data) && strings.IndexByte("0123456789.eE+-", p.data[p.pos]) >= 0 {
+			p.pos++
+		}
+		p.tok, p.tokText = tokNumber, string(p.data[start:p.pos])
+	case r == '"':
+		start := p.pos
+		p.pos++
+		for {
+			if p.pos >= len(p.data) || p.data[p.pos] == '\n' {
+				return fmt.Errorf("unterminated string")
+			}
+			c := p.data[p.pos]
+			p.pos++
+			if c == '\\' {
+				p.pos++
+			} else if c == '"' {
+				break
+			}
+		}
+		p.tok, p.tokText = tokString, string(p.data[start:p.pos])
+	default:
+		p.pos += n
+		p.tok, p.tokText = tokPunct, string(r)
+	}
+	return nil
+}
+
+// skipSpace skips white space and comments, recording
+// the text of the last doc comment in p.doc.
+func (p *parser) skipSpace() error {
+	for p.pos < len(p.data) {
+		switch c := p.data[p.pos]; {
+		case c == ' ' || c == '\n' || c == '\t' || c == '\r':
+			p.pos++
+		case bytes.HasPrefix(p.data[p.pos:], []byte("//")):
+			end := bytes.IndexByte(p.data[p.pos:], '\n')
+			if end < 0 {
+				p.pos = len(p.data)
+			} else {
+				p.pos += end
+			}
+		case bytes.HasPrefix(p.data[p.pos:], []byte("/*")):
+			end := bytes.Index(p.data[p.pos+2:], []byte("*/"))
+			if end < 0 {
+				return fmt.Errorf("unterminated comment")
+			}
+			comment := p.data[p.pos+2 : p.pos+2+end]
+			p.pos += end + 4
+			if len(comment) > 0 && comment[0] == '*' {
+				p.doc = docText(string(comment[1:]))
+			}
+		default:
+			return nil
+		}
+	}
+	return nil
+}
+
+// docText returns the text of a doc comment with
+// surrounding white space and leading asterisks removed.
+func docText(s string) string {
+	lines := strings.Split(s, "\n")
+	for i, line := range lines {
+		line = strings.TrimSpace(line)
+		if i > 0 {
+			line = strings.TrimPrefix(line, "*")
+			line = strings.TrimPrefix(line, " ")
+		}
+		lines[i] = line
+	}
+	return strings.TrimSpace(strings.Join(lines, "\n"))
+}
+
+func isIdentRune(r rune) bool {
+	return r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r)
+}
@@ -0,0 +1,277 @@
+package avdl_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro/internal/avdl"
+)
+
+var parseTests = []struct {
+	testName       string
+	idl            string
+	expectDoc      string
+	expectImports  []avdl.Import
+	expectTypes    string
+	expectMessages []avdl.Message
+	expectError    string
+}{{
+	testName: "all-types",
+	idl: `
+/** The P protocol. */
+@namespace("org.example")
+protocol P {
+	import idl "other.avdl";
+	import schema "other.avsc";
+
+	/** A fixed type. */
+	fixed MD5(16);
+
+	enum Color {
+		red, green, blue
+	} = red;
+
+	// An ordinary comment.
+	@aliases(["OldR"])
+	record R {
+		/** The name. */
+		string name;
+		int a = 1, b = 2;
+		union { null, string } opt = null;
+		long? short;
+		array<map<bytes>> am = [];
+		@logicalType("timestamp-micros") long t;
+		decimal(10, 2) amount;
+		date d;
+		Color c = "green";
+		MD5 ` + "`error`" + `;
+		@go.package("example.com/x") other.Ext ext;
+	}
+
+	error Oops {
+		string message;
+	}
+
+	void ping(string x = "a)b");
+	/** Gets an R. */
+	R get(int id, string @order("ignore") name) throws Oops, Oops2;
+	void notify(Color c) oneway;
+}
+`,
+	expectDoc: "The P protocol.",
+	expectImports: []avdl.Import{{
+		Kind: "idl",
+		Path: "other.avdl",
+	}, {
+		Kind: "schema",
+		Path: "other.avsc",
+	}},
+	expectTypes: `[{
+		"type": "fixed",
+		"name": "MD5",
+		"namespace": "org.example",
+		"doc": "A fixed type.",
+		"size": 16
+	}, {
+		"type": "enum",
+		"name": "Color",
+		"namespace": "org.example",
+		"symbols": ["red", "green", "blue"],
+		"default": "red"
+	}, {
+		"type": "record",
+		"name": "R",
+		"namespace": "org.example",
+		"aliases": ["OldR"],
+		"fields": [{
+			"name": "name",
+			"doc": "The name.",
+			"type": "string"
+		}, {
+			"name": "a",
+			"type": "int",
+			"default": 1
+		}, {
+			"name": "b",
+			"type": "int",
+			"default": 2
+		}, {
+			"name": "opt",
+			"type": ["null", "string"],
+			"default": null
+		}, {
+			"name": "short",
+			"type": ["null", "long"]
+		}, {
+			"name": "am",
+			"type": {
+				"type": "array",
+				"items": {
+					"type": "map",
+					"values": "bytes"
+				}
+			},
+			"default": []
+		}, {
+			"name": "t",
+			"type": {
+				"type": "long",
+				"logicalType": "timestamp-micros"
+			}
+		}, {
+			"name": "amount",
+			"type": {
+				"type": "bytes",
+				"logicalType": "decimal",
+				"precision": 10,
+				"scale": 2
+			}
+		}, {
+			"name": "d",
+			"type": {
+				"type": "int",
+				"logicalType": "date"
+			}
+		}, {
+			"name": "c",
+			"type": "Color",
+			"default": "green"
+		}, {
+			"name": "error",
+			"type": "MD5"
+		}, {
+			"name": "ext",
+			"type": {
+				"type": "other.Ext",
+				"go.package": "example.com/x"
+			}
+		}]
+	}, {
+		"type": "error",
+		"name": "Oops",
+		"namespace": "org.example",
+		"fields": [{
+			"name": "message",
+			"type": "string"
+		}]
+	}]`,
+	expectMessages: []avdl.Message{{
+		Name: "ping",
+		Request: []interface{}{
+			map[string]interface{}{
+				"name":    "x",
+				"type":    "string",
+				"default": "a)b",
+			},
+		},
+		Response: "null",
+	}, {
+		Name: "get",
+		Doc:  "Gets an R.",
+		Request: []interface{}{
+			map[string]interface{}{
+				"name": "id",
+				"type": "int",
+			},
+			map[string]interface{}{
+				"name":  "name",
+				"type":  "string",
+				"order": "ignore",
+			},
+		},
+		Response: "R",
+		Errors:   []interface{}{"Oops", "Oops2"},
+	}, {
+		Name: "notify",
+		Request: []interface{}{
+			map[string]interface{}{
+				"name": "c",
+				"type": "Color",
+			},
+		},
+		Response: "null",
+		OneWay:   true,
+	}},
+}, {
+	testName: "no-namespace",
+	idl: `
+protocol P {
+	record R {
+		R? next = null;
+	}
+	@namespace("x") enum E { A }
+}
+`,
+	expectTypes: `[{
+		"type": "record",
+		"name": "R",
+		"fields": [{
+			"name": "next",
+			"type": ["null", "R"],
+			"default": null
+		}]
+	}, {
+		"type": "enum",
+		"name": "E",
+		"namespace": "x",
+		"symbols": ["A"]
+	}]`,
+}, {
+	testName: "missing-semicolon",
+	idl: `
+protocol P {
+	record R {
+		int a
+	}
+}
+`,
+	expectError: `test.avdl:5: expected ";", found "}"`,
+}, {
+	testName: "bad-default",
+	idl: `
+protocol P {
+	record R {
+		int a = ;
+	}
+}
+`,
+	expectError: `test.avdl:4: invalid JSON value: .*`,
+}, {
+	testName: "bad-message",
+	idl: `
+protocol P {
+	void ping(int a int b);
+}
+`,
+	expectError: `test.avdl:3: expected ",", found identifier "int"`,
+}, {
+	testName:    "no-protocol",
+	idl:         `record R {}`,
+	expectError: `test.avdl:1: expected "protocol", found identifier "record"`,
+}, {
+	testName:    "bad-import",
+	idl:         `protocol P { import foo "x"; }`,
+	expectError: `test.avdl:1: unknown import kind "foo"`,
+}}
+
+func TestParse(t *testing.T) {
+	c := qt.New(t)
+	for _, test := range parseTests {
+		c.Run(test.testName, func(c *qt.C) {
+			proto, err := avdl.Parse("test.avdl", []byte(test.idl))
+			if test.expectError != "" {
+				c.Assert(err, qt.ErrorMatches, test.expectError)
+				return
+			}
+			c.Assert(err, qt.IsNil)
+			c.Assert(proto.Doc, qt.Equals, test.expectDoc)
+			c.Assert(proto.Imports, qt.DeepEquals, test.expectImports)
+			data, err := json.Marshal(proto.Types)
+			c.Assert(err, qt.IsNil)
+			c.Assert(string(data), qt.JSONEquals, json.RawMessage(test.expectTypes))
+			c.Assert(proto.Messages, qt.DeepEquals, test.expectMessages)
+		})
+	}
+}
@@ -0,0 +1,252 @@
+// Package ocf implements the framing of Avro object container files:
+// the file header, the blocks that follow it and their compression.
+// It's shared by the avroocf package and the object container file
+// support in the avro package's Transcoder, which can't import avroocf.
+package ocf
+
+import (
+	"bufio"
+	"bytes"
+	"compress/flate"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"sort"
+)
+
+const (
+	// NullCodec is the codec name used for uncompressed blocks.
+	NullCodec = "null"
+
+	// DeflateCodec is the codec name used for blocks compressed
+	// with the deflate algorithm (RFC 1951).
+	DeflateCodec = "deflate"
+)
+
+// Metadata keys reserved by the Avro specification.
+const (
+	SchemaKey = "avro.schema"
+	CodecKey  = "avro.codec"
+)
+
+var magic = []byte("Obj\x01")
+
+// maxBlockSize holds the largest block we're prepared to read.
+const maxBlockSize = 1 << 28
+
+// Header holds the parts of an object container file header
+// that don't depend on the interpretation of the schema.
+type Header struct {
+	// Schema holds the schema of the values in the file, as JSON.
+	Schema []byte
+
+	// Codec holds the name of the codec used to compress blocks.
+	Codec string
+
+	// Meta holds all the metadata in the header, including
+	// the avro.schema and avro.codec entries.
+	Meta map[string][]byte
+
+	// Sync holds the sync marker that follows each block.
+	Sync [16]byte
+}
+
+// ReadHeader reads a file header from r.
+func ReadHeader(r *bufio.Reader) (*Header, error) {
+	var m [4]byte
+	if _, err := io.ReadFull(r, m[:]); err != nil || !bytes.Equal(m[:], magic) {
+		return nil, fmt.Errorf("not an Avro object container file")
+	}
+	meta, err := readMeta(r)
+	if err != nil {
+		return nil, fmt.Errorf("cannot read file header: %v", err)
+	}
+	h := &Header{
+		Meta:  meta,
+		Codec: NullCodec,
+	}
+	if codec, ok := meta[CodecKey]; ok && len(codec) > 0 {
+		h.Codec = string(codec)
+	}
+	schema, ok := meta[SchemaKey]
+	if !ok {
+		return nil, fmt.Errorf("no schema found in file header")
+	}
+	h.Schema = schema
+	if _, err := io.ReadFull(r, h.Sync[:]); err != nil {
+		return nil, fmt.Errorf("cannot read file header: %v", unexpectedEOF(err))
+	}
+	return h, nil
+}
+
+// ReadBlock reads the next block of a file with the given header
+// from r. It returns the number of values in the block, the size
+// of the block in the file and its data after decompression.
+// It returns io.EOF when there are no more blocks.
+func ReadBlock(r *bufio.Reader, h *Header) (count, size int, data []byte, err error) {
+	count64, err := binary.ReadVarint(r)
+	if err != nil {
+		if err == io.EOF {
+			return 0, 0, nil, io.EOF
+		}
+		return 0, 0, nil, fmt.Errorf("cannot read block: %v", unexpectedEOF(err))
+	}
+	size64, err := binary.ReadVarint(r)
+	if err != nil {
+		return 0, 0, nil, fmt.Errorf("cannot read block: %v", unexpectedEOF(err))
+	}
+	if count64 < 0 || size64 < 0 || size64 > maxBlockSize {
+		return 0, 0, nil, fmt.Errorf("invalid block header (count %d, size %d)", count64, size64)
+	}
+	data = make([]byte, size64)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return 0, 0, nil, fmt.Errorf("cannot read block: %v", unexpectedEOF(err))
+	}
+	var sync [16]byte
+	if _, err := io.ReadFull(r, sync[:]); err != nil {
+		return 0, 0, nil, fmt.Errorf("cannot read block: %v", unexpectedEOF(err))
+	}
+	if sync != h.Sync {
+		return 0, 0, nil, fmt.Errorf("block has invalid sync marker")
+	}
+	data, err = decompress(h.Codec, data)
+	if err != nil {
+		return 0, 0, nil, err
+	}
+	return int(count64), int(size64), data, nil
+}
+
+// CheckCodec returns an error if blocks can't be
+// compressed with the given codec.
+func CheckCodec(codec string) error {
+	_, err := compress(codec, nil)
+	return err
+}
+
+// AppendHeader appends the encoding of h to buf. The Schema and
+// Codec fields are ignored; they must already be present in h.Meta.
+func AppendHeader(buf []byte, h *Header) []byte {
+	buf = append(buf, magic...)
+	keys := make([]string, 0, len(h.Meta))
+	for key := range h.Meta {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	buf = appendVarint(buf, int64(len(keys)))
+	for _, key := range keys {
+		buf = appendBytes(buf, []byte(key))
+		buf = appendBytes(buf, h.Meta[key])
+	}
+	buf = appendVarint(buf, 0)
+	return append(buf, h.Sync[:]...)
+}
+
+// AppendBlock appends a block holding count values with the
+// given binary encoding to buf, compressing it with h.Codec.
+func AppendBlock(buf []byte, h *Header, count int, data []byte) ([]byte, error) {
+	data, err := compress(h.Codec, data)
+	if err != nil {
+		return nil, err
+	}
+	buf = appendVarint(buf, int64(count))
+	buf = appendBytes(buf, data)
+	return append(buf, h.Sync[:]...), nil
+}
+
+func compress(codec string, data []byte) ([]byte, error) {
+	switch codec {
+	case NullCodec:
+		return data, nil
+	case DeflateCodec:
+		var buf bytes.Buffer
+		w, err := flate.NewWriter(&buf, flate.DefaultCompression)
+		if err != nil {
+			return nil, err
+		}
+		w.Write(data)
+		if err := w.Close(); err != nil {
+			return nil, err
+		}
+		return buf.Bytes(), nil
+	}
+	return nil, fmt.Errorf("unsupported codec %q", codec)
+}
+
+func decompress(codec string, data []byte) ([]byte, error) {
+	switch codec {
+	case NullCodec:
+		return data, nil
+	case DeflateCodec:
+		data, err := ioutil.ReadAll(flate.NewReader(bytes.NewReader(data)))
+		if err != nil {
+			return nil, fmt.Errorf("cannot decompress block: %v", err)
+		}
+		return data, nil
+	}
+	return nil, fmt.Errorf("unsupported codec %q", codec)
+}
+
+// readMeta reads the metadata map from the file header.
+func readMeta(r *bufio.Reader) (map[string][]byte, error) {
+	meta := make(map[string][]byte)
+	for {
+		count, err := binary.ReadVarint(r)
+		if err != nil {
+			return nil, unexpectedEOF(err)
+		}
+		if count == 0 {
+			return meta, nil
+		}
+		if count < 0 {
+			// A negative count is followed by the size of the block in bytes.
+			count = -count
+			if _, err := binary.ReadVarint(r); err != nil {
+				return nil, unexpectedEOF(err)
+			}
+		}
+		for ; count > 0; count-- {
+			key, err := readBytes(r)
+			if err != nil {
+				return nil, err
+			}
+			val, err := readBytes(r)
+			if err != nil {
+				return nil, err
+			}
+			meta[string(key)] = val
+		}
+	}
+}
+
+func readBytes(r *bufio.Reader) ([]byte, error) {
+	n, err := binary.ReadVarint(r)
+	if err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	if n < 0 || n > maxBlockSize {
+		return nil, fmt.Errorf("length out of range: %d", n)
+	}
+	data := make([]byte, n)
+	if _, err := io.ReadFull(r, data); err != nil {
+		return nil, unexpectedEOF(err)
+	}
+	return data, nil
+}
+
+func appendBytes(buf, data []byte) []byte {
+	buf = appendVarint(buf, int64(len(data)))
+	return append(buf, data...)
+}
+
+func appendVarint(buf []byte, x int64) []byte {
+	var b [binary.MaxVarintLen64]byte
+	return append(buf, b[:binary.PutVarint(b[:], x)]...)
+}
+
+func unexpectedEOF(err error) error {
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	}
+	return err
+}
@@ -1,4 +1,9 @@
 // Code generated by avrogen. DO NOT EDIT.
+//
+// Schema fingerprints:
+//	com.heetch.CloudEvent 0x5535be83f60c2efc
+//	com.heetch.Message 0xc6228355901f6578
+//	com.heetch.Metadata 0x9ba5f007d92d8c11
 
 package testtypes
 
@@ -1,10 +1,14 @@
 package typeinfo
 
 import (
+	"database/sql"
 	"fmt"
 	"log"
+	"math/big"
 	"reflect"
 	"strings"
+	"sync"
+	"time"
 
 	"github.com/heetch/avro/avrotypegen"
 )
@@ -19,9 +23,11 @@ type Info struct {
 	// FieldName holds the Avro name of the field.
 	FieldName string
 
-	// FieldIndex holds the index of the field if this entry is about
-	// a struct field.
-	FieldIndex int
+	// FieldIndex holds the index sequence of the field, as used
+	// by reflect.Value.FieldByIndex, if this entry is about a struct
+	// field. It has more than one element for a field promoted
+	// from an embedded struct.
+	FieldIndex []int
 
 	// MakeDefault is a function that returns the default
 	// value for a field, or nil if there is no default value.
@@ -31,6 +37,10 @@ type Info struct {
 	// (if not, it's about a struct).
 	IsUnion bool
 
+	// UnionName holds the Avro name of the union member
+	// that this info is about, if known.
+	UnionName string
+
 	// Entries holds the possible types that can
 	// be descended in from this type.
 	// For structs (records) this holds an entry
@@ -62,31 +72,30 @@ func ForType(t reflect.Type) (Info, error) {
 		if v, ok := reflect.Zero(t).Interface().(avrotypegen.AvroRecord); ok {
 			r = v.AvroRecord()
 		}
-		for i := 0; i < t.NumField(); i++ {
-			f := t.Field(i)
-			if f.Anonymous {
-				// TODO consider struct embedding.
-				// https://github.com/heetch/avro/issues/40
-				return Info{}, fmt.Errorf("anonymous fields not supported")
-			}
-			if shouldOmitField(f) {
-				continue
-			}
+		fields, err := Fields(t)
+		if err != nil {
+			return Info{}, err
+		}
+		for _, f := range fields {
 			var required bool
 			var makeDefault func() reflect.Value
 			var unionInfo avrotypegen.UnionInfo
-			if i < len(r.Required) {
-				required = r.Required[i]
-			}
-			if i < len(r.Defaults) {
-				if md := r.Defaults[i]; md != nil {
-					makeDefault = func() reflect.Value {
-						return reflect.ValueOf(md())
+			// Generated types don't embed structs, so only
+			// top level fields have entries in the RecordInfo.
+			if i := f.Index[0]; len(f.Index) == 1 {
+				if i < len(r.Required) {
+					required = r.Required[i]
+				}
+				if i < len(r.Defaults) {
+					if md := r.Defaults[i]; md != nil {
+						makeDefault = func() reflect.Value {
+							return reflect.ValueOf(md())
+						}
 					}
 				}
-			}
-			if i < len(r.Unions) {
-				unionInfo = r.Unions[i]
+				if i < len(r.Unions) {
+					unionInfo = r.Unions[i]
+				}
 			}
 			entry := forField(f, required, makeDefault, unionInfo)
 			info.Entries = append(info.Entries, entry)
@@ -109,14 +118,10 @@ func ForType(t reflect.Type) (Info, error) {
 
 func forField(f reflect.StructField, required bool, makeDefault func() reflect.Value, unionInfo avrotypegen.UnionInfo) Info {
 	t := f.Type
-	if t.Kind() == reflect.Ptr && len(unionInfo.Union) == 0 {
-		// It's a pointer but there's no explicit union entry, which means that
-		// the union defaults to ["null", type]
-		unionInfo.Union = []avrotypegen.UnionInfo{{
-			Type: nil,
-		}, {
-			Type: reflect.New(t.Elem()).Interface(),
-		}}
+	if len(unionInfo.Union) == 0 {
+		// There's no explicit union entry, so infer
+		// the union, if any, from the Go type.
+		unionInfo.Union = implicitUnion(t)
 	}
 	// Make an appropriate makeDefault function, even when one isn't explicitly specified.
 	switch {
@@ -124,7 +129,7 @@ func forField(f reflect.StructField, required bool, makeDefault func() reflect.V
 		// Keep to the letter of the contract (makeDefault should always
 		// be nil in this case anyway).
 		makeDefault = nil
-	case makeDefault == nil && len(unionInfo.Union) > 0:
+	case makeDefault == nil && len(unionInfo.Union) > 0 && !isContainer(t):
 		// It's a ["null", T] union - we can infer the default
 		// value from the field type. The default value is the
 		// zero value of the first member of the union.
@@ -144,10 +149,10 @@ func forField(f reflect.StructField, required bool, makeDefault func() reflect.V
 			return v
 		}
 	}
-	name, _ := JSONFieldName(f)
+	name, _ := FieldName(f)
 	info := Info{
 		Type:        t,
-		FieldIndex:  f.Index[0],
+		FieldIndex:  f.Index,
 		FieldName:   name,
 		MakeDefault: makeDefault,
 	}
@@ -155,6 +160,54 @@ func forField(f reflect.StructField, required bool, makeDefault func() reflect.V
 	return info
 }
 
+// implicitUnion returns the members of the union represented by the
+// Go type t when there's no explicit union info for it, or nil if t
+// doesn't represent a union. A pointer or a nullable type represents
+// ["null", T] and a registered interface type represents the union of
+// its registered members. The union can also be in the items of a slice
+// or the values of a map, as with generated code.
+func implicitUnion(t reflect.Type) []avrotypegen.UnionInfo {
+	for isContainer(t) {
+		t = t.Elem()
+	}
+	if t.Kind() == reflect.Ptr && t != ratType {
+		return []avrotypegen.UnionInfo{{
+			Type: nil,
+		}, {
+			Type: reflect.New(t.Elem()).Interface(),
+		}}
+	}
+	if vt, ok := NullableValueType(t); ok {
+		// Similarly to pointers, a nullable type defaults to ["null", type].
+		return []avrotypegen.UnionInfo{{
+			Type: nil,
+		}, {
+			Type: reflect.New(vt).Interface(),
+		}}
+	}
+	if members, ok := UnionMembers(t); ok {
+		union := make([]avrotypegen.UnionInfo, len(members))
+		for i, mt := range members {
+			if mt != nil {
+				union[i].Type = reflect.New(mt).Interface()
+			}
+		}
+		return union
+	}
+	return nil
+}
+
+// isContainer reports whether t represents an Avro array or map.
+func isContainer(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Slice:
+		return t.Elem() != byteType
+	case reflect.Map:
+		return true
+	}
+	return false
+}
+
 func setUnionInfo(info *Info, unionInfo avrotypegen.UnionInfo) {
 	if len(unionInfo.Union) == 0 {
 		return
@@ -167,28 +220,171 @@ func setUnionInfo(info *Info, unionInfo avrotypegen.UnionInfo) {
 			ut = reflect.TypeOf(u.Type).Elem()
 		}
 		info.Entries[i] = Info{
-			Type: ut,
+			Type:      ut,
+			UnionName: u.Name,
 		}
 		setUnionInfo(&info.Entries[i], u)
 	}
 }
 
-func shouldOmitField(f reflect.StructField) bool {
-	name, _ := JSONFieldName(f)
-	return name == ""
+// ratType is the Go type used for decimal values, which
+// doesn't imply a union with null even though it's a pointer.
+var ratType = reflect.TypeOf((*big.Rat)(nil))
+
+// byteType is the type of the items of a slice that represents bytes.
+var byteType = reflect.TypeOf(byte(0))
+
+// nullableTypes maps from each of the nullable types defined
+// by the database/sql package and the Optional types defined
+// by the avrotypegen package to the type of the value it holds.
+var nullableTypes = map[reflect.Type]reflect.Type{
+	reflect.TypeOf(sql.NullBool{}):    reflect.TypeOf(false),
+	reflect.TypeOf(sql.NullInt32{}):   reflect.TypeOf(int32(0)),
+	reflect.TypeOf(sql.NullInt64{}):   reflect.TypeOf(int64(0)),
+	reflect.TypeOf(sql.NullFloat64{}): reflect.TypeOf(float64(0)),
+	reflect.TypeOf(sql.NullString{}):  reflect.TypeOf(""),
+	reflect.TypeOf(sql.NullTime{}):    reflect.TypeOf(time.Time{}),
+
+	reflect.TypeOf(avrotypegen.OptionalBool{}):    reflect.TypeOf(false),
+	reflect.TypeOf(avrotypegen.OptionalInt{}):     reflect.TypeOf(int(0)),
+	reflect.TypeOf(avrotypegen.OptionalInt64{}):   reflect.TypeOf(int64(0)),
+	reflect.TypeOf(avrotypegen.OptionalFloat32{}): reflect.TypeOf(float32(0)),
+	reflect.TypeOf(avrotypegen.OptionalFloat64{}): reflect.TypeOf(float64(0)),
+	reflect.TypeOf(avrotypegen.OptionalString{}):  reflect.TypeOf(""),
+	reflect.TypeOf(avrotypegen.OptionalBytes{}):   reflect.TypeOf([]byte(nil)),
+	reflect.TypeOf(avrotypegen.OptionalTime{}):    reflect.TypeOf(time.Time{}),
+}
+
+// NullableValueType reports whether t is one of the nullable
+// types defined by database/sql, such as sql.NullString, or one
+// of the Optional types defined by avrotypegen, and if so,
+// returns the type of the value that it holds. Such types represent
+// a union of null and their value type, like pointers. The value is held
+// in the first field of the struct and the second field (Valid) reports
+// whether the value is non-null.
+func NullableValueType(t reflect.Type) (reflect.Type, bool) {
+	vt, ok := nullableTypes[t]
+	return vt, ok
 }
 
-// JSONFieldName returns the name that the field will be given
-// when marshaled to JSON, or the empty string if
-// the field is ignored.
+// unionTypes maps from each interface type registered with
+// RegisterUnion to the types of its members.
+var unionTypes sync.Map // map[reflect.Type][]reflect.Type
+
+// RegisterUnion registers the interface type t as representing
+// a union with members of the given types. A nil member type
+// stands for null.
+func RegisterUnion(t reflect.Type, members []reflect.Type) {
+	unionTypes.Store(t, members)
+}
+
+// UnionMembers returns the types of the members of the union
+// represented by the interface type t, if it's been registered
+// with RegisterUnion.
+func UnionMembers(t reflect.Type) ([]reflect.Type, bool) {
+	members, ok := unionTypes.Load(t)
+	if !ok {
+		return nil, false
+	}
+	return members.([]reflect.Type), true
+}
+
+// Fields returns the fields of the struct type t that are
+// represented in Avro, in order, with the fields of embedded structs
+// flattened into it as the encoding/json package does. The Index of
+// each returned field holds its index sequence within t.
+//
+// An embedded struct without a name in its tag contributes its fields
+// rather than a field of its own. A field hides fields with the same
+// Avro name that are more deeply embedded; Fields returns an error if
+// several fields with the same name are embedded at the same depth,
+// or if a struct is embedded by pointer.
+func Fields(t reflect.Type) ([]reflect.StructField, error) {
+	type field struct {
+		reflect.StructField
+		name  string
+		depth int
+	}
+	var fields []field
+	var walk func(t reflect.Type, index []int) error
+	walk = func(t reflect.Type, index []int) error {
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			f.Index = append(append([]int(nil), index...), i)
+			if f.Anonymous {
+				tag, ok := f.Tag.Lookup("avro")
+				if !ok {
+					tag = f.Tag.Get("json")
+				}
+				if tag == "-" {
+					continue
+				}
+				ft := f.Type
+				if strings.Split(tag, ",")[0] == "" && (ft.Kind() == reflect.Struct || ft.Kind() == reflect.Ptr && ft.Elem().Kind() == reflect.Struct) {
+					if ft.Kind() == reflect.Ptr {
+						return fmt.Errorf("embedded pointer field %s in %s not supported", f.Name, t)
+					}
+					if err := walk(ft, f.Index); err != nil {
+						return err
+					}
+					continue
+				}
+			}
+			name, _ := FieldName(f)
+			if name == "" {
+				continue
+			}
+			fields = append(fields, field{
+				StructField: f,
+				name:        name,
+				depth:       len(f.Index),
+			})
+		}
+		return nil
+	}
+	if err := walk(t, nil); err != nil {
+		return nil, err
+	}
+	// Find the shallowest depth for each name.
+	minDepth := make(map[string]int)
+	for _, f := range fields {
+		if d, ok := minDepth[f.name]; !ok || f.depth < d {
+			minDepth[f.name] = f.depth
+		}
+	}
+	result := make([]reflect.StructField, 0, len(fields))
+	found := make(map[string]bool)
+	for _, f := range fields {
+		if f.depth != minDepth[f.name] {
+			continue
+		}
+		if found[f.name] {
+			return nil, fmt.Errorf("ambiguous field name %q in %s", f.name, t)
+		}
+		found[f.name] = true
+		result = append(result, f.StructField)
+	}
+	return result, nil
+}
+
+// FieldName returns the Avro name of the field, or the empty
+// string if the field is ignored. The name is taken from the
+// field's "avro" tag if present, or from its "json" tag otherwise,
+// in the same way that the encoding/json package does, so
+// the JSON and Avro names of a field can be different.
+// A field with the tag avro:"-" is ignored even if it
+// has a json tag.
 // It also reports whether the field has been qualified with
 // the "omitempty" qualifier.
-func JSONFieldName(f reflect.StructField) (name string, omitEmpty bool) {
+func FieldName(f reflect.StructField) (name string, omitEmpty bool) {
 	if f.PkgPath != "" {
 		// It's unexported.
 		return "", false
 	}
-	tag := f.Tag.Get("json")
+	tag, ok := f.Tag.Lookup("avro")
+	if !ok {
+		tag = f.Tag.Get("json")
+	}
 	parts := strings.Split(tag, ",")
 	for _, part := range parts[1:] {
 		if part == "omitempty" {
@@ -204,6 +400,39 @@ func JSONFieldName(f reflect.StructField) (name string, omitEmpty bool) {
 	return parts[0], omitEmpty
 }
 
+// HasOption reports whether the tag that FieldName takes the
+// field's name from includes the given qualifier, such as "omitempty".
+func HasOption(f reflect.StructField, option string) bool {
+	for _, part := range tagOptions(f) {
+		if part == option {
+			return true
+		}
+	}
+	return false
+}
+
+// OptionValue returns the value of a qualifier of the form key=value
+// in the tag that FieldName takes the field's name from, and reports
+// whether it was found.
+func OptionValue(f reflect.StructField, key string) (string, bool) {
+	for _, part := range tagOptions(f) {
+		if strings.HasPrefix(part, key+"=") {
+			return part[len(key)+1:], true
+		}
+	}
+	return "", false
+}
+
+// tagOptions returns the qualifiers that follow the
+// name in the field's "avro" or "json" tag.
+func tagOptions(f reflect.StructField) []string {
+	tag, ok := f.Tag.Lookup("avro")
+	if !ok {
+		tag = f.Tag.Get("json")
+	}
+	return strings.Split(tag, ",")[1:]
+}
+
 const debugging = false
 
 func debugf(f string, a ...interface{}) {
@@ -0,0 +1,659 @@
+package avro
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+)
+
+// JSONSchemaOptions holds options for FromJSONSchema.
+type JSONSchemaOptions struct {
+	// Name holds the name of the top level type when
+	// the schema has no title. If it's empty, "Record" is used.
+	Name string
+
+	// Namespace holds the namespace for all the
+	// resulting named types.
+	Namespace string
+}
+
+// FromJSONSchema converts a JSON Schema document (draft-07 or
+// 2020-12) into an Avro type. It's intended to help derive Avro
+// schemas for data that's described by JSON Schema; because JSON
+// Schema describes constraints on values rather than their
+// representation, the conversion is necessarily lossy.
+//
+// Types are converted as follows:
+//
+//   - "boolean", "string" and "null" map to the same Avro types.
+//   - "integer" maps to "long", or "int" with format "int32".
+//   - "number" maps to "double", or "float" with format "float".
+//   - strings with format "date", "date-time" or "uuid" map to the date,
+//     timestamp-micros and uuid logical types.
+//   - string enums whose values are all valid Avro names map to enums.
+//   - "array" maps to an array of its items.
+//   - "object" with properties maps to a record, named after the schema's
+//     title or the property that holds it. Properties that aren't required
+//     are made optional with a union with null and a null default. Required
+//     properties keep their default values.
+//   - "object" with an additionalProperties schema and no properties
+//     maps to a map.
+//   - several types, oneOf and anyOf map to unions.
+//   - allOf is supported when all its members are objects, whose
+//     properties are merged.
+//   - local references ("#", "#/definitions/..." and "#/$defs/...")
+//     are followed, and references to records and enums refer to
+//     the same named type, so recursive schemas are supported.
+//   - the description keyword maps to the doc attribute.
+//
+// The following information is lost: validation keywords
+// such as minimum, maxLength and pattern; other formats;
+// const values; defaults of properties that aren't required;
+// enum values that aren't valid Avro names (the enum becomes a string);
+// characters that aren't valid in Avro names, which are replaced by
+// underscores. Tuple arrays, objects without properties or an
+// additionalProperties schema, "not", conditional schemas and
+// non-local references are rejected.
+//
+// If opts is nil, the zero value is used.
+func FromJSONSchema(data []byte, opts *JSONSchemaOptions) (*Type, error) {
+	if opts == nil {
+		opts = &JSONSchemaOptions{}
+	}
+	name := opts.Name
+	if name == "" {
+		name = "Record"
+	}
+	c := &jsonSchemaConverter{
+		inf: &inferrer{
+			names: make(map[string]bool),
+		},
+		root:     json.RawMessage(data),
+		rootName: name,
+		refs:     make(map[string]*jsonSchemaRef),
+	}
+	// Convert the top level schema as a reference to
+	// itself so that it can be referred to recursively.
+	v, err := c.convertRef("#", "")
+	if err != nil {
+		return nil, err
+	}
+	if obj, ok := v.(map[string]interface{}); ok && opts.Namespace != "" && obj["name"] != nil {
+		obj["namespace"] = opts.Namespace
+	}
+	data, err = json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal converted schema: %v", err)
+	}
+	t, err := ParseType(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse converted schema: %v", err)
+	}
+	return t, nil
+}
+
+// jsonSchema holds the JSON Schema keywords used by FromJSONSchema.
+type jsonSchema struct {
+	Ref                  string                     `json:"$ref"`
+	Type                 json.RawMessage            `json:"type"`
+	Title                string                     `json:"title"`
+	Description          string                     `json:"description"`
+	Format               string                     `json:"format"`
+	Properties           json.RawMessage            `json:"properties"`
+	Required             []string                   `json:"required"`
+	AdditionalProperties json.RawMessage            `json:"additionalProperties"`
+	Items                json.RawMessage            `json:"items"`
+	PrefixItems          json.RawMessage            `json:"prefixItems"`
+	Enum                 []interface{}              `json:"enum"`
+	Default              json.RawMessage            `json:"default"`
+	OneOf                []json.RawMessage          `json:"oneOf"`
+	AnyOf                []json.RawMessage          `json:"anyOf"`
+	AllOf                []json.RawMessage          `json:"allOf"`
+	Not                  json.RawMessage            `json:"not"`
+	If                   json.RawMessage            `json:"if"`
+	Definitions          map[string]json.RawMessage `json:"definitions"`
+	Defs                 map[string]json.RawMessage `json:"$defs"`
+}
+
+type jsonSchemaConverter struct {
+	inf      *inferrer
+	root     json.RawMessage
+	rootName string
+	refs     map[string]*jsonSchemaRef
+
+	// exactName holds a name that has already been made unique
+	// for a referenced type that's about to be defined.
+	exactName string
+}
+
+// jsonSchemaRef holds the conversion state of a referenced schema.
+type jsonSchemaRef struct {
+	// name holds the Avro name of the type if
+	// the schema converts to a named type.
+	name string
+	// converting is true while the schema is being converted.
+	converting bool
+}
+
+// convert returns the JSON-marshalable Avro schema for the JSON
+// Schema in raw. Named types are named after name unless the
+// schema has a title. The path argument is used for error messages only.
+func (c *jsonSchemaConverter) convert(raw json.RawMessage, name, path string) (interface{}, error) {
+	s, err := parseJSONSchema(raw, path)
+	if err != nil {
+		return nil, err
+	}
+	return c.convertSchema(s, typeNameFor(s, name), path)
+}
+
+// convertSchema is like convert except that it's given the parsed
+// schema, and name holds the name to use for the top level type
+// if it's a named type.
+func (c *jsonSchemaConverter) convertSchema(s *jsonSchema, name, path string) (interface{}, error) {
+	switch {
+	case s.Ref != "":
+		return c.convertRef(s.Ref, path)
+	case s.Not != nil || s.If != nil:
+		return nil, fmt.Errorf("cannot convert conditional schema at %q", displayPath(path))
+	case len(s.AllOf) > 0:
+		merged, err := c.mergeAllOf(s, path)
+		if err != nil {
+			return nil, err
+		}
+		return c.convertSchema(merged, name, path)
+	case len(s.OneOf) > 0 || len(s.AnyOf) > 0:
+		var members []interface{}
+		add := func(keyword string, schemas []json.RawMessage) error {
+			for i, m := range schemas {
+				v, err := c.convert(m, name, fmt.Sprintf("%s/%s/%d", path, keyword, i))
+				if err != nil {
+					return err
+				}
+				members = append(members, v)
+			}
+			return nil
+		}
+		if err := add("oneOf", s.OneOf); err != nil {
+			return nil, err
+		}
+		if err := add("anyOf", s.AnyOf); err != nil {
+			return nil, err
+		}
+		return unionSchema(members), nil
+	}
+	types, err := s.types(path)
+	if err != nil {
+		return nil, err
+	}
+	members := make([]interface{}, len(types))
+	for i, t := range types {
+		members[i], err = c.convertType(s, t, name, path)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return unionSchema(members), nil
+}
+
+// convertType returns the Avro schema for values of
+// JSON type t described by s.
+func (c *jsonSchemaConverter) convertType(s *jsonSchema, t, name, path string) (interface{}, error) {
+	switch t {
+	case "null", "boolean":
+		return t, nil
+	case "integer":
+		if s.Format == "int32" {
+			return "int", nil
+		}
+		return "long", nil
+	case "number":
+		if s.Format == "float" {
+			return "float", nil
+		}
+		return "double", nil
+	case "string":
+		if symbols, ok := jsonEnumSymbols(s.Enum); ok {
+			return withDoc(map[string]interface{}{
+				"type":    "enum",
+				"name":    c.defineName(name),
+				"symbols": symbols,
+			}, s.Description), nil
+		}
+		switch s.Format {
+		case "date":
+			return map[string]interface{}{"type": "int", "logicalType": "date"}, nil
+		case "date-time":
+			return map[string]interface{}{"type": "long", "logicalType": timestampMicros}, nil
+		case "uuid":
+			return map[string]interface{}{"type": "string", "logicalType": "uuid"}, nil
+		}
+		return "string", nil
+	case "array":
+		if s.PrefixItems != nil || bytes.HasPrefix(bytes.TrimSpace(s.Items), []byte("[")) {
+			return nil, fmt.Errorf("cannot convert tuple array at %q", displayPath(path))
+		}
+		if s.Items == nil {
+			return nil, fmt.Errorf("array at %q has no items schema", displayPath(path))
+		}
+		items, err := c.convert(s.Items, name, path+"/items")
+		if err != nil {
+			return nil, err
+		}
+		return map[string]interface{}{
+			"type":  "array",
+			"items": items,
+		}, nil
+	case "object":
+		if s.Properties != nil {
+			return c.recordSchema(s, name, path)
+		}
+		if ap := bytes.TrimSpace(s.AdditionalProperties); len(ap) > 0 && ap[0] == '{' {
+			values, err := c.convert(s.AdditionalProperties, name, path+"/additionalProperties")
+			if err != nil {
+				return nil, err
+			}
+			return map[string]interface{}{
+				"type":   "map",
+				"values": values,
+			}, nil
+		}
+		return nil, fmt.Errorf("cannot convert object without properties or additionalProperties schema at %q", displayPath(path))
+	}
+	return nil, fmt.Errorf("unknown type %q at %q", t, displayPath(path))
+}
+
+func (c *jsonSchemaConverter) recordSchema(s *jsonSchema, name, path string) (interface{}, error) {
+	// Define the name before converting the fields so
+	// that they can refer to the record recursively.
+	name = c.defineName(name)
+	keys, props, err := objectMembers(s.Properties)
+	if err != nil {
+		return nil, fmt.Errorf("invalid properties at %q: %v", displayPath(path), err)
+	}
+	required := make(map[string]bool)
+	for _, r := range s.Required {
+		required[r] = true
+	}
+	fields := make([]interface{}, 0, len(keys))
+	fieldNames := make(map[string]string)
+	for _, key := range keys {
+		fname := avroName(key)
+		if other, ok := fieldNames[fname]; ok {
+			return nil, fmt.Errorf("properties %q and %q at %q both map to field %q", other, key, displayPath(path), fname)
+		}
+		fieldNames[fname] = key
+		ppath := path + "/properties/" + key
+		ps, err := parseJSONSchema(props[key], ppath)
+		if err != nil {
+			return nil, err
+		}
+		ftype, err := c.convertSchema(ps, typeNameFor(ps, strings.ToUpper(fname[:1])+fname[1:]), ppath)
+		if err != nil {
+			return nil, err
+		}
+		field := withDoc(map[string]interface{}{
+			"name": fname,
+			"type": ftype,
+		}, ps.Description)
+		if !required[key] {
+			field["type"] = optionalSchema(ftype)
+			field["default"] = nil
+		} else if ps.Default != nil {
+			field["default"] = ps.Default
+		}
+		fields = append(fields, field)
+	}
+	return withDoc(map[string]interface{}{
+		"type":   "record",
+		"name":   name,
+		"fields": fields,
+	}, s.Description), nil
+}
+
+// convertRef returns the Avro schema for the schema
+// referred to by ref.
+func (c *jsonSchemaConverter) convertRef(ref, path string) (interface{}, error) {
+	if r := c.refs[ref]; r != nil {
+		if r.name != "" {
+			return r.name, nil
+		}
+		if r.converting {
+			return nil, fmt.Errorf("recursive reference %q at %q does not refer to an object or enum", ref, displayPath(path))
+		}
+	}
+	raw, defName, err := c.resolveRef(ref)
+	if err != nil {
+		return nil, fmt.Errorf("cannot resolve %q at %q: %v", ref, displayPath(path), err)
+	}
+	s, err := parseJSONSchema(raw, ref)
+	if err != nil {
+		return nil, err
+	}
+	r := &jsonSchemaRef{
+		converting: true,
+	}
+	c.refs[ref] = r
+	name := typeNameFor(s, defName)
+	if s.isNamed() {
+		name = c.inf.uniqueName(name)
+		r.name = name
+		c.exactName = name
+	}
+	v, err := c.convertSchema(s, name, ref)
+	r.converting = false
+	return v, err
+}
+
+// defineName returns a unique name for a named type
+// based on name.
+func (c *jsonSchemaConverter) defineName(name string) string {
+	if name == c.exactName {
+		c.exactName = ""
+		return name
+	}
+	return c.inf.uniqueName(name)
+}
+
+// resolveRef returns the schema referred to by the local
+// reference ref, and the name of its definition.
+func (c *jsonSchemaConverter) resolveRef(ref string) (json.RawMessage, string, error) {
+	if ref == "#" {
+		return c.root, c.rootName, nil
+	}
+	var root jsonSchema
+	if err := json.Unmarshal(c.root, &root); err != nil {
+		return nil, "", err
+	}
+	var defs map[string]json.RawMessage
+	var name string
+	switch {
+	case strings.HasPrefix(ref, "#/definitions/"):
+		defs, name = root.Definitions, strings.TrimPrefix(ref, "#/definitions/")
+	case strings.HasPrefix(ref, "#/$defs/"):
+		defs, name = root.Defs, strings.TrimPrefix(ref, "#/$defs/")
+	default:
+		return nil, "", fmt.Errorf("only local references to definitions are supported")
+	}
+	raw, ok := defs[name]
+	if !ok {
+		return nil, "", fmt.Errorf("definition not found")
+	}
+	return raw, avroName(name), nil
+}
+
+// mergeAllOf returns the schema s with the members of its
+// allOf keyword merged into it. All the members must
+// be objects.
+func (c *jsonSchemaConverter) mergeAllOf(s *jsonSchema, path string) (*jsonSchema, error) {
+	merged := *s
+	merged.AllOf = nil
+	var keys []string
+	props := make(map[string]json.RawMessage)
+	addProps := func(raw json.RawMessage) error {
+		if raw == nil {
+			return nil
+		}
+		k, p, err := objectMembers(raw)
+		if err != nil {
+			return err
+		}
+		for _, key := range k {
+			if _, ok := props[key]; !ok {
+				keys = append(keys, key)
+			}
+			props[key] = p[key]
+		}
+		return nil
+	}
+	if err := addProps(s.Properties); err != nil {
+		return nil, fmt.Errorf("invalid properties at %q: %v", displayPath(path), err)
+	}
+	for i, raw := range s.AllOf {
+		mpath := fmt.Sprintf("%s/allOf/%d", path, i)
+		m, err := parseJSONSchema(raw, mpath)
+		if err != nil {
+			return nil, err
+		}
+		for m.Ref != "" {
+			raw, _, err = c.resolveRef(m.Ref)
+			if err != nil {
+				return nil, fmt.Errorf("cannot resolve %q at %q: %v", m.Ref, displayPath(mpath), err)
+			}
+			if m, err = parseJSONSchema(raw, m.Ref); err != nil {
+				return nil, err
+			}
+		}
+		if len(m.AllOf) > 0 {
+			if m, err = c.mergeAllOf(m, mpath); err != nil {
+				return nil, err
+			}
+		}
+		if types, err := m.types(mpath); err != nil || len(types) != 1 || types[0] != "object" {
+			return nil, fmt.Errorf("cannot merge non-object schema in allOf at %q", displayPath(mpath))
+		}
+		if err := addProps(m.Properties); err != nil {
+			return nil, fmt.Errorf("invalid properties at %q: %v", displayPath(mpath), err)
+		}
+		merged.Required = append(merged.Required, m.Required...)
+		if merged.Description == "" {
+			merged.Description = m.Description
+		}
+	}
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for i, key := range keys {
+		if i > 0 {
+			buf.WriteByte(',')
+		}
+		k, _ := json.Marshal(key)
+		buf.Write(k)
+		buf.WriteByte(':')
+		buf.Write(props[key])
+	}
+	buf.WriteByte('}')
+	merged.Properties = buf.Bytes()
+	merged.Type = json.RawMessage(`"object"`)
+	return &merged, nil
+}
+
+func parseJSONSchema(raw json.RawMessage, path string) (*jsonSchema, error) {
+	var s jsonSchema
+	if err := json.Unmarshal(raw, &s); err != nil {
+		return nil, fmt.Errorf("invalid schema at %q: %v", displayPath(path), err)
+	}
+	return &s, nil
+}
+
+// types returns the JSON types allowed by s, inferring them
+// from other keywords when there's no type keyword.
+func (s *jsonSchema) types(path string) ([]string, error) {
+	if s.Type != nil {
+		var t string
+		if err := json.Unmarshal(s.Type, &t); err == nil {
+			return []string{t}, nil
+		}
+		var ts []string
+		if err := json.Unmarshal(s.Type, &ts); err != nil || len(ts) == 0 {
+			return nil, fmt.Errorf("invalid type at %q", displayPath(path))
+		}
+		return ts, nil
+	}
+	switch {
+	case s.Properties != nil || s.AdditionalProperties != nil:
+		return []string{"object"}, nil
+	case s.Items != nil || s.PrefixItems != nil:
+		return []string{"array"}, nil
+	case len(s.Enum) > 0:
+		var ts []string
+		seen := make(map[string]bool)
+		for _, v := range s.Enum {
+			var t string
+			switch v.(type) {
+			case nil:
+				t = "null"
+			case string:
+				t = "string"
+			case bool:
+				t = "boolean"
+			case float64:
+				t = "number"
+			default:
+				return nil, fmt.Errorf("cannot convert enum with non-primitive values at %q", displayPath(path))
+			}
+			if !seen[t] {
+				seen[t] = true
+				ts = append(ts, t)
+			}
+		}
+		return ts, nil
+	}
+	return nil, fmt.Errorf("cannot determine type of schema at %q", displayPath(path))
+}
+
+// isNamed reports whether s converts to an Avro named type.
+func (s *jsonSchema) isNamed() bool {
+	if s.Ref != "" || len(s.OneOf) > 0 || len(s.AnyOf) > 0 {
+		return false
+	}
+	if len(s.AllOf) > 0 {
+		return true
+	}
+	types, err := s.types("")
+	if err != nil || len(types) != 1 {
+		return false
+	}
+	switch types[0] {
+	case "object":
+		return s.Properties != nil
+	case "string":
+		_, ok := jsonEnumSymbols(s.Enum)
+		return ok
+	}
+	return false
+}
+
+// typeNameFor returns the name to use for a named type
+// described by s, which is its title if it has one,
+// or name otherwise.
+func typeNameFor(s *jsonSchema, name string) string {
+	if s.Title != "" {
+		return avroName(strings.Replace(strings.Title(s.Title), " ", "", -1))
+	}
+	return name
+}
+
+// jsonEnumSymbols returns the values of enum as Avro enum symbols,
+// and reports whether they're all strings that are valid Avro names.
+func jsonEnumSymbols(enum []interface{}) ([]string, bool) {
+	if len(enum) == 0 {
+		return nil, false
+	}
+	symbols := make([]string, len(enum))
+	for i, v := range enum {
+		s, ok := v.(string)
+		if !ok || s == "" || avroName(s) != s {
+			return nil, false
+		}
+		symbols[i] = s
+	}
+	return symbols, true
+}
+
+// unionSchema returns the union of the given schemas, flattening
+// nested unions, removing duplicates and putting null first.
+// If there's only one member, it's returned on its own.
+func unionSchema(members []interface{}) interface{} {
+	var flat []interface{}
+	for _, m := range members {
+		if u, ok := m.([]interface{}); ok {
+			flat = append(flat, u...)
+		} else {
+			flat = append(flat, m)
+		}
+	}
+	var result []interface{}
+	seen := make(map[string]bool)
+	for _, m := range flat {
+		data, _ := json.Marshal(m)
+		if seen[string(data)] {
+			continue
+		}
+		seen[string(data)] = true
+		if m == "null" {
+			result = append([]interface{}{m}, result...)
+		} else {
+			result = append(result, m)
+		}
+	}
+	if len(result) == 1 {
+		return result[0]
+	}
+	return result
+}
+
+// objectMembers returns the keys of the JSON object in data,
+// in the order they appear, and their values.
+func objectMembers(data json.RawMessage) ([]string, map[string]json.RawMessage, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, nil, err
+	}
+	if tok != json.Delim('{') {
+		return nil, nil, fmt.Errorf("not a JSON object")
+	}
+	var keys []string
+	values := make(map[string]json.RawMessage)
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		key := tok.(string)
+		var v json.RawMessage
+		if err := dec.Decode(&v); err != nil {
+			return nil, nil, err
+		}
+		if _, ok := values[key]; !ok {
+			keys = append(keys, key)
+		}
+		values[key] = v
+	}
+	return keys, values, nil
+}
+
+// avroName returns s with all characters that aren't
+// valid in an Avro name replaced with underscores.
+func avroName(s string) string {
+	if s == "" {
+		return "_"
+	}
+	b := []byte(s)
+	for i, c := range b {
+		if !(c == '_' || 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || i > 0 && '0' <= c && c <= '9') {
+			b[i] = '_'
+		}
+	}
+	return string(b)
+}
+
+// withDoc adds a doc attribute to obj if doc is non-empty.
+func withDoc(obj map[string]interface{}, doc string) map[string]interface{} {
+	if doc != "" {
+		obj["doc"] = doc
+	}
+	return obj
+}
+
+// displayPath returns the JSON pointer path to use
+// in error messages.
+func displayPath(path string) string {
+	if path == "" {
+		return "#"
+	}
+	if strings.HasPrefix(path, "#") {
+		return path
+	}
+	return "#" + path
+}
@@ -0,0 +1,204 @@
+package avro_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro"
+)
+
+var fromJSONSchemaTests = []struct {
+	testName    string
+	schema      string
+	opts        *avro.JSONSchemaOptions
+	expect      string
+	expectError string
+}{{
+	testName: "primitives",
+	schema: `{
+		"$schema": "http://json-schema.org/draft-07/schema#",
+		"type": "object",
+		"description": "An event.",
+		"required": ["a", "b", "c", "d", "e", "f"],
+		"properties": {
+			"a": {"type": "integer"},
+			"b": {"type": "integer", "format": "int32"},
+			"c": {"type": "number", "minimum": 0},
+			"d": {"type": "boolean"},
+			"e": {"type": "string", "description": "The e field.", "default": "x"},
+			"f": {"type": "null"}
+		}
+	}`,
+	opts: &avro.JSONSchemaOptions{
+		Name:      "Event",
+		Namespace: "com.example",
+	},
+	expect: `{
+	"type": "record",
+	"name": "Event",
+	"namespace": "com.example",
+	"doc": "An event.",
+	"fields": [
+		{"name": "a", "type": "long"},
+		{"name": "b", "type": "int"},
+		{"name": "c", "type": "double"},
+		{"name": "d", "type": "boolean"},
+		{"name": "e", "type": "string", "doc": "The e field.", "default": "x"},
+		{"name": "f", "type": "null"}
+	]
+}`,
+}, {
+	testName: "optional-and-formats",
+	schema: `{
+		"title": "person record",
+		"type": "object",
+		"required": ["id"],
+		"properties": {
+			"id": {"type": "string", "format": "uuid"},
+			"born": {"type": "string", "format": "date"},
+			"updated": {"type": "string", "format": "date-time"},
+			"first-name": {"type": ["string", "null"]},
+			"kind": {"enum": ["a", "b"]},
+			"code": {"type": "string", "enum": ["x-1", "y-2"]}
+		}
+	}`,
+	expect: `{
+	"type": "record",
+	"name": "PersonRecord",
+	"fields": [
+		{"name": "id", "type": {"type": "string", "logicalType": "uuid"}},
+		{"name": "born", "type": ["null", {"type": "int", "logicalType": "date"}], "default": null},
+		{"name": "updated", "type": ["null", {"type": "long", "logicalType": "timestamp-micros"}], "default": null},
+		{"name": "first_name", "type": ["null", "string"], "default": null},
+		{"name": "kind", "type": ["null", {"type": "enum", "name": "Kind", "symbols": ["a", "b"]}], "default": null},
+		{"name": "code", "type": ["null", "string"], "default": null}
+	]
+}`,
+}, {
+	testName: "containers-and-unions",
+	schema: `{
+		"type": "object",
+		"required": ["tags", "attrs", "v", "item"],
+		"properties": {
+			"tags": {"type": "array", "items": {"type": "string"}},
+			"attrs": {"type": "object", "additionalProperties": {"type": "number"}},
+			"v": {"oneOf": [{"type": "string"}, {"type": "integer"}, {"type": "null"}]},
+			"item": {"type": "object", "required": ["id"], "properties": {"id": {"type": "integer"}}}
+		}
+	}`,
+	expect: `{
+	"type": "record",
+	"name": "Record",
+	"fields": [
+		{"name": "tags", "type": {"type": "array", "items": "string"}},
+		{"name": "attrs", "type": {"type": "map", "values": "double"}},
+		{"name": "v", "type": ["null", "string", "long"]},
+		{"name": "item", "type": {
+			"type": "record",
+			"name": "Item",
+			"fields": [{"name": "id", "type": "long"}]
+		}}
+	]
+}`,
+}, {
+	testName: "references",
+	schema: `{
+		"type": "object",
+		"required": ["home", "work", "children", "both"],
+		"properties": {
+			"home": {"$ref": "#/$defs/Address"},
+			"work": {"$ref": "#/$defs/Address"},
+			"children": {"type": "array", "items": {"$ref": "#"}},
+			"both": {"allOf": [
+				{"$ref": "#/$defs/Address"},
+				{"type": "object", "required": ["extra"], "properties": {"extra": {"type": "boolean"}}}
+			]}
+		},
+		"$defs": {
+			"Address": {"type": "object", "required": ["street"], "properties": {"street": {"type": "string"}}}
+		}
+	}`,
+	expect: `{
+	"type": "record",
+	"name": "Record",
+	"fields": [
+		{"name": "home", "type": {
+			"type": "record",
+			"name": "Address",
+			"fields": [{"name": "street", "type": "string"}]
+		}},
+		{"name": "work", "type": "Address"},
+		{"name": "children", "type": {"type": "array", "items": "Record"}},
+		{"name": "both", "type": {
+			"type": "record",
+			"name": "Both",
+			"fields": [
+				{"name": "street", "type": "string"},
+				{"name": "extra", "type": "boolean"}
+			]
+		}}
+	]
+}`,
+}, {
+	testName: "recursive-definition",
+	schema: `{
+		"$ref": "#/definitions/Node",
+		"definitions": {
+			"Node": {"type": "object", "properties": {"next": {"$ref": "#/definitions/Node"}}}
+		}
+	}`,
+	expect: `{
+	"type": "record",
+	"name": "Node",
+	"fields": [{"name": "next", "type": ["null", "Node"], "default": null}]
+}`,
+}, {
+	testName:    "tuple",
+	schema:      `{"type": "object", "properties": {"a": {"type": "array", "prefixItems": [{"type": "string"}]}}}`,
+	expectError: `cannot convert tuple array at "#/properties/a"`,
+}, {
+	testName:    "free-form-object",
+	schema:      `{"type": "object", "properties": {"a": {"type": "object"}}}`,
+	expectError: `cannot convert object without properties or additionalProperties schema at "#/properties/a"`,
+}, {
+	testName:    "remote-reference",
+	schema:      `{"type": "object", "properties": {"a": {"$ref": "http://example.com/a.json"}}}`,
+	expectError: `cannot resolve "http://example.com/a.json" at "#/properties/a": only local references to definitions are supported`,
+}, {
+	testName:    "missing-definition",
+	schema:      `{"type": "object", "properties": {"a": {"$ref": "#/$defs/B"}}}`,
+	expectError: `cannot resolve "#/\$defs/B" at "#/properties/a": definition not found`,
+}, {
+	testName:    "recursive-union",
+	schema:      `{"$ref": "#/$defs/S", "$defs": {"S": {"anyOf": [{"type": "string"}, {"$ref": "#/$defs/S"}]}}}`,
+	expectError: `recursive reference "#/\$defs/S" at "#/\$defs/S/anyOf/1" does not refer to an object or enum`,
+}, {
+	testName:    "conflicting-names",
+	schema:      `{"type": "object", "properties": {"a-b": {"type": "string"}, "a_b": {"type": "string"}}}`,
+	expectError: `properties "a-b" and "a_b" at "#" both map to field "a_b"`,
+}, {
+	testName:    "conditional",
+	schema:      `{"type": "object", "properties": {"a": {"not": {"type": "string"}}}}`,
+	expectError: `cannot convert conditional schema at "#/properties/a"`,
+}, {
+	testName:    "no-type",
+	schema:      `{"type": "object", "properties": {"a": {}}}`,
+	expectError: `cannot determine type of schema at "#/properties/a"`,
+}}
+
+func TestFromJSONSchema(t *testing.T) {
+	c := qt.New(t)
+	for _, test := range fromJSONSchemaTests {
+		c.Run(test.testName, func(c *qt.C) {
+			at, err := avro.FromJSONSchema([]byte(test.schema), test.opts)
+			if test.expectError != "" {
+				c.Assert(err, qt.ErrorMatches, test.expectError)
+				return
+			}
+			c.Assert(err, qt.Equals, nil)
+			c.Assert(at.String(), qt.JSONEquals, json.RawMessage(test.expect))
+		})
+	}
+}
@@ -0,0 +1,94 @@
+package avro
+
+import (
+	"github.com/rogpeppe/gogen-avro/v7/schema"
+)
+
+// Kind represents the kind of an Avro type.
+type Kind int
+
+const (
+	KindNull Kind = iota + 1
+	KindBoolean
+	KindInt
+	KindLong
+	KindFloat
+	KindDouble
+	KindBytes
+	KindString
+	KindRecord
+	KindEnum
+	KindArray
+	KindMap
+	KindUnion
+	KindFixed
+)
+
+var kindStrings = []string{
+	KindNull:    "null",
+	KindBoolean: "boolean",
+	KindInt:     "int",
+	KindLong:    "long",
+	KindFloat:   "float",
+	KindDouble:  "double",
+	KindBytes:   "bytes",
+	KindString:  "string",
+	KindRecord:  "record",
+	KindEnum:    "enum",
+	KindArray:   "array",
+	KindMap:     "map",
+	KindUnion:   "union",
+	KindFixed:   "fixed",
+}
+
+// String returns the name used for the kind in Avro schemas,
+// for example "record" or "long".
+func (k Kind) String() string {
+	if k <= 0 || int(k) >= len(kindStrings) {
+		return "unknown"
+	}
+	return kindStrings[k]
+}
+
+// isPrimitive reports whether k is one of the primitive Avro types.
+func (k Kind) isPrimitive() bool {
+	return KindNull <= k && k <= KindString
+}
+
+// kindOf returns the kind of the given Avro type.
+func kindOf(at schema.AvroType) Kind {
+	switch at := at.(type) {
+	case *schema.NullField:
+		return KindNull
+	case *schema.BoolField:
+		return KindBoolean
+	case *schema.IntField:
+		return KindInt
+	case *schema.LongField:
+		return KindLong
+	case *schema.FloatField:
+		return KindFloat
+	case *schema.DoubleField:
+		return KindDouble
+	case *schema.BytesField:
+		return KindBytes
+	case *schema.StringField:
+		return KindString
+	case *schema.ArrayField:
+		return KindArray
+	case *schema.MapField:
+		return KindMap
+	case *schema.UnionField:
+		return KindUnion
+	case *schema.Reference:
+		switch at.Def.(type) {
+		case *schema.RecordDefinition:
+			return KindRecord
+		case *schema.EnumDefinition:
+			return KindEnum
+		case *schema.FixedDefinition:
+			return KindFixed
+		}
+	}
+	return 0
+}
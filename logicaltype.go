@@ -0,0 +1,384 @@
+package avro
+
+import (
+	"fmt"
+	"math"
+	"reflect"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/rogpeppe/gogen-avro/v7/schema"
+)
+
+// Converter converts between Go values and the underlying Avro
+// representation of a logical type.
+//
+// Underlying Avro values are represented by the following Go types:
+//
+//   - boolean: bool
+//   - int, long: int64
+//   - float, double: float64
+//   - bytes: []byte
+//   - string: string
+type Converter interface {
+	// GoType returns the Go type that the logical type
+	// is represented as.
+	GoType() reflect.Type
+
+	// ToAvro converts x, which will be of type GoType(),
+	// to its underlying Avro representation.
+	ToAvro(x interface{}) (interface{}, error)
+
+	// FromAvro converts x from its underlying Avro representation
+	// to a value of type GoType().
+	FromAvro(x interface{}) (interface{}, error)
+}
+
+type logicalTypeInfo struct {
+	name       string
+	underlying Kind
+	conv       Converter
+}
+
+var logicalTypes struct {
+	mu sync.RWMutex
+	// byName maps from logical type name to the converters
+	// registered for it, in order of registration.
+	byName map[string][]logicalTypeInfo
+	// byGoType maps from Go type to its registered converter.
+	byGoType map[reflect.Type]logicalTypeInfo
+}
+
+// RegisterLogicalType registers a logical type with the given name,
+// so that any Avro type of kind underlying with a "logicalType"
+// attribute of name will be converted by conv when encoding and decoding
+// values of type conv.GoType(). This applies everywhere that values
+// are encoded and decoded, including Marshal, Unmarshal, the methods
+// on Names, SingleEncoder and SingleDecoder.
+//
+// TypeOf will also use the logical type as the Avro type for
+// conv.GoType(), and StructOf will use the Go type of the most
+// recently registered converter for the logical type.
+//
+// Registered converters take precedence over the built-in support for
+// logical types, such as the conversion between time.Time and
+// timestamp-micros, so a converter can be registered to override the
+// Go type used for any primitive logical type. Several Go types can be
+// registered for the same logical type, but each Go type can only be
+// registered once: registering a converter for a Go type that has
+// already been registered replaces the earlier registration.
+//
+// Only primitive kinds are allowed as the underlying kind.
+// RegisterLogicalType panics if underlying is not primitive or the
+// converter's Go type is nil.
+//
+// The schema, encoder and decoder for a Go type are computed once and
+// then cached, so a converter has no effect on Go types that have
+// already been used, for example by TypeOf or Marshal. Register
+// converters before any values containing their Go types are encoded
+// or decoded, for example in an init function.
+func RegisterLogicalType(name string, underlying Kind, conv Converter) {
+	if name == "" {
+		panic(fmt.Errorf("empty logical type name"))
+	}
+	if !underlying.isPrimitive() || underlying == KindNull {
+		panic(fmt.Errorf("invalid underlying kind %v for logical type %q", underlying, name))
+	}
+	goType := conv.GoType()
+	if goType == nil {
+		panic(fmt.Errorf("nil Go type for logical type %q", name))
+	}
+	logicalTypes.mu.Lock()
+	defer logicalTypes.mu.Unlock()
+	if logicalTypes.byName == nil {
+		logicalTypes.byName = make(map[string][]logicalTypeInfo)
+		logicalTypes.byGoType = make(map[reflect.Type]logicalTypeInfo)
+	}
+	if old, ok := logicalTypes.byGoType[goType]; ok {
+		infos := logicalTypes.byName[old.name]
+		for i, info := range infos {
+			if info.conv.GoType() == goType {
+				infos = append(infos[:i:i], infos[i+1:]...)
+				break
+			}
+		}
+		logicalTypes.byName[old.name] = infos
+	}
+	info := logicalTypeInfo{
+		name:       name,
+		underlying: underlying,
+		conv:       conv,
+	}
+	logicalTypes.byName[name] = append(logicalTypes.byName[name], info)
+	logicalTypes.byGoType[goType] = info
+}
+
+// logicalConverter returns the converter to use for values
+// of Go type t encoded with Avro type at, or nil if there is none.
+func logicalConverter(at schema.AvroType, t reflect.Type) Converter {
+	if at == nil || t == nil {
+		return nil
+	}
+	name := logicalType(at)
+	if name == "" {
+		return nil
+	}
+	logicalTypes.mu.RLock()
+	info, ok := logicalTypes.byGoType[t]
+	logicalTypes.mu.RUnlock()
+	if ok && info.name == name && info.underlying == kindOf(at) {
+		return info.conv
+	}
+	if conv, ok := timestampConverters[name]; ok && kindOf(at) == KindLong && t == timeType {
+		return conv
+	}
+	switch {
+	case name == "decimal":
+		return newDecimalConverter(at, t)
+	case name == "uuid" && kindOf(at) == KindString:
+		return newUUIDConverter(t)
+	case name == "duration":
+		return newDurationConverter(at, t)
+	case name == "date" && kindOf(at) == KindInt && t == timeType:
+		return dateConverter{}
+	case name == "date" && kindOf(at) == KindInt && t == dateType:
+		return dateValueConverter{}
+	}
+	return nil
+}
+
+// timestampConverters holds the converters used for time.Time values
+// with the timestamp logical types when no converter has been
+// registered for them.
+var timestampConverters = map[string]Converter{
+	timestampMillis:      timestampConverter(time.Millisecond),
+	timestampMicros:      timestampConverter(time.Microsecond),
+	localTimestampMillis: localTimestampConverter(time.Millisecond),
+	localTimestampMicros: localTimestampConverter(time.Microsecond),
+}
+
+// timestampConverter converts between time.Time and a long
+// holding the number of units of the given duration since
+// the Unix epoch. Times are decoded in UTC. The zero time
+// is encoded as 0.
+type timestampConverter time.Duration
+
+func (timestampConverter) GoType() reflect.Type {
+	return timeType
+}
+
+func (c timestampConverter) ToAvro(x interface{}) (interface{}, error) {
+	t := x.(time.Time)
+	if t.IsZero() {
+		return int64(0), nil
+	}
+	secs := t.Unix()
+	if secs >= math.MaxInt64/c.perSecond() || secs <= math.MinInt64/c.perSecond() {
+		return nil, fmt.Errorf("time %v out of range", t)
+	}
+	return secs*c.perSecond() + int64(t.Nanosecond())/int64(c), nil
+}
+
+func (c timestampConverter) FromAvro(x interface{}) (interface{}, error) {
+	n := x.(int64)
+	return time.Unix(n/c.perSecond(), n%c.perSecond()*int64(c)).UTC(), nil
+}
+
+func (c timestampConverter) perSecond() int64 {
+	return int64(time.Second / time.Duration(c))
+}
+
+// localTimestampLocation holds the *time.Location
+// set by SetLocalTimestampLocation.
+var localTimestampLocation atomic.Value
+
+// SetLocalTimestampLocation sets the location that time.Time values
+// with the local-timestamp-millis and local-timestamp-micros logical
+// types are decoded in. Those types hold a wall clock time without a
+// time zone, so when encoding, a time is first converted to loc and
+// its wall clock time is encoded, making the two symmetric.
+//
+// By default, loc is time.Local. SetLocalTimestampLocation panics
+// if loc is nil.
+func SetLocalTimestampLocation(loc *time.Location) {
+	if loc == nil {
+		panic(fmt.Errorf("nil location for local timestamps"))
+	}
+	localTimestampLocation.Store(loc)
+}
+
+func localLocation() *time.Location {
+	if loc, ok := localTimestampLocation.Load().(*time.Location); ok {
+		return loc
+	}
+	return time.Local
+}
+
+// localTimestampConverter converts between time.Time and
+// a long holding a wall clock time as the number of units of
+// the given duration since the Unix epoch in UTC. The zero time
+// is encoded as 0.
+type localTimestampConverter time.Duration
+
+func (localTimestampConverter) GoType() reflect.Type {
+	return timeType
+}
+
+func (c localTimestampConverter) ToAvro(x interface{}) (interface{}, error) {
+	t := x.(time.Time)
+	if t.IsZero() {
+		return int64(0), nil
+	}
+	return timestampConverter(c).ToAvro(withLocation(t.In(localLocation()), time.UTC))
+}
+
+func (c localTimestampConverter) FromAvro(x interface{}) (interface{}, error) {
+	t, err := timestampConverter(c).FromAvro(x)
+	if err != nil {
+		return nil, err
+	}
+	return withLocation(t.(time.Time).UTC(), localLocation()), nil
+}
+
+// dateConverter converts between time.Time and an int holding
+// the number of days since the Unix epoch. The date of a time
+// is taken in its own location, and dates are decoded as
+// midnight UTC. The zero time is encoded as 0.
+type dateConverter struct{}
+
+func (dateConverter) GoType() reflect.Type {
+	return timeType
+}
+
+func (dateConverter) ToAvro(x interface{}) (interface{}, error) {
+	t := x.(time.Time)
+	if t.IsZero() {
+		return int64(0), nil
+	}
+	days := withLocation(t, time.UTC).Truncate(24*time.Hour).Unix() / secondsPerDay
+	if days > math.MaxInt32 || days < math.MinInt32 {
+		return nil, fmt.Errorf("date %s out of range", t.Format("2006-01-02"))
+	}
+	return days, nil
+}
+
+func (dateConverter) FromAvro(x interface{}) (interface{}, error) {
+	return time.Unix(x.(int64)*secondsPerDay, 0).UTC(), nil
+}
+
+const secondsPerDay = 24 * 60 * 60
+
+// withLocation returns the time in loc with
+// the same wall clock time as t.
+func withLocation(t time.Time, loc *time.Location) time.Time {
+	year, month, day := t.Date()
+	hour, min, sec := t.Clock()
+	return time.Date(year, month, day, hour, min, sec, t.Nanosecond(), loc)
+}
+
+// logicalTypeForGoType returns the registered logical type
+// name and info for the Go type t.
+func logicalTypeForGoType(t reflect.Type) (string, logicalTypeInfo, bool) {
+	logicalTypes.mu.RLock()
+	defer logicalTypes.mu.RUnlock()
+	info, ok := logicalTypes.byGoType[t]
+	return info.name, info, ok
+}
+
+// registeredGoType returns the Go type of the most recently
+// registered converter for the logical type of at, or nil
+// if there is none.
+func registeredGoType(at schema.AvroType) reflect.Type {
+	name := logicalType(at)
+	if name == "" {
+		return nil
+	}
+	logicalTypes.mu.RLock()
+	defer logicalTypes.mu.RUnlock()
+	infos := logicalTypes.byName[name]
+	for i := len(infos) - 1; i >= 0; i-- {
+		if infos[i].underlying == kindOf(at) {
+			return infos[i].conv.GoType()
+		}
+	}
+	return nil
+}
+
+// underlyingGoKinds holds the reflect kind used to represent
+// the underlying value for each primitive Avro kind.
+var underlyingGoKinds = map[Kind]reflect.Kind{
+	KindBoolean: reflect.Bool,
+	KindInt:     reflect.Int64,
+	KindLong:    reflect.Int64,
+	KindFloat:   reflect.Float64,
+	KindDouble:  reflect.Float64,
+	KindBytes:   reflect.Slice,
+	KindString:  reflect.String,
+	KindFixed:   reflect.Slice,
+}
+
+// zeroUnderlyingDefault returns the JSON default value
+// used in schemas for a field with the given underlying kind.
+func zeroUnderlyingDefault(k Kind) interface{} {
+	switch k {
+	case KindBoolean:
+		return false
+	case KindInt, KindLong, KindFloat, KindDouble:
+		return 0
+	}
+	return ""
+}
+
+type logicalEncoder struct {
+	conv Converter
+	// name holds the name of the logical type.
+	name             string
+	kind             Kind
+	encodeUnderlying encoderFunc
+}
+
+func newLogicalEncoder(conv Converter, name string, k Kind) encoderFunc {
+	var enc encoderFunc
+	switch k {
+	case KindBoolean:
+		enc = boolEncoder
+	case KindInt, KindLong:
+		enc = longEncoder
+	case KindFloat:
+		enc = floatEncoder
+	case KindDouble:
+		enc = doubleEncoder
+	case KindBytes:
+		enc = bytesEncoder
+	case KindString:
+		enc = stringEncoder
+	case KindFixed:
+		// Only used for decimals, whose converter
+		// produces data of the right size.
+		enc = fixedBytesEncoder
+	default:
+		return errorEncoder(fmt.Errorf("unsupported underlying kind %v for logical type", k))
+	}
+	return logicalEncoder{
+		conv:             conv,
+		name:             name,
+		kind:             k,
+		encodeUnderlying: enc,
+	}.encode
+}
+
+func (le logicalEncoder) encode(e *encodeState, v reflect.Value) {
+	x, err := le.conv.ToAvro(v.Interface())
+	if err != nil {
+		e.error(&LogicalValueError{
+			LogicalType: le.name,
+			Err:         err,
+		})
+	}
+	xv := reflect.ValueOf(x)
+	if !xv.IsValid() || xv.Kind() != underlyingGoKinds[le.kind] || ((le.kind == KindBytes || le.kind == KindFixed) && xv.Type().Elem() != byteType) {
+		e.error(fmt.Errorf("logical type converter for %s returned %T, which is not a valid value for Avro type %v", v.Type(), x, le.kind))
+	}
+	le.encodeUnderlying(e, xv)
+}
@@ -0,0 +1,205 @@
+package avro_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro"
+)
+
+type testPoint struct {
+	X, Y int
+}
+
+type testPointConverter struct{}
+
+func (testPointConverter) GoType() reflect.Type {
+	return reflect.TypeOf(testPoint{})
+}
+
+func (testPointConverter) ToAvro(x interface{}) (interface{}, error) {
+	p := x.(testPoint)
+	return fmt.Sprintf("%d,%d", p.X, p.Y), nil
+}
+
+func (testPointConverter) FromAvro(x interface{}) (interface{}, error) {
+	var p testPoint
+	if _, err := fmt.Sscanf(x.(string), "%d,%d", &p.X, &p.Y); err != nil {
+		return nil, fmt.Errorf("invalid point %q: %v", x, err)
+	}
+	return p, nil
+}
+
+func init() {
+	avro.RegisterLogicalType("test-point", avro.KindString, testPointConverter{})
+}
+
+func TestRegisterLogicalType(t *testing.T) {
+	c := qt.New(t)
+	type R struct {
+		P testPoint
+		Q []testPoint
+	}
+	at, err := avro.TypeOf(R{})
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(at.String(), qt.JSONEquals, json.RawMessage(`{
+		"type": "record",
+		"name": "R",
+		"fields": [{
+			"name": "P",
+			"default": "",
+			"type": {"type": "string", "logicalType": "test-point"}
+		}, {
+			"name": "Q",
+			"default": [],
+			"type": {
+				"type": "array",
+				"items": {"type": "string", "logicalType": "test-point"}
+			}
+		}]
+	}`))
+	x := R{
+		P: testPoint{1, 2},
+		Q: []testPoint{{3, 4}, {-5, 6}},
+	}
+	data, wType, err := avro.Marshal(x)
+	c.Assert(err, qt.Equals, nil)
+
+	// Check that the data is encoded as plain strings.
+	type plainR struct {
+		P string
+		Q []string
+	}
+	var y plainR
+	wType1 := mustParseType(`{
+		"type": "record",
+		"name": "plainR",
+		"fields": [
+			{"name": "P", "type": "string"},
+			{"name": "Q", "type": {"type": "array", "items": "string"}}
+		]
+	}`)
+	_, err = avro.Unmarshal(data, &y, wType1)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(y.P, qt.Equals, "1,2")
+	c.Assert(y.Q, qt.DeepEquals, []string{"3,4", "-5,6"})
+
+	var x1 R
+	_, err = avro.Unmarshal(data, &x1, wType)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(x1, qt.DeepEquals, x)
+}
+
+func TestRegisterLogicalTypeBadUnderlying(t *testing.T) {
+	c := qt.New(t)
+	c.Assert(func() {
+		avro.RegisterLogicalType("test-bad", avro.KindRecord, testPointConverter{})
+	}, qt.PanicMatches, `invalid underlying kind record for logical type "test-bad"`)
+}
+
+// testMicros holds a number of microseconds since the epoch.
+type testMicros int64
+
+type testMicrosConverter struct{}
+
+func (testMicrosConverter) GoType() reflect.Type {
+	return reflect.TypeOf(testMicros(0))
+}
+
+func (testMicrosConverter) ToAvro(x interface{}) (interface{}, error) {
+	return int64(x.(testMicros)), nil
+}
+
+func (testMicrosConverter) FromAvro(x interface{}) (interface{}, error) {
+	return testMicros(x.(int64)), nil
+}
+
+func init() {
+	avro.RegisterLogicalType("timestamp-micros", avro.KindLong, testMicrosConverter{})
+}
+
+func TestRegisterLogicalTypeOverridesBuiltin(t *testing.T) {
+	c := qt.New(t)
+	type R struct {
+		T time.Time
+		M testMicros
+	}
+	at, err := avro.TypeOf(R{})
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(at.String(), qt.JSONEquals, json.RawMessage(`{
+		"type": "record",
+		"name": "R",
+		"fields": [{
+			"name": "T",
+			"default": 0,
+			"type": {"type": "long", "logicalType": "timestamp-micros"}
+		}, {
+			"name": "M",
+			"default": 0,
+			"type": {"type": "long", "logicalType": "timestamp-micros"}
+		}]
+	}`))
+
+	// Both the built-in conversion for time.Time and
+	// the registered converter are used.
+	x := R{
+		T: time.Unix(1, 0),
+		M: 1000000,
+	}
+	data, wType, err := avro.Marshal(x)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(data, qt.DeepEquals, []byte{0x80, 0x89, 0x7a, 0x80, 0x89, 0x7a})
+
+	var x1 R
+	_, err = avro.Unmarshal(data, &x1, wType)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(x1.T.Equal(x.T), qt.Equals, true)
+	c.Assert(x1.M, qt.Equals, x.M)
+
+	// StructOf uses the registered Go type.
+	st, err := avro.StructOf(at)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(st.Field(0).Type, qt.Equals, reflect.TypeOf(testMicros(0)))
+	c.Assert(st.Field(1).Type, qt.Equals, reflect.TypeOf(testMicros(0)))
+}
+
+type testRenamed string
+
+type testRenamedConverter struct{}
+
+func (testRenamedConverter) GoType() reflect.Type {
+	return reflect.TypeOf(testRenamed(""))
+}
+
+func (testRenamedConverter) ToAvro(x interface{}) (interface{}, error) {
+	return string(x.(testRenamed)), nil
+}
+
+func (testRenamedConverter) FromAvro(x interface{}) (interface{}, error) {
+	return testRenamed(x.(string)), nil
+}
+
+func TestRegisterLogicalTypeReplacesGoType(t *testing.T) {
+	c := qt.New(t)
+	avro.RegisterLogicalType("test-renamed-1", avro.KindString, testRenamedConverter{})
+	avro.RegisterLogicalType("test-renamed-2", avro.KindString, testRenamedConverter{})
+	type R struct {
+		F testRenamed
+	}
+	at, err := avro.TypeOf(R{})
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(at.String(), qt.JSONEquals, json.RawMessage(`{
+		"type": "record",
+		"name": "R",
+		"fields": [{
+			"name": "F",
+			"default": "",
+			"type": {"type": "string", "logicalType": "test-renamed-2"}
+		}]
+	}`))
+}
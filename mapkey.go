@@ -0,0 +1,113 @@
+package avro
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+	"strconv"
+)
+
+var (
+	stringType          = reflect.TypeOf("")
+	textMarshalerType   = reflect.TypeOf((*encoding.TextMarshaler)(nil)).Elem()
+	textUnmarshalerType = reflect.TypeOf((*encoding.TextUnmarshaler)(nil)).Elem()
+)
+
+// checkMapKeyType returns an error if values of type t can't be used as
+// keys in a Go map that represents an Avro map. As with encoding/json,
+// the key type must be a string type, an integer type or implement
+// encoding.TextMarshaler.
+func checkMapKeyType(t reflect.Type) error {
+	switch {
+	case t.Kind() == reflect.String,
+		t.Implements(textMarshalerType),
+		isIntKind(t.Kind()),
+		isUintKind(t.Kind()):
+		return nil
+	}
+	return fmt.Errorf("map key type %s must be a string or integer type or implement encoding.TextMarshaler", t)
+}
+
+// mapKeyEncoder returns a function that converts a map key of type t
+// to the string used for it in an Avro map, or nil if t is a string type
+// so no conversion is needed.
+func mapKeyEncoder(t reflect.Type) func(reflect.Value) (string, error) {
+	switch {
+	case t.Kind() == reflect.String:
+		return nil
+	case t.Implements(textMarshalerType):
+		return func(v reflect.Value) (string, error) {
+			if v.Kind() == reflect.Ptr && v.IsNil() {
+				return "", nil
+			}
+			data, err := v.Interface().(encoding.TextMarshaler).MarshalText()
+			if err != nil {
+				return "", fmt.Errorf("cannot marshal map key: %v", err)
+			}
+			return string(data), nil
+		}
+	case isIntKind(t.Kind()):
+		return func(v reflect.Value) (string, error) {
+			return strconv.FormatInt(v.Int(), 10), nil
+		}
+	case isUintKind(t.Kind()):
+		return func(v reflect.Value) (string, error) {
+			return strconv.FormatUint(v.Uint(), 10), nil
+		}
+	}
+	return func(v reflect.Value) (string, error) {
+		return "", fmt.Errorf("unsupported map key type %s", t)
+	}
+}
+
+// mapKeyDecoder returns a function that converts the string key of an
+// Avro map to a map key of type t, or nil if t is string so no
+// conversion is needed. As with encoding/json, a string type with
+// an UnmarshalText method is decoded with that method.
+func mapKeyDecoder(t reflect.Type) (func(string) (reflect.Value, error), error) {
+	switch {
+	case t == stringType:
+		return nil, nil
+	case reflect.PtrTo(t).Implements(textUnmarshalerType):
+		return func(s string) (reflect.Value, error) {
+			v := reflect.New(t)
+			if err := v.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(s)); err != nil {
+				return reflect.Value{}, fmt.Errorf("cannot unmarshal map key %q: %v", s, err)
+			}
+			return v.Elem(), nil
+		}, nil
+	case t.Kind() == reflect.String:
+		return func(s string) (reflect.Value, error) {
+			return reflect.ValueOf(s).Convert(t), nil
+		}, nil
+	case isIntKind(t.Kind()):
+		return func(s string) (reflect.Value, error) {
+			v := reflect.New(t).Elem()
+			n, err := strconv.ParseInt(s, 10, 64)
+			if err != nil || v.OverflowInt(n) {
+				return reflect.Value{}, fmt.Errorf("invalid map key %q for %s", s, t)
+			}
+			v.SetInt(n)
+			return v, nil
+		}, nil
+	case isUintKind(t.Kind()):
+		return func(s string) (reflect.Value, error) {
+			v := reflect.New(t).Elem()
+			n, err := strconv.ParseUint(s, 10, 64)
+			if err != nil || v.OverflowUint(n) {
+				return reflect.Value{}, fmt.Errorf("invalid map key %q for %s", s, t)
+			}
+			v.SetUint(n)
+			return v, nil
+		}, nil
+	}
+	return nil, fmt.Errorf("invalid key type for map with key %s", t)
+}
+
+func isIntKind(k reflect.Kind) bool {
+	return reflect.Int <= k && k <= reflect.Int64
+}
+
+func isUintKind(k reflect.Kind) bool {
+	return reflect.Uint <= k && k <= reflect.Uintptr
+}
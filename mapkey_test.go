@@ -0,0 +1,161 @@
+package avro_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro"
+)
+
+type mapKeyPoint struct {
+	X, Y int
+}
+
+func (p mapKeyPoint) MarshalText() ([]byte, error) {
+	return []byte(fmt.Sprintf("%d,%d", p.X, p.Y)), nil
+}
+
+func (p *mapKeyPoint) UnmarshalText(data []byte) error {
+	_, err := fmt.Sscanf(string(data), "%d,%d", &p.X, &p.Y)
+	return err
+}
+
+type mapKeyName string
+
+// mapKeyLower is a string type that lower-cases map keys
+// when they're decoded.
+type mapKeyLower string
+
+func (k *mapKeyLower) UnmarshalText(data []byte) error {
+	*k = mapKeyLower(strings.ToLower(string(data)))
+	return nil
+}
+
+func TestMapKeyTypes(t *testing.T) {
+	c := qt.New(t)
+	type R struct {
+		ByInt   map[int]string
+		ByUint  map[uint16]string
+		ByPoint map[mapKeyPoint]int
+		ByName  map[mapKeyName]bool
+	}
+	at, err := avro.TypeOf(R{})
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(at.String(), qt.JSONEquals, json.RawMessage(`{
+		"type": "record",
+		"name": "R",
+		"fields": [{
+			"name": "ByInt",
+			"default": {},
+			"type": {"type": "map", "values": "string"}
+		}, {
+			"name": "ByUint",
+			"default": {},
+			"type": {"type": "map", "values": "string"}
+		}, {
+			"name": "ByPoint",
+			"default": {},
+			"type": {"type": "map", "values": "long"}
+		}, {
+			"name": "ByName",
+			"default": {},
+			"type": {"type": "map", "values": "boolean"}
+		}]
+	}`))
+
+	x := R{
+		ByInt:   map[int]string{-1: "a", 2: "b"},
+		ByUint:  map[uint16]string{65535: "c"},
+		ByPoint: map[mapKeyPoint]int{{X: 1, Y: 2}: 3},
+		ByName:  map[mapKeyName]bool{"d": true},
+	}
+	data, wType, err := avro.Marshal(x)
+	c.Assert(err, qt.Equals, nil)
+	var x1 R
+	_, err = avro.Unmarshal(data, &x1, wType)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(x1, qt.DeepEquals, x)
+
+	// The keys are held as strings in the encoded data.
+	{
+		type R struct {
+			ByInt   map[string]string
+			ByUint  map[string]string
+			ByPoint map[string]int
+			ByName  map[string]bool
+		}
+		var x2 R
+		_, err = avro.Unmarshal(data, &x2, wType)
+		c.Assert(err, qt.Equals, nil)
+		c.Assert(x2, qt.DeepEquals, R{
+			ByInt:   map[string]string{"-1": "a", "2": "b"},
+			ByUint:  map[string]string{"65535": "c"},
+			ByPoint: map[string]int{"1,2": 3},
+			ByName:  map[string]bool{"d": true},
+		})
+	}
+}
+
+func TestMapKeyDecodeErrors(t *testing.T) {
+	c := qt.New(t)
+	type R struct {
+		M map[string]int
+	}
+	data, wType, err := avro.Marshal(R{
+		M: map[string]int{"300": 1},
+	})
+	c.Assert(err, qt.Equals, nil)
+	{
+		type R struct {
+			M map[int8]int
+		}
+		var x R
+		_, err = avro.Unmarshal(data, &x, wType)
+		c.Assert(err, qt.ErrorMatches, `invalid map key "300" for int8`)
+	}
+	{
+		type R struct {
+			M map[mapKeyPoint]int
+		}
+		var x R
+		_, err = avro.Unmarshal(data, &x, wType)
+		c.Assert(err, qt.ErrorMatches, `cannot unmarshal map key "300": .*`)
+	}
+}
+
+func TestMapKeyStringTypeWithUnmarshalText(t *testing.T) {
+	c := qt.New(t)
+	type R struct {
+		M map[string]int
+	}
+	data, wType, err := avro.Marshal(R{
+		M: map[string]int{"Foo": 1},
+	})
+	c.Assert(err, qt.Equals, nil)
+	{
+		// As with encoding/json, UnmarshalText is used in
+		// preference to converting the key directly.
+		type R struct {
+			M map[mapKeyLower]int
+		}
+		var x R
+		_, err = avro.Unmarshal(data, &x, wType)
+		c.Assert(err, qt.Equals, nil)
+		c.Assert(x, qt.DeepEquals, R{
+			M: map[mapKeyLower]int{"foo": 1},
+		})
+	}
+}
+
+func TestInvalidMapKeyType(t *testing.T) {
+	c := qt.New(t)
+	type R struct {
+		M map[float64]int
+	}
+	_, err := avro.TypeOf(R{})
+	c.Assert(err, qt.ErrorMatches, `map key type float64 must be a string or integer type or implement encoding.TextMarshaler`)
+}
@@ -0,0 +1,183 @@
+package avro
+
+import (
+	"encoding"
+	"fmt"
+	"reflect"
+
+	"github.com/rogpeppe/gogen-avro/v7/schema"
+)
+
+var (
+	binaryMarshalerType   = reflect.TypeOf((*encoding.BinaryMarshaler)(nil)).Elem()
+	binaryUnmarshalerType = reflect.TypeOf((*encoding.BinaryUnmarshaler)(nil)).Elem()
+)
+
+// isTextType reports whether TypeOf represents values of type t as
+// Avro strings using their MarshalText method. String types are
+// excluded because they're already strings, as are pointer and
+// interface types, which are represented by what they refer to.
+func isTextType(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.String, reflect.Ptr, reflect.Interface:
+		return false
+	}
+	// time.Time implements encoding.TextMarshaler but has its own
+	// representation as a timestamp.
+	return t != timeType && t.Implements(textMarshalerType)
+}
+
+// textDefault returns the default value used in schemas for the
+// text type t, which is the text form of its zero value.
+func textDefault(t reflect.Type) (interface{}, error) {
+	data, err := reflect.Zero(t).Interface().(encoding.TextMarshaler).MarshalText()
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal zero value of %s for default: %v", t, err)
+	}
+	return string(data), nil
+}
+
+// textEncoder encodes a value that implements encoding.TextMarshaler
+// as an Avro string.
+func textEncoder(e *encodeState, v reflect.Value) {
+	x, err := textConverter{v.Type()}.ToAvro(v.Interface())
+	if err != nil {
+		e.error(err)
+	}
+	stringEncoder(e, reflect.ValueOf(x))
+}
+
+// newTextConverter returns the converter used to decode values of
+// Avro type at into the Go type t with its UnmarshalText method,
+// or nil if there is none.
+func newTextConverter(at schema.AvroType, t reflect.Type) Converter {
+	if kindOf(at) != KindString {
+		return nil
+	}
+	switch t.Kind() {
+	case reflect.String, reflect.Ptr, reflect.Interface:
+		return nil
+	}
+	if !reflect.PtrTo(t).Implements(textUnmarshalerType) {
+		return nil
+	}
+	return textConverter{t}
+}
+
+// textConverter converts between Go values of type t and Avro
+// strings using the encoding.TextMarshaler and
+// encoding.TextUnmarshaler interfaces.
+type textConverter struct {
+	t reflect.Type
+}
+
+func (c textConverter) GoType() reflect.Type {
+	return c.t
+}
+
+func (c textConverter) ToAvro(x interface{}) (interface{}, error) {
+	data, err := x.(encoding.TextMarshaler).MarshalText()
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal %s as text: %v", c.t, err)
+	}
+	return string(data), nil
+}
+
+func (c textConverter) FromAvro(x interface{}) (interface{}, error) {
+	v := reflect.New(c.t)
+	if err := v.Interface().(encoding.TextUnmarshaler).UnmarshalText([]byte(x.(string))); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal %q into %s: %v", x, c.t, err)
+	}
+	return v.Elem().Interface(), nil
+}
+
+// isBinaryType reports whether TypeOf represents values of type t
+// as Avro bytes using their MarshalBinary method. Types that are
+// represented as text take precedence, and byte slices, pointer and
+// interface types are excluded.
+func isBinaryType(t reflect.Type) bool {
+	if !canBeBinary(t) || isTextType(t) {
+		return false
+	}
+	return t.Implements(binaryMarshalerType)
+}
+
+// canBeBinary reports whether values of type t can be represented
+// with their binary marshaling methods.
+func canBeBinary(t reflect.Type) bool {
+	switch t.Kind() {
+	case reflect.Slice:
+		return t.Elem() != byteType
+	case reflect.Ptr, reflect.Interface:
+		return false
+	}
+	// time.Time implements encoding.BinaryMarshaler but has its own
+	// representation as a timestamp.
+	return t != timeType
+}
+
+// binaryDefault returns the default value used in schemas for the
+// binary type t, which is the binary form of its zero value.
+func binaryDefault(t reflect.Type) (interface{}, error) {
+	data, err := reflect.Zero(t).Interface().(encoding.BinaryMarshaler).MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal zero value of %s for default: %v", t, err)
+	}
+	// Bytes values are represented in JSON as strings
+	// with one code point per byte.
+	r := make([]rune, len(data))
+	for i, b := range data {
+		r[i] = rune(b)
+	}
+	return string(r), nil
+}
+
+// binaryEncoder encodes a value that implements
+// encoding.BinaryMarshaler as Avro bytes.
+func binaryEncoder(e *encodeState, v reflect.Value) {
+	x, err := binaryConverter{v.Type()}.ToAvro(v.Interface())
+	if err != nil {
+		e.error(err)
+	}
+	bytesEncoder(e, reflect.ValueOf(x))
+}
+
+// newBinaryConverter returns the converter used to decode values of
+// Avro type at into the Go type t with its UnmarshalBinary method,
+// or nil if there is none.
+func newBinaryConverter(at schema.AvroType, t reflect.Type) Converter {
+	if kindOf(at) != KindBytes || !canBeBinary(t) {
+		return nil
+	}
+	if !reflect.PtrTo(t).Implements(binaryUnmarshalerType) {
+		return nil
+	}
+	return binaryConverter{t}
+}
+
+// binaryConverter converts between Go values of type t and Avro
+// bytes using the encoding.BinaryMarshaler and
+// encoding.BinaryUnmarshaler interfaces.
+type binaryConverter struct {
+	t reflect.Type
+}
+
+func (c binaryConverter) GoType() reflect.Type {
+	return c.t
+}
+
+func (c binaryConverter) ToAvro(x interface{}) (interface{}, error) {
+	data, err := x.(encoding.BinaryMarshaler).MarshalBinary()
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal %s as binary: %v", c.t, err)
+	}
+	return data, nil
+}
+
+func (c binaryConverter) FromAvro(x interface{}) (interface{}, error) {
+	v := reflect.New(c.t)
+	if err := v.Interface().(encoding.BinaryUnmarshaler).UnmarshalBinary(x.([]byte)); err != nil {
+		return nil, fmt.Errorf("cannot unmarshal binary data into %s: %v", c.t, err)
+	}
+	return v.Elem().Interface(), nil
+}
@@ -0,0 +1,198 @@
+package avro_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"net"
+	"strings"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro"
+)
+
+// textID is a type that's represented as text, such as a custom ID.
+type textID struct {
+	kind string
+	n    int
+}
+
+func (id textID) MarshalText() ([]byte, error) {
+	if id.kind == "" {
+		return []byte{}, nil
+	}
+	return []byte(fmt.Sprintf("%s-%d", id.kind, id.n)), nil
+}
+
+func (id *textID) UnmarshalText(data []byte) error {
+	if len(data) == 0 {
+		*id = textID{}
+		return nil
+	}
+	i := strings.LastIndex(string(data), "-")
+	if i == -1 {
+		return fmt.Errorf("no hyphen in ID")
+	}
+	id.kind = string(data[:i])
+	_, err := fmt.Sscan(string(data[i+1:]), &id.n)
+	return err
+}
+
+func TestTextMarshaler(t *testing.T) {
+	c := qt.New(t)
+	type R struct {
+		ID    textID
+		Other *textID
+		Addr  net.IP
+	}
+	at, err := avro.TypeOf(R{})
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(at.String(), qt.JSONEquals, json.RawMessage(`{
+		"type": "record",
+		"name": "R",
+		"fields": [{
+			"name": "ID",
+			"default": "",
+			"type": "string"
+		}, {
+			"name": "Other",
+			"default": null,
+			"type": ["null", "string"]
+		}, {
+			"name": "Addr",
+			"default": "",
+			"type": "string"
+		}]
+	}`))
+	x := R{
+		ID:    textID{"user", 1234},
+		Other: &textID{"group", 5},
+		Addr:  net.ParseIP("192.168.0.1"),
+	}
+	data, wType, err := avro.Marshal(x)
+	c.Assert(err, qt.Equals, nil)
+	var x1 R
+	_, err = avro.Unmarshal(data, &x1, wType)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(x1.ID, qt.Equals, x.ID)
+	c.Assert(*x1.Other, qt.Equals, *x.Other)
+	c.Assert(x1.Addr.Equal(x.Addr), qt.IsTrue)
+
+	// The values are held as strings in the encoded data.
+	{
+		type R struct {
+			ID    string
+			Other *string
+			Addr  string
+		}
+		var x2 R
+		_, err = avro.Unmarshal(data, &x2, wType)
+		c.Assert(err, qt.Equals, nil)
+		c.Assert(x2.ID, qt.Equals, "user-1234")
+		c.Assert(*x2.Other, qt.Equals, "group-5")
+		c.Assert(x2.Addr, qt.Equals, "192.168.0.1")
+	}
+}
+
+func TestTextUnmarshalerError(t *testing.T) {
+	c := qt.New(t)
+	type R struct {
+		ID string
+	}
+	data, wType, err := avro.Marshal(R{
+		ID: "bad",
+	})
+	c.Assert(err, qt.Equals, nil)
+	{
+		type R struct {
+			ID textID
+		}
+		var x R
+		_, err = avro.Unmarshal(data, &x, wType)
+		c.Assert(err, qt.ErrorMatches, `cannot unmarshal "bad" into avro_test.textID: no hyphen in ID`)
+	}
+}
+
+// binaryVersion is an opaque type that's represented as binary data.
+type binaryVersion struct {
+	major, minor byte
+}
+
+func (v binaryVersion) MarshalBinary() ([]byte, error) {
+	return []byte{v.major, v.minor}, nil
+}
+
+func (v *binaryVersion) UnmarshalBinary(data []byte) error {
+	if len(data) != 2 {
+		return fmt.Errorf("got %d bytes, want 2", len(data))
+	}
+	v.major, v.minor = data[0], data[1]
+	return nil
+}
+
+func TestBinaryMarshaler(t *testing.T) {
+	c := qt.New(t)
+	type R struct {
+		V     binaryVersion
+		Other *binaryVersion
+	}
+	at, err := avro.TypeOf(R{})
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(at.String(), qt.JSONEquals, json.RawMessage(`{
+		"type": "record",
+		"name": "R",
+		"fields": [{
+			"name": "V",
+			"default": "\u0000\u0000",
+			"type": "bytes"
+		}, {
+			"name": "Other",
+			"default": null,
+			"type": ["null", "bytes"]
+		}]
+	}`))
+	x := R{
+		V:     binaryVersion{1, 2},
+		Other: &binaryVersion{3, 4},
+	}
+	data, wType, err := avro.Marshal(x)
+	c.Assert(err, qt.Equals, nil)
+	var x1 R
+	_, err = avro.Unmarshal(data, &x1, wType)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(x1.V, qt.Equals, x.V)
+	c.Assert(*x1.Other, qt.Equals, *x.Other)
+
+	// The values are held as bytes in the encoded data.
+	{
+		type R struct {
+			V     []byte
+			Other *[]byte
+		}
+		var x2 R
+		_, err = avro.Unmarshal(data, &x2, wType)
+		c.Assert(err, qt.Equals, nil)
+		c.Assert(x2.V, qt.DeepEquals, []byte{1, 2})
+		c.Assert(*x2.Other, qt.DeepEquals, []byte{3, 4})
+	}
+}
+
+func TestBinaryUnmarshalerError(t *testing.T) {
+	c := qt.New(t)
+	type R struct {
+		V []byte
+	}
+	data, wType, err := avro.Marshal(R{
+		V: []byte{1},
+	})
+	c.Assert(err, qt.Equals, nil)
+	{
+		type R struct {
+			V binaryVersion
+		}
+		var x R
+		_, err = avro.Unmarshal(data, &x, wType)
+		c.Assert(err, qt.ErrorMatches, `cannot unmarshal binary data into avro_test.binaryVersion: got 1 bytes, want 2`)
+	}
+}
@@ -0,0 +1,465 @@
+package avro
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/rogpeppe/gogen-avro/v7/schema"
+)
+
+// MergePolicy determines how Merge treats two types
+// that cannot be combined into a single type.
+type MergePolicy int
+
+const (
+	// MergeError causes Merge to return an error when
+	// two types conflict.
+	MergeError MergePolicy = iota
+
+	// MergeUnion causes Merge to combine conflicting
+	// types into a union of both.
+	MergeUnion
+)
+
+// Merge returns a type that can read data written with either
+// t1 or t2. It's useful for building a single reader schema
+// for data that has been written with several different schemas.
+//
+// The two types are combined as follows:
+//
+//   - record fields are combined by name, with the fields of t1 first
+//     followed by fields only in t2. A field that has no default value and
+//     is only present in one of the records is made optional by
+//     turning it into a union with null and a default of null.
+//   - enum symbols are combined, with the symbols of t1 first.
+//   - primitive types are widened according to the promotion rules
+//     in the specification (for example int and float merge to float).
+//   - union members are combined by matching members of the same kind
+//     and name, or failing that, members that can be widened to one another,
+//     so ["null", "int"] and ["null", "long"] merge to ["null", "long"].
+//   - arrays and maps merge their item types.
+//
+// A named type defined in both t1 and t2 is merged wherever it's used,
+// even when it's only used in a field or union member present in just one
+// of them. Named types must have the same fully qualified name to be merged;
+// two types with the same name that can't be merged are always an error,
+// because a union can't hold both of them.
+//
+// The default value of a field present in both records is taken from
+// t1 if it's valid for the merged field type, or from t2 otherwise.
+// A null default moves null to the front of a merged union if needed.
+// If neither default can be used, the field has no default.
+//
+// Other combinations of types conflict and are treated according to policy.
+func Merge(t1, t2 *Type, policy MergePolicy) (*Type, error) {
+	m := &merger{
+		policy: policy,
+		scope:  emptyScope(),
+		defs1:  namedDefinitions(t1.avroType),
+		defs2:  namedDefinitions(t2.avroType),
+	}
+	v, err := m.merge("", t1.avroType, t2.avroType)
+	if err != nil {
+		return nil, err
+	}
+	t, err := parseMerged(v)
+	if err != nil {
+		return nil, err
+	}
+	if len(m.fields) == 0 {
+		return t, nil
+	}
+	// Now that the merged types are known, choose the defaults
+	// of fields that were present in both records.
+	defs := namedDefinitions(t.avroType)
+	for _, f := range m.fields {
+		def := defs[f.record].Def.(*schema.RecordDefinition)
+		if d, ok := f.chooseDefault(def.FieldByName(f.obj["name"].(string)).Type()); ok {
+			f.obj["default"] = d
+		}
+	}
+	return parseMerged(v)
+}
+
+// parseMerged returns the type for the JSON-marshalable
+// schema v that results from merging two types.
+func parseMerged(v interface{}) (*Type, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal merged schema: %v", err)
+	}
+	t, err := ParseType(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse merged schema: %v", err)
+	}
+	return t, nil
+}
+
+// namedDefinitions returns all the named types reachable from at,
+// indexed by name.
+func namedDefinitions(at schema.AvroType) map[schema.QualifiedName]*schema.Reference {
+	var refs []*schema.Reference
+	collectNamedTypes(at, make(map[schema.QualifiedName]bool), &refs)
+	defs := make(map[schema.QualifiedName]*schema.Reference)
+	for _, ref := range refs {
+		defs[ref.TypeName] = ref
+	}
+	return defs
+}
+
+type merger struct {
+	policy MergePolicy
+	// scope holds all the names defined so far in the
+	// resulting schema.
+	scope map[schema.QualifiedName]interface{}
+	// defs1 and defs2 hold the named types defined
+	// in the first and second types being merged.
+	defs1, defs2 map[schema.QualifiedName]*schema.Reference
+	// fields holds the fields present in both records
+	// being merged, which have their defaults chosen
+	// when the merged types are known.
+	fields []mergedField
+}
+
+// mergedField holds a field present in both of the records
+// being merged.
+type mergedField struct {
+	// record holds the name of the record holding the field.
+	record schema.QualifiedName
+	// obj holds the schema for the field in the merged record.
+	obj map[string]interface{}
+	// defaults holds the candidate default values, in
+	// order of preference.
+	defaults []interface{}
+}
+
+// chooseDefault returns the first of the candidate defaults that's
+// valid for at, the merged type of the field, and reports whether there
+// is one. Failing that, a null default is used if at is a union with a
+// null member, and null is moved to the front of the union so that
+// the default is valid for its first member.
+func (f mergedField) chooseDefault(at schema.AvroType) (interface{}, bool) {
+	for _, d := range f.defaults {
+		if _, err := appendAvroDefault(nil, d, at); err == nil {
+			return d, true
+		}
+	}
+	for _, d := range f.defaults {
+		if d != nil {
+			continue
+		}
+		if _, ok := f.obj["type"].([]interface{}); !ok {
+			continue
+		}
+		for _, member := range at.(*schema.UnionField).ItemTypes() {
+			if kindOf(member) == KindNull {
+				makeOptional(f.obj)
+				return nil, true
+			}
+		}
+	}
+	return nil, false
+}
+
+// merge returns the JSON-marshalable schema that results from
+// merging t1 and t2. The path is used for error messages only.
+func (m *merger) merge(path string, t1, t2 schema.AvroType) (interface{}, error) {
+	_, isUnion1 := t1.(*schema.UnionField)
+	_, isUnion2 := t2.(*schema.UnionField)
+	if isUnion1 || isUnion2 {
+		return m.mergeUnion(path, unionMembers(t1), unionMembers(t2))
+	}
+	if name1, name2 := primitiveName(t1), primitiveName(t2); name1 != "" && name2 != "" {
+		switch {
+		case name1 == name2:
+			return t1.Definition(m.scope)
+		case promotions[name2][name1]:
+			return t1.Definition(m.scope)
+		case promotions[name1][name2]:
+			return t2.Definition(m.scope)
+		}
+		return m.conflict(path, t1, t2)
+	}
+	switch t1 := t1.(type) {
+	case *schema.ArrayField:
+		t2, ok := t2.(*schema.ArrayField)
+		if !ok {
+			return m.conflict(path, t1, t2)
+		}
+		items, err := m.merge(path+".[*]", t1.ItemType(), t2.ItemType())
+		if err != nil {
+			return nil, err
+		}
+		obj := copyOfSchemaObj(t1)
+		obj["items"] = items
+		return obj, nil
+	case *schema.MapField:
+		t2, ok := t2.(*schema.MapField)
+		if !ok {
+			return m.conflict(path, t1, t2)
+		}
+		values, err := m.merge(path+".{*}", t1.ItemType(), t2.ItemType())
+		if err != nil {
+			return nil, err
+		}
+		obj := copyOfSchemaObj(t1)
+		obj["values"] = values
+		return obj, nil
+	case *schema.Reference:
+		t2, ok := t2.(*schema.Reference)
+		if !ok || t1.TypeName != t2.TypeName {
+			return m.conflict(path, t1, t2)
+		}
+		if path == "" {
+			path = t1.TypeName.Name
+		}
+		return m.mergeDefinition(path, t1.TypeName)
+	}
+	return m.conflict(path, t1, t2)
+}
+
+// copyType returns the schema for t, which is present in only one of
+// the types being merged. It's merged with itself so that the named types
+// it uses are merged with any definitions of the same name in the other type.
+func (m *merger) copyType(path string, t schema.AvroType) (interface{}, error) {
+	return m.merge(path, t, t)
+}
+
+// mergeDefinition returns the schema for the named type with the given
+// name, merging its definitions from both types if it's defined in both.
+func (m *merger) mergeDefinition(path string, name schema.QualifiedName) (interface{}, error) {
+	if _, ok := m.scope[name]; ok {
+		// Already defined (or being defined) so just refer to it by name.
+		return name.String(), nil
+	}
+	t1, t2 := m.defs1[name], m.defs2[name]
+	switch {
+	case t1 == nil:
+		t1 = t2
+	case t2 == nil:
+		t2 = t1
+	}
+	obj := copyOfSchemaObj(t1)
+	delete(obj, "namespace")
+	obj["name"] = t1.TypeName.String()
+	switch def1 := t1.Def.(type) {
+	case *schema.RecordDefinition:
+		def2, ok := t2.Def.(*schema.RecordDefinition)
+		if !ok {
+			return nil, mergeError(path, t1, t2)
+		}
+		m.scope[name] = obj
+		fields, err := m.mergeFields(path, name, def1, def2)
+		if err != nil {
+			return nil, err
+		}
+		obj["fields"] = fields
+	case *schema.EnumDefinition:
+		def2, ok := t2.Def.(*schema.EnumDefinition)
+		if !ok {
+			return nil, mergeError(path, t1, t2)
+		}
+		m.scope[name] = obj
+		syms := append([]string(nil), def1.Symbols()...)
+		found := make(map[string]bool)
+		for _, sym := range syms {
+			found[sym] = true
+		}
+		for _, sym := range def2.Symbols() {
+			if !found[sym] {
+				syms = append(syms, sym)
+			}
+		}
+		obj["symbols"] = syms
+	case *schema.FixedDefinition:
+		def2, ok := t2.Def.(*schema.FixedDefinition)
+		if !ok || def1.SizeBytes() != def2.SizeBytes() {
+			return nil, mergeError(path, t1, t2)
+		}
+		m.scope[name] = obj
+	default:
+		return nil, fmt.Errorf("unknown definition type %T", def1)
+	}
+	return obj, nil
+}
+
+func (m *merger) mergeFields(path string, name schema.QualifiedName, def1, def2 *schema.RecordDefinition) ([]interface{}, error) {
+	fields := []interface{}{}
+	for _, f1 := range def1.Fields() {
+		fobj := copyOfSchemaObj(f1)
+		f2 := def2.FieldByName(f1.Name())
+		if f2 == nil {
+			ftype, err := m.copyType(path+"."+f1.Name(), f1.Type())
+			if err != nil {
+				return nil, err
+			}
+			fobj["type"] = ftype
+			if !f1.HasDefault() {
+				makeOptional(fobj)
+			}
+			fields = append(fields, fobj)
+			continue
+		}
+		ftype, err := m.merge(path+"."+f1.Name(), f1.Type(), f2.Type())
+		if err != nil {
+			return nil, err
+		}
+		fobj["type"] = ftype
+		// The default is chosen when the merged type is known,
+		// because the default of either field might not be
+		// valid for it.
+		delete(fobj, "default")
+		var defaults []interface{}
+		if f1.HasDefault() {
+			defaults = append(defaults, f1.Default())
+		}
+		if f2.HasDefault() {
+			defaults = append(defaults, f2.Default())
+		}
+		if len(defaults) > 0 {
+			m.fields = append(m.fields, mergedField{
+				record:   name,
+				obj:      fobj,
+				defaults: defaults,
+			})
+		}
+		fields = append(fields, fobj)
+	}
+	for _, f2 := range def2.Fields() {
+		if def1.FieldByName(f2.Name()) != nil {
+			continue
+		}
+		fobj := copyOfSchemaObj(f2)
+		ftype, err := m.copyType(path+"."+f2.Name(), f2.Type())
+		if err != nil {
+			return nil, err
+		}
+		fobj["type"] = ftype
+		if !f2.HasDefault() {
+			makeOptional(fobj)
+		}
+		fields = append(fields, fobj)
+	}
+	return fields, nil
+}
+
+// makeOptional changes the given field so that it has a null
+// default, adding null to its type as the first member of a union
+// if needed.
+func makeOptional(fobj map[string]interface{}) {
+	fobj["default"] = nil
+	members, ok := fobj["type"].([]interface{})
+	if !ok {
+		fobj["type"] = []interface{}{"null", fobj["type"]}
+		return
+	}
+	for i, member := range members {
+		if isNullSchema(member) {
+			members1 := []interface{}{member}
+			members1 = append(members1, members[:i]...)
+			fobj["type"] = append(members1, members[i+1:]...)
+			return
+		}
+	}
+	fobj["type"] = append([]interface{}{"null"}, members...)
+}
+
+func isNullSchema(v interface{}) bool {
+	if obj, ok := v.(map[string]interface{}); ok {
+		v = obj["type"]
+	}
+	return v == "null"
+}
+
+func (m *merger) mergeUnion(path string, members1, members2 []schema.AvroType) (interface{}, error) {
+	// matches holds, for each member of members1, the
+	// member of members2 that it should be merged with, if any.
+	matches := make([]schema.AvroType, len(members1))
+	matched := make([]bool, len(members2))
+	// Match members of the same kind first, so that, for example,
+	// int only merges with long when there's no int to merge with.
+	for _, match := range []func(t1, t2 schema.AvroType) bool{sameKind, promotable} {
+	outer:
+		for j, t2 := range members2 {
+			if matched[j] {
+				continue
+			}
+			for i, t1 := range members1 {
+				if matches[i] == nil && match(t1, t2) {
+					matches[i] = t2
+					matched[j] = true
+					continue outer
+				}
+			}
+		}
+	}
+	var result []interface{}
+	for i, t1 := range members1 {
+		upath := fmt.Sprintf("%s[u%d]", path, i)
+		var v interface{}
+		var err error
+		if matches[i] != nil {
+			v, err = m.merge(upath, t1, matches[i])
+		} else {
+			v, err = m.copyType(upath, t1)
+		}
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, v)
+	}
+	for j, t2 := range members2 {
+		if matched[j] {
+			continue
+		}
+		v, err := m.copyType(fmt.Sprintf("%s[u%d]", path, len(result)), t2)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, v)
+	}
+	return result, nil
+}
+
+// sameKind reports whether t1 and t2 would occupy
+// the same slot in a union.
+func sameKind(t1, t2 schema.AvroType) bool {
+	switch t1 := t1.(type) {
+	case *schema.ArrayField:
+		_, ok := t2.(*schema.ArrayField)
+		return ok
+	case *schema.MapField:
+		_, ok := t2.(*schema.MapField)
+		return ok
+	case *schema.Reference:
+		t2, ok := t2.(*schema.Reference)
+		return ok && t1.TypeName == t2.TypeName
+	}
+	name1 := primitiveName(t1)
+	return name1 != "" && name1 == primitiveName(t2)
+}
+
+// promotable reports whether t1 and t2 are primitive types
+// where one can be widened to the other.
+func promotable(t1, t2 schema.AvroType) bool {
+	name1, name2 := primitiveName(t1), primitiveName(t2)
+	return promotions[name1][name2] || promotions[name2][name1]
+}
+
+func unionMembers(t schema.AvroType) []schema.AvroType {
+	if u, ok := t.(*schema.UnionField); ok {
+		return u.ItemTypes()
+	}
+	return []schema.AvroType{t}
+}
+
+func (m *merger) conflict(path string, t1, t2 schema.AvroType) (interface{}, error) {
+	if m.policy != MergeUnion {
+		return nil, mergeError(path, t1, t2)
+	}
+	return m.mergeUnion(path, []schema.AvroType{t1}, []schema.AvroType{t2})
+}
+
+func mergeError(path string, t1, t2 schema.AvroType) error {
+	return fmt.Errorf("cannot merge %s with %s at %q", schemaFragment(t1), schemaFragment(t2), path)
+}
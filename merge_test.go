@@ -0,0 +1,182 @@
+package avro_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro"
+)
+
+var mergeTests = []struct {
+	testName    string
+	t1          string
+	t2          string
+	policy      avro.MergePolicy
+	expect      string
+	expectError string
+}{{
+	testName: "widen-and-add-fields",
+	t1: `{
+	"type": "record",
+	"name": "R",
+	"fields": [
+		{"name": "a", "type": "int"},
+		{"name": "b", "type": "string", "default": "x"}
+	]
+}`,
+	t2: `{
+	"type": "record",
+	"name": "R",
+	"fields": [
+		{"name": "a", "type": "double"},
+		{"name": "c", "type": ["long", "null"]}
+	]
+}`,
+	expect: `{
+	"type": "record",
+	"name": "R",
+	"fields": [
+		{"name": "a", "type": "double"},
+		{"name": "b", "type": "string", "default": "x"},
+		{"name": "c", "type": ["null", "long"], "default": null}
+	]
+}`,
+}, {
+	testName: "enum-symbols",
+	t1:       `{"type": "enum", "name": "E", "symbols": ["a", "b"]}`,
+	t2:       `{"type": "enum", "name": "E", "symbols": ["c", "a"]}`,
+	expect:   `{"type": "enum", "name": "E", "symbols": ["a", "b", "c"]}`,
+}, {
+	testName: "union-members",
+	t1:       `["null", "int", {"type": "array", "items": "int"}]`,
+	t2:       `[{"type": "array", "items": "long"}, "string"]`,
+	expect:   `["null", "int", {"type": "array", "items": "long"}, "string"]`,
+}, {
+	testName: "union-member-widening",
+	t1:       `["null", "int"]`,
+	t2:       `["null", "long"]`,
+	expect:   `["null", "long"]`,
+}, {
+	testName: "union-member-same-kind-first",
+	t1:       `["int", "long"]`,
+	t2:       `["long", "string"]`,
+	expect:   `["int", "long", "string"]`,
+}, {
+	testName: "named-type-in-one-field",
+	t1: `{
+	"type": "record",
+	"name": "R",
+	"fields": [
+		{"name": "a", "type": {"type": "record", "name": "X", "fields": [{"name": "p", "type": "int"}]}}
+	]
+}`,
+	t2: `{
+	"type": "record",
+	"name": "R",
+	"fields": [
+		{"name": "b", "type": {"type": "record", "name": "X", "fields": [{"name": "q", "type": "string"}]}}
+	]
+}`,
+	expect: `{
+	"type": "record",
+	"name": "R",
+	"fields": [{
+		"name": "a",
+		"type": ["null", {
+			"type": "record",
+			"name": "X",
+			"fields": [
+				{"name": "p", "type": ["null", "int"], "default": null},
+				{"name": "q", "type": ["null", "string"], "default": null}
+			]
+		}],
+		"default": null
+	}, {
+		"name": "b",
+		"type": ["null", "X"],
+		"default": null
+	}]
+}`,
+}, {
+	testName: "named-type-in-one-union",
+	t1:       `["null", {"type": "enum", "name": "E", "symbols": ["a"]}]`,
+	t2:       `[{"type": "array", "items": {"type": "enum", "name": "E", "symbols": ["b"]}}]`,
+	expect:   `["null", {"type": "enum", "name": "E", "symbols": ["a", "b"]}, {"type": "array", "items": "E"}]`,
+}, {
+	testName: "named-type-kind-conflict",
+	t1: `{
+	"type": "record",
+	"name": "R",
+	"fields": [
+		{"name": "a", "type": {"type": "record", "name": "X", "fields": []}}
+	]
+}`,
+	t2: `{
+	"type": "record",
+	"name": "R",
+	"fields": [
+		{"name": "b", "type": {"type": "enum", "name": "X", "symbols": ["x"]}}
+	]
+}`,
+	policy:      avro.MergeUnion,
+	expectError: `cannot merge X with X at "R.a"`,
+}, {
+	testName: "default-from-second",
+	t1:       `{"type": "record", "name": "R", "fields": [{"name": "a", "type": ["null", "int"]}]}`,
+	t2:       `{"type": "record", "name": "R", "fields": [{"name": "a", "type": ["null", "long"], "default": null}]}`,
+	expect:   `{"type": "record", "name": "R", "fields": [{"name": "a", "type": ["null", "long"], "default": null}]}`,
+}, {
+	testName: "default-moves-null-first",
+	t1:       `{"type": "record", "name": "R", "fields": [{"name": "a", "type": "int"}]}`,
+	t2:       `{"type": "record", "name": "R", "fields": [{"name": "a", "type": ["null", "long"], "default": null}]}`,
+	expect:   `{"type": "record", "name": "R", "fields": [{"name": "a", "type": ["null", "long"], "default": null}]}`,
+}, {
+	testName: "default-invalid-for-merged-type",
+	t1:       `{"type": "record", "name": "R", "fields": [{"name": "a", "type": "bytes"}]}`,
+	t2:       `{"type": "record", "name": "R", "fields": [{"name": "a", "type": "string", "default": "€"}]}`,
+	expect:   `{"type": "record", "name": "R", "fields": [{"name": "a", "type": "bytes"}]}`,
+}, {
+	testName: "default-of-first-invalid-for-union",
+	t1:       `{"type": "record", "name": "R", "fields": [{"name": "a", "type": "bytes", "default": "€"}]}`,
+	t2:       `{"type": "record", "name": "R", "fields": [{"name": "a", "type": ["null", "string"], "default": null}]}`,
+	expect:   `{"type": "record", "name": "R", "fields": [{"name": "a", "type": ["null", "bytes"], "default": null}]}`,
+}, {
+	testName:    "conflict-error",
+	t1:          `{"type": "record", "name": "R", "fields": [{"name": "a", "type": "int"}]}`,
+	t2:          `{"type": "record", "name": "R", "fields": [{"name": "a", "type": "string"}]}`,
+	expectError: `cannot merge "int" with "string" at "R.a"`,
+}, {
+	testName: "conflict-union",
+	t1:       `{"type": "record", "name": "R", "fields": [{"name": "a", "type": "int"}]}`,
+	t2:       `{"type": "record", "name": "R", "fields": [{"name": "a", "type": "string"}]}`,
+	policy:   avro.MergeUnion,
+	expect: `{
+	"type": "record",
+	"name": "R",
+	"fields": [
+		{"name": "a", "type": ["int", "string"]}
+	]
+}`,
+}, {
+	testName:    "fixed-size",
+	t1:          `{"type": "fixed", "name": "F", "size": 2}`,
+	t2:          `{"type": "fixed", "name": "F", "size": 3}`,
+	expectError: `cannot merge F with F at "F"`,
+}}
+
+func TestMerge(t *testing.T) {
+	c := qt.New(t)
+	for _, test := range mergeTests {
+		c.Run(test.testName, func(c *qt.C) {
+			mt, err := avro.Merge(mustParseType(test.t1), mustParseType(test.t2), test.policy)
+			if test.expectError != "" {
+				c.Assert(err, qt.ErrorMatches, test.expectError)
+				return
+			}
+			c.Assert(err, qt.Equals, nil)
+			c.Assert(mt.String(), qt.JSONEquals, json.RawMessage(test.expect))
+		})
+	}
+}
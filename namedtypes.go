@@ -0,0 +1,82 @@
+package avro
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/rogpeppe/gogen-avro/v7/schema"
+)
+
+// NamedTypes returns all the record, enum and fixed definitions
+// reachable from t, including t itself if it's a definition.
+//
+// The types are in dependency order: every type comes after all the types
+// that it refers to, except where types refer to one another recursively.
+// Each returned type is self-contained, holding the definitions
+// of all the types it depends on.
+func (t *Type) NamedTypes() []*Type {
+	var refs []*schema.Reference
+	collectNamedTypes(t.avroType, make(map[schema.QualifiedName]bool), &refs)
+	types := make([]*Type, len(refs))
+	for i, ref := range refs {
+		if ref == t.avroType {
+			types[i] = t
+			continue
+		}
+		t1, err := standaloneType(ref)
+		if err != nil {
+			// This should never happen because we're
+			// only using parts of a valid schema.
+			panic(fmt.Errorf("cannot make type for %s: %v", ref.TypeName, err))
+		}
+		types[i] = t1
+	}
+	return types
+}
+
+// collectNamedTypes appends all the definitions reachable from at
+// to refs in dependency order.
+func collectNamedTypes(at schema.AvroType, visited map[schema.QualifiedName]bool, refs *[]*schema.Reference) {
+	switch at := at.(type) {
+	case *schema.ArrayField:
+		collectNamedTypes(at.ItemType(), visited, refs)
+	case *schema.MapField:
+		collectNamedTypes(at.ItemType(), visited, refs)
+	case *schema.UnionField:
+		for _, item := range at.ItemTypes() {
+			collectNamedTypes(item, visited, refs)
+		}
+	case *schema.Reference:
+		if visited[at.TypeName] {
+			return
+		}
+		visited[at.TypeName] = true
+		if def, ok := at.Def.(*schema.RecordDefinition); ok {
+			for _, f := range def.Fields() {
+				collectNamedTypes(f.Type(), visited, refs)
+			}
+		}
+		*refs = append(*refs, at)
+	}
+}
+
+// standaloneType returns the type for the given part of a schema
+// with the definitions of all the types it refers to included
+// inline.
+func standaloneType(at schema.AvroType) (*Type, error) {
+	v := renameSchema(at, "", make(map[schema.QualifiedName]bool), originalName)
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal schema for %s: %v", schemaFragment(at), err)
+	}
+	t, err := ParseType(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse schema for %s: %v", schemaFragment(at), err)
+	}
+	return t, nil
+}
+
+// originalName is a renameFunc that leaves names unchanged.
+func originalName(ref *schema.Reference) (schema.QualifiedName, []schema.QualifiedName) {
+	return ref.TypeName, ref.Def.Aliases()
+}
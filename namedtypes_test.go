@@ -0,0 +1,39 @@
+package avro_test
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+func TestNamedTypes(t *testing.T) {
+	c := qt.New(t)
+	at := mustParseType(`{
+	"type": "record",
+	"name": "com.example.R",
+	"fields": [
+		{"name": "a", "type": {
+			"type": "record",
+			"name": "S",
+			"fields": [
+				{"name": "e", "type": {"type": "enum", "name": "E", "symbols": ["x"]}},
+				{"name": "r", "type": ["null", "R"]}
+			]
+		}},
+		{"name": "b", "type": {"type": "map", "values": {"type": "fixed", "name": "other.F", "size": 2}}},
+		{"name": "c", "type": {"type": "array", "items": "E"}}
+	]
+}`)
+	types := at.NamedTypes()
+	var names []string
+	for _, t := range types {
+		names = append(names, t.Name())
+	}
+	c.Assert(names, qt.DeepEquals, []string{"com.example.E", "com.example.S", "other.F", "com.example.R"})
+	c.Assert(types[len(types)-1], qt.Equals, at)
+	c.Assert(types[0].CanonicalString(0), qt.Equals, `{"name":"com.example.E","type":"enum","symbols":["x"]}`)
+	// S refers to R, which is defined inline.
+	c.Assert(types[1].CanonicalString(0), qt.Equals, `{"name":"com.example.S","type":"record","fields":[{"name":"e","type":{"name":"com.example.E","type":"enum","symbols":["x"]}},{"name":"r","type":["null",{"name":"com.example.R","type":"record","fields":[{"name":"a","type":"com.example.S"},{"name":"b","type":{"type":"map","values":{"name":"other.F","type":"fixed","size":2}}},{"name":"c","type":{"type":"array","items":"com.example.E"}}]}]}]}`)
+
+	c.Assert(mustParseType(`"string"`).NamedTypes(), qt.HasLen, 0)
+}
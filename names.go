@@ -26,6 +26,10 @@ type Names struct {
 	// an errorSchema.
 	goTypeToAvroType sync.Map
 	goTypeToEncoder  sync.Map
+
+	// uint64Policy holds the representation used
+	// for uint64 and uint values.
+	uint64Policy Uint64Policy
 }
 
 var builtinTypes = map[string]bool{
@@ -45,6 +49,20 @@ func (names *Names) Marshal(x interface{}) ([]byte, *Type, error) {
 	return marshalAppend(names, nil, reflect.ValueOf(x))
 }
 
+// MarshalAppend is like the MarshalAppend function except that names
+// in the schema for x are renamed according to names.
+func (names *Names) MarshalAppend(buf []byte, x interface{}) ([]byte, *Type, error) {
+	return marshalAppend(names, buf, reflect.ValueOf(x))
+}
+
+// MarshalWithWriter is like the MarshalWithWriter function except that names
+// in the schema for x are renamed according to names.
+func (names *Names) MarshalWithWriter(x interface{}, wType *Type) ([]byte, *Type, error) {
+	xv := reflect.ValueOf(x)
+	avroType, enc := typeEncoderWithWriter(names, xv.Type(), wType)
+	return encodeAppend(nil, xv, avroType, enc)
+}
+
 // Rename returns a copy of n that renames oldName to newName
 // with the given aliases when a schema is used.
 //
@@ -71,7 +89,8 @@ func (n *Names) Rename(oldName string, newName string, newAliases ...string) *Na
 		panic(fmt.Errorf("rename of built-in type %q to %q", oldName, newName))
 	}
 	n1 := &Names{
-		renames: make(map[string][]string),
+		renames:      make(map[string][]string),
+		uint64Policy: n.uint64Policy,
 	}
 	for name, names := range n.renames {
 		n1.renames[name] = names
@@ -113,29 +132,44 @@ func (n *Names) TypeOf(x interface{}) (*Type, error) {
 	return avroTypeOf(n, reflect.TypeOf(x))
 }
 
+// TypeOfWithWriter is like the TypeOfWithWriter function except
+// that Avro names in x will be translated through the namespace n.
+func (n *Names) TypeOfWithWriter(x interface{}, wType *Type) (*Type, error) {
+	return avroTypeOfWithWriter(n, reflect.TypeOf(x), wType)
+}
+
 func (names *Names) renameSchema(at schema.AvroType) interface{} {
-	return names.renameSchema1(at, "", make(map[schema.QualifiedName]bool))
+	return renameSchema(at, "", make(map[schema.QualifiedName]bool), names.rename)
+}
+
+// rename returns the new name and aliases for the given definition.
+func (names *Names) rename(ref *schema.Reference) (schema.QualifiedName, []schema.QualifiedName) {
+	// https://avro.apache.org/docs/1.9.1/spec.html#Aliases
+	// "A type alias may be specified either as fully
+	// namespace-qualified, or relative to the namespace of
+	// the name it is an alias for."
+	newNames, ok := names.renames[ref.TypeName.String()]
+	if !ok {
+		return ref.TypeName, ref.Def.Aliases()
+	}
+	qname := parser.ParseAvroName("", newNames[0])
+	qaliases := make([]schema.QualifiedName, len(newNames)-1)
+	for i, alias := range newNames[1:] {
+		qaliases[i] = parser.ParseAvroName(qname.Namespace, alias)
+	}
+	return qname, qaliases
 }
 
-func (names *Names) renameSchema1(at schema.AvroType, enclosingNamespace string, defined map[schema.QualifiedName]bool) interface{} {
+// renameFunc returns the name and aliases to use for
+// the given definition.
+type renameFunc func(ref *schema.Reference) (schema.QualifiedName, []schema.QualifiedName)
+
+// renameSchema returns the JSON-marshalable schema for at with
+// all definitions renamed according to rename.
+func renameSchema(at schema.AvroType, enclosingNamespace string, defined map[schema.QualifiedName]bool, rename renameFunc) interface{} {
 	switch at := at.(type) {
 	case *schema.Reference:
-		// https://avro.apache.org/docs/1.9.1/spec.html#Aliases
-		// "A type alias may be specified either as fully
-		// namespace-qualified, or relative to the namespace of
-		// the name it is an alias for."
-		var qname schema.QualifiedName
-		var qaliases []schema.QualifiedName
-		if newNames, ok := names.renames[at.TypeName.String()]; ok {
-			qname = parser.ParseAvroName("", newNames[0])
-			qaliases = make([]schema.QualifiedName, len(newNames)-1)
-			for i, alias := range newNames[1:] {
-				qaliases[i] = parser.ParseAvroName(qname.Namespace, alias)
-			}
-		} else {
-			qname = at.TypeName
-			qaliases = at.Def.Aliases()
-		}
+		qname, qaliases := rename(at)
 		if defined[qname] {
 			// It's a reference to a type that's already been
 			// defined, so just use the new name.
@@ -150,7 +184,7 @@ func (names *Names) renameSchema1(at schema.AvroType, enclosingNamespace string,
 			fieldDefs := make([]map[string]interface{}, len(adef.Fields()))
 			for i, f := range adef.Fields() {
 				fieldDef := copyOfSchemaObj(f)
-				fieldDef["type"] = names.renameSchema1(f.Type(), qname.Namespace, defined)
+				fieldDef["type"] = renameSchema(f.Type(), qname.Namespace, defined, rename)
 				fieldDefs[i] = fieldDef
 			}
 			def["fields"] = fieldDefs
@@ -173,16 +207,16 @@ func (names *Names) renameSchema1(at schema.AvroType, enclosingNamespace string,
 	case *schema.UnionField:
 		items := make([]interface{}, len(at.ItemTypes()))
 		for i, item := range at.ItemTypes() {
-			items[i] = names.renameSchema1(item, enclosingNamespace, defined)
+			items[i] = renameSchema(item, enclosingNamespace, defined, rename)
 		}
 		return items
 	case *schema.ArrayField:
 		obj := copyOfSchemaObj(at)
-		obj["items"] = names.renameSchema1(at.ItemType(), enclosingNamespace, defined)
+		obj["items"] = renameSchema(at.ItemType(), enclosingNamespace, defined, rename)
 		return obj
 	case *schema.MapField:
 		obj := copyOfSchemaObj(at)
-		obj["values"] = names.renameSchema1(at.ItemType(), enclosingNamespace, defined)
+		obj["values"] = renameSchema(at.ItemType(), enclosingNamespace, defined, rename)
 		return obj
 	default:
 		obj, _ := at.Definition(emptyScope())
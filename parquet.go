@@ -0,0 +1,227 @@
+package avro
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rogpeppe/gogen-avro/v7/schema"
+)
+
+// ParquetSchema holds a Parquet schema derived from an Avro record
+// type by Type.ParquetSchema.
+type ParquetSchema struct {
+	// Definition holds the schema in the textual form used by the
+	// Parquet tools and accepted by parquet-go packages such as
+	// github.com/fraugster/parquet-go/parquetschema, for example:
+	//
+	//	message R {
+	//	  required int64 id;
+	//	  optional binary name (STRING);
+	//	}
+	Definition string
+
+	// Columns maps the path of each Avro field that holds
+	// a primitive value to the path of the Parquet column that
+	// holds it. Avro paths are written as for Type.Project, so they
+	// go through arrays, maps and unions without naming them;
+	// Parquet paths name every group in the schema, for example
+	// "tags.list.element".
+	Columns map[string]string
+}
+
+// ParquetSchema returns the Parquet schema that corresponds to t,
+// which must be a record type. It follows the mapping used by the
+// Parquet Avro bindings:
+//
+//   - records become groups, and fields become required columns
+//     unless their type is a union of null and one other type,
+//     in which case they're optional;
+//   - arrays and maps become groups annotated with LIST and MAP;
+//   - strings and enums become binary columns annotated with
+//     STRING and ENUM, and fixed types become fixed_len_byte_array
+//     columns;
+//   - the date, time, timestamp, decimal and duration logical
+//     types become the corresponding Parquet annotations.
+//
+// ParquetSchema returns an error if t holds a type that Parquet
+// can't represent: a union other than one with null, a null
+// type on its own, or a record that contains itself.
+func (t *Type) ParquetSchema() (*ParquetSchema, error) {
+	ref, ok := t.avroType.(*schema.Reference)
+	if !ok {
+		return nil, fmt.Errorf("cannot make Parquet schema for non-record type")
+	}
+	def, ok := ref.Def.(*schema.RecordDefinition)
+	if !ok {
+		return nil, fmt.Errorf("cannot make Parquet schema for non-record type %s", ref.TypeName)
+	}
+	w := &parquetWriter{
+		columns: make(map[string]string),
+		active: map[schema.QualifiedName]bool{
+			ref.TypeName: true,
+		},
+	}
+	fmt.Fprintf(&w.buf, "message %s {\n", ref.TypeName.Name)
+	if err := w.writeFields(1, def, "", ""); err != nil {
+		return nil, err
+	}
+	w.buf.WriteString("}\n")
+	return &ParquetSchema{
+		Definition: w.buf.String(),
+		Columns:    w.columns,
+	}, nil
+}
+
+type parquetWriter struct {
+	buf     strings.Builder
+	columns map[string]string
+
+	// active holds the records that are currently being written,
+	// so that recursive types can be detected.
+	active map[schema.QualifiedName]bool
+}
+
+func (w *parquetWriter) writeFields(depth int, def *schema.RecordDefinition, avroPath, colPath string) error {
+	for _, f := range def.Fields() {
+		if err := w.writeField(depth, "required", f.Name(), f.Type(), joinPath(avroPath, f.Name()), joinPath(colPath, f.Name())); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeField writes the Parquet field with the given name and
+// repetition that represents a value of type at. The avroPath and
+// colPath arguments hold the paths to the field in the Avro and
+// Parquet schemas respectively.
+func (w *parquetWriter) writeField(depth int, repetition, name string, at schema.AvroType, avroPath, colPath string) error {
+	if u, ok := at.(*schema.UnionField); ok {
+		item := nullableItem(u)
+		if item == nil {
+			return fmt.Errorf("cannot represent union at %q in Parquet", avroPath)
+		}
+		at, repetition = item, "optional"
+	}
+	indent := strings.Repeat("  ", depth)
+	switch at := at.(type) {
+	case *schema.ArrayField:
+		fmt.Fprintf(&w.buf, "%s%s group %s (LIST) {\n", indent, repetition, name)
+		fmt.Fprintf(&w.buf, "%s  repeated group list {\n", indent)
+		if err := w.writeField(depth+2, "required", "element", at.ItemType(), avroPath, colPath+".list.element"); err != nil {
+			return err
+		}
+		fmt.Fprintf(&w.buf, "%s  }\n%s}\n", indent, indent)
+		return nil
+	case *schema.MapField:
+		fmt.Fprintf(&w.buf, "%s%s group %s (MAP) {\n", indent, repetition, name)
+		fmt.Fprintf(&w.buf, "%s  repeated group key_value {\n", indent)
+		fmt.Fprintf(&w.buf, "%s    required binary key (STRING);\n", indent)
+		if err := w.writeField(depth+2, "required", "value", at.ItemType(), avroPath, colPath+".key_value.value"); err != nil {
+			return err
+		}
+		fmt.Fprintf(&w.buf, "%s  }\n%s}\n", indent, indent)
+		return nil
+	case *schema.Reference:
+		if def, ok := at.Def.(*schema.RecordDefinition); ok {
+			if w.active[at.TypeName] {
+				return fmt.Errorf("cannot represent recursive type %s at %q in Parquet", at.TypeName, avroPath)
+			}
+			w.active[at.TypeName] = true
+			defer delete(w.active, at.TypeName)
+			fmt.Fprintf(&w.buf, "%s%s group %s {\n", indent, repetition, name)
+			if err := w.writeFields(depth+1, def, avroPath, colPath); err != nil {
+				return err
+			}
+			fmt.Fprintf(&w.buf, "%s}\n", indent)
+			return nil
+		}
+	}
+	physical, annotation, err := parquetPrimitive(at)
+	if err != nil {
+		return fmt.Errorf("cannot represent field %q in Parquet: %v", avroPath, err)
+	}
+	if annotation != "" {
+		annotation = " (" + annotation + ")"
+	}
+	fmt.Fprintf(&w.buf, "%s%s %s %s%s;\n", indent, repetition, physical, name, annotation)
+	w.columns[avroPath] = colPath
+	return nil
+}
+
+// nullableItem returns the non-null member of u
+// if u is a union of null and one other type,
+// or nil otherwise.
+func nullableItem(u *schema.UnionField) schema.AvroType {
+	items := u.ItemTypes()
+	if len(items) != 2 {
+		return nil
+	}
+	switch {
+	case isNull(items[0]) && !isNull(items[1]):
+		return items[1]
+	case isNull(items[1]) && !isNull(items[0]):
+		return items[0]
+	}
+	return nil
+}
+
+func isNull(at schema.AvroType) bool {
+	_, ok := at.(*schema.NullField)
+	return ok
+}
+
+// parquetPrimitive returns the Parquet physical type and
+// annotation that represent the non-record Avro type at.
+func parquetPrimitive(at schema.AvroType) (physical, annotation string, err error) {
+	ltype := logicalType(at)
+	if precision, scale, ok := decimalParams(at); ok {
+		annotation = fmt.Sprintf("DECIMAL(%d, %d)", precision, scale)
+	}
+	switch at := at.(type) {
+	case *schema.BoolField:
+		return "boolean", "", nil
+	case *schema.IntField:
+		switch ltype {
+		case "date":
+			annotation = "DATE"
+		case "time-millis":
+			annotation = "TIME(MILLIS, true)"
+		}
+		return "int32", annotation, nil
+	case *schema.LongField:
+		switch ltype {
+		case "time-micros":
+			annotation = "TIME(MICROS, true)"
+		case timestampMillis:
+			annotation = "TIMESTAMP(MILLIS, true)"
+		case timestampMicros:
+			annotation = "TIMESTAMP(MICROS, true)"
+		case localTimestampMillis:
+			annotation = "TIMESTAMP(MILLIS, false)"
+		case localTimestampMicros:
+			annotation = "TIMESTAMP(MICROS, false)"
+		}
+		return "int64", annotation, nil
+	case *schema.FloatField:
+		return "float", "", nil
+	case *schema.DoubleField:
+		return "double", "", nil
+	case *schema.BytesField:
+		return "binary", annotation, nil
+	case *schema.StringField:
+		return "binary", "STRING", nil
+	case *schema.NullField:
+		return "", "", fmt.Errorf("null type has no Parquet equivalent")
+	case *schema.Reference:
+		switch def := at.Def.(type) {
+		case *schema.EnumDefinition:
+			return "binary", "ENUM", nil
+		case *schema.FixedDefinition:
+			if ltype == "duration" && def.SizeBytes() == 12 {
+				annotation = "INTERVAL"
+			}
+			return fmt.Sprintf("fixed_len_byte_array(%d)", def.SizeBytes()), annotation, nil
+		}
+	}
+	return "", "", fmt.Errorf("unexpected Avro type %T", at)
+}
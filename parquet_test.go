@@ -0,0 +1,130 @@
+package avro_test
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro"
+)
+
+func TestParquetSchema(t *testing.T) {
+	c := qt.New(t)
+	at, err := avro.ParseType(`{
+		"type": "record",
+		"name": "R",
+		"namespace": "ns",
+		"fields": [
+			{"name": "id", "type": "long"},
+			{"name": "name", "type": ["null", "string"]},
+			{"name": "ok", "type": "boolean"},
+			{"name": "ts", "type": {"type": "long", "logicalType": "timestamp-millis"}},
+			{"name": "day", "type": {"type": "int", "logicalType": "date"}},
+			{"name": "amount", "type": {"type": "bytes", "logicalType": "decimal", "precision": 10, "scale": 2}},
+			{"name": "color", "type": {"type": "enum", "name": "Color", "symbols": ["red", "green"]}},
+			{"name": "hash", "type": {"type": "fixed", "name": "Hash", "size": 16}},
+			{"name": "tags", "type": {"type": "array", "items": "string"}},
+			{"name": "counts", "type": {"type": "map", "values": ["null", "int"]}},
+			{"name": "items", "type": {"type": "array", "items": {
+				"type": "record",
+				"name": "Item",
+				"fields": [
+					{"name": "sku", "type": "string"},
+					{"name": "price", "type": "double"}
+				]
+			}}},
+			{"name": "parent", "type": ["null", {
+				"type": "record",
+				"name": "Parent",
+				"fields": [
+					{"name": "id", "type": "long"}
+				]
+			}]}
+		]
+	}`)
+	c.Assert(err, qt.Equals, nil)
+	ps, err := at.ParquetSchema()
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(ps.Definition, qt.Equals, `message R {
+  required int64 id;
+  optional binary name (STRING);
+  required boolean ok;
+  required int64 ts (TIMESTAMP(MILLIS, true));
+  required int32 day (DATE);
+  required binary amount (DECIMAL(10, 2));
+  required binary color (ENUM);
+  required fixed_len_byte_array(16) hash;
+  required group tags (LIST) {
+    repeated group list {
+      required binary element (STRING);
+    }
+  }
+  required group counts (MAP) {
+    repeated group key_value {
+      required binary key (STRING);
+      optional int32 value;
+    }
+  }
+  required group items (LIST) {
+    repeated group list {
+      required group element {
+        required binary sku (STRING);
+        required double price;
+      }
+    }
+  }
+  optional group parent {
+    required int64 id;
+  }
+}
+`)
+	c.Assert(ps.Columns, qt.DeepEquals, map[string]string{
+		"id":          "id",
+		"name":        "name",
+		"ok":          "ok",
+		"ts":          "ts",
+		"day":         "day",
+		"amount":      "amount",
+		"color":       "color",
+		"hash":        "hash",
+		"tags":        "tags.list.element",
+		"counts":      "counts.key_value.value",
+		"items.sku":   "items.list.element.sku",
+		"items.price": "items.list.element.price",
+		"parent.id":   "parent.id",
+	})
+}
+
+var parquetSchemaErrorTests = []struct {
+	testName    string
+	schema      string
+	expectError string
+}{{
+	testName:    "not-record",
+	schema:      `"string"`,
+	expectError: `cannot make Parquet schema for non-record type`,
+}, {
+	testName:    "union",
+	schema:      `{"type": "record", "name": "R", "fields": [{"name": "a", "type": ["int", "string"]}]}`,
+	expectError: `cannot represent union at "a" in Parquet`,
+}, {
+	testName:    "null",
+	schema:      `{"type": "record", "name": "R", "fields": [{"name": "a", "type": "null"}]}`,
+	expectError: `cannot represent field "a" in Parquet: null type has no Parquet equivalent`,
+}, {
+	testName:    "recursive",
+	schema:      `{"type": "record", "name": "R", "fields": [{"name": "next", "type": ["null", "R"]}]}`,
+	expectError: `cannot represent recursive type R at "next" in Parquet`,
+}}
+
+func TestParquetSchemaError(t *testing.T) {
+	c := qt.New(t)
+	for _, test := range parquetSchemaErrorTests {
+		c.Run(test.testName, func(c *qt.C) {
+			at, err := avro.ParseType(test.schema)
+			c.Assert(err, qt.Equals, nil)
+			_, err = at.ParquetSchema()
+			c.Assert(err, qt.ErrorMatches, test.expectError)
+		})
+	}
+}
@@ -0,0 +1,208 @@
+package avro
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/rogpeppe/gogen-avro/v7/schema"
+
+	"github.com/heetch/avro/internal/typeinfo"
+)
+
+// TypeOfWithWriter is like TypeOf except that interface types in x,
+// which TypeOf can't derive a schema for, take their Avro types from the
+// corresponding parts of the writer type wType. This makes it possible
+// to encode and decode Go types that only partially specify their
+// schema, without generating code.
+//
+// The part of wType corresponding to an interface type is found by
+// following record fields by name (or by the aliases in an "avroalias"
+// tag), array items, map values and the non-null member of a union
+// represented by a Go pointer. It's an error if there's no corresponding
+// part.
+//
+// When decoding into an interface-typed struct field, the value is of
+// the Go type that StructOf uses for the Avro type; when encoding,
+// the value must be of that type too. A nil interface value represents
+// null.
+//
+// If x doesn't contain any interface types, TypeOfWithWriter
+// returns TypeOf(x) and wType is ignored.
+func TypeOfWithWriter(x interface{}, wType *Type) (*Type, error) {
+	return globalNames.TypeOfWithWriter(x, wType)
+}
+
+// MarshalWithWriter is like Marshal except that the Avro types
+// of interface values in x are taken from wType as described
+// for TypeOfWithWriter.
+func MarshalWithWriter(x interface{}, wType *Type) ([]byte, *Type, error) {
+	return globalNames.MarshalWithWriter(x, wType)
+}
+
+// avroTypeOfWithWriter is like avroTypeOf except that interface
+// types in t are filled in from wType if it's non-nil. Types
+// derived in this way depend on the writer type, so they
+// aren't cached.
+func avroTypeOfWithWriter(names *Names, t reflect.Type, wType *Type) (*Type, error) {
+	if wType == nil || !hasPlaceholder(t, make(map[reflect.Type]bool)) {
+		return avroTypeOf(names, t)
+	}
+	return avroTypeOfUncached(names, t, wType.avroType)
+}
+
+// typeEncoderWithWriter is like typeEncoder except that
+// interface types in t are filled in from wType if it's non-nil.
+func typeEncoderWithWriter(names *Names, t reflect.Type, wType *Type) (*Type, encoderFunc) {
+	if wType == nil || !hasPlaceholder(t, make(map[reflect.Type]bool)) {
+		return typeEncoder(names, t)
+	}
+	at, err := avroTypeOfUncached(names, t, wType.avroType)
+	if err != nil {
+		return nil, errorEncoder(err)
+	}
+	b := &encoderBuilder{
+		names:        names,
+		typeEncoders: make(map[reflect.Type]encoderFunc),
+	}
+	return at, b.typeEncoder(at.avroType, t, typeinfo.Info{})
+}
+
+// hasPlaceholder reports whether the schema for t depends on the
+// writer type, because t contains an interface type that isn't
+// described by a generated schema.
+func hasPlaceholder(t reflect.Type, visited map[reflect.Type]bool) bool {
+	if visited[t] {
+		return false
+	}
+	visited[t] = true
+	if avroRecordOf(t) != nil {
+		return false
+	}
+	if _, ok := runtimeTypes.Load(t); ok {
+		return false
+	}
+	if registeredSchema(t) != nil || isTextType(t) || isBinaryType(t) {
+		return false
+	}
+	switch t.Kind() {
+	case reflect.Interface:
+		return true
+	case reflect.Slice, reflect.Map, reflect.Ptr, reflect.Array:
+		return hasPlaceholder(t.Elem(), visited)
+	case reflect.Struct:
+		// Any error will be reported when the
+		// schema for the type is derived.
+		fields, _ := typeinfo.Fields(t)
+		for _, f := range fields {
+			if hasPlaceholder(f.Type, visited) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// writerField returns the field of the writer record type w
+// that corresponds to a field with the given name and aliases,
+// or nil if there is none.
+func writerField(w schema.AvroType, name string, aliases []string) *schema.Field {
+	ref, ok := w.(*schema.Reference)
+	if !ok {
+		return nil
+	}
+	def, ok := ref.Def.(*schema.RecordDefinition)
+	if !ok {
+		return nil
+	}
+	if wf := def.FieldByName(name); wf != nil {
+		return wf
+	}
+	for _, alias := range aliases {
+		if wf := def.FieldByName(alias); wf != nil {
+			return wf
+		}
+	}
+	return nil
+}
+
+// writerItemType returns the item type of the writer array
+// or map type w, or nil if w isn't an array or a map.
+func writerItemType(w schema.AvroType) schema.AvroType {
+	switch w := w.(type) {
+	case *schema.ArrayField:
+		return w.ItemType()
+	case *schema.MapField:
+		return w.ItemType()
+	}
+	return nil
+}
+
+// writerNonNull returns the first non-null member of
+// the writer union type w, or w itself if it isn't a union.
+func writerNonNull(w schema.AvroType) schema.AvroType {
+	u, ok := w.(*schema.UnionField)
+	if !ok {
+		return w
+	}
+	for _, item := range u.ItemTypes() {
+		if _, ok := item.(*schema.NullField); !ok {
+			return item
+		}
+	}
+	return nil
+}
+
+// placeholderGoType returns the Go type used to hold values
+// of the Avro type at in an interface placeholder.
+func placeholderGoType(at schema.AvroType) (reflect.Type, error) {
+	b := &structBuilder{
+		inProgress: make(map[schema.QualifiedName]bool),
+	}
+	t, err := b.goType(at)
+	if err != nil {
+		return nil, fmt.Errorf("cannot determine Go type for %s: %v", schemaFragment(at), err)
+	}
+	return t, nil
+}
+
+// placeholderUnionEntries returns the union entries for an
+// interface placeholder holding the union type at, so it can be
+// treated in the same way as a generated union type.
+func placeholderUnionEntries(at *schema.UnionField) ([]typeinfo.Info, error) {
+	entries := make([]typeinfo.Info, len(at.ItemTypes()))
+	for i, item := range at.ItemTypes() {
+		if _, ok := item.(*schema.NullField); ok {
+			// A nil type represents null.
+			continue
+		}
+		t, err := placeholderGoType(item)
+		if err != nil {
+			return nil, err
+		}
+		entries[i] = typeinfo.Info{
+			Type: t,
+		}
+	}
+	return entries, nil
+}
+
+// interfaceEncoder encodes an interface placeholder
+// holding a value of type typ.
+type interfaceEncoder struct {
+	typ reflect.Type
+	enc encoderFunc
+}
+
+func (ie interfaceEncoder) encode(e *encodeState, v reflect.Value) {
+	if v.IsNil() {
+		if ie.typ == nullType {
+			return
+		}
+		e.error(fmt.Errorf("nil value not allowed (want %s)", ie.typ))
+	}
+	v = v.Elem()
+	if v.Type() != ie.typ {
+		e.error(fmt.Errorf("cannot encode %s in interface value (want %s)", v.Type(), ie.typ))
+	}
+	ie.enc(e, v)
+}
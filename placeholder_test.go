@@ -0,0 +1,113 @@
+package avro_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro"
+)
+
+func TestTypeOfWithWriter(t *testing.T) {
+	c := qt.New(t)
+	wType := mustParseType(`{
+		"type": "record",
+		"name": "R",
+		"fields": [
+			{"name": "id", "type": "long"},
+			{"name": "payload", "type": "string"},
+			{"name": "extra", "type": ["null", "int"], "default": null}
+		]
+	}`)
+	type R struct {
+		ID      int         `json:"id"`
+		Payload interface{} `json:"payload"`
+		Extra   interface{} `json:"extra"`
+	}
+	_, err := avro.TypeOf(R{})
+	c.Assert(err, qt.ErrorMatches, `cannot determine Avro type for interface type interface \{\} without a writer type \(use TypeOfWithWriter, RegisterUnion or avrogo instead\)`)
+
+	at, err := avro.TypeOfWithWriter(R{}, wType)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(at.String(), qt.JSONEquals, json.RawMessage(`{
+		"type": "record",
+		"name": "R",
+		"fields": [
+			{"name": "id", "type": "long", "default": 0},
+			{"name": "payload", "type": "string"},
+			{"name": "extra", "type": ["null", "int"], "default": null}
+		]
+	}`))
+
+	for _, x := range []R{{
+		ID:      1,
+		Payload: "hello",
+		Extra:   int32(99),
+	}, {
+		ID:      2,
+		Payload: "goodbye",
+	}} {
+		data, _, err := avro.MarshalWithWriter(x, wType)
+		c.Assert(err, qt.Equals, nil)
+		var x1 R
+		_, err = avro.Unmarshal(data, &x1, wType)
+		c.Assert(err, qt.Equals, nil)
+		c.Assert(x1, qt.DeepEquals, x)
+	}
+
+	_, _, err = avro.MarshalWithWriter(R{Payload: 99}, wType)
+	c.Assert(err, qt.ErrorMatches, `cannot encode int in interface value \(want string\)`)
+}
+
+func TestTypeOfWithWriterRecord(t *testing.T) {
+	c := qt.New(t)
+	type Point struct {
+		X int `json:"x"`
+		Y int `json:"y"`
+	}
+	type R struct {
+		ID    int     `json:"id"`
+		Where []Point `json:"where"`
+	}
+	data, wType, err := avro.Marshal(R{
+		ID:    1,
+		Where: []Point{{X: 2, Y: 3}},
+	})
+	c.Assert(err, qt.Equals, nil)
+	{
+		// This R type leaves the type of the where field
+		// to be determined by the writer.
+		type R struct {
+			ID    int         `json:"id"`
+			Where interface{} `json:"where"`
+		}
+		var x R
+		_, err := avro.Unmarshal(data, &x, wType)
+		c.Assert(err, qt.Equals, nil)
+		c.Assert(x.ID, qt.Equals, 1)
+		whereData, err := json.Marshal(x.Where)
+		c.Assert(err, qt.Equals, nil)
+		c.Assert(whereData, qt.JSONEquals, []interface{}{
+			map[string]interface{}{"x": 2, "y": 3},
+		})
+
+		data1, wType1, err := avro.MarshalWithWriter(x, wType)
+		c.Assert(err, qt.Equals, nil)
+		c.Assert(data1, qt.DeepEquals, data)
+		c.Assert(avro.Equal(wType1, wType), qt.Equals, true)
+	}
+}
+
+func TestTypeOfWithWriterMissingField(t *testing.T) {
+	c := qt.New(t)
+	type R struct {
+		A interface{}
+	}
+	_, err := avro.TypeOfWithWriter(R{}, mustParseType(`{
+		"type": "record",
+		"name": "R",
+		"fields": [{"name": "B", "type": "int"}]
+	}`))
+	c.Assert(err, qt.ErrorMatches, `cannot determine Avro type for interface type interface \{\} without a writer type .*`)
+}
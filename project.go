@@ -0,0 +1,242 @@
+package avro
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/rogpeppe/gogen-avro/v7/schema"
+)
+
+// Project returns a record type holding only the fields of t selected
+// by the given paths. Each path is a dot-separated sequence of field
+// names, so "a.b" selects field b inside the record held in field a.
+// A path that names a record-typed field without going further selects
+// all of that record's fields. Paths descend through arrays, maps and
+// unions to the records inside them.
+//
+// The resulting record types keep their original names and namespaces,
+// and fields keep their original order, so data written with t
+// can be read using the projected type.
+//
+// Note that because a name can only be defined once, a record that's
+// projected applies everywhere that name is used in the result.
+//
+// Project returns an error if t is not a record type or if any path
+// does not refer to a field.
+func (t *Type) Project(paths []string) (*Type, error) {
+	ref, ok := t.avroType.(*schema.Reference)
+	if !ok {
+		return nil, fmt.Errorf("cannot project non-record type")
+	}
+	if _, ok := ref.Def.(*schema.RecordDefinition); !ok {
+		return nil, fmt.Errorf("cannot project non-record type %s", ref.TypeName)
+	}
+	sel := make(projection)
+	for _, p := range paths {
+		if p == "" {
+			return nil, fmt.Errorf("empty projection path")
+		}
+		sel.add(strings.Split(p, "."))
+	}
+	pr := &projector{
+		scope: emptyScope(),
+	}
+	v, err := pr.projectType(ref, sel, "")
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal projected schema: %v", err)
+	}
+	return ParseType(string(data))
+}
+
+// projection holds a tree of selected field names. A nil entry
+// means that the whole of the field is selected.
+type projection map[string]projection
+
+func (p projection) add(path []string) {
+	sub, ok := p[path[0]]
+	if ok && sub == nil {
+		// The whole field is already selected.
+		return
+	}
+	if len(path) == 1 {
+		p[path[0]] = nil
+		return
+	}
+	if sub == nil {
+		sub = make(projection)
+		p[path[0]] = sub
+	}
+	sub.add(path[1:])
+}
+
+type projector struct {
+	// scope holds all the names defined so far in the
+	// resulting schema.
+	scope map[schema.QualifiedName]interface{}
+}
+
+// projectType returns the JSON-marshalable schema for at
+// projected by sel. The path argument is used for error messages only.
+func (pr *projector) projectType(at schema.AvroType, sel projection, path string) (interface{}, error) {
+	if sel == nil {
+		return at.Definition(pr.scope)
+	}
+	switch at := at.(type) {
+	case *schema.Reference:
+		def, ok := at.Def.(*schema.RecordDefinition)
+		if !ok {
+			return nil, fmt.Errorf("cannot select fields from non-record type at %q", path)
+		}
+		if _, ok := pr.scope[at.TypeName]; ok {
+			// It's already been defined, so it's either already been
+			// projected or it's been used in its entirety.
+			return at.TypeName.String(), nil
+		}
+		obj := copyOfSchemaObj(at)
+		pr.scope[at.TypeName] = obj
+		var fields []interface{}
+		found := 0
+		for _, f := range def.Fields() {
+			fsel, ok := sel[f.Name()]
+			if !ok {
+				continue
+			}
+			found++
+			fobj := copyOfSchemaObj(f)
+			ftype, err := pr.projectType(f.Type(), fsel, joinPath(path, f.Name()))
+			if err != nil {
+				return nil, err
+			}
+			fobj["type"] = ftype
+			if d, ok := fobj["default"]; ok {
+				fobj["default"] = projectDefault(d, f.Type(), fsel)
+			}
+			fields = append(fields, fobj)
+		}
+		if found != len(sel) {
+			for name := range sel {
+				if def.FieldByName(name) == nil {
+					return nil, fmt.Errorf("field %q not found in %s", joinPath(path, name), at.TypeName)
+				}
+			}
+		}
+		obj["fields"] = fields
+		return obj, nil
+	case *schema.UnionField:
+		items := make([]interface{}, len(at.ItemTypes()))
+		projected := false
+		for i, item := range at.ItemTypes() {
+			var err error
+			if isRecordContainer(item) {
+				items[i], err = pr.projectType(item, sel, path)
+				projected = true
+			} else {
+				items[i], err = item.Definition(pr.scope)
+			}
+			if err != nil {
+				return nil, err
+			}
+		}
+		if !projected {
+			return nil, fmt.Errorf("cannot select fields from union without record members at %q", path)
+		}
+		return items, nil
+	case *schema.ArrayField:
+		items, err := pr.projectType(at.ItemType(), sel, path)
+		if err != nil {
+			return nil, err
+		}
+		obj := copyOfSchemaObj(at)
+		obj["items"] = items
+		return obj, nil
+	case *schema.MapField:
+		values, err := pr.projectType(at.ItemType(), sel, path)
+		if err != nil {
+			return nil, err
+		}
+		obj := copyOfSchemaObj(at)
+		obj["values"] = values
+		return obj, nil
+	default:
+		return nil, fmt.Errorf("cannot select fields from non-record type at %q", path)
+	}
+}
+
+// isRecordContainer reports whether at is a record or
+// an array or map that holds records.
+func isRecordContainer(at schema.AvroType) bool {
+	switch at := at.(type) {
+	case *schema.Reference:
+		_, ok := at.Def.(*schema.RecordDefinition)
+		return ok
+	case *schema.ArrayField:
+		return isRecordContainer(at.ItemType())
+	case *schema.MapField:
+		return isRecordContainer(at.ItemType())
+	}
+	return false
+}
+
+// projectDefault removes any unselected record fields
+// from the default value d for a value of type at.
+func projectDefault(d interface{}, at schema.AvroType, sel projection) interface{} {
+	if sel == nil {
+		return d
+	}
+	switch at := at.(type) {
+	case *schema.Reference:
+		m, ok := d.(map[string]interface{})
+		if !ok {
+			return d
+		}
+		m1 := make(map[string]interface{})
+		for name, fsel := range sel {
+			v, ok := m[name]
+			if !ok {
+				continue
+			}
+			if f := at.Def.(*schema.RecordDefinition).FieldByName(name); f != nil {
+				v = projectDefault(v, f.Type(), fsel)
+			}
+			m1[name] = v
+		}
+		return m1
+	case *schema.UnionField:
+		// The default value for a union is always
+		// of the first member type.
+		return projectDefault(d, at.ItemTypes()[0], sel)
+	case *schema.ArrayField:
+		a, ok := d.([]interface{})
+		if !ok {
+			return d
+		}
+		a1 := make([]interface{}, len(a))
+		for i, v := range a {
+			a1[i] = projectDefault(v, at.ItemType(), sel)
+		}
+		return a1
+	case *schema.MapField:
+		m, ok := d.(map[string]interface{})
+		if !ok {
+			return d
+		}
+		m1 := make(map[string]interface{})
+		for k, v := range m {
+			m1[k] = projectDefault(v, at.ItemType(), sel)
+		}
+		return m1
+	}
+	return d
+}
+
+func joinPath(path, name string) string {
+	if path == "" {
+		return name
+	}
+	return path + "." + name
+}
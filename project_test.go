@@ -0,0 +1,131 @@
+package avro_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+)
+
+var projectTests = []struct {
+	testName    string
+	paths       []string
+	expect      string
+	expectError string
+}{{
+	testName: "top-level-fields",
+	paths:    []string{"c", "a"},
+	expect: `{
+	"type": "record",
+	"name": "R",
+	"namespace": "com.example",
+	"fields": [{
+		"name": "a",
+		"type": "int",
+		"default": 1
+	}, {
+		"name": "c",
+		"type": "string"
+	}]
+}`,
+}, {
+	testName: "nested-field",
+	paths:    []string{"b.y"},
+	expect: `{
+	"type": "record",
+	"name": "R",
+	"namespace": "com.example",
+	"fields": [{
+		"name": "b",
+		"type": ["null", {
+			"type": "record",
+			"name": "S",
+			"fields": [{
+				"name": "y",
+				"type": {"type": "array", "items": "long"}
+			}]
+		}]
+	}]
+}`,
+}, {
+	testName: "whole-record-wins",
+	paths:    []string{"b.y", "b"},
+	expect: `{
+	"type": "record",
+	"name": "R",
+	"namespace": "com.example",
+	"fields": [{
+		"name": "b",
+		"type": ["null", {
+			"type": "record",
+			"name": "S",
+			"fields": [{
+				"name": "x",
+				"type": "string"
+			}, {
+				"name": "y",
+				"type": {"type": "array", "items": "long"}
+			}]
+		}]
+	}]
+}`,
+}, {
+	testName:    "unknown-field",
+	paths:       []string{"a", "nope"},
+	expectError: `field "nope" not found in com.example.R`,
+}, {
+	testName:    "unknown-nested-field",
+	paths:       []string{"b.nope"},
+	expectError: `field "b.nope" not found in com.example.S`,
+}, {
+	testName:    "select-inside-primitive",
+	paths:       []string{"a.x"},
+	expectError: `cannot select fields from non-record type at "a"`,
+}}
+
+func TestProject(t *testing.T) {
+	c := qt.New(t)
+	at := mustParseType(`{
+	"type": "record",
+	"name": "R",
+	"namespace": "com.example",
+	"fields": [{
+		"name": "a",
+		"type": "int",
+		"default": 1
+	}, {
+		"name": "b",
+		"type": ["null", {
+			"type": "record",
+			"name": "S",
+			"fields": [{
+				"name": "x",
+				"type": "string"
+			}, {
+				"name": "y",
+				"type": {"type": "array", "items": "long"}
+			}]
+		}]
+	}, {
+		"name": "c",
+		"type": "string"
+	}]
+}`)
+	for _, test := range projectTests {
+		c.Run(test.testName, func(c *qt.C) {
+			pt, err := at.Project(test.paths)
+			if test.expectError != "" {
+				c.Assert(err, qt.ErrorMatches, test.expectError)
+				return
+			}
+			c.Assert(err, qt.Equals, nil)
+			c.Assert(pt.String(), qt.JSONEquals, json.RawMessage(test.expect))
+		})
+	}
+}
+
+func TestProjectNonRecord(t *testing.T) {
+	c := qt.New(t)
+	_, err := mustParseType(`"string"`).Project([]string{"a"})
+	c.Assert(err, qt.ErrorMatches, `cannot project non-record type`)
+}
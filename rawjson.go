@@ -0,0 +1,35 @@
+package avro
+
+import (
+	"encoding/json"
+	"reflect"
+)
+
+var rawMessageType = reflect.TypeOf(json.RawMessage(nil))
+
+// isRawMessageField reports whether f holds a json.RawMessage,
+// directly or through a pointer.
+func isRawMessageField(f reflect.StructField) bool {
+	t := f.Type
+	if t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	return t == rawMessageType
+}
+
+// useRawJSONBytes returns the schema for a json.RawMessage field
+// with the "bytes" tag option, given the schema derived for it,
+// which represents the JSON as a string.
+func useRawJSONBytes(ftype interface{}) interface{} {
+	switch ftype := ftype.(type) {
+	case string:
+		if ftype == "string" {
+			return "bytes"
+		}
+	case []interface{}:
+		for i, t := range ftype {
+			ftype[i] = useRawJSONBytes(t)
+		}
+	}
+	return ftype
+}
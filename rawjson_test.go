@@ -0,0 +1,63 @@
+package avro_test
+
+import (
+	"encoding/json"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro"
+)
+
+func TestRawMessage(t *testing.T) {
+	c := qt.New(t)
+	type R struct {
+		Payload  json.RawMessage
+		Optional *json.RawMessage
+		Bytes    json.RawMessage `avro:",bytes"`
+	}
+	at, err := avro.TypeOf(R{})
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(at.String(), qt.JSONEquals, json.RawMessage(`{
+		"type": "record",
+		"name": "R",
+		"fields": [{
+			"name": "Payload",
+			"default": "",
+			"type": "string"
+		}, {
+			"name": "Optional",
+			"default": null,
+			"type": ["null", "string"]
+		}, {
+			"name": "Bytes",
+			"default": "",
+			"type": "bytes"
+		}]
+	}`))
+	optional := json.RawMessage(`[1,2,3]`)
+	x := R{
+		Payload:  json.RawMessage(`{"a":"b"}`),
+		Optional: &optional,
+		Bytes:    json.RawMessage(`true`),
+	}
+	data, wType, err := avro.Marshal(x)
+	c.Assert(err, qt.Equals, nil)
+	var x1 R
+	_, err = avro.Unmarshal(data, &x1, wType)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(x1, qt.DeepEquals, x)
+
+	// The JSON is held as strings in the encoded data.
+	{
+		type R struct {
+			Payload  string
+			Optional *string
+		}
+		var x2 R
+		_, err = avro.Unmarshal(data, &x2, wType)
+		c.Assert(err, qt.Equals, nil)
+		c.Assert(x2.Payload, qt.Equals, `{"a":"b"}`)
+		c.Assert(*x2.Optional, qt.Equals, `[1,2,3]`)
+	}
+}
@@ -36,6 +36,7 @@ func (d *decoder) fill(n int) int {
 	// Slide any remaining bytes to the
 	// start of the buffer.
 	total := copy(d.buf, d.buf[d.scan:])
+	d.consumed += int64(d.scan)
 	d.scan = 0
 	d.buf = d.buf[:cap(d.buf)]
 	for total < n {
@@ -83,9 +84,9 @@ func (d *decoder) readBytes() []byte {
 }
 
 func (d *decoder) readFixed(size int) []byte {
-	if size < cap(d.buf) {
-		// The bytes will fit in the buffer we already
-		// have, so use that.
+	if size < cap(d.buf) || len(d.buf)-d.scan >= size || d.readErr != nil {
+		// The bytes are already in the buffer or will fit
+		// in it, so slice them directly from there.
 		return d.read(size)
 	}
 	buf := make([]byte, size)
@@ -94,27 +95,49 @@ func (d *decoder) readFixed(size int) []byte {
 	if err != nil {
 		d.error(err)
 	}
+	d.consumed += int64(size - n)
 	d.scan = len(d.buf)
 	return buf
 }
 
 func (d *decoder) readLong() int64 {
-	// Note: d.fill doesn't mind if we get less
-	// than the required number of bytes.
-	d.fill(binary.MaxVarintLen64)
-	x, nr := binary.Varint(d.buf[d.scan:])
-	switch {
-	case nr > 0:
-		d.scan += nr
-		return x
-	case nr == 0:
+	if len(d.buf)-d.scan < binary.MaxVarintLen64 {
+		// Note: d.fill doesn't mind if we get less
+		// than the required number of bytes.
+		d.fill(binary.MaxVarintLen64)
+	}
+	// Decode the zig-zag varint directly from the buffer
+	// rather than going through binary.Varint.
+	var ux uint64
+	buf := d.buf[d.scan:]
+	for i, b := range buf {
+		if i == binary.MaxVarintLen64 {
+			break
+		}
+		if b < 0x80 {
+			if i == binary.MaxVarintLen64-1 && b > 1 {
+				break
+			}
+			ux |= uint64(b) << (7 * uint(i))
+			d.scan += i + 1
+			return int64(ux>>1) ^ -int64(ux&1)
+		}
+		ux |= uint64(b&0x7f) << (7 * uint(i))
+	}
+	if len(buf) < binary.MaxVarintLen64 {
 		d.error(io.ErrUnexpectedEOF)
-	default:
-		d.error(fmt.Errorf("integer too large"))
 	}
+	d.error(fmt.Errorf("integer too large"))
 	panic("unreachable")
 }
 
 func (d *decoder) readString() string {
-	return string(d.readBytes())
+	if d.arena == nil {
+		return string(d.readBytes())
+	}
+	s, err := d.arena.newString(d.readBytes())
+	if err != nil {
+		d.error(err)
+	}
+	return s
 }
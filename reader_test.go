@@ -2,6 +2,7 @@ package avro
 
 import (
 	"io"
+	"math"
 	"strings"
 	"testing"
 	"testing/iotest"
@@ -25,7 +26,37 @@ func TestReadFixed(t *testing.T) {
 		d.readFixed(30)
 	})
 	c.Assert(p, qt.Not(qt.IsNil))
-	c.Assert(p.(*decodeError).err, qt.Equals, io.ErrUnexpectedEOF)
+	c.Assert(p.(*DecodeError).Err, qt.Equals, io.ErrUnexpectedEOF)
+	c.Assert(p.(*DecodeError).Offset, qt.Equals, int64(13))
+}
+
+func TestReadLong(t *testing.T) {
+	c := qt.New(t)
+	for _, x := range []int64{0, 1, -1, 63, -64, 64, 1 << 20, math.MaxInt64, math.MinInt64} {
+		d := &decoder{
+			buf:     appendLong(nil, x),
+			readErr: io.EOF,
+		}
+		c.Assert(d.readLong(), qt.Equals, x)
+		c.Assert(d.scan, qt.Equals, len(d.buf))
+	}
+	d := &decoder{
+		buf:     []byte{0x80, 0x80},
+		readErr: io.EOF,
+	}
+	p := catch(func() {
+		d.readLong()
+	})
+	c.Assert(p.(*DecodeError).Err, qt.Equals, io.ErrUnexpectedEOF)
+
+	d = &decoder{
+		buf:     []byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x01},
+		readErr: io.EOF,
+	}
+	p = catch(func() {
+		d.readLong()
+	})
+	c.Assert(p.(*DecodeError).Err, qt.ErrorMatches, "integer too large")
 }
 
 func catch(f func()) (v interface{}) {
@@ -0,0 +1,171 @@
+package avro_test
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro"
+)
+
+type recursiveList struct {
+	Value int
+	Next  *recursiveList
+}
+
+type recursiveTree struct {
+	Name   string
+	Leaves []recursiveLeaf
+}
+
+type recursiveLeaf struct {
+	Size    int
+	Subtree *recursiveTree
+}
+
+type recursiveNode struct {
+	Name     string
+	Children []*recursiveNode
+}
+
+type recursiveMap struct {
+	Value int
+	Sub   map[string]recursiveMap
+}
+
+func TestRecursiveGoType(t *testing.T) {
+	c := qt.New(t)
+	x := recursiveList{
+		Value: 1,
+		Next: &recursiveList{
+			Value: 2,
+			Next: &recursiveList{
+				Value: 3,
+			},
+		},
+	}
+	data, wType, err := avro.Marshal(x)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(wType.CanonicalString(0), qt.Equals, `{"name":"recursiveList","type":"record","fields":[{"name":"Value","type":"long"},{"name":"Next","type":["null","recursiveList"]}]}`)
+	var x1 recursiveList
+	_, err = avro.Unmarshal(data, &x1, wType)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(x1, qt.DeepEquals, x)
+}
+
+func TestMutuallyRecursiveGoType(t *testing.T) {
+	c := qt.New(t)
+	x := recursiveTree{
+		Name: "root",
+		Leaves: []recursiveLeaf{{
+			Size: 1,
+		}, {
+			Size: 2,
+			Subtree: &recursiveTree{
+				Name: "sub",
+				Leaves: []recursiveLeaf{{
+					Size: 3,
+				}},
+			},
+		}},
+	}
+	data, wType, err := avro.Marshal(x)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(wType.CanonicalString(0), qt.Equals, `{"name":"recursiveTree","type":"record","fields":[{"name":"Name","type":"string"},{"name":"Leaves","type":{"type":"array","items":{"name":"recursiveLeaf","type":"record","fields":[{"name":"Size","type":"long"},{"name":"Subtree","type":["null","recursiveTree"]}]}}}]}`)
+	var x1 recursiveTree
+	_, err = avro.Unmarshal(data, &x1, wType)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(x1, qt.DeepEquals, x)
+}
+
+func TestRecursiveGoTypeInSlice(t *testing.T) {
+	c := qt.New(t)
+	x := recursiveNode{
+		Name: "root",
+		Children: []*recursiveNode{{
+			Name: "a",
+		}, nil, {
+			Name: "b",
+			Children: []*recursiveNode{{
+				Name: "c",
+			}},
+		}},
+	}
+	data, wType, err := avro.Marshal(x)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(wType.CanonicalString(0), qt.Equals, `{"name":"recursiveNode","type":"record","fields":[{"name":"Name","type":"string"},{"name":"Children","type":{"type":"array","items":["null","recursiveNode"]}}]}`)
+	var x1 recursiveNode
+	_, err = avro.Unmarshal(data, &x1, wType)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(x1, qt.DeepEquals, x)
+}
+
+func TestRecursiveGoTypeInMap(t *testing.T) {
+	c := qt.New(t)
+	x := recursiveMap{
+		Value: 1,
+		Sub: map[string]recursiveMap{
+			"a": {
+				Value: 2,
+				Sub: map[string]recursiveMap{
+					"b": {Value: 3},
+				},
+			},
+		},
+	}
+	data, wType, err := avro.Marshal(x)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(wType.CanonicalString(0), qt.Equals, `{"name":"recursiveMap","type":"record","fields":[{"name":"Value","type":"long"},{"name":"Sub","type":{"type":"map","values":"recursiveMap"}}]}`)
+	var x1 recursiveMap
+	_, err = avro.Unmarshal(data, &x1, wType)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(x1, qt.DeepEquals, x)
+}
+
+func TestRecursiveSchemaResolution(t *testing.T) {
+	c := qt.New(t)
+	// The writer type has an extra field inside the recursive
+	// part of the type, which should be skipped when reading.
+	wType := mustParseType(`{
+	"type": "record",
+	"name": "recursiveList",
+	"fields": [
+		{"name": "Value", "type": "int"},
+		{"name": "Extra", "type": "string"},
+		{"name": "Next", "type": ["null", "recursiveList"]}
+	]
+}`)
+	type writerList struct {
+		Value int32
+		Extra string
+		Next  *writerList
+	}
+	data, _, err := avro.Marshal(writerList{
+		Value: 1,
+		Extra: "a",
+		Next: &writerList{
+			Value: 2,
+			Extra: "b",
+		},
+	})
+	c.Assert(err, qt.Equals, nil)
+	var x recursiveList
+	_, err = avro.Unmarshal(data, &x, wType)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(x, qt.DeepEquals, recursiveList{
+		Value: 1,
+		Next: &recursiveList{
+			Value: 2,
+		},
+	})
+
+	rType, err := avro.TypeOf(recursiveList{})
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(avro.Backward.Check(rType, wType), qt.Equals, nil)
+	err = avro.Forward.Check(rType, wType)
+	c.Assert(err, qt.ErrorMatches, `old type 0 cannot read data written with new type: incompatible schemas \(2 problems\):
+	recursiveList.Value: type mismatch \(writer type "long"; reader type "int"\)
+	recursiveList.Extra: field not present in writer and has no default value \(reader type "string"\)`)
+	c.Assert(avro.Equal(rType, mustParseType(rType.String())), qt.Equals, true)
+	c.Assert(avro.Equal(rType, wType), qt.Equals, false)
+}
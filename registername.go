@@ -0,0 +1,140 @@
+package avro
+
+import (
+	"fmt"
+	"reflect"
+	"sync"
+
+	"github.com/heetch/avro/internal/typeinfo"
+)
+
+// registeredNames holds the Avro names registered with RegisterName.
+var registeredNames struct {
+	mu sync.RWMutex
+	// byGoType maps from Go type to the Avro name registered for it.
+	byGoType map[reflect.Type]string
+	// byName maps from Avro name to the first Go type
+	// registered with that name, the canonical Go type for the name.
+	byName map[string]reflect.Type
+}
+
+// RegisterName registers fullName as the fully qualified Avro name
+// for the given Go type, which must be a struct type or a fixed-size byte
+// array type. TypeOf uses the registered name instead of the Go type
+// name when deriving a schema for the type, so that two packages that
+// define types with the same name can both be used in the same schema.
+//
+// Several Go types can be registered with the same name, which
+// asserts that they all represent the same Avro definition. The first
+// type registered for a name is its canonical Go type. When several
+// such types are found in the same Go type, the definition is taken from
+// the first one encountered and the others refer to it by name
+// instead of causing a duplicate definition error. TypeOf returns an
+// error if their kinds or field names differ. Values of such types
+// can only be decoded together if their fields have identical types.
+//
+// Types generated by avrogo take their names from their schemas, so
+// registering a generated type only affects duplicate resolution.
+//
+// Registering a Go type that has already been registered replaces the
+// earlier registration. RegisterName panics if goType is nil or has an
+// unsupported kind, or fullName is empty or the name of a built-in type.
+//
+// The name is used when a Go type's schema is first derived, and the
+// schema is cached after that, so registering a name doesn't change
+// the schema of any Go type that has already been used. Names should
+// be registered before any values of goType, or types containing it,
+// are encoded or decoded, for example in an init function.
+func RegisterName(goType reflect.Type, fullName string) {
+	if goType == nil {
+		panic(fmt.Errorf("nil Go type registered for name %q", fullName))
+	}
+	if fullName == "" || builtinTypes[fullName] {
+		panic(fmt.Errorf("invalid name %q registered for %s", fullName, goType))
+	}
+	if k := goType.Kind(); k != reflect.Struct && (k != reflect.Array || goType.Elem() != byteType) {
+		panic(fmt.Errorf("cannot register name %q for %s: not a struct or byte array type", fullName, goType))
+	}
+	registeredNames.mu.Lock()
+	defer registeredNames.mu.Unlock()
+	if registeredNames.byGoType == nil {
+		registeredNames.byGoType = make(map[reflect.Type]string)
+		registeredNames.byName = make(map[string]reflect.Type)
+	}
+	if old, ok := registeredNames.byGoType[goType]; ok && registeredNames.byName[old] == goType {
+		delete(registeredNames.byName, old)
+	}
+	registeredNames.byGoType[goType] = fullName
+	if _, ok := registeredNames.byName[fullName]; !ok {
+		registeredNames.byName[fullName] = goType
+	}
+}
+
+// registeredName returns the Avro name registered for t,
+// or the empty string if there is none.
+func registeredName(t reflect.Type) string {
+	registeredNames.mu.RLock()
+	defer registeredNames.mu.RUnlock()
+	return registeredNames.byGoType[t]
+}
+
+// sharedDefinition reports whether t can refer to the definition
+// that gts has already made for another Go type with the given name,
+// because both types have been registered with that name.
+// It returns an error if the types cannot represent the same definition.
+func (gts *goTypeSchema) sharedDefinition(t reflect.Type, name string) (bool, error) {
+	if registeredName(t) != name {
+		return false, nil
+	}
+	for t0, d := range gts.defs {
+		if d.name != name || t0 == t {
+			continue
+		}
+		if registeredName(t0) != name {
+			return false, nil
+		}
+		if err := sameShape(t0, t); err != nil {
+			return false, fmt.Errorf("cannot use %s and %s for Avro type %q: %v", t0, t, name, err)
+		}
+		return true, nil
+	}
+	return false, nil
+}
+
+// sameShape returns an error if the Go types t0 and t1 obviously
+// cannot represent the same Avro definition.
+func sameShape(t0, t1 reflect.Type) error {
+	if t0.Kind() != t1.Kind() {
+		return fmt.Errorf("kind %v does not match kind %v", t0.Kind(), t1.Kind())
+	}
+	switch t0.Kind() {
+	case reflect.Array:
+		if t0.Len() != t1.Len() {
+			return fmt.Errorf("size %d does not match size %d", t0.Len(), t1.Len())
+		}
+	case reflect.Struct:
+		names0, names1 := avroFieldNames(t0), avroFieldNames(t1)
+		if len(names0) != len(names1) {
+			return fmt.Errorf("field names %q do not match field names %q", names0, names1)
+		}
+		for i := range names0 {
+			if names0[i] != names1[i] {
+				return fmt.Errorf("field names %q do not match field names %q", names0, names1)
+			}
+		}
+	}
+	return nil
+}
+
+// avroFieldNames returns the Avro names of the fields of
+// the struct type t, in order.
+func avroFieldNames(t reflect.Type) []string {
+	// Any error will be reported when the
+	// schema for the type is derived.
+	fields, _ := typeinfo.Fields(t)
+	names := make([]string, len(fields))
+	for i, f := range fields {
+		names[i], _ = typeinfo.FieldName(f)
+	}
+	return names
+}
@@ -0,0 +1,175 @@
+package avro_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro"
+)
+
+func TestRegisterNameDisambiguates(t *testing.T) {
+	c := qt.New(t)
+	type User struct {
+		Name string
+	}
+	type OuterUser = User
+	{
+		// This User type has the same Go name as the one above.
+		type User struct {
+			ID int
+		}
+		type R struct {
+			A OuterUser
+			B User
+		}
+		_, err := avro.TypeOf(R{})
+		c.Assert(err, qt.ErrorMatches, `duplicate struct type name "User" \(use RegisterName to disambiguate\)`)
+
+		type S struct {
+			A OuterUser
+			B User
+		}
+		avro.RegisterName(reflect.TypeOf(OuterUser{}), "a.User")
+		avro.RegisterName(reflect.TypeOf(User{}), "b.User")
+		at, err := avro.TypeOf(S{})
+		c.Assert(err, qt.Equals, nil)
+		c.Assert(at.String(), qt.JSONEquals, json.RawMessage(`{
+			"type": "record",
+			"name": "S",
+			"fields": [{
+				"name": "A",
+				"default": {"Name": ""},
+				"type": {
+					"type": "record",
+					"name": "a.User",
+					"fields": [{
+						"name": "Name",
+						"type": "string",
+						"default": ""
+					}]
+				}
+			}, {
+				"name": "B",
+				"default": {"ID": 0},
+				"type": {
+					"type": "record",
+					"name": "b.User",
+					"fields": [{
+						"name": "ID",
+						"type": "long",
+						"default": 0
+					}]
+				}
+			}]
+		}`))
+
+		data, wType, err := avro.Marshal(S{
+			A: OuterUser{Name: "bob"},
+			B: User{ID: 99},
+		})
+		c.Assert(err, qt.Equals, nil)
+		var x S
+		_, err = avro.Unmarshal(data, &x, wType)
+		c.Assert(err, qt.Equals, nil)
+		c.Assert(x, qt.DeepEquals, S{
+			A: OuterUser{Name: "bob"},
+			B: User{ID: 99},
+		})
+	}
+}
+
+func TestRegisterNameSharedDefinition(t *testing.T) {
+	c := qt.New(t)
+	type Point struct {
+		X int
+		Y int
+	}
+	type OuterPoint = Point
+	{
+		// This Point type represents the same Avro
+		// definition as the one above.
+		type Point struct {
+			X int
+			Y int
+		}
+		avro.RegisterName(reflect.TypeOf(OuterPoint{}), "geo.Point")
+		avro.RegisterName(reflect.TypeOf(Point{}), "geo.Point")
+		type R struct {
+			A OuterPoint
+			B Point
+		}
+		at, err := avro.TypeOf(R{})
+		c.Assert(err, qt.Equals, nil)
+		c.Assert(at.String(), qt.JSONEquals, json.RawMessage(`{
+			"type": "record",
+			"name": "R",
+			"fields": [{
+				"name": "A",
+				"default": {"X": 0, "Y": 0},
+				"type": {
+					"type": "record",
+					"name": "geo.Point",
+					"fields": [{
+						"name": "X",
+						"type": "long",
+						"default": 0
+					}, {
+						"name": "Y",
+						"type": "long",
+						"default": 0
+					}]
+				}
+			}, {
+				"name": "B",
+				"default": {"X": 0, "Y": 0},
+				"type": "geo.Point"
+			}]
+		}`))
+		data, wType, err := avro.Marshal(R{
+			A: OuterPoint{X: 1, Y: 2},
+			B: Point{X: 3, Y: 4},
+		})
+		c.Assert(err, qt.Equals, nil)
+		var x R
+		_, err = avro.Unmarshal(data, &x, wType)
+		c.Assert(err, qt.Equals, nil)
+		c.Assert(x, qt.DeepEquals, R{
+			A: OuterPoint{X: 1, Y: 2},
+			B: Point{X: 3, Y: 4},
+		})
+	}
+}
+
+func TestRegisterNameMismatchedFields(t *testing.T) {
+	c := qt.New(t)
+	type Item struct {
+		A int
+	}
+	type OuterItem = Item
+	{
+		type Item struct {
+			B int
+		}
+		avro.RegisterName(reflect.TypeOf(OuterItem{}), "shop.Item")
+		avro.RegisterName(reflect.TypeOf(Item{}), "shop.Item")
+		type R struct {
+			A OuterItem
+			B Item
+		}
+		_, err := avro.TypeOf(R{})
+		c.Assert(err, qt.ErrorMatches, `cannot use .*Item and .*Item for Avro type "shop.Item": field names \["A"\] do not match field names \["B"\]`)
+	}
+}
+
+func TestRegisterNamePanics(t *testing.T) {
+	c := qt.New(t)
+	c.Assert(func() {
+		avro.RegisterName(reflect.TypeOf(0), "foo.Int")
+	}, qt.PanicMatches, `cannot register name "foo.Int" for int: not a struct or byte array type`)
+	c.Assert(func() {
+		avro.RegisterName(reflect.TypeOf(struct{}{}), "string")
+	}, qt.PanicMatches, `invalid name "string" registered for struct {}`)
+}
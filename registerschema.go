@@ -0,0 +1,142 @@
+package avro
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/rogpeppe/gogen-avro/v7/schema"
+
+	"github.com/heetch/avro/internal/typeinfo"
+)
+
+// registeredSchemas holds the schemas registered with RegisterSchema.
+var registeredSchemas struct {
+	mu sync.RWMutex
+	// byGoType maps from Go type to the schema registered for it.
+	byGoType map[reflect.Type]*Type
+}
+
+// RegisterSchema registers schema, which must be a record, enum or
+// fixed definition, as the Avro schema for the given Go type. TypeOf
+// uses it instead of deriving a schema from the Go type, so types that
+// can't be changed, such as types defined in other packages, can be
+// used with a hand-written schema.
+//
+// Values of the type are encoded and decoded in the usual way, so the
+// schema must be compatible with the Go type: record fields are matched
+// to struct fields by name as described for TypeOf, although struct
+// fields not in the record are ignored; an enum must be represented
+// by a signed integer type holding the index of the symbol; and a
+// fixed type must be represented by a byte array of the same size.
+//
+// Registering a Go type that has already been registered replaces
+// the earlier registration. RegisterSchema panics if goType is nil
+// or the schema is invalid or obviously incompatible with goType.
+//
+// Schemas are cached for each Go type, so a registered schema is not
+// used for goType if its schema has already been derived, for example
+// because a value of the type has already been encoded. Register the
+// schema before goType is used, for example in an init function.
+func RegisterSchema(goType reflect.Type, schema string) {
+	if goType == nil {
+		panic(fmt.Errorf("nil Go type registered for schema"))
+	}
+	t, err := ParseType(schema)
+	if err != nil {
+		panic(fmt.Errorf("cannot register schema for %s: %v", goType, err))
+	}
+	if err := checkRegisteredSchema(goType, t); err != nil {
+		panic(fmt.Errorf("cannot register schema for %s: %v", goType, err))
+	}
+	registeredSchemas.mu.Lock()
+	defer registeredSchemas.mu.Unlock()
+	if registeredSchemas.byGoType == nil {
+		registeredSchemas.byGoType = make(map[reflect.Type]*Type)
+	}
+	registeredSchemas.byGoType[goType] = t
+}
+
+// registeredSchema returns the schema registered for t,
+// or nil if there is none.
+func registeredSchema(t reflect.Type) *Type {
+	registeredSchemas.mu.RLock()
+	defer registeredSchemas.mu.RUnlock()
+	return registeredSchemas.byGoType[t]
+}
+
+// checkRegisteredSchema returns an error if the Go type t
+// obviously can't be used with the schema at.
+func checkRegisteredSchema(t reflect.Type, at *Type) error {
+	ref, ok := at.avroType.(*schema.Reference)
+	if !ok {
+		return fmt.Errorf("schema is not a record, enum or fixed definition")
+	}
+	switch def := ref.Def.(type) {
+	case *schema.RecordDefinition:
+		if t.Kind() != reflect.Struct {
+			return fmt.Errorf("record schema used for non-struct type")
+		}
+		fields, err := goFieldsByName(t)
+		if err != nil {
+			return err
+		}
+		for _, f := range def.Fields() {
+			if _, ok := fields[f.Name()]; !ok {
+				return fmt.Errorf("no struct field found for record field %q", f.Name())
+			}
+		}
+	case *schema.EnumDefinition:
+		if !isIntKind(t.Kind()) {
+			return fmt.Errorf("enum schema used for type that isn't a signed integer")
+		}
+	case *schema.FixedDefinition:
+		if t.Kind() != reflect.Array || t.Elem() != byteType || t.Len() != def.SizeBytes() {
+			return fmt.Errorf("fixed schema of size %d used for type that isn't [%d]byte", def.SizeBytes(), def.SizeBytes())
+		}
+	}
+	return nil
+}
+
+// goFieldsByName returns the fields of the struct type t
+// keyed by their Avro names.
+func goFieldsByName(t reflect.Type) (map[string]reflect.StructField, error) {
+	fields, err := typeinfo.Fields(t)
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]reflect.StructField)
+	for _, f := range fields {
+		name, _ := typeinfo.FieldName(f)
+		byName[name] = f
+	}
+	return byName, nil
+}
+
+// defaultForRegistered returns the default value for the Go type t
+// with the registered schema at, which represents the zero value of t.
+func (gts *goTypeSchema) defaultForRegistered(t reflect.Type, at *Type) (interface{}, error) {
+	switch def := at.avroType.(*schema.Reference).Def.(type) {
+	case *schema.RecordDefinition:
+		goFields, err := goFieldsByName(t)
+		if err != nil {
+			return nil, err
+		}
+		fields := make(map[string]interface{})
+		for _, f := range def.Fields() {
+			v, err := gts.defaultForType(goFields[f.Name()].Type)
+			if err != nil {
+				return nil, err
+			}
+			fields[f.Name()] = v
+		}
+		return fields, nil
+	case *schema.EnumDefinition:
+		return def.Symbols()[0], nil
+	case *schema.FixedDefinition:
+		return strings.Repeat("\u0000", def.SizeBytes()), nil
+	default:
+		return nil, fmt.Errorf("unknown definition type %T", def)
+	}
+}
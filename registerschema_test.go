@@ -0,0 +1,142 @@
+package avro_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro"
+)
+
+// registeredPoint stands in for a type from another package
+// that can't be changed.
+type registeredPoint struct {
+	X, Y  float64
+	Label string
+}
+
+type registeredColor int
+
+func TestRegisterSchema(t *testing.T) {
+	c := qt.New(t)
+	avro.RegisterSchema(reflect.TypeOf(registeredPoint{}), `{
+		"type": "record",
+		"name": "geo.Point",
+		"fields": [
+			{"name": "X", "type": "float"},
+			{"name": "Y", "type": "float"}
+		]
+	}`)
+	avro.RegisterSchema(reflect.TypeOf(registeredColor(0)), `{
+		"type": "enum",
+		"name": "Color",
+		"symbols": ["red", "green", "blue"]
+	}`)
+
+	type R struct {
+		P     registeredPoint
+		Color registeredColor
+	}
+	at, err := avro.TypeOf(R{})
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(at.String(), qt.JSONEquals, json.RawMessage(`{
+		"type": "record",
+		"name": "R",
+		"fields": [{
+			"name": "P",
+			"default": {"X": 0, "Y": 0},
+			"type": {
+				"type": "record",
+				"name": "geo.Point",
+				"fields": [
+					{"name": "X", "type": "float"},
+					{"name": "Y", "type": "float"}
+				]
+			}
+		}, {
+			"name": "Color",
+			"default": "red",
+			"type": {
+				"type": "enum",
+				"name": "Color",
+				"symbols": ["red", "green", "blue"]
+			}
+		}]
+	}`))
+
+	data, wType, err := avro.Marshal(R{
+		P: registeredPoint{
+			X:     1.5,
+			Y:     -2,
+			Label: "ignored",
+		},
+		Color: 2,
+	})
+	c.Assert(err, qt.Equals, nil)
+	var x R
+	_, err = avro.Unmarshal(data, &x, wType)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(x, qt.DeepEquals, R{
+		P: registeredPoint{
+			X: 1.5,
+			Y: -2,
+		},
+		Color: 2,
+	})
+}
+
+func TestRegisterSchemaErrors(t *testing.T) {
+	c := qt.New(t)
+	type T struct {
+		A int
+	}
+	tests := []struct {
+		testName    string
+		goType      reflect.Type
+		schema      string
+		expectPanic string
+	}{{
+		testName:    "nil-type",
+		schema:      `"string"`,
+		expectPanic: `nil Go type registered for schema`,
+	}, {
+		testName:    "invalid-schema",
+		goType:      reflect.TypeOf(T{}),
+		schema:      `{"type": "record"}`,
+		expectPanic: `cannot register schema for avro_test.T: .*`,
+	}, {
+		testName:    "not-a-definition",
+		goType:      reflect.TypeOf(T{}),
+		schema:      `"string"`,
+		expectPanic: `cannot register schema for avro_test.T: schema is not a record, enum or fixed definition`,
+	}, {
+		testName:    "missing-field",
+		goType:      reflect.TypeOf(T{}),
+		schema:      `{"type": "record", "name": "T", "fields": [{"name": "B", "type": "long"}]}`,
+		expectPanic: `cannot register schema for avro_test.T: no struct field found for record field "B"`,
+	}, {
+		testName:    "record-for-non-struct",
+		goType:      reflect.TypeOf(""),
+		schema:      `{"type": "record", "name": "T", "fields": []}`,
+		expectPanic: `cannot register schema for string: record schema used for non-struct type`,
+	}, {
+		testName:    "enum-for-non-integer",
+		goType:      reflect.TypeOf(""),
+		schema:      `{"type": "enum", "name": "E", "symbols": ["a"]}`,
+		expectPanic: `cannot register schema for string: enum schema used for type that isn't a signed integer`,
+	}, {
+		testName:    "fixed-size-mismatch",
+		goType:      reflect.TypeOf([4]byte{}),
+		schema:      `{"type": "fixed", "name": "F", "size": 8}`,
+		expectPanic: `cannot register schema for \[4\]uint8: fixed schema of size 8 used for type that isn't \[8\]byte`,
+	}}
+	for _, test := range tests {
+		c.Run(test.testName, func(c *qt.C) {
+			c.Assert(func() {
+				avro.RegisterSchema(test.goType, test.schema)
+			}, qt.PanicMatches, test.expectPanic)
+		})
+	}
+}
@@ -0,0 +1,81 @@
+package avro
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/rogpeppe/gogen-avro/v7/parser"
+	"github.com/rogpeppe/gogen-avro/v7/schema"
+)
+
+// RenameNamespace returns a copy of t with all named types in the
+// namespace from, or in any namespace nested inside it, moved to the
+// namespace to. For example, renaming "com.example" to "org.other"
+// will rename "com.example.sub.T" to "org.other.sub.T".
+//
+// Aliases and references to renamed types are changed too.
+func RenameNamespace(t *Type, from, to string) (*Type, error) {
+	return RenameNames(t, func(name string) string {
+		switch {
+		case from == "":
+			if to == "" {
+				return name
+			}
+			return to + "." + name
+		case strings.HasPrefix(name, from+"."):
+			rest := strings.TrimPrefix(name, from+".")
+			if to == "" {
+				return rest
+			}
+			return to + "." + rest
+		}
+		return name
+	})
+}
+
+// RenameNames returns a copy of t with all named types renamed
+// by calling rename with the fully qualified name of each type
+// and each alias. The returned name must also be fully qualified.
+//
+// It returns an error if rename maps two different names defined in t
+// to the same name or returns an invalid name.
+func RenameNames(t *Type, rename func(name string) string) (*Type, error) {
+	var err error
+	// renamed holds the original name for every new name.
+	renamed := make(map[schema.QualifiedName]schema.QualifiedName)
+	renameName := func(name schema.QualifiedName) schema.QualifiedName {
+		newName := rename(name.String())
+		if newName == "" {
+			if err == nil {
+				err = fmt.Errorf("empty name returned when renaming %q", name)
+			}
+			return name
+		}
+		return parser.ParseAvroName("", newName)
+	}
+	v := renameSchema(t.avroType, "", make(map[schema.QualifiedName]bool), func(ref *schema.Reference) (schema.QualifiedName, []schema.QualifiedName) {
+		qname := renameName(ref.TypeName)
+		if oldName, ok := renamed[qname]; ok && oldName != ref.TypeName && err == nil {
+			err = fmt.Errorf("cannot rename both %q and %q to %q", oldName, ref.TypeName, qname)
+		}
+		renamed[qname] = ref.TypeName
+		var qaliases []schema.QualifiedName
+		for _, alias := range ref.Def.Aliases() {
+			qaliases = append(qaliases, renameName(alias))
+		}
+		return qname, qaliases
+	})
+	if err != nil {
+		return nil, err
+	}
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, fmt.Errorf("cannot marshal renamed schema: %v", err)
+	}
+	t1, err := ParseType(string(data))
+	if err != nil {
+		return nil, fmt.Errorf("cannot parse renamed schema: %v", err)
+	}
+	return t1, nil
+}
@@ -0,0 +1,69 @@
+package avro_test
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro"
+)
+
+func TestRenameNamespace(t *testing.T) {
+	c := qt.New(t)
+	t0 := mustParseType(`{
+	"type": "record",
+	"name": "com.example.R",
+	"aliases": ["com.example.OldR"],
+	"fields": [{
+		"name": "e",
+		"type": {"type": "enum", "name": "sub.E", "symbols": ["a"]}
+	}, {
+		"name": "f",
+		"type": {"type": "fixed", "name": "other.F", "size": 2}
+	}, {
+		"name": "r",
+		"type": ["null", "R"]
+	}]
+}`)
+	t1, err := avro.RenameNamespace(t0, "com.example", "org.other")
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(t1.Name(), qt.Equals, "org.other.R")
+	c.Assert(t1.CanonicalString(0), qt.Equals, `{"name":"org.other.R","type":"record","fields":[{"name":"e","type":{"name":"sub.E","type":"enum","symbols":["a"]}},{"name":"f","type":{"name":"other.F","type":"fixed","size":2}},{"name":"r","type":["null","org.other.R"]}]}`)
+	c.Assert(t1.String(), qt.JSONEquals, json.RawMessage(`{
+	"type": "record",
+	"name": "org.other.R",
+	"aliases": ["OldR"],
+	"fields": [{
+		"name": "e",
+		"type": {"type": "enum", "name": "sub.E", "symbols": ["a"]}
+	}, {
+		"name": "f",
+		"type": {"type": "fixed", "name": "other.F", "size": 2}
+	}, {
+		"name": "r",
+		"type": ["null", "R"]
+	}]
+}`))
+}
+
+func TestRenameNames(t *testing.T) {
+	c := qt.New(t)
+	t0 := mustParseType(`{
+	"type": "record",
+	"name": "R",
+	"fields": [{
+		"name": "a",
+		"type": {"type": "enum", "name": "E", "symbols": ["a"]}
+	}]
+}`)
+	t1, err := avro.RenameNames(t0, strings.ToLower)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(t1.CanonicalString(0), qt.Equals, `{"name":"r","type":"record","fields":[{"name":"a","type":{"name":"e","type":"enum","symbols":["a"]}}]}`)
+
+	_, err = avro.RenameNames(t0, func(string) string {
+		return "X"
+	})
+	c.Assert(err, qt.ErrorMatches, `cannot rename both "R" and "E" to "X"`)
+}
@@ -0,0 +1,324 @@
+package avro
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"github.com/rogpeppe/gogen-avro/v7/schema"
+)
+
+// ResolutionError is returned when data written with one Avro type
+// cannot be read with another. It holds an entry for every
+// incompatibility that was found, not just the first one.
+type ResolutionError struct {
+	Mismatches []Mismatch
+}
+
+// Error implements the error interface.
+func (e *ResolutionError) Error() string {
+	if len(e.Mismatches) == 1 {
+		return "incompatible schemas: " + e.Mismatches[0].String()
+	}
+	var buf strings.Builder
+	fmt.Fprintf(&buf, "incompatible schemas (%d problems):", len(e.Mismatches))
+	for _, m := range e.Mismatches {
+		buf.WriteString("\n\t")
+		buf.WriteString(m.String())
+	}
+	return buf.String()
+}
+
+// Mismatch describes a single place where a writer type
+// does not resolve to a reader type.
+type Mismatch struct {
+	// Path holds the location of the mismatch within the reader
+	// type. Record fields are separated by dots, and array items,
+	// map values and members of a writer union are written directly
+	// after their container as "[*]", "{*}" and "[uN]" respectively,
+	// where N is the index of the member. For example, the items of
+	// array field c in record R are at "R.c[*]".
+	Path string
+
+	// WriterPath holds the corresponding location within the
+	// writer type, in the same form as Path. It differs from Path
+	// when a record or field has been renamed using an alias,
+	// and it's empty when the writer type has no counterpart
+	// at Path.
+	WriterPath string
+
+	// Writer and Reader hold the schema fragments found at Path
+	// in the writer and reader types respectively. A fragment is
+	// empty when the type has no counterpart at that location.
+	Writer string
+	Reader string
+
+	// Message describes the problem.
+	Message string
+}
+
+// String returns a one-line description of the mismatch.
+func (m Mismatch) String() string {
+	var buf strings.Builder
+	if m.Path != "" {
+		buf.WriteString(m.Path)
+		buf.WriteString(": ")
+	}
+	buf.WriteString(m.Message)
+	switch {
+	case m.Writer != "" && m.Reader != "":
+		fmt.Fprintf(&buf, " (writer type %s; reader type %s)", m.Writer, m.Reader)
+	case m.Writer != "":
+		fmt.Fprintf(&buf, " (writer type %s)", m.Writer)
+	case m.Reader != "":
+		fmt.Fprintf(&buf, " (reader type %s)", m.Reader)
+	}
+	return buf.String()
+}
+
+// resolutionError returns a *ResolutionError describing all the
+// places where data written with wType cannot be read as rType,
+// or nil if there are none.
+func resolutionError(wType, rType *Type) error {
+	mismatches := resolutionMismatches(wType.avroType, rType.avroType)
+	if len(mismatches) == 0 {
+		return nil
+	}
+	return &ResolutionError{
+		Mismatches: mismatches,
+	}
+}
+
+// resolutionMismatches returns all the places where data written
+// with wType cannot be read as rType according to the rules described in
+// https://avro.apache.org/docs/1.9.1/spec.html#Schema+Resolution.
+func resolutionMismatches(wType, rType schema.AvroType) []Mismatch {
+	r := &resolutionChecker{
+		checked: make(map[[2]schema.QualifiedName]bool),
+	}
+	r.check("", "", wType, rType)
+	return r.mismatches
+}
+
+type resolutionChecker struct {
+	mismatches []Mismatch
+	// checked holds the (writer, reader) pairs of definitions
+	// that have already been checked, which guards against
+	// infinite recursion in recursive types.
+	checked map[[2]schema.QualifiedName]bool
+}
+
+func (r *resolutionChecker) addf(path, wpath string, wType, rType schema.AvroType, f string, a ...interface{}) {
+	r.mismatches = append(r.mismatches, Mismatch{
+		Path:       path,
+		WriterPath: wpath,
+		Writer:     schemaFragment(wType),
+		Reader:     schemaFragment(rType),
+		Message:    fmt.Sprintf(f, a...),
+	})
+}
+
+// check checks that wType at wpath in the writer type can be
+// read as rType at path in the reader type.
+func (r *resolutionChecker) check(path, wpath string, wType, rType schema.AvroType) {
+	if wType, ok := wType.(*schema.UnionField); ok {
+		// Every member of the writer union must be readable
+		// by the reader.
+		for i, wt := range wType.ItemTypes() {
+			r.check(fmt.Sprintf("%s[u%d]", path, i), fmt.Sprintf("%s[u%d]", wpath, i), wt, rType)
+		}
+		return
+	}
+	if rType, ok := rType.(*schema.UnionField); ok {
+		// The first member of the reader union that matches
+		// the writer type is used.
+		for _, rt := range rType.ItemTypes() {
+			if typesMatch(wType, rt) {
+				r.check(path, wpath, wType, rt)
+				return
+			}
+		}
+		r.addf(path, wpath, wType, rType, "no member of reader union matches writer type")
+		return
+	}
+	if !typesMatch(wType, rType) {
+		r.addf(path, wpath, wType, rType, "type mismatch")
+		return
+	}
+	switch wType := wType.(type) {
+	case *schema.ArrayField:
+		r.check(path+"[*]", wpath+"[*]", wType.ItemType(), rType.(*schema.ArrayField).ItemType())
+	case *schema.MapField:
+		r.check(path+"{*}", wpath+"{*}", wType.ItemType(), rType.(*schema.MapField).ItemType())
+	case *schema.Reference:
+		rType := rType.(*schema.Reference)
+		key := [2]schema.QualifiedName{wType.TypeName, rType.TypeName}
+		if r.checked[key] {
+			return
+		}
+		r.checked[key] = true
+		if path == "" {
+			path = rType.TypeName.Name
+		}
+		if wpath == "" {
+			wpath = wType.TypeName.Name
+		}
+		switch wDef := wType.Def.(type) {
+		case *schema.RecordDefinition:
+			r.checkRecord(path, wpath, wDef, rType.Def.(*schema.RecordDefinition))
+		case *schema.EnumDefinition:
+			r.checkEnum(path, wpath, wType, rType)
+		case *schema.FixedDefinition:
+			if wSize, rSize := wDef.SizeBytes(), rType.Def.(*schema.FixedDefinition).SizeBytes(); wSize != rSize {
+				r.addf(path, wpath, wType, rType, "fixed size mismatch (writer %d; reader %d)", wSize, rSize)
+			}
+		}
+	}
+}
+
+func (r *resolutionChecker) checkRecord(path, wpath string, wDef, rDef *schema.RecordDefinition) {
+	for _, rf := range rDef.Fields() {
+		fpath := path + "." + rf.Name()
+		wf := writerFieldFor(wDef, rf)
+		if wf == nil {
+			if !rf.HasDefault() {
+				r.addf(fpath, "", nil, rf.Type(), "field not present in writer and has no default value")
+			}
+			continue
+		}
+		r.check(fpath, wpath+"."+wf.Name(), wf.Type(), rf.Type())
+	}
+}
+
+func (r *resolutionChecker) checkEnum(path, wpath string, wType, rType *schema.Reference) {
+	wDef := wType.Def.(*schema.EnumDefinition)
+	rDef := rType.Def.(*schema.EnumDefinition)
+	if rDef.Attribute("default") != nil {
+		// Unknown symbols resolve to the reader's default.
+		return
+	}
+	rSyms := make(map[string]bool)
+	for _, sym := range rDef.Symbols() {
+		rSyms[sym] = true
+	}
+	var missing []string
+	for _, sym := range wDef.Symbols() {
+		if !rSyms[sym] {
+			missing = append(missing, sym)
+		}
+	}
+	if len(missing) > 0 {
+		r.addf(path, wpath, wType, rType, "writer symbols %s not present in reader enum", strings.Join(missing, ", "))
+	}
+}
+
+// writerFieldFor returns the field in the writer record that
+// corresponds to the reader field rf, or nil if there is none.
+func writerFieldFor(wDef *schema.RecordDefinition, rf *schema.Field) *schema.Field {
+	if wf := wDef.FieldByName(rf.Name()); wf != nil {
+		return wf
+	}
+	aliases, _ := copyOfSchemaObj(rf)["aliases"].([]interface{})
+	for _, alias := range aliases {
+		if alias, ok := alias.(string); ok {
+			if wf := wDef.FieldByName(alias); wf != nil {
+				return wf
+			}
+		}
+	}
+	return nil
+}
+
+// typesMatch reports whether the non-union types wType and rType
+// match without looking inside them, as defined by the
+// schema resolution rules.
+func typesMatch(wType, rType schema.AvroType) bool {
+	if _, ok := rType.(*schema.UnionField); ok {
+		return false
+	}
+	switch wType := wType.(type) {
+	case *schema.ArrayField:
+		_, ok := rType.(*schema.ArrayField)
+		return ok
+	case *schema.MapField:
+		_, ok := rType.(*schema.MapField)
+		return ok
+	case *schema.Reference:
+		rType, ok := rType.(*schema.Reference)
+		if !ok || !namesMatch(wType, rType) {
+			return false
+		}
+		switch rType.Def.(type) {
+		case *schema.RecordDefinition:
+			_, ok = wType.Def.(*schema.RecordDefinition)
+		case *schema.EnumDefinition:
+			_, ok = wType.Def.(*schema.EnumDefinition)
+		case *schema.FixedDefinition:
+			_, ok = wType.Def.(*schema.FixedDefinition)
+		default:
+			ok = false
+		}
+		return ok
+	}
+	wName, rName := primitiveName(wType), primitiveName(rType)
+	if wName == "" || rName == "" {
+		return false
+	}
+	return wName == rName || promotions[wName][rName]
+}
+
+// namesMatch reports whether the writer definition name
+// matches the reader definition name or one of its aliases.
+// As in the specification, only the unqualified names are
+// compared, so a change of namespace doesn't prevent a match.
+func namesMatch(wType, rType *schema.Reference) bool {
+	if wType.TypeName.Name == rType.TypeName.Name {
+		return true
+	}
+	for _, alias := range rType.Def.Aliases() {
+		if alias.Name == wType.TypeName.Name {
+			return true
+		}
+	}
+	return false
+}
+
+// promotions holds the primitive type promotions allowed
+// by the specification, indexed by writer type, then reader type.
+var promotions = map[string]map[string]bool{
+	"int":    {"long": true, "float": true, "double": true},
+	"long":   {"float": true, "double": true},
+	"float":  {"double": true},
+	"string": {"bytes": true},
+	"bytes":  {"string": true},
+}
+
+// primitiveName returns the name of the given primitive
+// Avro type, or the empty string if it's not primitive.
+func primitiveName(at schema.AvroType) string {
+	if k := kindOf(at); k.isPrimitive() {
+		return k.String()
+	}
+	return ""
+}
+
+// schemaFragment returns a compact representation of at
+// suitable for including in error messages. Definitions are
+// represented by their name only.
+func schemaFragment(at schema.AvroType) string {
+	if at == nil {
+		return ""
+	}
+	if ref, ok := at.(*schema.Reference); ok {
+		return ref.TypeName.String()
+	}
+	c := &canonicalizer{
+		defined: make(map[schema.QualifiedName]bool),
+		opts:    RetainLogicalTypes,
+	}
+	data, err := json.Marshal(c.canonicalValue(at))
+	if err != nil {
+		return fmt.Sprintf("<%T>", at)
+	}
+	return string(data)
+}
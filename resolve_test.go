@@ -0,0 +1,152 @@
+package avro_test
+
+import (
+	"errors"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro"
+	"github.com/heetch/avro/internal/testtypes"
+)
+
+func TestResolutionErrorReportsAllMismatches(t *testing.T) {
+	c := qt.New(t)
+	type R struct {
+		A int      `json:"a"`
+		B string   `json:"b"`
+		C []int    `json:"c"`
+		D []string `json:"d"`
+	}
+	wType := mustParseType(`{
+	"name": "R",
+	"type": "record",
+	"fields": [{
+		"name": "a",
+		"type": "string"
+	}, {
+		"name": "b",
+		"type": "boolean"
+	}, {
+		"name": "c",
+		"type": {"type": "array", "items": "string"}
+	}, {
+		"name": "d",
+		"type": {"type": "array", "items": "string"}
+	}]
+}`)
+	_, err := avro.Unmarshal(nil, new(R), wType)
+	var rerr *avro.ResolutionError
+	c.Assert(errors.As(err, &rerr), qt.Equals, true)
+	c.Assert(rerr.Mismatches, qt.DeepEquals, []avro.Mismatch{{
+		Path:       "R.a",
+		WriterPath: "R.a",
+		Writer:     `"string"`,
+		Reader:     `"long"`,
+		Message:    "type mismatch",
+	}, {
+		Path:       "R.b",
+		WriterPath: "R.b",
+		Writer:     `"boolean"`,
+		Reader:     `"string"`,
+		Message:    "type mismatch",
+	}, {
+		Path:       "R.c[*]",
+		WriterPath: "R.c[*]",
+		Writer:     `"string"`,
+		Reader:     `"long"`,
+		Message:    "type mismatch",
+	}})
+	c.Assert(err, qt.ErrorMatches, `cannot create decoder: incompatible schemas \(3 problems\):
+	R.a: type mismatch \(writer type "string"; reader type "long"\)
+	R.b: type mismatch \(writer type "boolean"; reader type "string"\)
+	R.c\[\*\]: type mismatch \(writer type "string"; reader type "long"\)`)
+}
+
+func TestResolutionErrorUnionAndEnum(t *testing.T) {
+	c := qt.New(t)
+	type R struct {
+		A *int           `json:"a"`
+		E testtypes.Enum `json:"e"`
+		B string         `json:"b"`
+	}
+	wType := mustParseType(`{
+	"name": "R",
+	"type": "record",
+	"fields": [{
+		"name": "a",
+		"type": ["null", "string"]
+	}, {
+		"name": "e",
+		"type": {
+			"type": "enum",
+			"name": "Enum",
+			"symbols": ["One", "Two", "Seventeen"]
+		}
+	}, {
+		"name": "b",
+		"type": "boolean"
+	}]
+}`)
+	_, err := avro.Unmarshal(nil, new(R), wType)
+	var rerr *avro.ResolutionError
+	c.Assert(errors.As(err, &rerr), qt.Equals, true)
+	c.Assert(rerr.Mismatches, qt.DeepEquals, []avro.Mismatch{{
+		Path:       "R.a[u1]",
+		WriterPath: "R.a[u1]",
+		Writer:     `"string"`,
+		Reader:     `["null","long"]`,
+		Message:    "no member of reader union matches writer type",
+	}, {
+		Path:       "R.e",
+		WriterPath: "R.e",
+		Writer:     "Enum",
+		Reader:     "Enum",
+		Message:    "writer symbols Seventeen not present in reader enum",
+	}, {
+		Path:       "R.b",
+		WriterPath: "R.b",
+		Writer:     `"boolean"`,
+		Reader:     `"string"`,
+		Message:    "type mismatch",
+	}})
+}
+
+func TestResolutionErrorWriterPath(t *testing.T) {
+	c := qt.New(t)
+	wType := mustParseType(`{
+	"name": "R",
+	"type": "record",
+	"fields": [{
+		"name": "old",
+		"type": {"type": "array", "items": "string"}
+	}]
+}`)
+	rType := mustParseType(`{
+	"name": "S",
+	"type": "record",
+	"aliases": ["R"],
+	"fields": [{
+		"name": "new",
+		"aliases": ["old"],
+		"type": {"type": "array", "items": "int"}
+	}, {
+		"name": "other",
+		"type": "int"
+	}]
+}`)
+	err := avro.Backward.Check(rType, wType)
+	var rerr *avro.ResolutionError
+	c.Assert(errors.As(err, &rerr), qt.Equals, true)
+	c.Assert(rerr.Mismatches, qt.DeepEquals, []avro.Mismatch{{
+		Path:       "S.new[*]",
+		WriterPath: "R.old[*]",
+		Writer:     `"string"`,
+		Reader:     `"int"`,
+		Message:    "type mismatch",
+	}, {
+		Path:    "S.other",
+		Reader:  `"int"`,
+		Message: "field not present in writer and has no default value",
+	}})
+}
@@ -0,0 +1,345 @@
+package avro
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// SchemaError is returned by ParseType when a schema is
+// not valid.
+type SchemaError struct {
+	// Path holds a JSON pointer (RFC 6901) to the offending
+	// element of the schema. It's empty for JSON syntax errors
+	// and when the location of the problem isn't known.
+	Path string
+
+	// Line and Column hold the position of the offending element
+	// in the schema text. Both start at 1. They're zero when
+	// the location of the problem isn't known.
+	Line   int
+	Column int
+
+	// Message describes the problem.
+	Message string
+}
+
+// Error implements the error interface.
+func (e *SchemaError) Error() string {
+	if e.Line == 0 {
+		return e.Message
+	}
+	if e.Path == "" {
+		return fmt.Sprintf("invalid schema at line %d, column %d: %s", e.Line, e.Column, e.Message)
+	}
+	return fmt.Sprintf("invalid schema at line %d, column %d (%s): %s", e.Line, e.Column, e.Path, e.Message)
+}
+
+// checkSchemaJSON checks the schema in data for common problems,
+// so that they can be reported with their location. It's not a
+// complete check - the schema parser checks everything else.
+func checkSchemaJSON(data []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(data, &v); err != nil {
+		if err, ok := err.(*json.SyntaxError); ok {
+			// The offset is just after the offending character.
+			off := err.Offset
+			if off > 0 {
+				off--
+			}
+			line, col := lineAndColumn(data, off)
+			return &SchemaError{
+				Line:    line,
+				Column:  col,
+				Message: err.Error(),
+			}
+		}
+		return err
+	}
+	c := &schemaChecker{
+		defined: make(map[string]bool),
+	}
+	c.collectNames(v, "")
+	if path, msg := c.check(v, "", ""); msg != "" {
+		line, col := lineAndColumn(data, valueOffset(data, path))
+		return &SchemaError{
+			Path:    path,
+			Line:    line,
+			Column:  col,
+			Message: msg,
+		}
+	}
+	return nil
+}
+
+type schemaChecker struct {
+	// defined holds the full names of all the definitions
+	// in the schema.
+	defined map[string]bool
+}
+
+// collectNames adds the names of all the definitions in v
+// to c.defined.
+func (c *schemaChecker) collectNames(v interface{}, namespace string) {
+	switch v := v.(type) {
+	case []interface{}:
+		for _, item := range v {
+			c.collectNames(item, namespace)
+		}
+	case map[string]interface{}:
+		switch v["type"] {
+		case "record", "error", "enum", "fixed":
+			name, ok := v["name"].(string)
+			if !ok {
+				return
+			}
+			fullName, ns := fullNameOf(v, name, namespace)
+			c.defined[fullName] = true
+			if fields, ok := v["fields"].([]interface{}); ok {
+				for _, f := range fields {
+					if f, ok := f.(map[string]interface{}); ok {
+						c.collectNames(f["type"], ns)
+					}
+				}
+			}
+		case "array":
+			c.collectNames(v["items"], namespace)
+		case "map":
+			c.collectNames(v["values"], namespace)
+		default:
+			c.collectNames(v["type"], namespace)
+		}
+	}
+}
+
+// check checks the schema value v found at the given JSON
+// pointer path. If there's a problem, it returns the path to the
+// problem and a description of it.
+func (c *schemaChecker) check(v interface{}, path, namespace string) (string, string) {
+	switch v := v.(type) {
+	case string:
+		if builtinTypes[v] {
+			return "", ""
+		}
+		fullName := v
+		if !strings.Contains(v, ".") && namespace != "" {
+			fullName = namespace + "." + v
+		}
+		if !c.defined[fullName] && !c.defined[v] {
+			return path, fmt.Sprintf("unknown type %q", v)
+		}
+		return "", ""
+	case []interface{}:
+		for i, item := range v {
+			ipath := fmt.Sprintf("%s/%d", path, i)
+			if _, ok := item.([]interface{}); ok {
+				return ipath, "union contains another union"
+			}
+			if p, msg := c.check(item, ipath, namespace); msg != "" {
+				return p, msg
+			}
+		}
+		return "", ""
+	case map[string]interface{}:
+		return c.checkObject(v, path, namespace)
+	}
+	return path, fmt.Sprintf("invalid schema value %s", jsonString(v))
+}
+
+func (c *schemaChecker) checkObject(v map[string]interface{}, path, namespace string) (string, string) {
+	t, ok := v["type"]
+	if !ok {
+		return path, `missing "type" attribute`
+	}
+	switch t {
+	case "record", "error", "enum", "fixed":
+		name, ok := v["name"].(string)
+		if !ok {
+			return attrPath(v, path, "name"), fmt.Sprintf("%s must have a string name", t)
+		}
+		_, namespace = fullNameOf(v, name, namespace)
+	}
+	switch t {
+	case "record", "error":
+		fields, ok := v["fields"].([]interface{})
+		if !ok {
+			return attrPath(v, path, "fields"), "record must have a fields array"
+		}
+		for i, f := range fields {
+			fpath := fmt.Sprintf("%s/fields/%d", path, i)
+			f, ok := f.(map[string]interface{})
+			if !ok {
+				return fpath, "record field must be an object"
+			}
+			if _, ok := f["name"].(string); !ok {
+				return attrPath(f, fpath, "name"), "record field must have a string name"
+			}
+			ftype, ok := f["type"]
+			if !ok {
+				return fpath, `missing "type" attribute`
+			}
+			if p, msg := c.check(ftype, fpath+"/type", namespace); msg != "" {
+				return p, msg
+			}
+		}
+	case "enum":
+		syms, ok := v["symbols"].([]interface{})
+		if !ok {
+			return attrPath(v, path, "symbols"), "enum must have a symbols array"
+		}
+		for i, sym := range syms {
+			if _, ok := sym.(string); !ok {
+				return fmt.Sprintf("%s/symbols/%d", path, i), "enum symbol must be a string"
+			}
+		}
+	case "fixed":
+		if _, ok := v["size"].(float64); !ok {
+			return attrPath(v, path, "size"), "fixed must have a numeric size"
+		}
+	case "array":
+		items, ok := v["items"]
+		if !ok {
+			return path, `missing "items" attribute`
+		}
+		return c.check(items, path+"/items", namespace)
+	case "map":
+		values, ok := v["values"]
+		if !ok {
+			return path, `missing "values" attribute`
+		}
+		return c.check(values, path+"/values", namespace)
+	default:
+		return c.check(t, path+"/type", namespace)
+	}
+	return "", ""
+}
+
+// attrPath returns the path to the given attribute of the object v
+// at path, or the path of the object itself if the attribute
+// isn't present.
+func attrPath(v map[string]interface{}, path, attr string) string {
+	if _, ok := v[attr]; ok {
+		return path + "/" + attr
+	}
+	return path
+}
+
+// fullNameOf returns the full name of the definition v with
+// the given name inside the given enclosing namespace, and the
+// namespace that applies inside v.
+func fullNameOf(v map[string]interface{}, name, namespace string) (fullName, ns string) {
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		return name, name[:i]
+	}
+	if ns, ok := v["namespace"].(string); ok {
+		namespace = ns
+	}
+	if namespace == "" {
+		return name, ""
+	}
+	return namespace + "." + name, namespace
+}
+
+func jsonString(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Sprint(v)
+	}
+	return string(data)
+}
+
+// lineAndColumn returns the line and column of the
+// given byte offset within data.
+func lineAndColumn(data []byte, offset int64) (line, col int) {
+	if offset > int64(len(data)) {
+		offset = int64(len(data))
+	}
+	before := data[:offset]
+	line = bytes.Count(before, []byte("\n")) + 1
+	lineStart := bytes.LastIndexByte(before, '\n') + 1
+	return line, utf8.RuneCount(before[lineStart:]) + 1
+}
+
+// valueOffset returns the byte offset within data of the JSON value
+// at the given JSON pointer path, or zero if it's not found.
+func valueOffset(data []byte, path string) int64 {
+	w := &offsetWalker{
+		data:   data,
+		dec:    json.NewDecoder(bytes.NewReader(data)),
+		target: path,
+	}
+	if err := w.walk(""); err != nil {
+		return 0
+	}
+	return w.found
+}
+
+type offsetWalker struct {
+	data   []byte
+	dec    *json.Decoder
+	target string
+	found  int64
+	done   bool
+}
+
+// walk reads the next JSON value, which is at the given path.
+func (w *offsetWalker) walk(path string) error {
+	if path == w.target {
+		w.found = w.nextValueOffset()
+		w.done = true
+		return nil
+	}
+	tok, err := w.dec.Token()
+	if err != nil {
+		return err
+	}
+	switch tok {
+	case json.Delim('{'):
+		for !w.done && w.dec.More() {
+			key, err := w.dec.Token()
+			if err != nil {
+				return err
+			}
+			if err := w.walk(path + "/" + escapePointer(key.(string))); err != nil {
+				return err
+			}
+		}
+	case json.Delim('['):
+		for i := 0; !w.done && w.dec.More(); i++ {
+			if err := w.walk(fmt.Sprintf("%s/%d", path, i)); err != nil {
+				return err
+			}
+		}
+	default:
+		return nil
+	}
+	if w.done {
+		return nil
+	}
+	// Read the closing delimiter.
+	_, err = w.dec.Token()
+	return err
+}
+
+// nextValueOffset returns the offset of the start of the
+// next value to be read by the decoder.
+func (w *offsetWalker) nextValueOffset() int64 {
+	off := w.dec.InputOffset()
+	for ; off < int64(len(w.data)); off++ {
+		switch w.data[off] {
+		case ' ', '\t', '\r', '\n', ':', ',':
+		default:
+			return off
+		}
+	}
+	return off
+}
+
+var pointerEscaper = strings.NewReplacer("~", "~0", "/", "~1")
+
+// escapePointer escapes s for use as a JSON pointer component.
+func escapePointer(s string) string {
+	return pointerEscaper.Replace(s)
+}
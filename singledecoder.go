@@ -80,6 +80,13 @@ func NewSingleDecoder(r DecodingRegistry, names *Names) *SingleDecoder {
 //
 // Unmarshal returns the actual type that was decoded into.
 func (c *SingleDecoder) Unmarshal(ctx context.Context, data []byte, x interface{}) (*Type, error) {
+	return c.UnmarshalArena(ctx, data, x, nil)
+}
+
+// UnmarshalArena is like Unmarshal except that strings and byte
+// slices in the result are allocated from the given arena.
+// If a is nil, no arena is used.
+func (c *SingleDecoder) UnmarshalArena(ctx context.Context, data []byte, x interface{}, a *Arena) (*Type, error) {
 	v := reflect.ValueOf(x)
 	if v.Kind() != reflect.Ptr {
 		return nil, fmt.Errorf("cannot decode into non-pointer value %T", x)
@@ -92,9 +99,9 @@ func (c *SingleDecoder) Unmarshal(ctx context.Context, data []byte, x interface{
 	}
 	prog, err := c.getProgram(ctx, vt, wID)
 	if err != nil {
-		return nil, fmt.Errorf("cannot unmarshal: %v", err)
+		return nil, fmt.Errorf("cannot unmarshal: %w", err)
 	}
-	return unmarshal(nil, body, prog, v)
+	return unmarshal(nil, body, prog, v, a)
 }
 
 func (c *SingleDecoder) getProgram(ctx context.Context, vt reflect.Type, wID int64) (*decodeProgram, error) {
@@ -83,7 +83,7 @@ func TestSingleDecoder(t *testing.T) {
 	// There's no default value for A, so it doesn't work that way around.
 	var x2 TestRecord
 	_, err = dec.Unmarshal(context.Background(), []byte{3, 80}, &x2)
-	c.Assert(err, qt.ErrorMatches, `cannot unmarshal: cannot create decoder: Incompatible schemas: field B in reader is not present in writer and has no default value`)
+	c.Assert(err, qt.ErrorMatches, `cannot unmarshal: cannot create decoder: incompatible schemas: TestRecord.B: field not present in writer and has no default value \(reader type "int"\)`)
 }
 
 // memRegistry implements DecodingRegistry and EncodingRegistry by associating a single-byte
@@ -0,0 +1,73 @@
+package avro
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"fmt"
+	"reflect"
+)
+
+// SQLValue wraps a Go value so that it can be stored in a database
+// column, such as a Postgres bytea column, as Avro binary-encoded data.
+// It implements driver.Valuer, so it can be used as a query argument,
+// and sql.Scanner, so it can be used as a destination for Scan.
+//
+// For example:
+//
+//	_, err := db.Exec("INSERT INTO orders (id, data) VALUES ($1, $2)", id, avro.SQLValue{V: order, Type: orderType})
+//	...
+//	var order Order
+//	err := db.QueryRow("SELECT data FROM orders WHERE id = $1", id).Scan(&avro.SQLValue{V: &order, Type: orderType})
+type SQLValue struct {
+	// V holds the value to encode. When scanning, it
+	// must be a pointer to the value to decode into.
+	// A nil pointer is stored as NULL, and NULL
+	// is scanned as the zero value.
+	V interface{}
+
+	// Type holds the Avro type of the data in the column.
+	// When scanning, data is decoded with Type as the writer's
+	// schema, so the Go type of V only needs to be compatible
+	// with it. When storing, the schema of V must be the same
+	// as Type, so that the data can be read back.
+	// If Type is nil, the schema of V is used in both cases.
+	Type *Type
+}
+
+var (
+	_ driver.Valuer = SQLValue{}
+	_ sql.Scanner   = (*SQLValue)(nil)
+)
+
+// Value implements driver.Valuer.Value by
+// returning v.V encoded as Avro binary data.
+func (v SQLValue) Value() (driver.Value, error) {
+	xv := reflect.ValueOf(v.V)
+	if !xv.IsValid() || xv.Kind() == reflect.Ptr && xv.IsNil() {
+		return nil, nil
+	}
+	data, err := marshalWithType(reflect.Indirect(xv), v.Type)
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
+
+// Scan implements sql.Scanner.Scan by decoding the
+// Avro binary data in src into v.V.
+func (v *SQLValue) Scan(src interface{}) error {
+	switch src := src.(type) {
+	case nil:
+		xv := reflect.ValueOf(v.V)
+		if xv.Kind() != reflect.Ptr || xv.IsNil() {
+			return fmt.Errorf("cannot decode into non-pointer value %T", v.V)
+		}
+		xv.Elem().Set(reflect.Zero(xv.Type().Elem()))
+		return nil
+	case []byte:
+		return unmarshalWithType(src, v.V, v.Type)
+	case string:
+		return unmarshalWithType([]byte(src), v.V, v.Type)
+	}
+	return fmt.Errorf("cannot scan %T into Avro value", src)
+}
@@ -0,0 +1,68 @@
+package avro_test
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro"
+)
+
+type sqlRecord struct {
+	A int
+	B string
+}
+
+func TestSQLValueRoundTrip(t *testing.T) {
+	c := qt.New(t)
+	x := sqlRecord{A: 20, B: "x"}
+	data, err := avro.SQLValue{V: x}.Value()
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(data, qt.DeepEquals, []byte{40, 2, 'x'})
+
+	var x1 sqlRecord
+	err = (&avro.SQLValue{V: &x1}).Scan(data)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(x1, qt.Equals, x)
+}
+
+func TestSQLValueWithType(t *testing.T) {
+	c := qt.New(t)
+	colType := mustTypeOf(sqlRecord{})
+	data, err := avro.SQLValue{V: &sqlRecord{A: 20, B: "x"}, Type: colType}.Value()
+	c.Assert(err, qt.Equals, nil)
+
+	// Decode into a type that only has some of the fields.
+	type sqlRecord struct {
+		B string
+	}
+	var x1 sqlRecord
+	err = (&avro.SQLValue{V: &x1, Type: colType}).Scan(data)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(x1, qt.Equals, sqlRecord{B: "x"})
+
+	_, err = avro.SQLValue{V: x1, Type: colType}.Value()
+	c.Assert(err, qt.ErrorMatches, `cannot encode avro_test.sqlRecord: its schema does not match the expected type`)
+}
+
+func TestSQLValueNull(t *testing.T) {
+	c := qt.New(t)
+	data, err := avro.SQLValue{V: (*sqlRecord)(nil)}.Value()
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(data, qt.IsNil)
+
+	x := sqlRecord{A: 1}
+	err = (&avro.SQLValue{V: &x}).Scan(nil)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(x, qt.Equals, sqlRecord{})
+}
+
+func TestSQLValueScanError(t *testing.T) {
+	c := qt.New(t)
+	err := (&avro.SQLValue{V: sqlRecord{}}).Scan([]byte{})
+	c.Assert(err, qt.ErrorMatches, `cannot decode into non-pointer value avro_test.sqlRecord`)
+
+	var x sqlRecord
+	err = (&avro.SQLValue{V: &x}).Scan(123)
+	c.Assert(err, qt.ErrorMatches, `cannot scan int into Avro value`)
+}
@@ -0,0 +1,196 @@
+package avro
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"sync"
+
+	"github.com/rogpeppe/gogen-avro/v7/schema"
+)
+
+// runtimeTypes holds the Avro type for each Go type
+// created by StructOf. It's effectively a map[reflect.Type]*Type.
+var runtimeTypes sync.Map
+
+// StructOf returns a Go struct type, created with reflect.StructOf,
+// that can be used to encode and decode values of the Avro record
+// type t. This makes it possible to decode data with schemas that are
+// only known at runtime into typed values without generating code.
+//
+// TypeOf applied to a value of the returned type returns t.
+//
+// Each record field is represented by an exported Go field with a "json"
+// tag holding the Avro field name. Avro types are represented as
+// follows:
+//
+//   - null: Null
+//   - boolean: bool
+//   - int: int32, or time.Time with a date logical type
+//   - long: int64, or time.Time with a timestamp or local-timestamp logical type
+//   - float: float32
+//   - double: float64
+//   - bytes: []byte
+//   - string: string
+//   - record: a struct created in the same way
+//   - enum: int, holding the index of the symbol
+//   - fixed: [N]byte, or Duration with a duration logical type
+//   - array: []T
+//   - map: map[string]T
+//   - a union of null and T: *T
+//
+// A primitive type with a logical type for which a converter has been
+// registered with RegisterLogicalType is represented by the converter's
+// Go type instead.
+//
+// Other unions and recursive types are not supported.
+//
+// Note that when decoding, fields not present in the writer's data
+// are set to their zero Go value, not to the default value in the
+// schema.
+func StructOf(t *Type) (reflect.Type, error) {
+	ref, ok := t.avroType.(*schema.Reference)
+	if !ok {
+		return nil, fmt.Errorf("cannot make struct type from non-record type %s", t)
+	}
+	if _, ok := ref.Def.(*schema.RecordDefinition); !ok {
+		return nil, fmt.Errorf("cannot make struct type from non-record type %s", t)
+	}
+	b := &structBuilder{
+		inProgress: make(map[schema.QualifiedName]bool),
+	}
+	return b.recordType(ref, t)
+}
+
+type structBuilder struct {
+	// inProgress holds the names of the records that are
+	// currently being built, so we can detect recursive types.
+	inProgress map[schema.QualifiedName]bool
+}
+
+func (b *structBuilder) goType(at schema.AvroType) (reflect.Type, error) {
+	if t := registeredGoType(at); t != nil {
+		return t, nil
+	}
+	switch at := at.(type) {
+	case *schema.NullField:
+		return nullType, nil
+	case *schema.BoolField:
+		return reflect.TypeOf(false), nil
+	case *schema.IntField:
+		if logicalType(at) == "date" {
+			return timeType, nil
+		}
+		return reflect.TypeOf(int32(0)), nil
+	case *schema.LongField:
+		switch logicalType(at) {
+		case timestampMillis, timestampMicros, localTimestampMillis, localTimestampMicros:
+			return timeType, nil
+		}
+		return reflect.TypeOf(int64(0)), nil
+	case *schema.FloatField:
+		return reflect.TypeOf(float32(0)), nil
+	case *schema.DoubleField:
+		return reflect.TypeOf(float64(0)), nil
+	case *schema.BytesField:
+		return reflect.TypeOf([]byte(nil)), nil
+	case *schema.StringField:
+		return reflect.TypeOf(""), nil
+	case *schema.ArrayField:
+		elem, err := b.goType(at.ItemType())
+		if err != nil {
+			return nil, err
+		}
+		return reflect.SliceOf(elem), nil
+	case *schema.MapField:
+		elem, err := b.goType(at.ItemType())
+		if err != nil {
+			return nil, err
+		}
+		return reflect.MapOf(reflect.TypeOf(""), elem), nil
+	case *schema.UnionField:
+		items := at.ItemTypes()
+		if len(items) != 2 {
+			return nil, fmt.Errorf("unsupported union type %s", schemaFragment(at))
+		}
+		if _, ok := items[0].(*schema.NullField); !ok {
+			return nil, fmt.Errorf("unsupported union type %s (null must be the first member)", schemaFragment(at))
+		}
+		elem, err := b.goType(items[1])
+		if err != nil {
+			return nil, err
+		}
+		return reflect.PtrTo(elem), nil
+	case *schema.Reference:
+		switch def := at.Def.(type) {
+		case *schema.EnumDefinition:
+			return reflect.TypeOf(0), nil
+		case *schema.FixedDefinition:
+			if logicalType(at) == "duration" && def.SizeBytes() == 12 {
+				return durationType, nil
+			}
+			return reflect.ArrayOf(def.SizeBytes(), byteType), nil
+		case *schema.RecordDefinition:
+			return b.recordType(at, nil)
+		default:
+			return nil, fmt.Errorf("unknown definition type %T", def)
+		}
+	default:
+		return nil, fmt.Errorf("unknown Avro type %T", at)
+	}
+}
+
+// recordType returns the struct type for the given record.
+// If t is non-nil, it holds the Avro type for the record; otherwise
+// it will be derived from ref.
+func (b *structBuilder) recordType(ref *schema.Reference, t *Type) (reflect.Type, error) {
+	if b.inProgress[ref.TypeName] {
+		return nil, fmt.Errorf("recursive type %s not supported", ref.TypeName)
+	}
+	b.inProgress[ref.TypeName] = true
+	defer delete(b.inProgress, ref.TypeName)
+
+	def := ref.Def.(*schema.RecordDefinition)
+	fields := make([]reflect.StructField, len(def.Fields()))
+	goNames := make(map[string]bool)
+	for i, f := range def.Fields() {
+		ftype, err := b.goType(f.Type())
+		if err != nil {
+			return nil, fmt.Errorf("cannot make type for field %q of %s: %v", f.Name(), ref.TypeName, err)
+		}
+		name := goFieldName(f.Name())
+		if goNames[name] {
+			return nil, fmt.Errorf("field %q of %s has the same Go name as another field", f.Name(), ref.TypeName)
+		}
+		goNames[name] = true
+		fields[i] = reflect.StructField{
+			Name: name,
+			Type: ftype,
+			Tag:  reflect.StructTag(fmt.Sprintf(`json:%q`, f.Name())),
+		}
+	}
+	st := reflect.StructOf(fields)
+	if t == nil {
+		var err error
+		t, err = standaloneType(ref)
+		if err != nil {
+			return nil, err
+		}
+	}
+	// Struct types with identical fields are identical, so
+	// make sure that we're not associating a type with two
+	// different schemas.
+	if t0, loaded := runtimeTypes.LoadOrStore(st, t); loaded && !Equal(t0.(*Type), t) {
+		return nil, fmt.Errorf("struct type for %s is already in use for a different schema %s", ref.TypeName, t0)
+	}
+	return st, nil
+}
+
+// goFieldName returns an exported Go field name
+// for the given Avro field name.
+func goFieldName(name string) string {
+	if name[0] == '_' {
+		return "F" + name
+	}
+	return strings.ToUpper(name[:1]) + name[1:]
+}
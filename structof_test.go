@@ -0,0 +1,102 @@
+package avro_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro"
+)
+
+func TestStructOf(t *testing.T) {
+	c := qt.New(t)
+	at := mustParseType(`{
+	"type": "record",
+	"name": "com.example.R",
+	"fields": [
+		{"name": "a", "type": "int"},
+		{"name": "b", "type": ["null", "string"]},
+		{"name": "c", "type": {"type": "array", "items": "long"}},
+		{"name": "d", "type": {"type": "map", "values": "double"}},
+		{"name": "e", "type": {"type": "enum", "name": "E", "symbols": ["x", "y"]}},
+		{"name": "f", "type": {"type": "fixed", "name": "F", "size": 2}},
+		{"name": "_g", "type": {
+			"type": "record",
+			"name": "S",
+			"fields": [{"name": "e", "type": "E"}]
+		}}
+	]
+}`)
+	st, err := avro.StructOf(at)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(st.Kind(), qt.Equals, reflect.Struct)
+	c.Assert(st.NumField(), qt.Equals, 7)
+	c.Assert(st.Field(6).Name, qt.Equals, "F_g")
+	c.Assert(st.Field(6).Tag.Get("json"), qt.Equals, "_g")
+
+	v := reflect.New(st)
+	err = json.Unmarshal([]byte(`{
+		"a": 1,
+		"b": "hello",
+		"c": [2, 3],
+		"d": {"k": 1.5},
+		"e": 1,
+		"f": [5, 6],
+		"_g": {"e": 1}
+	}`), v.Interface())
+	c.Assert(err, qt.Equals, nil)
+
+	data, wType, err := avro.Marshal(v.Elem().Interface())
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(avro.Equal(wType, at), qt.Equals, true)
+
+	v1 := reflect.New(st)
+	_, err = avro.Unmarshal(data, v1.Interface(), at)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(v1.Elem().Interface(), qt.DeepEquals, v.Elem().Interface())
+
+	// Creating the type again returns the same Go type.
+	st1, err := avro.StructOf(mustParseType(at.String()))
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(st1, qt.Equals, st)
+}
+
+var structOfErrorTests = []struct {
+	testName    string
+	schema      string
+	expectError string
+}{{
+	testName:    "not-record",
+	schema:      `"string"`,
+	expectError: `cannot make struct type from non-record type "string"`,
+}, {
+	testName:    "recursive",
+	schema:      `{"type": "record", "name": "R", "fields": [{"name": "r", "type": ["null", "R"]}]}`,
+	expectError: `cannot make type for field "r" of R: recursive type R not supported`,
+}, {
+	testName:    "union",
+	schema:      `{"type": "record", "name": "R", "fields": [{"name": "u", "type": ["int", "string"]}]}`,
+	expectError: `cannot make type for field "u" of R: unsupported union type \["int","string"\] \(null must be the first member\)`,
+}, {
+	testName:    "duplicate-go-name",
+	schema:      `{"type": "record", "name": "R", "fields": [{"name": "a", "type": "int"}, {"name": "A", "type": "int"}]}`,
+	expectError: `field "A" of R has the same Go name as another field`,
+}, {
+	testName:    "same-struct-different-schema",
+	schema:      `{"type": "record", "name": "Other", "fields": [{"name": "a", "type": "int"}]}`,
+	expectError: `struct type for Other is already in use for a different schema .*`,
+}}
+
+func TestStructOfError(t *testing.T) {
+	c := qt.New(t)
+	_, err := avro.StructOf(mustParseType(`{"type": "record", "name": "Some", "fields": [{"name": "a", "type": "int"}]}`))
+	c.Assert(err, qt.Equals, nil)
+	for _, test := range structOfErrorTests {
+		c.Run(test.testName, func(c *qt.C) {
+			_, err := avro.StructOf(mustParseType(test.schema))
+			c.Assert(err, qt.ErrorMatches, test.expectError)
+		})
+	}
+}
@@ -1,4 +1,7 @@
 // Code generated by avrogen. DO NOT EDIT.
+//
+// Schema fingerprints:
+//	TestRecord 0xf41eefcfa860b753
 
 package avro_test
 
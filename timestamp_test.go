@@ -0,0 +1,209 @@
+package avro_test
+
+import (
+	"encoding/json"
+	"reflect"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro"
+	"github.com/heetch/avro/avrotypegen"
+)
+
+// timestamps holds time.Time values with both
+// timestamp logical types, including inside an
+// array and a union.
+type timestamps struct {
+	Millis      time.Time
+	Micros      time.Time
+	MillisArray []time.Time
+	MillisOpt   *time.Time
+}
+
+func (timestamps) AvroRecord() avrotypegen.RecordInfo {
+	return avrotypegen.RecordInfo{
+		Schema: `{"fields":[{"name":"Millis","type":{"logicalType":"timestamp-millis","type":"long"}},{"name":"Micros","type":{"logicalType":"timestamp-micros","type":"long"}},{"name":"MillisArray","type":{"items":{"logicalType":"timestamp-millis","type":"long"},"type":"array"}},{"default":null,"name":"MillisOpt","type":["null",{"logicalType":"timestamp-millis","type":"long"}]}],"name":"R","type":"record"}`,
+		Required: []bool{
+			0: true,
+			1: true,
+			2: true,
+		},
+	}
+}
+
+func TestTimestampEncoding(t *testing.T) {
+	c := qt.New(t)
+	data, _, err := avro.Marshal(timestamps{
+		Millis: time.Unix(1, 0),
+		Micros: time.Unix(1, 0),
+	})
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(data, qt.DeepEquals, []byte{
+		// Millis: 1000
+		0xd0, 0x0f,
+		// Micros: 1000000
+		0x80, 0x89, 0x7a,
+		// MillisArray: empty
+		0,
+		// MillisOpt: null
+		0,
+	})
+}
+
+func TestTimestampRoundTrip(t *testing.T) {
+	c := qt.New(t)
+	t0 := time.Date(2020, 5, 6, 7, 8, 9, 123456789, time.UTC)
+	t1 := t0.Add(time.Hour)
+	data, wType, err := avro.Marshal(timestamps{
+		Millis:      t0,
+		Micros:      t0,
+		MillisArray: []time.Time{t0, t1},
+		MillisOpt:   &t1,
+	})
+	c.Assert(err, qt.Equals, nil)
+
+	var x timestamps
+	_, err = avro.Unmarshal(data, &x, wType)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(x.Millis.UTC(), qt.Equals, t0.Truncate(time.Millisecond))
+	c.Assert(x.Micros.UTC(), qt.Equals, t0.Truncate(time.Microsecond))
+	c.Assert(x.MillisArray, qt.HasLen, 2)
+	c.Assert(x.MillisArray[0].UTC(), qt.Equals, t0.Truncate(time.Millisecond))
+	c.Assert(x.MillisArray[1].UTC(), qt.Equals, t1.Truncate(time.Millisecond))
+	c.Assert(x.MillisOpt, qt.Not(qt.IsNil))
+	c.Assert(x.MillisOpt.UTC(), qt.Equals, t1.Truncate(time.Millisecond))
+}
+
+func TestTimestampMillisStructOf(t *testing.T) {
+	c := qt.New(t)
+	at := mustParseType(`{
+	"type": "record",
+	"name": "TimestampMillis",
+	"fields": [
+		{"name": "t", "type": {"type": "long", "logicalType": "timestamp-millis"}}
+	]
+}`)
+	st, err := avro.StructOf(at)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(st.Field(0).Type, qt.Equals, reflect.TypeOf(time.Time{}))
+
+	t0 := time.Date(2020, 5, 6, 7, 8, 9, 123456789, time.UTC)
+	v := reflect.New(st)
+	v.Elem().Field(0).Set(reflect.ValueOf(t0))
+	data, wType, err := avro.Marshal(v.Elem().Interface())
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(avro.Equal(wType, at), qt.Equals, true)
+
+	v1 := reflect.New(st)
+	_, err = avro.Unmarshal(data, v1.Interface(), at)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(v1.Elem().Field(0).Interface().(time.Time).UTC(), qt.Equals, t0.Truncate(time.Millisecond))
+}
+
+// localTimestamps holds time.Time values with
+// both local-timestamp logical types.
+type localTimestamps struct {
+	Millis time.Time
+	Micros []time.Time
+}
+
+func (localTimestamps) AvroRecord() avrotypegen.RecordInfo {
+	return avrotypegen.RecordInfo{
+		Schema: `{"fields":[{"name":"Millis","type":{"logicalType":"local-timestamp-millis","type":"long"}},{"name":"Micros","type":{"items":{"logicalType":"local-timestamp-micros","type":"long"},"type":"array"}}],"name":"R","type":"record"}`,
+		Required: []bool{
+			0: true,
+			1: true,
+		},
+	}
+}
+
+func TestLocalTimestamp(t *testing.T) {
+	c := qt.New(t)
+	loc := time.FixedZone("UTC+5", 5*60*60)
+	avro.SetLocalTimestampLocation(loc)
+	defer avro.SetLocalTimestampLocation(time.Local)
+
+	// The wall clock time in loc is encoded, so one second
+	// after midnight in loc encodes as one second, regardless
+	// of the location of the time being encoded.
+	t0 := time.Date(1970, 1, 1, 0, 0, 1, 0, loc)
+	data, wType, err := avro.Marshal(localTimestamps{
+		Millis: t0.UTC(),
+		Micros: []time.Time{t0},
+	})
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(data, qt.DeepEquals, []byte{
+		// Millis: 1000
+		0xd0, 0x0f,
+		// Micros: [1000000]
+		2, 0x80, 0x89, 0x7a, 0,
+	})
+
+	var x localTimestamps
+	_, err = avro.Unmarshal(data, &x, wType)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(x.Millis.Location(), qt.Equals, loc)
+	c.Assert(x.Millis.Equal(t0), qt.Equals, true)
+	c.Assert(x.Micros, qt.HasLen, 1)
+	c.Assert(x.Micros[0].Equal(t0), qt.Equals, true)
+}
+
+func TestTypeOfTime(t *testing.T) {
+	c := qt.New(t)
+	type R struct {
+		Micros      time.Time
+		Millis      time.Time   `json:",millis"`
+		MillisOpt   *time.Time  `json:"millisOpt,millis"`
+		MillisArray []time.Time `json:"millisArray,millis"`
+	}
+	at, err := avro.TypeOf(R{})
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(at.String(), qt.JSONEquals, json.RawMessage(`{
+		"type": "record",
+		"name": "R",
+		"fields": [{
+			"name": "Micros",
+			"default": 0,
+			"type": {"type": "long", "logicalType": "timestamp-micros"}
+		}, {
+			"name": "Millis",
+			"default": 0,
+			"type": {"type": "long", "logicalType": "timestamp-millis"}
+		}, {
+			"name": "millisOpt",
+			"default": null,
+			"type": ["null", {"type": "long", "logicalType": "timestamp-millis"}]
+		}, {
+			"name": "millisArray",
+			"default": [],
+			"type": {
+				"type": "array",
+				"items": {"type": "long", "logicalType": "timestamp-millis"}
+			}
+		}]
+	}`))
+
+	// Times are decoded in UTC regardless of the location
+	// they were encoded in.
+	loc := time.FixedZone("UTC+5", 5*60*60)
+	t0 := time.Date(2020, 5, 6, 7, 8, 9, 123456789, loc)
+	data, wType, err := avro.Marshal(R{
+		Micros:      t0,
+		Millis:      t0,
+		MillisOpt:   &t0,
+		MillisArray: []time.Time{t0},
+	})
+	c.Assert(err, qt.Equals, nil)
+	var x R
+	_, err = avro.Unmarshal(data, &x, wType)
+	c.Assert(err, qt.Equals, nil)
+	millis := t0.UTC().Truncate(time.Millisecond)
+	c.Assert(x, qt.DeepEquals, R{
+		Micros:      t0.UTC().Truncate(time.Microsecond),
+		Millis:      millis,
+		MillisOpt:   &millis,
+		MillisArray: []time.Time{millis},
+	})
+}
@@ -0,0 +1,429 @@
+package avro
+
+import (
+	"bufio"
+	"crypto/rand"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+
+	"github.com/heetch/avro/internal/ocf"
+)
+
+// TranscodeFormat represents a way of holding a stream
+// of Avro values. See NewTranscoder.
+type TranscodeFormat int
+
+const (
+	// FramedBinary holds values in the Avro binary encoding, each
+	// preceded by its length encoded as an Avro long, so that the
+	// stream is a sequence of Avro bytes values.
+	FramedBinary TranscodeFormat = iota
+
+	// SingleObject holds values in Avro single-object encoding,
+	// one after another.
+	SingleObject
+
+	// OCF holds values in an Avro object container file, as read
+	// and written by the avroocf package. When reading, the schema
+	// in the file header is used as the writer's schema, so it
+	// need only be compatible with TranscoderOptions.Type.
+	// Blocks are written uncompressed.
+	OCF
+
+	// JSONLines holds values in the Avro JSON encoding,
+	// one per line.
+	JSONLines
+)
+
+var transcodeFormatNames = []string{
+	FramedBinary: "framed",
+	SingleObject: "single-object",
+	OCF:          "ocf",
+	JSONLines:    "jsonl",
+}
+
+// String returns the name of the format, as accepted
+// by ParseTranscodeFormat.
+func (f TranscodeFormat) String() string {
+	if f < 0 || int(f) >= len(transcodeFormatNames) {
+		return fmt.Sprintf("TranscodeFormat(%d)", int(f))
+	}
+	return transcodeFormatNames[f]
+}
+
+// ParseTranscodeFormat returns the format with the given name,
+// one of "framed", "single-object", "ocf" or "jsonl".
+func ParseTranscodeFormat(s string) (TranscodeFormat, error) {
+	for i, name := range transcodeFormatNames {
+		if name == s {
+			return TranscodeFormat(i), nil
+		}
+	}
+	return 0, fmt.Errorf("unknown transcode format %q", s)
+}
+
+// DefaultMaxValueSize holds the default maximum size
+// of a single encoded value read by a Transcoder.
+const DefaultMaxValueSize = 16 << 20
+
+// TranscoderOptions holds the options for NewTranscoder.
+type TranscoderOptions struct {
+	// Type holds the type of the values. It must be a
+	// record type that's supported by StructOf.
+	Type *Type
+
+	// From holds the format of the input.
+	From TranscodeFormat
+
+	// To holds the format of the output.
+	To TranscodeFormat
+
+	// MaxValueSize holds the maximum size of a single encoded
+	// value in the input. If it's zero, DefaultMaxValueSize is used.
+	MaxValueSize int
+}
+
+// Transcoder converts a stream of Avro values from one
+// format to another. Only one value is held in memory
+// at a time, or one block of values when reading or
+// writing an object container file. Output is buffered, so Close must be called
+// after the last call to Next.
+type Transcoder struct {
+	in   io.Reader
+	out  *bufio.Writer
+	opts TranscoderOptions
+
+	goType reflect.Type
+	prog   *decodeProgram
+
+	// pending holds input that hasn't been used yet.
+	pending []byte
+	// inErr holds any error from reading the input.
+	inErr error
+
+	jsonDec *json.Decoder
+	header  [10]byte
+
+	// ocfIn reads an input object container file and ocfInHeader
+	// holds its header, read by the first call to Next.
+	ocfIn       *bufio.Reader
+	ocfInHeader *ocf.Header
+	// block holds the encoded values remaining in the current
+	// input block and blockCount holds how many there are.
+	block      []byte
+	blockCount int
+
+	// ocfOutHeader holds the header of an output object container
+	// file. outBlock holds the encoded values that haven't been
+	// written yet and outCount holds how many there are.
+	ocfOutHeader *ocf.Header
+	outBlock     []byte
+	outCount     int
+}
+
+// ocfBlockSize holds the approximate size of the uncompressed
+// data in each block of an output object container file.
+const ocfBlockSize = 64 * 1024
+
+// NewTranscoder returns a Transcoder that reads values in the
+// format opts.From from in and writes them in the format opts.To
+// to out.
+func NewTranscoder(in io.Reader, out io.Writer, opts TranscoderOptions) (*Transcoder, error) {
+	if opts.Type == nil {
+		return nil, fmt.Errorf("no type specified")
+	}
+	for _, f := range []TranscodeFormat{opts.From, opts.To} {
+		switch f {
+		case FramedBinary, SingleObject, OCF, JSONLines:
+		default:
+			return nil, fmt.Errorf("unknown transcode format %v", f)
+		}
+	}
+	if opts.MaxValueSize == 0 {
+		opts.MaxValueSize = DefaultMaxValueSize
+	}
+	goType, err := StructOf(opts.Type)
+	if err != nil {
+		return nil, err
+	}
+	prog, err := compileDecoder(globalNames, goType, opts.Type)
+	if err != nil {
+		return nil, err
+	}
+	t := &Transcoder{
+		in:     in,
+		out:    bufio.NewWriter(out),
+		opts:   opts,
+		goType: goType,
+		prog:   prog,
+	}
+	t.header[0], t.header[1] = 0xc3, 0x01
+	binary.LittleEndian.PutUint64(t.header[2:], opts.Type.Fingerprint())
+	if opts.From == JSONLines {
+		t.jsonDec = json.NewDecoder(in)
+		t.jsonDec.UseNumber()
+	}
+	if opts.From == OCF {
+		t.ocfIn = bufio.NewReader(in)
+	}
+	if opts.To == OCF {
+		h := &ocf.Header{
+			Codec: ocf.NullCodec,
+			Meta: map[string][]byte{
+				ocf.SchemaKey: []byte(opts.Type.String()),
+				ocf.CodecKey:  []byte(ocf.NullCodec),
+			},
+		}
+		if _, err := rand.Read(h.Sync[:]); err != nil {
+			return nil, err
+		}
+		t.ocfOutHeader = h
+		t.out.Write(ocf.AppendHeader(nil, h))
+	}
+	return t, nil
+}
+
+// Transcode transcodes all the values in the input
+// and then calls Close.
+func (t *Transcoder) Transcode() error {
+	for {
+		if err := t.Next(); err != nil {
+			if err == io.EOF {
+				return t.Close()
+			}
+			return err
+		}
+	}
+}
+
+// Next transcodes a single value. It returns io.EOF when
+// there are no more values in the input.
+func (t *Transcoder) Next() error {
+	v := reflect.New(t.goType)
+	if err := t.read(v); err != nil {
+		return err
+	}
+	return t.write(v.Elem())
+}
+
+// Close writes any buffered output. It doesn't close
+// the underlying writer.
+func (t *Transcoder) Close() error {
+	if err := t.writeBlock(); err != nil {
+		return err
+	}
+	return t.out.Flush()
+}
+
+func (t *Transcoder) read(v reflect.Value) error {
+	switch t.opts.From {
+	case JSONLines:
+		var x interface{}
+		if err := t.jsonDec.Decode(&x); err != nil {
+			if err == io.EOF {
+				return err
+			}
+			return fmt.Errorf("cannot decode JSON value: %v", err)
+		}
+		data, err := appendAvroBinary(nil, x, t.opts.Type.avroType)
+		if err != nil {
+			return fmt.Errorf("cannot decode JSON value: %v", err)
+		}
+		if _, err := unmarshal(nil, data, t.prog, v.Elem(), nil); err != nil {
+			return fmt.Errorf("cannot decode value: %w", err)
+		}
+		return nil
+	case FramedBinary:
+		if err := t.fill(1); err != nil {
+			return err
+		}
+		// The length is at most binary.MaxVarintLen64 bytes.
+		var size int64
+		var n int
+		for {
+			size, n = binary.Varint(t.pending)
+			if n != 0 || len(t.pending) >= binary.MaxVarintLen64 {
+				break
+			}
+			if err := t.fill(len(t.pending) + 1); err != nil {
+				return truncated(err)
+			}
+		}
+		if n <= 0 || size < 0 {
+			return fmt.Errorf("invalid value length")
+		}
+		if size > int64(t.opts.MaxValueSize) {
+			return fmt.Errorf("value of %d bytes exceeds maximum size", size)
+		}
+		if err := t.fill(n + int(size)); err != nil {
+			return truncated(err)
+		}
+		data := t.pending[n : n+int(size)]
+		t.pending = t.pending[n+int(size):]
+		if _, err := unmarshal(nil, data, t.prog, v.Elem(), nil); err != nil {
+			return fmt.Errorf("cannot decode value: %w", err)
+		}
+		return nil
+	case SingleObject:
+		if err := t.fill(len(t.header)); err != nil {
+			if err == io.EOF && len(t.pending) == 0 {
+				return io.EOF
+			}
+			return truncated(err)
+		}
+		if t.pending[0] != t.header[0] || t.pending[1] != t.header[1] {
+			return fmt.Errorf("value is not in single-object encoding")
+		}
+		if string(t.pending[2:len(t.header)]) != string(t.header[2:]) {
+			return fmt.Errorf("unexpected schema fingerprint %#x", binary.LittleEndian.Uint64(t.pending[2:]))
+		}
+		t.pending = t.pending[len(t.header):]
+		return t.readBinary(v)
+	case OCF:
+		for t.blockCount == 0 {
+			if err := t.readBlock(); err != nil {
+				return err
+			}
+		}
+		n, err := decodePrefix(t.block, t.prog, v.Elem())
+		if err != nil {
+			return fmt.Errorf("cannot decode value: %w", err)
+		}
+		t.block = t.block[n:]
+		t.blockCount--
+		return nil
+	}
+	panic("unreachable")
+}
+
+// readBlock reads the next block of an input object container
+// file, reading the file header first if necessary.
+func (t *Transcoder) readBlock() error {
+	if t.ocfInHeader == nil {
+		h, err := ocf.ReadHeader(t.ocfIn)
+		if err != nil {
+			return err
+		}
+		wType, err := ParseType(string(h.Schema))
+		if err != nil {
+			return fmt.Errorf("invalid schema in file header: %v", err)
+		}
+		prog, err := compileDecoder(globalNames, t.goType, wType)
+		if err != nil {
+			return err
+		}
+		t.ocfInHeader, t.prog = h, prog
+	}
+	count, _, data, err := ocf.ReadBlock(t.ocfIn, t.ocfInHeader)
+	if err != nil {
+		return err
+	}
+	t.block, t.blockCount = data, count
+	return nil
+}
+
+// readBinary decodes a single binary value from the input into v.
+func (t *Transcoder) readBinary(v reflect.Value) error {
+	for {
+		n, err := decodePrefix(t.pending, t.prog, v.Elem())
+		if err == nil {
+			t.pending = t.pending[n:]
+			return nil
+		}
+		if !errors.Is(err, io.EOF) && !errors.Is(err, io.ErrUnexpectedEOF) {
+			return fmt.Errorf("cannot decode value: %w", err)
+		}
+		// We don't have all of the value yet.
+		if len(t.pending) >= t.opts.MaxValueSize {
+			return fmt.Errorf("value exceeds maximum size")
+		}
+		if err := t.fill(len(t.pending) + 1); err != nil {
+			return truncated(err)
+		}
+		v.Elem().Set(reflect.Zero(t.goType))
+	}
+}
+
+// fill reads from the input until at least n bytes are pending.
+// It returns io.EOF if the input ends first.
+func (t *Transcoder) fill(n int) error {
+	for len(t.pending) < n {
+		if t.inErr != nil {
+			return t.inErr
+		}
+		if cap(t.pending)-len(t.pending) < bufSize {
+			// Make room, moving the pending bytes
+			// to the start of a new buffer.
+			size := 2 * cap(t.pending)
+			if size < n+bufSize {
+				size = n + bufSize
+			}
+			buf := make([]byte, len(t.pending), size)
+			copy(buf, t.pending)
+			t.pending = buf
+		}
+		nr, err := t.in.Read(t.pending[len(t.pending):cap(t.pending)])
+		t.pending = t.pending[:len(t.pending)+nr]
+		if err != nil {
+			t.inErr = err
+		}
+	}
+	return nil
+}
+
+// truncated returns the error to use when the input
+// ends in the middle of a value.
+func truncated(err error) error {
+	if err == io.EOF {
+		return fmt.Errorf("unexpected end of input")
+	}
+	return err
+}
+
+func (t *Transcoder) write(v reflect.Value) error {
+	data, _, err := marshalAppend(globalNames, nil, v)
+	if err != nil {
+		return fmt.Errorf("cannot encode value: %v", err)
+	}
+	switch t.opts.To {
+	case JSONLines:
+		data, _, err = appendAvroJSON(nil, data, t.opts.Type.avroType)
+		if err != nil {
+			return fmt.Errorf("cannot encode JSON value: %v", err)
+		}
+		data = append(data, '\n')
+	case FramedBinary:
+		var buf [binary.MaxVarintLen64]byte
+		t.out.Write(buf[:binary.PutVarint(buf[:], int64(len(data)))])
+	case SingleObject:
+		t.out.Write(t.header[:])
+	case OCF:
+		t.outBlock = append(t.outBlock, data...)
+		t.outCount++
+		if len(t.outBlock) >= ocfBlockSize {
+			return t.writeBlock()
+		}
+		return nil
+	}
+	_, err = t.out.Write(data)
+	return err
+}
+
+// writeBlock writes any pending values to the
+// output object container file as a block.
+func (t *Transcoder) writeBlock() error {
+	if t.outCount == 0 {
+		return nil
+	}
+	data, err := ocf.AppendBlock(nil, t.ocfOutHeader, t.outCount, t.outBlock)
+	if err != nil {
+		return err
+	}
+	t.outBlock, t.outCount = t.outBlock[:0], 0
+	_, err = t.out.Write(data)
+	return err
+}
@@ -0,0 +1,312 @@
+package avro_test
+
+import (
+	"bytes"
+	"encoding/binary"
+	"strings"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro"
+	"github.com/heetch/avro/avroocf"
+)
+
+const transcodeSchema = `{
+	"type": "record",
+	"name": "R",
+	"fields": [
+		{"name": "a", "type": "long"},
+		{"name": "b", "type": ["null", "string"]}
+	]
+}`
+
+const transcodeJSONLines = `{"a":1,"b":{"string":"x"}}
+{"a":-2,"b":null}
+`
+
+func TestTranscodeRoundTrip(t *testing.T) {
+	c := qt.New(t)
+	rType, err := avro.ParseType(transcodeSchema)
+	c.Assert(err, qt.Equals, nil)
+
+	transcode := func(in []byte, from, to avro.TranscodeFormat) []byte {
+		var out bytes.Buffer
+		tc, err := avro.NewTranscoder(bytes.NewReader(in), &out, avro.TranscoderOptions{
+			Type: rType,
+			From: from,
+			To:   to,
+		})
+		c.Assert(err, qt.Equals, nil)
+		c.Assert(tc.Transcode(), qt.Equals, nil)
+		return out.Bytes()
+	}
+	framed := transcode([]byte(transcodeJSONLines), avro.JSONLines, avro.FramedBinary)
+	c.Assert(framed, qt.DeepEquals, []byte{
+		8, 2, 2, 2, 'x',
+		4, 3, 0,
+	})
+
+	single := transcode(framed, avro.FramedBinary, avro.SingleObject)
+	fp := make([]byte, 8)
+	binary.LittleEndian.PutUint64(fp, rType.Fingerprint())
+	var expect []byte
+	expect = append(expect, 0xc3, 0x01)
+	expect = append(expect, fp...)
+	expect = append(expect, 2, 2, 2, 'x')
+	expect = append(expect, 0xc3, 0x01)
+	expect = append(expect, fp...)
+	expect = append(expect, 3, 0)
+	c.Assert(single, qt.DeepEquals, expect)
+
+	jsonl := transcode(single, avro.SingleObject, avro.JSONLines)
+	c.Assert(string(jsonl), qt.Equals, transcodeJSONLines)
+}
+
+func TestTranscodeNext(t *testing.T) {
+	c := qt.New(t)
+	rType, err := avro.ParseType(transcodeSchema)
+	c.Assert(err, qt.Equals, nil)
+	var out bytes.Buffer
+	tc, err := avro.NewTranscoder(strings.NewReader(transcodeJSONLines), &out, avro.TranscoderOptions{
+		Type: rType,
+		From: avro.JSONLines,
+		To:   avro.FramedBinary,
+	})
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(tc.Next(), qt.Equals, nil)
+	// The output is buffered until Close is called.
+	c.Assert(out.Len(), qt.Equals, 0)
+	c.Assert(tc.Next(), qt.Equals, nil)
+	c.Assert(tc.Close(), qt.Equals, nil)
+	c.Assert(out.Bytes(), qt.DeepEquals, []byte{8, 2, 2, 2, 'x', 4, 3, 0})
+}
+
+func TestTranscodeAvroJSON(t *testing.T) {
+	c := qt.New(t)
+	rType, err := avro.ParseType(`{
+		"type": "record",
+		"name": "R",
+		"fields": [
+			{"name": "a", "type": "long"},
+			{"name": "b", "type": ["null", {
+				"type": "record",
+				"name": "R2",
+				"fields": [
+					{"name": "x", "type": {"type": "enum", "name": "E", "symbols": ["X", "Y"]}}
+				]
+			}]},
+			{"name": "c", "type": "bytes"},
+			{"name": "d", "type": {"type": "fixed", "name": "F", "size": 2}},
+			{"name": "e", "type": {"type": "map", "values": {"type": "array", "items": "double"}}},
+			{"name": "f", "type": {"type": "long", "logicalType": "timestamp-millis"}}
+		]
+	}`)
+	c.Assert(err, qt.Equals, nil)
+	in := `{"a":1,"b":{"R2":{"x":"Y"}},"c":"\u0000\u0001ÿ","d":"ab","e":{"k":[1.5,-2]},"f":1577836800000}
+{"a":2,"b":null,"c":"","d":"\u0000\u0000","e":{},"f":0}
+`
+	var framed bytes.Buffer
+	tc, err := avro.NewTranscoder(strings.NewReader(in), &framed, avro.TranscoderOptions{
+		Type: rType,
+		From: avro.JSONLines,
+		To:   avro.FramedBinary,
+	})
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(tc.Transcode(), qt.Equals, nil)
+
+	var out bytes.Buffer
+	tc, err = avro.NewTranscoder(&framed, &out, avro.TranscoderOptions{
+		Type: rType,
+		From: avro.FramedBinary,
+		To:   avro.JSONLines,
+	})
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(tc.Transcode(), qt.Equals, nil)
+	c.Assert(out.String(), qt.Equals, in)
+}
+
+func TestTranscodeOCF(t *testing.T) {
+	c := qt.New(t)
+	rType, err := avro.ParseType(transcodeSchema)
+	c.Assert(err, qt.Equals, nil)
+
+	var file bytes.Buffer
+	tc, err := avro.NewTranscoder(strings.NewReader(transcodeJSONLines), &file, avro.TranscoderOptions{
+		Type: rType,
+		From: avro.JSONLines,
+		To:   avro.OCF,
+	})
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(tc.Transcode(), qt.Equals, nil)
+
+	// The file can be read by avroocf.
+	type R struct {
+		A int64   `json:"a"`
+		B *string `json:"b"`
+	}
+	r, err := avroocf.NewReader(bytes.NewReader(file.Bytes()))
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(r.Header().Schema.String(), qt.Equals, rType.String())
+	c.Assert(r.Header().Codec, qt.Equals, avroocf.NullCodec)
+	b, err := r.NextBlock()
+	c.Assert(err, qt.Equals, nil)
+	var xs []R
+	c.Assert(b.Decode(&xs), qt.Equals, nil)
+	c.Assert(xs, qt.DeepEquals, []R{{A: 1, B: newString("x")}, {A: -2}})
+
+	var out bytes.Buffer
+	tc, err = avro.NewTranscoder(&file, &out, avro.TranscoderOptions{
+		Type: rType,
+		From: avro.OCF,
+		To:   avro.JSONLines,
+	})
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(tc.Transcode(), qt.Equals, nil)
+	c.Assert(out.String(), qt.Equals, transcodeJSONLines)
+}
+
+func TestTranscodeOCFWithWriterSchema(t *testing.T) {
+	c := qt.New(t)
+	rType, err := avro.ParseType(`{
+		"type": "record",
+		"name": "Resolved",
+		"fields": [
+			{"name": "a", "type": "long"}
+		]
+	}`)
+	c.Assert(err, qt.Equals, nil)
+
+	// The file is written with a different but compatible
+	// schema, over several compressed blocks. The int field
+	// is promoted to long and the extra field is dropped.
+	type Resolved struct {
+		A int32  `json:"a"`
+		C string `json:"c"`
+	}
+	wType, err := avro.TypeOf(Resolved{})
+	c.Assert(err, qt.Equals, nil)
+	var file bytes.Buffer
+	w, err := avroocf.NewWriter(&file, wType, &avroocf.WriterOptions{
+		Codec:     avroocf.DeflateCodec,
+		BlockSize: 1,
+	})
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(w.Write(Resolved{A: 1, C: "x"}), qt.Equals, nil)
+	c.Assert(w.Write(Resolved{A: -2, C: "y"}), qt.Equals, nil)
+	c.Assert(w.Close(), qt.Equals, nil)
+
+	var out bytes.Buffer
+	tc, err := avro.NewTranscoder(&file, &out, avro.TranscoderOptions{
+		Type: rType,
+		From: avro.OCF,
+		To:   avro.JSONLines,
+	})
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(tc.Transcode(), qt.Equals, nil)
+	c.Assert(out.String(), qt.Equals, `{"a":1}
+{"a":-2}
+`)
+}
+
+var transcodeErrorTests = []struct {
+	testName    string
+	in          []byte
+	from        avro.TranscodeFormat
+	expectError string
+}{{
+	testName:    "truncated-framed",
+	in:          []byte{6, 2, 2},
+	from:        avro.FramedBinary,
+	expectError: `unexpected end of input`,
+}, {
+	testName:    "truncated-single-object",
+	in:          []byte{0xc3, 0x01, 1, 2},
+	from:        avro.SingleObject,
+	expectError: `unexpected end of input`,
+}, {
+	testName:    "not-single-object",
+	in:          []byte{1, 2, 3, 4, 5, 6, 7, 8, 9, 10},
+	from:        avro.SingleObject,
+	expectError: `value is not in single-object encoding`,
+}, {
+	testName:    "wrong-fingerprint",
+	in:          []byte{0xc3, 0x01, 1, 0, 0, 0, 0, 0, 0, 0, 2, 0},
+	from:        avro.SingleObject,
+	expectError: `unexpected schema fingerprint 0x1`,
+}, {
+	testName:    "too-large",
+	in:          []byte{0x80, 0x80, 0x80, 0x10},
+	from:        avro.FramedBinary,
+	expectError: `value of 16777216 bytes exceeds maximum size`,
+}, {
+	testName:    "not-ocf",
+	in:          []byte("Obj\x02"),
+	from:        avro.OCF,
+	expectError: `not an Avro object container file`,
+}, {
+	testName:    "truncated-ocf",
+	in:          []byte("Obj\x01\x02"),
+	from:        avro.OCF,
+	expectError: `cannot read file header: unexpected EOF`,
+}, {
+	testName:    "invalid-json",
+	in:          []byte(`{"a": "x"}`),
+	from:        avro.JSONLines,
+	expectError: `cannot decode JSON value: .*`,
+}, {
+	testName:    "unknown-union-member",
+	in:          []byte(`{"a": 1, "b": {"int": 1}}`),
+	from:        avro.JSONLines,
+	expectError: `cannot decode JSON value: cannot encode field "b" of R: .*: no member of union has type "int"`,
+}, {
+	testName:    "missing-field",
+	in:          []byte(`{"b": null}`),
+	from:        avro.JSONLines,
+	expectError: `cannot decode JSON value: field "a" of R not present and has no default value`,
+}}
+
+func TestTranscodeError(t *testing.T) {
+	c := qt.New(t)
+	rType, err := avro.ParseType(transcodeSchema)
+	c.Assert(err, qt.Equals, nil)
+	for _, test := range transcodeErrorTests {
+		c.Run(test.testName, func(c *qt.C) {
+			to := avro.JSONLines
+			if test.from == avro.JSONLines {
+				to = avro.FramedBinary
+			}
+			tc, err := avro.NewTranscoder(bytes.NewReader(test.in), new(bytes.Buffer), avro.TranscoderOptions{
+				Type:         rType,
+				From:         test.from,
+				To:           to,
+				MaxValueSize: 1 << 20,
+			})
+			c.Assert(err, qt.Equals, nil)
+			c.Assert(tc.Transcode(), qt.ErrorMatches, test.expectError)
+		})
+	}
+}
+
+func TestNewTranscoderUnknownFormat(t *testing.T) {
+	c := qt.New(t)
+	rType, err := avro.ParseType(transcodeSchema)
+	c.Assert(err, qt.Equals, nil)
+	_, err = avro.NewTranscoder(nil, nil, avro.TranscoderOptions{
+		Type: rType,
+		From: avro.FramedBinary,
+		To:   avro.TranscodeFormat(99),
+	})
+	c.Assert(err, qt.ErrorMatches, `unknown transcode format TranscodeFormat\(99\)`)
+}
+
+func TestParseTranscodeFormat(t *testing.T) {
+	c := qt.New(t)
+	for _, f := range []avro.TranscodeFormat{avro.FramedBinary, avro.SingleObject, avro.OCF, avro.JSONLines} {
+		f1, err := avro.ParseTranscodeFormat(f.String())
+		c.Assert(err, qt.Equals, nil)
+		c.Assert(f1, qt.Equals, f)
+	}
+	_, err := avro.ParseTranscodeFormat("xml")
+	c.Assert(err, qt.ErrorMatches, `unknown transcode format "xml"`)
+}
@@ -23,10 +23,25 @@ type Type struct {
 
 // ParseType parses an Avro schema in the format defined by the Avro
 // specification at https://avro.apache.org/docs/current/spec.html.
+//
+// The precision and scale of decimal logical types are checked;
+// see Type.DecimalParams.
+//
+// The returned error is always a *SchemaError, which holds the
+// location of the problem in the schema when it's known.
 func ParseType(s string) (*Type, error) {
+	if err := checkSchemaJSON([]byte(s)); err != nil {
+		if _, ok := err.(*SchemaError); !ok {
+			err = &SchemaError{Message: err.Error()}
+		}
+		return nil, err
+	}
 	avroType, err := typeinfo.ParseSchema(s, nil)
 	if err != nil {
-		return nil, err
+		return nil, &SchemaError{Message: err.Error()}
+	}
+	if err := checkDecimals(avroType); err != nil {
+		return nil, &SchemaError{Message: fmt.Sprintf("invalid schema %q: %v", s, err)}
 	}
 	return &Type{
 		schema:   s,
@@ -34,10 +49,80 @@ func ParseType(s string) (*Type, error) {
 	}, nil
 }
 
+// String returns the schema that the type was created from.
+// Any attributes not defined by the Avro specification
+// are retained.
 func (t *Type) String() string {
 	return t.schema
 }
 
+// MarshalJSON implements json.Marshaler by returning
+// the schema of the type.
+func (t *Type) MarshalJSON() ([]byte, error) {
+	return []byte(t.schema), nil
+}
+
+// UnmarshalJSON implements json.Unmarshaler by parsing
+// data as an Avro schema with ParseType.
+func (t *Type) UnmarshalJSON(data []byte) error {
+	t1, err := ParseType(string(data))
+	if err != nil {
+		return err
+	}
+	*t = Type{
+		schema:   t1.schema,
+		avroType: t1.avroType,
+	}
+	return nil
+}
+
+// reservedAttrs holds the schema attributes that define the
+// structure of a type and are hence not returned by Properties.
+var reservedAttrs = map[string]bool{
+	"type":      true,
+	"name":      true,
+	"namespace": true,
+	"aliases":   true,
+	"doc":       true,
+	"fields":    true,
+	"symbols":   true,
+	"default":   true,
+	"items":     true,
+	"values":    true,
+	"size":      true,
+}
+
+// Properties returns the attributes of the top level type other than
+// those that define its structure (for example "type", "name" and
+// "fields"), such as "logicalType" or custom metadata like
+// "confluent:tags". It returns nil if there are none.
+//
+// The returned map is a copy and may be modified freely.
+func (t *Type) Properties() map[string]interface{} {
+	if _, ok := t.avroType.(*schema.UnionField); ok {
+		return nil
+	}
+	def, err := t.avroType.Definition(emptyScope())
+	if err != nil {
+		return nil
+	}
+	obj, ok := def.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	var props map[string]interface{}
+	for name, val := range obj {
+		if reservedAttrs[name] {
+			continue
+		}
+		if props == nil {
+			props = make(map[string]interface{})
+		}
+		props[name] = val
+	}
+	return props
+}
+
 // CanonicalOpts holds a bitmask of options for CanonicalString.
 type CanonicalOpts int
 
@@ -52,6 +137,9 @@ const (
 // CanonicalString returns the canonical string representation of the type,
 // as documented here: https://avro.apache.org/docs/1.9.1/spec.html#Transforming+into+Parsing+Canonical+Form
 //
+// Unlike String, custom attributes (see Properties) are always
+// omitted from the result, whatever the options.
+//
 // BUG: Unicode characters \u2028 and \u2029 in strings inside the schema are always escaped, contrary to the
 // specification above.
 func (t *Type) CanonicalString(opts CanonicalOpts) string {
@@ -126,11 +214,9 @@ func (c *canonicalizer) canonicalValue(at schema.AvroType) interface{} {
 		panic("unexpected type returned from canonicalValue1")
 	}
 	r.LogicalType = ltype
-	if ltype == "decimal" {
-		scale, _ := at.Attribute("scale").(float64)
-		r.Scale = int(scale)
-		precision, _ := at.Attribute("precision").(float64)
-		r.Precision = int(precision)
+	if precision, scale, ok := decimalParams(at); ok {
+		r.Precision = precision
+		r.Scale = scale
 	}
 	return r
 }
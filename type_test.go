@@ -1,6 +1,8 @@
 package avro_test
 
 import (
+	"encoding/json"
+	"errors"
 	"testing"
 
 	qt "github.com/frankban/quicktest"
@@ -302,3 +304,223 @@ func mustParseType(s string) *avro.Type {
 	}
 	return t
 }
+
+var propertiesTests = []struct {
+	testName string
+	in       string
+	expect   map[string]interface{}
+}{{
+	testName: "record",
+	in: `{
+	"type": "record",
+	"name": "R",
+	"doc": "documentation",
+	"confluent:tags": ["PII"],
+	"extra-meta": "hello",
+	"fields": [{"name": "a", "type": "string"}]
+}`,
+	expect: map[string]interface{}{
+		"confluent:tags": []interface{}{"PII"},
+		"extra-meta":     "hello",
+	},
+}, {
+	testName: "custom-logical-type",
+	in:       `{"type": "bytes", "logicalType": "custom-decimal", "precision": 4}`,
+	expect: map[string]interface{}{
+		"logicalType": "custom-decimal",
+		"precision":   4.0,
+	},
+}, {
+	testName: "no-properties",
+	in:       `{"type": "enum", "name": "E", "symbols": ["a"]}`,
+}, {
+	testName: "primitive",
+	in:       `"string"`,
+}, {
+	testName: "union",
+	in:       `["null", "string"]`,
+}}
+
+func TestProperties(t *testing.T) {
+	c := qt.New(t)
+	for _, test := range propertiesTests {
+		c.Run(test.testName, func(c *qt.C) {
+			t, err := avro.ParseType(test.in)
+			c.Assert(err, qt.Equals, nil)
+			c.Assert(t.Properties(), qt.DeepEquals, test.expect)
+			// The original schema, including all properties, is retained.
+			c.Assert(t.String(), qt.Equals, test.in)
+		})
+	}
+}
+
+func TestTypeJSON(t *testing.T) {
+	c := qt.New(t)
+	type config struct {
+		Schema *avro.Type `json:"schema"`
+	}
+	data := []byte(`{"schema": {"type": "record", "name": "R", "fields": [{"name": "a", "type": "int"}]}}`)
+	var cfg config
+	err := json.Unmarshal(data, &cfg)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(cfg.Schema.Name(), qt.Equals, "R")
+	c.Assert(cfg.Schema.CanonicalString(0), qt.Equals, `{"name":"R","type":"record","fields":[{"name":"a","type":"int"}]}`)
+
+	data1, err := json.Marshal(cfg)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(string(data1), qt.Equals, `{"schema":{"type":"record","name":"R","fields":[{"name":"a","type":"int"}]}}`)
+
+	err = json.Unmarshal([]byte(`{"schema": {"type": "unknown"}}`), &cfg)
+	c.Assert(err, qt.ErrorMatches, `(?s).*unknown.*`)
+}
+
+var decimalParamsTests = []struct {
+	testName        string
+	in              string
+	expectPrecision int
+	expectScale     int
+	expectOK        bool
+	expectError     string
+}{{
+	testName:        "bytes",
+	in:              `{"type": "bytes", "logicalType": "decimal", "precision": 6, "scale": 2}`,
+	expectPrecision: 6,
+	expectScale:     2,
+	expectOK:        true,
+}, {
+	testName:        "fixed-default-scale",
+	in:              `{"type": "fixed", "name": "F", "size": 4, "logicalType": "decimal", "precision": 9}`,
+	expectPrecision: 9,
+	expectOK:        true,
+}, {
+	testName: "not-decimal",
+	in:       `{"type": "bytes"}`,
+}, {
+	testName: "ignored-on-string",
+	in:       `{"type": "string", "logicalType": "decimal"}`,
+}, {
+	testName:    "missing-precision",
+	in:          `{"type": "bytes", "logicalType": "decimal"}`,
+	expectError: `invalid schema .*: decimal precision must be a positive integer, not <nil>`,
+}, {
+	testName:    "zero-precision",
+	in:          `{"type": "bytes", "logicalType": "decimal", "precision": 0}`,
+	expectError: `invalid schema .*: decimal precision must be a positive integer, not 0`,
+}, {
+	testName:    "negative-scale",
+	in:          `{"type": "bytes", "logicalType": "decimal", "precision": 3, "scale": -1}`,
+	expectError: `invalid schema .*: decimal scale must be a non-negative integer, not -1`,
+}, {
+	testName:    "scale-too-large",
+	in:          `{"type": "record", "name": "R", "fields": [{"name": "a", "type": {"type": "bytes", "logicalType": "decimal", "precision": 3, "scale": 4}}]}`,
+	expectError: `invalid schema .*: field "a" of R: decimal scale 4 is greater than precision 3`,
+}}
+
+func TestDecimalParams(t *testing.T) {
+	c := qt.New(t)
+	for _, test := range decimalParamsTests {
+		c.Run(test.testName, func(c *qt.C) {
+			t, err := avro.ParseType(test.in)
+			if test.expectError != "" {
+				c.Assert(err, qt.ErrorMatches, test.expectError)
+				return
+			}
+			c.Assert(err, qt.Equals, nil)
+			precision, scale, ok := t.DecimalParams()
+			c.Assert(ok, qt.Equals, test.expectOK)
+			c.Assert(precision, qt.Equals, test.expectPrecision)
+			c.Assert(scale, qt.Equals, test.expectScale)
+		})
+	}
+}
+
+var parseTypeErrorTests = []struct {
+	testName    string
+	in          string
+	expectError string
+	expectPath  string
+}{{
+	testName: "syntax-error",
+	in: `{
+	"type": "record",
+	"name": "R",
+	"fields": [
+		{"name": "a", "type": "int"}
+		{"name": "b", "type": "int"}
+	]
+}`,
+	expectError: `invalid schema at line 6, column 3: invalid character '{' after array element`,
+}, {
+	testName: "unknown-type",
+	in: `{
+	"type": "record",
+	"name": "R",
+	"fields": [
+		{"name": "a", "type": "int"},
+		{"name": "b", "type": ["null", "strin"]}
+	]
+}`,
+	expectError: `invalid schema at line 6, column 34 \(/fields/1/type/1\): unknown type "strin"`,
+	expectPath:  "/fields/1/type/1",
+}, {
+	testName: "missing-field-type",
+	in: `{
+	"type": "record",
+	"name": "R",
+	"fields": [
+		{"name": "a", "type": {"type": "array", "items": "int"}},
+		{"name": "b"}
+	]
+}`,
+	expectError: `invalid schema at line 6, column 3 \(/fields/1\): missing "type" attribute`,
+	expectPath:  "/fields/1",
+}, {
+	testName:    "enum-without-symbols",
+	in:          `{"type": "enum", "name": "E"}`,
+	expectError: `invalid schema at line 1, column 1: enum must have a symbols array`,
+}, {
+	testName:    "namespaced-reference",
+	in:          `{"type": "record", "name": "R", "namespace": "x", "fields": [{"name": "a", "type": "y.R"}]}`,
+	expectError: `invalid schema at line 1, column 84 \(/fields/0/type\): unknown type "y.R"`,
+	expectPath:  "/fields/0/type",
+}}
+
+func TestParseTypeErrorLocation(t *testing.T) {
+	c := qt.New(t)
+	for _, test := range parseTypeErrorTests {
+		c.Run(test.testName, func(c *qt.C) {
+			_, err := avro.ParseType(test.in)
+			c.Assert(err, qt.ErrorMatches, test.expectError)
+			serr, ok := err.(*avro.SchemaError)
+			c.Assert(ok, qt.Equals, true)
+			c.Assert(serr.Path, qt.Equals, test.expectPath)
+		})
+	}
+}
+
+func TestParseTypeErrorWithoutLocation(t *testing.T) {
+	c := qt.New(t)
+	_, err := avro.ParseType(`{"type": "bytes", "logicalType": "decimal", "precision": 0}`)
+	c.Assert(err, qt.ErrorMatches, `invalid schema .*: decimal precision must be a positive integer, not 0`)
+	var serr *avro.SchemaError
+	c.Assert(errors.As(err, &serr), qt.Equals, true)
+	c.Assert(serr.Line, qt.Equals, 0)
+	c.Assert(serr.Path, qt.Equals, "")
+}
+
+func TestParseTypeForwardReference(t *testing.T) {
+	c := qt.New(t)
+	// The location checks should not reject references
+	// that are resolved by the parser.
+	_, err := avro.ParseType(`{
+	"type": "record",
+	"name": "R",
+	"namespace": "x",
+	"fields": [
+		{"name": "a", "type": ["null", "R"]},
+		{"name": "b", "type": {"type": "enum", "name": "E", "symbols": ["a"]}},
+		{"name": "c", "type": "x.E"}
+	]
+}`)
+	c.Assert(err, qt.Equals, nil)
+}
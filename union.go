@@ -0,0 +1,77 @@
+package avro
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/heetch/avro/avrotypegen"
+	"github.com/heetch/avro/internal/typeinfo"
+)
+
+// The Optional types represent a union of null and another type,
+// in the same way as the nullable types in database/sql: Value holds
+// the value and Valid reports whether it's non-null.
+// They can be used wherever a pointer can be used to represent such
+// a union, and they're used by code generated with avrogo -nullable optional.
+type (
+	OptionalBool    = avrotypegen.OptionalBool
+	OptionalInt     = avrotypegen.OptionalInt
+	OptionalInt64   = avrotypegen.OptionalInt64
+	OptionalFloat32 = avrotypegen.OptionalFloat32
+	OptionalFloat64 = avrotypegen.OptionalFloat64
+	OptionalString  = avrotypegen.OptionalString
+	OptionalBytes   = avrotypegen.OptionalBytes
+	OptionalTime    = avrotypegen.OptionalTime
+)
+
+// RegisterUnion registers the interface type that iface points to
+// as representing an Avro union with a member for the type of each
+// of the given values, in order. A nil member stands for the Avro
+// null type. For example:
+//
+//	type Shape interface{}
+//
+//	func init() {
+//		avro.RegisterUnion((*Shape)(nil), nil, Circle{}, Square{})
+//	}
+//
+// A value of the interface type must hold nil or a value of one of
+// the member types. Values of the interface type can be used anywhere
+// that other Go values can, including as struct fields and in slices
+// and maps, and TypeOf, Marshal, Unmarshal and the other encoders
+// and decoders in this package all use the registered members.
+//
+// RegisterUnion panics if iface isn't a pointer to an interface type,
+// if a member doesn't implement the interface or if two members
+// have the same type.
+//
+// Note that registration affects cached types, so it should be done
+// before any values are encoded or decoded, for example in an init
+// function.
+func RegisterUnion(iface interface{}, members ...interface{}) {
+	t := reflect.TypeOf(iface)
+	if t == nil || t.Kind() != reflect.Ptr || t.Elem().Kind() != reflect.Interface {
+		panic(fmt.Errorf("union type %T is not a pointer to an interface type", iface))
+	}
+	t = t.Elem()
+	if len(members) == 0 {
+		panic(fmt.Errorf("no members for union type %s", t))
+	}
+	memberTypes := make([]reflect.Type, len(members))
+	seen := make(map[reflect.Type]bool)
+	for i, m := range members {
+		mt := reflect.TypeOf(m)
+		if seen[mt] {
+			if mt == nil {
+				panic(fmt.Errorf("duplicate null member for union type %s", t))
+			}
+			panic(fmt.Errorf("duplicate member %s for union type %s", mt, t))
+		}
+		seen[mt] = true
+		if mt != nil && !mt.Implements(t) {
+			panic(fmt.Errorf("member %s of union type %s does not implement it", mt, t))
+		}
+		memberTypes[i] = mt
+	}
+	typeinfo.RegisterUnion(t, memberTypes)
+}
@@ -0,0 +1,260 @@
+package avro_test
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro"
+)
+
+type Shape interface{}
+
+type Circle struct {
+	Radius float64
+}
+
+type Square struct {
+	Side float64
+}
+
+type Label interface{}
+
+func init() {
+	avro.RegisterUnion((*Shape)(nil), nil, Circle{}, Square{})
+	avro.RegisterUnion((*Label)(nil), "", int64(0))
+}
+
+func TestGoTypeWithOptionalTypes(t *testing.T) {
+	c := qt.New(t)
+	type R struct {
+		A avro.OptionalString
+		B avro.OptionalInt
+		C avro.OptionalInt64
+		D avro.OptionalFloat32
+		E avro.OptionalFloat64
+		F avro.OptionalBool
+		G avro.OptionalBytes
+		H avro.OptionalTime
+		I []avro.OptionalString
+	}
+	c.Assert(mustTypeOf(R{}).String(), qt.JSONEquals, json.RawMessage(`{
+		"type": "record",
+		"name": "R",
+		"fields": [{
+			"name": "A",
+			"default": null,
+			"type": ["null", "string"]
+		}, {
+			"name": "B",
+			"default": null,
+			"type": ["null", "long"]
+		}, {
+			"name": "C",
+			"default": null,
+			"type": ["null", "long"]
+		}, {
+			"name": "D",
+			"default": null,
+			"type": ["null", "float"]
+		}, {
+			"name": "E",
+			"default": null,
+			"type": ["null", "double"]
+		}, {
+			"name": "F",
+			"default": null,
+			"type": ["null", "boolean"]
+		}, {
+			"name": "G",
+			"default": null,
+			"type": ["null", "bytes"]
+		}, {
+			"name": "H",
+			"default": null,
+			"type": ["null", {"type": "long", "logicalType": "timestamp-micros"}]
+		}, {
+			"name": "I",
+			"default": [],
+			"type": {"type": "array", "items": ["null", "string"]}
+		}]
+	}`))
+	r := R{
+		A: avro.OptionalString{Value: "hello", Valid: true},
+		B: avro.OptionalInt{Value: 99, Valid: true},
+		D: avro.OptionalFloat32{Value: 1.5, Valid: true},
+		F: avro.OptionalBool{Value: false, Valid: true},
+		G: avro.OptionalBytes{Value: []byte{1, 2}, Valid: true},
+		H: avro.OptionalTime{Value: time.Date(2020, 1, 15, 18, 47, 8, 888888000, time.UTC), Valid: true},
+		I: []avro.OptionalString{{}, {Value: "x", Valid: true}},
+	}
+	data, wType, err := avro.Marshal(r)
+	c.Assert(err, qt.Equals, nil)
+	var x R
+	_, err = avro.Unmarshal(data, &x, wType)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(x, qt.DeepEquals, r)
+
+	// The encoding is the same as for pointers.
+	type P struct {
+		A *string
+		B *int
+		C *int64
+		D *float32
+		E *float64
+		F *bool
+		G *[]byte
+		H *time.Time
+		I []*string
+	}
+	var p P
+	_, err = avro.Unmarshal(data, &p, wType)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(*p.A, qt.Equals, "hello")
+	c.Assert(*p.B, qt.Equals, 99)
+	c.Assert(p.C, qt.IsNil)
+	c.Assert(*p.D, qt.Equals, float32(1.5))
+	c.Assert(p.E, qt.IsNil)
+	c.Assert(*p.F, qt.Equals, false)
+	c.Assert(*p.G, qt.DeepEquals, []byte{1, 2})
+	c.Assert(p.H.Equal(r.H.Value), qt.Equals, true)
+	c.Assert(p.I, qt.HasLen, 2)
+	c.Assert(p.I[0], qt.IsNil)
+	c.Assert(*p.I[1], qt.Equals, "x")
+}
+
+func TestGoTypeWithRegisteredUnion(t *testing.T) {
+	c := qt.New(t)
+	type R struct {
+		A Shape
+		B []Shape
+		C map[string]Label
+		D Label
+	}
+	c.Assert(mustTypeOf(R{}).String(), qt.JSONEquals, json.RawMessage(`{
+		"type": "record",
+		"name": "R",
+		"fields": [{
+			"name": "A",
+			"default": null,
+			"type": [
+				"null",
+				{
+					"type": "record",
+					"name": "Circle",
+					"fields": [{"name": "Radius", "default": 0, "type": "double"}]
+				},
+				{
+					"type": "record",
+					"name": "Square",
+					"fields": [{"name": "Side", "default": 0, "type": "double"}]
+				}
+			]
+		}, {
+			"name": "B",
+			"default": [],
+			"type": {"type": "array", "items": ["null", "Circle", "Square"]}
+		}, {
+			"name": "C",
+			"default": {},
+			"type": {"type": "map", "values": ["string", "long"]}
+		}, {
+			"name": "D",
+			"default": "",
+			"type": ["string", "long"]
+		}]
+	}`))
+	r := R{
+		A: Square{Side: 2},
+		B: []Shape{Circle{Radius: 1}, nil, Square{Side: 3}},
+		C: map[string]Label{"a": "x", "b": int64(5)},
+		D: int64(7),
+	}
+	data, wType, err := avro.Marshal(r)
+	c.Assert(err, qt.Equals, nil)
+	var x R
+	_, err = avro.Unmarshal(data, &x, wType)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(x, qt.DeepEquals, r)
+
+	// A missing field gets the first member of the union.
+	type Empty struct{}
+	data, wType, err = avro.Marshal(Empty{})
+	c.Assert(err, qt.Equals, nil)
+	x = R{}
+	_, err = avro.Unmarshal(data, &x, wType)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(x.D, qt.Equals, Label(""))
+
+	_, _, err = avro.Marshal(R{A: "x"})
+	c.Assert(err, qt.ErrorMatches, `.*unknown type for union string`)
+}
+
+func TestGoTypeWithPointerItems(t *testing.T) {
+	c := qt.New(t)
+	type R struct {
+		A []*int
+		B map[string]*string
+	}
+	one, s := 1, "s"
+	r := R{
+		A: []*int{&one, nil},
+		B: map[string]*string{"a": &s, "b": nil},
+	}
+	data, wType, err := avro.Marshal(r)
+	c.Assert(err, qt.Equals, nil)
+	var x R
+	_, err = avro.Unmarshal(data, &x, wType)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(x, qt.DeepEquals, r)
+}
+
+var registerUnionErrorTests = []struct {
+	testName    string
+	iface       interface{}
+	members     []interface{}
+	expectPanic string
+}{{
+	testName:    "not-interface",
+	iface:       new(int),
+	members:     []interface{}{0},
+	expectPanic: `union type \*int is not a pointer to an interface type`,
+}, {
+	testName:    "nil",
+	iface:       nil,
+	members:     []interface{}{0},
+	expectPanic: `union type <nil> is not a pointer to an interface type`,
+}, {
+	testName:    "no-members",
+	iface:       (*Shape)(nil),
+	expectPanic: `no members for union type avro_test.Shape`,
+}, {
+	testName:    "duplicate-null",
+	iface:       (*Shape)(nil),
+	members:     []interface{}{nil, Circle{}, nil},
+	expectPanic: `duplicate null member for union type avro_test.Shape`,
+}, {
+	testName:    "duplicate-member",
+	iface:       (*Shape)(nil),
+	members:     []interface{}{Circle{}, Circle{Radius: 1}},
+	expectPanic: `duplicate member avro_test.Circle for union type avro_test.Shape`,
+}, {
+	testName:    "not-implemented",
+	iface:       (*fmt.Stringer)(nil),
+	members:     []interface{}{0},
+	expectPanic: `member int of union type fmt.Stringer does not implement it`,
+}}
+
+func TestRegisterUnionErrors(t *testing.T) {
+	c := qt.New(t)
+	for _, test := range registerUnionErrorTests {
+		c.Run(test.testName, func(c *qt.C) {
+			c.Assert(func() {
+				avro.RegisterUnion(test.iface, test.members...)
+			}, qt.PanicMatches, test.expectPanic)
+		})
+	}
+}
@@ -0,0 +1,147 @@
+package avro_test
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro"
+	"github.com/heetch/avro/avrotypegen"
+)
+
+// reorderedUnion lists the members of its union
+// in a different order from its schema.
+type reorderedUnion struct {
+	F interface{}
+}
+
+func (reorderedUnion) AvroRecord() avrotypegen.RecordInfo {
+	return avrotypegen.RecordInfo{
+		Schema: `{"fields":[{"name":"F","type":["null","int","string"]}],"name":"R","type":"record"}`,
+		Required: []bool{
+			0: true,
+		},
+		Unions: []avrotypegen.UnionInfo{
+			0: {
+				Type: new(interface{}),
+				Union: []avrotypegen.UnionInfo{{
+					Type: new(string),
+					Name: "string",
+				}, {
+					Type: nil,
+					Name: "null",
+				}, {
+					Type: new(int),
+					Name: "int",
+				}},
+			},
+		},
+	}
+}
+
+// badUnionName has a union member name
+// that isn't in its schema.
+type badUnionName struct {
+	F interface{}
+}
+
+func (badUnionName) AvroRecord() avrotypegen.RecordInfo {
+	return avrotypegen.RecordInfo{
+		Schema: `{"fields":[{"name":"F","type":["null","int"]}],"name":"R","type":"record"}`,
+		Required: []bool{
+			0: true,
+		},
+		Unions: []avrotypegen.UnionInfo{
+			0: {
+				Type: new(interface{}),
+				Union: []avrotypegen.UnionInfo{{
+					Type: nil,
+					Name: "null",
+				}, {
+					Type: new(int),
+					Name: "long",
+				}},
+			},
+		},
+	}
+}
+
+// promotableUnion has union members that can
+// each be promoted to the other.
+type promotableUnion struct {
+	F interface{}
+}
+
+func (promotableUnion) AvroRecord() avrotypegen.RecordInfo {
+	return avrotypegen.RecordInfo{
+		Schema: `{"fields":[{"name":"F","type":["bytes","string"]}],"name":"R","type":"record"}`,
+		Required: []bool{
+			0: true,
+		},
+		Unions: []avrotypegen.UnionInfo{
+			0: {
+				Type: new(interface{}),
+				Union: []avrotypegen.UnionInfo{{
+					Type: new([]byte),
+					Name: "bytes",
+				}, {
+					Type: new(string),
+					Name: "string",
+				}},
+			},
+		},
+	}
+}
+
+var unionNameTests = []struct {
+	testName   string
+	val        reorderedUnion
+	expectData []byte
+}{{
+	testName:   "null",
+	val:        reorderedUnion{},
+	expectData: []byte{0},
+}, {
+	testName:   "int",
+	val:        reorderedUnion{F: 5},
+	expectData: []byte{2, 10},
+}, {
+	testName:   "string",
+	val:        reorderedUnion{F: "x"},
+	expectData: []byte{4, 2, 'x'},
+}}
+
+func TestUnionMembersMatchedByName(t *testing.T) {
+	c := qt.New(t)
+	for _, test := range unionNameTests {
+		c.Run(test.testName, func(c *qt.C) {
+			data, wType, err := avro.Marshal(test.val)
+			c.Assert(err, qt.Equals, nil)
+			c.Assert(data, qt.DeepEquals, test.expectData)
+
+			var x reorderedUnion
+			_, err = avro.Unmarshal(data, &x, wType)
+			c.Assert(err, qt.Equals, nil)
+			c.Assert(x, qt.DeepEquals, test.val)
+		})
+	}
+}
+
+func TestUnionMemberWithExactType(t *testing.T) {
+	c := qt.New(t)
+	for _, v := range []interface{}{[]byte("x"), "x"} {
+		data, wType, err := avro.Marshal(promotableUnion{F: v})
+		c.Assert(err, qt.Equals, nil)
+
+		var x promotableUnion
+		_, err = avro.Unmarshal(data, &x, wType)
+		c.Assert(err, qt.Equals, nil)
+		c.Assert(x.F, qt.DeepEquals, v)
+	}
+}
+
+func TestUnionMemberNameNotFound(t *testing.T) {
+	c := qt.New(t)
+	_, _, err := avro.Marshal(badUnionName{F: 1})
+	c.Assert(err, qt.ErrorMatches, `no Go type found for union member int`)
+}
@@ -0,0 +1,180 @@
+package avro
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"math/big"
+	"reflect"
+
+	"github.com/rogpeppe/gogen-avro/v7/schema"
+)
+
+// Uint64Policy determines how TypeOf represents uint64 and uint
+// values, which have no direct Avro equivalent.
+type Uint64Policy int
+
+const (
+	// Uint64Error makes TypeOf return an error for uint64 and
+	// uint types. It's the default.
+	Uint64Error Uint64Policy = iota
+
+	// Uint64Long represents values as "long". Encoding a value
+	// greater than math.MaxInt64 returns an error.
+	Uint64Long
+
+	// Uint64Fixed represents values as
+	// {"type": "fixed", "name": "go.Uint64", "size": 8},
+	// holding the value in big-endian byte order.
+	Uint64Fixed
+
+	// Uint64Decimal represents values as
+	// {"type": "bytes", "logicalType": "decimal", "precision": 20, "scale": 0},
+	// which can hold any uint64 value and is understood by
+	// other Avro implementations.
+	Uint64Decimal
+)
+
+// WithUint64Policy returns a copy of n that represents uint64
+// and uint values according to the given policy.
+func (n *Names) WithUint64Policy(p Uint64Policy) *Names {
+	return &Names{
+		renames:      n.renames,
+		uint64Policy: p,
+	}
+}
+
+// schemaForUint64 returns the schema for the 64-bit unsigned
+// integer type t according to the uint64 policy of gts.names.
+func (gts *goTypeSchema) schemaForUint64(t reflect.Type) (interface{}, error) {
+	switch gts.names.uint64Policy {
+	case Uint64Long:
+		return "long", nil
+	case Uint64Fixed:
+		return gts.defineFixed(map[string]interface{}{
+			"type": "fixed",
+			"name": "go.Uint64",
+			"size": 8,
+		}), nil
+	case Uint64Decimal:
+		return map[string]interface{}{
+			"type":        "bytes",
+			"logicalType": "decimal",
+			"precision":   20,
+			"scale":       0,
+		}, nil
+	}
+	return nil, fmt.Errorf("cannot make Avro schema for Go type %s (use Names.WithUint64Policy to choose its representation)", t)
+}
+
+// uintEncoder encodes an unsigned integer as an Avro int or long.
+func uintEncoder(e *encodeState, v reflect.Value) {
+	x := v.Uint()
+	if x > math.MaxInt64 {
+		e.error(fmt.Errorf("value %d of type %s out of range for Avro long", x, v.Type()))
+	}
+	e.writeLong(int64(x))
+}
+
+// uint64FixedEncoder encodes an unsigned integer
+// as a big-endian fixed value of size 8.
+func uint64FixedEncoder(e *encodeState, v reflect.Value) {
+	e.buf = append(e.buf, 0, 0, 0, 0, 0, 0, 0, 0)
+	binary.BigEndian.PutUint64(e.buf[len(e.buf)-8:], v.Uint())
+}
+
+// unsignedConverter returns the converter used to decode
+// values of Avro type at into the unsigned integer type t,
+// or nil if there is none.
+func unsignedConverter(at schema.AvroType, t reflect.Type) Converter {
+	if !isUintKind(t.Kind()) {
+		return nil
+	}
+	switch kindOf(at) {
+	case KindInt, KindLong:
+		return uintConverter{t}
+	case KindFixed:
+		if at.(*schema.Reference).Def.(*schema.FixedDefinition).SizeBytes() == 8 {
+			return uint64FixedConverter{t}
+		}
+	}
+	return nil
+}
+
+// uintConverter converts between unsigned integer types
+// and Avro int or long values, checking that they're in range.
+type uintConverter struct {
+	t reflect.Type
+}
+
+func (c uintConverter) GoType() reflect.Type {
+	return c.t
+}
+
+func (c uintConverter) ToAvro(x interface{}) (interface{}, error) {
+	n := reflect.ValueOf(x).Uint()
+	if n > math.MaxInt64 {
+		return nil, fmt.Errorf("value %d of type %s out of range for Avro long", n, c.t)
+	}
+	return int64(n), nil
+}
+
+func (c uintConverter) FromAvro(x interface{}) (interface{}, error) {
+	n := x.(int64)
+	v := reflect.New(c.t).Elem()
+	if n < 0 || v.OverflowUint(uint64(n)) {
+		return nil, fmt.Errorf("value %d out of range for %s", n, c.t)
+	}
+	v.SetUint(uint64(n))
+	return v.Interface(), nil
+}
+
+// uint64FixedConverter converts between unsigned integer types and
+// Avro fixed values of size 8 holding big-endian integers.
+type uint64FixedConverter struct {
+	t reflect.Type
+}
+
+func (c uint64FixedConverter) GoType() reflect.Type {
+	return c.t
+}
+
+func (c uint64FixedConverter) ToAvro(x interface{}) (interface{}, error) {
+	data := make([]byte, 8)
+	binary.BigEndian.PutUint64(data, reflect.ValueOf(x).Uint())
+	return data, nil
+}
+
+func (c uint64FixedConverter) FromAvro(x interface{}) (interface{}, error) {
+	n := binary.BigEndian.Uint64(x.([]byte))
+	v := reflect.New(c.t).Elem()
+	if v.OverflowUint(n) {
+		return nil, fmt.Errorf("value %d out of range for %s", n, c.t)
+	}
+	v.SetUint(n)
+	return v.Interface(), nil
+}
+
+// uintDecimalConverter is the DecimalConverter used for
+// unsigned integer types held in decimals.
+type uintDecimalConverter struct {
+	t reflect.Type
+}
+
+func (c uintDecimalConverter) GoType() reflect.Type {
+	return c.t
+}
+
+func (c uintDecimalConverter) ToRat(x interface{}) (*big.Rat, error) {
+	return new(big.Rat).SetInt(new(big.Int).SetUint64(reflect.ValueOf(x).Uint())), nil
+}
+
+func (c uintDecimalConverter) FromUnscaled(unscaled *big.Int, scale int) (interface{}, error) {
+	n, m := new(big.Int).QuoRem(unscaled, pow10(scale), new(big.Int))
+	v := reflect.New(c.t).Elem()
+	if m.Sign() != 0 || n.Sign() < 0 || !n.IsUint64() || v.OverflowUint(n.Uint64()) {
+		return nil, fmt.Errorf("decimal value %s out of range for %s", new(big.Rat).SetFrac(unscaled, pow10(scale)).RatString(), c.t)
+	}
+	v.SetUint(n.Uint64())
+	return v.Interface(), nil
+}
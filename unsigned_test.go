@@ -0,0 +1,114 @@
+package avro_test
+
+import (
+	"encoding/json"
+	"math"
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro"
+)
+
+type uint64Record struct {
+	N uint64
+	U uint16
+}
+
+var uint64PolicyTests = []struct {
+	testName   string
+	policy     avro.Uint64Policy
+	expectType string
+	values     []uint64
+}{{
+	testName: "long",
+	policy:   avro.Uint64Long,
+	expectType: `{
+		"type": "record",
+		"name": "uint64Record",
+		"fields": [
+			{"name": "N", "type": "long", "default": 0},
+			{"name": "U", "type": "int", "default": 0}
+		]
+	}`,
+	values: []uint64{0, 1 << 40, math.MaxInt64},
+}, {
+	testName: "fixed",
+	policy:   avro.Uint64Fixed,
+	expectType: `{
+		"type": "record",
+		"name": "uint64Record",
+		"fields": [
+			{"name": "N", "type": {"type": "fixed", "name": "go.Uint64", "size": 8}, "default": "\u0000\u0000\u0000\u0000\u0000\u0000\u0000\u0000"},
+			{"name": "U", "type": "int", "default": 0}
+		]
+	}`,
+	values: []uint64{0, 1 << 40, math.MaxUint64},
+}, {
+	testName: "decimal",
+	policy:   avro.Uint64Decimal,
+	expectType: `{
+		"type": "record",
+		"name": "uint64Record",
+		"fields": [
+			{"name": "N", "type": {"type": "bytes", "logicalType": "decimal", "precision": 20, "scale": 0}, "default": ""},
+			{"name": "U", "type": "int", "default": 0}
+		]
+	}`,
+	values: []uint64{0, 1 << 40, math.MaxUint64},
+}}
+
+func TestUint64Policy(t *testing.T) {
+	c := qt.New(t)
+	for _, test := range uint64PolicyTests {
+		c.Run(test.testName, func(c *qt.C) {
+			names := new(avro.Names).WithUint64Policy(test.policy)
+			at, err := names.TypeOf(uint64Record{})
+			c.Assert(err, qt.Equals, nil)
+			c.Assert(at.String(), qt.JSONEquals, json.RawMessage(test.expectType))
+			for _, n := range test.values {
+				x := uint64Record{
+					N: n,
+					U: math.MaxUint16,
+				}
+				data, wType, err := names.Marshal(x)
+				c.Assert(err, qt.Equals, nil)
+				var x1 uint64Record
+				_, err = names.Unmarshal(data, &x1, wType)
+				c.Assert(err, qt.Equals, nil)
+				c.Assert(x1, qt.Equals, x)
+			}
+		})
+	}
+}
+
+func TestUint64PolicyErrors(t *testing.T) {
+	c := qt.New(t)
+	_, err := avro.TypeOf(uint64Record{})
+	c.Assert(err, qt.ErrorMatches, `cannot make Avro schema for Go type uint64 \(use Names.WithUint64Policy to choose its representation\)`)
+
+	names := new(avro.Names).WithUint64Policy(avro.Uint64Long)
+	_, _, err = names.Marshal(uint64Record{
+		N: math.MaxUint64,
+	})
+	c.Assert(err, qt.ErrorMatches, `value 18446744073709551615 of type uint64 out of range for Avro long`)
+}
+
+func TestUnsignedOutOfRange(t *testing.T) {
+	c := qt.New(t)
+	type R struct {
+		N int
+	}
+	data, wType, err := avro.Marshal(R{
+		N: -1,
+	})
+	c.Assert(err, qt.Equals, nil)
+	{
+		type R struct {
+			N uint32
+		}
+		var x R
+		_, err = avro.Unmarshal(data, &x, wType)
+		c.Assert(err, qt.ErrorMatches, `value -1 out of range for uint32`)
+	}
+}
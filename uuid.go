@@ -0,0 +1,95 @@
+package avro
+
+import (
+	"encoding/hex"
+	"fmt"
+	"reflect"
+)
+
+var uuidArrayType = reflect.TypeOf([16]byte{})
+
+// uuidConverter converts between the uuid logical type and Go values
+// of type t, which is either a string type or a [16]byte type such as
+// github.com/google/uuid.UUID. Strings are checked to be in the
+// canonical textual form, such as "f81d4fae-7dec-11d0-a765-00a0c91e6bf6".
+type uuidConverter struct {
+	t reflect.Type
+}
+
+// newUUIDConverter returns the converter to use for values of
+// Go type t encoded with the uuid logical type, or nil if t
+// can't represent a UUID.
+func newUUIDConverter(t reflect.Type) Converter {
+	switch {
+	case t.Kind() == reflect.String:
+	case t.Kind() == reflect.Array && t.Len() == 16 && t.Elem() == byteType:
+	default:
+		return nil
+	}
+	return uuidConverter{t}
+}
+
+func (c uuidConverter) GoType() reflect.Type {
+	return c.t
+}
+
+func (c uuidConverter) ToAvro(x interface{}) (interface{}, error) {
+	v := reflect.ValueOf(x)
+	if c.t.Kind() == reflect.String {
+		s := v.String()
+		if _, err := parseUUID(s); err != nil {
+			return nil, err
+		}
+		return s, nil
+	}
+	return formatUUID(v.Convert(uuidArrayType).Interface().([16]byte)), nil
+}
+
+func (c uuidConverter) FromAvro(x interface{}) (interface{}, error) {
+	s := x.(string)
+	if c.t.Kind() == reflect.String {
+		return reflect.ValueOf(s).Convert(c.t).Interface(), nil
+	}
+	u, err := parseUUID(s)
+	if err != nil {
+		return nil, err
+	}
+	return reflect.ValueOf(u).Convert(c.t).Interface(), nil
+}
+
+// uuidHyphens holds the positions of the hyphens
+// in the canonical textual form of a UUID.
+var uuidHyphens = [...]int{8, 13, 18, 23}
+
+// parseUUID parses a UUID in its canonical textual form.
+func parseUUID(s string) ([16]byte, error) {
+	var u [16]byte
+	if len(s) != 36 {
+		return u, fmt.Errorf("invalid UUID %q", s)
+	}
+	for _, i := range uuidHyphens {
+		if s[i] != '-' {
+			return u, fmt.Errorf("invalid UUID %q", s)
+		}
+	}
+	digits := s[0:8] + s[9:13] + s[14:18] + s[19:23] + s[24:]
+	if _, err := hex.Decode(u[:], []byte(digits)); err != nil {
+		return u, fmt.Errorf("invalid UUID %q", s)
+	}
+	return u, nil
+}
+
+// formatUUID returns the canonical textual form of u.
+func formatUUID(u [16]byte) string {
+	var buf [36]byte
+	hex.Encode(buf[0:8], u[0:4])
+	buf[8] = '-'
+	hex.Encode(buf[9:13], u[4:6])
+	buf[13] = '-'
+	hex.Encode(buf[14:18], u[6:8])
+	buf[18] = '-'
+	hex.Encode(buf[19:23], u[8:10])
+	buf[23] = '-'
+	hex.Encode(buf[24:], u[10:])
+	return string(buf[:])
+}
@@ -0,0 +1,118 @@
+package avro_test
+
+import (
+	"testing"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro"
+	"github.com/heetch/avro/avrotypegen"
+)
+
+// testUUID mimics the UUID types defined by
+// packages such as github.com/google/uuid.
+type testUUID [16]byte
+
+// uuidRecord holds UUIDs represented by
+// both strings and byte arrays.
+type uuidRecord struct {
+	S string
+	A [16]byte
+	U *testUUID
+}
+
+func (uuidRecord) AvroRecord() avrotypegen.RecordInfo {
+	return avrotypegen.RecordInfo{
+		Schema: `{"fields":[{"name":"S","type":{"logicalType":"uuid","type":"string"}},{"name":"A","type":{"logicalType":"uuid","type":"string"}},{"default":null,"name":"U","type":["null",{"logicalType":"uuid","type":"string"}]}],"name":"R","type":"record"}`,
+		Required: []bool{
+			0: true,
+			1: true,
+		},
+	}
+}
+
+// plainUUIDRecord has the same schema as uuidRecord
+// but without the logical types.
+type plainUUIDRecord struct {
+	S string
+	A string
+	U *string
+}
+
+func (plainUUIDRecord) AvroRecord() avrotypegen.RecordInfo {
+	return avrotypegen.RecordInfo{
+		Schema: `{"fields":[{"name":"S","type":"string"},{"name":"A","type":"string"},{"default":null,"name":"U","type":["null","string"]}],"name":"R","type":"record"}`,
+		Required: []bool{
+			0: true,
+			1: true,
+		},
+	}
+}
+
+var testUUIDValue = testUUID{0xf8, 0x1d, 0x4f, 0xae, 0x7d, 0xec, 0x11, 0xd0, 0xa7, 0x65, 0x00, 0xa0, 0xc9, 0x1e, 0x6b, 0xf6}
+
+func TestUUID(t *testing.T) {
+	c := qt.New(t)
+	x := uuidRecord{
+		S: "01234567-89ab-cdef-0123-456789abcdef",
+		A: testUUIDValue,
+		U: &testUUIDValue,
+	}
+	data, wType, err := avro.Marshal(x)
+	c.Assert(err, qt.Equals, nil)
+
+	// Check that the byte arrays are encoded in the canonical form.
+	var y plainUUIDRecord
+	_, err = avro.Unmarshal(data, &y, wType)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(y.S, qt.Equals, "01234567-89ab-cdef-0123-456789abcdef")
+	c.Assert(y.A, qt.Equals, "f81d4fae-7dec-11d0-a765-00a0c91e6bf6")
+	c.Assert(*y.U, qt.Equals, "f81d4fae-7dec-11d0-a765-00a0c91e6bf6")
+
+	var x1 uuidRecord
+	_, err = avro.Unmarshal(data, &x1, wType)
+	c.Assert(err, qt.Equals, nil)
+	c.Assert(x1, qt.DeepEquals, x)
+}
+
+var uuidEncodeErrorTests = []struct {
+	testName string
+	s        string
+}{{
+	testName: "empty",
+	s:        "",
+}, {
+	testName: "no-hyphens",
+	s:        "f81d4fae7dec11d0a76500a0c91e6bf6",
+}, {
+	testName: "misplaced-hyphen",
+	s:        "f81d4fa-e7dec-11d0-a765-00a0c91e6bf6",
+}, {
+	testName: "bad-hex",
+	s:        "f81d4fae-7dec-11d0-a765-00a0c91e6bfg",
+}, {
+	testName: "braces",
+	s:        "{f81d4fae-7dec-11d0-a765-00a0c91e6bf6}",
+}}
+
+func TestUUIDEncodeError(t *testing.T) {
+	c := qt.New(t)
+	for _, test := range uuidEncodeErrorTests {
+		c.Run(test.testName, func(c *qt.C) {
+			_, _, err := avro.Marshal(uuidRecord{S: test.s})
+			c.Assert(err, qt.ErrorMatches, `invalid uuid value at S: invalid UUID ".*"`)
+		})
+	}
+}
+
+func TestUUIDDecodeError(t *testing.T) {
+	c := qt.New(t)
+	data, wType, err := avro.Marshal(plainUUIDRecord{
+		S: "f81d4fae-7dec-11d0-a765-00a0c91e6bf6",
+		A: "not-a-uuid",
+	})
+	c.Assert(err, qt.Equals, nil)
+	var x uuidRecord
+	_, err = avro.Unmarshal(data, &x, wType)
+	c.Assert(err, qt.ErrorMatches, `invalid UUID "not-a-uuid"`)
+}
@@ -0,0 +1,68 @@
+package avro
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// LogicalValueError is returned by Marshal when a value can't be
+// encoded because it's not valid for its logical type, for example
+// a decimal that doesn't fit in its precision or a malformed UUID.
+type LogicalValueError struct {
+	// Path holds the location of the value within the value being
+	// encoded. Record fields are separated by dots, array items
+	// are written as "[N]" and map values as "{key}". It's empty
+	// when the value being encoded is itself invalid.
+	Path string
+
+	// LogicalType holds the name of the logical type.
+	LogicalType string
+
+	// Err holds the underlying error.
+	Err error
+}
+
+// Error implements the error interface.
+func (e *LogicalValueError) Error() string {
+	if e.Path == "" {
+		return fmt.Sprintf("invalid %s value: %v", e.LogicalType, e.Err)
+	}
+	return fmt.Sprintf("invalid %s value at %s: %v", e.LogicalType, e.Path, e.Err)
+}
+
+// Unwrap returns the underlying error.
+func (e *LogicalValueError) Unwrap() error {
+	return e.Err
+}
+
+// addErrorPath is deferred by the encoders of records, arrays and
+// maps. If a LogicalValueError is being raised, it prepends the path
+// element returned by elem to the error's path. Encoders are
+// stateless so that they can be cached, which is why the path is
+// built while the panic unwinds rather than as values are encoded.
+func addErrorPath(elem func() string) {
+	r := recover()
+	if r == nil {
+		return
+	}
+	if err, ok := r.(*encodeError); ok {
+		if verr, ok := err.err.(*LogicalValueError); ok {
+			verr.Path = prependPath(elem(), verr.Path)
+		}
+	}
+	panic(r)
+}
+
+// prependPath returns the path elem followed by path.
+func prependPath(elem, path string) string {
+	if path == "" || strings.HasPrefix(path, "[") || strings.HasPrefix(path, "{") {
+		return elem + path
+	}
+	return elem + "." + path
+}
+
+// indexPathElem returns the path element for item i of an array.
+func indexPathElem(i int) string {
+	return "[" + strconv.Itoa(i) + "]"
+}
@@ -0,0 +1,130 @@
+package avro_test
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	qt "github.com/frankban/quicktest"
+
+	"github.com/heetch/avro"
+	"github.com/heetch/avro/avrotypegen"
+)
+
+// nestedUUIDs holds UUIDs inside an array
+// of records inside a map.
+type nestedUUIDs struct {
+	Items map[string][]nestedUUIDItem
+}
+
+type nestedUUIDItem struct {
+	ID string
+}
+
+func (nestedUUIDs) AvroRecord() avrotypegen.RecordInfo {
+	return avrotypegen.RecordInfo{
+		Schema: `{"fields":[{"name":"Items","type":{"type":"map","values":{"items":{"fields":[{"name":"ID","type":{"logicalType":"uuid","type":"string"}}],"name":"Item","type":"record"},"type":"array"}}}],"name":"R","type":"record"}`,
+		Required: []bool{
+			0: true,
+		},
+	}
+}
+
+func TestLogicalValueErrorPath(t *testing.T) {
+	c := qt.New(t)
+	_, _, err := avro.Marshal(nestedUUIDs{
+		Items: map[string][]nestedUUIDItem{
+			"k": {
+				{ID: "f81d4fae-7dec-11d0-a765-00a0c91e6bf6"},
+				{ID: "bad"},
+			},
+		},
+	})
+	c.Assert(err, qt.ErrorMatches, `invalid uuid value at Items{k}\[1\].ID: invalid UUID "bad"`)
+	var verr *avro.LogicalValueError
+	c.Assert(errors.As(err, &verr), qt.Equals, true)
+	c.Assert(verr.Path, qt.Equals, "Items{k}[1].ID")
+	c.Assert(verr.LogicalType, qt.Equals, "uuid")
+}
+
+func TestTimestampOutOfRange(t *testing.T) {
+	c := qt.New(t)
+	type R struct {
+		T time.Time
+	}
+	_, _, err := avro.Marshal(R{
+		T: time.Date(300000000, 1, 1, 0, 0, 0, 0, time.UTC),
+	})
+	c.Assert(err, qt.ErrorMatches, `invalid timestamp-micros value at T: time .* out of range`)
+	var verr *avro.LogicalValueError
+	c.Assert(errors.As(err, &verr), qt.Equals, true)
+	c.Assert(verr.LogicalType, qt.Equals, "timestamp-micros")
+}
+
+// dateRecord holds a time.Time with the date logical type.
+type dateRecord struct {
+	D time.Time
+}
+
+func (dateRecord) AvroRecord() avrotypegen.RecordInfo {
+	return avrotypegen.RecordInfo{
+		Schema: `{"fields":[{"name":"D","type":{"logicalType":"date","type":"int"}}],"name":"R","type":"record"}`,
+		Required: []bool{
+			0: true,
+		},
+	}
+}
+
+var dateTests = []struct {
+	testName   string
+	t          time.Time
+	expectData []byte
+	expectTime time.Time
+}{{
+	testName:   "epoch",
+	t:          time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC),
+	expectData: []byte{0},
+	expectTime: time.Date(1970, 1, 1, 0, 0, 0, 0, time.UTC),
+}, {
+	testName:   "time-of-day-dropped",
+	t:          time.Date(1970, 1, 2, 23, 59, 0, 0, time.UTC),
+	expectData: []byte{2},
+	expectTime: time.Date(1970, 1, 2, 0, 0, 0, 0, time.UTC),
+}, {
+	testName:   "before-epoch",
+	t:          time.Date(1969, 12, 31, 12, 0, 0, 0, time.UTC),
+	expectData: []byte{1},
+	expectTime: time.Date(1969, 12, 31, 0, 0, 0, 0, time.UTC),
+}, {
+	testName:   "own-location",
+	t:          time.Date(1970, 1, 2, 1, 0, 0, 0, time.FixedZone("X", 5*60*60)),
+	expectData: []byte{2},
+	expectTime: time.Date(1970, 1, 2, 0, 0, 0, 0, time.UTC),
+}}
+
+func TestDate(t *testing.T) {
+	c := qt.New(t)
+	for _, test := range dateTests {
+		c.Run(test.testName, func(c *qt.C) {
+			data, wType, err := avro.Marshal(dateRecord{D: test.t})
+			c.Assert(err, qt.Equals, nil)
+			c.Assert(data, qt.DeepEquals, test.expectData)
+
+			var x dateRecord
+			_, err = avro.Unmarshal(data, &x, wType)
+			c.Assert(err, qt.Equals, nil)
+			c.Assert(x.D, qt.Equals, test.expectTime)
+		})
+	}
+}
+
+func TestDateOutOfRange(t *testing.T) {
+	c := qt.New(t)
+	_, _, err := avro.Marshal(dateRecord{
+		D: time.Date(6000000, 1, 1, 0, 0, 0, 0, time.UTC),
+	})
+	c.Assert(err, qt.ErrorMatches, `invalid date value at D: date 6000000-01-01 out of range`)
+	var verr *avro.LogicalValueError
+	c.Assert(errors.As(err, &verr), qt.Equals, true)
+	c.Assert(verr.Path, qt.Equals, "D")
+}